@@ -0,0 +1,16 @@
+// Package controlv1 is the gRPC control API that `go-cli serve --grpc`
+// exposes: trigger runs, query their status, stream task logs, and read
+// the effective config. Go services import it for a typed client:
+//
+//	conn, err := grpc.NewClient("unix:///run/user/1000/go-cli.sock",
+//		grpc.WithTransportCredentials(insecure.NewCredentials()))
+//	...
+//	client := controlv1.NewControlClient(conn)
+//	run, err := client.TriggerRun(ctx, &controlv1.TriggerRunRequest{Task: "deploy"})
+//
+// When serve.token is set, send it as "authorization: Bearer <token>"
+// metadata. control.pb.go and control_grpc.pb.go are generated from
+// control.proto; regenerate them with `go generate` after editing it.
+package controlv1
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative control.proto
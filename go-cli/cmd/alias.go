@@ -0,0 +1,116 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+)
+
+func newAliasCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "alias",
+		Short:   "Inspect command aliases from the [aliases] config table.",
+		Example: "  go-cli alias list\n  go-cli config get aliases",
+		Long:    "Aliases are defined in the [aliases] config table, e.g. deploy = \"run deploy --profile prod --json\". Running go-cli deploy --dry-run then runs go-cli run deploy --profile prod --json --dry-run. An alias may start with another alias; loops are rejected. Built-in commands take precedence over aliases of the same name.",
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List configured aliases and the command lines they expand to.",
+		Example: "  go-cli alias list\n  go-cli alias list --json",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleAliasList(ctx, commandNames(cmd.Root()))
+		},
+	})
+	return cmd
+}
+
+// commandNames lists the names and aliases of root's subcommands.
+func commandNames(root *cobra.Command) []string {
+	names := []string{"help"}
+	for _, sub := range root.Commands() {
+		names = append(names, sub.Name())
+		names = append(names, sub.Aliases...)
+	}
+	return names
+}
+
+// expandAliases expands a configured alias in the command position of
+// args, the first word that is not a global flag or its value. Built-in
+// commands are never replaced.
+func expandAliases(root *cobra.Command, aliases map[string]string, args []string) ([]string, error) {
+	if len(aliases) == 0 {
+		return args, nil
+	}
+	i := commandIndex(root, args)
+	if i < 0 {
+		return args, nil
+	}
+	if isCommandName(root, args[i]) {
+		return args, nil
+	}
+	return app.ExpandAlias(aliases, args, i)
+}
+
+// isCommandName reports whether name is a built-in command of root.
+func isCommandName(root *cobra.Command, name string) bool {
+	return slices.Contains(commandNames(root), name)
+}
+
+// commandIndex returns the index of the first word in args that is not a
+// persistent flag or a flag value, or -1 when there is none.
+func commandIndex(root *cobra.Command, args []string) int {
+	flags := root.PersistentFlags()
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return -1
+		case strings.HasPrefix(arg, "--"):
+			name, _, hasValue := strings.Cut(arg[2:], "=")
+			if f := flags.Lookup(name); f != nil && !hasValue && f.NoOptDefVal == "" {
+				i++
+			}
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			// In a group like -qv or -Cdir, the first shorthand that takes
+			// a value takes the rest of the group, or the next word when
+			// the group ends there.
+			for j := 1; j < len(arg); j++ {
+				if f := flags.ShorthandLookup(arg[j : j+1]); f != nil && f.NoOptDefVal == "" {
+					if j == len(arg)-1 {
+						i++
+					}
+					break
+				}
+			}
+		default:
+			return i
+		}
+	}
+	return -1
+}
+
+// configOverride returns the value of a --config flag in args, which must
+// be known before the command line is parsed to find the aliases.
+func configOverride(args []string) string {
+	override := ""
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if value, ok := strings.CutPrefix(arg, "--config="); ok {
+			override = value
+		} else if arg == "--config" && i+1 < len(args) {
+			override = args[i+1]
+		}
+	}
+	return override
+}
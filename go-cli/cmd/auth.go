@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+)
+
+func newAuthCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "auth",
+		Short:   "Log in to the OAuth2 provider configured under [auth].",
+		Example: "  go-cli auth login\n  go-cli auth status\n  go-cli auth logout",
+	}
+
+	cmd.AddCommand(newAuthLoginCommand())
+	cmd.AddCommand(newAuthStatusCommand())
+	cmd.AddCommand(copying(newAuthTokenCommand()))
+	cmd.AddCommand(newAuthLogoutCommand())
+
+	return cmd
+}
+
+func newAuthLoginCommand() *cobra.Command {
+	var opts app.AuthLoginOptions
+
+	cmd := &cobra.Command{
+		Use:     "login",
+		Short:   "Log in with the OAuth2 device authorization flow.",
+		Example: "  go-cli auth login\n  go-cli auth login --insecure-storage",
+		Long:    "Requests a device code from auth.device_url, prints it with the URL to enter it at, and waits until the login is approved in a browser. The token is stored in the OS keyring (Secret Service, macOS Keychain, or Windows Credential Manager), or with --insecure-storage in a file in the state directory readable only by you.",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleAuthLogin(ctx, opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.InsecureStorage, "insecure-storage", false, "Store the token in a plain file instead of the OS keyring, e.g. on machines without one.")
+
+	return cmd
+}
+
+func newAuthStatusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "status",
+		Short:   "Show whether a token is stored, where, and when it expires.",
+		Example: "  go-cli auth status\n  go-cli auth status --json",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleAuthStatus(ctx)
+		},
+	}
+}
+
+func newAuthTokenCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "token",
+		Short:   "Print a valid access token, refreshing it if it expired.",
+		Example: "  curl -H \"Authorization: Bearer $(go-cli auth token)\" https://api.example.com/v1/me",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleAuthToken(ctx)
+		},
+	}
+}
+
+func newAuthLogoutCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "logout",
+		Short:   "Remove the stored token.",
+		Example: "  go-cli auth logout",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleAuthLogout(ctx)
+		},
+	}
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/buildinfo"
+)
+
+func newBugReportCommand() *cobra.Command {
+	opts := app.BugReportOptions{}
+	cmd := &cobra.Command{
+		Use:   "bug-report",
+		Short: "Collect diagnostics into an archive to attach to an issue.",
+		Long:  "Gathers version and platform details, the effective config with secrets redacted, paths, set GO_CLI_* variables, health checks, recent run history, and the end of the task, log-file, and daemon logs. They are written to a tar.gz archive, or printed as Markdown with --markdown for pasting into an issue. The summary lists every file collected; review them before sharing.",
+		Example: "  go-cli bug-report\n" +
+			"  go-cli bug-report --output report.tar.gz\n" +
+			"  go-cli bug-report --markdown | pbcopy",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			if opts.Markdown && (opts.Output != "" || ctx.Common.JSON || ctx.Common.YAML || ctx.Common.Porcelain) {
+				return app.UsageError(fmt.Errorf("--markdown cannot be combined with --output, --json, --yaml, or --porcelain"))
+			}
+			if opts.Lines < 0 || opts.History < 0 {
+				return app.UsageError(fmt.Errorf("--lines and --history must not be negative"))
+			}
+			opts.Build = buildinfo.Get()
+			return app.HandleBugReport(ctx, opts)
+		},
+	}
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", "", "Archive path (default go-cli-bug-report-<timestamp>.tar.gz).")
+	cmd.Flags().BoolVar(&opts.Markdown, "markdown", false, "Print a Markdown report to stdout instead of writing an archive.")
+	cmd.Flags().IntVar(&opts.Lines, "lines", 200, "Lines to include from the end of each log (0 for all).")
+	cmd.Flags().IntVar(&opts.History, "history", 20, "Recent runs to include from the history (0 for all).")
+	return cmd
+}
@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+)
+
+func newCacheCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "cache",
+		Short:   "Inspect and clean the cache directory.",
+		Example: "  go-cli cache size\n  go-cli cache clean --older-than 7d",
+	}
+
+	cmd.AddCommand(copying(newCachePathCommand()))
+	cmd.AddCommand(newCacheSizeCommand())
+	cmd.AddCommand(locking(newCacheCleanCommand()))
+
+	return cmd
+}
+
+func newCachePathCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "path",
+		Short:   "Print the cache directory.",
+		Example: "  go-cli cache path\n  du -sh \"$(go-cli cache path)\"",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleCachePath(ctx)
+		},
+	}
+}
+
+func newCacheSizeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "size",
+		Short:   "Show how many files the cache holds and their total size.",
+		Example: "  go-cli cache size\n  go-cli cache size --json",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleCacheSize(ctx)
+		},
+	}
+}
+
+func newCacheCleanCommand() *cobra.Command {
+	var olderThan app.Duration
+
+	cmd := &cobra.Command{
+		Use:     "clean",
+		Short:   "Remove cached files.",
+		Long:    "Remove cached files: everything, or with --older-than only files last written longer ago than that. Expired entries are always removed.",
+		Example: "  go-cli cache clean\n  go-cli cache clean --older-than 7d",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleCacheClean(ctx, app.CacheCleanOptions{OlderThan: olderThan.Std()})
+		},
+	}
+
+	cmd.Flags().Var(&olderThan, "older-than", "Only remove files last written longer ago than this (e.g. 12h, 7d).")
+
+	return cmd
+}
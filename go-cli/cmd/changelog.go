@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/buildinfo"
+)
+
+func newChangelogCommand() *cobra.Command {
+	var since string
+
+	cmd := &cobra.Command{
+		Use:   "changelog",
+		Short: "Show the release notes built into this binary.",
+		Long: "Prints the sections of CHANGELOG.md embedded at build time, newest first, up to the running version. " +
+			"With --since only releases newer than the given version are shown. Development builds include the Unreleased section.",
+		Example: "  go-cli changelog\n  go-cli changelog --since v1.2.0\n  go-cli changelog --porcelain",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleChangelog(ctx, buildinfo.Get(), since)
+		},
+	}
+
+	cmd.Flags().StringVar(&since, "since", "", "Only show releases newer than this version, e.g. v1.2.0.")
+
+	return cmd
+}
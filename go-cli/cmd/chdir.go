@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+)
+
+// chdirApplied counts the -C/--chdir flags enterDirs applied; see
+// checkChdir.
+var chdirApplied int
+
+// chdirDirs returns the directories -C/--chdir names among the global
+// flags before the command name in args, in order. It reads the raw
+// arguments because the working directory has to change before aliases,
+// plugins, and the config are looked up, all of which happens before cobra
+// parses the flags. Arguments from the command name on belong to the
+// command or plugin and are left alone.
+func chdirDirs(root *cobra.Command, args []string) []string {
+	if i := commandIndex(root, args); i >= 0 {
+		args = args[:i]
+	}
+	var dirs []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--":
+			return dirs
+		case arg == "-C" || arg == "--chdir":
+			if i+1 < len(args) {
+				i++
+				dirs = append(dirs, args[i])
+			}
+		case strings.HasPrefix(arg, "--chdir="):
+			dirs = append(dirs, strings.TrimPrefix(arg, "--chdir="))
+		case strings.HasPrefix(arg, "-C") && !strings.HasPrefix(arg, "--"):
+			dirs = append(dirs, strings.TrimPrefix(arg[2:], "="))
+		}
+	}
+	return dirs
+}
+
+// enterDirs changes into each -C/--chdir directory in args in turn, each
+// relative to the one before, as git -C does.
+func enterDirs(root *cobra.Command, args []string) error {
+	dirs := chdirDirs(root, args)
+	chdirApplied = len(dirs)
+	for _, dir := range dirs {
+		if err := os.Chdir(dir); err != nil {
+			return app.UsageError(fmt.Errorf("--chdir: %w", err))
+		}
+	}
+	if len(dirs) == 0 {
+		return nil
+	}
+	// Child processes inherit $PWD, which would otherwise still name the
+	// directory go-cli was started in.
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	return os.Setenv("PWD", wd)
+}
+
+// checkChdir rejects the -C/--chdir flags cobra parsed but enterDirs never
+// applied: those after the command name, and those an alias expanded to.
+// Both would otherwise be ignored without a word.
+func checkChdir() error {
+	if len(chdirFlag) > chdirApplied {
+		return app.UsageError(errors.New("--chdir must come before the command name and cannot be set by an alias"))
+	}
+	return nil
+}
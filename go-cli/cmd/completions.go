@@ -10,32 +10,34 @@ import (
 
 func newCompletionsCommand() *cobra.Command {
 	return &cobra.Command{
-		Use:   "completions [shell]",
-		Short: "Generate shell completion scripts.",
-		Long:  "Generate shell completion scripts for supported shells (bash, zsh, fish, powershell).",
-		Args:  cobra.ExactArgs(1),
+		Use:     "completions [shell]",
+		Short:   "Generate shell completion scripts.",
+		Long:    "Generate shell completion scripts for supported shells (bash, zsh, fish, powershell).",
+		Example: "  go-cli completions bash > /etc/bash_completion.d/go-cli\n  go-cli completions fish > ~/.config/fish/completions/go-cli.fish",
+		Args:    cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			shell := args[0]
-			root := cmd.Root()
-			var err error
-
-			switch shell {
-			case "bash":
-				err = root.GenBashCompletion(os.Stdout)
-			case "zsh":
-				err = root.GenZshCompletion(os.Stdout)
-			case "fish":
-				err = root.GenFishCompletion(os.Stdout, true)
-			case "powershell":
-				err = root.GenPowerShellCompletionWithDesc(os.Stdout)
-			default:
-				return fmt.Errorf("unsupported shell %q", shell)
-			}
-
-			if err != nil && err != io.EOF {
-				return err
-			}
-			return nil
+			return writeCompletion(cmd.Root(), args[0], os.Stdout)
 		},
 	}
 }
+
+// writeCompletion writes the completion script of root for shell to w.
+func writeCompletion(root *cobra.Command, shell string, w io.Writer) error {
+	var err error
+	switch shell {
+	case "bash":
+		err = root.GenBashCompletion(w)
+	case "zsh":
+		err = root.GenZshCompletion(w)
+	case "fish":
+		err = root.GenFishCompletion(w, true)
+	case "powershell":
+		err = root.GenPowerShellCompletionWithDesc(w)
+	default:
+		return fmt.Errorf("unsupported shell %q", shell)
+	}
+	if err != nil && err != io.EOF {
+		return err
+	}
+	return nil
+}
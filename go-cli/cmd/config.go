@@ -1,6 +1,8 @@
 package cmd
 
 import (
+	"strings"
+
 	"github.com/spf13/cobra"
 
 	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
@@ -8,23 +10,27 @@ import (
 
 func newConfigCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "config",
-		Short: "Inspect and manage configuration.",
+		Use:     "config",
+		Short:   "Inspect and manage configuration.",
+		Example: "  go-cli config show\n  go-cli config get runtime.timeout\n  go-cli config diff",
 	}
 
 	cmd.AddCommand(newConfigShowCommand())
-	cmd.AddCommand(newConfigPathCommand())
+	cmd.AddCommand(newConfigGetCommand())
+	cmd.AddCommand(copying(newConfigPathCommand()))
 	cmd.AddCommand(newConfigPathsCommand())
 	cmd.AddCommand(newConfigSchemaCommand())
-	cmd.AddCommand(newConfigResetCommand())
+	cmd.AddCommand(locking(newConfigResetCommand()))
+	cmd.AddCommand(newConfigDiffCommand())
 
 	return cmd
 }
 
 func newConfigShowCommand() *cobra.Command {
 	return &cobra.Command{
-		Use:   "show",
-		Short: "Output the effective configuration.",
+		Use:     "show",
+		Short:   "Output the effective configuration.",
+		Example: "  go-cli config show\n  go-cli config show --json",
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			ctx, err := Context(cmd)
 			if err != nil {
@@ -35,10 +41,28 @@ func newConfigShowCommand() *cobra.Command {
 	}
 }
 
+func newConfigGetCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:               "get KEY",
+		Short:             "Print the effective value of a config key, or of every key in a section.",
+		Example:           "  go-cli config get runtime.timeout\n  go-cli config get runtime.retry\n  go-cli config get watch.ignore --json",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeConfigPaths,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleConfigGet(ctx, args[0])
+		},
+	}
+}
+
 func newConfigPathCommand() *cobra.Command {
 	return &cobra.Command{
-		Use:   "path",
-		Short: "Print the resolved config file path.",
+		Use:     "path",
+		Short:   "Print the resolved config file path.",
+		Example: "  go-cli config path\n  $EDITOR \"$(go-cli config path)\"",
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			ctx, err := Context(cmd)
 			if err != nil {
@@ -51,8 +75,9 @@ func newConfigPathCommand() *cobra.Command {
 
 func newConfigPathsCommand() *cobra.Command {
 	return &cobra.Command{
-		Use:   "paths",
-		Short: "Print all resolved paths (config, data, state, cache).",
+		Use:     "paths",
+		Short:   "Print all resolved paths (config, data, state, cache).",
+		Example: "  go-cli config paths\n  go-cli config paths --json",
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			ctx, err := Context(cmd)
 			if err != nil {
@@ -65,8 +90,9 @@ func newConfigPathsCommand() *cobra.Command {
 
 func newConfigSchemaCommand() *cobra.Command {
 	return &cobra.Command{
-		Use:   "schema",
-		Short: "Print the JSON schema for the config file.",
+		Use:     "schema",
+		Short:   "Print the JSON schema for the config file.",
+		Example: "  go-cli config schema > config.schema.json",
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			ctx, err := Context(cmd)
 			if err != nil {
@@ -79,8 +105,9 @@ func newConfigSchemaCommand() *cobra.Command {
 
 func newConfigResetCommand() *cobra.Command {
 	return &cobra.Command{
-		Use:   "reset",
-		Short: "Regenerate the default configuration file.",
+		Use:     "reset",
+		Short:   "Regenerate the default configuration file.",
+		Example: "  go-cli config reset --dry-run\n  go-cli config reset --yes",
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			ctx, err := Context(cmd)
 			if err != nil {
@@ -90,3 +117,53 @@ func newConfigResetCommand() *cobra.Command {
 		},
 	}
 }
+
+func newConfigDiffCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "diff",
+		Short:   "Show how the config file differs from the defaults.",
+		Example: "  go-cli config diff",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleConfigDiff(ctx)
+		},
+	}
+}
+
+// completeConfigKeys completes --set with the config keys, described from
+// the config schema.
+func completeConfigKeys(_ *cobra.Command, _ []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if strings.Contains(toComplete, "=") {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	descriptions := map[string]string{}
+	for _, v := range app.EnvVars() {
+		descriptions[v.Key] = v.Description
+	}
+	var keys []string
+	for _, key := range app.ConfigKeys() {
+		keys = append(keys, cobra.CompletionWithDesc(key+"=", descriptions[key]))
+	}
+	return keys, cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeConfigPaths completes the key paths of the effective config,
+// including entries of tables such as tasks and profiles.
+func completeConfigPaths(cmd *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	ctx, err := Context(cmd)
+	if err != nil || len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	descriptions := map[string]string{}
+	for _, v := range app.EnvVars() {
+		descriptions[v.Key] = v.Description
+	}
+	var keys []string
+	for _, key := range app.ConfigKeyPaths(ctx.Config) {
+		keys = append(keys, cobra.CompletionWithDesc(key, descriptions[key]))
+	}
+	return keys, cobra.ShellCompDirectiveNoFileComp
+}
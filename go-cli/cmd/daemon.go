@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+)
+
+func newDaemonCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "daemon",
+		Short:   "Run the scheduler and watcher as a resident background process.",
+		Example: "  go-cli daemon start\n  go-cli daemon status\n  go-cli daemon logs --follow",
+		Long:    "Manages a resident process that runs the [daemon] loops: the cron scheduler and, if daemon.watch_task is set, a file watcher. The daemon logs to <state>/daemon.log and answers on the control socket <state>/control.sock (a named pipe on Windows), which status, stop, reload, and logs talk to. A running `serve` answers on the same socket, so these commands manage it too.",
+	}
+
+	cmd.AddCommand(newDaemonStartCommand())
+	cmd.AddCommand(newDaemonStopCommand())
+	cmd.AddCommand(newDaemonStatusCommand())
+	cmd.AddCommand(newDaemonReloadCommand())
+	cmd.AddCommand(newDaemonLogsCommand())
+
+	return cmd
+}
+
+func newDaemonStartCommand() *cobra.Command {
+	opts := app.DaemonStartOptions{}
+	var workDir string
+
+	cmd := &cobra.Command{
+		Use:     "start",
+		Short:   "Start the daemon in the background.",
+		Example: "  go-cli daemon start\n  go-cli daemon start --foreground",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			// Windows services start in the system directory; relative
+			// task file paths need the one the service was installed from.
+			if workDir != "" {
+				if err := os.Chdir(workDir); err != nil {
+					return err
+				}
+			}
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			registry, err := taskRegistry(ctx)
+			if err != nil {
+				return err
+			}
+			if task := ctx.Config.Daemon.WatchTask; task != "" {
+				if _, err := registry.Lookup(task); err != nil {
+					return err
+				}
+			}
+			opts.Jobs = registryJobs
+			return app.HandleDaemonStart(ctx, opts)
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.Foreground, "foreground", false, "Run in the foreground instead of detaching.")
+	cmd.Flags().StringVar(&workDir, "workdir", "", "Change to this directory first (used by Windows services).")
+	_ = cmd.Flags().MarkHidden("workdir")
+
+	return cmd
+}
+
+func newDaemonStopCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "stop",
+		Short:   "Stop the daemon, canceling any run in progress.",
+		Example: "  go-cli daemon stop",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleDaemonStop(ctx)
+		},
+	}
+}
+
+func newDaemonStatusCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "status",
+		Short:   "Show whether the daemon is running.",
+		Example: "  go-cli daemon status\n  go-cli daemon status --json",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleDaemonStatus(ctx)
+		},
+	}
+}
+
+func newDaemonReloadCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "reload",
+		Short:   "Make the running daemon re-read its config file.",
+		Example: "  go-cli daemon reload",
+		Long:    "Asks the running daemon (or serve) to load its config file again. Runs that start afterwards use the new settings; paths, logging, listen addresses, and task definitions need a restart.",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleDaemonReload(ctx)
+		},
+	}
+}
+
+func newDaemonLogsCommand() *cobra.Command {
+	opts := app.DaemonLogsOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "logs",
+		Short:   "Print the daemon log.",
+		Example: "  go-cli daemon logs --lines 50\n  go-cli daemon logs --follow",
+		Long:    "Prints the recent log of the running daemon (or serve) from its control socket and, with --follow, streams new lines. When nothing is running, prints the tail of <state>/daemon.log.",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleDaemonLogs(ctx, opts)
+		},
+	}
+
+	cmd.Flags().IntVarP(&opts.Lines, "lines", "n", 50, "Number of trailing lines to print (0 for all).")
+	cmd.Flags().BoolVarP(&opts.Follow, "follow", "f", false, "Keep printing new log output until interrupted.")
+
+	return cmd
+}
@@ -0,0 +1,230 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/buildinfo"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/releasenotes"
+)
+
+// deprecation retires or renames a command or flag. Retired ones are
+// hidden from help and completion but keep working; a renamed one keeps
+// its old name as a hidden alias until RemoveIn. Either way the first use
+// warns, and every use is recorded on the command's trace span.
+type deprecation struct {
+	// Command is the command path without the binary name, e.g. "runs
+	// list"; empty for the root command.
+	Command string
+	// Flag, if set, is the flag of Command this entry is about.
+	Flag string
+	// OldName, if set, makes the entry a rename: the command or flag used
+	// to be called OldName.
+	OldName string
+	// Replacement tells users what to use instead of a retired command or
+	// flag, e.g. "`go-cli run --dry-run`"; renames point to the new name.
+	Replacement string
+	// Since is the release that deprecated it.
+	Since string
+	// RemoveIn is the first release without it; an old name stops working
+	// once the running release reaches it.
+	RemoveIn string
+}
+
+// deprecations lists the commands and flags on their way out. Add an entry
+// instead of removing or renaming one outright, for example:
+//
+//	{Command: "runs", OldName: "artifacts", Since: "1.4.0", RemoveIn: "2.0.0"}
+//	{Command: "run", Flag: "plan", Replacement: "--dry-run", Since: "1.4.0", RemoveIn: "2.0.0"}
+//	{Command: "runs list", Flag: "limit", OldName: "max", Since: "1.4.0", RemoveIn: "2.0.0"}
+var deprecations = []deprecation{}
+
+// deprecatedAnnotation holds the warning of a retired command or flag, or,
+// suffixed with "/" and the old name, of a renamed command's alias.
+const deprecatedAnnotation = "go-cli/deprecated"
+
+// calledAsAnnotation records the old name a renamed command was invoked
+// by; see markRenamed.
+const calledAsAnnotation = "go-cli/called-as"
+
+// notice completes the warning for what, e.g. "`go-cli runs list`".
+func (d deprecation) notice(what string) string {
+	msg := what + " is deprecated since " + releasenotes.Canonical(d.Since)
+	if d.RemoveIn != "" {
+		msg += " and will be removed in " + releasenotes.Canonical(d.RemoveIn)
+	}
+	if d.Replacement != "" {
+		msg += "; use " + d.Replacement + " instead"
+	}
+	return msg
+}
+
+// expired reports whether the running release has reached RemoveIn.
+// Development builds have no release and keep every old name.
+func (d deprecation) expired() bool {
+	current := releasenotes.Canonical(buildinfo.Get().Version)
+	return current != "" && d.RemoveIn != "" && releasenotes.Compare(current, d.RemoveIn) >= 0
+}
+
+// applyDeprecations hides and aliases what the deprecations table lists.
+// An entry that names no command or flag of the tree is a bug, and panics.
+func applyDeprecations(root *cobra.Command, table []deprecation) {
+	for _, d := range table {
+		cmd, rest, err := root.Find(strings.Fields(d.Command))
+		if err != nil || len(rest) > 0 || (d.Command == "") != (cmd == root) {
+			panic(fmt.Sprintf("deprecations: no command %q", d.Command))
+		}
+		if d.OldName != "" && d.expired() {
+			continue
+		}
+		if d.Flag == "" {
+			deprecateCommand(cmd, d)
+			continue
+		}
+		flags := cmd.PersistentFlags()
+		if flags.Lookup(d.Flag) == nil {
+			flags = cmd.Flags()
+		}
+		f := flags.Lookup(d.Flag)
+		if f == nil {
+			panic(fmt.Sprintf("deprecations: no flag --%s on %q", d.Flag, d.Command))
+		}
+		deprecateFlag(flags, f, d)
+	}
+	hideDeprecatedAliases(root)
+}
+
+func deprecateCommand(cmd *cobra.Command, d deprecation) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	if d.OldName == "" {
+		cmd.Hidden = true
+		cmd.Annotations[deprecatedAnnotation] = d.notice(fmt.Sprintf("`%s`", cmd.CommandPath()))
+		return
+	}
+	if d.Replacement == "" {
+		d.Replacement = fmt.Sprintf("`%s`", cmd.CommandPath())
+	}
+	old := strings.TrimSpace(strings.TrimSuffix(cmd.CommandPath(), cmd.Name()) + d.OldName)
+	cmd.Aliases = append(cmd.Aliases, d.OldName)
+	cmd.Annotations[deprecatedAnnotation+"/"+d.OldName] = d.notice(fmt.Sprintf("`%s`", old))
+}
+
+func deprecateFlag(flags *pflag.FlagSet, f *pflag.Flag, d deprecation) {
+	if d.OldName == "" {
+		f.Hidden = true
+		_ = flags.SetAnnotation(f.Name, deprecatedAnnotation, []string{d.notice("--" + f.Name)})
+		return
+	}
+	if d.Replacement == "" {
+		d.Replacement = "--" + f.Name
+	}
+	// The alias shares the flag's value, so either name sets it.
+	flags.AddFlag(&pflag.Flag{
+		Name:        d.OldName,
+		Usage:       f.Usage,
+		Value:       f.Value,
+		DefValue:    f.DefValue,
+		NoOptDefVal: f.NoOptDefVal,
+		Hidden:      true,
+		Annotations: map[string][]string{deprecatedAnnotation: {d.notice("--" + d.OldName)}},
+	})
+}
+
+// hideDeprecatedAliases leaves the old names of renamed commands out of
+// the Aliases line of their help.
+func hideDeprecatedAliases(root *cobra.Command) {
+	help := root.HelpFunc()
+	root.SetHelpFunc(func(cmd *cobra.Command, args []string) {
+		aliases := cmd.Aliases
+		cmd.Aliases = slices.DeleteFunc(slices.Clone(aliases), func(alias string) bool {
+			return cmd.Annotations[deprecatedAnnotation+"/"+alias] != ""
+		})
+		help(cmd, args)
+		cmd.Aliases = aliases
+	})
+}
+
+// markRenamed records on each command in the command path of args that was
+// invoked by a deprecated old name which name it was; cobra only tells
+// that for the final command.
+func markRenamed(root *cobra.Command, args []string) {
+	cur := root
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return
+		}
+		if strings.HasPrefix(arg, "-") {
+			if takesValue(cur, arg) {
+				i++
+			}
+			continue
+		}
+		var next *cobra.Command
+		for _, sub := range cur.Commands() {
+			if sub.Name() == arg || slices.Contains(sub.Aliases, arg) {
+				next = sub
+				break
+			}
+		}
+		if next == nil {
+			return
+		}
+		if next.Annotations[deprecatedAnnotation+"/"+arg] != "" {
+			next.Annotations[calledAsAnnotation] = arg
+		}
+		cur = next
+	}
+}
+
+// takesValue reports whether the flag arg of cmd consumes the next word.
+func takesValue(cmd *cobra.Command, arg string) bool {
+	var f *pflag.Flag
+	if name, ok := strings.CutPrefix(arg, "--"); ok {
+		if strings.Contains(name, "=") {
+			return false
+		}
+		f = cmd.LocalFlags().Lookup(name)
+		if f == nil {
+			f = cmd.InheritedFlags().Lookup(name)
+		}
+	} else if len(arg) == 2 {
+		f = cmd.LocalFlags().ShorthandLookup(arg[1:])
+		if f == nil {
+			f = cmd.InheritedFlags().ShorthandLookup(arg[1:])
+		}
+	}
+	return f != nil && f.NoOptDefVal == ""
+}
+
+// noticeDeprecated warns about the deprecated commands and flags cmd was
+// invoked with.
+func noticeDeprecated(rtx *app.RuntimeContext, cmd *cobra.Command) {
+	for c := cmd; c != nil; c = c.Parent() {
+		if notice := c.Annotations[deprecatedAnnotation]; notice != "" {
+			app.NoticeDeprecated(rtx, "command:"+c.CommandPath(), notice)
+		}
+		if old := c.Annotations[calledAsAnnotation]; old != "" {
+			app.NoticeDeprecated(rtx, "command:"+c.CommandPath()+":"+old, c.Annotations[deprecatedAnnotation+"/"+old])
+		}
+	}
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		notice := f.Annotations[deprecatedAnnotation]
+		if len(notice) == 0 {
+			return
+		}
+		// A persistent flag is one flag, whichever command it is used with.
+		owner := cmd
+		for cmd.LocalNonPersistentFlags().Lookup(f.Name) == nil && owner.PersistentFlags().Lookup(f.Name) == nil && owner.HasParent() {
+			owner = owner.Parent()
+		}
+		app.NoticeDeprecated(rtx, "flag:"+owner.CommandPath()+" --"+f.Name, notice[0])
+	})
+}
@@ -0,0 +1,222 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/buildinfo"
+)
+
+func newDocsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "docs",
+		Short:   "Generate reference documentation for the CLI.",
+		Example: "  go-cli docs man --output-dir ./man\n  go-cli docs markdown --output-dir docs/cli",
+	}
+
+	cmd.AddCommand(newDocsManCommand())
+	cmd.AddCommand(newDocsMarkdownCommand())
+
+	return cmd
+}
+
+func newDocsManCommand() *cobra.Command {
+	var outputDir string
+
+	cmd := &cobra.Command{
+		Use:     "man",
+		Short:   "Write a man page for every command.",
+		Long:    "Writes one section 1 man page per command (go-cli.1, go-cli-run.1, ...) with its flags, the global flags, and examples. SOURCE_DATE_EPOCH, when set, fixes the page date for reproducible builds.",
+		Example: "  go-cli docs man --output-dir ./man\n  man ./man/go-cli-run.1",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+
+			date, err := manDate(ctx)
+			if err != nil {
+				return err
+			}
+			root := cmd.Root()
+			pages := manPages(root, outputDir)
+			if ctx.Common.DryRun {
+				ctx.Logger.Info("dry-run: would write %d man pages to %s", len(pages), outputDir)
+				return nil
+			}
+
+			if err := os.MkdirAll(outputDir, 0o755); err != nil {
+				return fmt.Errorf("create %s: %w", outputDir, err)
+			}
+			header := &doc.GenManHeader{
+				Title:   strings.ToUpper(root.Name()),
+				Section: "1",
+				Date:    &date,
+				Source:  root.Name() + " " + buildinfo.Get().Version,
+			}
+			if err := doc.GenManTree(root, header, outputDir); err != nil {
+				return fmt.Errorf("generate man pages: %w", err)
+			}
+
+			if ctx.Common.Porcelain {
+				for _, page := range pages {
+					ctx.Out.Println(page)
+				}
+				return nil
+			}
+			ctx.Logger.Info("wrote %d man pages to %s", len(pages), outputDir)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputDir, "output-dir", "o", "man", "Directory to write the man pages to.")
+
+	return cmd
+}
+
+func newDocsMarkdownCommand() *cobra.Command {
+	var outputDir string
+
+	cmd := &cobra.Command{
+		Use:     "markdown",
+		Short:   "Write a Markdown reference page for every command.",
+		Long:    "Writes one Markdown page per command (go-cli.md, go-cli_run.md, ...) with its usage, flags, the global flags, examples, and the environment variables it reads, linked together. The root page lists the config overrides every command honors. Regenerate the pages in CI so published docs never drift from the command tree.",
+		Example: "  go-cli docs markdown --output-dir docs/cli",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+
+			root := cmd.Root()
+			// Without the dated footer, regenerating unchanged docs is a no-op.
+			root.DisableAutoGenTag = true
+			pages := docPages(root)
+			if ctx.Common.DryRun {
+				ctx.Logger.Info("dry-run: would write %d Markdown pages to %s", len(pages), outputDir)
+				return nil
+			}
+			if err := os.MkdirAll(outputDir, 0o755); err != nil {
+				return fmt.Errorf("create %s: %w", outputDir, err)
+			}
+
+			vars := app.EnvVars()
+			for _, c := range pages {
+				var b bytes.Buffer
+				if err := doc.GenMarkdownCustom(c, &b, func(name string) string { return name }); err != nil {
+					return fmt.Errorf("generate %s: %w", c.CommandPath(), err)
+				}
+				page := withEnvTable(b.String(), c, vars)
+
+				path := filepath.Join(outputDir, markdownName(c))
+				if err := os.WriteFile(path, []byte(page), 0o644); err != nil {
+					return fmt.Errorf("write %s: %w", path, err)
+				}
+				if ctx.Common.Porcelain {
+					ctx.Out.Println(path)
+				}
+			}
+			if !ctx.Common.Porcelain {
+				ctx.Logger.Info("wrote %d Markdown pages to %s", len(pages), outputDir)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&outputDir, "output-dir", "o", filepath.Join("docs", "cli"), "Directory to write the Markdown pages to.")
+
+	return cmd
+}
+
+// markdownName is the file cobra/doc links to for c, e.g. go-cli_runs_clean.md.
+func markdownName(c *cobra.Command) string {
+	return strings.ReplaceAll(c.CommandPath(), " ", "_") + ".md"
+}
+
+// withEnvTable adds a section listing the environment variables c reads to
+// its page, ahead of SEE ALSO. The root page lists the config overrides,
+// which apply to every command.
+func withEnvTable(page string, c *cobra.Command, vars []app.EnvVar) string {
+	path := strings.TrimPrefix(strings.TrimPrefix(c.CommandPath(), c.Root().Name()), " ")
+	var rows []app.EnvVar
+	for _, v := range vars {
+		if v.Command == path && (v.Command != "" || !c.HasParent()) {
+			rows = append(rows, v)
+		}
+	}
+	if len(rows) == 0 {
+		return page
+	}
+
+	var b strings.Builder
+	b.WriteString("### Environment\n\n")
+	if !c.HasParent() {
+		fmt.Fprintf(&b, "Every config key can be overridden with `%s_<SECTION>__<KEY>`; the variable wins over the config file.\n\n", app.EnvPrefix())
+	}
+	b.WriteString("| Variable | Config key | Description |\n|----------|------------|-------------|\n")
+	for _, v := range rows {
+		key := "–"
+		if v.Key != "" {
+			key = "`" + v.Key + "`"
+		}
+		fmt.Fprintf(&b, "| `%s` | %s | %s |\n", v.Name, key, strings.ReplaceAll(v.Description, "|", "\\|"))
+	}
+	b.WriteString("\n")
+
+	if i := strings.Index(page, "### SEE ALSO"); i >= 0 {
+		return page[:i] + b.String() + page[i:]
+	}
+	return page + b.String()
+}
+
+// docPages lists the commands that get a page: the root and every
+// available subcommand, as cobra/doc's tree generators select them.
+func docPages(root *cobra.Command) []*cobra.Command {
+	var pages []*cobra.Command
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		if !c.IsAvailableCommand() && c != root || c.IsAdditionalHelpTopicCommand() {
+			return
+		}
+		pages = append(pages, c)
+		for _, child := range c.Commands() {
+			walk(child)
+		}
+	}
+	walk(root)
+	return pages
+}
+
+// manPages lists the files GenManTree writes for root.
+func manPages(root *cobra.Command, dir string) []string {
+	var pages []string
+	for _, c := range docPages(root) {
+		pages = append(pages, filepath.Join(dir, strings.ReplaceAll(c.CommandPath(), " ", "-")+".1"))
+	}
+	return pages
+}
+
+// manDate is the date printed in the pages: SOURCE_DATE_EPOCH if set, else
+// today.
+func manDate(ctx *app.RuntimeContext) (time.Time, error) {
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		return ctx.Clock.Now(), nil
+	}
+	secs, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid SOURCE_DATE_EPOCH %q: %w", epoch, err)
+	}
+	return time.Unix(secs, 0).UTC(), nil
+}
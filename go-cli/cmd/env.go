@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+)
+
+func newEnvCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "env",
+		Short:   "List the GO_CLI_* environment variables the CLI reads and their current values.",
+		Long:    "Lists every recognized GO_CLI_* variable with whether it is set, its value (secrets redacted), and the config key it overrides. Config overrides take the form GO_CLI_<SECTION>__<KEY> and win over the config file.",
+		Example: "  go-cli env\n  GO_CLI_LOGGING__LEVEL=debug go-cli env --json",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleEnv(ctx)
+		},
+	}
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+)
+
+func newExamplesCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "examples [COMMAND...]",
+		Short: "Print copy-pasteable usage examples for a command and its subcommands.",
+		Long:  "Collects the Examples sections of a command's help and of every subcommand below it, one command line per line. Without a COMMAND it covers the whole CLI. --porcelain prints only the command lines.",
+		Example: "  go-cli examples run\n" +
+			"  go-cli examples config get\n" +
+			"  go-cli examples --porcelain | grep -- --json",
+		ValidArgsFunction: completeSubcommands,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			target, rest, err := cmd.Root().Find(args)
+			if err != nil || len(rest) > 0 {
+				return app.UsageError(fmt.Errorf("unknown command %q; run help for the list", strings.Join(args, " ")))
+			}
+			return app.HandleExamples(ctx, commandExamples(target))
+		},
+	}
+}
+
+// commandExamples collects the examples of cmd and of the available
+// commands below it, in help order.
+func commandExamples(cmd *cobra.Command) []app.CommandExamples {
+	var all []app.CommandExamples
+	if cmd.HasExample() {
+		var lines []string
+		for _, line := range strings.Split(cmd.Example, "\n") {
+			if line = strings.TrimSpace(line); line != "" {
+				lines = append(lines, line)
+			}
+		}
+		all = append(all, app.CommandExamples{Command: cmd.CommandPath(), Summary: cmd.Short, Examples: lines})
+	}
+	for _, sub := range cmd.Commands() {
+		if sub.IsAvailableCommand() {
+			all = append(all, commandExamples(sub)...)
+		}
+	}
+	return all
+}
+
+// completeSubcommands completes the names of the available subcommands of
+// the command that args lead to.
+func completeSubcommands(cmd *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	parent, rest, err := cmd.Root().Find(args)
+	if err != nil || len(rest) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, sub := range parent.Commands() {
+		if sub.IsAvailableCommand() {
+			names = append(names, cobra.CompletionWithDesc(sub.Name(), sub.Short))
+		}
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
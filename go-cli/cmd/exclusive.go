@@ -0,0 +1,117 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// exclusiveAnnotation holds, one per line, the groups of a command's
+// flags of which at most one may be set, names separated by spaces. A
+// command's groups also hold for its subcommands, which inherit its
+// persistent flags.
+const exclusiveAnnotation = "go-cli/exclusive"
+
+// markExclusive declares that at most one of the named flags of cmd may be
+// set. Cobra gets the group too, so completion stops offering the others
+// once one is set.
+func markExclusive(cmd *cobra.Command, names ...string) {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	groups := cmd.Annotations[exclusiveAnnotation]
+	if groups != "" {
+		groups += "\n"
+	}
+	cmd.Annotations[exclusiveAnnotation] = groups + strings.Join(names, " ")
+	cmd.MarkFlagsMutuallyExclusive(names...)
+}
+
+// exclusiveGroups returns the groups markExclusive declared on cmd itself.
+func exclusiveGroups(cmd *cobra.Command) [][]string {
+	var groups [][]string
+	for _, line := range strings.Split(cmd.Annotations[exclusiveAnnotation], "\n") {
+		if names := strings.Fields(line); len(names) > 0 {
+			groups = append(groups, names)
+		}
+	}
+	return groups
+}
+
+// checkExclusiveFlags rejects flags of cmd set together although they are
+// declared mutually exclusive on cmd or one of its parents.
+func checkExclusiveFlags(cmd *cobra.Command) error {
+	for c := cmd; c != nil; c = c.Parent() {
+		for _, group := range exclusiveGroups(c) {
+			var set []string
+			for _, name := range group {
+				if cmd.Flags().Changed(name) {
+					set = append(set, name)
+				}
+			}
+			if len(set) > 1 {
+				return fmt.Errorf("--%s and --%s cannot be used together", set[0], set[1])
+			}
+		}
+	}
+	return nil
+}
+
+// noteExclusiveFlags appends the flags each flag of the tree cannot be
+// combined with to its help text, since cobra enforces flag groups but
+// does not show them.
+func noteExclusiveFlags(root *cobra.Command) {
+	var walk func(c *cobra.Command)
+	walk = func(c *cobra.Command) {
+		var names []string
+		others := map[string][]string{}
+		for _, group := range exclusiveGroups(c) {
+			for _, name := range group {
+				if !slices.Contains(names, name) {
+					names = append(names, name)
+				}
+				for _, other := range group {
+					if other != name && !slices.Contains(others[name], other) {
+						others[name] = append(others[name], other)
+					}
+				}
+			}
+		}
+		for _, name := range names {
+			if f := lookupFlag(c, name); f != nil {
+				f.Usage += " Not with " + flagList(others[name]) + "."
+			}
+		}
+		for _, sub := range c.Commands() {
+			walk(sub)
+		}
+	}
+	walk(root)
+}
+
+// lookupFlag finds a persistent or local flag of c by name.
+func lookupFlag(c *cobra.Command, name string) *pflag.Flag {
+	if f := c.PersistentFlags().Lookup(name); f != nil {
+		return f
+	}
+	return c.Flags().Lookup(name)
+}
+
+// flagList joins names as flags: "--a", "--a or --b", "--a, --b, or --c".
+func flagList(names []string) string {
+	flags := make([]string, len(names))
+	for i, name := range names {
+		flags[i] = "--" + name
+	}
+	switch n := len(flags); n {
+	case 1:
+		return flags[0]
+	case 2:
+		return flags[0] + " or " + flags[1]
+	default:
+		return strings.Join(flags[:n-1], ", ") + ", or " + flags[n-1]
+	}
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import "testing"
+
+func TestCheckExclusiveFlags(t *testing.T) {
+	for _, tt := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"run", "--json", "--dry-run", "--yes"}, ""},
+		{[]string{"run", "--json", "--porcelain"}, "--json and --porcelain cannot be used together"},
+		{[]string{"run", "-q", "--trace"}, "--quiet and --trace cannot be used together"},
+		{[]string{"run", "--resume", "--from-scratch"}, "--resume and --from-scratch cannot be used together"},
+	} {
+		root := newRootCommand()
+		cmd, args, err := root.Find(tt.args)
+		if err != nil {
+			t.Fatalf("find %v: %v", tt.args, err)
+		}
+		if err := cmd.ParseFlags(args); err != nil {
+			t.Fatalf("parse %v: %v", tt.args, err)
+		}
+		got := ""
+		if err := checkExclusiveFlags(cmd); err != nil {
+			got = err.Error()
+		}
+		if got != tt.want {
+			t.Errorf("%v: error = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+)
+
+func newExplainCommand() *cobra.Command {
+	var names []string
+	for _, info := range app.ExitCodes {
+		names = append(names, info.Name)
+	}
+	return &cobra.Command{
+		Use:   "explain [CODE]",
+		Short: "Explain an exit code: what it means, likely causes, and what to do.",
+		Long:  "Describes an exit code given by number (4) or name (E_TIMEOUT, or just timeout). Without an argument it lists every exit code.",
+		Example: "  go-cli explain\n" +
+			"  go-cli explain 4\n" +
+			"  go-cli explain E_PARTIAL --json",
+		Args:      cobra.MaximumNArgs(1),
+		ValidArgs: names,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			query := ""
+			if len(args) == 1 {
+				query = args[0]
+			}
+			return app.HandleExplain(ctx, query)
+		},
+	}
+}
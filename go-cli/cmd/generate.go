@@ -0,0 +1,52 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+)
+
+func newGenerateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "generate",
+		Short:   "Scaffold code in a project built from this template.",
+		Example: "  go-cli generate command sync-users",
+	}
+
+	cmd.AddCommand(newGenerateCommandCommand())
+
+	return cmd
+}
+
+func newGenerateCommandCommand() *cobra.Command {
+	opts := app.GenerateCommandOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "command NAME",
+		Short: "Add a subcommand: cmd/NAME.go, a handler stub and test, and its registration.",
+		Long: "Writes cmd/NAME.go with a cobra command, internal/app/NAME.go with a Handle function stub, and internal/app/NAME_test.go with a test for it, from templates embedded in the binary. " +
+			"The command is wired into the root command by regenerating cmd/commands_generated.go from every constructor marked //gocli:command, so there is nothing to copy or register by hand.\n\n" +
+			"Run it inside the project; the nearest directory with a go.mod is used. Existing files are left alone unless --force is given, and --dry-run lists what would be written.",
+		Example: "  go-cli generate command sync-users\n" +
+			"  go-cli generate command report --dir ~/src/mytool --dry-run",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			opts.Name = args[0]
+			opts.Reserved = []string{"help"}
+			for _, c := range cmd.Root().Commands() {
+				opts.Reserved = append(opts.Reserved, c.Name())
+				opts.Reserved = append(opts.Reserved, c.Aliases...)
+			}
+			return app.HandleGenerateCommand(ctx, opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Dir, "dir", "", "Directory inside the project (default: the working directory).")
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "Overwrite existing command, handler, and test files.")
+
+	return cmd
+}
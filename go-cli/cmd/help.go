@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// flagGroupAnnotation names the help section a flag is listed under.
+const flagGroupAnnotation = "go-cli/flag-group"
+
+// flagGroups are the help sections of the global flags, in help order.
+var flagGroups = []string{"Output", "Logging", "Configuration", "Behavior"}
+
+func init() {
+	cobra.AddTemplateFunc("flagSections", flagSections)
+}
+
+// groupFlags lists the named flags under group in help.
+func groupFlags(flags *pflag.FlagSet, group string, names ...string) {
+	if !slices.Contains(flagGroups, group) {
+		panic(fmt.Sprintf("flag groups: no group %q", group))
+	}
+	for _, name := range names {
+		if err := flags.SetAnnotation(name, flagGroupAnnotation, []string{group}); err != nil {
+			panic(fmt.Sprintf("flag groups: %v", err))
+		}
+	}
+}
+
+// flagSections renders flags as help sections: the ungrouped ones under
+// title, then one section per group, e.g. "Global Output Flags".
+func flagSections(title string, flags *pflag.FlagSet) string {
+	sets := map[string]*pflag.FlagSet{}
+	flags.VisitAll(func(f *pflag.Flag) {
+		var group string
+		if g := f.Annotations[flagGroupAnnotation]; len(g) > 0 {
+			group = g[0]
+		}
+		if sets[group] == nil {
+			sets[group] = pflag.NewFlagSet(group, pflag.ContinueOnError)
+			sets[group].SortFlags = flags.SortFlags
+		}
+		sets[group].AddFlag(f)
+	})
+	var sections []string
+	for _, group := range append([]string{""}, flagGroups...) {
+		set := sets[group]
+		if set == nil || !set.HasAvailableFlags() {
+			continue
+		}
+		heading := title
+		if group != "" {
+			heading = strings.Replace(title, "Flags", group+" Flags", 1)
+		}
+		sections = append(sections, heading+":\n"+strings.TrimRight(set.FlagUsages(), " \n"))
+	}
+	return strings.Join(sections, "\n\n")
+}
+
+// usageTemplate is cobra's default usage template with the flags split
+// into the sections of flagSections.
+const usageTemplate = `Usage:{{if .Runnable}}
+  {{.UseLine}}{{end}}{{if .HasAvailableSubCommands}}
+  {{.CommandPath}} [command]{{end}}{{if gt (len .Aliases) 0}}
+
+Aliases:
+  {{.NameAndAliases}}{{end}}{{if .HasExample}}
+
+Examples:
+{{.Example}}{{end}}{{if .HasAvailableSubCommands}}{{$cmds := .Commands}}{{if eq (len .Groups) 0}}
+
+Available Commands:{{range $cmds}}{{if (or .IsAvailableCommand (eq .Name "help"))}}
+  {{rpad .Name .NamePadding }} {{.Short}}{{end}}{{end}}{{else}}{{range $group := .Groups}}
+
+{{.Title}}{{range $cmds}}{{if (and (eq .GroupID $group.ID) (or .IsAvailableCommand (eq .Name "help")))}}
+  {{rpad .Name .NamePadding }} {{.Short}}{{end}}{{end}}{{end}}{{if not .AllChildCommandsHaveGroup}}
+
+Additional Commands:{{range $cmds}}{{if (and (eq .GroupID "") (or .IsAvailableCommand (eq .Name "help")))}}
+  {{rpad .Name .NamePadding }} {{.Short}}{{end}}{{end}}{{end}}{{end}}{{end}}{{if .HasAvailableLocalFlags}}
+
+{{flagSections "Flags" .LocalFlags}}{{end}}{{if .HasAvailableInheritedFlags}}
+
+{{flagSections "Global Flags" .InheritedFlags}}{{end}}{{if .HasHelpSubCommands}}
+
+Additional help topics:{{range .Commands}}{{if .IsAdditionalHelpTopicCommand}}
+  {{rpad .CommandPath .CommandPathPadding}} {{.Short}}{{end}}{{end}}{{end}}{{if .HasAvailableSubCommands}}
+
+Use "{{.CommandPath}} [command] --help" for more information about a command.{{end}}
+`
@@ -0,0 +1,35 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/testutil/golden"
+)
+
+// TestHelpGolden pins the help text of the root command and of commands
+// whose flags scripts depend on.
+func TestHelpGolden(t *testing.T) {
+	for _, args := range [][]string{
+		{},
+		{"run"},
+		{"config", "show"},
+	} {
+		name := "root"
+		if len(args) > 0 {
+			name = strings.Join(args, "-")
+		}
+		t.Run(name, func(t *testing.T) {
+			root := newRootCommand()
+			var out bytes.Buffer
+			root.SetOut(&out)
+			root.SetErr(&out)
+			root.SetArgs(append(args, "--help"))
+			if err := root.Execute(); err != nil {
+				t.Fatalf("help: %v", err)
+			}
+			golden.Assert(t, "help/"+name, out.String())
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+)
+
+func newHistoryCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "history",
+		Short:   "Inspect past runs recorded in the state directory.",
+		Example: "  go-cli history list\n  go-cli history show last",
+	}
+
+	cmd.AddCommand(newHistoryListCommand())
+	cmd.AddCommand(newHistoryShowCommand())
+
+	return cmd
+}
+
+func newHistoryListCommand() *cobra.Command {
+	opts := app.HistoryListOptions{Limit: 20}
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "List recent runs, newest first.",
+		Example: "  go-cli history list -n 5\n  go-cli history list --task ci --json\n  go-cli history list --where status=failed --where 'started>=2026-06-01'",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleHistoryList(ctx, opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Task, "task", "", "Only show runs of this task.")
+	_ = cmd.RegisterFlagCompletionFunc("task", completeTaskNames)
+	cmd.Flags().IntVarP(&opts.Limit, "limit", "n", opts.Limit, "Maximum number of runs to show (0 = all).")
+	cmd.Flags().StringArrayVar(&opts.Where, "where", nil, "Only show runs matching FIELD OP VALUE, e.g. status=failed or duration_ms>60000; repeat to require several (fields: id, task, profile, status, exit_code, duration_ms, started; ops: = != < <= > >= ~).")
+
+	return cmd
+}
+
+func newHistoryShowCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "show ID",
+		Short:   "Show details of one run (use \"last\" for the most recent).",
+		Example: "  go-cli history show last\n  go-cli history show 20260528T183539Z-3f9a1c --yaml",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleHistoryShow(ctx, args[0])
+		},
+	}
+}
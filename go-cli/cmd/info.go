@@ -0,0 +1,25 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/buildinfo"
+)
+
+func newInfoCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "info",
+		Short:   "Show version, resolved paths, active profile, log level, and parallelism.",
+		Long:    "Prints the facts most support requests and scripts need in one place: the build, the config file and data, state, and cache directories, the active profile, the effective log level, and the parallelism a run starts with. --parallel, -v, and environment overrides are taken into account.",
+		Example: "  go-cli info\n  go-cli info --json | jq -r .paths.data",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleInfo(ctx, buildinfo.Get())
+		},
+	}
+}
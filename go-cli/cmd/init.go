@@ -10,8 +10,9 @@ func newInitCommand() *cobra.Command {
 	opts := app.InitOptions{}
 
 	cmd := &cobra.Command{
-		Use:   "init",
-		Short: "Create config directories and default files.",
+		Use:     "init",
+		Short:   "Create config directories and default files.",
+		Example: "  go-cli init\n  go-cli init --force",
 		RunE: func(cmd *cobra.Command, _ []string) error {
 			ctx, err := Context(cmd)
 			if err != nil {
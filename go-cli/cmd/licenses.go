@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+)
+
+func newLicensesCommand() *cobra.Command {
+	opts := app.LicensesOptions{}
+	cmd := &cobra.Command{
+		Use:   "licenses [MODULE]",
+		Short: "Show the licenses of the third-party code compiled into the binary.",
+		Long:  "Lists every module linked into the binary, including the Go standard library, with its version and license. The inventory and license texts are embedded at build time. Pass a module path, or --full, to print the license texts.",
+		Example: "  go-cli licenses\n" +
+			"  go-cli licenses github.com/spf13/cobra\n" +
+			"  go-cli licenses --full > THIRD_PARTY_LICENSES",
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			if len(args) == 1 {
+				opts.Module = args[0]
+			}
+			return app.HandleLicenses(ctx, opts)
+		},
+	}
+	cmd.Flags().BoolVar(&opts.Full, "full", false, "Print every license text.")
+	return cmd
+}
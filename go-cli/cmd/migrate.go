@@ -0,0 +1,45 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+)
+
+func newMigrateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "migrate",
+		Short:   "Move application data to a new location.",
+		Example: "  go-cli migrate data --dry-run",
+	}
+
+	cmd.AddCommand(locking(newMigrateDataCommand()))
+
+	return cmd
+}
+
+func newMigrateDataCommand() *cobra.Command {
+	opts := app.MigrateDataOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "data",
+		Short: "Copy an old data directory into the current one, verifying every file.",
+		Long: "Brings the contents of an old data directory into the current one (paths.data_dir, or the default location). The old directory is the one runs used before paths.data_dir changed, else the default location when paths.data_dir points elsewhere; --from names it explicitly.\n\n" +
+			"Every copied file is checked against the SHA-256 of its source, and the new location is recorded in the state directory. Files the target already holds with the same content are skipped; different content stops the migration before anything is written. The old directory is kept unless --move is given. --dry-run previews the migration.",
+		Example: "  go-cli migrate data --dry-run\n" +
+			"  go-cli migrate data --from ~/old-data --move",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleMigrateData(ctx, opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.From, "from", "", "Old data directory (default: detected).")
+	cmd.Flags().BoolVar(&opts.Move, "move", false, "Remove the old directory after a verified copy.")
+
+	return cmd
+}
@@ -0,0 +1,49 @@
+package cmd
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// pickAnnotation marks a flag whose value is picked interactively when it
+// is given bare, as in `run --profile`.
+const pickAnnotation = "pick"
+
+// pickValue is the value a bare pickable flag receives.
+const pickValue = "?"
+
+// pickable marks the flag name of cmd as pickable.
+func pickable(cmd *cobra.Command, name string) {
+	_ = cmd.Flags().SetAnnotation(name, pickAnnotation, []string{"true"})
+}
+
+// pickArgs gives pickable flags that appear without a value pickValue;
+// pflag would otherwise take the next flag as the value, or fail at the
+// end of the line. "--flag VALUE" and "--flag=VALUE" are unchanged, so
+// completion and scripts see the flag as a normal string flag.
+func pickArgs(root *cobra.Command, args []string) []string {
+	cmd, _, err := root.Find(args)
+	if err != nil {
+		return args
+	}
+	out := slices.Clone(args)
+	for i, arg := range out {
+		if arg == "--" {
+			break
+		}
+		name, ok := strings.CutPrefix(arg, "--")
+		if !ok || strings.Contains(name, "=") {
+			continue
+		}
+		f := cmd.Flags().Lookup(name)
+		if f == nil || f.Annotations[pickAnnotation] == nil {
+			continue
+		}
+		if i+1 == len(out) || strings.HasPrefix(out[i+1], "-") {
+			out[i] = arg + "=" + pickValue
+		}
+	}
+	return out
+}
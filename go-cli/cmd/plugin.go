@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+)
+
+func newPluginCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "plugin",
+		Short:   "Inspect external plugin commands found on PATH.",
+		Example: "  go-cli plugin list",
+		Long:    "An executable named go-cli-<name> on PATH becomes the command go-cli <name>, run with the remaining arguments when no built-in command or alias has that name. Plugins inherit the environment plus GO_CLI_CONFIG_FILE, GO_CLI_DATA_DIR, GO_CLI_STATE_DIR, GO_CLI_CACHE_DIR, GO_CLI_OUTPUT (text, json, yaml, or porcelain), GO_CLI_PROFILE, GO_CLI_DRY_RUN, and GO_CLI_RUN_ID. Global flags go before the plugin name; everything after it is passed to the plugin.",
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List plugins on PATH in lookup order.",
+		Example: "  go-cli plugin list\n  go-cli plugin list --porcelain",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandlePluginList(ctx, commandNames(cmd.Root()))
+		},
+	})
+	return cmd
+}
+
+// pluginArgs handles a command word in args that names no built-in
+// command but a plugin on PATH. It adds a command running the plugin to
+// root and returns args with the plugin's arguments after "--", so the
+// global flags before the name are parsed and the rest pass through.
+func pluginArgs(root *cobra.Command, args []string) []string {
+	i := commandIndex(root, args)
+	if i < 0 || isCommandName(root, args[i]) {
+		return args
+	}
+	name := args[i]
+	path, ok := app.LookPlugin(name)
+	if !ok {
+		return args
+	}
+	root.AddCommand(&cobra.Command{
+		Use:   name,
+		Short: "Run the " + path + " plugin.",
+		Args:  cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.RunPlugin(ctx, name, path, args)
+		},
+	})
+	return append(append(args[:i+1:i+1], "--"), args[i+1:]...)
+}
@@ -0,0 +1,105 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+)
+
+func newProfileCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "profile",
+		Short:   "Manage configuration profiles.",
+		Example: "  go-cli profile list\n  go-cli profile create staging --from prod\n  go-cli profile use staging",
+		Long:    "A profile is a [profiles.NAME] table in the config file whose settings override the top-level ones while the profile is active, e.g. [profiles.prod.runtime] parallelism = 8. The active profile is the top-level profile key, overridden by GO_CLI_PROFILE, and run --profile selects another for one run. Tables keyed by name, such as tasks, aliases, and runtime.priority, replace the top-level table as a whole.",
+	}
+	cmd.AddCommand(&cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List profiles and the settings each overrides; * marks the active one.",
+		Example: "  go-cli profile list\n  go-cli profile list --json",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleProfileList(ctx)
+		},
+	})
+	cmd.AddCommand(newProfileCreateCommand())
+	cmd.AddCommand(&cobra.Command{
+		Use:               "delete NAME",
+		Aliases:           []string{"rm"},
+		Short:             "Remove a profile's [profiles.NAME] tables from the config file.",
+		Long:              "Removes the [profiles.NAME] tables from the config file. It asks you to type NAME to confirm, and without a terminal needs --yes. The active profile cannot be deleted.",
+		Example:           "  go-cli profile delete staging\n  go-cli profile delete staging --yes",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeProfiles,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleProfileDelete(ctx, args[0])
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:               "rename OLD NEW",
+		Aliases:           []string{"mv"},
+		Short:             "Rename a profile, and the active profile if it is OLD.",
+		Example:           "  go-cli profile rename staging qa",
+		Args:              cobra.ExactArgs(2),
+		ValidArgsFunction: completeProfiles,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleProfileRename(ctx, args[0], args[1])
+		},
+	})
+	cmd.AddCommand(&cobra.Command{
+		Use:               "use NAME",
+		Short:             "Make NAME the active profile in the config file.",
+		Example:           "  go-cli profile use prod",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeProfiles,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleProfileUse(ctx, args[0])
+		},
+	})
+	return cmd
+}
+
+func newProfileCreateCommand() *cobra.Command {
+	opts := app.ProfileCreateOptions{}
+	cmd := &cobra.Command{
+		Use:     "create NAME",
+		Short:   "Add an empty profile, or a copy of another with --from.",
+		Example: "  go-cli profile create staging --from prod\n  go-cli profile use staging",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			opts.Name = args[0]
+			return app.HandleProfileCreate(ctx, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.From, "from", "", "Copy the settings of this profile.")
+	_ = cmd.RegisterFlagCompletionFunc("from", completeProfiles)
+	return cmd
+}
+
+// completeProfiles completes profile names from the config file named by
+// --config, or the default one.
+func completeProfiles(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	override, _ := cmd.Flags().GetString("config")
+	return app.LoadProfileNames(override), cobra.ShellCompDirectiveNoFileComp
+}
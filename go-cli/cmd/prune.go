@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+)
+
+func newPruneCommand() *cobra.Command {
+	opts := app.PruneOptions{}
+	var olderThan app.Duration
+	var keepLast int
+
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove old task logs, history entries, and run artifacts.",
+		Long: "Removes task logs (state directory), run history entries, and run artifact directories (data directory) past the retention limits. " +
+			"An item goes once it is older than --older-than (default retention.max_age) and not among the newest --keep-last (default retention.keep_last) of its kind; with an age limit of 0 only --keep-last applies. " +
+			"--dry-run lists what would be removed; otherwise prune asks first, and without a terminal needs --yes.",
+		Example: "  go-cli prune --dry-run\n" +
+			"  go-cli prune --what artifacts --keep-last 5 --older-than 0s\n" +
+			"  go-cli prune --older-than 7d --yes",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			if cmd.Flags().Changed("older-than") {
+				d := olderThan.Std()
+				opts.OlderThan = &d
+			}
+			if cmd.Flags().Changed("keep-last") {
+				opts.KeepLast = &keepLast
+			}
+			return app.HandlePrune(ctx, opts)
+		},
+	}
+
+	cmd.Flags().Var(&olderThan, "older-than", "Remove items older than this, e.g. 72h or 30d (default: retention.max_age; 0s disables).")
+	cmd.Flags().IntVar(&keepLast, "keep-last", 0, "Always keep the newest N runs of each kind (default: retention.keep_last).")
+	cmd.Flags().StringVar(&opts.What, "what", app.PruneAll, "What to prune: "+strings.Join(app.PruneKinds, ", ")+".")
+	_ = cmd.RegisterFlagCompletionFunc("what", cobra.FixedCompletions(app.PruneKinds, cobra.ShellCompDirectiveNoFileComp))
+
+	return cmd
+}
@@ -1,89 +1,275 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/spf13/cobra"
 
 	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/buildinfo"
 )
 
 var (
 	rootCmd      *cobra.Command
 	commonFlags  app.CommonFlags
-	timeoutFlag  int
-	parallelFlag int
+	timeoutFlag  app.Timeout
+	parallelFlag app.Parallelism
+	// chdirFlag is only declared for help, completion, and parsing;
+	// Execute applies -C before cobra runs. See enterDirs.
+	chdirFlag []string
 )
 
 func init() {
-	rootCmd = &cobra.Command{
+	rootCmd = newRootCommand()
+}
+
+// newRootCommand builds the command tree. The flag variables it binds are
+// reset to their defaults, so the interactive shell builds a fresh tree for
+// every line it runs.
+func newRootCommand() *cobra.Command {
+	rootCmd := &cobra.Command{
 		Use:           "go-cli",
 		Short:         "Opinionated starting point for cross-platform Go CLIs.",
+		Example:       "  go-cli run ci\n  go-cli -C ~/src/project run ci\n  go-cli run deploy --profile prod --dry-run\n  go-cli config show --json\n  go-cli examples run",
 		Long:          "go-cli is a batteries-included template demonstrating structured commands, config loading, logging, and shell completion generation.",
+		Version:       buildinfo.Get().String(),
 		SilenceErrors: true,
 		SilenceUsage:  true,
 		PersistentPreRunE: func(cmd *cobra.Command, _ []string) error {
-			if _, ok := app.FromContext(cmd.Context()); ok {
-				return nil
+			if err := checkChdir(); err != nil {
+				return err
 			}
-
 			flags := commonFlags
 			if f := cmd.Flags().Lookup("timeout"); f != nil && f.Changed {
-				flags.TimeoutSeconds = &timeoutFlag
+				timeout := timeoutFlag.Std()
+				flags.Timeout = &timeout
 			}
 			if f := cmd.Flags().Lookup("parallel"); f != nil && f.Changed {
 				flags.Parallelism = &parallelFlag
 			}
 
-			if flags.JSON && flags.YAML {
-				return fmt.Errorf("--json and --yaml cannot be used together")
+			// Cobra checks flag groups only after this hook; reject
+			// conflicts before the config is loaded.
+			if err := checkExclusiveFlags(cmd); err != nil {
+				return app.UsageError(err)
 			}
 
 			if err := flags.ValidateColor(); err != nil {
-				return err
+				return app.UsageError(err)
 			}
 
 			if err := flags.ValidateLogFormat(); err != nil {
-				return err
+				return app.UsageError(err)
 			}
 
-			rtx, err := app.NewRuntimeContext(cmd.Context(), flags)
-			if err != nil {
-				return err
+			// Inside the shell the context is already loaded; only the
+			// per-command flags change.
+			rtx, ok := app.FromContext(cmd.Context())
+			if ok {
+				rtx = rtx.Reuse(cmd.Context(), flags)
+			} else {
+				var err error
+				if rtx, err = app.NewRuntimeContext(cmd.Context(), flags); err != nil {
+					return err
+				}
+				if !strings.HasPrefix(cmd.Name(), cobra.ShellCompRequestCmd) {
+					app.NoticeUpgrade(rtx, buildinfo.Get())
+					if cmd.Annotations[scriptedAnnotation] == "" && app.ShouldOnboard(rtx) {
+						opts := app.OnboardingOptions{Completion: func(shell string, w io.Writer) error {
+							return writeCompletion(cmd.Root(), shell, w)
+						}}
+						if err := app.HandleOnboarding(rtx, opts); err != nil {
+							rtx.Logger.Warn("first-run setup: %v", err)
+						}
+					}
+				}
+				rtx.StartCommandSpan(cmd.CommandPath())
 			}
 
 			cmd.SetContext(rtx.Context)
+			if !strings.HasPrefix(cmd.Name(), cobra.ShellCompRequestCmd) {
+				noticeDeprecated(rtx, cmd)
+			}
+
+			if flags.Copy && cmd.Annotations[copyAnnotation] == "" {
+				return app.UsageError(fmt.Errorf("--copy: %s has no primary output to copy", cmd.CommandPath()))
+			}
+			rtx.CopyOutput = cmd.Annotations[copyAnnotation] != "" && (flags.Copy || rtx.Config.Output.Clipboard)
+
+			if cmd.Annotations[lockAnnotation] != "" {
+				return rtx.AcquireLock()
+			}
 			return nil
 		},
 	}
 
 	pflags := rootCmd.PersistentFlags()
+	pflags.StringArrayVarP(&chdirFlag, "chdir", "C", nil, "Run as if started in `DIR`: the config, task file, and relative paths resolve from there (repeatable; each is relative to the last).")
 	pflags.StringVar(&commonFlags.ConfigPath, "config", "", "Override the config file path.")
+	pflags.StringArrayVar(&commonFlags.Set, "set", nil, "Override a config key as `KEY=VALUE`, over the environment and the file (repeatable; lists are comma-separated). See `go-cli env` for the keys.")
 	pflags.BoolVarP(&commonFlags.Quiet, "quiet", "q", false, "Reduce output to only errors.")
 	pflags.CountVarP(&commonFlags.Verbose, "verbose", "v", "Increase logging verbosity (stackable).")
 	pflags.BoolVar(&commonFlags.Debug, "debug", false, "Enable debug logging (equivalent to -vv).")
 	pflags.BoolVar(&commonFlags.Trace, "trace", false, "Enable trace logging (overrides other levels).")
 	pflags.BoolVar(&commonFlags.JSON, "json", false, "Output machine-readable JSON.")
 	pflags.BoolVar(&commonFlags.YAML, "yaml", false, "Output machine-readable YAML.")
+	pflags.BoolVar(&commonFlags.Porcelain, "porcelain", false, "Output only stable, line-oriented identifiers for scripting.")
 	pflags.StringVar(&commonFlags.LogFormat, "log-format", "auto", "Log output format: auto, text, or json (auto = json when stderr is not a terminal).")
 	pflags.BoolVar(&commonFlags.NoColor, "no-color", false, "Disable ANSI colors in output.")
-	pflags.StringVar(&commonFlags.Color, "color", "auto", "Color output policy: auto, always, or never.")
+	pflags.StringVar(&commonFlags.Color, "color", "auto", "Color output policy: auto, always, or never (auto = no color inside a container).")
+	pflags.BoolVar(&commonFlags.ASCII, "ascii", false, "Use plain ASCII instead of Unicode glyphs, spinners, and box drawing.")
+	pflags.StringVar(&commonFlags.Lang, "lang", "", "Language for user-facing messages (defaults to LC_ALL, LC_MESSAGES, or LANG).")
 	pflags.BoolVar(&commonFlags.DryRun, "dry-run", false, "Do not change anything on disk.")
 	pflags.BoolVarP(&commonFlags.AssumeYes, "yes", "y", false, "Assume yes for interactive prompts (alias for --force).")
+	pflags.BoolVar(&commonFlags.NoOnboarding, "no-onboarding", false, "Skip the guided setup offered on the first interactive run.")
+	pflags.BoolVar(&commonFlags.NonInteractive, "non-interactive", false, "Fail instead of prompting (implied when CI is set or stdin is not a terminal); combine with --yes to accept the defaults.")
 	pflags.BoolVar(&commonFlags.NoProgress, "no-progress", false, "Disable progress indicators.")
+	pflags.BoolVar(&commonFlags.Copy, "copy", false, "Also put the primary output (config path, run ID, token) on the clipboard.")
+	pflags.BoolVar(&commonFlags.WaitLock, "wait", false, "Wait for another running instance to finish instead of failing.")
+	pflags.BoolVar(&commonFlags.NoLock, "no-lock", false, "Skip the single-instance lock (unsafe with concurrent runs).")
 	pflags.BoolVar(&commonFlags.Diagnostics, "diagnostics", false, "Emit additional diagnostics for troubleshooting.")
-	pflags.IntVar(&timeoutFlag, "timeout", 0, "Maximum seconds to allow an operation to run.")
-	pflags.IntVar(&parallelFlag, "parallel", 0, "Override the degree of parallelism.")
+	pflags.Var(&timeoutFlag, "timeout", "Maximum time an operation may run, such as 90s or 2m30s; a bare number is seconds.")
+	pflags.Var(&parallelFlag, "parallel", "Override the number of workers: a count, a share of the CPUs such as 50%, or auto.")
+
+	groupFlags(pflags, "Output", "json", "yaml", "porcelain", "color", "no-color", "ascii", "lang", "no-progress", "copy")
+	groupFlags(pflags, "Logging", "quiet", "verbose", "debug", "trace", "log-format", "diagnostics")
+	groupFlags(pflags, "Configuration", "chdir", "config", "set")
+	groupFlags(pflags, "Behavior", "dry-run", "yes", "non-interactive", "no-onboarding", "timeout", "parallel", "wait", "no-lock")
+
+	// --dry-run and --yes stay combinable: a dry run never prompts, so a
+	// scripted command can be previewed without dropping its --yes.
+	markExclusive(rootCmd, "json", "yaml", "porcelain")
+	markExclusive(rootCmd, "quiet", "verbose")
+	markExclusive(rootCmd, "quiet", "debug")
+	markExclusive(rootCmd, "quiet", "trace")
+	markExclusive(rootCmd, "color", "no-color")
+
+	_ = rootCmd.RegisterFlagCompletionFunc("set", completeConfigKeys)
+	_ = rootCmd.RegisterFlagCompletionFunc("parallel", cobra.FixedCompletions([]string{app.ParallelismAuto, "50%"}, cobra.ShellCompDirectiveNoFileComp))
+	_ = rootCmd.MarkPersistentFlagDirname("chdir")
+
+	rootCmd.SetVersionTemplate("{{.Name}} {{.Version}}\n")
+	rootCmd.SetUsageTemplate(usageTemplate)
+	rootCmd.SetFlagErrorFunc(func(_ *cobra.Command, err error) error {
+		return app.UsageError(err)
+	})
 
-	rootCmd.AddCommand(newRunCommand())
-	rootCmd.AddCommand(newInitCommand())
+	rootCmd.AddCommand(copying(locking(newRunCommand())))
+	rootCmd.AddCommand(newTaskCommand())
+	rootCmd.AddCommand(scripted(locking(newInitCommand())))
 	rootCmd.AddCommand(newConfigCommand())
-	rootCmd.AddCommand(newCompletionsCommand())
+	rootCmd.AddCommand(newProfileCommand())
+	rootCmd.AddCommand(newScheduleCommand())
+	rootCmd.AddCommand(newHistoryCommand())
+	rootCmd.AddCommand(newRunsCommand())
+	rootCmd.AddCommand(newCacheCommand())
+	rootCmd.AddCommand(newStateCommand())
+	rootCmd.AddCommand(locking(newPruneCommand()))
+	rootCmd.AddCommand(newDaemonCommand())
+	rootCmd.AddCommand(newServiceCommand())
+	rootCmd.AddCommand(newServeCommand())
+	rootCmd.AddCommand(newAuthCommand())
+	rootCmd.AddCommand(newTUICommand())
+	rootCmd.AddCommand(scripted(newCompletionsCommand()))
+	rootCmd.AddCommand(scripted(newShellInitCommand()))
+	rootCmd.AddCommand(scripted(newCdCommand()))
+	rootCmd.AddCommand(scripted(newVersionCommand()))
+	rootCmd.AddCommand(newChangelogCommand())
+	rootCmd.AddCommand(newInfoCommand())
+	rootCmd.AddCommand(newLicensesCommand())
+	rootCmd.AddCommand(newEnvCommand())
+	rootCmd.AddCommand(newBugReportCommand())
+	rootCmd.AddCommand(newExplainCommand())
+	rootCmd.AddCommand(newExamplesCommand())
+	rootCmd.AddCommand(scripted(newUninstallCommand()))
+	rootCmd.AddCommand(newMigrateCommand())
+	rootCmd.AddCommand(scripted(newDocsCommand()))
+	rootCmd.AddCommand(newShellCommand())
+	rootCmd.AddCommand(newAliasCommand())
+	rootCmd.AddCommand(newPluginCommand())
+	rootCmd.AddCommand(newGenerateCommand())
+	rootCmd.AddCommand(generatedCommands()...)
+
+	applyDeprecations(rootCmd, deprecations)
+	noteExclusiveFlags(rootCmd)
+	return rootCmd
+}
+
+// lockAnnotation marks commands that change state. They take the
+// single-instance lock before running; see RuntimeContext.AcquireLock.
+const lockAnnotation = "go-cli/lock"
+
+// locking marks cmd as a state-changing command and returns it.
+func locking(cmd *cobra.Command) *cobra.Command {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[lockAnnotation] = "true"
+	return cmd
+}
+
+// scriptedAnnotation marks commands whose output is usually captured or
+// that set things up themselves; the first-run setup is not offered
+// before them.
+const scriptedAnnotation = "go-cli/scripted"
+
+// scripted marks cmd as scripted and returns it.
+func scripted(cmd *cobra.Command) *cobra.Command {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[scriptedAnnotation] = "true"
+	return cmd
+}
+
+// copyAnnotation marks commands with a primary output worth pasting
+// elsewhere; --copy is refused on the others. See RuntimeContext.CopyPrimary.
+const copyAnnotation = "go-cli/copy"
+
+// copying marks cmd as supporting --copy and returns it.
+func copying(cmd *cobra.Command) *cobra.Command {
+	if cmd.Annotations == nil {
+		cmd.Annotations = map[string]string{}
+	}
+	cmd.Annotations[copyAnnotation] = "true"
+	return cmd
 }
 
-// Execute runs the CLI.
+// Execute runs the CLI, expanding a configured alias first and falling
+// back to a plugin for an unknown command. SIGINT/SIGTERM cancel the
+// command context so running work can stop cleanly and persist its state.
 func Execute() error {
-	return rootCmd.Execute()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	args := os.Args[1:]
+	if len(args) > 0 && strings.HasPrefix(args[0], cobra.ShellCompRequestCmd) {
+		// The last word is the one being completed, perhaps a -C value
+		// still being typed; a bad directory only costs the candidates.
+		_ = enterDirs(rootCmd, args[:len(args)-1])
+	} else if err := enterDirs(rootCmd, args); err != nil {
+		return err
+	}
+	args, err := expandAliases(rootCmd, app.LoadAliases(configOverride(args)), args)
+	if err != nil {
+		return err
+	}
+	args = pickArgs(rootCmd, pluginArgs(rootCmd, args))
+	markRenamed(rootCmd, args)
+	rootCmd.SetArgs(args)
+	cmd, err := rootCmd.ExecuteContextC(ctx)
+	if rtx, ok := app.FromContext(cmd.Context()); ok {
+		rtx.EndCommandSpan(err)
+		if cerr := rtx.Close(); err == nil {
+			err = cerr
+		}
+	}
+	return err
 }
 
 // Context extracts the runtime context from a command.
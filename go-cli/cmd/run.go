@@ -1,36 +1,156 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 
 	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/tasks"
 )
 
 func newRunCommand() *cobra.Command {
-	opts := app.RunOptions{
-		Task: "default",
-	}
+	var opts app.RunOptions
+	var list, watchMode, stdin bool
+	var params []string
+	var chaos string
+	var notify bool
+	var on, reports []string
 
 	cmd := &cobra.Command{
-		Use:   "run [TASK]",
-		Short: "Execute the CLI's primary behavior.",
-		Long:  "Runs the template's core workflow. Specify an optional task name and override the active profile if desired.",
-		Args:  cobra.MaximumNArgs(1),
+		Use:               "run [TASK]",
+		Short:             "Execute the CLI's primary behavior.",
+		Long:              "Runs a registered task. Without a TASK, a fuzzy picker over the tasks opens on a terminal; under --yes the \"default\" task runs, and in non-interactive mode (--non-interactive, CI, or stdin not a terminal) it fails with the list of tasks. --profile without a value picks the profile the same way. Use --list to see the available tasks.\n\nWith --stdin, jobs are read from standard input instead, one per line: a shell command, or an NDJSON object such as {\"task\": \"lint\"} or {\"name\": \"a\", \"cmd\": \"make a\", \"dir\": \"sub\", \"env\": [\"K=V\"]}. They start as soon as a worker is free.",
+		Example:           "  go-cli run deploy --param env=staging --param replicas=3\n  go-cli run restart --on web,db1\n  go-cli run ci --report junit=reports/tasks.xml\n  generate-jobs | go-cli run --stdin --parallel 8",
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeTasks,
 		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+
+			registry, err := taskRegistry(ctx)
+			if err != nil {
+				return err
+			}
+
+			if list {
+				return app.HandleTaskList(ctx, registry.Infos())
+			}
+			if opts.Profile == pickValue {
+				if opts.Profile, err = app.PickProfile(ctx); err != nil {
+					return err
+				}
+			}
+
+			opts.Flags = changedFlags(cmd)
+			if opts.Reports, err = app.ParseReports(reports); err != nil {
+				return app.UsageError(err)
+			}
+			if cmd.Flags().Changed("notify") {
+				opts.Notify = &notify
+			}
+
+			if !cmd.Flags().Changed("chaos") {
+				chaos = os.Getenv(app.EnvPrefix() + "_CHAOS")
+			}
+			if chaos != "" {
+				if opts.Chaos, err = app.ParseChaos(chaos); err != nil {
+					return app.UsageError(err)
+				}
+			}
+
+			if stdin {
+				if len(args) > 0 || watchMode || opts.Resume || opts.FromScratch || opts.Plan || ctx.Common.DryRun || len(params) > 0 || len(on) > 0 {
+					return app.UsageError(fmt.Errorf("--stdin cannot be combined with a TASK argument, --watch, --resume, --from-scratch, --plan, --dry-run, --param, or --on"))
+				}
+				opts.Task = "stdin"
+				opts.Stream = cmd.InOrStdin()
+				opts.StreamJob = streamJobs(registry)
+				return app.HandleRun(ctx, opts)
+			}
+
 			if len(args) > 0 {
 				opts.Task = args[0]
+			} else if opts.Task, err = app.PickTask(ctx, registry.Infos()); err != nil {
+				return err
 			}
-
-			ctx, err := Context(cmd)
+			resolved, err := registry.Resolve(opts.Task)
+			if err != nil {
+				return err
+			}
+			schema, err := tasks.ParamSchema(resolved)
+			if err != nil {
+				return err
+			}
+			if opts.Params, err = app.ResolveParams(opts.Task, schema, params); err != nil {
+				return err
+			}
+			format, err := tasks.InputFormat(resolved)
 			if err != nil {
 				return err
 			}
+			if opts.Input, err = app.ReadInput(ctx, cmd.InOrStdin(), format); err != nil {
+				return err
+			}
+			if len(on) > 0 {
+				remotes, err := ctx.Config.SelectRemotes(on)
+				if err != nil {
+					return err
+				}
+				if opts.Jobs, err = tasks.RemoteJobs(ctx, opts.Task, resolved, remotes); err != nil {
+					return err
+				}
+				opts.KeepGoing = true
+			} else {
+				opts.Jobs = tasks.Jobs(ctx, resolved)
+			}
 
+			if watchMode {
+				return app.HandleWatch(ctx, opts, nil)
+			}
 			return app.HandleRun(ctx, opts)
 		},
 	}
 
-	cmd.Flags().StringVar(&opts.Profile, "profile", "", "Override the profile to run under.")
+	cmd.Flags().StringVar(&opts.Profile, "profile", "", "Override the profile to run under; without a value, pick one interactively.")
+	_ = cmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+	pickable(cmd, "profile")
+	cmd.Flags().StringArrayVar(&params, "param", nil, "Set a task parameter as NAME=VALUE (repeatable); see --list for the parameters each task declares.")
+	cmd.Flags().StringSliceVar(&on, "on", nil, "Run the task on these [remotes] over SSH instead of locally: remote names or tags, comma-separated or repeated.")
+	_ = cmd.RegisterFlagCompletionFunc("on", completeRemotes)
+	cmd.Flags().BoolVar(&list, "list", false, "List registered tasks with their descriptions.")
+	cmd.Flags().BoolVar(&opts.Resume, "resume", false, "Skip tasks completed by a previous interrupted run.")
+	cmd.Flags().BoolVar(&watchMode, "watch", false, "Re-run the task whenever files matching [watch] paths change.")
+	cmd.Flags().BoolVar(&opts.Plan, "plan", false, "Print the ordered actions the run would take without executing (same as --dry-run).")
+	cmd.Flags().StringArrayVar(&reports, "report", nil, "Write the task results to a file as FORMAT=PATH (repeatable); junit writes JUnit XML for CI test reports.")
+	cmd.Flags().BoolVar(&opts.Stats, "stats", false, "Print per-task timings, retries, and worker utilization after the run.")
+	cmd.Flags().BoolVar(&stdin, "stdin", false, "Read job specs (NDJSON or one shell command per line) from stdin and run them as they arrive.")
+	cmd.Flags().BoolVar(&opts.FromScratch, "from-scratch", false, "Discard any checkpoint and run every task.")
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "Run tasks even if their declared inputs are unchanged since they last succeeded.")
+	cmd.Flags().BoolVar(&notify, "notify", false, "Show a desktop notification when the run finishes, if it took at least notifications.desktop_after (--notify=false turns off notifications.desktop).")
+	cmd.Flags().StringVar(&chaos, "chaos", "", "Fail or delay this percentage of task attempts at random, to test retries and failure handling (also GO_CLI_CHAOS).")
+	_ = cmd.Flags().MarkHidden("chaos")
+	cmd.Flags().StringToIntVar(&opts.Priority, "priority", nil, "Override task queue priorities, e.g. --priority test=10,lint=-1 (higher starts first).")
+	markExclusive(cmd, "resume", "from-scratch")
 
 	return cmd
 }
+
+// changedFlags lists the flags set on the command line as --name=value, for
+// the run history.
+func changedFlags(cmd *cobra.Command) []string {
+	var flags []string
+	cmd.Flags().Visit(func(f *pflag.Flag) {
+		flags = append(flags, fmt.Sprintf("--%s=%s", f.Name, f.Value))
+	})
+	return flags
+}
+
+func completeRemotes(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	override, _ := cmd.Flags().GetString("config")
+	return app.LoadRemoteSelectors(override), cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+)
+
+func newRunsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "runs",
+		Short:   "Manage the per-run artifacts directories in the data directory.",
+		Example: "  go-cli runs list\n  go-cli runs clean --keep 10",
+	}
+
+	cmd.AddCommand(newRunsListCommand())
+	cmd.AddCommand(locking(newRunsCleanCommand()))
+
+	return cmd
+}
+
+func newRunsListCommand() *cobra.Command {
+	limit := 20
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List run directories with their artifact count and size, newest first.",
+		Example: "  go-cli runs list\n  go-cli runs list --limit 5 --json",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleRunsList(ctx, limit)
+		},
+	}
+
+	cmd.Flags().IntVarP(&limit, "limit", "n", limit, "Maximum number of runs to show (0 = all).")
+
+	return cmd
+}
+
+func newRunsCleanCommand() *cobra.Command {
+	opts := app.RunsCleanOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "clean",
+		Short:   "Remove run directories.",
+		Long:    "Remove run directories. A run is removed only when it matches every selector given. Runs copied to [storage] are removed there too. It asks first, and without a terminal needs --yes.",
+		Example: "  go-cli runs clean --keep 10\n  go-cli runs clean --older-than 168h\n  go-cli runs clean --all --yes",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleRunsClean(ctx, opts)
+		},
+	}
+
+	cmd.Flags().IntVar(&opts.Keep, "keep", 0, "Keep the newest N runs.")
+	cmd.Flags().DurationVar(&opts.OlderThan, "older-than", 0, "Only remove runs started longer ago than this (e.g. 168h).")
+	cmd.Flags().BoolVar(&opts.All, "all", false, "Remove all runs.")
+
+	return cmd
+}
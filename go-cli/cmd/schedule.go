@@ -0,0 +1,111 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+)
+
+func newScheduleCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "schedule",
+		Short:   "Run tasks periodically on cron schedules.",
+		Example: "  go-cli schedule add @daily ci\n  go-cli schedule list\n  go-cli daemon start",
+	}
+
+	cmd.AddCommand(locking(newScheduleAddCommand()))
+	cmd.AddCommand(newScheduleListCommand())
+	cmd.AddCommand(locking(newScheduleRemoveCommand()))
+	cmd.AddCommand(newScheduleRunCommand())
+
+	return cmd
+}
+
+func newScheduleAddCommand() *cobra.Command {
+	opts := app.ScheduleAddOptions{}
+
+	cmd := &cobra.Command{
+		Use:     "add CRON TASK",
+		Short:   "Schedule a task with a five-field cron expression.",
+		Example: "  go-cli schedule add \"*/5 * * * *\" default\n  go-cli schedule add @daily ci --profile nightly",
+		Args:    cobra.ExactArgs(2),
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 1 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return completeTaskNames(cmd, args, toComplete)
+		},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			registry, err := taskRegistry(ctx)
+			if err != nil {
+				return err
+			}
+			if _, err := registry.Lookup(args[1]); err != nil {
+				return err
+			}
+			opts.Cron, opts.Task = args[0], args[1]
+			return app.HandleScheduleAdd(ctx, opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Profile, "profile", "", "Profile to run the task under.")
+	_ = cmd.RegisterFlagCompletionFunc("profile", completeProfiles)
+
+	return cmd
+}
+
+func newScheduleListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Short:   "List schedules and their next run time.",
+		Example: "  go-cli schedule list\n  go-cli schedule list --json",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleScheduleList(ctx)
+		},
+	}
+}
+
+func newScheduleRemoveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "remove ID",
+		Aliases: []string{"rm"},
+		Short:   "Delete a schedule.",
+		Example: "  go-cli schedule remove 3f9a1c2b",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleScheduleRemove(ctx, args[0])
+		},
+	}
+}
+
+func newScheduleRunCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "run",
+		Short:   "Run the scheduler in the foreground, triggering tasks as they come due.",
+		Example: "  go-cli schedule run",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			if _, err := taskRegistry(ctx); err != nil {
+				return err
+			}
+			return app.HandleScheduleRun(ctx, registryJobs)
+		},
+	}
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/buildinfo"
+)
+
+func newServeCommand() *cobra.Command {
+	opts := app.ServeOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve runs, status, and history over a local HTTP+JSON API.",
+		Long: `Starts an HTTP server so other services can drive the CLI:
+
+  GET  /healthz         liveness (no auth)
+  GET  /readyz          readiness (no auth); 503 while shutting down
+  POST /v1/runs         start a run: {"task": "deploy", "profile": "prod", "params": {"env": "staging"}}
+  GET  /v1/runs         recent runs from history, newest first (?limit=N)
+  GET  /v1/runs/{id}    one run: queued or running, else its history entry
+  GET  /v1/status       server state, active runs, and the last run
+  GET  /v1/config       effective settings, secrets redacted
+  GET  /openapi.json    OpenAPI 3 document of this API (no auth), for generating clients
+
+The address comes from serve.addr (default 127.0.0.1:8765) or --addr. When serve.token is set, the /v1 endpoints require "Authorization: Bearer <token>"; listening on a non-loopback address requires a token. Without a token they only answer requests whose Host is a loopback name or address. POST /v1/runs takes a body sent as Content-Type: application/json. Runs execute one at a time, in the order they were queued, under the instance lock. --openapi prints the OpenAPI document and exits.
+
+--grpc (or serve.grpc_addr) also serves the gRPC Control service of api/control/v1 (TriggerRun, GetStatus, StreamLogs, GetConfig) on host:port or a Unix socket given as unix:PATH. It shares the run queue and the token, sent as "authorization: Bearer <token>" metadata.`,
+		Example: "  go-cli serve\n  GO_CLI_SERVE__TOKEN=s3cret go-cli serve --addr 0.0.0.0:8765\n  curl -X POST -H 'Authorization: Bearer s3cret' -H 'Content-Type: application/json' -d '{\"task\":\"default\"}' http://127.0.0.1:8765/v1/runs\n  go-cli serve --grpc unix:$XDG_RUNTIME_DIR/go-cli.sock\n  go-cli serve --openapi > openapi.json",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			opts.Version = buildinfo.Get().Version
+			if opts.OpenAPI {
+				return app.HandleServe(ctx, opts)
+			}
+			// Fail at startup rather than on the first request when the
+			// task file is broken.
+			if _, err := taskRegistry(ctx); err != nil {
+				return err
+			}
+			opts.Resolve = registryRuns
+			return app.HandleServe(ctx, opts)
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Addr, "addr", "", "Listen on this host:port instead of serve.addr.")
+	cmd.Flags().BoolVar(&opts.OpenAPI, "openapi", false, "Print the OpenAPI 3 document of the HTTP API and exit, e.g. to generate a client.")
+	cmd.Flags().StringVar(&opts.GRPCAddr, "grpc", "", "Also serve the gRPC control API on host:port or unix:PATH (default: serve.grpc_addr).")
+
+	return cmd
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+)
+
+func newServiceCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "service",
+		Short:   "Run the daemon under the system's service manager.",
+		Example: "  go-cli service install\n  go-cli service start\n  go-cli service uninstall",
+		Long:    "Installs, starts, stops, and removes a unit that runs the daemon under systemd (Linux), launchd (macOS), or the Windows service control manager. Without --systemd, --launchd, or --windows the platform's manager is used.",
+	}
+
+	cmd.AddCommand(newServiceInstallCommand())
+	cmd.AddCommand(newServiceActionCommand("start", "Start the installed unit.", "", "  go-cli service start\n  go-cli service start --system", app.HandleServiceStart))
+	cmd.AddCommand(newServiceActionCommand("stop", "Stop the installed unit.", "", "  go-cli service stop", app.HandleServiceStop))
+	cmd.AddCommand(newServiceActionCommand("uninstall", "Stop and remove the unit written by install.",
+		"Stops and unregisters the unit (systemctl disable --now, launchctl bootout, or the service control manager) and removes it. For systemd and launchd, stopping is best effort, so a unit that was never started is removed all the same.",
+		"  go-cli service uninstall\n  go-cli service uninstall --systemd --system",
+		app.HandleServiceUninstall))
+
+	return cmd
+}
+
+// addServiceTargetFlags registers the flags that select the service
+// manager and scope.
+func addServiceTargetFlags(flags *pflag.FlagSet, target *app.ServiceTarget) {
+	flags.BoolVar(&target.Systemd, "systemd", false, "Use systemd.")
+	flags.BoolVar(&target.Launchd, "launchd", false, "Use launchd.")
+	flags.BoolVar(&target.Windows, "windows", false, "Use the Windows service control manager.")
+	flags.BoolVar(&target.System, "system", false, "Use the system unit (LaunchDaemon) instead of the user unit (LaunchAgent).")
+}
+
+func newServiceInstallCommand() *cobra.Command {
+	opts := app.ServiceInstallOptions{}
+
+	cmd := &cobra.Command{
+		Use:   "install",
+		Short: "Register the daemon with the service manager.",
+		Long: "Registers a unit that runs `daemon start --foreground` with this binary, config file, working directory, and data and state directories.\n\n" +
+			"systemd: a user unit in ~/.config/systemd/user, or with --system one in /etc/systemd/system. The unit has Type=notify: the daemon reports READY once its control socket is up and, with --watchdog, pings systemd's watchdog while the socket answers, so a hung daemon is restarted. --hardening basic (the default) adds sandboxing that leaves the filesystem writable outside /usr, /boot, and /etc; strict makes everything read-only except the data, state, cache, and working directories.\n\n" +
+			"launchd: a LaunchAgent in ~/Library/LaunchAgents, or with --system a LaunchDaemon in /Library/LaunchDaemons. It starts at load, is restarted when it fails, and logs to <state>/daemon.log, where `daemon logs` finds it.\n\n" +
+			"Windows: a service that starts with the system, runs as LocalSystem or the --run-as account, is restarted when it fails, and logs to the Application event log. Needs an elevated prompt.",
+		Example: "  go-cli service install\n" +
+			"  go-cli service install --systemd --system --run-as builder --hardening strict\n" +
+			"  go-cli service install --launchd --output - | less",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleServiceInstall(ctx, opts)
+		},
+	}
+
+	addServiceTargetFlags(cmd.Flags(), &opts.ServiceTarget)
+	cmd.Flags().StringVar(&opts.RunAs, "run-as", "", "User a system unit or Windows service runs as.")
+	cmd.Flags().StringVar(&opts.Hardening, "hardening", "basic", "systemd sandboxing options: "+strings.Join(app.ServiceHardeningLevels, ", ")+".")
+	cmd.Flags().DurationVar(&opts.Watchdog, "watchdog", app.DefaultServiceWatchdog, "Have systemd restart the daemon when it stops answering for this long (0 to disable).")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", "", "Write the unit to this path instead (- prints it).")
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "Replace an existing unit.")
+	_ = cmd.RegisterFlagCompletionFunc("hardening", cobra.FixedCompletions(app.ServiceHardeningLevels, cobra.ShellCompDirectiveNoFileComp))
+
+	return cmd
+}
+
+func newServiceActionCommand(use, short, long, example string, handle func(*app.RuntimeContext, app.ServiceTarget) error) *cobra.Command {
+	target := app.ServiceTarget{}
+
+	cmd := &cobra.Command{
+		Use:     use,
+		Short:   short,
+		Long:    long,
+		Example: example,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return handle(ctx, target)
+		},
+	}
+	addServiceTargetFlags(cmd.Flags(), &target)
+
+	return cmd
+}
@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/chzyer/readline"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+)
+
+func newShellCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "shell",
+		Short:   "Start an interactive prompt that runs commands without reloading the config.",
+		Example: "  go-cli shell",
+		Long:    "Starts a prompt where each line is a go-cli command without the program name, e.g. \"run ci --json\". The config is loaded once for the session; per-command flags such as --json, --dry-run, and --timeout apply to their line. Tab completes subcommands and flags, and history is kept in the state directory. Leave with exit, quit, or Ctrl-D.",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return runShell(ctx, cmd.Root())
+		},
+	}
+}
+
+func runShell(ctx *app.RuntimeContext, root *cobra.Command) error {
+	historyFile := app.ShellHistoryPath(ctx.Paths.StateDir)
+	if ctx.Common.DryRun {
+		historyFile = ""
+	}
+	rl, err := readline.NewEx(&readline.Config{
+		Prompt:          root.Name() + "> ",
+		HistoryFile:     historyFile,
+		HistoryLimit:    1000,
+		AutoComplete:    completer{root: root, aliases: ctx.Config.Aliases},
+		InterruptPrompt: "^C",
+		EOFPrompt:       "exit",
+	})
+	if err != nil {
+		return fmt.Errorf("start shell: %w", err)
+	}
+	defer rl.Close()
+
+	// Ctrl-C stops the command in progress, not the shell, so lines run on
+	// a context detached from the one the first signal cancels.
+	session := context.WithoutCancel(ctx.Context)
+	for {
+		line, err := rl.Readline()
+		if errors.Is(err, readline.ErrInterrupt) {
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		args, err := app.SplitArgs(line)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+		switch args[0] {
+		case "exit", "quit":
+			return nil
+		case "shell":
+			fmt.Fprintln(os.Stderr, "error: already in the shell")
+			continue
+		}
+
+		if err := runShellLine(session, ctx.Config.Aliases, args); err != nil {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		}
+	}
+}
+
+// runShellLine executes one command on a fresh command tree, so flags set
+// by earlier lines do not leak into it.
+func runShellLine(session context.Context, aliases map[string]string, args []string) error {
+	lineCtx, stop := signal.NotifyContext(session, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	root := newRootCommand()
+	// The session's config and tasks were loaded from the directory the
+	// shell started in.
+	if len(chdirDirs(root, args)) > 0 {
+		return fmt.Errorf("--chdir applies to the whole session; start the shell with %s -C DIR shell", root.Name())
+	}
+	chdirApplied = 0
+	args, err := expandAliases(root, aliases, args)
+	if err != nil {
+		return err
+	}
+	args = pickArgs(root, pluginArgs(root, args))
+	markRenamed(root, args)
+	root.SetArgs(args)
+	cmd, err := root.ExecuteContextC(lineCtx)
+	if rtx, ok := app.FromContext(cmd.Context()); ok {
+		if lerr := rtx.ReleaseLock(); err == nil {
+			err = lerr
+		}
+	}
+	return err
+}
+
+// completer completes subcommand names, aliases, and flags from the
+// command tree.
+type completer struct {
+	root    *cobra.Command
+	aliases map[string]string
+}
+
+// Do implements readline.AutoCompleter: it returns the suffixes that
+// complete the word before the cursor, and that word's length.
+func (c completer) Do(line []rune, pos int) ([][]rune, int) {
+	before := string(line[:pos])
+	args, err := app.SplitArgs(before)
+	if err != nil {
+		return nil, 0
+	}
+	prefix := ""
+	if len(args) > 0 && !strings.HasSuffix(before, " ") {
+		prefix, args = args[len(args)-1], args[:len(args)-1]
+	}
+
+	cmd, _, err := c.root.Find(args)
+	if err != nil {
+		cmd = c.root
+	}
+
+	var candidates []string
+	if strings.HasPrefix(prefix, "-") {
+		add := func(f *pflag.Flag) {
+			if !f.Hidden {
+				candidates = append(candidates, "--"+f.Name)
+			}
+		}
+		cmd.NonInheritedFlags().VisitAll(add)
+		cmd.InheritedFlags().VisitAll(add)
+	} else {
+		for _, sub := range cmd.Commands() {
+			if sub.IsAvailableCommand() && sub.Name() != "shell" {
+				candidates = append(candidates, sub.Name())
+			}
+		}
+		if cmd == c.root {
+			candidates = append(candidates, "exit", "quit")
+			for name := range c.aliases {
+				if !slices.Contains(candidates, name) {
+					candidates = append(candidates, name)
+				}
+			}
+		}
+	}
+	sort.Strings(candidates)
+
+	var suffixes [][]rune
+	for _, candidate := range candidates {
+		if rest, ok := strings.CutPrefix(candidate, prefix); ok {
+			suffixes = append(suffixes, []rune(rest+" "))
+		}
+	}
+	return suffixes, len([]rune(prefix))
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+)
+
+func newShellInitCommand() *cobra.Command {
+	var fn string
+
+	cmd := &cobra.Command{
+		Use:   "shell-init SHELL",
+		Short: "Print shell integration to eval in your shell's rc file.",
+		Long: "Prints a snippet for " + strings.Join(app.ShellInitShells, ", ") + " that loads completions, exports GO_CLI_SHELL and GO_CLI_BIN, and defines a wrapper function that runs go-cli and changes directory when a command asks it to, as `go-cli cd` does.\n\n" +
+			"Add it to your rc file, e.g. eval \"$(go-cli shell-init bash)\" in ~/.bashrc or go-cli shell-init fish | source in config.fish.",
+		Example:   "  eval \"$(go-cli shell-init zsh)\"\n  eval \"$(go-cli shell-init bash --cmd gc)\"",
+		Args:      cobra.ExactArgs(1),
+		ValidArgs: app.ShellInitShells,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			binary, err := os.Executable()
+			if err != nil {
+				binary = cmd.Root().Name()
+			}
+			return app.HandleShellInit(ctx, app.ShellInitOptions{
+				Shell:  args[0],
+				Binary: binary,
+				Name:   cmd.Root().Name(),
+				Cmd:    fn,
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&fn, "cmd", "", "Name of the wrapper function (default: the program name).")
+
+	return cmd
+}
+
+func newCdCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:       "cd [config|data|state|cache]",
+		Short:     "Change into an application directory (prints it without shell-init).",
+		Long:      "Under the shell-init wrapper the shell changes into the directory (default: data) after the command exits. Without it the directory is printed, so cd \"$(go-cli cd state)\" works too.",
+		Example:   "  go-cli cd\n  go-cli cd state",
+		Args:      cobra.MaximumNArgs(1),
+		ValidArgs: []string{"config", "data", "state", "cache"},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			var name string
+			if len(args) == 1 {
+				name = args[0]
+			}
+			return app.HandleCd(ctx, name)
+		},
+	}
+}
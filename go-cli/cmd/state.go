@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+)
+
+func newStateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "state",
+		Short:   "Inspect and prune what the CLI keeps in the state directory.",
+		Example: "  go-cli state ls\n  go-cli state prune --older-than 7d",
+	}
+
+	cmd.AddCommand(newStateListCommand())
+	cmd.AddCommand(newStateShowCommand())
+	cmd.AddCommand(locking(newStatePruneCommand()))
+
+	return cmd
+}
+
+func newStateListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "List history, checkpoints, task logs, locks, and other state with their sizes.",
+		Example: "  go-cli state ls\n  go-cli state ls --json",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleStateList(ctx)
+		},
+	}
+}
+
+func newStateShowCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "show KEY",
+		Short:   "Print a state file, or list a state directory, by its key from state ls.",
+		Example: "  go-cli state show inputs.json\n  go-cli state show logs --json",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleStateShow(ctx, args[0])
+		},
+	}
+}
+
+func newStatePruneCommand() *cobra.Command {
+	var olderThan app.Duration
+
+	cmd := &cobra.Command{
+		Use:     "prune",
+		Short:   "Remove old history entries, checkpoints, task logs, and input fingerprints.",
+		Long:    "Remove history entries, checkpoints, per-run task logs, and input fingerprints older than --older-than (default 30d). The lock, schedules, daemon files, and audit log are kept. It asks first, and without a terminal needs --yes.",
+		Example: "  go-cli state prune\n  go-cli state prune --older-than 7d --dry-run",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleStatePrune(ctx, app.StatePruneOptions{OlderThan: olderThan.Std()})
+		},
+	}
+
+	cmd.Flags().Var(&olderThan, "older-than", "Remove state last updated longer ago than this (default 30d).")
+
+	return cmd
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/tasks"
+)
+
+func newTaskCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "task",
+		Short:   "Inspect registered tasks.",
+		Example: "  go-cli task list\n  go-cli task describe ci",
+	}
+
+	cmd.AddCommand(newTaskListCommand())
+	cmd.AddCommand(newTaskDescribeCommand())
+
+	return cmd
+}
+
+func newTaskListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List tasks with their dependencies, parameters, timeout, and last run.",
+		Example: "  go-cli task list\n  go-cli task list --json",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			registry, err := taskRegistry(ctx)
+			if err != nil {
+				return err
+			}
+			return app.HandleTaskDetails(ctx, registry.Infos())
+		},
+	}
+}
+
+func newTaskDescribeCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:               "describe NAME",
+		Short:             "Show a task's description, parameters, dependencies, timeout, and last run.",
+		Example:           "  go-cli task describe ci\n  go-cli task describe deploy --yaml",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeTasks,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			registry, err := taskRegistry(ctx)
+			if err != nil {
+				return err
+			}
+			task, err := registry.Lookup(args[0])
+			if err != nil {
+				return app.UsageError(err)
+			}
+			return app.HandleTaskDescribe(ctx, tasks.Info(task))
+		},
+	}
+}
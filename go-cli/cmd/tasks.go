@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/runner"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/tasks"
+)
+
+// taskRegistry returns a copy of the default registry extended with the
+// tasks declared in ctx's config and task file. Commands that resolve task
+// names use it instead of tasks.Default so declared tasks behave like
+// built-ins.
+func taskRegistry(ctx *app.RuntimeContext) (*tasks.Registry, error) {
+	specs, err := app.DeclaredTasks(ctx)
+	if err != nil {
+		return nil, err
+	}
+	registry := tasks.Default.Clone()
+	tasks.RegisterDeclared(registry, specs)
+	return registry, nil
+}
+
+// registryJobs is the app.JobsFunc of handlers that resolve tasks by name
+// while running, such as the scheduler. It builds the registry from rtx
+// each time, so a resident instance runs the tasks of the config that
+// `daemon reload` last loaded and of the task file as it is now.
+func registryJobs(rtx *app.RuntimeContext, task string) ([]runner.Job, error) {
+	registry, err := taskRegistry(rtx)
+	if err != nil {
+		return nil, err
+	}
+	resolved, err := registry.Resolve(task)
+	if err != nil {
+		return nil, err
+	}
+	return tasks.Jobs(rtx, resolved), nil
+}
+
+// streamJobs builds jobs for `run --stdin`: specs naming a task resolve it
+// in registry, others become command tasks.
+func streamJobs(registry *tasks.Registry) app.StreamJobFunc {
+	return func(rtx *app.RuntimeContext, spec app.StreamSpec) (runner.Job, error) {
+		if spec.Task == "" {
+			return tasks.Job(rtx, tasks.Command{TaskName: spec.Name, Spec: spec.TaskSpec()}), nil
+		}
+		task, err := registry.Lookup(spec.Task)
+		if err != nil {
+			return runner.Job{}, err
+		}
+		job := tasks.Job(rtx, task)
+		job.Name = spec.Name
+		return job, nil
+	}
+}
+
+// registryRuns resolves run requests for `serve` the way `run` resolves
+// its arguments: the task, then its --param values. Like registryJobs, it
+// builds the registry from rtx for each request. An unknown task is the
+// client's mistake, so it is reported as a usage error.
+func registryRuns(rtx *app.RuntimeContext, task string, params []string) (app.RunOptions, error) {
+	registry, err := taskRegistry(rtx)
+	if err != nil {
+		return app.RunOptions{}, err
+	}
+	resolved, err := registry.Resolve(task)
+	if err != nil {
+		return app.RunOptions{}, app.UsageError(err)
+	}
+	schema, err := tasks.ParamSchema(resolved)
+	if err != nil {
+		return app.RunOptions{}, err
+	}
+	values, err := app.ResolveParams(task, schema, params)
+	if err != nil {
+		return app.RunOptions{}, err
+	}
+	return app.RunOptions{Task: task, Params: values, Jobs: tasks.Jobs(rtx, resolved)}, nil
+}
+
+// completeTasks completes the first argument with the registered tasks,
+// including those declared in the config and task file.
+func completeTasks(cmd *cobra.Command, args []string, _ string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	return completeTaskNames(cmd, args, "")
+}
+
+// completeTaskNames completes a task name wherever it appears, e.g. as the
+// value of a flag.
+func completeTaskNames(cmd *cobra.Command, _ []string, _ string) ([]string, cobra.ShellCompDirective) {
+	ctx, err := Context(cmd)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	registry, err := taskRegistry(ctx)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var names []string
+	for _, info := range registry.Infos() {
+		names = append(names, cobra.CompletionWithDesc(info.Name, info.Description))
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
@@ -0,0 +1,37 @@
+package cmd
+
+import (
+	"testing"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app/apptest"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/tasks"
+)
+
+// TestTaskRegistryPerContext checks that declared tasks come from the
+// context's config alone: they neither leak into tasks.Default nor into
+// a registry built for another config.
+func TestTaskRegistryPerContext(t *testing.T) {
+	first := apptest.NewTestContext(t, apptest.WithConfig("[tasks.first]\ncmds = [\"true\"]\n"))
+	registry, err := taskRegistry(first.RuntimeContext)
+	if err != nil {
+		t.Fatalf("taskRegistry: %v", err)
+	}
+	if _, err := registry.Lookup("first"); err != nil {
+		t.Errorf("declared task missing: %v", err)
+	}
+	if _, err := tasks.Default.Lookup("first"); err == nil {
+		t.Error("declared task was added to tasks.Default")
+	}
+
+	second := apptest.NewTestContext(t, apptest.WithConfig("[tasks.second]\ncmds = [\"true\"]\n"))
+	registry, err = taskRegistry(second.RuntimeContext)
+	if err != nil {
+		t.Fatalf("taskRegistry: %v", err)
+	}
+	if _, err := registry.Lookup("second"); err != nil {
+		t.Errorf("task of the second config missing: %v", err)
+	}
+	if _, err := registry.Lookup("first"); err == nil {
+		t.Error("task of the first config leaked into the second registry")
+	}
+}
@@ -0,0 +1,31 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+)
+
+func newTUICommand() *cobra.Command {
+	return &cobra.Command{
+		Use:     "tui",
+		Short:   "Open an interactive dashboard of tasks, recent runs, and live progress.",
+		Example: "  go-cli tui",
+		Long:    "Shows the active profile and paths, the registered tasks, recent runs from history, the progress of a run started from the dashboard, and the log. Keys: tab switches between the task and history panes, j/k or the arrow keys select, enter runs the selected task (or reruns the selected history entry), l shows the task logs of the selected run, x interrupts the current run, esc goes back, and q quits. Runs take the instance lock like `run` does. Tasks that need --param values must be started with `run`.",
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			registry, err := taskRegistry(ctx)
+			if err != nil {
+				return err
+			}
+			return app.HandleTUI(ctx, app.TUIOptions{
+				Tasks:   registry.Infos(),
+				Resolve: registryRuns,
+			})
+		},
+	}
+}
@@ -0,0 +1,28 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+)
+
+func newUninstallCommand() *cobra.Command {
+	opts := app.UninstallOptions{}
+	cmd := &cobra.Command{
+		Use:   "uninstall",
+		Short: "Stop the daemon and remove installed completions and service units; --purge also removes all config and data.",
+		Long:  "Stops a running daemon and removes completion scripts installed in the usual bash, zsh, and fish locations and systemd units written by `service install`. With --purge it also removes the config, data, state, and cache directories. It asks you to type go-cli to confirm, and without a terminal needs --yes; --dry-run lists what would be removed. The binary itself is left for the package manager or the user to remove.",
+		Example: "  go-cli uninstall --purge --dry-run\n" +
+			"  go-cli uninstall --purge --yes",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			ctx, err := Context(cmd)
+			if err != nil {
+				return err
+			}
+			return app.HandleUninstall(ctx, opts)
+		},
+	}
+	cmd.Flags().BoolVar(&opts.Purge, "purge", false, "Also remove the config, data, state, and cache directories.")
+	return cmd
+}
@@ -0,0 +1,169 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// maxAliasDepth bounds how many aliases one expansion may pass through.
+const maxAliasDepth = 16
+
+// LoadAliases reads the [aliases] table from the config file ahead of
+// command parsing, without creating the file or applying env overrides.
+// A missing or unreadable config yields no aliases; loading it for the
+// command itself reports the error.
+func LoadAliases(configOverride string) map[string]string {
+	paths, err := DiscoverPaths(appName, configOverride)
+	if err != nil {
+		return nil
+	}
+	v := viper.New()
+	v.SetConfigFile(paths.ConfigFile)
+	v.SetConfigType("toml")
+	if err := v.ReadInConfig(); err != nil {
+		return nil
+	}
+	return v.GetStringMapString("aliases")
+}
+
+// ExpandAlias replaces args[i], an alias name, with the command line it
+// maps to, and repeats while the expansion starts with another alias. An
+// alias that leads back to itself is a usage error.
+func ExpandAlias(aliases map[string]string, args []string, i int) ([]string, error) {
+	var chain []string
+	for i < len(args) {
+		name := args[i]
+		line, ok := aliases[name]
+		if !ok {
+			break
+		}
+		chain = append(chain, name)
+		if slices.Contains(chain[:len(chain)-1], name) || len(chain) > maxAliasDepth {
+			return nil, UsageError(fmt.Errorf("alias loop: %s", strings.Join(chain, " -> ")))
+		}
+		words, err := SplitArgs(line)
+		if err != nil {
+			return nil, UsageError(fmt.Errorf("alias %q: %w", name, err))
+		}
+		args = slices.Concat(args[:i:i], words, args[i+1:])
+	}
+	return args, nil
+}
+
+// SplitArgs splits a command line into words like a POSIX shell:
+// whitespace separates words, quotes group them, and a backslash escapes
+// the next character outside single quotes.
+func SplitArgs(line string) ([]string, error) {
+	var args []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t':
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+	return args, nil
+}
+
+func validateAliases(aliases map[string]string) error {
+	for name, line := range aliases {
+		if name == "" || strings.HasPrefix(name, "-") || strings.ContainsAny(name, " \t") {
+			return fmt.Errorf("invalid alias name %q (expected a single word not starting with -)", name)
+		}
+		words, err := SplitArgs(line)
+		if err != nil {
+			return fmt.Errorf("invalid aliases.%s: %w", name, err)
+		}
+		if len(words) == 0 {
+			return fmt.Errorf("invalid aliases.%s (expected a command line)", name)
+		}
+	}
+	return nil
+}
+
+// Alias is one configured alias.
+type Alias struct {
+	Name    string `json:"name" yaml:"name"`
+	Command string `json:"command" yaml:"command"`
+	// Shadowed aliases share a built-in command's name and never expand.
+	Shadowed bool `json:"shadowed,omitempty" yaml:"shadowed,omitempty"`
+}
+
+// HandleAliasList prints the configured aliases. commands holds the names
+// of the built-in commands, which take precedence over aliases.
+func HandleAliasList(ctx *RuntimeContext, commands []string) error {
+	aliases := make([]Alias, 0, len(ctx.Config.Aliases))
+	for name, line := range ctx.Config.Aliases {
+		aliases = append(aliases, Alias{Name: name, Command: line, Shadowed: slices.Contains(commands, name)})
+	}
+	sort.Slice(aliases, func(i, j int) bool { return aliases[i].Name < aliases[j].Name })
+
+	switch {
+	case ctx.Common.JSON:
+		data, err := json.MarshalIndent(aliases, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+	case ctx.Common.YAML:
+		data, err := yaml.Marshal(aliases)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		for _, a := range aliases {
+			fmt.Fprintf(ctx.Out.Writer(), "%s\t%s\n", a.Name, a.Command)
+		}
+	default:
+		if len(aliases) == 0 {
+			ctx.Logger.Info("no aliases; add them under [aliases] in %s", ctx.Paths.ConfigFile)
+			return nil
+		}
+		rows := make([][]string, 0, len(aliases))
+		for _, a := range aliases {
+			command := a.Command
+			if a.Shadowed {
+				command += " " + ctx.Out.Dim(ctx.Out.Text(msgAliasShadowed))
+			}
+			rows = append(rows, []string{a.Name, command})
+		}
+		ctx.Out.Table("", headers(ctx.Out, msgColAlias, msgColCommand), rows)
+	}
+	return nil
+}
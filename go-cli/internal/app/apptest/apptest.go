@@ -0,0 +1,165 @@
+// Package apptest builds runtime contexts for handler tests. A test context
+// loads its config from a temporary directory instead of the user's, writes
+// output and log lines to buffers, and reads time from a fake clock, so
+// tests neither touch the real HOME or XDG directories nor depend on the
+// wall clock.
+//
+// The application does its file I/O through package os, so the
+// "filesystem" of a test context is a directory under t.TempDir: HOME and
+// the XDG base directories point into it for the duration of the test, and
+// it is removed afterwards. Because it sets environment variables, a test
+// using NewTestContext cannot call t.Parallel.
+package apptest
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/clock"
+)
+
+// Epoch is the time a test context's clock starts at unless WithTime sets
+// another.
+var Epoch = time.Date(2025, time.January, 2, 15, 4, 5, 0, time.UTC)
+
+// Context is a runtime context for a test, with the buffers and clock
+// behind it.
+type Context struct {
+	*app.RuntimeContext
+	// Stdout collects what handlers print through RuntimeContext.Out.
+	Stdout *bytes.Buffer
+	// Log collects the logger's lines, uncolored.
+	Log *bytes.Buffer
+	// Time is the fake clock behind RuntimeContext.Clock; Advance it to
+	// move time.
+	Time *clock.Fake
+	// Root is the temporary directory holding HOME and the XDG base
+	// directories.
+	Root string
+}
+
+type options struct {
+	config string
+	flags  app.CommonFlags
+	now    time.Time
+}
+
+// Option configures NewTestContext.
+type Option func(*options)
+
+// WithConfig writes body as the config file before it is loaded. Without
+// it the default config is created, as on a first run.
+func WithConfig(body string) Option {
+	return func(o *options) {
+		o.config = body
+	}
+}
+
+// WithFlags sets the global flags, e.g. JSON output or -v. ConfigPath is
+// ignored; the config always lives under Root. Messages are in English
+// unless Lang is set.
+func WithFlags(flags app.CommonFlags) Option {
+	return func(o *options) {
+		o.flags = flags
+	}
+}
+
+// WithTime starts the clock at now instead of Epoch.
+func WithTime(now time.Time) Option {
+	return func(o *options) {
+		o.now = now
+	}
+}
+
+// NewTestContext returns a runtime context whose paths lie under a fresh
+// temporary directory. Environment overrides of config keys (GO_CLI_*) are
+// cleared for the test, so the developer's environment does not leak in.
+func NewTestContext(t testing.TB, opts ...Option) *Context {
+	t.Helper()
+	o := options{now: Epoch}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	root := t.TempDir()
+	for env, dir := range map[string]string{
+		"HOME":            "home",
+		"XDG_CONFIG_HOME": "config",
+		"XDG_DATA_HOME":   "data",
+		"XDG_STATE_HOME":  "state",
+		"XDG_CACHE_HOME":  "cache",
+	} {
+		t.Setenv(env, filepath.Join(root, dir))
+	}
+	clearEnv(t, app.EnvPrefix()+"_")
+
+	flags := o.flags
+	flags.ConfigPath = ""
+	if flags.Lang == "" {
+		flags.Lang = "en"
+	}
+	if o.config != "" {
+		path := filepath.Join(root, "config.toml")
+		if err := os.WriteFile(path, []byte(o.config), 0o600); err != nil {
+			t.Fatalf("apptest: write config: %v", err)
+		}
+		flags.ConfigPath = path
+	}
+
+	// Loading logs to stderr before the buffer logger is in place; keep
+	// those lines for Log too.
+	early, err := os.Create(filepath.Join(root, "stderr.log"))
+	if err != nil {
+		t.Fatalf("apptest: %v", err)
+	}
+	stderr := os.Stderr
+	os.Stderr = early
+	rtx, err := app.NewRuntimeContext(context.Background(), flags)
+	os.Stderr = stderr
+	early.Close()
+	if err != nil {
+		t.Fatalf("apptest: %v", err)
+	}
+	// The context's own logger may also hold a log file open.
+	rtx.Logger.Close()
+
+	tc := &Context{
+		RuntimeContext: rtx,
+		Stdout:         &bytes.Buffer{},
+		Log:            &bytes.Buffer{},
+		Time:           clock.NewFake(o.now),
+		Root:           root,
+	}
+	if data, err := os.ReadFile(early.Name()); err == nil {
+		tc.Log.Write(data)
+	}
+	settings := rtx.LogSettings
+	settings.Writers = []io.Writer{tc.Log}
+	settings.FileHandle = nil
+	settings.Colorize = false
+	rtx.LogSettings = settings
+	rtx.Logger = app.ConfigureLogger(settings)
+	rtx.Out = app.NewRenderer(tc.Stdout, false, rtx.Glyphs, rtx.Printer)
+	rtx.Clock = tc.Time
+	return tc
+}
+
+// clearEnv unsets every environment variable starting with prefix until
+// the test ends.
+func clearEnv(t testing.TB, prefix string) {
+	for _, kv := range os.Environ() {
+		key, _, _ := strings.Cut(kv, "=")
+		if strings.HasPrefix(key, prefix) {
+			// Setenv registers the restore; then drop the variable.
+			t.Setenv(key, "")
+			os.Unsetenv(key)
+		}
+	}
+}
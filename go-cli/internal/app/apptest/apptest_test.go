@@ -0,0 +1,54 @@
+package apptest
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+)
+
+func TestNewTestContextIsolatesPaths(t *testing.T) {
+	tc := NewTestContext(t)
+
+	for _, path := range []string{tc.Paths.ConfigFile, tc.Paths.DataDir, tc.Paths.StateDir, tc.Paths.CacheDir} {
+		if !strings.HasPrefix(path, tc.Root+string(filepath.Separator)) {
+			t.Errorf("path %s is outside the test root %s", path, tc.Root)
+		}
+	}
+	if _, err := os.Stat(tc.Paths.ConfigFile); err != nil {
+		t.Errorf("default config was not created: %v", err)
+	}
+
+	if err := app.HandleConfigPath(tc.RuntimeContext); err != nil {
+		t.Fatalf("HandleConfigPath: %v", err)
+	}
+	if got := strings.TrimSpace(tc.Stdout.String()); got != tc.Paths.ConfigFile {
+		t.Errorf("output = %q, want %q", got, tc.Paths.ConfigFile)
+	}
+}
+
+func TestNewTestContextConfigAndEnv(t *testing.T) {
+	t.Setenv(app.EnvPrefix()+"_LOGGING__LEVEL", "error")
+	tc := NewTestContext(t, WithConfig("[logging]\nlevel = \"debug\"\n"))
+
+	if got := tc.Config.Logging.Level; got != "debug" {
+		t.Errorf("logging.level = %q, want the config file's debug", got)
+	}
+	tc.Logger.Debug("hello %s", "test")
+	if !strings.Contains(tc.Log.String(), "hello test") {
+		t.Errorf("log = %q, want the debug line", tc.Log.String())
+	}
+}
+
+func TestNewTestContextClock(t *testing.T) {
+	start := time.Date(2030, time.June, 1, 0, 0, 0, 0, time.UTC)
+	tc := NewTestContext(t, WithTime(start))
+
+	tc.Time.Advance(time.Hour)
+	if got := tc.Clock.Now(); !got.Equal(start.Add(time.Hour)) {
+		t.Errorf("Now = %s, want %s", got, start.Add(time.Hour))
+	}
+}
@@ -0,0 +1,416 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/gitinfo"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/humanize"
+)
+
+// runStatusRunning marks the manifest of a run that has not finished; a
+// manifest left in this state belongs to a run that crashed.
+const runStatusRunning = "running"
+
+// manifestName is the manifest file inside each run directory.
+const manifestName = "manifest.json"
+
+// RunManifest describes a run's artifacts directory.
+type RunManifest struct {
+	RunID    string     `json:"run_id" yaml:"run_id"`
+	Task     string     `json:"task" yaml:"task"`
+	Profile  string     `json:"profile" yaml:"profile"`
+	Started  time.Time  `json:"started" yaml:"started"`
+	Finished *time.Time `json:"finished,omitempty" yaml:"finished,omitempty"`
+	Status   string     `json:"status" yaml:"status"`
+	// Git describes the checkout the run started in, if any.
+	Git       *gitinfo.Info `json:"git,omitempty" yaml:"git,omitempty"`
+	Artifacts []Artifact    `json:"artifacts" yaml:"artifacts"`
+}
+
+// Size returns the total size of the run's artifacts in bytes.
+func (m RunManifest) Size() int64 {
+	var total int64
+	for _, a := range m.Artifacts {
+		total += a.Size
+	}
+	return total
+}
+
+// Artifact is one file in a run directory. Name is slash-separated and
+// relative to the run directory.
+type Artifact struct {
+	Name   string `json:"name" yaml:"name"`
+	Size   int64  `json:"size" yaml:"size"`
+	SHA256 string `json:"sha256" yaml:"sha256"`
+}
+
+// RunsCleanOptions select the run directories `runs clean` removes. A run
+// is removed only when it matches every criterion given.
+type RunsCleanOptions struct {
+	// Keep spares the newest Keep runs.
+	Keep int
+	// OlderThan removes runs started longer ago than this.
+	OlderThan time.Duration
+	// All removes every run.
+	All bool
+}
+
+func runsDir(dataDir string) string {
+	return filepath.Join(dataDir, "runs")
+}
+
+// artifactStore is the artifacts directory of the run in progress.
+type artifactStore struct {
+	dir string
+
+	mu       sync.Mutex
+	manifest RunManifest
+	// written caches the entries recorded by ArtifactWriter so the final
+	// manifest does not hash them again.
+	written map[string]Artifact
+}
+
+// openArtifacts creates the run directory and its initial manifest.
+func openArtifacts(ctx *RuntimeContext, manifest RunManifest) (*artifactStore, error) {
+	dir := filepath.Join(runsDir(ctx.Paths.DataDir), manifest.RunID)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create run directory: %w", err)
+	}
+	manifest.Status = runStatusRunning
+	manifest.Artifacts = []Artifact{}
+	store := &artifactStore{dir: dir, manifest: manifest, written: map[string]Artifact{}}
+	if err := store.save(); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+func (s *artifactStore) save() error {
+	data, err := json.MarshalIndent(s.manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(filepath.Join(s.dir, manifestName), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("write run manifest: %w", err)
+	}
+	return nil
+}
+
+// record adds or replaces an artifact written through ArtifactWriter.
+func (s *artifactStore) record(a Artifact) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.written[a.Name] = a
+	s.manifest.Artifacts = mergeArtifact(s.manifest.Artifacts, a)
+	return s.save()
+}
+
+func mergeArtifact(list []Artifact, a Artifact) []Artifact {
+	for i := range list {
+		if list[i].Name == a.Name {
+			list[i] = a
+			return list
+		}
+	}
+	list = append(list, a)
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// finish rescans the directory, so files written by shell commands through
+// GO_CLI_ARTIFACTS_DIR are listed too, and records the run's outcome.
+func (s *artifactStore) finish(status string, finished time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	artifacts := []Artifact{}
+	err := filepath.WalkDir(s.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(s.dir, path)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if name == manifestName || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if a, ok := s.written[name]; ok && a.Size == info.Size() {
+			artifacts = append(artifacts, a)
+			return nil
+		}
+		sum, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		artifacts = append(artifacts, Artifact{Name: name, Size: info.Size(), SHA256: sum})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("scan run directory: %w", err)
+	}
+
+	finished = finished.UTC()
+	s.manifest.Artifacts = artifacts
+	s.manifest.Status = status
+	s.manifest.Finished = &finished
+	return s.save()
+}
+
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ArtifactWriter creates the artifact name in the current run's directory,
+// replacing an earlier artifact of the same name. name is slash-separated
+// and may contain subdirectories but must stay inside the run directory.
+// The artifact appears, with its size and SHA-256, in the run's
+// manifest.json once the writer is closed. It fails outside of a run.
+func (rtx *RuntimeContext) ArtifactWriter(name string) (io.WriteCloser, error) {
+	store := rtx.artifacts
+	if store == nil {
+		return nil, errors.New("artifacts can only be written while a run is in progress")
+	}
+	rel := filepath.FromSlash(name)
+	if !filepath.IsLocal(rel) || filepath.ToSlash(filepath.Clean(rel)) == manifestName {
+		return nil, fmt.Errorf("invalid artifact name %q", name)
+	}
+	path := filepath.Join(store.dir, rel)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return nil, err
+	}
+	return &artifactWriter{
+		file:  tmp,
+		hash:  sha256.New(),
+		store: store,
+		name:  filepath.ToSlash(filepath.Clean(rel)),
+		path:  path,
+	}, nil
+}
+
+// artifactWriter writes to a temporary file and renames it into place on
+// Close, so an artifact is either complete or absent.
+type artifactWriter struct {
+	file   *os.File
+	hash   hash.Hash
+	size   int64
+	store  *artifactStore
+	name   string
+	path   string
+	closed bool
+}
+
+func (w *artifactWriter) Write(p []byte) (int, error) {
+	n, err := w.file.Write(p)
+	w.hash.Write(p[:n])
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *artifactWriter) Close() error {
+	if w.closed {
+		return nil
+	}
+	w.closed = true
+	if err := w.file.Close(); err != nil {
+		os.Remove(w.file.Name())
+		return err
+	}
+	if err := os.Rename(w.file.Name(), w.path); err != nil {
+		os.Remove(w.file.Name())
+		return err
+	}
+	return w.store.record(Artifact{Name: w.name, Size: w.size, SHA256: hex.EncodeToString(w.hash.Sum(nil))})
+}
+
+// artifactsEnv names the run directory for shell commands.
+func (rtx *RuntimeContext) artifactsEnv() []string {
+	if rtx.artifacts == nil {
+		return nil
+	}
+	return []string{EnvPrefix() + "_ARTIFACTS_DIR=" + rtx.artifacts.dir}
+}
+
+// loadRuns reads the manifests under the runs directory, newest first. Run
+// directories without a readable manifest are skipped.
+func loadRuns(ctx *RuntimeContext) ([]RunManifest, error) {
+	dir := runsDir(ctx.Paths.DataDir)
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read runs: %w", err)
+	}
+
+	runs := make([]RunManifest, 0, len(entries))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name(), manifestName)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			ctx.Logger.Debug("skipping run %s: %v", entry.Name(), err)
+			continue
+		}
+		var m RunManifest
+		if err := json.Unmarshal(data, &m); err != nil || m.RunID != entry.Name() {
+			ctx.Logger.Debug("skipping run %s: invalid manifest %s", entry.Name(), path)
+			continue
+		}
+		runs = append(runs, m)
+	}
+	// Run IDs sort by start time.
+	sort.Slice(runs, func(i, j int) bool { return runs[i].RunID > runs[j].RunID })
+	return runs, nil
+}
+
+// HandleRunsList prints the runs that have an artifacts directory, newest
+// first.
+func HandleRunsList(ctx *RuntimeContext, limit int) error {
+	runs, err := loadAllRuns(ctx)
+	if err != nil {
+		return err
+	}
+	if limit > 0 && len(runs) > limit {
+		runs = runs[:limit]
+	}
+
+	switch {
+	case ctx.Common.JSON:
+		data, err := json.MarshalIndent(runs, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+	case ctx.Common.YAML:
+		data, err := yaml.Marshal(runs)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		for _, r := range runs {
+			fmt.Fprintf(ctx.Out.Writer(), "%s\t%s\t%s\t%d\t%d\n", r.RunID, r.Task, r.Status, len(r.Artifacts), r.Size())
+		}
+	default:
+		if len(runs) == 0 {
+			ctx.Logger.Info("no run directories in %s", runsDir(ctx.Paths.DataDir))
+			return nil
+		}
+		now := ctx.Clock.Now()
+		rows := make([][]string, 0, len(runs))
+		for _, r := range runs {
+			rows = append(rows, []string{
+				r.RunID,
+				humanize.RelTime(r.Started, now),
+				r.Task,
+				historyStatus(ctx, r.Status),
+				fmt.Sprint(len(r.Artifacts)),
+				humanize.Bytes(r.Size()),
+			})
+		}
+		ctx.Out.Table("", headers(ctx.Out, msgColID, msgColStarted, msgColTask, msgColStatus, msgColFiles, msgColSize), rows)
+	}
+	return nil
+}
+
+// HandleRunsClean removes run directories selected by opts, together with
+// their task logs and their copies in the configured store.
+func HandleRunsClean(ctx *RuntimeContext, opts RunsCleanOptions) error {
+	if !opts.All && opts.Keep <= 0 && opts.OlderThan <= 0 {
+		return UsageError(errors.New("pass --keep, --older-than, or --all"))
+	}
+	runs, err := loadAllRuns(ctx)
+	if err != nil {
+		return err
+	}
+	store, err := ctx.runStore()
+	if err != nil {
+		return err
+	}
+
+	now := ctx.Clock.Now()
+	var removed []RunManifest
+	for i, r := range runs {
+		if opts.Keep > 0 && i < opts.Keep {
+			continue
+		}
+		if opts.OlderThan > 0 && now.Sub(r.Started) < opts.OlderThan {
+			continue
+		}
+		removed = append(removed, r)
+	}
+	if len(removed) > 0 {
+		resource := humanize.Plural(len(removed), "run", "runs")
+		err := confirmDestructive(ctx, Destructive{
+			Action:   "runs clean",
+			Resource: resource,
+			Question: fmt.Sprintf("Remove %s with their artifacts and task logs? Run with --dry-run to list them.", resource),
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	var freed int64
+	for _, r := range removed {
+		if ctx.Common.DryRun {
+			ctx.Logger.Info("dry-run: would remove run %s (%s)", r.RunID, humanize.Bytes(r.Size()))
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(runsDir(ctx.Paths.DataDir), r.RunID)); err != nil {
+			return fmt.Errorf("remove run %s: %w", r.RunID, err)
+		}
+		if err := os.RemoveAll(filepath.Join(taskLogsDir(ctx.Paths.StateDir), r.RunID)); err != nil {
+			return fmt.Errorf("remove task logs of run %s: %w", r.RunID, err)
+		}
+		if store != nil {
+			if err := deleteStoredRun(ctx, store, r.RunID); err != nil {
+				return fmt.Errorf("remove run %s from %s: %w", r.RunID, store, err)
+			}
+		}
+		freed += r.Size()
+		if ctx.Common.Porcelain {
+			ctx.Out.Println(r.RunID)
+		}
+	}
+	if !ctx.Common.DryRun {
+		ctx.Logger.Info("removed %s, freed %s", humanize.Plural(len(removed), "run", "runs"), humanize.Bytes(freed))
+	}
+	return nil
+}
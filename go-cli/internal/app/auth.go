@@ -0,0 +1,410 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/zalando/go-keyring"
+	"golang.org/x/oauth2"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// authFile holds the token in the state directory when it is not kept in
+// the keyring (auth login --insecure-storage).
+const authFile = "auth.json"
+
+// Where a token is stored.
+const storageKeyring = "keyring"
+
+// Login states reported by auth status.
+const (
+	AuthLoggedIn  = "logged-in"
+	AuthExpired   = "expired"
+	AuthLoggedOut = "logged-out"
+)
+
+// AuthConfig configures `auth login`, the OAuth2 device authorization flow
+// (RFC 8628) for tools that call OAuth-protected APIs.
+type AuthConfig struct {
+	// ClientID is the public client registered with the provider; empty
+	// disables auth.
+	ClientID string `mapstructure:"client_id" json:"client_id,omitempty" yaml:"client_id,omitempty"`
+	// DeviceURL is the device authorization endpoint.
+	DeviceURL string `mapstructure:"device_url" json:"device_url,omitempty" yaml:"device_url,omitempty"`
+	// TokenURL is the token endpoint.
+	TokenURL string   `mapstructure:"token_url" json:"token_url,omitempty" yaml:"token_url,omitempty"`
+	Scopes   []string `mapstructure:"scopes" json:"scopes" yaml:"scopes"`
+}
+
+func validateAuth(cfg AuthConfig) error {
+	if cfg.ClientID == "" {
+		return nil
+	}
+	for _, u := range []struct{ key, value string }{{"device_url", cfg.DeviceURL}, {"token_url", cfg.TokenURL}} {
+		if !isHTTPURL(u.value) {
+			return fmt.Errorf("invalid auth.%s %q (expected an http:// or https:// URL when auth.client_id is set)", u.key, u.value)
+		}
+	}
+	return nil
+}
+
+func (cfg AuthConfig) oauth2Config() (*oauth2.Config, error) {
+	if cfg.ClientID == "" {
+		return nil, UsageError(errors.New("auth is not configured; set auth.client_id, auth.device_url, and auth.token_url"))
+	}
+	return &oauth2.Config{
+		ClientID: cfg.ClientID,
+		Endpoint: oauth2.Endpoint{DeviceAuthURL: cfg.DeviceURL, TokenURL: cfg.TokenURL},
+		Scopes:   cfg.Scopes,
+	}, nil
+}
+
+// tokenStore keeps the token in the OS keyring (Secret Service, macOS
+// Keychain, or Windows Credential Manager) under the app name and client
+// ID, or in a file readable only by the user.
+type tokenStore struct {
+	service, account, file string
+}
+
+func (rtx *RuntimeContext) tokenStore() tokenStore {
+	return tokenStore{service: appName, account: rtx.Config.Auth.ClientID, file: filepath.Join(rtx.Paths.StateDir, authFile)}
+}
+
+// load returns the stored token and where it was found, or a nil token
+// when there is none. A keyring that cannot be reached counts as empty.
+func (s tokenStore) load() (*oauth2.Token, string, error) {
+	var tok oauth2.Token
+	if data, err := keyring.Get(s.service, s.account); err == nil {
+		if err := json.Unmarshal([]byte(data), &tok); err != nil {
+			return nil, "", fmt.Errorf("decode token from the keyring: %w", err)
+		}
+		return &tok, storageKeyring, nil
+	}
+	data, err := os.ReadFile(s.file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("read token: %w", err)
+	}
+	if err := json.Unmarshal(data, &tok); err != nil {
+		return nil, "", fmt.Errorf("decode token %s: %w", s.file, err)
+	}
+	return &tok, s.file, nil
+}
+
+// probe checks that the keyring can be reached.
+func (s tokenStore) probe() error {
+	if _, err := keyring.Get(s.service, s.account); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		return fmt.Errorf("the OS keyring is not available: %w (pass --insecure-storage to store the token in %s instead)", err, s.file)
+	}
+	return nil
+}
+
+// save stores tok in the keyring, or in the file when insecure, removing
+// any copy kept in the other place, and returns where it went.
+func (s tokenStore) save(tok *oauth2.Token, insecure bool) (string, error) {
+	data, err := json.Marshal(tok)
+	if err != nil {
+		return "", err
+	}
+	if insecure {
+		if err := os.MkdirAll(filepath.Dir(s.file), 0o700); err != nil {
+			return "", fmt.Errorf("create state directory: %w", err)
+		}
+		if err := writeFileAtomic(s.file, data, 0o600); err != nil {
+			return "", fmt.Errorf("write token: %w", err)
+		}
+		_ = keyring.Delete(s.service, s.account)
+		return s.file, nil
+	}
+	if err := keyring.Set(s.service, s.account, string(data)); err != nil {
+		return "", fmt.Errorf("store token in the keyring: %w (pass --insecure-storage to write it to %s instead)", err, s.file)
+	}
+	if err := os.Remove(s.file); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", fmt.Errorf("remove old token file: %w", err)
+	}
+	return storageKeyring, nil
+}
+
+// delete removes the token from both places and returns where it was.
+func (s tokenStore) delete() ([]string, error) {
+	var removed []string
+	if err := keyring.Delete(s.service, s.account); err == nil {
+		removed = append(removed, storageKeyring)
+	}
+	err := os.Remove(s.file)
+	switch {
+	case err == nil:
+		removed = append(removed, s.file)
+	case !errors.Is(err, fs.ErrNotExist):
+		return removed, fmt.Errorf("remove token file: %w", err)
+	}
+	return removed, nil
+}
+
+// oauthContext makes golang.org/x/oauth2 use the shared HTTP client, so
+// auth requests honor the proxy and CA settings.
+func (rtx *RuntimeContext) oauthContext(ctx context.Context) (context.Context, error) {
+	client, err := rtx.HTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	return context.WithValue(ctx, oauth2.HTTPClient, client), nil
+}
+
+// AccessToken returns the token stored by `auth login`, refreshed and
+// stored again when it has expired. Commands that call OAuth-protected APIs
+// use it, or AuthHTTPClient, instead of implementing a login of their own.
+func (rtx *RuntimeContext) AccessToken(ctx context.Context) (*oauth2.Token, error) {
+	conf, err := rtx.Config.Auth.oauth2Config()
+	if err != nil {
+		return nil, err
+	}
+	store := rtx.tokenStore()
+	tok, where, err := store.load()
+	if err != nil {
+		return nil, err
+	}
+	if tok == nil {
+		return nil, fmt.Errorf("not logged in; run `%s auth login`", appName)
+	}
+	if tok.Valid() {
+		return tok, nil
+	}
+	if tok.RefreshToken == "" {
+		return nil, fmt.Errorf("login expired; run `%s auth login`", appName)
+	}
+	octx, err := rtx.oauthContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fresh, err := conf.TokenSource(octx, tok).Token()
+	if err != nil {
+		return nil, fmt.Errorf("refresh token (run `%s auth login` if this persists): %w", appName, err)
+	}
+	if _, err := store.save(fresh, where != storageKeyring); err != nil {
+		rtx.Logger.Warn("could not store the refreshed token: %v", err)
+	}
+	return fresh, nil
+}
+
+// AuthHTTPClient returns the shared HTTP client with the access token sent
+// in the Authorization header of every request.
+func (rtx *RuntimeContext) AuthHTTPClient(ctx context.Context) (*http.Client, error) {
+	tok, err := rtx.AccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	client, err := rtx.HTTPClient()
+	if err != nil {
+		return nil, err
+	}
+	client.Transport = &oauth2.Transport{Source: oauth2.StaticTokenSource(tok), Base: client.Transport}
+	return client, nil
+}
+
+// AuthStatus describes the stored login for `auth status` and `auth login`.
+type AuthStatus struct {
+	State string `json:"state" yaml:"state"`
+	// Storage is "keyring" or the path of the token file.
+	Storage string     `json:"storage,omitempty" yaml:"storage,omitempty"`
+	Expiry  *time.Time `json:"expiry,omitempty" yaml:"expiry,omitempty"`
+	// Refreshable tokens renew themselves when they expire.
+	Refreshable bool `json:"refreshable" yaml:"refreshable"`
+}
+
+func authStatus(tok *oauth2.Token, where string) AuthStatus {
+	if tok == nil {
+		return AuthStatus{State: AuthLoggedOut}
+	}
+	status := AuthStatus{State: AuthLoggedIn, Storage: where, Refreshable: tok.RefreshToken != ""}
+	if !tok.Expiry.IsZero() {
+		expiry := tok.Expiry.UTC()
+		status.Expiry = &expiry
+	}
+	if !tok.Valid() && !status.Refreshable {
+		status.State = AuthExpired
+	}
+	return status
+}
+
+// AuthLoginOptions configure auth login.
+type AuthLoginOptions struct {
+	// InsecureStorage writes the token to a file in the state directory
+	// instead of the keyring.
+	InsecureStorage bool
+}
+
+// HandleAuthLogin runs the device authorization flow: it prints a code
+// and a URL, waits while the user approves the request in a browser, and
+// stores the token it receives.
+func HandleAuthLogin(ctx *RuntimeContext, opts AuthLoginOptions) error {
+	conf, err := ctx.Config.Auth.oauth2Config()
+	if err != nil {
+		return err
+	}
+	octx, err := ctx.oauthContext(ctx)
+	if err != nil {
+		return err
+	}
+	if ctx.Common.DryRun {
+		ctx.Logger.Info("dry-run: would request a device code from %s", conf.Endpoint.DeviceAuthURL)
+		return nil
+	}
+	store := ctx.tokenStore()
+	// Fail before the user approves a login that could not be stored.
+	if !opts.InsecureStorage {
+		if err := store.probe(); err != nil {
+			return err
+		}
+	}
+	da, err := conf.DeviceAuth(octx)
+	if err != nil {
+		return fmt.Errorf("request device code: %w", err)
+	}
+
+	// The prompt goes to stderr so --json output stays parseable.
+	fmt.Fprintf(os.Stderr, "First copy your one-time code: %s\n", ctx.Out.Bold(da.UserCode))
+	fmt.Fprintf(os.Stderr, "Then open %s in a browser and enter it.\n", da.VerificationURI)
+	if da.VerificationURIComplete != "" {
+		fmt.Fprintf(os.Stderr, "(Or open %s, which fills in the code.)\n", da.VerificationURIComplete)
+	}
+	ctx.Logger.Info("waiting for authorization (expires %s)", da.Expiry.Local().Format(time.Kitchen))
+
+	tok, err := conf.DeviceAccessToken(octx, da)
+	if err != nil {
+		var rerr *oauth2.RetrieveError
+		if errors.As(err, &rerr) {
+			switch rerr.ErrorCode {
+			case "access_denied":
+				return errors.New("authorization was denied")
+			case "expired_token":
+				return fmt.Errorf("the code expired before it was approved; run `%s auth login` again", appName)
+			}
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			return fmt.Errorf("the code expired before it was approved; run `%s auth login` again", appName)
+		}
+		return fmt.Errorf("wait for authorization: %w", err)
+	}
+	where, err := store.save(tok, opts.InsecureStorage)
+	if err != nil {
+		return err
+	}
+	status := authStatus(tok, where)
+	switch {
+	case ctx.Common.JSON || ctx.Common.YAML:
+		return printAuthValue(ctx, status)
+	case ctx.Common.Porcelain:
+		ctx.Out.Println(status.State)
+	default:
+		ctx.Out.Success(ctx.Out.Sprintf(msgLoggedIn, where))
+	}
+	return nil
+}
+
+// HandleAuthStatus reports whether a token is stored and when it expires.
+func HandleAuthStatus(ctx *RuntimeContext) error {
+	if _, err := ctx.Config.Auth.oauth2Config(); err != nil {
+		return err
+	}
+	tok, where, err := ctx.tokenStore().load()
+	if err != nil {
+		return err
+	}
+	status := authStatus(tok, where)
+	switch {
+	case ctx.Common.JSON || ctx.Common.YAML:
+		return printAuthValue(ctx, status)
+	case ctx.Common.Porcelain:
+		ctx.Out.Println(status.State)
+	default:
+		out := ctx.Out
+		rows := []KeyValue{{Key: out.Text(msgLabelState), Value: status.State}}
+		if status.Storage != "" {
+			rows = append(rows, KeyValue{Key: out.Text(msgLabelStorage), Value: status.Storage})
+		}
+		if status.Expiry != nil {
+			rows = append(rows, KeyValue{Key: out.Text(msgLabelExpiry), Value: status.Expiry.Local().Format(time.RFC3339)})
+		}
+		if tok != nil {
+			rows = append(rows, KeyValue{Key: out.Text(msgLabelRefreshable), Value: fmt.Sprint(status.Refreshable)})
+		}
+		ctx.Out.KeyValues("", rows)
+	}
+	return nil
+}
+
+// HandleAuthToken prints a valid access token, refreshing it if needed,
+// e.g. for curl -H "Authorization: Bearer $(go-cli auth token)".
+func HandleAuthToken(ctx *RuntimeContext) error {
+	tok, err := ctx.AccessToken(ctx)
+	if err != nil {
+		return err
+	}
+	out := struct {
+		AccessToken string     `json:"access_token" yaml:"access_token"`
+		TokenType   string     `json:"token_type" yaml:"token_type"`
+		Expiry      *time.Time `json:"expiry,omitempty" yaml:"expiry,omitempty"`
+	}{AccessToken: tok.AccessToken, TokenType: tok.Type()}
+	if !tok.Expiry.IsZero() {
+		out.Expiry = &tok.Expiry
+	}
+	ctx.CopyPrimary("access token", tok.AccessToken)
+	if ctx.Common.JSON || ctx.Common.YAML {
+		return printAuthValue(ctx, out)
+	}
+	ctx.Out.Println(tok.AccessToken)
+	return nil
+}
+
+// HandleAuthLogout removes the stored token.
+func HandleAuthLogout(ctx *RuntimeContext) error {
+	if _, err := ctx.Config.Auth.oauth2Config(); err != nil {
+		return err
+	}
+	store := ctx.tokenStore()
+	if ctx.Common.DryRun {
+		ctx.Logger.Info("dry-run: would remove the token from the keyring and %s", store.file)
+		return nil
+	}
+	removed, err := store.delete()
+	if err != nil {
+		return err
+	}
+	if len(removed) == 0 {
+		ctx.Logger.Info("not logged in")
+		return nil
+	}
+	for _, where := range removed {
+		ctx.Logger.Debug("removed token from %s", where)
+	}
+	ctx.Out.Success(ctx.Out.Text(msgLoggedOut))
+	return nil
+}
+
+// printAuthValue prints v as JSON, or as YAML with --yaml.
+func printAuthValue(ctx *RuntimeContext, v any) error {
+	if ctx.Common.YAML {
+		data, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+		return nil
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(ctx.Out.Writer(), string(data))
+	return nil
+}
@@ -0,0 +1,330 @@
+package app
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/buildinfo"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/container"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/control"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/humanize"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/statestore"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/sysload"
+)
+
+// BugReportOptions configure bug-report.
+type BugReportOptions struct {
+	// Build is the metadata of the running binary.
+	Build buildinfo.Info
+	// Output is the archive path; empty picks a timestamped name in the
+	// working directory.
+	Output string
+	// Markdown prints a Markdown document to stdout instead of writing an
+	// archive.
+	Markdown bool
+	// Lines bounds how much of each log is included.
+	Lines int
+	// History is how many recent runs to include.
+	History int
+}
+
+// BugReportItem is one file of a bug report.
+type BugReportItem struct {
+	Name        string `json:"name" yaml:"name"`
+	Description string `json:"description" yaml:"description"`
+	Bytes       int    `json:"bytes" yaml:"bytes"`
+	data        []byte
+	// lang is the Markdown code fence language.
+	lang string
+}
+
+// BugReport summarizes what a bug report collected.
+type BugReport struct {
+	// Path is the written archive; empty for Markdown output or dry-run.
+	Path  string          `json:"path,omitempty" yaml:"path,omitempty"`
+	Items []BugReportItem `json:"items" yaml:"items"`
+}
+
+// HandleBugReport collects version, platform, redacted config, paths,
+// environment overrides, health checks, recent history, and recent logs
+// into a tar.gz archive or a Markdown document for attaching to an issue.
+func HandleBugReport(ctx *RuntimeContext, opts BugReportOptions) error {
+	items := collectBugReport(ctx, opts)
+
+	if opts.Markdown {
+		if ctx.Common.DryRun {
+			logReportItems(ctx, "dry-run: would print", items)
+			return nil
+		}
+		fmt.Fprint(ctx.Out.Writer(), bugReportMarkdown(items))
+		logReportItems(ctx, "collected", items)
+		return nil
+	}
+
+	path := opts.Output
+	if path == "" {
+		path = fmt.Sprintf("%s-bug-report-%s.tar.gz", appName, ctx.Clock.Now().UTC().Format("20060102T150405Z"))
+	}
+	report := BugReport{Items: items}
+	if ctx.Common.DryRun {
+		logReportItems(ctx, "dry-run: would write "+path+" with", items)
+	} else {
+		if err := writeReportArchive(path, ctx.Clock.Now(), items); err != nil {
+			return err
+		}
+		report.Path = path
+	}
+
+	switch {
+	case ctx.Common.JSON:
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+	case ctx.Common.YAML:
+		data, err := yaml.Marshal(report)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		if report.Path != "" {
+			ctx.Out.Println(report.Path)
+		}
+	case report.Path != "":
+		rows := make([][]string, 0, len(items))
+		for _, item := range items {
+			rows = append(rows, []string{item.Name, humanize.Bytes(int64(item.Bytes)), item.Description})
+		}
+		ctx.Out.Println("Collected into " + report.Path + ":")
+		ctx.Out.Table("  ", []string{"FILE", "SIZE", "CONTENTS"}, rows)
+		ctx.Out.Println(ctx.Out.Dim("Secrets in exec.env, task env, and secret variables are redacted. Review the files before attaching them."))
+	}
+	return nil
+}
+
+func logReportItems(ctx *RuntimeContext, verb string, items []BugReportItem) {
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		names = append(names, item.Name)
+	}
+	ctx.Logger.Info("%s %s: %s", verb, humanize.Plural(len(items), "file", "files"), strings.Join(names, ", "))
+}
+
+func collectBugReport(ctx *RuntimeContext, opts BugReportOptions) []BugReportItem {
+	var items []BugReportItem
+	add := func(name, description, lang string, data []byte) {
+		items = append(items, BugReportItem{Name: name, Description: description, Bytes: len(data), data: data, lang: lang})
+	}
+
+	add("version.txt", "version, build, and platform", "", reportKeyValues(platformInfo(opts.Build)))
+	add("config.txt", "effective config, secrets redacted", "", reportKeyValues(redactedConfig(ctx.Config)))
+	add("paths.txt", "config, data, state, and cache directories", "", reportKeyValues([]KeyValue{
+		{Key: "config", Value: ctx.Paths.ConfigFile},
+		{Key: "data", Value: ctx.Paths.DataDir},
+		{Key: "state", Value: ctx.Paths.StateDir},
+		{Key: "cache", Value: ctx.Paths.CacheDir},
+	}))
+	add("env.txt", "set "+EnvPrefix()+"_* variables, secrets redacted", "", reportKeyValues(setEnvVars()))
+	add("checks.txt", "health checks", "", reportKeyValues(healthChecks(ctx)))
+
+	entries, err := loadHistory(ctx, statestore.Query{Limit: opts.History})
+	if err == nil && len(entries) > 0 {
+		var buf bytes.Buffer
+		for _, entry := range entries {
+			line, _ := json.Marshal(entry)
+			buf.Write(append(line, '\n'))
+		}
+		add("history.jsonl", humanize.Plural(len(entries), "recent run", "recent runs"), "json", buf.Bytes())
+
+		latest := entries[len(entries)-1].ID
+		logs, _ := filepath.Glob(filepath.Join(taskLogsDir(ctx.Paths.StateDir), latest, "*.log"))
+		sort.Strings(logs)
+		for _, path := range logs {
+			if data, ok := tailFile(path, opts.Lines); ok {
+				add("logs/"+latest+"/"+filepath.Base(path), "task log of the latest run", "", data)
+			}
+		}
+	}
+
+	if ctx.Config.Logging.File != "" {
+		if data, ok := tailFile(ctx.Config.Logging.File, opts.Lines); ok {
+			add("logs/"+appName+".log", "end of logging.file", "", data)
+		}
+	}
+	if data, ok := tailFile(daemonLogPath(ctx.Paths.StateDir), opts.Lines); ok {
+		add("logs/daemon.log", "end of the daemon log", "", data)
+	}
+	return items
+}
+
+func platformInfo(info buildinfo.Info) []KeyValue {
+	rows := []KeyValue{
+		{Key: "version", Value: info.Version},
+		{Key: "commit", Value: info.Commit},
+		{Key: "modified", Value: fmt.Sprint(info.Modified)},
+		{Key: "date", Value: info.Date},
+		{Key: "go", Value: info.GoVersion},
+		{Key: "platform", Value: info.Platform},
+		{Key: "cpus", Value: fmt.Sprint(runtime.NumCPU())},
+	}
+	if load, err := sysload.Sample(); err == nil {
+		rows = append(rows, KeyValue{Key: "load1", Value: fmt.Sprintf("%.2f", load.Load1)})
+		if load.MemAvailable >= 0 {
+			rows = append(rows, KeyValue{Key: "memory available", Value: fmt.Sprintf("%.0f%%", load.MemAvailable*100)})
+		}
+	}
+	for _, name := range []string{"TERM", "SHELL", "LANG"} {
+		rows = append(rows, KeyValue{Key: strings.ToLower(name), Value: os.Getenv(name)})
+	}
+	return rows
+}
+
+// redactedConfig flattens cfg with secret values replaced.
+func redactedConfig(cfg AppConfig) []KeyValue {
+	rows := flattenConfig(cfg)
+	for i, row := range rows {
+		if secretKey(row.Key) && row.Value != "[]" && row.Value != "" {
+			rows[i].Value = redacted
+		}
+	}
+	return rows
+}
+
+// setEnvVars lists the recognized variables that are set.
+func setEnvVars() []KeyValue {
+	var rows []KeyValue
+	for _, v := range EnvVars() {
+		value, ok := os.LookupEnv(v.Name)
+		if !ok {
+			continue
+		}
+		if v.Secret && value != "" {
+			value = redacted
+		}
+		rows = append(rows, KeyValue{Key: v.Name, Value: value})
+	}
+	return rows
+}
+
+// healthChecks reports the state of the directories, the task file, and
+// the daemon.
+func healthChecks(ctx *RuntimeContext) []KeyValue {
+	status := func(path string) string {
+		info, err := os.Stat(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			return "missing"
+		case err != nil:
+			return "error: " + err.Error()
+		case info.IsDir():
+			return "ok (directory)"
+		default:
+			return "ok"
+		}
+	}
+	rows := []KeyValue{
+		{Key: "config file", Value: status(ctx.Paths.ConfigFile)},
+		{Key: "config valid", Value: fmt.Sprint(ctx.Config.Validate() == nil)},
+		{Key: "data dir", Value: status(ctx.Paths.DataDir)},
+		{Key: "state dir", Value: status(ctx.Paths.StateDir)},
+		{Key: "cache dir", Value: status(ctx.Paths.CacheDir)},
+		{Key: "taskfile", Value: status(ctx.Config.Taskfile)},
+	}
+	daemon := "stopped"
+	if s, err := queryInstance(ctx, ctx.Paths.StateDir, control.CmdStatus); err == nil {
+		daemon = fmt.Sprintf("%s running (pid %d)", s.Mode, s.PID)
+	}
+	return append(rows, KeyValue{Key: "daemon", Value: daemon}, KeyValue{Key: "container", Value: containerStatus()})
+}
+
+// containerStatus describes the container detection for the health checks.
+func containerStatus() string {
+	info := container.Detect()
+	if !info.Detected() {
+		return "not detected"
+	}
+	s := fmt.Sprintf("%s (%s)", info.Runtime, info.Reason)
+	if q := container.CPUQuota(); q > 0 {
+		s += fmt.Sprintf(", cpu quota %d", q)
+	}
+	return s
+}
+
+func reportKeyValues(rows []KeyValue) []byte {
+	var buf bytes.Buffer
+	for _, row := range rows {
+		fmt.Fprintf(&buf, "%s: %s\n", row.Key, row.Value)
+	}
+	return buf.Bytes()
+}
+
+// tailFile returns the last n lines of the file at path.
+func tailFile(path string, n int) ([]byte, bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, false
+	}
+	defer f.Close()
+	lines, err := tailLines(f, n)
+	if err != nil || len(lines) == 0 {
+		return nil, false
+	}
+	return []byte(strings.Join(lines, "\n") + "\n"), true
+}
+
+// writeReportArchive writes items into a gzip-compressed tar file at path,
+// under a directory named after the archive.
+func writeReportArchive(path string, now time.Time, items []BugReportItem) error {
+	dir := strings.TrimSuffix(filepath.Base(path), ".tar.gz")
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for _, item := range items {
+		header := &tar.Header{
+			Name:    dir + "/" + item.Name,
+			Mode:    0o644,
+			Size:    int64(len(item.data)),
+			ModTime: now,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("write bug report: %w", err)
+		}
+		if _, err := tw.Write(item.data); err != nil {
+			return fmt.Errorf("write bug report: %w", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return fmt.Errorf("write bug report: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return fmt.Errorf("write bug report: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		return fmt.Errorf("write bug report: %w", err)
+	}
+	return nil
+}
+
+func bugReportMarkdown(items []BugReportItem) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s bug report\n", appName)
+	for _, item := range items {
+		fmt.Fprintf(&b, "\n<details><summary>%s (%s)</summary>\n\n```%s\n%s```\n\n</details>\n", item.Name, item.Description, item.lang, item.data)
+	}
+	return b.String()
+}
@@ -0,0 +1,228 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/clock"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/humanize"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/kvcache"
+)
+
+// Cache is a key/value store in the cache directory for data that can be
+// recomputed, such as API responses or computed indexes. Entries live in
+// one bbolt file and are namespaced by profile, so profiles pointing at
+// different environments do not share lookups.
+type Cache struct {
+	db        *kvcache.DB
+	namespace string
+	clock     clock.Clock
+	dryRun    bool
+}
+
+func cacheDBPath(cacheDir string) string {
+	return filepath.Join(cacheDir, kvcache.FileName)
+}
+
+// Cache returns the cache of the active profile. Under --dry-run, Set and
+// Delete do nothing.
+func (rtx *RuntimeContext) Cache() *Cache {
+	return &Cache{
+		db:        kvcache.New(cacheDBPath(rtx.Paths.CacheDir)),
+		namespace: rtx.Config.Profile,
+		clock:     clock.Or(rtx.Clock),
+		dryRun:    rtx.Common.DryRun,
+	}
+}
+
+// Get returns the value stored under key. Missing and expired entries
+// report ok == false.
+func (c *Cache) Get(key string) (value []byte, ok bool, err error) {
+	entry, err := c.db.Get(c.namespace, key)
+	if err != nil {
+		return nil, false, fmt.Errorf("read cache entry: %w", err)
+	}
+	if entry == nil || entry.Expired(c.clock.Now()) {
+		return nil, false, nil
+	}
+	return entry.Value, true, nil
+}
+
+// Set stores value under key. A ttl of zero keeps the entry until the
+// cache is cleaned.
+func (c *Cache) Set(key string, value []byte, ttl time.Duration) error {
+	if c.dryRun {
+		return nil
+	}
+	now := c.clock.Now().UTC()
+	entry := kvcache.Entry{Namespace: c.namespace, Key: key, Value: value, Created: now}
+	if ttl > 0 {
+		entry.Expires = now.Add(ttl)
+	}
+	if err := c.db.Set(entry); err != nil {
+		return fmt.Errorf("write cache entry: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the entry for key, if any.
+func (c *Cache) Delete(key string) error {
+	if c.dryRun {
+		return nil
+	}
+	if err := c.db.Delete(c.namespace, key); err != nil {
+		return fmt.Errorf("delete cache entry: %w", err)
+	}
+	return nil
+}
+
+// CacheUsage is the disk usage of the cache directory.
+type CacheUsage struct {
+	Path  string `json:"path" yaml:"path"`
+	Files int    `json:"files" yaml:"files"`
+	Bytes int64  `json:"bytes" yaml:"bytes"`
+}
+
+func cacheUsage(dir string) (CacheUsage, error) {
+	usage := CacheUsage{Path: dir}
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.Type().IsRegular() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			usage.Files++
+			usage.Bytes += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return usage, fmt.Errorf("scan cache: %w", err)
+	}
+	return usage, nil
+}
+
+// HandleCachePath prints the cache directory.
+func HandleCachePath(ctx *RuntimeContext) error {
+	ctx.Out.Println(ctx.Paths.CacheDir)
+	ctx.CopyPrimary("cache path", ctx.Paths.CacheDir)
+	return nil
+}
+
+// HandleCacheSize prints how many files the cache holds and their size.
+func HandleCacheSize(ctx *RuntimeContext) error {
+	usage, err := cacheUsage(ctx.Paths.CacheDir)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case ctx.Common.JSON:
+		data, err := json.MarshalIndent(usage, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+	case ctx.Common.YAML:
+		data, err := yaml.Marshal(usage)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		fmt.Fprintf(ctx.Out.Writer(), "%d\t%d\n", usage.Files, usage.Bytes)
+	default:
+		ctx.Out.KeyValues("", []KeyValue{
+			{Key: ctx.Out.Text(msgLabelPath), Value: usage.Path},
+			{Key: ctx.Out.Text(msgLabelFiles), Value: fmt.Sprint(usage.Files)},
+			{Key: ctx.Out.Text(msgLabelSize), Value: humanize.Bytes(usage.Bytes)},
+		})
+	}
+	return nil
+}
+
+// CacheCleanOptions configure cache clean.
+type CacheCleanOptions struct {
+	// OlderThan limits removal to files last written longer ago than
+	// this; zero removes everything. Expired entries are always removed.
+	OlderThan time.Duration
+}
+
+// HandleCacheClean removes cache files selected by opts. With OlderThan,
+// the entries in the cache database are purged by age instead of the
+// whole file.
+func HandleCacheClean(ctx *RuntimeContext, opts CacheCleanOptions) error {
+	now := ctx.Clock.Now()
+	dbPath := cacheDBPath(ctx.Paths.CacheDir)
+
+	var purged int
+	if opts.OlderThan > 0 {
+		var err error
+		purged, err = kvcache.New(dbPath).Purge(func(e kvcache.Entry) bool {
+			return e.Expired(now) || now.Sub(e.Created) >= opts.OlderThan
+		}, ctx.Common.DryRun)
+		if err != nil {
+			return fmt.Errorf("purge cache entries: %w", err)
+		}
+	}
+
+	var removed []string
+	var freed int64
+	err := filepath.WalkDir(ctx.Paths.CacheDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		if opts.OlderThan > 0 && (path == dbPath || now.Sub(info.ModTime()) < opts.OlderThan) {
+			return nil
+		}
+		if !ctx.Common.DryRun {
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("remove %s: %w", path, err)
+			}
+		}
+		removed = append(removed, path)
+		freed += info.Size()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if ctx.Common.DryRun {
+		ctx.Logger.Info("dry-run: would remove %s (%s) and %s from %s", humanize.Plural(len(removed), "file", "files"), humanize.Bytes(freed),
+			humanize.Plural(purged, "cache entry", "cache entries"), ctx.Paths.CacheDir)
+		return nil
+	}
+	if ctx.Common.Porcelain {
+		for _, path := range removed {
+			ctx.Out.Println(path)
+		}
+	}
+	ctx.Logger.Info("removed %s and %s from the cache, freed %s", humanize.Plural(len(removed), "file", "files"),
+		humanize.Plural(purged, "cache entry", "cache entries"), humanize.Bytes(freed))
+	return nil
+}
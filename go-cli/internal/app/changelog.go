@@ -0,0 +1,113 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/buildinfo"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/humanize"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/releasenotes"
+)
+
+// HandleChangelog prints the embedded release notes up to the running
+// version, or only those newer than since when it is set.
+func HandleChangelog(ctx *RuntimeContext, build buildinfo.Info, since string) error {
+	if since != "" {
+		canonical := releasenotes.Canonical(since)
+		if canonical == "" {
+			return UsageError(fmt.Errorf("invalid --since %q (expected a version such as v1.2.3)", since))
+		}
+		since = canonical
+	}
+	releases := releasenotes.Between(releasenotes.Parse(releasenotes.Markdown), since, build.Version)
+
+	switch {
+	case ctx.Common.JSON:
+		data, err := json.MarshalIndent(releases, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+	case ctx.Common.YAML:
+		data, err := yaml.Marshal(releases)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		for _, r := range releases {
+			fmt.Fprintln(ctx.Out.Writer(), releaseName(r))
+		}
+	default:
+		if len(releases) == 0 {
+			if since != "" {
+				ctx.Logger.Info("no changes since %s", since)
+			} else {
+				ctx.Logger.Info("no release notes in this build")
+			}
+			return nil
+		}
+		for i, r := range releases {
+			if i > 0 {
+				ctx.Out.Println()
+			}
+			ctx.Out.Heading(r.Title)
+			if r.Notes != "" {
+				ctx.Out.Println()
+				ctx.Out.Println(r.Notes)
+			}
+		}
+	}
+	return nil
+}
+
+// releaseName is a release's version, or "unreleased".
+func releaseName(r releasenotes.Release) string {
+	if r.Unreleased() {
+		return "unreleased"
+	}
+	return r.Version
+}
+
+// lastVersionPath records the version of the binary that last ran, so
+// the first run after an upgrade can point at what changed.
+func lastVersionPath(stateDir string) string {
+	return filepath.Join(stateDir, "last_version")
+}
+
+// NoticeUpgrade tells the user once when the binary is newer than the
+// one that last ran, with a pointer to `changelog --since`. The first
+// run after installing records the version without a notice. Builds
+// without a release version are ignored.
+func NoticeUpgrade(ctx *RuntimeContext, build buildinfo.Info) {
+	current := releasenotes.Canonical(build.Version)
+	if current == "" {
+		return
+	}
+	path := lastVersionPath(ctx.Paths.StateDir)
+	data, err := os.ReadFile(path)
+	previous := releasenotes.Canonical(strings.TrimSpace(string(data)))
+	if err == nil && previous == current {
+		return
+	}
+	if previous != "" && releasenotes.Compare(current, previous) > 0 {
+		changes := 0
+		for _, r := range releasenotes.Between(releasenotes.Parse(releasenotes.Markdown), previous, current) {
+			for _, line := range strings.Split(r.Notes, "\n") {
+				if strings.HasPrefix(line, "- ") {
+					changes++
+				}
+			}
+		}
+		ctx.Logger.Info("updated from %s to %s (%s); run `%s changelog --since %s` to see what's new",
+			previous, current, humanize.Plural(changes, "change", "changes"), appName, previous)
+	}
+	if err := writeFileAtomic(path, []byte(current+"\n"), 0o644); err != nil {
+		ctx.Logger.Debug("could not record version: %v", err)
+	}
+}
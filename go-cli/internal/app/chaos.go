@@ -0,0 +1,89 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/clock"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/humanize"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/runner"
+)
+
+// ErrChaos is the failure injected by chaos mode.
+var ErrChaos = errors.New("injected failure (chaos mode)")
+
+// chaosMaxDelay bounds the delay chaos mode adds to a task attempt.
+const chaosMaxDelay = 2 * time.Second
+
+// ParseChaos reads a chaos percentage from --chaos or GO_CLI_CHAOS: an
+// integer from 0 to 100, optionally followed by %.
+func ParseChaos(value string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(value), "%"))
+	if err != nil || n < 0 || n > 100 {
+		return 0, fmt.Errorf("invalid chaos percentage %q (expected 0-100)", value)
+	}
+	return n, nil
+}
+
+// chaos injects faults into task attempts: each attempt is hit with
+// probability percent/100, and a hit either fails with ErrChaos or delays
+// the attempt by up to chaosMaxDelay, with equal odds. Attempts are drawn
+// independently, so retries can recover from an injected failure.
+type chaos struct {
+	percent int
+	clock   clock.Clock
+
+	mu  sync.Mutex
+	rng *rand.Rand
+}
+
+func newChaos(percent int, c clock.Clock) *chaos {
+	if percent <= 0 {
+		return nil
+	}
+	return &chaos{percent: percent, clock: c, rng: rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))}
+}
+
+// draw decides the fate of one attempt.
+func (c *chaos) draw() (hit, fail bool, delay time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.rng.IntN(100) >= c.percent {
+		return false, false, 0
+	}
+	if c.rng.IntN(2) == 0 {
+		return true, true, 0
+	}
+	return true, false, time.Duration(c.rng.Int64N(int64(chaosMaxDelay)))
+}
+
+// chaosJob wraps job so chaos can fail or delay each of its attempts. A nil
+// chaos returns job unchanged.
+func chaosJob(ctx *RuntimeContext, c *chaos, job runner.Job) runner.Job {
+	if c == nil {
+		return job
+	}
+	run := job.Run
+	job.Run = func(jobCtx context.Context) error {
+		hit, fail, delay := c.draw()
+		switch {
+		case !hit:
+		case fail:
+			ctx.TaskLogger(jobCtx).Warn("chaos: failing task %s", job.Name)
+			return ErrChaos
+		default:
+			ctx.TaskLogger(jobCtx).Warn("chaos: delaying task %s by %s", job.Name, humanize.Duration(delay))
+			if err := clock.Sleep(jobCtx, c.clock, delay); err != nil {
+				return err
+			}
+		}
+		return run(jobCtx)
+	}
+	return job
+}
@@ -0,0 +1,144 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/runner"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/statestore"
+)
+
+// Checkpoint records which tasks of a run finished successfully so an
+// interrupted run can resume where it stopped.
+type Checkpoint struct {
+	RunID     string    `json:"run_id"`
+	Task      string    `json:"task"`
+	Profile   string    `json:"profile"`
+	Completed []string  `json:"completed"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// checkpointKey names the checkpoint of a task/profile pair.
+func checkpointKey(task, profile string) string {
+	return task + "@" + profile
+}
+
+// loadCheckpoint reads the checkpoint stored under key; none yields nil.
+func loadCheckpoint(ctx *RuntimeContext, key string) (*Checkpoint, error) {
+	store, err := ctx.stateStore()
+	if err != nil {
+		return nil, err
+	}
+	rec, err := store.Checkpoint(ctx, key)
+	if err != nil {
+		return nil, fmt.Errorf("read checkpoint: %w", err)
+	}
+	if rec == nil {
+		return nil, nil
+	}
+	var cp Checkpoint
+	if err := json.Unmarshal(rec.Data, &cp); err != nil {
+		return nil, fmt.Errorf("decode checkpoint %s: %w", key, err)
+	}
+	return &cp, nil
+}
+
+// putCheckpoint stores cp under its task/profile key.
+func putCheckpoint(ctx context.Context, store statestore.Store, cp Checkpoint) error {
+	data, err := json.MarshalIndent(cp, "", "  ")
+	if err != nil {
+		return err
+	}
+	return store.PutCheckpoint(ctx, statestore.Record{Key: checkpointKey(cp.Task, cp.Profile), Data: data, Updated: cp.UpdatedAt})
+}
+
+// checkpointWriter persists completed tasks as the runner reports them. It is
+// a runner.Observer; JobFinished is only called from the runner's
+// coordinating goroutine, but the mutex keeps it safe for any caller.
+type checkpointWriter struct {
+	ctx *RuntimeContext
+	cp  Checkpoint
+	mu  sync.Mutex
+}
+
+func newCheckpointWriter(ctx *RuntimeContext, cp Checkpoint) *checkpointWriter {
+	return &checkpointWriter{ctx: ctx, cp: cp}
+}
+
+func (w *checkpointWriter) JobStarted(string) {}
+
+func (w *checkpointWriter) JobFinished(result runner.Result) {
+	if result.Err != nil || result.Skipped {
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.cp.Completed = append(w.cp.Completed, result.Name)
+	w.cp.UpdatedAt = w.ctx.Clock.Now().UTC()
+	store, err := w.ctx.stateStore()
+	if err == nil {
+		// Interrupting the run must not lose the tasks it completed.
+		err = putCheckpoint(context.WithoutCancel(w.ctx), store, w.cp)
+	}
+	if err != nil {
+		w.ctx.Logger.Warn("could not write checkpoint %s: %v", w.key(), err)
+	}
+}
+
+func (w *checkpointWriter) key() string {
+	return checkpointKey(w.cp.Task, w.cp.Profile)
+}
+
+// clear removes the checkpoint once the run no longer needs it.
+func (w *checkpointWriter) clear() {
+	store, err := w.ctx.stateStore()
+	if err == nil {
+		err = store.DeleteCheckpoint(context.WithoutCancel(w.ctx), w.key())
+	}
+	if err != nil {
+		w.ctx.Logger.Warn("could not remove checkpoint %s: %v", w.key(), err)
+	}
+}
+
+// skipCompleted drops jobs recorded as completed and removes them from the
+// remaining jobs' dependencies, which are already satisfied.
+func skipCompleted(jobs []runner.Job, completed []string) (remaining []runner.Job, resumed []string) {
+	done := make(map[string]bool, len(completed))
+	for _, name := range completed {
+		done[name] = true
+	}
+
+	for _, job := range jobs {
+		if done[job.Name] {
+			resumed = append(resumed, job.Name)
+			continue
+		}
+		var deps []string
+		for _, dep := range job.Deps {
+			if !done[dep] {
+				deps = append(deps, dep)
+			}
+		}
+		job.Deps = deps
+		remaining = append(remaining, job)
+	}
+	return remaining, resumed
+}
+
+// multiObserver fans runner events out to several observers.
+type multiObserver []runner.Observer
+
+func (m multiObserver) JobStarted(name string) {
+	for _, o := range m {
+		o.JobStarted(name)
+	}
+}
+
+func (m multiObserver) JobFinished(result runner.Result) {
+	for _, o := range m {
+		o.JobFinished(result)
+	}
+}
@@ -0,0 +1,28 @@
+package app
+
+import (
+	"context"
+	"errors"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/clipboard"
+)
+
+// CopyPrimary puts text, the command's primary output, on the clipboard
+// when --copy or output.clipboard asks for it. what names the value in the
+// confirmation, which never repeats the value itself. A missing clipboard
+// (an SSH session, a headless machine) is only warned about: the output
+// was still printed.
+func (rtx *RuntimeContext) CopyPrimary(what, text string) {
+	if !rtx.CopyOutput || text == "" {
+		return
+	}
+	err := clipboard.Write(context.WithoutCancel(rtx.Context), text)
+	switch {
+	case errors.Is(err, clipboard.ErrUnavailable):
+		rtx.Logger.Warn("could not copy the %s: %v; copy it from the output instead", what, err)
+	case err != nil:
+		rtx.Logger.Warn("could not copy the %s: %v", what, err)
+	default:
+		rtx.Logger.Info("copied the %s to the clipboard", what)
+	}
+}
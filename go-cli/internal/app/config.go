@@ -1,14 +1,24 @@
 package app
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/go-viper/mapstructure/v2"
 	"github.com/spf13/viper"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/runner"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/tracing"
 )
 
 // configSchemaJSON contains the JSON schema for the config file.
@@ -29,6 +39,46 @@ const configSchemaJSON = `{
       "description": "Active configuration profile",
       "default": "default"
     },
+    "taskfile": {
+      "type": "string",
+      "description": "Path of the declarative task file, relative to the working directory",
+      "default": "tasks.toml"
+    },
+    "tasks": {
+      "type": "object",
+      "description": "Inline command task declarations keyed by task name",
+      "additionalProperties": { "$ref": "#/definitions/taskSpec" }
+    },
+    "profiles": {
+      "type": "object",
+      "description": "Named overlays: the table of the active profile is merged over the rest of the config",
+      "propertyNames": { "pattern": "^[A-Za-z0-9_-]+$" },
+      "additionalProperties": { "type": "object" }
+    },
+    "aliases": {
+      "type": "object",
+      "description": "Command aliases: each name expands to the command line it maps to, followed by any further arguments",
+      "additionalProperties": { "type": "string", "minLength": 1 }
+    },
+    "remotes": {
+      "type": "object",
+      "description": "Hosts run --on executes command tasks on over SSH, keyed by remote name",
+      "propertyNames": { "pattern": "^[^,\\s]+$" },
+      "additionalProperties": {
+        "type": "object",
+        "required": ["host"],
+        "properties": {
+          "host": { "type": "string", "minLength": 1, "description": "Host name or address" },
+          "user": { "type": "string", "description": "Login user; defaults to the local user name" },
+          "port": { "type": "integer", "minimum": 1, "maximum": 65535, "default": 22 },
+          "identity_file": { "type": "string", "description": "Private key tried after the SSH agent; defaults to the unencrypted keys in ~/.ssh" },
+          "known_hosts": { "type": "string", "description": "known_hosts file the host key is checked against; defaults to ~/.ssh/known_hosts" },
+          "dir": { "type": "string", "description": "Remote directory tasks run in; defaults to the login directory" },
+          "tags": { "type": "array", "items": { "type": "string", "pattern": "^[^,\\s]+$" }, "description": "Groups selectable with run --on TAG" }
+        },
+        "additionalProperties": false
+      }
+    },
     "logging": {
       "type": "object",
       "description": "Logging configuration",
@@ -57,20 +107,104 @@ const configSchemaJSON = `{
       "description": "Runtime configuration",
       "properties": {
         "parallelism": {
-          "type": "integer",
-          "description": "Worker pool size. Defaults to logical CPU count.",
-          "minimum": 1
+          "description": "Worker pool size, a percentage of the CPUs such as \"50%\", or \"auto\" to adapt to system load. Defaults to logical CPU count.",
+          "oneOf": [
+            { "type": "integer", "minimum": 1 },
+            { "type": "string", "pattern": "^[1-9][0-9]*%$" },
+            { "type": "string", "enum": ["auto"] }
+          ]
         },
         "timeout": {
-          "type": "integer",
-          "description": "Timeout in seconds for long-running operations",
-          "default": 60,
-          "minimum": 1
+          "description": "Time limit for long-running operations, as a duration such as 90s or 2m30s, or whole seconds for compatibility; 0 disables it",
+          "oneOf": [
+            { "$ref": "#/definitions/duration" },
+            { "type": "integer", "minimum": 0 }
+          ],
+          "default": "1m"
         },
         "fail_fast": {
           "type": "boolean",
           "description": "Stop on first error",
           "default": true
+        },
+        "max_input": {
+          "type": "integer",
+          "description": "Largest payload, in bytes, that a task declaring an input reads from stdin",
+          "default": 10485760,
+          "minimum": 1
+        },
+        "retry": {
+          "type": "object",
+          "description": "Retry policy applied by the runner around each task",
+          "properties": {
+            "max_attempts": {
+              "type": "integer",
+              "description": "Total attempts per task; 1 disables retries",
+              "default": 1,
+              "minimum": 1
+            },
+            "initial_delay": {
+              "$ref": "#/definitions/duration",
+              "description": "Backoff before the first retry; doubles on each further attempt",
+              "default": "1s"
+            },
+            "max_delay": {
+              "$ref": "#/definitions/duration",
+              "description": "Upper bound for the backoff between attempts",
+              "default": "30s"
+            },
+            "jitter": {
+              "type": "number",
+              "description": "Randomize each wait by up to this fraction of its length",
+              "default": 0.2,
+              "minimum": 0,
+              "maximum": 1
+            },
+            "retry_on": {
+              "$ref": "#/definitions/retryClasses",
+              "default": ["transient", "timeout"]
+            },
+            "tasks": {
+              "type": "object",
+              "description": "Per-task overrides keyed by task name",
+              "additionalProperties": {
+                "type": "object",
+                "properties": {
+                  "max_attempts": { "type": "integer", "minimum": 1 },
+                  "initial_delay": { "$ref": "#/definitions/duration" },
+                  "max_delay": { "$ref": "#/definitions/duration" },
+                  "jitter": { "type": "number", "minimum": 0, "maximum": 1 },
+                  "retry_on": { "$ref": "#/definitions/retryClasses" }
+                },
+                "additionalProperties": false
+              }
+            }
+          },
+          "additionalProperties": false
+        },
+        "rate_limit": {
+          "type": "object",
+          "description": "Token-bucket throttle for task attempts across all workers",
+          "properties": {
+            "rate": {
+              "type": "number",
+              "description": "Sustained task attempts per second; 0 disables rate limiting",
+              "default": 0,
+              "minimum": 0
+            },
+            "burst": {
+              "type": "integer",
+              "description": "Attempts allowed back-to-back before the rate applies",
+              "default": 1,
+              "minimum": 1
+            }
+          },
+          "additionalProperties": false
+        },
+        "priority": {
+          "type": "object",
+          "description": "Queue priority per task name; higher starts first when more tasks are ready than workers",
+          "additionalProperties": { "type": "integer" }
         }
       },
       "additionalProperties": false
@@ -89,9 +223,493 @@ const configSchemaJSON = `{
         }
       },
       "additionalProperties": false
+    },
+    "output": {
+      "type": "object",
+      "description": "Human-readable output settings",
+      "properties": {
+        "unicode": {
+          "type": "boolean",
+          "description": "Use Unicode glyphs, spinners, and box drawing. Set to false for plain ASCII.",
+          "default": true
+        },
+        "summary": {
+          "type": "boolean",
+          "description": "Print an end-of-run summary and include it in JSON/YAML results",
+          "default": true
+        },
+        "accessible": {
+          "type": "boolean",
+          "description": "Report progress as plain periodic \"N of M complete\" lines for screen readers instead of spinners and redrawn bars. Also on when TERM=dumb or ACCESSIBLE is set.",
+          "default": false
+        },
+        "clipboard": {
+          "type": "boolean",
+          "description": "Also put the primary output of commands that support --copy (config path, run ID, auth token) on the system clipboard.",
+          "default": false
+        }
+      },
+      "additionalProperties": false
+    },
+    "hooks": {
+      "type": "object",
+      "description": "Shell commands run before and after every run",
+      "properties": {
+        "pre_run": {
+          "type": "array",
+          "description": "Commands run before the task; they receive GO_CLI_RUN_ID, GO_CLI_TASK, and GO_CLI_PROFILE",
+          "items": { "type": "string" },
+          "default": []
+        },
+        "post_run": {
+          "type": "array",
+          "description": "Commands run after the task; they also receive GO_CLI_RUN_STATUS and GO_CLI_EXIT_STATUS",
+          "items": { "type": "string" },
+          "default": []
+        },
+        "pre_run_failure": {
+          "type": "string",
+          "description": "What a failing pre_run hook does",
+          "enum": ["abort", "warn", "ignore"],
+          "default": "abort"
+        },
+        "post_run_failure": {
+          "type": "string",
+          "description": "What a failing post_run hook does",
+          "enum": ["warn", "fail", "ignore"],
+          "default": "warn"
+        },
+        "timeout": {
+          "$ref": "#/definitions/duration",
+          "description": "Time limit per hook command; 0s disables it",
+          "default": "1m"
+        }
+      },
+      "additionalProperties": false
+    },
+    "exec": {
+      "type": "object",
+      "description": "Environment of task and hook processes",
+      "properties": {
+        "env_passthrough": {
+          "type": "array",
+          "description": "Variables children inherit; a trailing * matches by prefix and \"*\" passes everything",
+          "items": { "type": "string" },
+          "default": ["PATH", "HOME", "USER", "LOGNAME", "SHELL", "TERM", "TMPDIR", "TZ", "LANG", "LC_*", "SYSTEMROOT", "WINDIR", "COMSPEC", "PATHEXT", "TEMP", "TMP", "USERPROFILE", "APPDATA", "LOCALAPPDATA"]
+        },
+        "env": {
+          "type": "array",
+          "description": "KEY=VALUE pairs set for every task and hook process",
+          "items": { "type": "string", "pattern": "^[^=]+=" },
+          "default": []
+        }
+      },
+      "additionalProperties": false
+    },
+    "serve": {
+      "type": "object",
+      "description": "HTTP API served by the serve command",
+      "properties": {
+        "addr": {
+          "type": "string",
+          "description": "host:port to listen on",
+          "default": "127.0.0.1:8765"
+        },
+        "token": {
+          "type": "string",
+          "description": "Bearer token clients must send; required unless addr is a loopback address"
+        },
+        "grpc_addr": {
+          "type": "string",
+          "description": "Also serve the gRPC control API on host:port or unix:PATH; requires token unless local"
+        }
+      },
+      "additionalProperties": false
+    },
+    "retention": {
+      "type": "object",
+      "description": "What prune keeps of task logs, run history, and run artifacts",
+      "properties": {
+        "max_age": {
+          "type": "string",
+          "description": "How long entries are kept, as a Go duration or whole days such as 30d; 0s disables pruning by age",
+          "pattern": "^([0-9]+d|([0-9]+(\\.[0-9]+)?(ns|us|µs|ms|s|m|h))+)$",
+          "default": "30d"
+        },
+        "keep_last": {
+          "type": "integer",
+          "description": "Newest runs always kept, whatever their age",
+          "minimum": 0,
+          "default": 10
+        }
+      },
+      "additionalProperties": false
+    },
+    "http": {
+      "type": "object",
+      "description": "Client for outbound HTTP requests; requests time out after runtime.timeout",
+      "properties": {
+        "max_retries": {
+          "type": "integer",
+          "description": "Retries after a 5xx or 429 response or a broken connection; 0 disables retries",
+          "minimum": 0,
+          "default": 3
+        },
+        "proxy": {
+          "type": "string",
+          "description": "Proxy URL for every request; unset uses HTTPS_PROXY and HTTP_PROXY"
+        },
+        "no_proxy": {
+          "type": "array",
+          "description": "Hosts reached without the proxy, added to NO_PROXY: names, domain suffixes such as .corp.example, IPs, and CIDR ranges",
+          "items": { "type": "string", "minLength": 1 },
+          "default": []
+        },
+        "ca_bundle": {
+          "type": "string",
+          "description": "PEM bundle trusted in addition to the system roots, e.g. the CA of a TLS-intercepting proxy. Supports ~ and environment variables."
+        },
+        "insecure_skip_verify": {
+          "type": "boolean",
+          "description": "Accept any server certificate; defeats TLS, for diagnosing certificate problems only",
+          "default": false
+        }
+      },
+      "additionalProperties": false
+    },
+    "telemetry": {
+      "type": "object",
+      "description": "OpenTelemetry settings",
+      "properties": {
+        "traces": {
+          "type": "object",
+          "description": "OTLP export of spans for config loading, the command, and each task",
+          "properties": {
+            "endpoint": {
+              "type": "string",
+              "description": "Collector URL such as http://localhost:4318; empty disables exporting",
+              "default": ""
+            },
+            "protocol": {
+              "type": "string",
+              "description": "OTLP transport",
+              "enum": ["http/protobuf", "grpc"],
+              "default": "http/protobuf"
+            },
+            "headers": {
+              "type": "object",
+              "description": "Headers sent with every export",
+              "additionalProperties": { "type": "string" }
+            },
+            "sample_ratio": {
+              "type": "number",
+              "description": "Share of new traces recorded",
+              "minimum": 0,
+              "maximum": 1,
+              "default": 1
+            }
+          },
+          "additionalProperties": false
+        }
+      },
+      "additionalProperties": false
+    },
+    "notifications": {
+      "type": "object",
+      "description": "Messages sent when a run ends",
+      "properties": {
+        "desktop": {
+          "type": "boolean",
+          "description": "Show a desktop notification when a long run finishes; run --notify overrides it",
+          "default": false
+        },
+        "desktop_after": {
+          "$ref": "#/definitions/duration",
+          "description": "Shortest run that gets a desktop notification",
+          "default": "30s"
+        },
+        "artifacts_url": {
+          "type": "string",
+          "description": "Base URL of the artifacts link in chat messages; the run ID is appended. Empty links the local directory",
+          "default": ""
+        },
+        "webhook": {
+          "type": "object",
+          "description": "POST a JSON summary of every finished run",
+          "properties": {
+            "url": {
+              "type": "string",
+              "description": "http:// or https:// URL to POST to; empty disables the webhook",
+              "default": ""
+            },
+            "headers": {
+              "type": "object",
+              "description": "Headers sent with every notification",
+              "additionalProperties": { "type": "string" }
+            },
+            "template": {
+              "type": "string",
+              "description": "Go text/template for the request body; empty sends the run summary as JSON"
+            },
+            "only_on_failure": {
+              "type": "boolean",
+              "description": "Only notify when a run did not succeed",
+              "default": false
+            }
+          },
+          "additionalProperties": false
+        },
+        "slack": {
+          "type": "object",
+          "description": "Post a message about every finished run to a Slack incoming webhook",
+          "properties": {
+            "url": {
+              "type": "string",
+              "description": "Incoming webhook URL; empty disables it",
+              "default": ""
+            },
+            "template": {
+              "type": "string",
+              "description": "Go text/template for the message text; empty uses the built-in summary"
+            },
+            "only_on_failure": {
+              "type": "boolean",
+              "description": "Only notify when a run did not succeed",
+              "default": false
+            }
+          },
+          "additionalProperties": false
+        },
+        "teams": {
+          "type": "object",
+          "description": "Post a message about every finished run to a Microsoft Teams incoming webhook",
+          "properties": {
+            "url": {
+              "type": "string",
+              "description": "Incoming webhook URL; empty disables it",
+              "default": ""
+            },
+            "template": {
+              "type": "string",
+              "description": "Go text/template for the message text; empty uses the built-in summary"
+            },
+            "only_on_failure": {
+              "type": "boolean",
+              "description": "Only notify when a run did not succeed",
+              "default": false
+            }
+          },
+          "additionalProperties": false
+        }
+      },
+      "additionalProperties": false
+    },
+    "storage": {
+      "type": "object",
+      "description": "Where finished runs are copied besides the data directory",
+      "properties": {
+        "backend": {
+          "type": "string",
+          "description": "local copies runs to local.path if set; s3 uploads them to a bucket",
+          "enum": ["local", "s3"],
+          "default": "local"
+        },
+        "local": {
+          "type": "object",
+          "properties": {
+            "path": {
+              "type": "string",
+              "description": "Directory runs are copied to; empty keeps them in the data directory only",
+              "default": ""
+            }
+          },
+          "additionalProperties": false
+        },
+        "s3": {
+          "type": "object",
+          "description": "S3-compatible bucket used by the s3 backend",
+          "properties": {
+            "endpoint": {
+              "type": "string",
+              "description": "Service URL; empty means AWS in region",
+              "default": ""
+            },
+            "region": { "type": "string", "default": "us-east-1" },
+            "bucket": { "type": "string", "default": "" },
+            "prefix": {
+              "type": "string",
+              "description": "Prepended to every key",
+              "default": ""
+            },
+            "path_style": {
+              "type": "boolean",
+              "description": "Address the bucket as ENDPOINT/BUCKET; needed by most self-hosted services",
+              "default": false
+            },
+            "access_key_id": {
+              "type": "string",
+              "description": "Empty falls back to AWS_ACCESS_KEY_ID"
+            },
+            "secret_access_key": {
+              "type": "string",
+              "description": "Empty falls back to AWS_SECRET_ACCESS_KEY"
+            },
+            "session_token": {
+              "type": "string",
+              "description": "Empty falls back to AWS_SESSION_TOKEN"
+            }
+          },
+          "additionalProperties": false
+        }
+      },
+      "additionalProperties": false
+    },
+    "auth": {
+      "type": "object",
+      "description": "OAuth2 device authorization flow used by auth login",
+      "properties": {
+        "client_id": {
+          "type": "string",
+          "description": "Public client ID registered with the provider; empty disables auth",
+          "default": ""
+        },
+        "device_url": {
+          "type": "string",
+          "description": "Device authorization endpoint",
+          "default": ""
+        },
+        "token_url": {
+          "type": "string",
+          "description": "Token endpoint",
+          "default": ""
+        },
+        "scopes": {
+          "type": "array",
+          "description": "Scopes requested at login",
+          "items": { "type": "string" },
+          "default": []
+        }
+      },
+      "additionalProperties": false
+    },
+    "daemon": {
+      "type": "object",
+      "description": "Loops run by daemon start",
+      "properties": {
+        "scheduler": {
+          "type": "boolean",
+          "description": "Run schedule entries as they come due",
+          "default": true
+        },
+        "watch_task": {
+          "type": "string",
+          "description": "Task re-run when [watch] paths change; empty disables the watcher",
+          "default": ""
+        }
+      },
+      "additionalProperties": false
+    },
+    "watch": {
+      "type": "object",
+      "description": "Settings for run --watch",
+      "properties": {
+        "paths": {
+          "type": "array",
+          "description": "Glob patterns that trigger a re-run; ** matches any number of directories",
+          "items": { "type": "string" },
+          "default": ["**/*"]
+        },
+        "ignore": {
+          "type": "array",
+          "description": "Glob patterns for files and directories to ignore",
+          "items": { "type": "string" },
+          "default": [".git", "dist"]
+        },
+        "debounce": {
+          "$ref": "#/definitions/duration",
+          "description": "Quiet period before a batch of changes triggers a run",
+          "default": "300ms"
+        },
+        "clear_screen": {
+          "type": "boolean",
+          "description": "Clear the terminal before each re-run",
+          "default": false
+        }
+      },
+      "additionalProperties": false
     }
   },
-  "additionalProperties": false
+  "additionalProperties": false,
+  "definitions": {
+    "duration": {
+      "type": "string",
+      "description": "Go duration string such as 500ms, 30s, or 2m30s",
+      "pattern": "^([0-9]+(\\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$"
+    },
+    "taskSpec": {
+      "type": "object",
+      "description": "A task that runs shell commands in order",
+      "properties": {
+        "description": { "type": "string", "description": "One-line summary shown by run --list" },
+        "cmds": {
+          "type": "array",
+          "description": "Shell commands run in order; the first failure fails the task",
+          "items": { "type": "string" }
+        },
+        "deps": {
+          "type": "array",
+          "description": "Tasks that must succeed first",
+          "items": { "type": "string" }
+        },
+        "dir": { "type": "string", "description": "Working directory for the commands" },
+        "env": {
+          "type": "array",
+          "description": "Extra environment variables as KEY=VALUE",
+          "items": { "type": "string", "pattern": "^[^=]+=" }
+        },
+        "priority": {
+          "type": "integer",
+          "description": "Queue priority; higher starts first when more tasks are ready than workers",
+          "default": 0
+        },
+        "params": {
+          "type": "object",
+          "description": "Parameters accepted through run --param NAME=VALUE, referenced in cmds as {{.name}} and exported as GO_CLI_PARAM_<NAME>",
+          "propertyNames": { "pattern": "^[a-z][a-z0-9_]*$" },
+          "additionalProperties": {
+            "type": "object",
+            "properties": {
+              "type": { "type": "string", "enum": ["string", "int", "float", "bool"], "default": "string" },
+              "description": { "type": "string" },
+              "required": { "type": "boolean", "default": false },
+              "default": { "type": ["string", "number", "boolean"] },
+              "choices": { "type": "array", "items": { "type": "string" } }
+            },
+            "additionalProperties": false
+          }
+        },
+        "inputs": {
+          "type": "array",
+          "description": "Globs (relative to dir, ** for any depth) of files the task reads; the task is skipped while they and its definition are unchanged",
+          "items": { "type": "string" }
+        },
+        "input": {
+          "type": "string",
+          "description": "Read a payload piped to run and pass it to cmds on stdin, with its format in GO_CLI_INPUT_FORMAT; auto accepts JSON, YAML, or text",
+          "enum": ["auto", "json", "yaml", "text"]
+        }
+      },
+      "additionalProperties": false
+    },
+    "retryClasses": {
+      "type": "array",
+      "description": "Error classes worth retrying",
+      "items": {
+        "type": "string",
+        "enum": ["any", "transient", "timeout"]
+      }
+    }
+  }
 }
 `
 
@@ -101,6 +719,44 @@ type AppConfig struct {
 	Logging LoggingConfig `mapstructure:"logging" json:"logging" yaml:"logging"`
 	Runtime RuntimeConfig `mapstructure:"runtime" json:"runtime" yaml:"runtime"`
 	Paths   PathsConfig   `mapstructure:"paths" json:"paths" yaml:"paths"`
+	Output  OutputConfig  `mapstructure:"output" json:"output" yaml:"output"`
+	Watch   WatchConfig   `mapstructure:"watch" json:"watch" yaml:"watch"`
+	Hooks   HooksConfig   `mapstructure:"hooks" json:"hooks" yaml:"hooks"`
+	Daemon  DaemonConfig  `mapstructure:"daemon" json:"daemon" yaml:"daemon"`
+	Exec    ExecConfig    `mapstructure:"exec" json:"exec" yaml:"exec"`
+	Serve   ServeConfig   `mapstructure:"serve" json:"serve" yaml:"serve"`
+	// Retention is what `prune` keeps.
+	Retention RetentionConfig `mapstructure:"retention" json:"retention" yaml:"retention"`
+	// HTTP configures the client outbound requests share.
+	HTTP HTTPConfig `mapstructure:"http" json:"http" yaml:"http"`
+	// Telemetry configures trace export.
+	Telemetry TelemetryConfig `mapstructure:"telemetry" json:"telemetry" yaml:"telemetry"`
+	// Notifications announce finished runs.
+	Notifications NotificationsConfig `mapstructure:"notifications" json:"notifications" yaml:"notifications"`
+	// Storage is where finished runs are persisted besides the data
+	// directory.
+	Storage StorageConfig `mapstructure:"storage" json:"storage" yaml:"storage"`
+	// Auth configures `auth login` against an OAuth2 provider.
+	Auth AuthConfig `mapstructure:"auth" json:"auth" yaml:"auth"`
+	// Taskfile is the path of the declarative task file, relative to the
+	// working directory unless absolute.
+	Taskfile string `mapstructure:"taskfile" json:"taskfile" yaml:"taskfile"`
+	// Tasks declares command tasks inline; a taskfile can add more.
+	Tasks map[string]TaskSpec `mapstructure:"tasks" json:"tasks,omitempty" yaml:"tasks,omitempty"`
+	// Aliases maps alias names to the command lines they expand to.
+	Aliases map[string]string `mapstructure:"aliases" json:"aliases,omitempty" yaml:"aliases,omitempty"`
+	// Remotes are the hosts `run --on` runs tasks on over SSH.
+	Remotes map[string]RemoteConfig `mapstructure:"remotes" json:"remotes,omitempty" yaml:"remotes,omitempty"`
+	// Profiles holds, per profile name, settings that override the
+	// top-level ones while that profile is active.
+	Profiles map[string]map[string]any `mapstructure:"profiles" json:"profiles,omitempty" yaml:"profiles,omitempty"`
+
+	// unprofiled is the config before the active profile's table was
+	// applied, kept so WithProfileOverride can switch profiles.
+	unprofiled *AppConfig
+	// overrides are the --set values, applied again over a profile
+	// WithProfileOverride switches to.
+	overrides map[string]string
 }
 
 // LoggingConfig controls log output.
@@ -112,9 +768,50 @@ type LoggingConfig struct {
 
 // RuntimeConfig contains runtime tuning parameters.
 type RuntimeConfig struct {
-	Parallelism    *int `mapstructure:"parallelism" json:"parallelism,omitempty" yaml:"parallelism,omitempty"`
-	TimeoutSeconds *int `mapstructure:"timeout" json:"timeout,omitempty" yaml:"timeout,omitempty"`
-	FailFast       bool `mapstructure:"fail_fast" json:"fail_fast" yaml:"fail_fast"`
+	Parallelism *Parallelism    `mapstructure:"parallelism" json:"parallelism,omitempty" yaml:"parallelism,omitempty"`
+	Timeout     *Timeout        `mapstructure:"timeout" json:"timeout,omitempty" yaml:"timeout,omitempty"`
+	FailFast    bool            `mapstructure:"fail_fast" json:"fail_fast" yaml:"fail_fast"`
+	Retry       RetryConfig     `mapstructure:"retry" json:"retry" yaml:"retry"`
+	RateLimit   RateLimitConfig `mapstructure:"rate_limit" json:"rate_limit" yaml:"rate_limit"`
+	// MaxInput caps, in bytes, the payload a task with an input reads
+	// from stdin.
+	MaxInput int64 `mapstructure:"max_input" json:"max_input" yaml:"max_input"`
+	// Priority maps task names to queue priorities; higher runs first when
+	// more tasks are ready than there are workers.
+	Priority map[string]int `mapstructure:"priority" json:"priority,omitempty" yaml:"priority,omitempty"`
+}
+
+// RateLimitConfig throttles task attempts with a token bucket.
+type RateLimitConfig struct {
+	// Rate is the sustained number of operations per second; 0 disables
+	// rate limiting.
+	Rate  float64 `mapstructure:"rate" json:"rate" yaml:"rate"`
+	Burst int     `mapstructure:"burst" json:"burst" yaml:"burst"`
+}
+
+// Limiter returns the runner limiter for these settings, or nil when rate
+// limiting is disabled.
+func (cfg RateLimitConfig) Limiter() *runner.Limiter {
+	return runner.NewLimiter(cfg.Rate, cfg.Burst)
+}
+
+// RetryConfig controls how the runner retries failed tasks.
+type RetryConfig struct {
+	MaxAttempts  int                      `mapstructure:"max_attempts" json:"max_attempts" yaml:"max_attempts"`
+	InitialDelay Duration                 `mapstructure:"initial_delay" json:"initial_delay" yaml:"initial_delay"`
+	MaxDelay     Duration                 `mapstructure:"max_delay" json:"max_delay" yaml:"max_delay"`
+	Jitter       float64                  `mapstructure:"jitter" json:"jitter" yaml:"jitter"`
+	RetryOn      []string                 `mapstructure:"retry_on" json:"retry_on" yaml:"retry_on"`
+	Tasks        map[string]RetryOverride `mapstructure:"tasks" json:"tasks,omitempty" yaml:"tasks,omitempty"`
+}
+
+// RetryOverride replaces individual retry settings for one task.
+type RetryOverride struct {
+	MaxAttempts  *int      `mapstructure:"max_attempts" json:"max_attempts,omitempty" yaml:"max_attempts,omitempty"`
+	InitialDelay *Duration `mapstructure:"initial_delay" json:"initial_delay,omitempty" yaml:"initial_delay,omitempty"`
+	MaxDelay     *Duration `mapstructure:"max_delay" json:"max_delay,omitempty" yaml:"max_delay,omitempty"`
+	Jitter       *float64  `mapstructure:"jitter" json:"jitter,omitempty" yaml:"jitter,omitempty"`
+	RetryOn      []string  `mapstructure:"retry_on" json:"retry_on,omitempty" yaml:"retry_on,omitempty"`
 }
 
 // PathsConfig lets users override data/state locations.
@@ -123,6 +820,202 @@ type PathsConfig struct {
 	StateDir string `mapstructure:"state_dir" json:"state_dir,omitempty" yaml:"state_dir,omitempty"`
 }
 
+// OutputConfig controls how human-readable output is rendered.
+type OutputConfig struct {
+	Unicode bool `mapstructure:"unicode" json:"unicode" yaml:"unicode"`
+	Summary bool `mapstructure:"summary" json:"summary" yaml:"summary"`
+	// Accessible reports progress as plain "N of M complete" lines; see
+	// RuntimeContext.Accessible.
+	Accessible bool `mapstructure:"accessible" json:"accessible" yaml:"accessible"`
+	// Clipboard turns --copy on for every command that supports it.
+	Clipboard bool `mapstructure:"clipboard" json:"clipboard" yaml:"clipboard"`
+}
+
+// HooksConfig lists commands run around every `run`.
+type HooksConfig struct {
+	PreRun  []string `mapstructure:"pre_run" json:"pre_run" yaml:"pre_run"`
+	PostRun []string `mapstructure:"post_run" json:"post_run" yaml:"post_run"`
+	// PreRunFailure is abort (skip the run), warn, or ignore.
+	PreRunFailure string `mapstructure:"pre_run_failure" json:"pre_run_failure" yaml:"pre_run_failure"`
+	// PostRunFailure is warn, fail (turn a successful run into a failure),
+	// or ignore.
+	PostRunFailure string   `mapstructure:"post_run_failure" json:"post_run_failure" yaml:"post_run_failure"`
+	Timeout        Duration `mapstructure:"timeout" json:"timeout" yaml:"timeout"`
+}
+
+// WatchConfig controls `run --watch`.
+type WatchConfig struct {
+	Paths       []string `mapstructure:"paths" json:"paths" yaml:"paths"`
+	Ignore      []string `mapstructure:"ignore" json:"ignore" yaml:"ignore"`
+	Debounce    Duration `mapstructure:"debounce" json:"debounce" yaml:"debounce"`
+	ClearScreen bool     `mapstructure:"clear_screen" json:"clear_screen" yaml:"clear_screen"`
+}
+
+// ExecConfig controls the environment of task and hook processes.
+type ExecConfig struct {
+	// EnvPassthrough names the variables children inherit; a trailing *
+	// matches by prefix and "*" passes everything.
+	EnvPassthrough []string `mapstructure:"env_passthrough" json:"env_passthrough" yaml:"env_passthrough"`
+	// Env holds KEY=VALUE pairs set for every child.
+	Env []string `mapstructure:"env" json:"env" yaml:"env"`
+}
+
+// defaultEnvPassthrough is the minimal environment shells and common tools
+// need; anything else, notably tokens and credentials, must be allowed
+// explicitly.
+var defaultEnvPassthrough = []string{
+	"PATH", "HOME", "USER", "LOGNAME", "SHELL", "TERM", "TMPDIR", "TZ", "LANG", "LC_*",
+	// Windows
+	"SYSTEMROOT", "WINDIR", "COMSPEC", "PATHEXT", "TEMP", "TMP", "USERPROFILE", "APPDATA", "LOCALAPPDATA",
+}
+
+// DaemonConfig selects the loops `daemon start` runs.
+type DaemonConfig struct {
+	// Scheduler runs the `schedule` entries as they come due.
+	Scheduler bool `mapstructure:"scheduler" json:"scheduler" yaml:"scheduler"`
+	// WatchTask is re-run whenever [watch] paths change; empty disables the
+	// watcher.
+	WatchTask string `mapstructure:"watch_task" json:"watch_task" yaml:"watch_task"`
+}
+
+// ServeConfig configures the `serve` HTTP API.
+type ServeConfig struct {
+	// Addr is the host:port to listen on.
+	Addr string `mapstructure:"addr" json:"addr" yaml:"addr"`
+	// Token, when set, must be sent as "Authorization: Bearer <token>".
+	// It is required unless Addr is a loopback address.
+	Token string `mapstructure:"token" json:"token,omitempty" yaml:"token,omitempty"`
+	// GRPCAddr, when set, also serves the gRPC control API on host:port
+	// or a Unix socket given as unix:PATH.
+	GRPCAddr string `mapstructure:"grpc_addr" json:"grpc_addr,omitempty" yaml:"grpc_addr,omitempty"`
+}
+
+// RetentionConfig sets what `prune` removes by default.
+type RetentionConfig struct {
+	// MaxAge is how long task logs, history entries, and run artifacts are
+	// kept; 0 disables pruning by age.
+	MaxAge Duration `mapstructure:"max_age" json:"max_age" yaml:"max_age"`
+	// KeepLast always keeps the newest KeepLast runs of each kind.
+	KeepLast int `mapstructure:"keep_last" json:"keep_last" yaml:"keep_last"`
+}
+
+// HTTPConfig configures the shared HTTP client; see
+// RuntimeContext.HTTPClient. Requests time out after runtime.timeout.
+type HTTPConfig struct {
+	// MaxRetries is how often a request is repeated after a 5xx or 429
+	// response or a broken connection; 0 disables retries.
+	MaxRetries int `mapstructure:"max_retries" json:"max_retries" yaml:"max_retries"`
+	// Proxy is the proxy URL for every request; empty uses HTTPS_PROXY and
+	// HTTP_PROXY.
+	Proxy string `mapstructure:"proxy" json:"proxy,omitempty" yaml:"proxy,omitempty"`
+	// NoProxy lists hosts, domain suffixes, IPs, and CIDR ranges reached
+	// without the proxy, in addition to NO_PROXY.
+	NoProxy []string `mapstructure:"no_proxy" json:"no_proxy" yaml:"no_proxy"`
+	// CABundle is a PEM bundle trusted in addition to the system roots.
+	CABundle string `mapstructure:"ca_bundle" json:"ca_bundle,omitempty" yaml:"ca_bundle,omitempty"`
+	// InsecureSkipVerify accepts any server certificate. It defeats TLS;
+	// use it only to diagnose certificate problems.
+	InsecureSkipVerify bool `mapstructure:"insecure_skip_verify" json:"insecure_skip_verify" yaml:"insecure_skip_verify"`
+}
+
+// TelemetryConfig groups the OpenTelemetry settings.
+type TelemetryConfig struct {
+	Traces TracesConfig `mapstructure:"traces" json:"traces" yaml:"traces"`
+}
+
+// TracesConfig sends spans for config loading, the command, and each task
+// to an OTLP collector.
+type TracesConfig struct {
+	// Endpoint is the collector URL; empty disables exporting.
+	Endpoint string `mapstructure:"endpoint" json:"endpoint,omitempty" yaml:"endpoint,omitempty"`
+	// Protocol is "http/protobuf" or "grpc".
+	Protocol string `mapstructure:"protocol" json:"protocol" yaml:"protocol"`
+	// Headers are sent with every export, e.g. an API key.
+	Headers map[string]string `mapstructure:"headers" json:"headers,omitempty" yaml:"headers,omitempty"`
+	// SampleRatio is the share of new traces recorded, 0 to 1.
+	SampleRatio float64 `mapstructure:"sample_ratio" json:"sample_ratio" yaml:"sample_ratio"`
+}
+
+// NotificationsConfig groups the ways finished runs are announced.
+type NotificationsConfig struct {
+	// Desktop shows a desktop notification when a run that took at least
+	// DesktopAfter finishes; run --notify overrides it.
+	Desktop      bool     `mapstructure:"desktop" json:"desktop" yaml:"desktop"`
+	DesktopAfter Duration `mapstructure:"desktop_after" json:"desktop_after" yaml:"desktop_after"`
+	// ArtifactsURL is the base of the artifacts link in chat messages, e.g.
+	// a web server exposing the runs directory; the run ID is appended.
+	// Empty links the run's directory as a file:// URL.
+	ArtifactsURL string        `mapstructure:"artifacts_url" json:"artifacts_url,omitempty" yaml:"artifacts_url,omitempty"`
+	Webhook      WebhookConfig `mapstructure:"webhook" json:"webhook" yaml:"webhook"`
+	Slack        ChatConfig    `mapstructure:"slack" json:"slack" yaml:"slack"`
+	Teams        ChatConfig    `mapstructure:"teams" json:"teams" yaml:"teams"`
+}
+
+// WebhookConfig POSTs a summary of every finished run; see RunNotification.
+type WebhookConfig struct {
+	// URL receives the POST; empty disables the webhook.
+	URL string `mapstructure:"url" json:"url,omitempty" yaml:"url,omitempty"`
+	// Headers are sent with every notification, e.g. an API key.
+	Headers map[string]string `mapstructure:"headers" json:"headers,omitempty" yaml:"headers,omitempty"`
+	// Template is a text/template rendering the body from a
+	// RunNotification; empty sends it as JSON.
+	Template string `mapstructure:"template" json:"template,omitempty" yaml:"template,omitempty"`
+	// OnlyOnFailure skips runs that succeeded.
+	OnlyOnFailure bool `mapstructure:"only_on_failure" json:"only_on_failure" yaml:"only_on_failure"`
+}
+
+// ChatConfig posts a message about every finished run to the incoming
+// webhook of a chat service (Slack or Microsoft Teams).
+type ChatConfig struct {
+	// URL is the incoming webhook; empty disables it.
+	URL string `mapstructure:"url" json:"url,omitempty" yaml:"url,omitempty"`
+	// Template is a text/template for the message text, rendered from a
+	// RunNotification; empty uses a summary with status, duration, failed
+	// tasks, and the artifacts link.
+	Template string `mapstructure:"template" json:"template,omitempty" yaml:"template,omitempty"`
+	// OnlyOnFailure skips runs that succeeded.
+	OnlyOnFailure bool `mapstructure:"only_on_failure" json:"only_on_failure" yaml:"only_on_failure"`
+}
+
+// Storage backends accepted by storage.backend.
+const (
+	StorageLocal = "local"
+	StorageS3    = "s3"
+)
+
+// StorageConfig selects the store finished runs are copied to: their
+// artifacts, manifest, and history entry. Runs always write to the data
+// directory first, since commands need a local path.
+type StorageConfig struct {
+	// Backend is StorageLocal or StorageS3.
+	Backend string             `mapstructure:"backend" json:"backend" yaml:"backend"`
+	Local   LocalStorageConfig `mapstructure:"local" json:"local" yaml:"local"`
+	S3      S3StorageConfig    `mapstructure:"s3" json:"s3" yaml:"s3"`
+}
+
+// LocalStorageConfig configures the local backend.
+type LocalStorageConfig struct {
+	// Path is a directory, e.g. a volume shared between CI jobs, that
+	// runs are copied to; empty keeps them in the data directory only.
+	Path string `mapstructure:"path" json:"path" yaml:"path"`
+}
+
+// S3StorageConfig configures the s3 backend. Empty credentials fall back
+// to AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and AWS_SESSION_TOKEN.
+type S3StorageConfig struct {
+	Endpoint        string `mapstructure:"endpoint" json:"endpoint" yaml:"endpoint"`
+	Region          string `mapstructure:"region" json:"region" yaml:"region"`
+	Bucket          string `mapstructure:"bucket" json:"bucket" yaml:"bucket"`
+	Prefix          string `mapstructure:"prefix" json:"prefix" yaml:"prefix"`
+	PathStyle       bool   `mapstructure:"path_style" json:"path_style" yaml:"path_style"`
+	AccessKeyID     string `mapstructure:"access_key_id" json:"access_key_id,omitempty" yaml:"access_key_id,omitempty"`
+	SecretAccessKey string `mapstructure:"secret_access_key" json:"secret_access_key,omitempty" yaml:"secret_access_key,omitempty"`
+	SessionToken    string `mapstructure:"session_token" json:"session_token,omitempty" yaml:"session_token,omitempty"`
+}
+
+// defaultServeAddr keeps the API on loopback, where no token is required.
+const defaultServeAddr = "127.0.0.1:8765"
+
 // RunConfig is the subset of AppConfig used by `run`.
 type RunConfig struct {
 	Profile string        `json:"profile" yaml:"profile"`
@@ -143,7 +1036,7 @@ func LoadOrInitConfig(paths AppPaths, flags CommonFlags) (AppConfig, error) {
 		return AppConfig{}, fmt.Errorf("failed to stat config file: %w", err)
 	}
 
-	cfg := defaultConfig()
+	defaults := defaultConfig()
 
 	v := viper.New()
 	v.SetConfigFile(paths.ConfigFile)
@@ -152,11 +1045,83 @@ func LoadOrInitConfig(paths AppPaths, flags CommonFlags) (AppConfig, error) {
 	v.SetEnvKeyReplacer(strings.NewReplacer(".", "__"))
 	v.AutomaticEnv()
 
-	v.SetDefault("profile", cfg.Profile)
-	v.SetDefault("logging.level", cfg.Logging.Level)
-	v.SetDefault("logging.format", cfg.Logging.Format)
-	v.SetDefault("runtime.timeout", 60)
+	v.SetDefault("profile", defaults.Profile)
+	v.SetDefault("taskfile", defaults.Taskfile)
+	v.SetDefault("logging.level", defaults.Logging.Level)
+	v.SetDefault("logging.format", defaults.Logging.Format)
+	v.SetDefault("runtime.timeout", "1m")
 	v.SetDefault("runtime.fail_fast", true)
+	v.SetDefault("runtime.max_input", defaults.Runtime.MaxInput)
+	v.SetDefault("runtime.retry.max_attempts", defaults.Runtime.Retry.MaxAttempts)
+	v.SetDefault("runtime.retry.initial_delay", defaults.Runtime.Retry.InitialDelay.String())
+	v.SetDefault("runtime.retry.max_delay", defaults.Runtime.Retry.MaxDelay.String())
+	v.SetDefault("runtime.retry.jitter", defaults.Runtime.Retry.Jitter)
+	v.SetDefault("runtime.retry.retry_on", defaults.Runtime.Retry.RetryOn)
+	v.SetDefault("runtime.rate_limit.rate", defaults.Runtime.RateLimit.Rate)
+	v.SetDefault("runtime.rate_limit.burst", defaults.Runtime.RateLimit.Burst)
+	v.SetDefault("output.unicode", defaults.Output.Unicode)
+	v.SetDefault("output.summary", defaults.Output.Summary)
+	v.SetDefault("output.accessible", defaults.Output.Accessible)
+	v.SetDefault("output.clipboard", defaults.Output.Clipboard)
+	v.SetDefault("watch.paths", defaults.Watch.Paths)
+	v.SetDefault("watch.ignore", defaults.Watch.Ignore)
+	v.SetDefault("watch.debounce", defaults.Watch.Debounce.String())
+	v.SetDefault("watch.clear_screen", defaults.Watch.ClearScreen)
+	v.SetDefault("hooks.pre_run", defaults.Hooks.PreRun)
+	v.SetDefault("hooks.post_run", defaults.Hooks.PostRun)
+	v.SetDefault("hooks.pre_run_failure", defaults.Hooks.PreRunFailure)
+	v.SetDefault("hooks.post_run_failure", defaults.Hooks.PostRunFailure)
+	v.SetDefault("hooks.timeout", defaults.Hooks.Timeout.String())
+	v.SetDefault("daemon.scheduler", defaults.Daemon.Scheduler)
+	v.SetDefault("exec.env_passthrough", defaults.Exec.EnvPassthrough)
+	v.SetDefault("exec.env", defaults.Exec.Env)
+	v.SetDefault("daemon.watch_task", defaults.Daemon.WatchTask)
+	v.SetDefault("serve.addr", defaults.Serve.Addr)
+	v.SetDefault("serve.token", defaults.Serve.Token)
+	v.SetDefault("serve.grpc_addr", defaults.Serve.GRPCAddr)
+	v.SetDefault("retention.max_age", defaults.Retention.MaxAge.String())
+	v.SetDefault("retention.keep_last", defaults.Retention.KeepLast)
+	v.SetDefault("http.max_retries", defaults.HTTP.MaxRetries)
+	v.SetDefault("http.proxy", defaults.HTTP.Proxy)
+	v.SetDefault("http.no_proxy", defaults.HTTP.NoProxy)
+	v.SetDefault("http.ca_bundle", defaults.HTTP.CABundle)
+	v.SetDefault("http.insecure_skip_verify", defaults.HTTP.InsecureSkipVerify)
+	v.SetDefault("telemetry.traces.endpoint", defaults.Telemetry.Traces.Endpoint)
+	v.SetDefault("telemetry.traces.protocol", defaults.Telemetry.Traces.Protocol)
+	v.SetDefault("telemetry.traces.sample_ratio", defaults.Telemetry.Traces.SampleRatio)
+	v.SetDefault("notifications.desktop", defaults.Notifications.Desktop)
+	v.SetDefault("notifications.desktop_after", defaults.Notifications.DesktopAfter.String())
+	v.SetDefault("notifications.webhook.url", defaults.Notifications.Webhook.URL)
+	v.SetDefault("notifications.webhook.template", defaults.Notifications.Webhook.Template)
+	v.SetDefault("notifications.webhook.only_on_failure", defaults.Notifications.Webhook.OnlyOnFailure)
+	v.SetDefault("notifications.artifacts_url", defaults.Notifications.ArtifactsURL)
+	for _, chat := range []struct {
+		name string
+		cfg  ChatConfig
+	}{{"slack", defaults.Notifications.Slack}, {"teams", defaults.Notifications.Teams}} {
+		v.SetDefault("notifications."+chat.name+".url", chat.cfg.URL)
+		v.SetDefault("notifications."+chat.name+".template", chat.cfg.Template)
+		v.SetDefault("notifications."+chat.name+".only_on_failure", chat.cfg.OnlyOnFailure)
+	}
+	v.SetDefault("storage.backend", defaults.Storage.Backend)
+	v.SetDefault("storage.local.path", defaults.Storage.Local.Path)
+	v.SetDefault("storage.s3.endpoint", defaults.Storage.S3.Endpoint)
+	v.SetDefault("storage.s3.region", defaults.Storage.S3.Region)
+	v.SetDefault("storage.s3.bucket", defaults.Storage.S3.Bucket)
+	v.SetDefault("storage.s3.prefix", defaults.Storage.S3.Prefix)
+	v.SetDefault("storage.s3.path_style", defaults.Storage.S3.PathStyle)
+	v.SetDefault("storage.s3.access_key_id", defaults.Storage.S3.AccessKeyID)
+	v.SetDefault("storage.s3.secret_access_key", defaults.Storage.S3.SecretAccessKey)
+	v.SetDefault("storage.s3.session_token", defaults.Storage.S3.SessionToken)
+	v.SetDefault("auth.client_id", defaults.Auth.ClientID)
+	v.SetDefault("auth.device_url", defaults.Auth.DeviceURL)
+	v.SetDefault("auth.token_url", defaults.Auth.TokenURL)
+	v.SetDefault("auth.scopes", defaults.Auth.Scopes)
+
+	overrides, err := flags.ConfigOverrides()
+	if err != nil {
+		return AppConfig{}, UsageError(err)
+	}
 
 	if err := v.ReadInConfig(); err != nil {
 		var notFound viper.ConfigFileNotFoundError
@@ -165,32 +1130,108 @@ func LoadOrInitConfig(paths AppPaths, flags CommonFlags) (AppConfig, error) {
 		}
 	}
 
-	if err := v.Unmarshal(&cfg); err != nil {
+	cfg, err := decodeConfig(v)
+	if err != nil {
+		return AppConfig{}, err
+	}
+	if err := validateProfiles(cfg); err != nil {
+		return AppConfig{}, err
+	}
+	// --set profile=NAME picks the table merged below.
+	if name, ok := overrides["profile"]; ok {
+		cfg.Profile = name
+	}
+
+	// The active profile's table is merged over the file, so environment
+	// overrides still take precedence over it.
+	if overlay := cfg.Profiles[cfg.Profile]; len(overlay) > 0 {
+		base := cfg
+		if err := v.MergeConfigMap(overlay); err != nil {
+			return AppConfig{}, fmt.Errorf("apply profile %s: %w", cfg.Profile, err)
+		}
+		if cfg, err = decodeConfig(v); err != nil {
+			return AppConfig{}, fmt.Errorf("profile %s: %w", base.Profile, err)
+		}
+		cfg.Profile = base.Profile
+		cfg.unprofiled = &base
+	}
+
+	// --set wins over the environment, the profile, and the file. The
+	// config was valid without it, so a failure here is the values' fault.
+	if err := applyOverrides(&cfg, overrides); err != nil {
+		return AppConfig{}, UsageError(fmt.Errorf("--set: %w", err))
+	}
+	if err := finishConfig(&cfg); err != nil {
+		return AppConfig{}, UsageError(fmt.Errorf("--set: %w", err))
+	}
+	cfg.overrides = overrides
+	return cfg, nil
+}
+
+// decodeConfig unmarshals and validates the settings loaded into v.
+func decodeConfig(v *viper.Viper) (AppConfig, error) {
+	cfg := defaultConfig()
+	if err := v.Unmarshal(&cfg, viper.DecodeHook(configDecodeHook())); err != nil {
 		return AppConfig{}, fmt.Errorf("decode config: %w", err)
 	}
+	// Unmarshal drops empty tables, but an empty [profiles.NAME] still
+	// defines a profile.
+	for name := range v.GetStringMap("profiles") {
+		if _, ok := cfg.Profiles[name]; !ok {
+			if cfg.Profiles == nil {
+				cfg.Profiles = map[string]map[string]any{}
+			}
+			cfg.Profiles[name] = map[string]any{}
+		}
+	}
+	if err := finishConfig(&cfg); err != nil {
+		return AppConfig{}, err
+	}
+	return cfg, nil
+}
+
+// finishConfig validates cfg and fills in derived settings.
+func finishConfig(cfg *AppConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
 
 	if cfg.Logging.File != "" {
 		expanded, err := expandPath(cfg.Logging.File)
 		if err != nil {
-			return AppConfig{}, fmt.Errorf("expand log file path: %w", err)
+			return fmt.Errorf("expand log file path: %w", err)
 		}
 		cfg.Logging.File = expanded
 	}
-
-	if cfg.Runtime.TimeoutSeconds == nil {
-		defaultTimeout := 60
-		cfg.Runtime.TimeoutSeconds = &defaultTimeout
+	if cfg.HTTP.CABundle != "" {
+		expanded, err := expandPath(cfg.HTTP.CABundle)
+		if err != nil {
+			return fmt.Errorf("expand http.ca_bundle: %w", err)
+		}
+		cfg.HTTP.CABundle = expanded
 	}
 
-	return cfg, nil
+	if cfg.Runtime.Timeout == nil {
+		defaultTimeout := Timeout(time.Minute)
+		cfg.Runtime.Timeout = &defaultTimeout
+	}
+	return nil
 }
 
 // WithProfileOverride returns a shallow copy with the profile overridden.
+// The [profiles] table of the new profile, if any, replaces the active
+// one's, applied over the file and environment settings.
 func (cfg AppConfig) WithProfileOverride(profile string) AppConfig {
-	if profile != "" {
+	if profile == "" || profile == cfg.Profile {
+		return cfg
+	}
+	out, err := cfg.withProfile(profile)
+	if err != nil {
+		// validateProfiles applied every table when the config loaded.
 		cfg.Profile = profile
+		return cfg
 	}
-	return cfg
+	return out
 }
 
 func (cfg AppConfig) RunConfig() RunConfig {
@@ -204,21 +1245,25 @@ func writeDefaultConfig(path string) error {
 	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
 		return fmt.Errorf("create config directory: %w", err)
 	}
-	body := strings.Builder{}
-	body.WriteString(defaultConfigHeader(path))
-	body.WriteString(defaultConfigBody())
-	if err := os.WriteFile(path, []byte(body.String()), 0o644); err != nil {
+	if err := os.WriteFile(path, []byte(defaultConfigContents(path)), 0o644); err != nil {
 		return fmt.Errorf("write config: %w", err)
 	}
 	return nil
 }
 
+func defaultConfigContents(path string) string {
+	return defaultConfigHeader(path) + defaultConfigBody()
+}
+
 func defaultConfigHeader(path string) string {
 	return fmt.Sprintf("# Configuration for %s\n# File: %s\n\n", appName, path)
 }
 
 func defaultConfigBody() string {
 	return `profile = "default"
+# Declarative command tasks are read from this file when it exists. Tasks
+# can also be declared here under [tasks.<name>]; see examples/tasks.toml.
+taskfile = "tasks.toml"
 
 [logging]
 # Valid levels: error, warn, info, debug, trace
@@ -231,37 +1276,577 @@ format = "auto"
 
 [runtime]
 # Override the worker pool size; defaults to logical CPU count when unset.
+# A percentage such as "50%" takes that share of the CPUs, at least one.
+# "auto" starts at the CPU count and scales down under high load or memory
+# pressure, and back up when the machine recovers.
 # parallelism = 8
-# Timeout in seconds for long-running operations.
-timeout = 60
+# Time limit for long-running operations, as a duration such as 90s or
+# 2m30s; a bare number is seconds. 0 disables it.
+timeout = "1m"
 fail_fast = true
+# Largest payload, in bytes, that a task declaring an input reads from stdin.
+max_input = 10485760
+
+[runtime.retry]
+# Total attempts per task; 1 disables retries.
+max_attempts = 1
+# Backoff before the first retry; doubles on each further attempt up to max_delay.
+initial_delay = "1s"
+max_delay = "30s"
+# Randomize each wait by up to ±20%.
+jitter = 0.2
+# Error classes to retry: transient, timeout, or any.
+retry_on = ["transient", "timeout"]
+
+# Per-task overrides:
+# [runtime.retry.tasks.fetch]
+# max_attempts = 5
+
+[runtime.rate_limit]
+# Maximum task attempts per second across all workers; 0 disables the limit.
+rate = 0
+# Attempts allowed back-to-back before the rate applies.
+burst = 1
+
+# Queue priorities per task (default 0). When more tasks are ready than
+# there are workers, higher priorities start first; run --priority overrides.
+# [runtime.priority]
+# test = 10
 
 [paths]
 # Uncomment to move persistent data/state to custom directories.
 # data_dir = "$XDG_DATA_HOME/` + appName + `"
 # state_dir = "$XDG_STATE_HOME/` + appName + `"
+
+[output]
+# Set to false to replace glyphs, spinners, and box drawing with plain ASCII.
+unicode = true
+# Print an end-of-run summary (and include it in JSON/YAML results).
+summary = true
+# Replace spinners and progress bars with plain periodic "N of M complete"
+# lines for screen readers. Also on when TERM=dumb or ACCESSIBLE is set.
+accessible = false
+# Also put the primary output (config path, run ID, auth token) on the
+# clipboard, as --copy does.
+clipboard = false
+
+[watch]
+# Glob patterns (relative to the working directory) that trigger a re-run
+# under ` + "`run --watch`" + `. "**" matches any number of directories.
+paths = ["**/*"]
+ignore = [".git", "dist"]
+# Quiet period before a batch of changes triggers a run.
+debounce = "300ms"
+# Clear the terminal before each re-run.
+clear_screen = false
+
+[hooks]
+# Shell commands run before and after every run. They receive
+# ` + EnvPrefix() + `_RUN_ID, _TASK, and _PROFILE; post_run hooks also get
+# ` + EnvPrefix() + `_RUN_STATUS and _EXIT_STATUS.
+pre_run = []
+post_run = []
+# What a failing pre_run hook does: abort (skip the run), warn, or ignore.
+pre_run_failure = "abort"
+# What a failing post_run hook does: warn, fail (fail the run), or ignore.
+post_run_failure = "warn"
+# Time limit per hook command; "0s" disables it.
+timeout = "1m"
+
+[daemon]
+# Loops run by ` + "`daemon start`" + `: the cron scheduler, and a watcher that
+# re-runs watch_task when [watch] paths change (empty disables it).
+scheduler = true
+watch_task = ""
+
+[exec]
+# Environment variables task and hook processes inherit. A trailing *
+# matches by prefix; ["*"] passes everything. Keep secrets out unless a
+# task needs them.
+env_passthrough = [
+  "PATH", "HOME", "USER", "LOGNAME", "SHELL", "TERM", "TMPDIR", "TZ", "LANG", "LC_*",
+  "SYSTEMROOT", "WINDIR", "COMSPEC", "PATHEXT", "TEMP", "TMP", "USERPROFILE", "APPDATA", "LOCALAPPDATA",
+]
+# KEY=VALUE pairs set for every task and hook process.
+env = []
+
+[serve]
+# Address of the ` + "`serve`" + ` HTTP API. Listening beyond loopback requires a
+# token; prefer setting it through ` + EnvPrefix() + `_SERVE__TOKEN.
+addr = "` + defaultServeAddr + `"
+# token = ""
+# Also serve the gRPC control API (api/control/v1) on host:port or a Unix
+# socket given as unix:PATH; serve --grpc overrides it.
+# grpc_addr = "unix:$XDG_RUNTIME_DIR/` + appName + `.sock"
+
+[retention]
+# What ` + "`prune`" + ` keeps of task logs, run history, and run artifacts:
+# entries younger than max_age ("0s" disables pruning by age), and always
+# the newest keep_last runs.
+max_age = "30d"
+keep_last = 10
+
+[http]
+# Client for outbound requests (remote config, updates, notifications, and
+# tasks). Requests time out after runtime.timeout; 5xx and 429 responses
+# and broken connections are retried up to max_retries times with backoff.
+max_retries = 3
+# Proxy URL; unset uses HTTPS_PROXY and HTTP_PROXY.
+# proxy = "http://proxy.example.com:3128"
+# Hosts reached without the proxy, added to NO_PROXY: names, domain
+# suffixes such as ".corp.example", IPs, and CIDR ranges.
+no_proxy = []
+# PEM bundle trusted in addition to the system roots, e.g. the CA of a
+# TLS-intercepting proxy; supports ~.
+# ca_bundle = "~/certs/company-ca.pem"
+# Accept any server certificate. This defeats TLS; use it only to
+# diagnose certificate problems, and prefer ca_bundle.
+insecure_skip_verify = false
+
+[telemetry.traces]
+# OTLP collector that receives spans for config loading, the command, and
+# each task; empty disables exporting. Subprocesses get TRACEPARENT either
+# way, so they join a trace this process inherited.
+endpoint = ""
+# "http/protobuf" (usually port 4318) or "grpc" (usually port 4317).
+protocol = "http/protobuf"
+# Share of new traces recorded, 0 to 1.
+sample_ratio = 1.0
+# Headers sent with every export; prefer
+# ` + EnvPrefix() + `_TELEMETRY__TRACES__HEADERS for secrets.
+# [telemetry.traces.headers]
+# api-key = "..."
+
+[notifications]
+# Show a desktop notification (notify-send, osascript, or a Windows toast)
+# when a run that took at least desktop_after finishes; run --notify and
+# --notify=false override desktop for one run.
+desktop = false
+desktop_after = "30s"
+# Base URL of the artifacts link in Slack and Teams messages, e.g. a web
+# server exposing the runs directory; the run ID is appended. Empty links
+# the local directory as a file:// URL.
+artifacts_url = ""
+
+[notifications.webhook]
+# POST a JSON summary of every finished run (status, duration, exit code,
+# error) to url; empty disables it. POSTs are retried on 429 and 503.
+url = ""
+# Only notify when a run did not succeed.
+only_on_failure = false
+# Go text/template for the request body, rendered from the summary, e.g.
+# for a chat service. Fields: .ID .Task .Profile .Status .ExitCode .Error
+# .DurationMS .Host; "json" quotes a value and "duration" formats
+# .DurationMS.
+# template = '{"text": {{ printf "%s: %s (%s)" .Task .Status (duration .DurationMS) | json }}}'
+# Headers sent with every notification; prefer
+# ` + EnvPrefix() + `_NOTIFICATIONS__WEBHOOK__HEADERS for secrets.
+# [notifications.webhook.headers]
+# Authorization = "Bearer ..."
+
+[notifications.slack]
+# Post a message about every finished run (status, duration, failed tasks,
+# and a link to its artifacts) to a Slack incoming webhook; empty disables
+# it.
+url = ""
+only_on_failure = false
+# Go text/template for the message text, with the fields of the webhook
+# template plus .FailedTasks and .ArtifactsURL; empty uses the built-in
+# summary.
+# template = '{{ .Task }} {{ .Status }} on {{ .Host }}'
+
+[notifications.teams]
+# The same for a Microsoft Teams incoming webhook (a Workflows "post to a
+# channel when a webhook request is received" URL); sent as an Adaptive
+# Card.
+url = ""
+only_on_failure = false
+
+[storage]
+# Where finished runs (artifacts, manifest, and history entry) are copied,
+# e.g. from ephemeral CI containers. Runs always write to the data
+# directory first. "local" copies them to local.path if set; "s3" uploads
+# them to an S3-compatible bucket. runs list, runs clean, and history
+# include the stored runs.
+backend = "local"
+
+[storage.local]
+# Directory runs are copied to, e.g. a shared volume; empty keeps them in
+# the data directory only.
+path = ""
+
+[storage.s3]
+# Service URL; empty means AWS in region. Set path_style for MinIO and
+# most self-hosted services.
+endpoint = ""
+region = "us-east-1"
+bucket = ""
+# Prepended to every key, e.g. "ci/myproject/".
+prefix = ""
+path_style = false
+# Credentials; empty falls back to AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY,
+# and AWS_SESSION_TOKEN. Prefer the environment for secrets.
+# access_key_id = ""
+# secret_access_key = ""
+
+[auth]
+# OAuth2 device authorization flow for ` + "`" + appName + ` auth login` + "`" + `: it prints a
+# code and a URL, waits while you approve the request in a browser, and
+# keeps the token in the OS keyring. Commands read it through
+# RuntimeContext.AccessToken. Empty client_id disables auth.
+client_id = ""
+device_url = ""
+token_url = ""
+scopes = []
+
+# Command aliases. ` + "`" + appName + ` NAME args...` + "`" + ` runs the alias's command line
+# with args appended; an alias may start with another alias. Names of
+# built-in commands cannot be aliased.
+# [aliases]
+# deploy = "run deploy --profile prod --json"
+
+# Hosts ` + "`run --on NAME`" + ` runs command tasks on over SSH; --on also accepts
+# a tag and runs on every remote carrying it. Authentication uses the SSH
+# agent, then identity_file (default: the unencrypted keys in ~/.ssh); host
+# keys must be in known_hosts (default: ~/.ssh/known_hosts).
+# [remotes.web1]
+# host = "web1.example.com"
+# user = "deploy"
+# port = 22
+# dir = "/srv/app"
+# tags = ["web"]
+
+# Profiles override top-level settings while active. Select one with
+# ` + "`profile`" + ` above, ` + EnvPrefix() + `_PROFILE, or ` + "`run --profile`" + `; manage them
+# with ` + "`" + appName + ` profile` + "`" + `.
+# [profiles.prod.runtime]
+# parallelism = 8
+# fail_fast = false
 `
 }
 
 func defaultConfig() AppConfig {
-	defaultTimeout := 60
+	defaultTimeout := Timeout(time.Minute)
 	return AppConfig{
-		Profile: "default",
+		Profile:  "default",
+		Taskfile: "tasks.toml",
 		Logging: LoggingConfig{
 			Level:  "info",
 			Format: "auto",
 		},
 		Runtime: RuntimeConfig{
-			TimeoutSeconds: &defaultTimeout,
-			FailFast:       true,
+			Timeout:  &defaultTimeout,
+			FailFast: true,
+			MaxInput: 10 << 20,
+			Retry: RetryConfig{
+				MaxAttempts:  1,
+				InitialDelay: Duration(time.Second),
+				MaxDelay:     Duration(30 * time.Second),
+				Jitter:       0.2,
+				RetryOn:      []string{runner.RetryOnTransient, runner.RetryOnTimeout},
+			},
+			RateLimit: RateLimitConfig{
+				Burst: 1,
+			},
+		},
+		Output: OutputConfig{
+			Unicode: true,
+			Summary: true,
+		},
+		Watch: WatchConfig{
+			Paths:    []string{"**/*"},
+			Ignore:   []string{".git", "dist"},
+			Debounce: Duration(300 * time.Millisecond),
+		},
+		Hooks: HooksConfig{
+			PreRun:         []string{},
+			PostRun:        []string{},
+			PreRunFailure:  HookAbort,
+			PostRunFailure: HookWarn,
+			Timeout:        Duration(time.Minute),
+		},
+		Daemon: DaemonConfig{
+			Scheduler: true,
+		},
+		Exec: ExecConfig{
+			EnvPassthrough: append([]string(nil), defaultEnvPassthrough...),
+			Env:            []string{},
+		},
+		Serve: ServeConfig{
+			Addr: defaultServeAddr,
+		},
+		Retention: RetentionConfig{
+			MaxAge:   Duration(defaultPruneAge),
+			KeepLast: 10,
+		},
+		HTTP: HTTPConfig{
+			MaxRetries: 3,
+			NoProxy:    []string{},
+		},
+		Telemetry: TelemetryConfig{
+			Traces: TracesConfig{
+				Protocol:    tracing.ProtocolHTTP,
+				SampleRatio: 1,
+			},
+		},
+		Notifications: NotificationsConfig{
+			DesktopAfter: Duration(30 * time.Second),
+		},
+		Storage: StorageConfig{
+			Backend: StorageLocal,
+			S3:      S3StorageConfig{Region: "us-east-1"},
+		},
+		Auth: AuthConfig{
+			Scopes: []string{},
 		},
 	}
 }
 
+// configDecodeHook extends viper's default hooks so types such as Duration
+// can decode themselves from strings.
+func configDecodeHook() mapstructure.DecodeHookFunc {
+	return mapstructure.ComposeDecodeHookFunc(
+		parallelismHook(),
+		timeoutHook(),
+		mapstructure.TextUnmarshallerHookFunc(),
+		mapstructure.StringToTimeDurationHookFunc(),
+		mapstructure.StringToSliceHookFunc(","),
+	)
+}
+
+// Validate rejects settings the rest of the application cannot honor.
+func (cfg AppConfig) Validate() error {
+	if level := strings.ToLower(cfg.Logging.Level); level != "" && !slices.Contains(logLevels, level) {
+		return enumError("logging.level", cfg.Logging.Level, logLevels...)
+	}
+	switch cfg.Logging.Format {
+	case "", "auto", "text", "json":
+	default:
+		return enumError("logging.format", cfg.Logging.Format, "auto", "text", "json")
+	}
+	if cfg.Runtime.Timeout != nil && *cfg.Runtime.Timeout < 0 {
+		return fmt.Errorf("invalid runtime.timeout %s (must not be negative)", cfg.Runtime.Timeout)
+	}
+	retry := cfg.Runtime.Retry
+	if err := validateRetry("runtime.retry", retry.Jitter, retry.RetryOn); err != nil {
+		return err
+	}
+	if err := validateHooks(cfg.Hooks); err != nil {
+		return err
+	}
+	if err := validateTaskSpecs("tasks", cfg.Tasks); err != nil {
+		return err
+	}
+	if err := validateAliases(cfg.Aliases); err != nil {
+		return err
+	}
+	if err := validateRemotes(cfg.Remotes); err != nil {
+		return err
+	}
+	if _, _, err := net.SplitHostPort(cfg.Serve.Addr); err != nil {
+		return fmt.Errorf("invalid serve.addr %q (expected host:port): %v", cfg.Serve.Addr, err)
+	}
+	if cfg.Serve.GRPCAddr != "" {
+		if _, err := parseGRPCAddr(cfg.Serve.GRPCAddr); err != nil {
+			return fmt.Errorf("invalid serve.grpc_addr: %v", err)
+		}
+	}
+	for _, kv := range cfg.Exec.Env {
+		if k, _, ok := strings.Cut(kv, "="); !ok || k == "" {
+			return fmt.Errorf("invalid exec.env entry %q (expected KEY=VALUE)", kv)
+		}
+	}
+	if cfg.Retention.MaxAge < 0 || cfg.Retention.KeepLast < 0 {
+		return fmt.Errorf("invalid retention (max_age and keep_last must not be negative, got %s and %d)", cfg.Retention.MaxAge, cfg.Retention.KeepLast)
+	}
+	if cfg.HTTP.MaxRetries < 0 {
+		return fmt.Errorf("invalid http.max_retries %d (must not be negative)", cfg.HTTP.MaxRetries)
+	}
+	if cfg.HTTP.Proxy != "" {
+		if u, err := url.Parse(cfg.HTTP.Proxy); err != nil || u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("invalid http.proxy %q (expected a URL such as http://host:port)", cfg.HTTP.Proxy)
+		}
+	}
+	for _, host := range cfg.HTTP.NoProxy {
+		if host == "" || strings.ContainsAny(host, ", \t") {
+			return fmt.Errorf("invalid http.no_proxy entry %q (expected a host, .domain, IP, or CIDR range)", host)
+		}
+	}
+	if traces := cfg.Telemetry.Traces; traces.Protocol != tracing.ProtocolHTTP && traces.Protocol != tracing.ProtocolGRPC {
+		return enumError("telemetry.traces.protocol", traces.Protocol, tracing.ProtocolHTTP, tracing.ProtocolGRPC)
+	} else if traces.SampleRatio < 0 || traces.SampleRatio > 1 {
+		return fmt.Errorf("invalid telemetry.traces.sample_ratio %v (expected a value between 0 and 1)", traces.SampleRatio)
+	}
+	if cfg.Notifications.DesktopAfter < 0 {
+		return fmt.Errorf("invalid notifications.desktop_after %s (must not be negative)", cfg.Notifications.DesktopAfter)
+	}
+	if err := validateChat("notifications.slack", cfg.Notifications.Slack); err != nil {
+		return err
+	}
+	if err := validateChat("notifications.teams", cfg.Notifications.Teams); err != nil {
+		return err
+	}
+	if u := cfg.Notifications.ArtifactsURL; u != "" && !isHTTPURL(u) {
+		return fmt.Errorf("invalid notifications.artifacts_url %q (expected an http:// or https:// URL)", u)
+	}
+	if err := validateWebhook(cfg.Notifications.Webhook); err != nil {
+		return err
+	}
+	if err := validateStorage(cfg.Storage); err != nil {
+		return err
+	}
+	if err := validateAuth(cfg.Auth); err != nil {
+		return err
+	}
+	if cfg.Runtime.MaxInput < 1 {
+		return fmt.Errorf("invalid runtime.max_input %d (must be at least 1)", cfg.Runtime.MaxInput)
+	}
+	if limit := cfg.Runtime.RateLimit; limit.Rate < 0 || limit.Burst < 1 {
+		return fmt.Errorf("invalid runtime.rate_limit (rate must be >= 0 and burst >= 1, got rate %v, burst %d)", limit.Rate, limit.Burst)
+	}
+	for task, override := range retry.Tasks {
+		jitter := retry.Jitter
+		if override.Jitter != nil {
+			jitter = *override.Jitter
+		}
+		if err := validateRetry("runtime.retry.tasks."+task, jitter, override.RetryOn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateRetry(key string, jitter float64, retryOn []string) error {
+	if jitter < 0 || jitter > 1 {
+		return fmt.Errorf("invalid %s.jitter %v (expected a value between 0 and 1)", key, jitter)
+	}
+	for _, class := range retryOn {
+		if !runner.ValidRetryClass(class) {
+			return enumError(key+".retry_on value", class, runner.RetryOnAny, runner.RetryOnTransient, runner.RetryOnTimeout)
+		}
+	}
+	return nil
+}
+
+// RetryPolicy returns the runner retry policy for task, applying any
+// per-task override on top of the defaults.
+func (cfg RetryConfig) RetryPolicy(task string) runner.RetryPolicy {
+	policy := runner.RetryPolicy{
+		MaxAttempts:  cfg.MaxAttempts,
+		InitialDelay: cfg.InitialDelay.Std(),
+		MaxDelay:     cfg.MaxDelay.Std(),
+		Jitter:       cfg.Jitter,
+		RetryOn:      cfg.RetryOn,
+	}
+
+	override, ok := cfg.Tasks[task]
+	if !ok {
+		return policy
+	}
+	if override.MaxAttempts != nil {
+		policy.MaxAttempts = *override.MaxAttempts
+	}
+	if override.InitialDelay != nil {
+		policy.InitialDelay = override.InitialDelay.Std()
+	}
+	if override.MaxDelay != nil {
+		policy.MaxDelay = override.MaxDelay.Std()
+	}
+	if override.Jitter != nil {
+		policy.Jitter = *override.Jitter
+	}
+	if override.RetryOn != nil {
+		policy.RetryOn = override.RetryOn
+	}
+	return policy
+}
+
 // TimeoutDuration returns the configured timeout as a time.Duration.
 func (cfg RuntimeConfig) TimeoutDuration() time.Duration {
-	if cfg.TimeoutSeconds == nil {
+	if cfg.Timeout == nil {
 		return 0
 	}
-	return time.Duration(*cfg.TimeoutSeconds) * time.Second
+	return cfg.Timeout.Std()
+}
+
+// ConfigKeyPaths lists the dotted keys of every leaf setting of cfg,
+// including the entries of tables such as tasks and profiles.
+func ConfigKeyPaths(cfg AppConfig) []string {
+	rows := flattenConfig(cfg)
+	keys := make([]string, 0, len(rows))
+	for _, row := range rows {
+		keys = append(keys, row.Key)
+	}
+	return keys
 }
+
+// configValue looks up a dotted key in cfg as it marshals to JSON, so the
+// value keeps its type; keys omitted when empty yield nil.
+func configValue(cfg AppConfig, key string) (any, error) {
+	data, err := json.Marshal(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var node any
+	if err := json.Unmarshal(data, &node); err != nil {
+		return nil, err
+	}
+	for part := range strings.SplitSeq(key, ".") {
+		table, _ := node.(map[string]any)
+		node = table[part]
+	}
+	return node, nil
+}
+
+// flattenConfig lists every leaf setting as a dotted key (e.g. logging.level)
+// in declaration order, for human-readable display.
+func flattenConfig(cfg AppConfig) []KeyValue {
+	var rows []KeyValue
+	flattenValue("", reflect.ValueOf(cfg), &rows)
+	return rows
+}
+
+func flattenValue(prefix string, v reflect.Value, rows *[]KeyValue) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+
+		flattenField(key, v.Field(i), rows)
+	}
+}
+
+func flattenField(key string, value reflect.Value, rows *[]KeyValue) {
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			*rows = append(*rows, KeyValue{Key: key, Value: "(unset)"})
+			return
+		}
+		value = value.Elem()
+	}
+
+	switch {
+	case value.Kind() == reflect.Struct && !value.Type().Implements(stringerType):
+		flattenValue(key, value, rows)
+	case value.Kind() == reflect.Map:
+		names := make([]string, 0, value.Len())
+		for _, k := range value.MapKeys() {
+			names = append(names, fmt.Sprint(k.Interface()))
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			flattenField(key+"."+name, value.MapIndex(reflect.ValueOf(name).Convert(value.Type().Key())), rows)
+		}
+	default:
+		*rows = append(*rows, KeyValue{Key: key, Value: fmt.Sprint(value.Interface())})
+	}
+}
+
+var stringerType = reflect.TypeOf((*fmt.Stringer)(nil)).Elem()
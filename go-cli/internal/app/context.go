@@ -1,8 +1,28 @@
 package app
 
-import "context"
-
-const appName = "go-cli"
+import (
+	"context"
+	"errors"
+	"time"
+
+	"golang.org/x/text/message"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/clock"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/gitinfo"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/lock"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/stdinutil"
+)
+
+// appName names the program in paths, the environment prefix, and
+// messages. It is process-wide; see SetAppName.
+var appName = "go-cli"
+
+// SetAppName renames the program, for a CLI built on this package as a
+// library (see pkg/clifw). Call it before the first runtime context is
+// built.
+func SetAppName(name string) {
+	appName = name
+}
 
 // ContextKey is used to store the runtime context in a context.Context.
 type ContextKey struct{}
@@ -15,6 +35,39 @@ type RuntimeContext struct {
 	Config      AppConfig
 	Logger      Logger
 	LogSettings LogSettings
+	Glyphs      Glyphs
+	Printer     *message.Printer
+	Out         Renderer
+	// Timeout bounds long-running operations (--timeout, else runtime.timeout).
+	Timeout time.Duration
+	// Clock stamps runs and history and drives retries and the scheduler;
+	// tests swap in a clock.Fake.
+	Clock clock.Clock
+	// Params are the typed --param values of the run in progress, checked
+	// against the parameters its tasks declare.
+	Params Params
+	// Input is the payload piped to the run in progress, for tasks that
+	// declare an input; nil otherwise.
+	Input *stdinutil.Payload
+	// Git describes the repository the run in progress started in; nil
+	// outside one.
+	Git *gitinfo.Info
+	// CopyOutput puts the command's primary output on the clipboard; see
+	// CopyPrimary.
+	CopyOutput bool
+
+	lock *lock.Lock
+	// artifacts is the directory of the run in progress; see ArtifactWriter.
+	artifacts *artifactStore
+	// http is shared by forks; see HTTPClient.
+	http *sharedTransport
+	// state is shared by forks; see stateStore.
+	state *sharedState
+	// traces is set on the context of a top-level invocation; see
+	// StartCommandSpan.
+	traces *traces
+	// live is set in a resident instance; see currentConfig.
+	live *liveConfig
 }
 
 // NewRuntimeContext builds a runtime context from CLI flags and the current environment.
@@ -23,15 +76,22 @@ func NewRuntimeContext(parent context.Context, flags CommonFlags) (*RuntimeConte
 		parent = context.Background()
 	}
 
+	lang, err := ResolveLanguage(flags.Lang)
+	if err != nil {
+		return nil, err
+	}
+
 	paths, err := DiscoverPaths(appName, flags.ConfigPath)
 	if err != nil {
 		return nil, err
 	}
 
+	loadStart := time.Now()
 	cfg, err := LoadOrInitConfig(paths, flags)
 	if err != nil {
 		return nil, err
 	}
+	loadEnd := time.Now()
 
 	effPaths, err := ApplyPathOverrides(paths, cfg)
 	if err != nil {
@@ -47,6 +107,8 @@ func NewRuntimeContext(parent context.Context, flags CommonFlags) (*RuntimeConte
 		return nil, err
 	}
 	logger := ConfigureLogger(logSettings)
+	glyphs := ResolveGlyphs(flags, cfg)
+	printer := NewPrinter(lang)
 
 	rtx := &RuntimeContext{
 		Context:     parent,
@@ -55,9 +117,20 @@ func NewRuntimeContext(parent context.Context, flags CommonFlags) (*RuntimeConte
 		Config:      cfg,
 		Logger:      logger,
 		LogSettings: logSettings,
+		Glyphs:      glyphs,
+		Printer:     printer,
+		Out:         ResolveRenderer(flags, glyphs, printer),
+		Timeout:     cfg.Runtime.TimeoutDuration(),
+		Clock:       clock.Real(),
+		http:        &sharedTransport{},
+		state:       &sharedState{},
+	}
+	if flags.Timeout != nil {
+		rtx.Timeout = *flags.Timeout
 	}
 
 	rtx.Context = context.WithValue(parent, ContextKey{}, rtx)
+	rtx.setupTracing(loadStart, loadEnd)
 	rtx.Logger.Debug("resolved paths: config=%s data=%s state=%s", rtx.Paths.ConfigFile, rtx.Paths.DataDir, rtx.Paths.StateDir)
 
 	return rtx, nil
@@ -74,15 +147,69 @@ func FromContext(ctx context.Context) (*RuntimeContext, bool) {
 	return nil, false
 }
 
+// fork returns a copy of rtx bound to ctx, for a loop that runs alongside
+// others in the same process. The copy does not share the instance lock.
+func (rtx *RuntimeContext) fork(ctx context.Context) *RuntimeContext {
+	c := *rtx
+	c.lock = nil
+	c.Context = context.WithValue(ctx, ContextKey{}, &c)
+	return &c
+}
+
+// Reuse returns a copy of rtx bound to ctx for another command in the same
+// process, as the interactive shell runs them. Output, dry-run, prompt,
+// timeout, parallelism, progress, copy, and lock flags come from flags; config
+// (including --set), paths, logging, colors, and language stay as loaded.
+func (rtx *RuntimeContext) Reuse(ctx context.Context, flags CommonFlags) *RuntimeContext {
+	c := rtx.fork(ctx)
+	c.Common.JSON = flags.JSON
+	c.Common.YAML = flags.YAML
+	c.Common.Porcelain = flags.Porcelain
+	c.Common.DryRun = flags.DryRun
+	c.Common.AssumeYes = flags.AssumeYes
+	c.Common.NonInteractive = flags.NonInteractive
+	c.Common.Timeout = flags.Timeout
+	c.Common.Parallelism = flags.Parallelism
+	c.Common.NoProgress = flags.NoProgress
+	c.Common.Copy = flags.Copy
+	c.Common.WaitLock = flags.WaitLock
+	c.Common.NoLock = flags.NoLock
+	c.Timeout = c.Config.Runtime.TimeoutDuration()
+	if flags.Timeout != nil {
+		c.Timeout = *flags.Timeout
+	}
+	return c
+}
+
 // EnvPrefix returns the environment variable prefix for configuration overrides.
 func EnvPrefix() string {
 	return toEnvPrefix(appName)
 }
 
+// WithTimeout derives a context bounded by rtx.Timeout for a long-running
+// operation. A zero timeout means no deadline.
+func (rtx *RuntimeContext) WithTimeout() (context.Context, context.CancelFunc) {
+	if rtx.Timeout <= 0 {
+		return context.WithCancel(rtx.Context)
+	}
+	return context.WithTimeout(rtx.Context, rtx.Timeout)
+}
+
+// TimeoutErr converts an expired operation context into a TimeoutError,
+// otherwise returns err unchanged.
+func (rtx *RuntimeContext) TimeoutErr(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return TimeoutError(rtx.Timeout)
+	}
+	return err
+}
+
 // Close releases resources held by the runtime context.
 func (rtx *RuntimeContext) Close() error {
 	if rtx == nil {
 		return nil
 	}
-	return rtx.Logger.Close()
+	rtx.EndCommandSpan(nil)
+	rtx.flushTraces()
+	return errors.Join(rtx.closeState(), rtx.ReleaseLock(), rtx.Logger.Close())
 }
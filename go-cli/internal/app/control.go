@@ -0,0 +1,251 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/control"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/sdnotify"
+)
+
+// Kinds of resident instance, as reported by DaemonStatus.Mode.
+const (
+	InstanceDaemon = "daemon"
+	InstanceServe  = "serve"
+)
+
+const (
+	// controlTimeout bounds one exchange on the control socket.
+	controlTimeout = 5 * time.Second
+	// logFeedLines is how many recent log lines an instance keeps for
+	// `daemon logs`.
+	logFeedLines = 1000
+	// logFeedBuffer is how far a follower may fall behind before lines
+	// are dropped for it; logging never waits for a follower.
+	logFeedBuffer = 256
+)
+
+// controlSocketPath is where the resident instance (daemon or serve)
+// answers; on Windows it names a pipe instead, see control.Address.
+func controlSocketPath(stateDir string) string {
+	return filepath.Join(stateDir, "control.sock")
+}
+
+// liveConfig is the configuration of a resident instance. `daemon reload`
+// replaces it; forks share it, and each run picks up the current one when
+// it starts.
+type liveConfig struct {
+	mu  sync.Mutex
+	cfg AppConfig
+}
+
+// currentConfig returns the config the next run should use: the reloaded
+// one in a resident instance, else rtx.Config.
+func (rtx *RuntimeContext) currentConfig() AppConfig {
+	if rtx.live == nil {
+		return rtx.Config
+	}
+	rtx.live.mu.Lock()
+	defer rtx.live.mu.Unlock()
+	return rtx.live.cfg
+}
+
+// reloadConfig reads the config file again for the runs to come, task
+// definitions included. Paths, logging, and listen addresses keep their
+// values until the instance restarts.
+func (rtx *RuntimeContext) reloadConfig() error {
+	cfg, err := LoadOrInitConfig(rtx.Paths, rtx.Common)
+	if err != nil {
+		return err
+	}
+	rtx.live.mu.Lock()
+	rtx.live.cfg = cfg
+	rtx.live.mu.Unlock()
+	return nil
+}
+
+// logFeed is a log writer that keeps the most recent lines and passes
+// new ones on to followers.
+type logFeed struct {
+	mu        sync.Mutex
+	lines     []string
+	followers map[chan string]struct{}
+}
+
+func newLogFeed() *logFeed {
+	return &logFeed{followers: map[chan string]struct{}{}}
+}
+
+func (f *logFeed) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		f.lines = append(f.lines, line)
+		for ch := range f.followers {
+			select {
+			case ch <- line:
+			default:
+			}
+		}
+	}
+	if over := len(f.lines) - logFeedLines; over > 0 {
+		f.lines = append(f.lines[:0:0], f.lines[over:]...)
+	}
+	return len(p), nil
+}
+
+// follow returns the last n lines kept (all for n <= 0) and, when follow
+// is set, a channel receiving later lines until stop is called.
+func (f *logFeed) follow(n int, follow bool) (recent []string, next <-chan string, stop func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	recent = f.lines
+	if n > 0 && len(recent) > n {
+		recent = recent[len(recent)-n:]
+	}
+	recent = append([]string(nil), recent...)
+	if !follow {
+		return recent, nil, func() {}
+	}
+	ch := make(chan string, logFeedBuffer)
+	f.followers[ch] = struct{}{}
+	return recent, ch, func() {
+		f.mu.Lock()
+		delete(f.followers, ch)
+		f.mu.Unlock()
+	}
+}
+
+// controlChannel is the instance side of the control socket.
+type controlChannel struct {
+	ctx    *RuntimeContext
+	status func() DaemonStatus
+	stop   func()
+	feed   *logFeed
+}
+
+// openControl makes ctx a resident instance: its log is fed to `daemon
+// logs`, its config becomes reloadable, and the control socket answers
+// status, stop, reload, and logs requests. status describes the instance
+// and stop shuts it down. The returned function closes the socket.
+func openControl(ctx *RuntimeContext, status func() DaemonStatus, stop func()) (func(), error) {
+	path := controlSocketPath(ctx.Paths.StateDir)
+	ln, err := control.Listen(path)
+	if err != nil {
+		return nil, fmt.Errorf("open control socket: %w", err)
+	}
+	feed := newLogFeed()
+	ctx.Logger = ctx.Logger.withWriter(feed)
+	ctx.live = &liveConfig{cfg: ctx.Config}
+
+	c := &controlChannel{ctx: ctx, status: status, stop: stop, feed: feed}
+	go control.Serve(ln, c.handle)
+	// Closing the listener also removes the socket file.
+	return func() { ln.Close() }, nil
+}
+
+func (c *controlChannel) handle(conn net.Conn, req control.Request, send func(control.Reply) error) error {
+	c.ctx.Logger.Debug("control socket: %s", req.Command)
+	switch req.Command {
+	case control.CmdStatus:
+		return c.sendStatus(send)
+	case control.CmdStop:
+		c.ctx.Logger.Info("stop requested over control socket")
+		defer c.stop()
+		return c.sendStatus(send)
+	case control.CmdReload:
+		notifySystemd(c.ctx, sdnotify.Reloading)
+		defer notifySystemd(c.ctx, sdnotify.Ready)
+		if err := c.ctx.reloadConfig(); err != nil {
+			c.ctx.Logger.Error("reload failed, keeping the current config: %v", err)
+			return err
+		}
+		c.ctx.Logger.Info("config reloaded from %s; it applies from the next run", c.ctx.Paths.ConfigFile)
+		return c.sendStatus(send)
+	case control.CmdLogs:
+		return c.streamLogs(conn, req, send)
+	}
+	return fmt.Errorf("unknown command %q", req.Command)
+}
+
+func (c *controlChannel) sendStatus(send func(control.Reply) error) error {
+	data, err := json.Marshal(c.status())
+	if err != nil {
+		return err
+	}
+	return send(control.Reply{Status: data, Done: true})
+}
+
+// streamLogs replays recent log lines and, with req.Follow, sends new ones
+// until the client hangs up or the instance stops.
+func (c *controlChannel) streamLogs(conn net.Conn, req control.Request, send func(control.Reply) error) error {
+	recent, next, stop := c.feed.follow(req.Lines, req.Follow)
+	defer stop()
+	for _, line := range recent {
+		if err := send(control.Reply{Line: line}); err != nil {
+			return nil
+		}
+	}
+	if !req.Follow {
+		return send(control.Reply{Done: true})
+	}
+	// The client sends nothing more; a read returns once it hangs up.
+	gone := make(chan struct{})
+	go func() {
+		_, _ = io.Copy(io.Discard, conn)
+		close(gone)
+	}()
+	for {
+		select {
+		case line := <-next:
+			if err := send(control.Reply{Line: line}); err != nil {
+				return nil
+			}
+		case <-gone:
+			return nil
+		case <-c.ctx.Done():
+			return send(control.Reply{Done: true})
+		}
+	}
+}
+
+// queryInstance sends command to the resident instance and returns the
+// status it answers with. It fails when nothing is listening.
+func queryInstance(ctx context.Context, stateDir, command string) (*DaemonStatus, error) {
+	var status *DaemonStatus
+	err := control.Call(ctx, controlSocketPath(stateDir), control.Request{Command: command}, controlTimeout, func(reply control.Reply) error {
+		if len(reply.Status) == 0 {
+			return nil
+		}
+		status = &DaemonStatus{}
+		return json.Unmarshal(reply.Status, status)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if status == nil {
+		return nil, errors.New("empty reply on control socket")
+	}
+	return status, nil
+}
+
+// instanceLogs prints the instance's recent log lines, and with follow
+// the new ones until ctx is canceled, to out.
+func instanceLogs(ctx context.Context, stateDir string, lines int, follow bool, out io.Writer) error {
+	req := control.Request{Command: control.CmdLogs, Lines: lines, Follow: follow}
+	return control.Call(ctx, controlSocketPath(stateDir), req, controlTimeout, func(reply control.Reply) error {
+		if reply.Done {
+			return nil
+		}
+		_, err := fmt.Fprintln(out, reply.Line)
+		return err
+	})
+}
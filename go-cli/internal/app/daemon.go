@@ -0,0 +1,402 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/control"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/humanize"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/sdnotify"
+)
+
+const (
+	// daemonStartupTimeout bounds how long `daemon start` waits for the
+	// detached process to answer on its control socket.
+	daemonStartupTimeout = 5 * time.Second
+	// daemonStopTimeout bounds how long `daemon stop` waits for the daemon
+	// to finish its current run and exit.
+	daemonStopTimeout = 30 * time.Second
+	daemonDialTimeout = time.Second
+	daemonPoll        = 100 * time.Millisecond
+)
+
+// DaemonStatus describes the resident process that answers on the control
+// socket: the daemon started by `daemon start`, or `serve`.
+type DaemonStatus struct {
+	Running bool `json:"running" yaml:"running"`
+	// Mode is InstanceDaemon or InstanceServe.
+	Mode      string     `json:"mode,omitempty" yaml:"mode,omitempty"`
+	PID       int        `json:"pid,omitempty" yaml:"pid,omitempty"`
+	Started   *time.Time `json:"started,omitempty" yaml:"started,omitempty"`
+	Scheduler bool       `json:"scheduler" yaml:"scheduler"`
+	WatchTask string     `json:"watch_task,omitempty" yaml:"watch_task,omitempty"`
+	// Addr is the API address of `serve`.
+	Addr    string `json:"addr,omitempty" yaml:"addr,omitempty"`
+	Socket  string `json:"socket" yaml:"socket"`
+	LogFile string `json:"log_file,omitempty" yaml:"log_file,omitempty"`
+}
+
+// DaemonStartOptions configure `daemon start`.
+type DaemonStartOptions struct {
+	// Foreground runs the loops in this process instead of detaching.
+	Foreground bool
+	Jobs       JobsFunc
+}
+
+// DaemonLogsOptions configure `daemon logs`.
+type DaemonLogsOptions struct {
+	Lines  int
+	Follow bool
+}
+
+func daemonPIDPath(stateDir string) string {
+	return filepath.Join(stateDir, "daemon.pid")
+}
+
+func daemonLogPath(stateDir string) string {
+	return filepath.Join(stateDir, "daemon.log")
+}
+
+// HandleDaemonStart starts the daemon, detached unless opts.Foreground is
+// set. The detached process is this executable re-run with --foreground and
+// its output appended to <state>/daemon.log.
+func HandleDaemonStart(ctx *RuntimeContext, opts DaemonStartOptions) error {
+	if status, err := queryInstance(ctx, ctx.Paths.StateDir, control.CmdStatus); err == nil {
+		return fmt.Errorf("%s already running (pid %d)", status.Mode, status.PID)
+	}
+	if !ctx.Config.Daemon.Scheduler && ctx.Config.Daemon.WatchTask == "" {
+		return fmt.Errorf("daemon has nothing to do: enable daemon.scheduler or set daemon.watch_task")
+	}
+	if ctx.Common.DryRun {
+		ctx.Logger.Info("dry-run: would start the daemon")
+		return nil
+	}
+	if opts.Foreground {
+		return runAsService(ctx, func(rtx *RuntimeContext) error {
+			return runDaemon(rtx, opts)
+		})
+	}
+	return spawnDaemon(ctx)
+}
+
+// spawnDaemon re-executes the binary in the background and waits until it
+// answers on the control socket.
+func spawnDaemon(ctx *RuntimeContext) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("locate executable: %w", err)
+	}
+	logPath := daemonLogPath(ctx.Paths.StateDir)
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open daemon log: %w", err)
+	}
+	defer logFile.Close()
+
+	cmd := exec.Command(exe, append(os.Args[1:], "--foreground")...)
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+	cmd.SysProcAttr = detachedProcAttr()
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("start daemon: %w", err)
+	}
+
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	deadline := time.After(daemonStartupTimeout)
+	for {
+		if status, err := queryInstance(ctx, ctx.Paths.StateDir, control.CmdStatus); err == nil {
+			ctx.Logger.Info("daemon started (pid %d, logs in %s)", status.PID, logPath)
+			if ctx.Common.JSON || ctx.Common.YAML || ctx.Common.Porcelain {
+				return printDaemonStatus(ctx, status)
+			}
+			return nil
+		}
+		select {
+		case err := <-exited:
+			return fmt.Errorf("daemon exited during startup (%v); see `daemon logs`", err)
+		case <-deadline:
+			return fmt.Errorf("daemon did not start within %s; see `daemon logs`", humanize.Duration(daemonStartupTimeout))
+		case <-time.After(daemonPoll):
+		}
+	}
+}
+
+// runDaemon runs the configured loops in this process until it is stopped
+// over the control socket or receives SIGINT/SIGTERM.
+func runDaemon(ctx *RuntimeContext, opts DaemonStartOptions) error {
+	stateDir := ctx.Paths.StateDir
+	pidPath := daemonPIDPath(stateDir)
+
+	dctx, stop := context.WithCancel(ctx.Context)
+	defer stop()
+	ctx = ctx.fork(dctx)
+
+	started := ctx.Clock.Now().UTC()
+	status := DaemonStatus{
+		Running:   true,
+		Mode:      InstanceDaemon,
+		PID:       os.Getpid(),
+		Started:   &started,
+		Scheduler: ctx.Config.Daemon.Scheduler,
+		WatchTask: ctx.Config.Daemon.WatchTask,
+		Socket:    control.Address(controlSocketPath(stateDir)),
+		LogFile:   daemonLogPath(stateDir),
+	}
+	closeControl, err := openControl(ctx, func() DaemonStatus { return status }, stop)
+	if err != nil {
+		return err
+	}
+	defer closeControl()
+
+	// The pid file is informational: clients find the daemon through the
+	// control socket.
+	if err := os.WriteFile(pidPath, []byte(strconv.Itoa(os.Getpid())+"\n"), 0o644); err != nil {
+		return fmt.Errorf("write pid file: %w", err)
+	}
+	defer os.Remove(pidPath)
+	ctx.Logger.Info("daemon running (pid %d)", status.PID)
+	notifySystemd(ctx, sdnotify.Ready, sdnotify.Status(daemonSummary(status)))
+	if interval := sdnotify.WatchdogInterval(); interval > 0 {
+		go watchdog(ctx, interval/2)
+	}
+
+	// Each loop gets its own copy of the runtime context: HandleRun applies
+	// profiles to it, and the per-run instance lock keeps the loops' runs
+	// from overlapping.
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	loop := func(name string, fn func(*RuntimeContext) error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(ctx.fork(dctx)); err != nil {
+				errs <- fmt.Errorf("%s: %w", name, err)
+				stop()
+			}
+		}()
+	}
+	if ctx.Config.Daemon.Scheduler {
+		loop("scheduler", func(rtx *RuntimeContext) error {
+			return HandleScheduleRun(rtx, opts.Jobs)
+		})
+	}
+	if task := ctx.Config.Daemon.WatchTask; task != "" {
+		loop("watcher", func(rtx *RuntimeContext) error {
+			return HandleWatch(rtx, RunOptions{Task: task}, opts.Jobs)
+		})
+	}
+
+	<-dctx.Done()
+	notifySystemd(ctx, sdnotify.Stopping)
+	wg.Wait()
+	close(errs)
+	ctx.Logger.Info("daemon stopped")
+	var loopErrs []error
+	for err := range errs {
+		loopErrs = append(loopErrs, err)
+	}
+	return errors.Join(loopErrs...)
+}
+
+// HandleDaemonStop asks the resident instance (the daemon, or serve) to
+// stop and waits for it to exit. A run in progress is canceled and
+// checkpointed like on Ctrl+C.
+func HandleDaemonStop(ctx *RuntimeContext) error {
+	status, err := queryInstance(ctx, ctx.Paths.StateDir, control.CmdStatus)
+	if err != nil {
+		return fmt.Errorf("daemon is not running")
+	}
+	if ctx.Common.DryRun {
+		ctx.Logger.Info("dry-run: would stop the %s (pid %d)", status.Mode, status.PID)
+		return nil
+	}
+	if err := stopDaemon(ctx, status.PID); err != nil {
+		return err
+	}
+	ctx.Logger.Info("%s stopped (pid %d)", status.Mode, status.PID)
+	if ctx.Common.Porcelain {
+		ctx.Out.Println(status.PID)
+	}
+	return nil
+}
+
+// stopDaemon asks the instance with the given pid to stop and waits until
+// its control socket closes, which it does last on the way out.
+func stopDaemon(ctx *RuntimeContext, pid int) error {
+	if _, err := queryInstance(ctx, ctx.Paths.StateDir, control.CmdStop); err != nil {
+		return fmt.Errorf("stop daemon: %w", err)
+	}
+
+	path := controlSocketPath(ctx.Paths.StateDir)
+	deadline := time.Now().Add(daemonStopTimeout)
+	for {
+		conn, err := control.Dial(path, daemonDialTimeout)
+		if err != nil {
+			return nil
+		}
+		conn.Close()
+		if time.Now().After(deadline) {
+			return fmt.Errorf("daemon (pid %d) did not exit within %s", pid, humanize.Duration(daemonStopTimeout))
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(daemonPoll):
+		}
+	}
+}
+
+// HandleDaemonStatus reports whether the daemon (or serve) is running and
+// what it runs.
+func HandleDaemonStatus(ctx *RuntimeContext) error {
+	stateDir := ctx.Paths.StateDir
+	status, err := queryInstance(ctx, stateDir, control.CmdStatus)
+	if err != nil {
+		status = &DaemonStatus{
+			Socket:  control.Address(controlSocketPath(stateDir)),
+			LogFile: daemonLogPath(stateDir),
+		}
+		if _, err := os.Stat(daemonPIDPath(stateDir)); err == nil {
+			ctx.Logger.Warn("stale pid file %s: the daemon exited without cleaning up", daemonPIDPath(stateDir))
+		}
+	}
+	return printDaemonStatus(ctx, status)
+}
+
+// daemonLoops names the loops the daemon runs, for display.
+func daemonLoops(status DaemonStatus) []string {
+	loops := []string{}
+	if status.Scheduler {
+		loops = append(loops, "scheduler")
+	}
+	if status.WatchTask != "" {
+		loops = append(loops, "watcher ("+status.WatchTask+")")
+	}
+	return loops
+}
+
+func printDaemonStatus(ctx *RuntimeContext, status *DaemonStatus) error {
+	switch {
+	case ctx.Common.JSON:
+		data, err := json.MarshalIndent(status, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+	case ctx.Common.YAML:
+		data, err := yaml.Marshal(status)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		if status.Running {
+			fmt.Fprintf(ctx.Out.Writer(), "running\t%d\n", status.PID)
+		} else {
+			fmt.Fprintln(ctx.Out.Writer(), "stopped")
+		}
+	default:
+		if !status.Running {
+			ctx.Out.Failure(ctx.Out.Text(msgDaemonNotRunning))
+			return nil
+		}
+		out := ctx.Out
+		out.Success(out.Sprintf(msgDaemonRunning, status.Mode, strconv.Itoa(status.PID)))
+		rows := []KeyValue{{Key: out.Text(msgLabelStarted), Value: humanize.RelTime(*status.Started, ctx.Clock.Now())}}
+		if status.Mode == InstanceServe {
+			rows = append(rows, KeyValue{Key: "api", Value: "http://" + status.Addr})
+		} else {
+			rows = append(rows, KeyValue{Key: out.Text(msgLabelLoops), Value: strings.Join(daemonLoops(*status), ", ")})
+		}
+		rows = append(rows, KeyValue{Key: out.Text(msgLabelSocket), Value: status.Socket})
+		if status.LogFile != "" {
+			rows = append(rows, KeyValue{Key: out.Text(msgLabelLogFile), Value: status.LogFile})
+		}
+		ctx.Out.KeyValues("  ", rows)
+	}
+	return nil
+}
+
+// HandleDaemonLogs prints the recent log of the running instance (the
+// daemon, or serve) and, with Follow, streams new lines from it until the
+// command is interrupted. When nothing is running it prints the tail of
+// the daemon log file instead.
+func HandleDaemonLogs(ctx *RuntimeContext, opts DaemonLogsOptions) error {
+	out := ctx.Out.Writer()
+	if _, err := queryInstance(ctx, ctx.Paths.StateDir, control.CmdStatus); err == nil {
+		return instanceLogs(ctx, ctx.Paths.StateDir, opts.Lines, opts.Follow, out)
+	}
+
+	path := daemonLogPath(ctx.Paths.StateDir)
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		ctx.Logger.Info("daemon is not running and has no log yet at %s", path)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open daemon log: %w", err)
+	}
+	defer f.Close()
+
+	lines, err := tailLines(f, opts.Lines)
+	if err != nil {
+		return fmt.Errorf("read daemon log: %w", err)
+	}
+	for _, line := range lines {
+		fmt.Fprintln(out, line)
+	}
+	if opts.Follow {
+		ctx.Logger.Info("daemon is not running; nothing to follow")
+	}
+	return nil
+}
+
+// HandleDaemonReload makes the running instance (the daemon, or serve)
+// read its config file again. Runs started from then on use it; paths,
+// logging, listen addresses, and task definitions need a restart.
+func HandleDaemonReload(ctx *RuntimeContext) error {
+	if _, err := queryInstance(ctx, ctx.Paths.StateDir, control.CmdStatus); err != nil {
+		return fmt.Errorf("daemon is not running")
+	}
+	if ctx.Common.DryRun {
+		ctx.Logger.Info("dry-run: would reload the config of the running instance")
+		return nil
+	}
+	status, err := queryInstance(ctx, ctx.Paths.StateDir, control.CmdReload)
+	if err != nil {
+		return fmt.Errorf("reload: %w", err)
+	}
+	ctx.Logger.Info("%s (pid %d) reloaded %s", status.Mode, status.PID, ctx.Paths.ConfigFile)
+	if ctx.Common.Porcelain {
+		ctx.Out.Println(status.PID)
+	}
+	return nil
+}
+
+// tailLines returns the last n lines of r, or every line when n <= 0.
+func tailLines(r io.Reader, n int) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+		if n > 0 && len(lines) > n {
+			lines = lines[1:]
+		}
+	}
+	return lines, scanner.Err()
+}
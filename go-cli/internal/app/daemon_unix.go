@@ -0,0 +1,11 @@
+//go:build !windows
+
+package app
+
+import "syscall"
+
+// detachedProcAttr starts the daemon in its own session so it outlives the
+// terminal that launched it.
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,42 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// deprecationsPath lists the deprecated commands and flags already warned
+// about, one ID per line.
+func deprecationsPath(stateDir string) string {
+	return filepath.Join(stateDir, "deprecations")
+}
+
+// noDeprecationWarningsEnv silences deprecation warnings when set.
+func noDeprecationWarningsEnv() string {
+	return EnvPrefix() + "_NO_DEPRECATION_WARNINGS"
+}
+
+// NoticeDeprecated records that the deprecated command or flag id was used
+// on the command's trace span, and warns with message the first time
+// only. GO_CLI_NO_DEPRECATION_WARNINGS silences the warning, not the
+// record.
+func NoticeDeprecated(ctx *RuntimeContext, id, message string) {
+	ctx.recordDeprecation(id)
+	if os.Getenv(noDeprecationWarningsEnv()) != "" {
+		return
+	}
+	path := deprecationsPath(ctx.Paths.StateDir)
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		ctx.Logger.Debug("could not read %s: %v", path, err)
+	}
+	if slices.Contains(strings.Split(string(data), "\n"), id) {
+		return
+	}
+	ctx.Logger.Warn("%s (shown once; %s=1 hides these warnings)", message, noDeprecationWarningsEnv())
+	if err := writeFileAtomic(path, append(data, id+"\n"...), 0o644); err != nil {
+		ctx.Logger.Debug("could not record deprecation warning: %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/prompt"
+)
+
+// auditLogPath records every decision on a destructive action, one JSON
+// object per line.
+func auditLogPath(stateDir string) string {
+	return filepath.Join(stateDir, "audit.log")
+}
+
+// Destructive describes an action that deletes or overwrites the user's
+// data, such as `config reset` or `uninstall`.
+type Destructive struct {
+	// Action names the command in errors and the audit log.
+	Action string
+	// Resource is what the action destroys: a path, a profile, or a
+	// summary such as "12 items".
+	Resource string
+	// Question is asked on a terminal.
+	Question string
+	// HighRisk has the user type Resource instead of answering y.
+	HighRisk bool
+}
+
+// Decisions recorded in the audit log.
+const (
+	auditConfirmed = "confirmed"
+	auditAssumed   = "assumed" // --yes
+	auditDeclined  = "declined"
+	auditRefused   = "refused" // no terminal to ask on, and no --yes
+)
+
+// auditEntry is one line of the audit log.
+type auditEntry struct {
+	Time     time.Time `json:"time"`
+	Action   string    `json:"action"`
+	Resource string    `json:"resource"`
+	Decision string    `json:"decision"`
+	User     string    `json:"user,omitempty"`
+	PID      int       `json:"pid"`
+}
+
+// confirmDestructive asks before d goes ahead: on a terminal it prompts,
+// and elsewhere only --yes lets it through, so a script never destroys
+// data by accident. The decision is appended to the audit log. A nil
+// error means go ahead; dry runs destroy nothing and are not asked about.
+func confirmDestructive(ctx *RuntimeContext, d Destructive) error {
+	if ctx.Common.DryRun {
+		return nil
+	}
+	p := ctx.Prompter()
+	var decision string
+	var err error
+	switch {
+	case p.AssumeYes:
+		decision = auditAssumed
+	case p.NonInteractive:
+		decision = auditRefused
+		err = UsageError(fmt.Errorf("%s needs confirmation: %w (%s); pass --yes to go ahead", d.Action, prompt.ErrNonInteractive, p.Why()))
+	case !p.Interactive:
+		decision = auditRefused
+		err = UsageError(fmt.Errorf("%s needs confirmation but there is no terminal to ask on; pass --yes to go ahead", d.Action))
+	default:
+		var ok bool
+		if d.HighRisk {
+			ok, err = p.ConfirmTyped(d.Question, d.Resource)
+		} else {
+			ok, err = p.Confirm(d.Question, false)
+		}
+		decision = auditDeclined
+		if ok {
+			decision = auditConfirmed
+		} else if err == nil {
+			err = fmt.Errorf("%s canceled; pass --yes to confirm", d.Action)
+		}
+	}
+	ctx.audit(d, decision)
+	return err
+}
+
+// audit appends a decision to the audit log. A log that cannot be written
+// is warned about but does not stop the action.
+func (rtx *RuntimeContext) audit(d Destructive, decision string) {
+	entry := auditEntry{
+		Time:     rtx.Clock.Now().UTC(),
+		Action:   d.Action,
+		Resource: d.Resource,
+		Decision: decision,
+		PID:      os.Getpid(),
+	}
+	if u, err := user.Current(); err == nil {
+		entry.User = u.Username
+	}
+	if err := appendAuditEntry(auditLogPath(rtx.Paths.StateDir), entry); err != nil {
+		rtx.Logger.Warn("could not write the audit log: %v", err)
+	}
+}
+
+func appendAuditEntry(path string, entry auditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
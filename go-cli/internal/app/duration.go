@@ -0,0 +1,145 @@
+package app
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// Duration is a time.Duration that reads and writes Go duration strings
+// ("1s", "2m30s") in config files, flags, and JSON/YAML output.
+type Duration time.Duration
+
+// Std returns the value as a time.Duration.
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+// String implements fmt.Stringer.
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Set implements pflag.Value, so flags accept the same forms as config.
+func (d *Duration) Set(value string) error {
+	return d.UnmarshalText([]byte(value))
+}
+
+// Type implements pflag.Value.
+func (d *Duration) Type() string {
+	return "duration"
+}
+
+// ParseDuration parses a Go duration string, additionally accepting a
+// whole number of days ("7d") for retention settings.
+func ParseDuration(text string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(text, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return time.Duration(n) * 24 * time.Hour, nil
+		}
+	}
+	parsed, err := time.ParseDuration(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid duration %q (e.g. 90s, 2m30s, 7d)", text)
+	}
+	return parsed, nil
+}
+
+// Timeout is the Duration of runtime.timeout and --timeout. Both took
+// whole seconds before they took durations, so a bare integer is still
+// read as seconds there; other durations need a unit.
+type Timeout Duration
+
+// Std returns the value as a time.Duration.
+func (t Timeout) Std() time.Duration {
+	return time.Duration(t)
+}
+
+// String implements fmt.Stringer.
+func (t Timeout) String() string {
+	return time.Duration(t).String()
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (t Timeout) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (t *Timeout) UnmarshalText(text []byte) error {
+	parsed, err := parseTimeout(string(text))
+	if err != nil {
+		return err
+	}
+	*t = Timeout(parsed)
+	return nil
+}
+
+// Set implements pflag.Value, so --timeout accepts the same forms as
+// runtime.timeout.
+func (t *Timeout) Set(value string) error {
+	return t.UnmarshalText([]byte(value))
+}
+
+// Type implements pflag.Value.
+func (t *Timeout) Type() string {
+	return "duration"
+}
+
+// parseTimeout parses a timeout: a duration or a whole number of seconds,
+// neither of them negative.
+func parseTimeout(text string) (time.Duration, error) {
+	if n, err := strconv.Atoi(text); err == nil {
+		if n < 0 {
+			return 0, fmt.Errorf("invalid timeout %q (must not be negative)", text)
+		}
+		return time.Duration(n) * time.Second, nil
+	}
+	parsed, err := ParseDuration(text)
+	if err != nil {
+		return 0, err
+	}
+	if parsed < 0 {
+		return 0, fmt.Errorf("invalid timeout %q (must not be negative)", text)
+	}
+	return parsed, nil
+}
+
+// timeoutHook decodes integers into Timeout as seconds; strings go
+// through UnmarshalText.
+func timeoutHook() mapstructure.DecodeHookFuncType {
+	target := reflect.TypeOf(Timeout(0))
+	return func(from, to reflect.Type, data any) (any, error) {
+		if to != target {
+			return data, nil
+		}
+		switch v := data.(type) {
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			n := reflect.ValueOf(v).Convert(reflect.TypeOf(int64(0))).Int()
+			if n < 0 {
+				return nil, fmt.Errorf("invalid timeout %d (must not be negative)", n)
+			}
+			return Timeout(time.Duration(n) * time.Second), nil
+		}
+		return data, nil
+	}
+}
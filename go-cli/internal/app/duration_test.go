@@ -0,0 +1,68 @@
+package app
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseDurationNeedsUnit(t *testing.T) {
+	for _, text := range []string{"7", "90"} {
+		if d, err := ParseDuration(text); err == nil {
+			t.Errorf("ParseDuration(%q) = %s, want an error", text, d)
+		}
+	}
+	if d, err := ParseDuration("7d"); err != nil || d != 7*24*time.Hour {
+		t.Errorf("ParseDuration(7d) = %s, %v", d, err)
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	for _, tt := range []struct {
+		text string
+		want time.Duration
+		ok   bool
+	}{
+		{"90", 90 * time.Second, true},
+		{"0", 0, true},
+		{"1500ms", 1500 * time.Millisecond, true},
+		{"2m30s", 150 * time.Second, true},
+		{"-5", 0, false},
+		{"-5s", 0, false},
+		{"soon", 0, false},
+	} {
+		var got Timeout
+		err := got.Set(tt.text)
+		if (err == nil) != tt.ok || got.Std() != tt.want {
+			t.Errorf("Set(%q) = %s, %v; want %s, ok=%v", tt.text, got, err, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestRuntimeTimeoutConfig(t *testing.T) {
+	for _, tt := range []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{`90`, 90 * time.Second, true},
+		{`"90s"`, 90 * time.Second, true},
+		{`-5`, 0, false},
+		{`"-5s"`, 0, false},
+	} {
+		dir := t.TempDir()
+		paths := AppPaths{ConfigFile: filepath.Join(dir, "config.toml")}
+		if err := os.WriteFile(paths.ConfigFile, []byte("[runtime]\ntimeout = "+tt.value+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := LoadOrInitConfig(paths, CommonFlags{})
+		if (err == nil) != tt.ok {
+			t.Errorf("timeout = %s: err = %v, want ok=%v", tt.value, err, tt.ok)
+			continue
+		}
+		if err == nil && cfg.Runtime.TimeoutDuration() != tt.want {
+			t.Errorf("timeout = %s: got %s, want %s", tt.value, cfg.Runtime.TimeoutDuration(), tt.want)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// EnvVar is an environment variable the CLI reads.
+type EnvVar struct {
+	Name string `json:"name" yaml:"name"`
+	// Key is the config key the variable overrides, if any.
+	Key string `json:"key,omitempty" yaml:"key,omitempty"`
+	// Command is the command path, without the binary name, of the only
+	// command that reads the variable; empty means every command.
+	Command     string `json:"command,omitempty" yaml:"command,omitempty"`
+	Description string `json:"description" yaml:"description"`
+	// Secret values are redacted when displayed.
+	Secret bool `json:"secret,omitempty" yaml:"secret,omitempty"`
+}
+
+// ConfigEnvName returns the variable that overrides a dotted config key,
+// e.g. GO_CLI_RUNTIME__FAIL_FAST for runtime.fail_fast.
+func ConfigEnvName(key string) string {
+	return EnvPrefix() + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "__"))
+}
+
+// EnvVars lists every variable the CLI reads: one override per scalar or
+// list config key, described from the config schema, followed by the
+// variables individual commands read.
+func EnvVars() []EnvVar {
+	vars := configEnvVars()
+	return append(vars,
+		EnvVar{Name: noDeprecationWarningsEnv(), Description: "Set to any value to hide the one-time warnings about deprecated commands and flags"},
+		EnvVar{Name: EnvPrefix() + "_CHAOS", Command: "run", Description: "Percentage of task attempts to fail or delay at random (same as the hidden --chaos flag)"},
+		EnvVar{Name: cdFileEnv(), Command: "cd", Description: "File the shell-init wrapper reads the directory to change into from; set by the wrapper"},
+		EnvVar{Name: "SOURCE_DATE_EPOCH", Command: "docs man", Description: "Unix time to print as the page date, for reproducible builds"},
+	)
+}
+
+// schemaNode is the part of a JSON schema configEnvVars reads.
+type schemaNode struct {
+	Description          string                `json:"description"`
+	Ref                  string                `json:"$ref"`
+	Properties           map[string]schemaNode `json:"properties"`
+	AdditionalProperties json.RawMessage       `json:"additionalProperties"`
+	Definitions          map[string]schemaNode `json:"definitions"`
+}
+
+// configEnvVars walks the config schema. Tables keyed by task name (tasks,
+// runtime.priority, runtime.retry.tasks) have no single variable and are
+// left out.
+func configEnvVars() []EnvVar {
+	var root schemaNode
+	if err := json.Unmarshal([]byte(configSchemaJSON), &root); err != nil {
+		panic("invalid embedded config schema: " + err.Error())
+	}
+
+	var vars []EnvVar
+	var walk func(prefix string, node schemaNode)
+	walk = func(prefix string, node schemaNode) {
+		for name, child := range node.Properties {
+			key := prefix + name
+			switch {
+			case strings.HasPrefix(name, "$"):
+			case len(child.Properties) > 0:
+				walk(key+".", child)
+			case len(child.AdditionalProperties) > 0 && string(child.AdditionalProperties) != "false":
+			default:
+				description := child.Description
+				if description == "" && child.Ref != "" {
+					description = root.Definitions[strings.TrimPrefix(child.Ref, "#/definitions/")].Description
+				}
+				vars = append(vars, EnvVar{
+					Name:        ConfigEnvName(key),
+					Key:         key,
+					Description: description,
+					Secret:      secretKey(key),
+				})
+			}
+		}
+	}
+	walk("", root)
+	sort.Slice(vars, func(i, j int) bool { return vars[i].Key < vars[j].Key })
+	return vars
+}
+
+// secretKey reports whether values of the config key may hold credentials:
+// exec.env and task env lists carry KEY=VALUE pairs for child processes,
+// header tables carry API keys, webhook URLs often embed one, the proxy
+// URL may carry user:password, and keys that mention tokens or passwords
+// are treated the same way.
+func secretKey(key string) bool {
+	if key == "exec.env" || key == "http.proxy" || strings.HasSuffix(key, ".env") || strings.Contains(key, ".headers") || (strings.HasPrefix(key, "notifications.") && strings.HasSuffix(key, ".url")) {
+		return true
+	}
+	for _, word := range []string{"token", "password", "secret", "credential"} {
+		if strings.Contains(key, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// redacted replaces secret values in env output.
+const redacted = "[redacted]"
+
+// EnvStatus is an EnvVar with its value in the current environment.
+type EnvStatus struct {
+	EnvVar `yaml:",inline"`
+	Set    bool   `json:"set" yaml:"set"`
+	Value  string `json:"value,omitempty" yaml:"value,omitempty"`
+}
+
+// HandleEnv prints every recognized GO_CLI_* variable, whether it is set,
+// its value, and the config key it overrides.
+func HandleEnv(ctx *RuntimeContext) error {
+	var vars []EnvStatus
+	for _, v := range EnvVars() {
+		if !strings.HasPrefix(v.Name, EnvPrefix()+"_") {
+			continue
+		}
+		status := EnvStatus{EnvVar: v}
+		status.Value, status.Set = os.LookupEnv(v.Name)
+		if status.Set && v.Secret && status.Value != "" {
+			status.Value = redacted
+		}
+		vars = append(vars, status)
+	}
+
+	switch {
+	case ctx.Common.JSON:
+		data, err := json.MarshalIndent(vars, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+	case ctx.Common.YAML:
+		data, err := yaml.Marshal(vars)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		for _, v := range vars {
+			if v.Set {
+				fmt.Fprintf(ctx.Out.Writer(), "%s\t%s\t%s\n", v.Name, v.Key, v.Value)
+			}
+		}
+	default:
+		rows := make([][]string, 0, len(vars))
+		for _, v := range vars {
+			value := ctx.Out.Dim(ctx.Out.Text(msgUnset))
+			if v.Set {
+				value = ctx.Out.Green(v.Value)
+			}
+			key := v.Key
+			if key == "" {
+				key = ctx.Out.Dim("(" + v.Command + ")")
+			}
+			rows = append(rows, []string{v.Name, value, key})
+		}
+		ctx.Out.Table("", headers(ctx.Out, msgColVariable, msgColValue, msgColConfigKey), rows)
+	}
+	return nil
+}
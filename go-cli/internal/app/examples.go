@@ -0,0 +1,56 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// CommandExamples holds the example command lines of one command.
+type CommandExamples struct {
+	Command  string   `json:"command" yaml:"command"`
+	Summary  string   `json:"summary" yaml:"summary"`
+	Examples []string `json:"examples" yaml:"examples"`
+}
+
+// HandleExamples prints the examples of each command under a heading naming
+// it; --porcelain prints just the command lines.
+func HandleExamples(ctx *RuntimeContext, commands []CommandExamples) error {
+	switch {
+	case ctx.Common.JSON:
+		data, err := json.MarshalIndent(commands, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+	case ctx.Common.YAML:
+		data, err := yaml.Marshal(commands)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		for _, c := range commands {
+			for _, line := range c.Examples {
+				fmt.Fprintln(ctx.Out.Writer(), line)
+			}
+		}
+	default:
+		if len(commands) == 0 {
+			ctx.Logger.Info("no examples")
+			return nil
+		}
+		for i, c := range commands {
+			if i > 0 {
+				ctx.Out.Println()
+			}
+			ctx.Out.Heading(c.Command)
+			ctx.Out.Println(ctx.Out.Dim(c.Summary))
+			for _, line := range c.Examples {
+				ctx.Out.Println("  " + line)
+			}
+		}
+	}
+	return nil
+}
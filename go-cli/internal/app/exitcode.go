@@ -0,0 +1,64 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/humanize"
+)
+
+// Process exit codes. Scripts may rely on these values; never renumber them.
+// ExitCodes in explain.go documents each one for `explain`.
+const (
+	ExitOK      = 0
+	ExitFailure = 1
+	ExitUsage   = 2
+	// ExitPartialFailure means some tasks failed while others succeeded.
+	ExitPartialFailure = 3
+	ExitTimeout        = 4
+)
+
+// ExitError attaches a process exit code to an error.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// WithExitCode wraps err so the process exits with code.
+func WithExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &ExitError{Code: code, Err: err}
+}
+
+// UsageError marks err as caused by invalid flags or arguments.
+func UsageError(err error) error {
+	return WithExitCode(ExitUsage, err)
+}
+
+// TimeoutError reports an operation that exceeded its deadline.
+func TimeoutError(after time.Duration) error {
+	return WithExitCode(ExitTimeout, fmt.Errorf("operation timed out after %s", humanize.Duration(after)))
+}
+
+// ExitCode maps err to the process exit code.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return ExitFailure
+}
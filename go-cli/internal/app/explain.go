@@ -0,0 +1,171 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// ExitCodeInfo documents one exit code for `explain`.
+type ExitCodeInfo struct {
+	Code        int      `json:"code" yaml:"code"`
+	Name        string   `json:"name" yaml:"name"`
+	Summary     string   `json:"summary" yaml:"summary"`
+	Causes      []string `json:"causes" yaml:"causes"`
+	Remediation []string `json:"remediation" yaml:"remediation"`
+}
+
+// ExitCodes describes every exit code the CLI returns, in code order. Keep
+// it in step with the constants in exitcode.go and the README table.
+var ExitCodes = []ExitCodeInfo{
+	{
+		Code:    ExitOK,
+		Name:    "E_OK",
+		Summary: "The command succeeded.",
+		Causes:  []string{"Everything requested was done, or was already up to date."},
+	},
+	{
+		Code:    ExitFailure,
+		Name:    "E_FAILURE",
+		Summary: "The command failed.",
+		Causes: []string{
+			"Every task in a run failed, or a hook configured to fail the run did.",
+			"The config file could not be read or is invalid.",
+			"Another instance holds the lock (\"another instance (pid N) is running\").",
+			"A file or directory could not be read or written.",
+		},
+		Remediation: []string{
+			"Read the error message; rerun with -v or --debug for more detail.",
+			"For runs, check the task logs named in the failure summary, or `history show <run-id>`.",
+			"Use --wait to queue behind another instance instead of failing.",
+			"Check the config with `config show`; collect details with `bug-report`.",
+		},
+	},
+	{
+		Code:    ExitUsage,
+		Name:    "E_USAGE",
+		Summary: "The command line was invalid.",
+		Causes: []string{
+			"An unknown flag, a missing or extra argument, or an invalid flag value.",
+			"Conflicting flags, such as --json with --yaml.",
+			"An unknown task name, or an alias that expands into a loop.",
+			"A destructive command such as prune or uninstall run without a terminal to confirm on and without --yes.",
+		},
+		Remediation: []string{
+			"Run the command with --help to see its usage.",
+			"Run `run --list` to see the available tasks, and `alias list` for aliases.",
+			"Pass --yes to let a destructive command go ahead in a script, after previewing it with --dry-run.",
+		},
+	},
+	{
+		Code:    ExitPartialFailure,
+		Name:    "E_PARTIAL",
+		Summary: "Some tasks failed while others succeeded.",
+		Causes: []string{
+			"A run with runtime.fail_fast = false completed some tasks and failed others.",
+		},
+		Remediation: []string{
+			"The failure summary, and the JSON `failures` list, name the failed tasks and their logs.",
+			"Fix the failures and rerun with --resume to skip the tasks that already succeeded.",
+		},
+	},
+	{
+		Code:    ExitTimeout,
+		Name:    "E_TIMEOUT",
+		Summary: "The operation exceeded its time limit.",
+		Causes: []string{
+			"The run took longer than --timeout or runtime.timeout allow.",
+			"A task was slowed by rate limiting, retries, or an overloaded machine.",
+		},
+		Remediation: []string{
+			"Raise the limit with --timeout (e.g. 2m30s) or runtime.timeout in the config.",
+			"Check `history show <run-id>` for the slowest tasks.",
+			"Rerun with --resume to continue from the checkpoint.",
+		},
+	},
+}
+
+// LookupExitCode finds an exit code by number or name. Names match case
+// insensitively, with or without the E_ prefix.
+func LookupExitCode(query string) (ExitCodeInfo, bool) {
+	if code, err := strconv.Atoi(query); err == nil {
+		for _, info := range ExitCodes {
+			if info.Code == code {
+				return info, true
+			}
+		}
+		return ExitCodeInfo{}, false
+	}
+	name := strings.ToUpper(query)
+	if !strings.HasPrefix(name, "E_") {
+		name = "E_" + name
+	}
+	for _, info := range ExitCodes {
+		if info.Name == name {
+			return info, true
+		}
+	}
+	return ExitCodeInfo{}, false
+}
+
+// HandleExplain describes the exit code named by query, or lists every
+// exit code when query is empty.
+func HandleExplain(ctx *RuntimeContext, query string) error {
+	codes := ExitCodes
+	if query != "" {
+		info, ok := LookupExitCode(query)
+		if !ok {
+			if _, err := strconv.Atoi(query); err == nil {
+				return UsageError(fmt.Errorf("%s is not a %s exit code (a plugin may return its own statuses); run explain without arguments for the list", query, appName))
+			}
+			return UsageError(fmt.Errorf("unknown exit code %q; run explain without arguments for the list", query))
+		}
+		codes = []ExitCodeInfo{info}
+	}
+
+	switch {
+	case ctx.Common.JSON:
+		data, err := json.MarshalIndent(codes, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+	case ctx.Common.YAML:
+		data, err := yaml.Marshal(codes)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		for _, info := range codes {
+			fmt.Fprintf(ctx.Out.Writer(), "%d\t%s\t%s\n", info.Code, info.Name, info.Summary)
+		}
+	case query == "":
+		rows := make([][]string, 0, len(codes))
+		for _, info := range codes {
+			rows = append(rows, []string{strconv.Itoa(info.Code), info.Name, info.Summary})
+		}
+		ctx.Out.Table("", []string{"CODE", "NAME", "MEANING"}, rows)
+	default:
+		info := codes[0]
+		ctx.Out.Heading(fmt.Sprintf("%d %s", info.Code, info.Name))
+		ctx.Out.Println(info.Summary)
+		explainList(ctx, "Likely causes", info.Causes)
+		explainList(ctx, "What to do", info.Remediation)
+	}
+	return nil
+}
+
+func explainList(ctx *RuntimeContext, title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	ctx.Out.Println("")
+	ctx.Out.Println(ctx.Out.Bold(title + ":"))
+	for _, item := range items {
+		ctx.Out.Println("  - " + item)
+	}
+}
@@ -0,0 +1,104 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/runner"
+)
+
+// TaskError is the final error of a failed task.
+type TaskError struct {
+	Task     string
+	Attempts int
+	Err      error
+	// Log is the task's own log file, if it wrote one.
+	Log string
+}
+
+func (e *TaskError) Error() string {
+	if e.Attempts > 1 {
+		return fmt.Sprintf("%s (after %d attempts): %v", e.Task, e.Attempts, e.Err)
+	}
+	return fmt.Sprintf("%s: %v", e.Task, e.Err)
+}
+
+func (e *TaskError) Unwrap() error {
+	return e.Err
+}
+
+// TaskFailure is the JSON/YAML form of a TaskError.
+type TaskFailure struct {
+	Task     string `json:"task" yaml:"task"`
+	Attempts int    `json:"attempts" yaml:"attempts"`
+	Error    string `json:"error" yaml:"error"`
+	Log      string `json:"log,omitempty" yaml:"log,omitempty"`
+}
+
+// collectFailures returns every failed task in report order.
+func collectFailures(report runner.Report) []*TaskError {
+	var failures []*TaskError
+	for _, r := range report.Results {
+		if r.Err != nil {
+			failures = append(failures, &TaskError{Task: r.Name, Attempts: r.Attempts, Err: r.Err})
+		}
+	}
+	return failures
+}
+
+// failureError joins the task errors. When some tasks succeeded the result
+// carries ExitPartialFailure so scripts can tell partial from total failure.
+func failureError(failures []*TaskError, succeeded int) error {
+	if len(failures) == 0 {
+		return nil
+	}
+	errs := make([]error, len(failures))
+	for i, f := range failures {
+		errs[i] = f
+	}
+	joined := errors.Join(errs...)
+	if succeeded > 0 {
+		return WithExitCode(ExitPartialFailure, joined)
+	}
+	return joined
+}
+
+func failureRecords(failures []*TaskError) []TaskFailure {
+	records := make([]TaskFailure, len(failures))
+	for i, f := range failures {
+		records[i] = TaskFailure{Task: f.Task, Attempts: f.Attempts, Error: f.Err.Error(), Log: f.Log}
+	}
+	return records
+}
+
+// renderFailures prints failed tasks grouped by identical error message, so
+// one root cause hitting many tasks reads as one entry. Each task points to
+// its log file.
+func renderFailures(out Renderer, failures []*TaskError, total int) {
+	if len(failures) == 0 {
+		return
+	}
+
+	var order []string
+	groups := map[string][]*TaskError{}
+	for _, f := range failures {
+		msg := f.Err.Error()
+		if _, ok := groups[msg]; !ok {
+			order = append(order, msg)
+		}
+		groups[msg] = append(groups[msg], f)
+	}
+
+	out.Println()
+	out.Heading(out.Sprintf(msgFailures, len(failures), plural(out, total, msgTask, msgTasks)))
+	for _, msg := range order {
+		out.Failure(msg)
+		for _, f := range groups[msg] {
+			detail := plural(out, f.Attempts, msgAttempt, msgAttempts)
+			if f.Log != "" {
+				detail += out.Sprintf(msgFailureLog, f.Log)
+			}
+			out.Println("    " + f.Task + out.Dim(" ("+detail+")"))
+		}
+	}
+}
@@ -1,24 +1,36 @@
 package app
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // CommonFlags capture global CLI options shared by all commands.
 type CommonFlags struct {
 	ConfigPath     string
+	Set            []string
 	Quiet          bool
 	Verbose        int
 	Debug          bool
 	Trace          bool
 	JSON           bool
 	YAML           bool
+	Porcelain      bool
 	LogFormat      string
 	NoColor        bool
 	Color          string
+	ASCII          bool
+	Lang           string
 	DryRun         bool
 	AssumeYes      bool
-	TimeoutSeconds *int
-	Parallelism    *int
+	NonInteractive bool
+	NoOnboarding   bool
+	Timeout        *time.Duration
+	Parallelism    *Parallelism
 	NoProgress     bool
+	Copy           bool
+	WaitLock       bool
+	NoLock         bool
 	Diagnostics    bool
 }
 
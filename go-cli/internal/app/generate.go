@@ -0,0 +1,136 @@
+package app
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/scaffold"
+)
+
+// GenerateCommandOptions configure `generate command`.
+type GenerateCommandOptions struct {
+	Name string
+	// Dir is where to look for the project root; empty means the working
+	// directory.
+	Dir string
+	// Force overwrites the command, handler, and test files if they exist.
+	Force bool
+	// Reserved are the names the root command already uses.
+	Reserved []string
+}
+
+// GeneratedFile is one file `generate command` wrote.
+type GeneratedFile struct {
+	Path string `json:"path" yaml:"path"`
+	// Action is "create", "overwrite", "update", or "unchanged".
+	Action string `json:"action" yaml:"action"`
+}
+
+// HandleGenerateCommand scaffolds a subcommand in the project around
+// opts.Dir: cmd/<name>.go, a handler stub and its test in internal/app,
+// and the regenerated registration file that adds it to the root command.
+func HandleGenerateCommand(ctx *RuntimeContext, opts GenerateCommandOptions) error {
+	if err := scaffold.ValidateName(opts.Name); err != nil {
+		return UsageError(err)
+	}
+	if slices.Contains(opts.Reserved, opts.Name) {
+		return UsageError(fmt.Errorf("%q is already a command", opts.Name))
+	}
+	dir := opts.Dir
+	if dir == "" {
+		dir = "."
+	}
+	root, module, err := scaffold.FindRoot(dir)
+	if err != nil {
+		return UsageError(err)
+	}
+
+	command := scaffold.Command{Name: opts.Name, App: appName, Module: module}
+	files, err := scaffold.Render(command)
+	if err != nil {
+		return err
+	}
+	if !opts.Force {
+		defined, err := scaffold.Defined(root, command)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			if _, err := os.Stat(filepath.Join(root, f.Path)); err == nil && !slices.Contains(defined, f.Path) {
+				defined = append(defined, f.Path)
+			}
+		}
+		if len(defined) > 0 {
+			return UsageError(fmt.Errorf("%s already exists in %s; pass --force to overwrite the generated files", opts.Name, strings.Join(defined, ", ")))
+		}
+	}
+	registration, err := scaffold.Registration(root, appName, command.Constructor())
+	if err != nil {
+		return err
+	}
+	files = append(files, registration)
+
+	written := make([]GeneratedFile, 0, len(files))
+	for _, f := range files {
+		path := filepath.Join(root, f.Path)
+		action := "create"
+		if current, err := os.ReadFile(path); err == nil {
+			switch {
+			case bytes.Equal(current, f.Content):
+				action = "unchanged"
+			case f.Path == registration.Path:
+				action = "update"
+			default:
+				action = "overwrite"
+			}
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		if ctx.Common.DryRun {
+			ctx.Logger.Info("dry-run: would %s %s", action, path)
+			continue
+		}
+		if action != "unchanged" {
+			if err := writeFileAtomic(path, f.Content, 0o644); err != nil {
+				return fmt.Errorf("write %s: %w", f.Path, err)
+			}
+		}
+		written = append(written, GeneratedFile{Path: path, Action: action})
+	}
+	if ctx.Common.DryRun {
+		return nil
+	}
+
+	switch {
+	case ctx.Common.JSON:
+		data, err := json.MarshalIndent(written, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+	case ctx.Common.YAML:
+		data, err := yaml.Marshal(written)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		for _, f := range written {
+			ctx.Out.Println(f.Path)
+		}
+	default:
+		for _, f := range written {
+			ctx.Logger.Info("%s %s", f.Action, f.Path)
+		}
+		ctx.Logger.Info("fill in cmd/%s and Handle%s, then run go build ./... && go test ./...", filepath.Base(files[0].Path), command.Camel())
+	}
+	return nil
+}
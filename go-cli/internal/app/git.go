@@ -0,0 +1,34 @@
+package app
+
+import (
+	"strconv"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/gitinfo"
+)
+
+// detectGit describes the git repository of the working directory, or
+// returns nil outside one. It is best effort: failures are logged at debug
+// level, since most commands work the same without it.
+func detectGit(ctx *RuntimeContext) *gitinfo.Info {
+	info, err := gitinfo.Detect(ctx, ".")
+	if err != nil {
+		ctx.Logger.Debug("git repository not described: %v", err)
+		return nil
+	}
+	return info
+}
+
+// gitEnviron returns GO_CLI_GIT_ROOT, _BRANCH, _COMMIT, and _DIRTY for the
+// repository of the run in progress, or nothing outside one.
+func (rtx *RuntimeContext) gitEnviron() []string {
+	if rtx.Git == nil {
+		return nil
+	}
+	prefix := EnvPrefix() + "_GIT_"
+	return []string{
+		prefix + "ROOT=" + rtx.Git.Root,
+		prefix + "BRANCH=" + rtx.Git.Branch,
+		prefix + "COMMIT=" + rtx.Git.Commit,
+		prefix + "DIRTY=" + strconv.FormatBool(rtx.Git.Dirty),
+	}
+}
@@ -0,0 +1,81 @@
+package app
+
+// Glyphs holds every non-alphanumeric symbol used in human-readable output so
+// callers never hard-code Unicode characters.
+type Glyphs struct {
+	Success  string
+	Failure  string
+	Warning  string
+	Skipped  string
+	Bullet   string
+	Arrow    string
+	Ellipsis string
+	Spinner  []string
+	Bar      BarGlyphs
+	Box      BoxGlyphs
+}
+
+// BarGlyphs are the characters used to draw progress bars.
+type BarGlyphs struct {
+	Filled string
+	Empty  string
+}
+
+// BoxGlyphs are the line-drawing characters used for tables and panels.
+type BoxGlyphs struct {
+	Horizontal  string
+	Vertical    string
+	TopLeft     string
+	TopRight    string
+	BottomLeft  string
+	BottomRight string
+}
+
+var unicodeGlyphs = Glyphs{
+	Success:  "✓",
+	Failure:  "✗",
+	Warning:  "!",
+	Skipped:  "↷",
+	Bullet:   "•",
+	Arrow:    "→",
+	Ellipsis: "…",
+	Spinner:  []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"},
+	Bar:      BarGlyphs{Filled: "█", Empty: "░"},
+	Box: BoxGlyphs{
+		Horizontal:  "─",
+		Vertical:    "│",
+		TopLeft:     "┌",
+		TopRight:    "┐",
+		BottomLeft:  "└",
+		BottomRight: "┘",
+	},
+}
+
+var asciiGlyphs = Glyphs{
+	Success:  "ok",
+	Failure:  "x",
+	Warning:  "!",
+	Skipped:  "-",
+	Bullet:   "*",
+	Arrow:    "->",
+	Ellipsis: "...",
+	Spinner:  []string{"|", "/", "-", "\\"},
+	Bar:      BarGlyphs{Filled: "#", Empty: "-"},
+	Box: BoxGlyphs{
+		Horizontal:  "-",
+		Vertical:    "|",
+		TopLeft:     "+",
+		TopRight:    "+",
+		BottomLeft:  "+",
+		BottomRight: "+",
+	},
+}
+
+// ResolveGlyphs picks the glyph set from the --ascii flag and the
+// output.unicode config key. --ascii forces ASCII regardless of config.
+func ResolveGlyphs(flags CommonFlags, cfg AppConfig) Glyphs {
+	if flags.ASCII || !cfg.Output.Unicode {
+		return asciiGlyphs
+	}
+	return unicodeGlyphs
+}
@@ -0,0 +1,59 @@
+package app_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app/apptest"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/runner"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/testutil/golden"
+)
+
+// formats are the output modes every golden test covers.
+var formats = []struct {
+	name  string
+	flags app.CommonFlags
+}{
+	{"text", app.CommonFlags{}},
+	{"json", app.CommonFlags{JSON: true}},
+	{"yaml", app.CommonFlags{YAML: true}},
+	{"porcelain", app.CommonFlags{Porcelain: true}},
+}
+
+func TestConfigShowGolden(t *testing.T) {
+	for _, format := range formats {
+		t.Run(format.name, func(t *testing.T) {
+			tc := apptest.NewTestContext(t, apptest.WithFlags(format.flags))
+			if err := app.HandleConfigShow(tc.RuntimeContext); err != nil {
+				t.Fatalf("HandleConfigShow: %v", err)
+			}
+			golden.Assert(t, "config-show/"+format.name, tc.Stdout.String(), golden.Path(tc.Root, "$ROOT"))
+		})
+	}
+}
+
+// runConfig pins the worker count, which otherwise follows the CPUs.
+const runConfig = `[runtime]
+parallelism = 1
+fail_fast = false
+`
+
+func TestRunGolden(t *testing.T) {
+	for _, format := range formats {
+		t.Run(format.name, func(t *testing.T) {
+			tc := apptest.NewTestContext(t, apptest.WithFlags(format.flags), apptest.WithConfig(runConfig))
+			jobs := []runner.Job{
+				{Name: "build", Run: func(context.Context) error { return nil }},
+				{Name: "test", Deps: []string{"build"}, Run: func(context.Context) error { return nil }},
+				{Name: "lint", Run: func(context.Context) error { return errors.New("2 problems") }},
+			}
+			err := app.HandleRun(tc.RuntimeContext, app.RunOptions{Task: "ci", Jobs: jobs})
+			if err == nil {
+				t.Fatal("HandleRun succeeded, want the lint failure")
+			}
+			golden.Assert(t, "run/"+format.name, tc.Stdout.String()+"error: "+err.Error()+"\n", golden.Path(tc.Root, "$ROOT"))
+		})
+	}
+}
@@ -0,0 +1,300 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	controlv1 "gitlab.cc-asp.fraunhofer.de/templates/go-cli/api/control/v1"
+)
+
+// grpcLogPoll is how often StreamLogs with follow looks for new lines.
+const grpcLogPoll = 250 * time.Millisecond
+
+// grpcAddr is a parsed serve.grpc_addr: "unix:PATH" for a Unix socket,
+// else host:port.
+type grpcAddr struct {
+	network, address string
+}
+
+func parseGRPCAddr(addr string) (grpcAddr, error) {
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		if path == "" {
+			return grpcAddr{}, fmt.Errorf("invalid gRPC address %q (expected unix:PATH)", addr)
+		}
+		expanded, err := expandPath(path)
+		if err != nil {
+			return grpcAddr{}, err
+		}
+		return grpcAddr{network: "unix", address: expanded}, nil
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		return grpcAddr{}, fmt.Errorf("invalid gRPC address %q (expected host:port or unix:PATH): %v", addr, err)
+	}
+	return grpcAddr{network: "tcp", address: addr}, nil
+}
+
+// local reports whether only this machine can connect.
+func (a grpcAddr) local() bool {
+	if a.network == "unix" {
+		return true
+	}
+	host, _, _ := net.SplitHostPort(a.address)
+	return loopbackHost(host)
+}
+
+func (a grpcAddr) String() string {
+	if a.network == "unix" {
+		return "unix:" + a.address
+	}
+	return a.address
+}
+
+// listen opens the address. A stale socket file is replaced, and the
+// socket is made accessible to its owner only.
+func (a grpcAddr) listen() (net.Listener, error) {
+	if a.network == "unix" {
+		if err := os.MkdirAll(filepath.Dir(a.address), 0o755); err != nil {
+			return nil, err
+		}
+		if conn, err := net.Dial("unix", a.address); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s is in use by another server", a.address)
+		}
+		_ = os.Remove(a.address)
+	}
+	ln, err := net.Listen(a.network, a.address)
+	if err != nil {
+		return nil, err
+	}
+	if a.network == "unix" {
+		if err := os.Chmod(a.address, 0o600); err != nil {
+			ln.Close()
+			return nil, err
+		}
+	}
+	return ln, nil
+}
+
+// controlService implements the gRPC Control service on top of the HTTP
+// server's run queue, so runs from either API share one queue.
+type controlService struct {
+	controlv1.UnimplementedControlServer
+	s *server
+}
+
+// grpcServer returns a gRPC server exposing s, behind the bearer token
+// when one is configured.
+func (s *server) grpcServer() *grpc.Server {
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, next grpc.UnaryHandler) (any, error) {
+			if err := s.authGRPC(ctx); err != nil {
+				return nil, err
+			}
+			start := time.Now()
+			resp, err := next(ctx, req)
+			s.ctx.Logger.Debug("grpc %s -> %s (%s)", info.FullMethod, status.Code(err), time.Since(start).Round(time.Millisecond))
+			return resp, err
+		}),
+		grpc.ChainStreamInterceptor(func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, next grpc.StreamHandler) error {
+			if err := s.authGRPC(ss.Context()); err != nil {
+				return err
+			}
+			err := next(srv, ss)
+			s.ctx.Logger.Debug("grpc %s -> %s", info.FullMethod, status.Code(err))
+			return err
+		}),
+	)
+	controlv1.RegisterControlServer(srv, &controlService{s: s})
+	return srv
+}
+
+// authGRPC checks the "authorization: Bearer <token>" metadata.
+func (s *server) authGRPC(ctx context.Context) error {
+	if s.token == "" {
+		return nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, value := range md.Get("authorization") {
+		given, ok := strings.CutPrefix(value, "Bearer ")
+		if ok && subtle.ConstantTimeCompare([]byte(given), []byte(s.token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or invalid bearer token")
+}
+
+func (c *controlService) TriggerRun(_ context.Context, req *controlv1.TriggerRunRequest) (*controlv1.Run, error) {
+	run, err := c.s.enqueue(ServeRunRequest{Task: req.GetTask(), Profile: req.GetProfile(), Params: req.GetParams()})
+	if err != nil {
+		code := codes.Internal
+		switch {
+		case errors.Is(err, errServerStopping):
+			code = codes.Unavailable
+		case ExitCode(err) == ExitUsage:
+			code = codes.InvalidArgument
+		}
+		return nil, status.Error(code, err.Error())
+	}
+	return activeRunProto(run), nil
+}
+
+func (c *controlService) GetStatus(_ context.Context, req *controlv1.GetStatusRequest) (*controlv1.GetStatusResponse, error) {
+	st := c.s.status()
+	resp := &controlv1.GetStatusResponse{Addr: st.Addr, Started: timestamppb.New(st.Started)}
+	for _, run := range st.Active {
+		resp.Active = append(resp.Active, activeRunProto(run))
+	}
+	if st.LastRun != nil {
+		resp.LastRun = historyRunProto(*st.LastRun)
+	}
+	if id := req.GetRunId(); id != "" {
+		active, entry, err := c.s.lookup(id)
+		switch {
+		case err != nil:
+			return nil, status.Error(codes.Internal, err.Error())
+		case active != nil:
+			resp.Run = activeRunProto(*active)
+		case entry != nil:
+			resp.Run = historyRunProto(*entry)
+		default:
+			return nil, status.Errorf(codes.NotFound, "no run with id %q", id)
+		}
+	}
+	return resp, nil
+}
+
+func (c *controlService) StreamLogs(req *controlv1.StreamLogsRequest, stream controlv1.Control_StreamLogsServer) error {
+	id := req.GetRunId()
+	if id == "" {
+		return status.Error(codes.InvalidArgument, "run_id is required")
+	}
+	active, entry, err := c.s.lookup(id)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	if active == nil && entry == nil {
+		return status.Errorf(codes.NotFound, "no run with id %q", id)
+	}
+
+	logs := newTaskLogs(c.s.ctx, id)
+	offsets := map[string]int64{}
+	for {
+		// Check before reading so the last pass sees everything the run
+		// wrote.
+		running := req.GetFollow() && c.s.active(id)
+		if err := sendNewLogLines(stream, logs, req.GetTask(), offsets); err != nil {
+			return err
+		}
+		if !running {
+			return nil
+		}
+		select {
+		case <-stream.Context().Done():
+			return status.FromContextError(stream.Context().Err()).Err()
+		case <-c.s.ctx.Done():
+			return status.Error(codes.Unavailable, errServerStopping.Error())
+		case <-time.After(grpcLogPoll):
+		}
+	}
+}
+
+// active reports whether the run is queued or running.
+func (s *server) active(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, ok := s.runs[id]
+	return ok
+}
+
+// sendNewLogLines sends the complete lines appended to the run's task
+// logs since offsets, which it advances. A non-empty task limits it to
+// that task's file.
+func sendNewLogLines(stream controlv1.Control_StreamLogsServer, logs *taskLogs, task string, offsets map[string]int64) error {
+	var paths []string
+	if task != "" {
+		paths = []string{logs.path(task)}
+	} else {
+		paths, _ = filepath.Glob(filepath.Join(logs.dir, "*.log"))
+		sort.Strings(paths)
+	}
+	for _, path := range paths {
+		name := task
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(path), ".log")
+		}
+		f, err := os.Open(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		if _, err := f.Seek(offsets[path], io.SeekStart); err != nil {
+			f.Close()
+			return status.Error(codes.Internal, err.Error())
+		}
+		r := bufio.NewReader(f)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				// A partial line is sent once it is complete.
+				break
+			}
+			offsets[path] += int64(len(line))
+			if err := stream.Send(&controlv1.LogLine{Task: name, Line: strings.TrimRight(line, "\r\n")}); err != nil {
+				f.Close()
+				return err
+			}
+		}
+		f.Close()
+	}
+	return nil
+}
+
+func (c *controlService) GetConfig(context.Context, *controlv1.GetConfigRequest) (*controlv1.GetConfigResponse, error) {
+	cfg := c.s.config()
+	return &controlv1.GetConfigResponse{Profile: cfg.Profile, Settings: cfg.Settings}, nil
+}
+
+func activeRunProto(run ServeRun) *controlv1.Run {
+	out := &controlv1.Run{
+		Id:      run.ID,
+		Task:    run.Task,
+		Profile: run.Profile,
+		Status:  run.Status,
+		Queued:  timestamppb.New(run.Queued),
+	}
+	if run.Started != nil {
+		out.Started = timestamppb.New(*run.Started)
+	}
+	return out
+}
+
+func historyRunProto(entry HistoryEntry) *controlv1.Run {
+	return &controlv1.Run{
+		Id:         entry.ID,
+		Task:       entry.Task,
+		Profile:    entry.Profile,
+		Status:     entry.Status,
+		Started:    timestamppb.New(entry.Started),
+		DurationMs: entry.DurationMS,
+		ExitCode:   int32(entry.ExitCode),
+		Error:      entry.Error,
+	}
+}
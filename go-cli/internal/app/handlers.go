@@ -5,85 +5,31 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
 
 	yaml "gopkg.in/yaml.v3"
-)
 
-// RunOptions configure the run command behaviour.
-type RunOptions struct {
-	Task    string
-	Profile string
-}
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/buildinfo"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/textdiff"
+)
 
 // InitOptions configure the init command behaviour.
 type InitOptions struct {
 	Force bool
 }
 
-// HandleRun executes the run command.
-func HandleRun(ctx *RuntimeContext, opts RunOptions) error {
-	effective := ctx.Config.WithProfileOverride(opts.Profile)
-	runCfg := effective.RunConfig()
-
-	if ctx.Common.Parallelism != nil {
-		value := *ctx.Common.Parallelism
-		runCfg.Runtime.Parallelism = &value
-	}
-
-	if runCfg.Runtime.Parallelism == nil {
-		value := defaultParallelism()
-		runCfg.Runtime.Parallelism = &value
-	}
-
-	if ctx.Common.TimeoutSeconds != nil {
-		value := *ctx.Common.TimeoutSeconds
-		runCfg.Runtime.TimeoutSeconds = &value
-	}
-
-	parallelism := 0
-	if runCfg.Runtime.Parallelism != nil {
-		parallelism = *runCfg.Runtime.Parallelism
-	}
-
-	timeout := 0
-	if runCfg.Runtime.TimeoutSeconds != nil {
-		timeout = *runCfg.Runtime.TimeoutSeconds
-	}
-
-	ctx.Logger.Info("running task %s with profile %s", opts.Task, runCfg.Profile)
-
-	result := map[string]any{
-		"task":        opts.Task,
-		"profile":     runCfg.Profile,
-		"parallelism": parallelism,
-		"timeout":     timeout,
-	}
-
-	switch {
-	case ctx.Common.JSON:
-		data, err := json.MarshalIndent(result, "", "  ")
+// HandleInit creates the config if necessary.
+func HandleInit(ctx *RuntimeContext, opts InitOptions) error {
+	path := ctx.Paths.ConfigFile
+	if _, err := os.Stat(path); err == nil && !opts.Force {
+		ok, err := confirm(ctx, fmt.Sprintf("Config %s exists. Overwrite it with the defaults?", path), "--force")
 		if err != nil {
 			return err
 		}
-		fmt.Println(string(data))
-	case ctx.Common.YAML:
-		data, err := yaml.Marshal(result)
-		if err != nil {
-			return err
+		if !ok {
+			return errors.New(ctx.Printer.Sprintf(msgConfigExists, path))
 		}
-		fmt.Print(string(data))
-	default:
-		fmt.Printf("Running task %q with profile %q (parallelism: %d, timeout: %ds)\n", opts.Task, runCfg.Profile, parallelism, timeout)
-	}
-
-	return nil
-}
-
-// HandleInit creates the config if necessary.
-func HandleInit(ctx *RuntimeContext, opts InitOptions) error {
-	path := ctx.Paths.ConfigFile
-	if _, err := os.Stat(path); err == nil && !(opts.Force || ctx.Common.AssumeYes) {
-		return fmt.Errorf("config already exists at %s (use --force to overwrite)", path)
 	} else if err != nil && !errors.Is(err, os.ErrNotExist) {
 		return err
 	}
@@ -98,6 +44,9 @@ func HandleInit(ctx *RuntimeContext, opts InitOptions) error {
 	}
 
 	ctx.Logger.Info("wrote default config to %s", path)
+	if ctx.Common.Porcelain {
+		ctx.Out.Println(path)
+	}
 	return nil
 }
 
@@ -109,22 +58,81 @@ func HandleConfigShow(ctx *RuntimeContext) error {
 		if err != nil {
 			return err
 		}
-		fmt.Println(string(data))
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
 	case ctx.Common.YAML:
 		data, err := yaml.Marshal(ctx.Config)
 		if err != nil {
 			return err
 		}
-		fmt.Print(string(data))
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		for _, row := range flattenConfig(ctx.Config) {
+			fmt.Fprintf(ctx.Out.Writer(), "%s=%s\n", row.Key, row.Value)
+		}
+	default:
+		ctx.Out.KeyValues("", flattenConfig(ctx.Config))
+	}
+	return nil
+}
+
+// HandleConfigGet prints the effective value of key, or of every key under
+// it when key names a section such as runtime.retry. JSON and YAML keep the
+// value's type.
+func HandleConfigGet(ctx *RuntimeContext, key string) error {
+	var rows []KeyValue
+	var known []string
+	for _, row := range flattenConfig(ctx.Config) {
+		if row.Key == key || strings.HasPrefix(row.Key, key+".") {
+			rows = append(rows, row)
+		}
+		// Tables can be asked for too.
+		for i := range row.Key {
+			if row.Key[i] == '.' && !slices.Contains(known, row.Key[:i]) {
+				known = append(known, row.Key[:i])
+			}
+		}
+		known = append(known, row.Key)
+	}
+	if len(rows) == 0 {
+		if hint := didYouMean(suggest(key, known)); hint != "" {
+			return UsageError(fmt.Errorf("unknown config key %s%s", key, hint))
+		}
+		return UsageError(fmt.Errorf("unknown config key %s (see `%s config show`)", key, appName))
+	}
+
+	switch {
+	case ctx.Common.JSON || ctx.Common.YAML:
+		value, err := configValue(ctx.Config, key)
+		if err != nil {
+			return err
+		}
+		var data []byte
+		if ctx.Common.JSON {
+			data, err = json.MarshalIndent(value, "", "  ")
+			data = append(data, '\n')
+		} else {
+			data, err = yaml.Marshal(value)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case len(rows) == 1 && rows[0].Key == key:
+		ctx.Out.Println(rows[0].Value)
+	case ctx.Common.Porcelain:
+		for _, row := range rows {
+			fmt.Fprintf(ctx.Out.Writer(), "%s=%s\n", row.Key, row.Value)
+		}
 	default:
-		fmt.Printf("%+v\n", ctx.Config)
+		ctx.Out.KeyValues("", rows)
 	}
 	return nil
 }
 
 // HandleConfigPath prints the config path.
 func HandleConfigPath(ctx *RuntimeContext) error {
-	fmt.Println(ctx.Paths.ConfigFile)
+	ctx.Out.Println(ctx.Paths.ConfigFile)
+	ctx.CopyPrimary("config path", ctx.Paths.ConfigFile)
 	return nil
 }
 
@@ -132,24 +140,70 @@ func HandleConfigPath(ctx *RuntimeContext) error {
 func HandleConfigReset(ctx *RuntimeContext) error {
 	if ctx.Common.DryRun {
 		ctx.Logger.Info("dry-run: would reset config at %s", ctx.Paths.ConfigFile)
+		current, err := readConfigFile(ctx.Paths.ConfigFile)
+		if err != nil {
+			return err
+		}
+		ctx.Out.Diff(textdiff.Unified(ctx.Paths.ConfigFile, "default", current, defaultConfigContents(ctx.Paths.ConfigFile), textdiff.DefaultContext))
 		return nil
 	}
 
+	current, err := readConfigFile(ctx.Paths.ConfigFile)
+	if err != nil {
+		return err
+	}
+	if current != "" && current != defaultConfigContents(ctx.Paths.ConfigFile) {
+		err := confirmDestructive(ctx, Destructive{
+			Action:   "config reset",
+			Resource: ctx.Paths.ConfigFile,
+			Question: fmt.Sprintf("Reset %s to the defaults? Your changes to it are lost (see `config diff`).", ctx.Paths.ConfigFile),
+		})
+		if err != nil {
+			return err
+		}
+	}
 	if err := writeDefaultConfig(ctx.Paths.ConfigFile); err != nil {
 		return err
 	}
 
 	ctx.Logger.Info("reset config at %s", ctx.Paths.ConfigFile)
+	if ctx.Common.Porcelain {
+		ctx.Out.Println(ctx.Paths.ConfigFile)
+	}
 	return nil
 }
 
-// HandleConfigPaths prints all resolved paths.
-func HandleConfigPaths(ctx *RuntimeContext) error {
-	cacheDir, err := defaultCacheDir(appName)
+// HandleConfigDiff shows how the config file differs from the defaults.
+func HandleConfigDiff(ctx *RuntimeContext) error {
+	current, err := readConfigFile(ctx.Paths.ConfigFile)
 	if err != nil {
 		return err
 	}
 
+	diff := textdiff.Unified("default", ctx.Paths.ConfigFile, defaultConfigContents(ctx.Paths.ConfigFile), current, textdiff.DefaultContext)
+	if diff == "" {
+		ctx.Logger.Info("config at %s matches the defaults", ctx.Paths.ConfigFile)
+		return nil
+	}
+	ctx.Out.Diff(diff)
+	return nil
+}
+
+// readConfigFile returns the config file contents, or "" if it does not exist yet.
+func readConfigFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("read config: %w", err)
+	}
+	return string(data), nil
+}
+
+// HandleConfigPaths prints all resolved paths.
+func HandleConfigPaths(ctx *RuntimeContext) error {
+	cacheDir := ctx.Paths.CacheDir
 	paths := map[string]string{
 		"config": ctx.Paths.ConfigFile,
 		"data":   ctx.Paths.DataDir,
@@ -163,24 +217,73 @@ func HandleConfigPaths(ctx *RuntimeContext) error {
 		if err != nil {
 			return err
 		}
-		fmt.Println(string(data))
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
 	case ctx.Common.YAML:
 		data, err := yaml.Marshal(paths)
 		if err != nil {
 			return err
 		}
-		fmt.Print(string(data))
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		fmt.Fprintf(ctx.Out.Writer(), "config\t%s\ndata\t%s\nstate\t%s\ncache\t%s\n", ctx.Paths.ConfigFile, ctx.Paths.DataDir, ctx.Paths.StateDir, cacheDir)
 	default:
-		fmt.Printf("config: %s\n", ctx.Paths.ConfigFile)
-		fmt.Printf("data:   %s\n", ctx.Paths.DataDir)
-		fmt.Printf("state:  %s\n", ctx.Paths.StateDir)
-		fmt.Printf("cache:  %s\n", cacheDir)
+		ctx.Out.KeyValues("", []KeyValue{
+			{Key: ctx.Out.Text(msgLabelConfig), Value: ctx.Paths.ConfigFile},
+			{Key: ctx.Out.Text(msgLabelData), Value: ctx.Paths.DataDir},
+			{Key: ctx.Out.Text(msgLabelState), Value: ctx.Paths.StateDir},
+			{Key: ctx.Out.Text(msgLabelCache), Value: cacheDir},
+		})
 	}
 	return nil
 }
 
 // HandleConfigSchema prints the JSON schema for the config file.
-func HandleConfigSchema(_ *RuntimeContext) error {
-	fmt.Print(configSchemaJSON)
+func HandleConfigSchema(ctx *RuntimeContext) error {
+	fmt.Fprint(ctx.Out.Writer(), configSchemaJSON)
+	return nil
+}
+
+// HandleVersion prints the build metadata of the running binary.
+func HandleVersion(ctx *RuntimeContext, info buildinfo.Info) error {
+	rows := []KeyValue{
+		{Key: msgLabelVersion, Value: info.Version},
+		{Key: msgLabelCommit, Value: info.Commit},
+		{Key: msgLabelDate, Value: info.Date},
+		{Key: "go", Value: info.GoVersion},
+		{Key: msgLabelPlatform, Value: info.Platform},
+	}
+
+	switch {
+	case ctx.Common.JSON:
+		data, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+	case ctx.Common.YAML:
+		data, err := yaml.Marshal(info)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		for _, row := range rows {
+			fmt.Fprintf(ctx.Out.Writer(), "%s\t%s\n", row.Key, row.Value)
+		}
+		fmt.Fprintf(ctx.Out.Writer(), "modified\t%t\n", info.Modified)
+	default:
+		if info.Modified {
+			rows[1].Value += ctx.Out.Dim(ctx.Out.Text(msgModifiedNote))
+		}
+		for i := range rows {
+			// The keys double as porcelain field names; only the text is
+			// translated.
+			rows[i].Key = ctx.Out.Text(rows[i].Key)
+			if rows[i].Value == "" {
+				rows[i].Value = ctx.Out.Dim(ctx.Out.Text(msgUnknown))
+			}
+		}
+		ctx.Out.KeyValues("", rows)
+	}
 	return nil
 }
@@ -0,0 +1,298 @@
+package app
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/gitinfo"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/humanize"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/statestore"
+)
+
+// Run statuses recorded in history.
+const (
+	HistorySucceeded   = "succeeded"
+	HistoryFailed      = "failed"
+	HistoryTimedOut    = "timed_out"
+	HistoryInterrupted = "interrupted"
+)
+
+// HistoryEntry records one `run` invocation.
+type HistoryEntry struct {
+	ID         string    `json:"id" yaml:"id"`
+	Task       string    `json:"task" yaml:"task"`
+	Profile    string    `json:"profile" yaml:"profile"`
+	Started    time.Time `json:"started" yaml:"started"`
+	DurationMS int64     `json:"duration_ms" yaml:"duration_ms"`
+	Status     string    `json:"status" yaml:"status"`
+	ExitCode   int       `json:"exit_code" yaml:"exit_code"`
+	Error      string    `json:"error,omitempty" yaml:"error,omitempty"`
+	Flags      []string  `json:"flags,omitempty" yaml:"flags,omitempty"`
+	// Metrics are kept for trend analysis; see RunMetrics.
+	Metrics *RunMetrics `json:"metrics,omitempty" yaml:"metrics,omitempty"`
+	// Git describes the checkout the run started in, if any.
+	Git *gitinfo.Info `json:"git,omitempty" yaml:"git,omitempty"`
+}
+
+// HistoryListOptions filter `history list`.
+type HistoryListOptions struct {
+	Task  string
+	Limit int
+	// Where holds conditions such as status=failed that entries must all
+	// satisfy; see statestore.ParseCondition.
+	Where []string
+}
+
+// recordHistory appends the outcome of a run. Failing to record is logged,
+// never fatal: history must not turn a successful run into a failed one.
+// It returns the entry for the run notification.
+func recordHistory(ctx *RuntimeContext, opts RunOptions, runID string, started time.Time, metrics *RunMetrics, runErr error) HistoryEntry {
+	entry := HistoryEntry{
+		ID:         runID,
+		Task:       opts.Task,
+		Profile:    ctx.Config.Profile,
+		Started:    started.UTC(),
+		DurationMS: ctx.Clock.Since(started).Milliseconds(),
+		Status:     runStatus(ctx, runErr),
+		ExitCode:   ExitCode(runErr),
+		Flags:      opts.Flags,
+		Metrics:    metrics,
+		Git:        ctx.Git,
+	}
+	if runErr != nil {
+		entry.Error = runErr.Error()
+	}
+
+	store, err := ctx.stateStore()
+	if err == nil {
+		err = addHistory(ctx, store, entry)
+	}
+	if err != nil {
+		ctx.Logger.Warn("could not record run history: %v", err)
+	}
+	return entry
+}
+
+// runStatus classifies the outcome of a run.
+func runStatus(ctx *RuntimeContext, err error) string {
+	switch {
+	case err == nil:
+		return HistorySucceeded
+	case ExitCode(err) == ExitTimeout:
+		return HistoryTimedOut
+	case ctx.Err() != nil:
+		return HistoryInterrupted
+	default:
+		return HistoryFailed
+	}
+}
+
+// addHistory records entry in store.
+func addHistory(ctx context.Context, store statestore.Store, entry HistoryEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	run := historyRun(entry)
+	run.Data = data
+	return store.AddRun(ctx, run)
+}
+
+// historyRun is the statestore form of entry, without its JSON.
+func historyRun(entry HistoryEntry) statestore.Run {
+	return statestore.Run{
+		ID:         entry.ID,
+		Task:       entry.Task,
+		Profile:    entry.Profile,
+		Status:     entry.Status,
+		Started:    entry.Started,
+		DurationMS: entry.DurationMS,
+		ExitCode:   entry.ExitCode,
+	}
+}
+
+// loadHistory returns the recorded runs matching q, oldest first. Entries
+// that no longer decode are skipped.
+func loadHistory(ctx *RuntimeContext, q statestore.Query) ([]HistoryEntry, error) {
+	store, err := ctx.stateStore()
+	if err != nil {
+		return nil, err
+	}
+	runs, err := store.Runs(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+	entries := make([]HistoryEntry, 0, len(runs))
+	for _, r := range runs {
+		var entry HistoryEntry
+		if json.Unmarshal(r.Data, &entry) == nil && entry.ID != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// readHistoryFile reads the JSON Lines history of earlier versions, oldest
+// first. Malformed lines (e.g. from a crash mid-write) are skipped.
+func readHistoryFile(path string) ([]HistoryEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+	defer f.Close()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil && entry.ID != "" {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read history: %w", err)
+	}
+	return entries, nil
+}
+
+// HandleHistoryList prints recent runs, newest first.
+func HandleHistoryList(ctx *RuntimeContext, opts HistoryListOptions) error {
+	where, err := parseConditions(opts.Where)
+	if err != nil {
+		return err
+	}
+	all, err := loadAllHistory(ctx, where)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]HistoryEntry, 0, len(all))
+	for i := len(all) - 1; i >= 0; i-- {
+		if opts.Task != "" && all[i].Task != opts.Task {
+			continue
+		}
+		entries = append(entries, all[i])
+		if opts.Limit > 0 && len(entries) == opts.Limit {
+			break
+		}
+	}
+
+	switch {
+	case ctx.Common.JSON:
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+	case ctx.Common.YAML:
+		data, err := yaml.Marshal(entries)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		for _, e := range entries {
+			fmt.Fprintf(ctx.Out.Writer(), "%s\t%s\t%s\t%s\t%d\n", e.ID, e.Task, e.Profile, e.Status, e.ExitCode)
+		}
+	default:
+		if len(entries) == 0 && len(opts.Where) > 0 {
+			ctx.Logger.Info("no recorded runs match")
+			return nil
+		}
+		if len(entries) == 0 {
+			ctx.Logger.Info("no runs recorded yet")
+			return nil
+		}
+		now := ctx.Clock.Now()
+		rows := make([][]string, 0, len(entries))
+		for _, e := range entries {
+			rows = append(rows, []string{
+				e.ID,
+				humanize.RelTime(e.Started, now),
+				e.Task,
+				e.Profile,
+				historyStatus(ctx, e.Status),
+				humanize.Duration(time.Duration(e.DurationMS) * time.Millisecond),
+			})
+		}
+		ctx.Out.Table("", headers(ctx.Out, msgColID, msgColStarted, msgColTask, msgColProfile, msgColStatus, msgColDuration), rows)
+	}
+	return nil
+}
+
+// HandleHistoryShow prints one run. "last" selects the most recent run.
+func HandleHistoryShow(ctx *RuntimeContext, id string) error {
+	entries, err := loadAllHistory(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	var entry *HistoryEntry
+	for i := len(entries) - 1; i >= 0; i-- {
+		if id == "last" || entries[i].ID == id {
+			entry = &entries[i]
+			break
+		}
+	}
+	if entry == nil {
+		return fmt.Errorf("no run with id %q in history", id)
+	}
+
+	switch {
+	case ctx.Common.JSON:
+		data, err := json.MarshalIndent(entry, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+	case ctx.Common.YAML:
+		data, err := yaml.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		fmt.Fprintf(ctx.Out.Writer(), "%s\t%s\t%s\t%s\t%d\n", entry.ID, entry.Task, entry.Profile, entry.Status, entry.ExitCode)
+	default:
+		out := ctx.Out
+		rows := []KeyValue{
+			{Key: out.Text(msgLabelID), Value: entry.ID},
+			{Key: out.Text(msgTask), Value: entry.Task},
+			{Key: out.Text(msgLabelProfile), Value: entry.Profile},
+			{Key: out.Text(msgLabelStarted), Value: entry.Started.Local().Format(time.RFC3339) + out.Dim(" ("+humanize.RelTime(entry.Started, ctx.Clock.Now())+")")},
+			{Key: out.Text(msgLabelDuration), Value: humanize.Duration(time.Duration(entry.DurationMS) * time.Millisecond)},
+			{Key: out.Text(msgLabelStatus), Value: historyStatus(ctx, entry.Status)},
+			{Key: out.Text(msgLabelExitCode), Value: fmt.Sprint(entry.ExitCode)},
+		}
+		if entry.Git != nil {
+			rows = append(rows, KeyValue{Key: "git", Value: entry.Git.Short() + out.Dim(out.Sprintf(msgGitIn, entry.Git.Root))})
+		}
+		if len(entry.Flags) > 0 {
+			rows = append(rows, KeyValue{Key: out.Text(msgLabelFlags), Value: strings.Join(entry.Flags, " ")})
+		}
+		if entry.Error != "" {
+			rows = append(rows, KeyValue{Key: out.Text(msgLabelError), Value: strings.ReplaceAll(entry.Error, "\n", "; ")})
+		}
+		ctx.Out.KeyValues("", rows)
+	}
+	return nil
+}
+
+func historyStatus(ctx *RuntimeContext, status string) string {
+	switch status {
+	case HistorySucceeded:
+		return ctx.Out.Green(ctx.Glyphs.Success + " " + status)
+	case HistoryFailed, HistoryTimedOut:
+		return ctx.Out.Red(ctx.Glyphs.Failure + " " + status)
+	default:
+		return ctx.Out.Yellow(ctx.Glyphs.Warning + " " + status)
+	}
+}
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/execx"
+)
+
+// Hook failure policies.
+const (
+	HookAbort  = "abort"
+	HookWarn   = "warn"
+	HookFail   = "fail"
+	HookIgnore = "ignore"
+)
+
+func validateHooks(cfg HooksConfig) error {
+	switch cfg.PreRunFailure {
+	case HookAbort, HookWarn, HookIgnore:
+	default:
+		return enumError("hooks.pre_run_failure", cfg.PreRunFailure, HookAbort, HookWarn, HookIgnore)
+	}
+	switch cfg.PostRunFailure {
+	case HookFail, HookWarn, HookIgnore:
+	default:
+		return enumError("hooks.post_run_failure", cfg.PostRunFailure, HookFail, HookWarn, HookIgnore)
+	}
+	return nil
+}
+
+// hookEnv describes the run to hook commands.
+type hookEnv struct {
+	RunID   string
+	Task    string
+	Profile string
+	// Status and ExitCode are only set for post-run hooks.
+	Status   string
+	ExitCode *int
+}
+
+// environ returns the variables added to the hook's inherited environment.
+func (e hookEnv) environ() []string {
+	prefix := EnvPrefix() + "_"
+	env := []string{
+		prefix + "RUN_ID=" + e.RunID,
+		prefix + "TASK=" + e.Task,
+		prefix + "PROFILE=" + e.Profile,
+	}
+	if e.ExitCode != nil {
+		env = append(env,
+			prefix+"RUN_STATUS="+e.Status,
+			prefix+"EXIT_STATUS="+strconv.Itoa(*e.ExitCode),
+		)
+	}
+	return env
+}
+
+// runPreHooks runs hooks.pre_run. The returned error, if any, should abort
+// the run.
+func runPreHooks(ctx *RuntimeContext, env hookEnv) error {
+	cfg := ctx.Config.Hooks
+	err := runHooks(ctx, ctx, "pre_run", cfg.PreRun, env)
+	if err == nil {
+		return nil
+	}
+	switch cfg.PreRunFailure {
+	case HookAbort:
+		return fmt.Errorf("pre_run hook failed: %w", err)
+	case HookWarn:
+		ctx.Logger.Warn("pre_run hook failed: %v", err)
+	}
+	return nil
+}
+
+// runPostHooks runs hooks.post_run. They run even after Ctrl+C so
+// notifications still fire; only the hook timeout bounds them. The returned
+// error, if any, should fail the run.
+func runPostHooks(ctx *RuntimeContext, env hookEnv) error {
+	cfg := ctx.Config.Hooks
+	err := runHooks(ctx, context.WithoutCancel(ctx), "post_run", cfg.PostRun, env)
+	if err == nil {
+		return nil
+	}
+	switch cfg.PostRunFailure {
+	case HookFail:
+		return fmt.Errorf("post_run hook failed: %w", err)
+	case HookWarn:
+		ctx.Logger.Warn("post_run hook failed: %v", err)
+	}
+	return nil
+}
+
+// runHooks runs commands in order through the platform shell and stops at
+// the first failure.
+func runHooks(rtx *RuntimeContext, ctx context.Context, stage string, commands []string, env hookEnv) error {
+	for _, line := range commands {
+		cmd := rtx.Command(line)
+		cmd.Env = env.environ()
+		cmd.Timeout = rtx.Config.Hooks.Timeout.Std()
+		cmd.OnLine = func(text string) { rtx.Logger.Info("%s | %s", stage, text) }
+		if _, err := execx.Run(ctx, cmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
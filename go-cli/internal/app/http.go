@@ -0,0 +1,54 @@
+package app
+
+import (
+	"net/http"
+	"sync"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/buildinfo"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/httpx"
+)
+
+// sharedTransport builds the [http] client once per process, so commands
+// and tasks reuse its connections.
+type sharedTransport struct {
+	once   sync.Once
+	client *http.Client
+	err    error
+}
+
+// httpOptions translates [http] for httpx.
+func (rtx *RuntimeContext) httpOptions() httpx.Options {
+	build := buildinfo.Get()
+	return httpx.Options{
+		MaxRetries:         rtx.Config.HTTP.MaxRetries,
+		Proxy:              rtx.Config.HTTP.Proxy,
+		NoProxy:            rtx.Config.HTTP.NoProxy,
+		CABundle:           rtx.Config.HTTP.CABundle,
+		InsecureSkipVerify: rtx.Config.HTTP.InsecureSkipVerify,
+		UserAgent:          appName + "/" + build.Version + " (" + build.Platform + ")",
+		Clock:              rtx.Clock,
+	}
+}
+
+// HTTPClient returns the client for outbound requests: [http] proxy,
+// CA bundle, and retries, a User-Agent naming the build, and the
+// context's Timeout per request. Commands and tasks should use it
+// rather than http.DefaultClient.
+func (rtx *RuntimeContext) HTTPClient() (*http.Client, error) {
+	shared := rtx.http
+	if shared == nil {
+		shared = &sharedTransport{}
+	}
+	shared.once.Do(func() {
+		if rtx.Config.HTTP.InsecureSkipVerify {
+			rtx.Logger.Warn("http.insecure_skip_verify is set: TLS certificates are not verified")
+		}
+		shared.client, shared.err = httpx.New(rtx.httpOptions())
+	})
+	if shared.err != nil {
+		return nil, shared.err
+	}
+	client := *shared.client
+	client.Timeout = rtx.Timeout
+	return &client, nil
+}
@@ -0,0 +1,123 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/buildinfo"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/gitinfo"
+)
+
+// InfoReport is what `info` prints: the build, where files live, and the
+// settings that shape a run.
+type InfoReport struct {
+	Build       buildinfo.Info `json:"build" yaml:"build"`
+	Paths       InfoPaths      `json:"paths" yaml:"paths"`
+	Profile     string         `json:"profile" yaml:"profile"`
+	LogLevel    string         `json:"log_level" yaml:"log_level"`
+	Parallelism Parallelism    `json:"parallelism" yaml:"parallelism"`
+	// Git describes the repository of the working directory, if any.
+	Git *gitinfo.Info `json:"git,omitempty" yaml:"git,omitempty"`
+}
+
+// InfoPaths are the resolved config file and directories.
+type InfoPaths struct {
+	Config string `json:"config" yaml:"config"`
+	Data   string `json:"data" yaml:"data"`
+	State  string `json:"state" yaml:"state"`
+	Cache  string `json:"cache" yaml:"cache"`
+}
+
+// effectiveParallelism is the setting a run uses, --parallel else
+// runtime.parallelism, with Workers set to the worker count it starts with.
+func effectiveParallelism(ctx *RuntimeContext) Parallelism {
+	configured := ctx.Config.Runtime.Parallelism
+	if ctx.Common.Parallelism != nil {
+		configured = ctx.Common.Parallelism
+	}
+	if configured == nil {
+		return Parallelism{Workers: defaultParallelism()}
+	}
+	p := *configured
+	p.Workers = p.Start(defaultParallelism())
+	return p
+}
+
+// HandleInfo prints version, paths, active profile, log level, and
+// parallelism in one block, for support triage and for scripts that need
+// to find where data lives.
+func HandleInfo(ctx *RuntimeContext, build buildinfo.Info) error {
+	report := InfoReport{
+		Build: build,
+		Paths: InfoPaths{
+			Config: ctx.Paths.ConfigFile,
+			Data:   ctx.Paths.DataDir,
+			State:  ctx.Paths.StateDir,
+			Cache:  ctx.Paths.CacheDir,
+		},
+		Profile:     ctx.Config.Profile,
+		LogLevel:    levelName(ctx.LogSettings.Level),
+		Parallelism: effectiveParallelism(ctx),
+		Git:         detectGit(ctx),
+	}
+
+	rows := []KeyValue{
+		{Key: msgLabelVersion, Value: build.Version},
+		{Key: msgLabelPlatform, Value: build.Platform},
+		{Key: msgLabelConfig, Value: report.Paths.Config},
+		{Key: msgLabelData, Value: report.Paths.Data},
+		{Key: msgLabelState, Value: report.Paths.State},
+		{Key: msgLabelCache, Value: report.Paths.Cache},
+		{Key: msgLabelProfile, Value: report.Profile},
+		{Key: msgLabelLogLevel, Value: report.LogLevel},
+		{Key: msgLabelParallelism, Value: report.Parallelism.String()},
+	}
+	parallelismRow := len(rows) - 1
+	if report.Git != nil {
+		rows = append(rows, KeyValue{Key: "git", Value: report.Git.Short()})
+	}
+
+	switch {
+	case ctx.Common.JSON:
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+	case ctx.Common.YAML:
+		data, err := yaml.Marshal(report)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		for _, row := range rows {
+			fmt.Fprintf(ctx.Out.Writer(), "%s\t%s\n", strings.ReplaceAll(row.Key, " ", "_"), row.Value)
+		}
+	default:
+		if build.Commit != "" {
+			commit := build.Commit
+			if build.Modified {
+				commit += ctx.Out.Text(msgModified)
+			}
+			rows[0].Value += ctx.Out.Dim(" (" + commit + ")")
+		}
+		switch {
+		case report.Parallelism.Auto:
+			rows[parallelismRow].Value += ctx.Out.Dim(ctx.Out.Sprintf(msgUpToWorkers, report.Parallelism.Workers))
+		case report.Parallelism.Percent > 0:
+			rows[parallelismRow].Value += ctx.Out.Dim(ctx.Out.Sprintf(msgWorkers, report.Parallelism.Workers))
+		}
+		rows[1].Value += ctx.Out.Dim(", " + build.GoVersion)
+		// The keys double as porcelain field names; only the text is
+		// translated.
+		for i := range rows {
+			rows[i].Key = ctx.Out.Text(rows[i].Key)
+		}
+		ctx.Out.KeyValues("", rows)
+	}
+	return nil
+}
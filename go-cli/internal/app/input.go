@@ -0,0 +1,37 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/stdinutil"
+)
+
+// ReadInput reads the payload piped to a run whose tasks expect input in
+// format want (see TaskSpec.Input), bounded by runtime.max_input. It
+// returns nil without reading when no input is wanted or stdin is a
+// terminal, so such tasks still run interactively.
+func ReadInput(ctx *RuntimeContext, stdin io.Reader, want string) (*stdinutil.Payload, error) {
+	if want == "" {
+		return nil, nil
+	}
+	if f, ok := stdin.(*os.File); ok && !stdinutil.Piped(f) {
+		return nil, nil
+	}
+	payload, err := stdinutil.Read(stdin, ctx.Config.Runtime.MaxInput, stdinutil.Format(want))
+	if err != nil {
+		return nil, UsageError(fmt.Errorf("read input from stdin: %w", err))
+	}
+	ctx.Logger.Debug("read %d bytes of %s input from stdin", len(payload.Data), payload.Format)
+	return payload, nil
+}
+
+// InputEnviron returns GO_CLI_INPUT_FORMAT=<format> for the payload of the
+// run in progress, or nothing without one.
+func (rtx *RuntimeContext) InputEnviron() []string {
+	if rtx.Input == nil {
+		return nil
+	}
+	return []string{EnvPrefix() + "_INPUT_FORMAT=" + string(rtx.Input.Format)}
+}
@@ -0,0 +1,258 @@
+package app
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/runner"
+)
+
+// Fingerprint hashes the task definition, the run parameters, and the
+// content of every file matched by spec.Inputs, so any change to them
+// changes it. Patterns are
+// slash-separated, relative to spec.Dir (or the working directory), and
+// may use ** to match any number of directories.
+func (spec TaskSpec) Fingerprint(params Params) (string, error) {
+	def := spec
+	def.Description = ""
+	data, err := json.Marshal(struct {
+		Spec   TaskSpec `json:"spec"`
+		Params Params   `json:"params,omitempty"`
+	}{def, params})
+	if err != nil {
+		return "", err
+	}
+
+	root := spec.Dir
+	if root == "" {
+		if root, err = os.Getwd(); err != nil {
+			return "", err
+		}
+	}
+	files, err := matchInputs(root, spec.Inputs)
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	h.Write(data)
+	for _, rel := range files {
+		f, err := os.Open(filepath.Join(root, filepath.FromSlash(rel)))
+		if err != nil {
+			return "", fmt.Errorf("hash input %s: %w", rel, err)
+		}
+		fmt.Fprintf(h, "\x00%s\x00", rel)
+		_, err = io.Copy(h, f)
+		f.Close()
+		if err != nil {
+			return "", fmt.Errorf("hash input %s: %w", rel, err)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// matchInputs returns the sorted, slash-separated paths of the regular files
+// under root that match any of patterns.
+func matchInputs(root string, patterns []string) ([]string, error) {
+	split := make([][]string, 0, len(patterns))
+	for _, p := range patterns {
+		split = append(split, strings.Split(path.Clean(p), "/"))
+	}
+
+	seen := map[string]bool{}
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		segs := strings.Split(filepath.ToSlash(rel), "/")
+		if d.IsDir() {
+			if rel == "." {
+				return nil
+			}
+			if d.Name() == ".git" || !anyPrefixMatch(split, segs) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		for _, pattern := range split {
+			if matchGlob(pattern, segs) {
+				seen[strings.Join(segs, "/")] = true
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan inputs: %w", err)
+	}
+	files := make([]string, 0, len(seen))
+	for rel := range seen {
+		files = append(files, rel)
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// matchGlob matches path segments against pattern segments, where a "**"
+// segment matches zero or more path segments.
+func matchGlob(pattern, segs []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(segs); i++ {
+				if matchGlob(pattern[1:], segs[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segs) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], segs[0]); !ok {
+			return false
+		}
+		pattern, segs = pattern[1:], segs[1:]
+	}
+	return len(segs) == 0
+}
+
+// anyPrefixMatch reports whether files below the directory dir could match
+// one of patterns, so the walk can skip directories no pattern reaches.
+func anyPrefixMatch(patterns [][]string, dir []string) bool {
+	for _, pattern := range patterns {
+		if prefixMatch(pattern, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+func prefixMatch(pattern, dir []string) bool {
+	for i, seg := range dir {
+		if i < len(pattern) && pattern[i] == "**" {
+			return true
+		}
+		// The last pattern segment names files, not directories.
+		if i >= len(pattern)-1 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[i], seg); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// inputRecord is the fingerprint of a task's last successful run.
+type inputRecord struct {
+	Fingerprint string    `json:"fingerprint"`
+	RunID       string    `json:"run_id"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func inputsPath(stateDir string) string {
+	return filepath.Join(stateDir, "inputs.json")
+}
+
+// inputStore maps task@profile to the fingerprint of its last successful
+// run. Tasks finish on worker goroutines, so access is guarded.
+type inputStore struct {
+	path    string
+	profile string
+	runID   string
+	mu      sync.Mutex
+	records map[string]inputRecord
+}
+
+// loadInputStore reads the fingerprints recorded by earlier runs. An
+// unreadable file is treated as empty so every task simply runs.
+func loadInputStore(ctx *RuntimeContext, profile, runID string) *inputStore {
+	store := &inputStore{path: inputsPath(ctx.Paths.StateDir), profile: profile, runID: runID, records: map[string]inputRecord{}}
+	data, err := os.ReadFile(store.path)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			ctx.Logger.Warn("could not read task fingerprints: %v", err)
+		}
+		return store
+	}
+	if err := json.Unmarshal(data, &store.records); err != nil {
+		ctx.Logger.Warn("ignoring invalid task fingerprints %s: %v", store.path, err)
+		store.records = map[string]inputRecord{}
+	}
+	return store
+}
+
+func (s *inputStore) key(task string) string {
+	return task + "@" + s.profile
+}
+
+func (s *inputStore) unchanged(task, fingerprint string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.records[s.key(task)].Fingerprint == fingerprint
+}
+
+func (s *inputStore) record(task, fingerprint string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[s.key(task)] = inputRecord{Fingerprint: fingerprint, RunID: s.runID, UpdatedAt: now.UTC()}
+	data, err := json.MarshalIndent(s.records, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, append(data, '\n'), 0o644)
+}
+
+// skipUnchanged wraps a job that has a fingerprint so it returns
+// runner.ErrUpToDate when the fingerprint matches the task's last
+// successful run, and records the new fingerprint when it succeeds. An
+// empty fingerprint disables the check. With force set the job always runs
+// but still records its fingerprint.
+func skipUnchanged(ctx *RuntimeContext, store *inputStore, job runner.Job, force bool) runner.Job {
+	if job.Fingerprint == nil {
+		return job
+	}
+	fingerprint, run := job.Fingerprint, job.Run
+	job.Run = func(jobCtx context.Context) error {
+		sum, err := fingerprint(jobCtx)
+		if err != nil {
+			ctx.Logger.Warn("task %s: cannot check inputs, running it: %v", job.Name, err)
+			return run(jobCtx)
+		}
+		if sum == "" {
+			return run(jobCtx)
+		}
+		if !force && store.unchanged(job.Name, sum) {
+			ctx.Logger.Info("task %s is up to date", job.Name)
+			return runner.ErrUpToDate
+		}
+		if err := run(jobCtx); err != nil {
+			return err
+		}
+		if err := store.record(job.Name, sum, ctx.Clock.Now()); err != nil {
+			ctx.Logger.Warn("could not record inputs of task %s: %v", job.Name, err)
+		}
+		return nil
+	}
+	return job
+}
@@ -0,0 +1,91 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/licenses"
+)
+
+// LicensesOptions configure the licenses command.
+type LicensesOptions struct {
+	// Module limits output to the module with this path, printed with its
+	// full text.
+	Module string
+	// Full prints every license text instead of the inventory table.
+	Full bool
+}
+
+// HandleLicenses prints the third-party license inventory embedded at
+// build time.
+func HandleLicenses(ctx *RuntimeContext, opts LicensesOptions) error {
+	modules, err := licenses.All()
+	if err != nil {
+		return err
+	}
+	if opts.Module != "" {
+		var match []licenses.Module
+		for _, m := range modules {
+			if m.Path == opts.Module {
+				match = append(match, m)
+			}
+		}
+		if len(match) == 0 {
+			return UsageError(fmt.Errorf("no license recorded for module %q", opts.Module))
+		}
+		modules, opts.Full = match, true
+	}
+
+	switch {
+	case ctx.Common.JSON:
+		if !opts.Full {
+			modules = withoutText(modules)
+		}
+		data, err := json.MarshalIndent(modules, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+	case ctx.Common.YAML:
+		if !opts.Full {
+			modules = withoutText(modules)
+		}
+		data, err := yaml.Marshal(modules)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		for _, m := range modules {
+			fmt.Fprintf(ctx.Out.Writer(), "%s\t%s\t%s\n", m.Path, m.Version, m.License)
+		}
+	case opts.Full:
+		for i, m := range modules {
+			if i > 0 {
+				ctx.Out.Println("")
+			}
+			ctx.Out.Println(ctx.Out.Bold(m.Path+" "+m.Version) + ctx.Out.Dim(" ("+m.License+", "+m.File+")"))
+			ctx.Out.Println(strings.TrimRight(m.Text, "\n"))
+		}
+	default:
+		rows := make([][]string, 0, len(modules))
+		for _, m := range modules {
+			rows = append(rows, []string{m.Path, m.Version, m.License})
+		}
+		ctx.Out.Table("", []string{"MODULE", "VERSION", "LICENSE"}, rows)
+	}
+	return nil
+}
+
+// withoutText drops the license texts for the inventory listing.
+func withoutText(modules []licenses.Module) []licenses.Module {
+	out := make([]licenses.Module, len(modules))
+	for i, m := range modules {
+		m.Text = ""
+		out[i] = m
+	}
+	return out
+}
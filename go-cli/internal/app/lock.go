@@ -0,0 +1,66 @@
+package app
+
+import (
+	"errors"
+	"path/filepath"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/lock"
+)
+
+func lockPath(stateDir string) string {
+	return filepath.Join(stateDir, appName+".lock")
+}
+
+// AcquireLock takes the single-instance lock in the state directory so
+// concurrent invocations cannot corrupt checkpoints, history, or other state.
+// If another instance holds it, it fails with "another instance (pid N) is
+// running", or with --wait blocks until the lock is free. --no-lock and
+// --dry-run skip locking. The lock is released by Close.
+func (rtx *RuntimeContext) AcquireLock() error {
+	return rtx.acquireLock(rtx.Common.WaitLock)
+}
+
+func (rtx *RuntimeContext) acquireLock(wait bool) error {
+	if rtx.Common.NoLock || rtx.Common.DryRun || rtx.lock != nil {
+		return nil
+	}
+	path := lockPath(rtx.Paths.StateDir)
+	l, err := lock.Acquire(rtx.Context, path, false)
+	var held *lock.HeldError
+	if errors.As(err, &held) && wait {
+		rtx.Logger.Info("%v; waiting for it to finish", held)
+		l, err = lock.Acquire(rtx.Context, path, true)
+	}
+	if err != nil {
+		return err
+	}
+	rtx.lock = l
+	rtx.Logger.Debug("acquired lock %s", path)
+	return nil
+}
+
+// ReleaseLock releases the single-instance lock, if held.
+func (rtx *RuntimeContext) ReleaseLock() error {
+	err := rtx.lock.Release()
+	rtx.lock = nil
+	return err
+}
+
+// withRunLock runs fn while holding the instance lock, waiting for it if
+// another instance has it. Long-lived loops (the scheduler, the daemon's
+// watcher) lock per run so other invocations can get in between. A lock rtx
+// already holds is reused and kept.
+func (rtx *RuntimeContext) withRunLock(fn func() error) error {
+	if rtx.lock != nil {
+		return fn()
+	}
+	if err := rtx.acquireLock(true); err != nil {
+		return err
+	}
+	defer func() {
+		if err := rtx.ReleaseLock(); err != nil {
+			rtx.Logger.Warn("release lock: %v", err)
+		}
+	}()
+	return fn()
+}
@@ -8,6 +8,8 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/container"
 )
 
 // Level represents logging severity in ascending order.
@@ -44,7 +46,11 @@ type LogSettings struct {
 // Logger is a lightweight structured logger tailored for the template.
 type Logger struct {
 	settings LogSettings
-	mu       sync.Mutex
+	// mu is shared by every copy of the Logger so concurrent writers never
+	// interleave partial lines.
+	mu *sync.Mutex
+	// also receives every record as well; see tee.
+	also *Logger
 }
 
 // ConfigureLogger returns a logger configured with the supplied settings.
@@ -54,6 +60,7 @@ func ConfigureLogger(settings LogSettings) Logger {
 	}
 	return Logger{
 		settings: settings,
+		mu:       &sync.Mutex{},
 	}
 }
 
@@ -86,20 +93,55 @@ func (l Logger) Error(msg string, args ...any) {
 }
 
 func (l Logger) log(level Level, msg string, args ...any) {
+	if l.also != nil {
+		l.also.log(level, msg, args...)
+	}
 	if level > l.settings.Level {
 		return
 	}
 
-	l.mu.Lock()
-	defer l.mu.Unlock()
+	if l.mu != nil {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+	}
 
-	formatted := formatMessage(level, l.settings, msg, args...)
+	// One write per record, so wrapping writers (see withStderr) see whole
+	// lines.
+	formatted := formatMessage(level, l.settings, msg, args...) + "\n"
 	for _, w := range l.settings.Writers {
 		io.WriteString(w, formatted)
-		io.WriteString(w, "\n")
 	}
 }
 
+// withStderr returns a copy of the logger that writes to w instead of
+// os.Stderr, e.g. to keep log lines from tearing a live progress display.
+// Other writers, such as the log file, are unchanged.
+func (l Logger) withStderr(w io.Writer) Logger {
+	writers := make([]io.Writer, len(l.settings.Writers))
+	for i, existing := range l.settings.Writers {
+		if existing == io.Writer(os.Stderr) {
+			existing = w
+		}
+		writers[i] = existing
+	}
+	l.settings.Writers = writers
+	return l
+}
+
+// withWriter returns a copy of the logger that also writes every record
+// to w.
+func (l Logger) withWriter(w io.Writer) Logger {
+	l.settings.Writers = append(append([]io.Writer(nil), l.settings.Writers...), w)
+	return l
+}
+
+// tee returns a copy of the logger that also sends every record to other,
+// which applies its own level and format.
+func (l Logger) tee(other Logger) Logger {
+	l.also = &other
+	return l
+}
+
 func formatMessage(level Level, settings LogSettings, msg string, args ...any) string {
 	body := fmt.Sprintf(msg, args...)
 
@@ -198,12 +240,7 @@ func ResolveLogSettings(flags CommonFlags, cfg AppConfig) (LogSettings, error) {
 		level = LevelError
 	}
 
-	colorPolicy := flags.Color
-	if flags.NoColor {
-		colorPolicy = "never"
-	}
-
-	colorize := shouldColorize(colorPolicy)
+	colorize := shouldColorize(colorPolicy(flags), os.Stderr)
 
 	format := resolveLogFormat(flags.LogFormat, cfg.Logging.Format)
 	if format == FormatJSON {
@@ -265,6 +302,9 @@ func resolveLogFormat(flagValue, configValue string) LogFormat {
 	}
 }
 
+// logLevels are the names parseLevel accepts, quietest first.
+var logLevels = []string{"error", "warn", "info", "debug", "trace"}
+
 func parseLevel(value string) Level {
 	switch strings.ToLower(value) {
 	case "trace":
@@ -280,13 +320,24 @@ func parseLevel(value string) Level {
 	}
 }
 
-func shouldColorize(policy string) bool {
+// colorPolicy folds --no-color into the --color policy.
+func colorPolicy(flags CommonFlags) string {
+	if flags.NoColor {
+		return "never"
+	}
+	return flags.Color
+}
+
+// shouldColorize applies a color policy to the given stream; "auto" colors
+// only when the stream is a terminal outside a container, since container
+// output usually ends up in a log collector even when a TTY is attached.
+func shouldColorize(policy string, f *os.File) bool {
 	switch policy {
 	case "always":
 		return true
 	case "never":
 		return false
 	default:
-		return isTerminal(os.Stderr)
+		return isTerminal(f) && !container.Detect().Detected()
 	}
 }
@@ -0,0 +1,418 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/humanize"
+)
+
+// User-facing message keys. The English text doubles as the key so an
+// untranslated message still renders sensibly; add translations to
+// translations below instead of editing handlers.
+//
+// Everything a handler writes to stdout for people goes through the
+// catalog: headings, labels, column headers, notes, and results. Values
+// keep their fixed form (statuses, durations, sizes, relative times), as do
+// log records, so they can be searched and parsed whatever the language,
+// and machine-readable output (--json, --yaml, --porcelain). Counts may be
+// passed as %d, which groups digits by locale; pass identifiers such as
+// PIDs and exit codes as strings.
+const (
+	msgRunningTask         = "%s Running task %q with profile %q (parallelism: %d, timeout: %s)\n"
+	msgPlanHeader          = "%s Plan for task %q with profile %q:\n"
+	msgPlanSummary         = "Plan: %d to create, %d to update, %d to delete, %d to run. Nothing was executed.\n"
+	msgConfigExists        = "config already exists at %s (use --force to overwrite)"
+	msgUnsupportedLanguage = "invalid --lang value %q (expected a BCP 47 tag such as en or de-DE)"
+)
+
+// Nouns counted with humanize.Plural; some double as labels.
+const (
+	msgTask     = "task"
+	msgTasks    = "tasks"
+	msgAttempt  = "attempt"
+	msgAttempts = "attempts"
+	msgRetry    = "retry"
+	msgRetries  = "retries"
+)
+
+// Labels of key/value rows.
+const (
+	msgLabelID          = "id"
+	msgLabelProfile     = "profile"
+	msgLabelStarted     = "started"
+	msgLabelDuration    = "duration"
+	msgLabelStatus      = "status"
+	msgLabelExitCode    = "exit code"
+	msgLabelError       = "error"
+	msgLabelFlags       = "flags"
+	msgLabelConfig      = "config"
+	msgLabelData        = "data"
+	msgLabelState       = "state"
+	msgLabelCache       = "cache"
+	msgLabelVersion     = "version"
+	msgLabelCommit      = "commit"
+	msgLabelDate        = "date"
+	msgLabelPlatform    = "platform"
+	msgLabelPath        = "path"
+	msgLabelFiles       = "files"
+	msgLabelSize        = "size"
+	msgLabelLogLevel    = "log level"
+	msgLabelParallelism = "parallelism"
+	msgLabelDescription = "description"
+	msgLabelNeeds       = "needs"
+	msgLabelParam       = "param %s"
+	msgLabelInput       = "input"
+	msgLabelTimeout     = "timeout"
+	msgLabelLastRun     = "last run"
+	msgLabelRunID       = "run id"
+	msgLabelAttempted   = "attempted"
+	msgLabelSucceeded   = "succeeded"
+	msgLabelFailed      = "failed"
+	msgLabelSkipped     = "skipped"
+	msgLabelUpToDate    = "up to date"
+	msgLabelSlowest     = "slowest"
+	msgLabelWallTime    = "wall time"
+	msgLabelBusyTime    = "busy time"
+	msgLabelWorkers     = "workers"
+	msgLabelUtilization = "utilization"
+	msgLabelLoops       = "loops"
+	msgLabelSocket      = "socket"
+	msgLabelLogFile     = "log file"
+	msgLabelStorage     = "storage"
+	msgLabelExpiry      = "expiry"
+	msgLabelRefreshable = "refreshable"
+)
+
+// Column headers of tables.
+const (
+	msgColID          = "ID"
+	msgColStarted     = "STARTED"
+	msgColTask        = "TASK"
+	msgColProfile     = "PROFILE"
+	msgColStatus      = "STATUS"
+	msgColDuration    = "DURATION"
+	msgColKind        = "KIND"
+	msgColSize        = "SIZE"
+	msgColOverrides   = "OVERRIDES"
+	msgColFiles       = "FILES"
+	msgColKey         = "KEY"
+	msgColModified    = "MODIFIED"
+	msgColDescription = "DESCRIPTION"
+	msgColNeeds       = "NEEDS"
+	msgColParams      = "PARAMS"
+	msgColTimeout     = "TIMEOUT"
+	msgColLastRun     = "LAST RUN"
+	msgColQueued      = "QUEUED"
+	msgColAttempts    = "ATTEMPTS"
+	msgColAlias       = "ALIAS"
+	msgColCommand     = "COMMAND"
+	msgColPlugin      = "PLUGIN"
+	msgColPath        = "PATH"
+	msgColVariable    = "VARIABLE"
+	msgColValue       = "VALUE"
+	msgColConfigKey   = "CONFIG KEY"
+)
+
+// Headings, notes, and results, by command.
+const (
+	// run and its reports
+	msgTaskNeeds      = " (needs %s)"
+	msgTaskParams     = " [params: %s]"
+	msgPlanAfter      = " (after %s)"
+	msgSummary        = "Summary"
+	msgStats          = "Stats"
+	msgWorkersPeak    = "%d (peak %d busy)"
+	msgFailures       = "Failures (%d of %s)"
+	msgFailureLog     = ", log: %s"
+	msgWatchChanged   = "%s changed: %s %s"
+	msgWatchMore      = " (+%d more)"
+	msgScheduleNext   = " (next %s)"
+	msgGitIn          = " in %s"
+	msgNoOverrides    = "(no overrides)"
+	msgProfileFromEnv = "%s_PROFILE=%s selects the active profile."
+	msgStateTotal     = "%s in %s"
+
+	// task
+	msgParamRequired = "required"
+	msgParamDefault  = "default %v"
+	msgParamOneOf    = "one of %s"
+	msgInputOnStdin  = "%s on stdin"
+	msgNoTimeout     = "none"
+	msgNeverRun      = "never"
+
+	// daemon, auth, and service
+	msgDaemonNotRunning = "daemon is not running"
+	msgDaemonRunning    = "%s is running (pid %s)"
+	msgLoggedIn         = "Logged in; token stored in %s"
+	msgLoggedOut        = "Logged out"
+	msgServiceCreated   = "created Windows service %s"
+	msgServiceStartWith = "start it with: %s"
+	msgServiceWrote     = "wrote %s %s unit %s"
+	msgServiceStarted   = "started %s unit %s"
+	msgServiceStopped   = "stopped %s unit %s"
+	msgServiceRemoved   = "removed %s %s unit %s"
+
+	// alias, plugin, env, version, and info
+	msgAliasShadowed  = "(shadowed by the built-in command)"
+	msgPluginShadowed = "(shadowed by %s)"
+	msgUnset          = "unset"
+	msgModifiedNote   = " (modified)"
+	msgModified       = ", modified"
+	msgUnknown        = "unknown"
+	msgUpToWorkers    = " (up to %d workers)"
+	msgWorkers        = " (%d workers)"
+
+	// onboarding
+	msgWelcome              = "Welcome to %s"
+	msgOnboardConfirm       = "This looks like the first run. Set up the config, a profile, and shell completions now?"
+	msgOnboardSkipped       = "Skipped; `%[1]s init`, `%[1]s profile`, and `%[1]s completions` do the same later."
+	msgOnboardDefaultConfig = "default config written to %s"
+	msgOnboardExisting      = "using the existing config at %s"
+	msgNextSteps            = "Next steps"
+	msgStepList             = "see the tasks you can run"
+	msgStepRun              = "pick a task and run it"
+	msgStepConfig           = "inspect the effective settings"
+	msgStepTUI              = "open the dashboard"
+	msgStepHelp             = "list every command"
+	msgProfileBase          = "default (the base config)"
+	msgProfileNew           = "create a new profile"
+	msgProfileQuestion      = "Which profile should runs use? Profiles override settings per environment, such as dev or prod."
+	msgProfileInUse         = "runs use profile %s"
+	msgProfileName          = "Profile name"
+	msgProfileExists        = "profile %s exists"
+	msgZshCompletions       = "For zsh completions, add `source <(%s completions zsh)` to ~/.zshrc."
+	msgSeeCompletions       = "See `%s completions --help` to set up shell completions."
+	msgInstallCompletions   = "Install %s completions to %s?"
+	msgCompletionsInstalled = "%s completions installed to %s (new shells pick them up)"
+)
+
+var translations = map[language.Tag]map[string]string{
+	language.German: {
+		msgRunningTask:  "%s Führe Aufgabe %q mit Profil %q aus (Parallelität: %d, Zeitlimit: %s)\n",
+		msgPlanHeader:   "%s Plan für Aufgabe %q mit Profil %q:\n",
+		msgPlanSummary:  "Plan: %d erstellen, %d ändern, %d löschen, %d ausführen. Es wurde nichts ausgeführt.\n",
+		msgConfigExists: "Konfiguration existiert bereits unter %s (mit --force überschreiben)",
+
+		msgTask:     "Aufgabe",
+		msgTasks:    "Aufgaben",
+		msgAttempt:  "Versuch",
+		msgAttempts: "Versuche",
+		msgRetry:    "Wiederholung",
+		msgRetries:  "Wiederholungen",
+
+		msgLabelID:          "ID",
+		msgLabelProfile:     "Profil",
+		msgLabelStarted:     "Gestartet",
+		msgLabelDuration:    "Dauer",
+		msgLabelStatus:      "Status",
+		msgLabelExitCode:    "Exit-Code",
+		msgLabelError:       "Fehler",
+		msgLabelFlags:       "Flags",
+		msgLabelConfig:      "Konfiguration",
+		msgLabelData:        "Daten",
+		msgLabelState:       "Zustand",
+		msgLabelCache:       "Cache",
+		msgLabelVersion:     "Version",
+		msgLabelCommit:      "Commit",
+		msgLabelDate:        "Datum",
+		msgLabelPlatform:    "Plattform",
+		msgLabelPath:        "Pfad",
+		msgLabelFiles:       "Dateien",
+		msgLabelSize:        "Größe",
+		msgLabelLogLevel:    "Log-Level",
+		msgLabelParallelism: "Parallelität",
+		msgLabelDescription: "Beschreibung",
+		msgLabelNeeds:       "Benötigt",
+		msgLabelParam:       "Parameter %s",
+		msgLabelInput:       "Eingabe",
+		msgLabelTimeout:     "Zeitlimit",
+		msgLabelLastRun:     "Letzter Lauf",
+		msgLabelRunID:       "Lauf-ID",
+		msgLabelAttempted:   "Versucht",
+		msgLabelSucceeded:   "Erfolgreich",
+		msgLabelFailed:      "Fehlgeschlagen",
+		msgLabelSkipped:     "Übersprungen",
+		msgLabelUpToDate:    "Aktuell",
+		msgLabelSlowest:     "Langsamste",
+		msgLabelWallTime:    "Gesamtzeit",
+		msgLabelBusyTime:    "Arbeitszeit",
+		msgLabelWorkers:     "Worker",
+		msgLabelUtilization: "Auslastung",
+		msgLabelLoops:       "Schleifen",
+		msgLabelSocket:      "Socket",
+		msgLabelLogFile:     "Logdatei",
+		msgLabelStorage:     "Speicherort",
+		msgLabelExpiry:      "Ablauf",
+		msgLabelRefreshable: "Erneuerbar",
+
+		msgColStarted:     "GESTARTET",
+		msgColTask:        "AUFGABE",
+		msgColProfile:     "PROFIL",
+		msgColDuration:    "DAUER",
+		msgColKind:        "ART",
+		msgColSize:        "GRÖSSE",
+		msgColOverrides:   "ÜBERSCHREIBT",
+		msgColFiles:       "DATEIEN",
+		msgColKey:         "SCHLÜSSEL",
+		msgColModified:    "GEÄNDERT",
+		msgColDescription: "BESCHREIBUNG",
+		msgColNeeds:       "BENÖTIGT",
+		msgColParams:      "PARAMETER",
+		msgColTimeout:     "ZEITLIMIT",
+		msgColLastRun:     "LETZTER LAUF",
+		msgColQueued:      "WARTEZEIT",
+		msgColAttempts:    "VERSUCHE",
+		msgColCommand:     "BEFEHL",
+		msgColPath:        "PFAD",
+		msgColValue:       "WERT",
+		msgColConfigKey:   "KONFIGURATIONSSCHLÜSSEL",
+
+		msgTaskNeeds:      " (benötigt %s)",
+		msgTaskParams:     " [Parameter: %s]",
+		msgPlanAfter:      " (nach %s)",
+		msgSummary:        "Zusammenfassung",
+		msgStats:          "Statistik",
+		msgWorkersPeak:    "%d (höchstens %d beschäftigt)",
+		msgFailures:       "Fehler (%d von %s)",
+		msgFailureLog:     ", Log: %s",
+		msgWatchChanged:   "%s geändert: %s %s",
+		msgWatchMore:      " (+%d weitere)",
+		msgScheduleNext:   " (nächster Lauf %s)",
+		msgNoOverrides:    "(keine Überschreibungen)",
+		msgProfileFromEnv: "%s_PROFILE=%s wählt das aktive Profil.",
+
+		msgParamRequired: "erforderlich",
+		msgParamDefault:  "Standard %v",
+		msgParamOneOf:    "eins von %s",
+		msgInputOnStdin:  "%s auf stdin",
+		msgNoTimeout:     "keins",
+		msgNeverRun:      "nie",
+
+		msgDaemonNotRunning: "Daemon läuft nicht",
+		msgDaemonRunning:    "%s läuft (PID %s)",
+		msgLoggedIn:         "Angemeldet; Token gespeichert in %s",
+		msgLoggedOut:        "Abgemeldet",
+		msgServiceCreated:   "Windows-Dienst %s erstellt",
+		msgServiceStartWith: "Starten mit: %s",
+		msgServiceWrote:     "%[2]s-Unit %[3]s (%[1]s) geschrieben",
+		msgServiceStarted:   "%s-Unit %s gestartet",
+		msgServiceStopped:   "%s-Unit %s gestoppt",
+		msgServiceRemoved:   "%[2]s-Unit %[3]s (%[1]s) entfernt",
+
+		msgAliasShadowed:  "(vom eingebauten Befehl verdeckt)",
+		msgPluginShadowed: "(verdeckt von %s)",
+		msgUnset:          "nicht gesetzt",
+		msgModifiedNote:   " (geändert)",
+		msgModified:       ", geändert",
+		msgUnknown:        "unbekannt",
+		msgUpToWorkers:    " (bis zu %d Worker)",
+		msgWorkers:        " (%d Worker)",
+
+		msgWelcome:              "Willkommen bei %s",
+		msgOnboardConfirm:       "Das scheint der erste Start zu sein. Jetzt Konfiguration, Profil und Shell-Vervollständigung einrichten?",
+		msgOnboardSkipped:       "Übersprungen; `%[1]s init`, `%[1]s profile` und `%[1]s completions` erledigen das später.",
+		msgOnboardDefaultConfig: "Standardkonfiguration nach %s geschrieben",
+		msgOnboardExisting:      "Vorhandene Konfiguration unter %s wird verwendet",
+		msgNextSteps:            "Nächste Schritte",
+		msgStepList:             "ausführbare Aufgaben anzeigen",
+		msgStepRun:              "eine Aufgabe auswählen und ausführen",
+		msgStepConfig:           "wirksame Einstellungen ansehen",
+		msgStepTUI:              "das Dashboard öffnen",
+		msgStepHelp:             "alle Befehle auflisten",
+		msgProfileBase:          "default (die Basiskonfiguration)",
+		msgProfileNew:           "ein neues Profil anlegen",
+		msgProfileQuestion:      "Welches Profil sollen Läufe verwenden? Profile überschreiben Einstellungen je Umgebung, etwa dev oder prod.",
+		msgProfileInUse:         "Läufe verwenden Profil %s",
+		msgProfileName:          "Profilname",
+		msgProfileExists:        "Profil %s existiert bereits",
+		msgZshCompletions:       "Für die zsh-Vervollständigung `source <(%s completions zsh)` zu ~/.zshrc hinzufügen.",
+		msgSeeCompletions:       "Siehe `%s completions --help`, um die Shell-Vervollständigung einzurichten.",
+		msgInstallCompletions:   "%s-Vervollständigung nach %s installieren?",
+		msgCompletionsInstalled: "%s-Vervollständigung nach %s installiert (neue Shells laden sie)",
+	},
+}
+
+var messages = buildCatalog()
+
+func buildCatalog() *catalog.Builder {
+	b := catalog.NewBuilder(catalog.Fallback(language.English))
+	for tag, entries := range translations {
+		for key, msg := range entries {
+			if err := b.SetString(tag, key, msg); err != nil {
+				panic(fmt.Sprintf("invalid translation for %s: %v", tag, err))
+			}
+		}
+	}
+	return b
+}
+
+// ResolveLanguage picks the output language: the --lang override first, then
+// the first of LC_ALL, LC_MESSAGES, and LANG that is set, as in POSIX, so
+// LC_ALL=C selects English whatever LANG says. Without any, it is English.
+func ResolveLanguage(override string) (language.Tag, error) {
+	if override != "" {
+		tag, err := language.Parse(override)
+		if err != nil {
+			return language.Und, fmt.Errorf(msgUnsupportedLanguage, override)
+		}
+		return matchLanguage(tag), nil
+	}
+
+	for _, key := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(key); value != "" {
+			return matchLanguage(parseLocale(value)), nil
+		}
+	}
+	return language.English, nil
+}
+
+// headers translates the column headers of a table.
+func headers(out Renderer, keys ...string) []string {
+	translated := make([]string, len(keys))
+	for i, key := range keys {
+		translated[i] = out.Text(key)
+	}
+	return translated
+}
+
+// plural counts n of a catalog noun, e.g. "3 attempts".
+func plural(out Renderer, n int, singular, plural string) string {
+	return humanize.Plural(n, out.Text(singular), out.Text(plural))
+}
+
+// NewPrinter returns a message printer backed by the template's catalog.
+func NewPrinter(tag language.Tag) *message.Printer {
+	return message.NewPrinter(tag, message.Catalog(messages))
+}
+
+func matchLanguage(tag language.Tag) language.Tag {
+	supported := append([]language.Tag{language.English}, messages.Languages()...)
+	_, index, confidence := language.NewMatcher(supported).Match(tag)
+	if confidence == language.No {
+		return language.English
+	}
+	return supported[index]
+}
+
+// parseLocale converts POSIX locale strings such as "de_DE.UTF-8" into
+// language tags. The C and POSIX locales, with or without a codeset such
+// as C.UTF-8, and values that are no locale are English.
+func parseLocale(value string) language.Tag {
+	if i := strings.IndexAny(value, ".@"); i >= 0 {
+		value = value[:i]
+	}
+	if value == "C" || value == "POSIX" {
+		return language.English
+	}
+	tag, err := language.Parse(strings.ReplaceAll(value, "_", "-"))
+	if err != nil {
+		return language.English
+	}
+	return tag
+}
@@ -0,0 +1,52 @@
+package app
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestResolveLanguage(t *testing.T) {
+	for _, tt := range []struct {
+		lcAll, lcMessages, lang string
+		want                    language.Tag
+	}{
+		{"", "", "", language.English},
+		{"", "", "de_DE.UTF-8", language.German},
+		{"", "de_AT", "en_US.UTF-8", language.German},
+		{"C", "", "de_DE.UTF-8", language.English},
+		{"C.UTF-8", "de_DE", "de_DE", language.English},
+		{"POSIX", "", "de_DE", language.English},
+		{"de_CH.UTF-8@euro", "C", "", language.German},
+		{"", "C", "de_DE", language.English},
+		{"fr_FR", "", "de_DE", language.English},
+	} {
+		t.Setenv("LC_ALL", tt.lcAll)
+		t.Setenv("LC_MESSAGES", tt.lcMessages)
+		t.Setenv("LANG", tt.lang)
+		got, err := ResolveLanguage("")
+		if err != nil {
+			t.Fatalf("ResolveLanguage: %v", err)
+		}
+		// matchLanguage may keep a region, e.g. de-u-rg-atzzzz.
+		if base, _ := got.Base(); base.String() != tt.want.String() {
+			t.Errorf("LC_ALL=%q LC_MESSAGES=%q LANG=%q: language = %s, want %s", tt.lcAll, tt.lcMessages, tt.lang, got, tt.want)
+		}
+	}
+}
+
+// TestTranslationsKeepVerbs catches translations that drop or add a
+// format verb, which would misplace or lose the arguments.
+func TestTranslationsKeepVerbs(t *testing.T) {
+	verbs := func(s string) int {
+		return strings.Count(strings.ReplaceAll(s, "%%", ""), "%")
+	}
+	for tag, entries := range translations {
+		for key, msg := range entries {
+			if verbs(msg) != verbs(key) {
+				t.Errorf("%s translation of %q has %d verbs, want %d: %q", tag, key, verbs(msg), verbs(key), msg)
+			}
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/humanize"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/runner"
+)
+
+// RunMetrics are the per-run statistics shown by `run --stats`, included in
+// JSON/YAML results, and stored with the run's history entry.
+type RunMetrics struct {
+	WallMS int64 `json:"wall_ms" yaml:"wall_ms"`
+	// BusyMS is the summed run time of all tasks.
+	BusyMS  int64 `json:"busy_ms" yaml:"busy_ms"`
+	Workers int   `json:"workers" yaml:"workers"`
+	// Utilization is BusyMS over Workers × WallMS, between 0 and 1.
+	Utilization     float64       `json:"utilization" yaml:"utilization"`
+	PeakConcurrency int           `json:"peak_concurrency" yaml:"peak_concurrency"`
+	Attempts        int           `json:"attempts" yaml:"attempts"`
+	Retries         int           `json:"retries" yaml:"retries"`
+	Tasks           []TaskMetrics `json:"tasks" yaml:"tasks"`
+}
+
+// TaskMetrics are the timings of one task.
+type TaskMetrics struct {
+	Name   string     `json:"name" yaml:"name"`
+	Status TaskStatus `json:"status" yaml:"status"`
+	// QueuedMS is how long the task waited after the run started, for
+	// dependencies or a free worker.
+	QueuedMS   int64 `json:"queued_ms" yaml:"queued_ms"`
+	DurationMS int64 `json:"duration_ms" yaml:"duration_ms"`
+	Attempts   int   `json:"attempts" yaml:"attempts"`
+}
+
+// CollectMetrics derives run metrics from a runner report.
+func CollectMetrics(report runner.Report) RunMetrics {
+	metrics := RunMetrics{
+		WallMS:  report.Duration.Milliseconds(),
+		Workers: report.Workers,
+		Tasks:   make([]TaskMetrics, 0, len(report.Results)),
+	}
+
+	type edge struct {
+		at    time.Time
+		delta int
+	}
+	var edges []edge
+	var busy time.Duration
+	for _, r := range report.Results {
+		task := TaskMetrics{Name: r.Name, Status: taskStatus(r), Attempts: r.Attempts}
+		if !r.Skipped && !r.UpToDate {
+			task.QueuedMS = r.Started.Sub(report.Started).Milliseconds()
+			task.DurationMS = r.Duration.Milliseconds()
+			busy += r.Duration
+			edges = append(edges, edge{r.Started, 1}, edge{r.Started.Add(r.Duration), -1})
+		}
+		metrics.Attempts += r.Attempts
+		if r.Attempts > 1 {
+			metrics.Retries += r.Attempts - 1
+		}
+		metrics.Tasks = append(metrics.Tasks, task)
+	}
+	metrics.BusyMS = busy.Milliseconds()
+	if report.Workers > 0 && report.Duration > 0 {
+		metrics.Utilization = float64(busy) / (float64(report.Workers) * float64(report.Duration))
+		if metrics.Utilization > 1 {
+			metrics.Utilization = 1
+		}
+	}
+
+	// Sweep start/finish edges; finishes sort first at equal times so
+	// back-to-back tasks do not count as overlapping.
+	sort.Slice(edges, func(i, j int) bool {
+		if edges[i].at.Equal(edges[j].at) {
+			return edges[i].delta < edges[j].delta
+		}
+		return edges[i].at.Before(edges[j].at)
+	})
+	running := 0
+	for _, e := range edges {
+		running += e.delta
+		if running > metrics.PeakConcurrency {
+			metrics.PeakConcurrency = running
+		}
+	}
+	return metrics
+}
+
+// renderStats prints the `run --stats` table.
+func renderStats(out Renderer, metrics RunMetrics) {
+	ms := func(v int64) string { return humanize.Duration(time.Duration(v) * time.Millisecond) }
+
+	out.Println()
+	out.Heading(out.Text(msgStats))
+	rows := make([][]string, 0, len(metrics.Tasks))
+	for _, t := range metrics.Tasks {
+		queued, duration := ms(t.QueuedMS), ms(t.DurationMS)
+		if t.Status == TaskSkipped || t.Status == TaskUpToDate {
+			queued, duration = "-", "-"
+		}
+		rows = append(rows, []string{t.Name, string(t.Status), queued, duration, strconv.Itoa(t.Attempts)})
+	}
+	out.Table("  ", headers(out, msgColTask, msgColStatus, msgColQueued, msgColDuration, msgColAttempts), rows)
+	out.Println()
+	out.KeyValues("  ", []KeyValue{
+		{Key: out.Text(msgLabelWallTime), Value: ms(metrics.WallMS)},
+		{Key: out.Text(msgLabelBusyTime), Value: ms(metrics.BusyMS)},
+		{Key: out.Text(msgLabelWorkers), Value: out.Sprintf(msgWorkersPeak, metrics.Workers, metrics.PeakConcurrency)},
+		{Key: out.Text(msgLabelUtilization), Value: fmt.Sprintf("%.0f%%", metrics.Utilization*100)},
+		{Key: out.Text(msgAttempts), Value: fmt.Sprintf("%d (%s)", metrics.Attempts, plural(out, metrics.Retries, msgRetry, msgRetries))},
+	})
+}
@@ -0,0 +1,335 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/humanize"
+)
+
+// MigrateDataOptions configure `migrate data`.
+type MigrateDataOptions struct {
+	// From is the old data directory; empty detects it.
+	From string
+	// Move removes the old directory once every file is verified.
+	Move bool
+}
+
+// MigrateDataResult reports what `migrate data` did.
+type MigrateDataResult struct {
+	From string `json:"from" yaml:"from"`
+	To   string `json:"to" yaml:"to"`
+	// Copied counts files written to To; Identical counts files To
+	// already held with the same content.
+	Copied    int   `json:"copied" yaml:"copied"`
+	Identical int   `json:"identical" yaml:"identical"`
+	Bytes     int64 `json:"bytes" yaml:"bytes"`
+	Removed   bool  `json:"removed" yaml:"removed"`
+}
+
+// dataDirRecordPath holds the data directory the CLI last used, so a
+// change of paths.data_dir can be noticed and migrated.
+func dataDirRecordPath(stateDir string) string {
+	return filepath.Join(stateDir, "data_dir")
+}
+
+func readDataDirRecord(stateDir string) string {
+	data, err := os.ReadFile(dataDirRecordPath(stateDir))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func writeDataDirRecord(stateDir, dir string) error {
+	return writeFileAtomic(dataDirRecordPath(stateDir), []byte(dir+"\n"), 0o644)
+}
+
+// checkDataDir records the data directory on a run's first use of it and
+// warns when it has since moved away from a directory that still holds
+// data.
+func checkDataDir(ctx *RuntimeContext) {
+	recorded := readDataDirRecord(ctx.Paths.StateDir)
+	if recorded == ctx.Paths.DataDir {
+		return
+	}
+	if recorded != "" && hasEntries(recorded) {
+		ctx.Logger.Warn("data directory changed from %s to %s; run `%s migrate data` to bring its contents along", recorded, ctx.Paths.DataDir, appName)
+		return
+	}
+	if err := writeDataDirRecord(ctx.Paths.StateDir, ctx.Paths.DataDir); err != nil {
+		ctx.Logger.Debug("could not record data directory: %v", err)
+	}
+}
+
+func hasEntries(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	return err == nil && len(entries) > 0
+}
+
+// migrationSource picks the directory to migrate from: the one recorded
+// before paths.data_dir changed, else the default location when
+// paths.data_dir points elsewhere.
+func migrationSource(ctx *RuntimeContext) string {
+	candidates := []string{readDataDirRecord(ctx.Paths.StateDir)}
+	if dir, err := defaultDataDir(appName); err == nil {
+		candidates = append(candidates, dir)
+	}
+	for _, dir := range candidates {
+		if dir != "" && dir != ctx.Paths.DataDir && hasEntries(dir) {
+			return dir
+		}
+	}
+	return ""
+}
+
+// migrateFile is one regular file or symlink to bring over, relative to
+// the source directory.
+type migrateFile struct {
+	rel  string
+	info fs.FileInfo
+}
+
+// HandleMigrateData copies the contents of an old data directory into the
+// current one, verifying each file's SHA-256, then records the new
+// location. Files the target already holds with different content stop
+// the migration before anything is written.
+func HandleMigrateData(ctx *RuntimeContext, opts MigrateDataOptions) error {
+	to := ctx.Paths.DataDir
+	from := opts.From
+	if from != "" {
+		expanded, err := expandPath(from)
+		if err != nil {
+			return UsageError(err)
+		}
+		if from, err = filepath.Abs(expanded); err != nil {
+			return err
+		}
+		info, err := os.Stat(from)
+		if err != nil {
+			return UsageError(fmt.Errorf("--from: %w", err))
+		}
+		if !info.IsDir() {
+			return UsageError(fmt.Errorf("--from: %s is not a directory", from))
+		}
+	} else if from = migrationSource(ctx); from == "" {
+		ctx.Logger.Info("no old data directory found; %s is up to date", to)
+		return nil
+	}
+	if within(from, to) || within(to, from) {
+		return UsageError(fmt.Errorf("cannot migrate between %s and %s: one contains the other", from, to))
+	}
+
+	files, err := migrationFiles(from)
+	if err != nil {
+		return err
+	}
+	result := MigrateDataResult{From: from, To: to}
+	var pending, conflicts []migrateFile
+	for _, f := range files {
+		same, err := sameFile(filepath.Join(from, f.rel), filepath.Join(to, f.rel), f.info)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			pending = append(pending, f)
+			result.Bytes += f.info.Size()
+		case err != nil:
+			return err
+		case same:
+			result.Identical++
+		default:
+			conflicts = append(conflicts, f)
+		}
+	}
+	if len(conflicts) > 0 {
+		names := make([]string, 0, len(conflicts))
+		for _, f := range conflicts {
+			names = append(names, filepath.ToSlash(f.rel))
+		}
+		return fmt.Errorf("%s different content in %s than in %s: %s; move them aside and retry",
+			humanize.Plural(len(conflicts), "file has", "files have"), to, from, strings.Join(names, ", "))
+	}
+
+	if ctx.Common.DryRun {
+		ctx.Logger.Info("dry-run: would copy %s (%s) from %s to %s", humanize.Plural(len(pending), "file", "files"), humanize.Bytes(result.Bytes), from, to)
+		if result.Identical > 0 {
+			ctx.Logger.Info("dry-run: %s already in place", humanize.Plural(result.Identical, "file is", "files are"))
+		}
+		if opts.Move {
+			ctx.Logger.Info("dry-run: would remove %s", from)
+		}
+		ctx.Logger.Info("dry-run: would record %s as the data directory", to)
+		return nil
+	}
+
+	for _, f := range pending {
+		if err := copyVerified(filepath.Join(from, f.rel), filepath.Join(to, f.rel), f.info); err != nil {
+			return fmt.Errorf("migrate %s: %w", filepath.ToSlash(f.rel), err)
+		}
+		result.Copied++
+	}
+	if err := writeDataDirRecord(ctx.Paths.StateDir, to); err != nil {
+		return fmt.Errorf("record data directory: %w", err)
+	}
+	if opts.Move {
+		if unsafeToRemove(from) {
+			ctx.Logger.Warn("not removing %s: it contains the home directory", from)
+		} else if err := os.RemoveAll(from); err != nil {
+			return fmt.Errorf("remove %s: %w", from, err)
+		} else {
+			result.Removed = true
+		}
+	}
+
+	switch {
+	case ctx.Common.JSON:
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+	case ctx.Common.YAML:
+		data, err := yaml.Marshal(result)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		fmt.Fprintf(ctx.Out.Writer(), "%s\t%s\t%d\t%d\n", result.From, result.To, result.Copied, result.Bytes)
+	default:
+		ctx.Logger.Info("copied %s (%s) from %s to %s", humanize.Plural(result.Copied, "file", "files"), humanize.Bytes(result.Bytes), from, to)
+		if result.Identical > 0 {
+			ctx.Logger.Info("%s already in place", humanize.Plural(result.Identical, "file was", "files were"))
+		}
+		if result.Removed {
+			ctx.Logger.Info("removed %s", from)
+		} else if !opts.Move {
+			ctx.Logger.Info("%s was kept; remove it once you no longer need it, or migrate with --move", from)
+		}
+	}
+	return nil
+}
+
+// within reports whether path is dir or inside it.
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && (rel == "." || (rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))))
+}
+
+// migrationFiles lists the regular files and symlinks under dir. Other
+// special files cannot be copied and are reported.
+func migrationFiles(dir string) ([]migrateFile, error) {
+	var files []migrateFile
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() && info.Mode()&fs.ModeSymlink == 0 {
+			return fmt.Errorf("cannot migrate %s: not a regular file", path)
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, migrateFile{rel: rel, info: info})
+		return nil
+	})
+	return files, err
+}
+
+// sameFile reports whether dst already matches src. It returns an error
+// wrapping fs.ErrNotExist when dst is missing.
+func sameFile(src, dst string, info fs.FileInfo) (bool, error) {
+	dstInfo, err := os.Lstat(dst)
+	if err != nil {
+		return false, err
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		if dstInfo.Mode()&fs.ModeSymlink == 0 {
+			return false, nil
+		}
+		a, errA := os.Readlink(src)
+		b, errB := os.Readlink(dst)
+		return errA == nil && errB == nil && a == b, errors.Join(errA, errB)
+	}
+	if !dstInfo.Mode().IsRegular() || dstInfo.Size() != info.Size() {
+		return false, nil
+	}
+	a, err := hashFile(src)
+	if err != nil {
+		return false, err
+	}
+	b, err := hashFile(dst)
+	if err != nil {
+		return false, err
+	}
+	return a == b, nil
+}
+
+// copyVerified copies src to dst through a temporary file, checks that
+// the copy hashes like the source, and keeps the mode and modification
+// time.
+func copyVerified(src, dst string, info fs.FileInfo) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	if info.Mode()&fs.ModeSymlink != 0 {
+		target, err := os.Readlink(src)
+		if err != nil {
+			return err
+		}
+		return os.Symlink(target, dst)
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, in); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(info.Mode().Perm()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	want, err := hashFile(src)
+	if err != nil {
+		return err
+	}
+	got, err := hashFile(tmp.Name())
+	if err != nil {
+		return err
+	}
+	if got != want {
+		return fmt.Errorf("verification failed: copy has SHA-256 %s, source %s", got, want)
+	}
+	if err := os.Chtimes(tmp.Name(), info.ModTime(), info.ModTime()); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
@@ -0,0 +1,337 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/buildinfo"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/desktop"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/humanize"
+)
+
+// webhookTimeout bounds delivery of one notification, retries included.
+// It also applies to interrupted runs, whose context is already canceled.
+const webhookTimeout = 30 * time.Second
+
+// RunNotification is what a webhook is told about a finished run: its
+// history entry plus where it ran. Without notifications.webhook.template
+// it is sent as JSON.
+type RunNotification struct {
+	Event string `json:"event"`
+	HistoryEntry
+	Host    string `json:"host"`
+	Version string `json:"version"`
+	// FailedTasks are the tasks that failed, in the order they ran.
+	FailedTasks []string `json:"failed_tasks,omitempty"`
+	// ArtifactsURL links the run's artifacts; see
+	// notifications.artifacts_url.
+	ArtifactsURL string `json:"artifacts_url,omitempty"`
+}
+
+// webhookFuncs are available to notifications.webhook.template in addition
+// to the text/template builtins.
+var webhookFuncs = template.FuncMap{
+	// json encodes a value, so strings such as .Error can be embedded in
+	// a JSON body safely.
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	// duration formats .DurationMS, e.g. "1m30s".
+	"duration": func(ms int64) string {
+		return humanize.Duration(time.Duration(ms) * time.Millisecond)
+	},
+}
+
+func parseWebhookTemplate(text string) (*template.Template, error) {
+	return template.New("webhook").Funcs(webhookFuncs).Option("missingkey=error").Parse(text)
+}
+
+func isHTTPURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func validateWebhook(cfg WebhookConfig) error {
+	if cfg.URL == "" {
+		return nil
+	}
+	if !isHTTPURL(cfg.URL) {
+		// The URL may embed a token, so it is not echoed.
+		return fmt.Errorf("invalid notifications.webhook.url (expected an http:// or https:// URL)")
+	}
+	if cfg.Template != "" {
+		if _, err := parseWebhookTemplate(cfg.Template); err != nil {
+			return fmt.Errorf("invalid notifications.webhook.template: %w", err)
+		}
+	}
+	return nil
+}
+
+func validateChat(key string, cfg ChatConfig) error {
+	if cfg.URL == "" {
+		return nil
+	}
+	if !isHTTPURL(cfg.URL) {
+		return fmt.Errorf("invalid %s.url (expected an http:// or https:// URL)", key)
+	}
+	if cfg.Template != "" {
+		if _, err := parseWebhookTemplate(cfg.Template); err != nil {
+			return fmt.Errorf("invalid %s.template: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// notifier delivers run notifications to one endpoint.
+type notifier struct {
+	name          string
+	url           string
+	headers       map[string]string
+	onlyOnFailure bool
+	body          func(RunNotification) ([]byte, error)
+}
+
+// notifiers returns the configured endpoints: the generic webhook, Slack,
+// and Teams.
+func notifiers(cfg NotificationsConfig) []notifier {
+	var list []notifier
+	if cfg.Webhook.URL != "" {
+		list = append(list, notifier{"webhook", cfg.Webhook.URL, cfg.Webhook.Headers, cfg.Webhook.OnlyOnFailure, func(n RunNotification) ([]byte, error) {
+			return webhookBody(cfg.Webhook.Template, n)
+		}})
+	}
+	if cfg.Slack.URL != "" {
+		list = append(list, notifier{"slack", cfg.Slack.URL, nil, cfg.Slack.OnlyOnFailure, func(n RunNotification) ([]byte, error) {
+			return slackBody(cfg.Slack.Template, n)
+		}})
+	}
+	if cfg.Teams.URL != "" {
+		list = append(list, notifier{"teams", cfg.Teams.URL, nil, cfg.Teams.OnlyOnFailure, func(n RunNotification) ([]byte, error) {
+			return teamsBody(cfg.Teams.Template, n)
+		}})
+	}
+	return list
+}
+
+// notifyRun announces a finished run: on the desktop when enabled and the
+// run was long enough, and to the webhook, Slack, and Teams when
+// configured. Failures are logged; a run's outcome never depends on its
+// notifications.
+func notifyRun(ctx *RuntimeContext, opts RunOptions, entry HistoryEntry) {
+	notifyDesktop(ctx, opts, entry)
+
+	list := notifiers(ctx.Config.Notifications)
+	if len(list) == 0 {
+		return
+	}
+	payload := runNotification(ctx, entry)
+	for _, n := range list {
+		if n.onlyOnFailure && entry.Status == HistorySucceeded {
+			continue
+		}
+		if err := postNotification(ctx, n, payload); err != nil {
+			ctx.Logger.Warn("%s notification for run %s failed: %v", n.name, entry.ID, err)
+			continue
+		}
+		ctx.Logger.Debug("%s notification for run %s sent", n.name, entry.ID)
+	}
+}
+
+// runNotification builds the payload for entry.
+func runNotification(ctx *RuntimeContext, entry HistoryEntry) RunNotification {
+	host, _ := os.Hostname()
+	n := RunNotification{Event: "run.finished", HistoryEntry: entry, Host: host, Version: buildinfo.Get().Version}
+	if entry.Metrics != nil {
+		for _, t := range entry.Metrics.Tasks {
+			if t.Status == TaskFailed {
+				n.FailedTasks = append(n.FailedTasks, t.Name)
+			}
+		}
+	}
+	dir := filepath.Join(runsDir(ctx.Paths.DataDir), entry.ID)
+	if base := ctx.Config.Notifications.ArtifactsURL; base != "" {
+		n.ArtifactsURL = strings.TrimSuffix(base, "/") + "/" + url.PathEscape(entry.ID)
+	} else if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		// Windows paths start with a drive letter, not a slash.
+		n.ArtifactsURL = (&url.URL{Scheme: "file", Path: "/" + strings.TrimPrefix(filepath.ToSlash(dir), "/")}).String()
+	}
+	return n
+}
+
+func notifyDesktop(ctx *RuntimeContext, opts RunOptions, entry HistoryEntry) {
+	cfg := ctx.Config.Notifications
+	enabled := cfg.Desktop
+	if opts.Notify != nil {
+		enabled = *opts.Notify
+	}
+	took := time.Duration(entry.DurationMS) * time.Millisecond
+	if !enabled || took < cfg.DesktopAfter.Std() {
+		return
+	}
+	n := desktop.Notification{
+		App:    appName,
+		Title:  fmt.Sprintf("%s: %s %s", appName, entry.Task, entry.Status),
+		Body:   fmt.Sprintf("Run %s finished in %s.", entry.ID, humanize.Duration(took)),
+		Urgent: entry.Status != HistorySucceeded,
+	}
+	if entry.Status != HistorySucceeded {
+		n.Body = fmt.Sprintf("Run %s %s after %s with exit code %d.", entry.ID, entry.Status, humanize.Duration(took), entry.ExitCode)
+		if entry.Error != "" {
+			n.Body += "\n" + entry.Error
+		}
+	}
+	if err := desktop.Notify(context.WithoutCancel(ctx.Context), n); err != nil {
+		ctx.Logger.Warn("desktop notification failed: %v", err)
+	}
+}
+
+// webhookBody renders the generic webhook's body: the payload as JSON,
+// or through tmpl when set.
+func webhookBody(tmpl string, n RunNotification) ([]byte, error) {
+	if tmpl == "" {
+		return json.Marshal(n)
+	}
+	return renderNotification(tmpl, n)
+}
+
+func renderNotification(text string, n RunNotification) ([]byte, error) {
+	tmpl, err := parseWebhookTemplate(text)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, n); err != nil {
+		return nil, fmt.Errorf("render template: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// chatSummary is the default message text for Slack and Teams. link
+// formats the artifacts link in the service's markup.
+func chatSummary(n RunNotification, link func(url, text string) string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s: task %s %s in %s", appName, n.Task, n.Status, humanize.Duration(time.Duration(n.DurationMS)*time.Millisecond))
+	if n.Status != HistorySucceeded {
+		fmt.Fprintf(&b, " (exit code %d)", n.ExitCode)
+	}
+	fmt.Fprintf(&b, "\nRun %s, profile %s, on %s", n.ID, n.Profile, n.Host)
+	if len(n.FailedTasks) > 0 {
+		fmt.Fprintf(&b, "\nFailed: %s", strings.Join(n.FailedTasks, ", "))
+	}
+	if n.ArtifactsURL != "" {
+		b.WriteString("\n" + link(n.ArtifactsURL, "Artifacts"))
+	}
+	return b.String()
+}
+
+// slackBody renders a message for a Slack incoming webhook, in a colored
+// attachment: green for success, red otherwise.
+func slackBody(tmpl string, n RunNotification) ([]byte, error) {
+	text := chatSummary(n, func(u, text string) string { return "<" + u + "|" + text + ">" })
+	if tmpl != "" {
+		rendered, err := renderNotification(tmpl, n)
+		if err != nil {
+			return nil, err
+		}
+		text = string(rendered)
+	}
+	color := "danger"
+	if n.Status == HistorySucceeded {
+		color = "good"
+	}
+	return json.Marshal(map[string]any{
+		"text": fmt.Sprintf("%s: %s %s", appName, n.Task, n.Status),
+		"attachments": []map[string]any{
+			{"color": color, "text": text, "mrkdwn_in": []string{"text"}},
+		},
+	})
+}
+
+// teamsBody renders an Adaptive Card for a Microsoft Teams incoming
+// webhook, with a button opening the artifacts when they have an http(s)
+// link.
+func teamsBody(tmpl string, n RunNotification) ([]byte, error) {
+	text := chatSummary(n, func(u, text string) string { return "[" + text + "](" + u + ")" })
+	if tmpl != "" {
+		rendered, err := renderNotification(tmpl, n)
+		if err != nil {
+			return nil, err
+		}
+		text = string(rendered)
+	}
+	color := "attention"
+	if n.Status == HistorySucceeded {
+		color = "good"
+	}
+	card := map[string]any{
+		"$schema": "http://adaptivecards.io/schemas/adaptive-card.json",
+		"type":    "AdaptiveCard",
+		"version": "1.4",
+		"body": []map[string]any{
+			{"type": "TextBlock", "text": fmt.Sprintf("%s %s", n.Task, n.Status), "weight": "bolder", "size": "medium", "color": color},
+			// Teams renders the lines of a TextBlock only with blank
+			// lines between them.
+			{"type": "TextBlock", "text": strings.ReplaceAll(text, "\n", "\n\n"), "wrap": true},
+		},
+	}
+	if isHTTPURL(n.ArtifactsURL) {
+		card["actions"] = []map[string]any{{"type": "Action.OpenUrl", "title": "Artifacts", "url": n.ArtifactsURL}}
+	}
+	return json.Marshal(map[string]any{
+		"type": "message",
+		"attachments": []map[string]any{
+			{"contentType": "application/vnd.microsoft.card.adaptive", "content": card},
+		},
+	})
+}
+
+// postNotification renders the payload for n and POSTs it.
+func postNotification(ctx *RuntimeContext, n notifier, payload RunNotification) error {
+	body, err := n.body(payload)
+	if err != nil {
+		return err
+	}
+
+	client, err := ctx.HTTPClient()
+	if err != nil {
+		return err
+	}
+	client.Timeout = webhookTimeout
+	reqCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx.Context), webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(reqCtx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range n.headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		// The error quotes the URL, which may embed a token.
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("server answered %s", resp.Status)
+	}
+	return nil
+}
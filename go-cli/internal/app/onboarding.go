@@ -0,0 +1,187 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/prompt"
+)
+
+// OnboardingOptions configure the first-run setup.
+type OnboardingOptions struct {
+	// Completion writes the completion script for shell (bash, zsh, or
+	// fish); the command layer supplies it from the command tree.
+	Completion func(shell string, w io.Writer) error
+}
+
+// onboardedPath marks that the first-run setup was offered, whether it
+// was completed or declined.
+func onboardedPath(stateDir string) string {
+	return filepath.Join(stateDir, "onboarded")
+}
+
+// ShouldOnboard reports whether to offer the first-run setup: no marker
+// yet, and a person at a terminal. It never triggers in scripts: stdin,
+// stdout, and stderr must be terminals, CI must be unset or false, and --yes,
+// --non-interactive, --dry-run, --quiet, machine-readable output, and
+// --no-onboarding all suppress it.
+func ShouldOnboard(ctx *RuntimeContext) bool {
+	c := ctx.Common
+	if c.NoOnboarding || c.AssumeYes || c.DryRun || c.Quiet || c.JSON || c.YAML || c.Porcelain {
+		return false
+	}
+	if p := ctx.Prompter(); p.NonInteractive || !p.Interactive || !isTerminal(os.Stdout) {
+		return false
+	}
+	_, err := os.Stat(onboardedPath(ctx.Paths.StateDir))
+	return errors.Is(err, fs.ErrNotExist)
+}
+
+// HandleOnboarding walks through the first-run setup: the config file, the
+// active profile, and shell completions, then prints next steps. It
+// records that it ran however it ends, so it is offered once; `init` and
+// the profile and completions commands do the same steps later.
+func HandleOnboarding(ctx *RuntimeContext, opts OnboardingOptions) error {
+	defer func() {
+		if err := writeFileAtomic(onboardedPath(ctx.Paths.StateDir), []byte(ctx.Clock.Now().UTC().Format("2006-01-02T15:04:05Z")+"\n"), 0o644); err != nil {
+			ctx.Logger.Debug("could not record onboarding: %v", err)
+		}
+	}()
+	p := ctx.Prompter()
+
+	out := ctx.Out
+	out.Println(out.Bold(out.Sprintf(msgWelcome, appName)))
+	ok, err := p.Confirm(out.Text(msgOnboardConfirm), true)
+	if err != nil || !ok {
+		out.Println(out.Dim(out.Sprintf(msgOnboardSkipped, appName)))
+		return skipAborted(err)
+	}
+
+	// 1. Config: loading already created it if it was missing.
+	current, err := readConfigFile(ctx.Paths.ConfigFile)
+	if err != nil {
+		return err
+	}
+	if current == defaultConfigContents(ctx.Paths.ConfigFile) {
+		out.Success(out.Sprintf(msgOnboardDefaultConfig, ctx.Paths.ConfigFile))
+	} else {
+		out.Success(out.Sprintf(msgOnboardExisting, ctx.Paths.ConfigFile))
+	}
+
+	// 2. Profile.
+	if err := onboardProfile(ctx, p); err != nil {
+		return skipAborted(err)
+	}
+
+	// 3. Completions.
+	if err := onboardCompletions(ctx, p, opts); err != nil {
+		return skipAborted(err)
+	}
+
+	// 4. Next steps.
+	out.Println()
+	out.Println(out.Bold(out.Text(msgNextSteps)))
+	out.KeyValues("", []KeyValue{
+		{Key: appName + " run --list", Value: out.Text(msgStepList)},
+		{Key: appName + " run", Value: out.Text(msgStepRun)},
+		{Key: appName + " config show", Value: out.Text(msgStepConfig)},
+		{Key: appName + " tui", Value: out.Text(msgStepTUI)},
+		{Key: appName + " --help", Value: out.Text(msgStepHelp)},
+	})
+	return nil
+}
+
+// onboardProfile lets the user pick the active profile, or create one.
+func onboardProfile(ctx *RuntimeContext, p *prompt.Prompter) error {
+	base, newName := ctx.Out.Text(msgProfileBase), ctx.Out.Text(msgProfileNew)
+	options := []string{base}
+	options = append(options, sortedKeys(ctx.Config.Profiles)...)
+	options = append(options, newName)
+	def := base
+	if _, ok := ctx.Config.Profiles[ctx.Config.Profile]; ok {
+		def = ctx.Config.Profile
+	}
+	choice, err := p.Select(ctx.Out.Text(msgProfileQuestion), options, def)
+	if err != nil {
+		return err
+	}
+	switch choice {
+	case def:
+		ctx.Out.Success(ctx.Out.Sprintf(msgProfileInUse, ctx.Config.Profile))
+		return nil
+	case base:
+		return HandleProfileUse(ctx, "default")
+	case newName:
+		name, err := p.Input(ctx.Out.Text(msgProfileName), "dev", func(name string) error {
+			if _, ok := ctx.Config.Profiles[name]; ok {
+				return errors.New(ctx.Out.Sprintf(msgProfileExists, name))
+			}
+			return ValidateProfileName(name)
+		})
+		if err != nil {
+			return err
+		}
+		if err := HandleProfileCreate(ctx, ProfileCreateOptions{Name: name}); err != nil {
+			return err
+		}
+		if ctx.Config.Profiles == nil {
+			ctx.Config.Profiles = map[string]map[string]any{}
+		}
+		ctx.Config.Profiles[name] = map[string]any{}
+		choice = name
+	}
+	return HandleProfileUse(ctx, choice)
+}
+
+// onboardCompletions installs the completion script for the login shell
+// where the shell loads it without setup, and where `uninstall` removes
+// it again; for zsh it prints the line to add instead.
+func onboardCompletions(ctx *RuntimeContext, p *prompt.Prompter, opts OnboardingOptions) error {
+	shell := filepath.Base(os.Getenv("SHELL"))
+	var target string
+	for _, path := range completionPaths() {
+		switch {
+		case shell == "bash" && filepath.Base(filepath.Dir(path)) == "completions" && filepath.Base(path) == appName:
+			target = path
+		case shell == "fish" && filepath.Base(path) == appName+".fish":
+			target = path
+		}
+	}
+	switch {
+	case shell == "zsh":
+		ctx.Out.Println(ctx.Out.Dim(ctx.Out.Sprintf(msgZshCompletions, appName)))
+		return nil
+	case target == "" || opts.Completion == nil:
+		ctx.Out.Println(ctx.Out.Dim(ctx.Out.Sprintf(msgSeeCompletions, appName)))
+		return nil
+	}
+	ok, err := p.Confirm(ctx.Out.Sprintf(msgInstallCompletions, shell, target), true)
+	if err != nil || !ok {
+		return err
+	}
+	var script bytes.Buffer
+	if err := opts.Completion(shell, &script); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	if err := writeFileAtomic(target, script.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("install completions: %w", err)
+	}
+	ctx.Out.Success(ctx.Out.Sprintf(msgCompletionsInstalled, shell, target))
+	return nil
+}
+
+// skipAborted treats ending the input (Ctrl+D) as declining the rest.
+func skipAborted(err error) error {
+	if errors.Is(err, prompt.ErrAborted) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,202 @@
+package app
+
+import (
+	"net/http"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// openAPIDocument describes routes as an OpenAPI 3.0 document. Body
+// schemas are derived from the Go types the handlers encode, following
+// their json tags, and shared under components/schemas.
+func openAPIDocument(routes []apiRoute, version string) map[string]any {
+	if version == "" {
+		version = "dev"
+	}
+	gen := schemaGen{defs: map[string]any{}, names: map[reflect.Type]string{}}
+	paths := map[string]any{}
+	for _, route := range routes {
+		op := map[string]any{
+			"operationId": route.ID,
+			"summary":     route.Summary,
+		}
+		if !route.Public {
+			op["security"] = []any{map[string]any{"bearer": []string{}}}
+		}
+
+		var params []any
+		for _, p := range route.Params {
+			params = append(params, map[string]any{
+				"name":        p.Name,
+				"in":          p.In,
+				"required":    p.In == "path",
+				"description": p.Description,
+				"schema":      map[string]any{"type": p.Type},
+			})
+		}
+		if params != nil {
+			op["parameters"] = params
+		}
+		if route.Request != nil {
+			op["requestBody"] = map[string]any{
+				"required": true,
+				"content":  jsonContent(gen.schema(reflect.TypeOf(route.Request))),
+			}
+		}
+
+		responses := map[string]any{}
+		for _, r := range route.Responses {
+			responses[strconv.Itoa(r.Status)] = map[string]any{
+				"description": r.Description,
+				"content":     jsonContent(gen.body(r.Body)),
+			}
+		}
+		if !route.Public {
+			responses[strconv.Itoa(http.StatusUnauthorized)] = map[string]any{
+				"description": "Missing or invalid bearer token.",
+				"content":     jsonContent(gen.body(ServeError{})),
+			}
+			responses[strconv.Itoa(http.StatusForbidden)] = map[string]any{
+				"description": "No serve.token is set and the Host header is not a loopback name or address.",
+				"content":     jsonContent(gen.body(ServeError{})),
+			}
+		}
+		op["responses"] = responses
+
+		item, _ := paths[route.Path].(map[string]any)
+		if item == nil {
+			item = map[string]any{}
+			paths[route.Path] = item
+		}
+		item[strings.ToLower(route.Method)] = op
+	}
+
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":       appName + " API",
+			"version":     version,
+			"description": "HTTP+JSON API of `" + appName + " serve`. The /v1 endpoints require a bearer token when serve.token is set.",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": gen.defs,
+			"securitySchemes": map[string]any{
+				"bearer": map[string]any{"type": "http", "scheme": "bearer", "description": "The value of serve.token."},
+			},
+		},
+	}
+}
+
+func jsonContent(schema map[string]any) map[string]any {
+	return map[string]any{"application/json": map[string]any{"schema": schema}}
+}
+
+// schemaGen derives JSON schemas from Go types, collecting named structs
+// in defs.
+type schemaGen struct {
+	defs  map[string]any
+	names map[reflect.Type]string
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// body returns the schema of a response body value.
+func (g *schemaGen) body(v any) map[string]any {
+	if alts, ok := v.(apiOneOf); ok {
+		oneOf := make([]any, 0, len(alts))
+		for _, alt := range alts {
+			oneOf = append(oneOf, g.schema(reflect.TypeOf(alt)))
+		}
+		return map[string]any{"oneOf": oneOf}
+	}
+	return g.schema(reflect.TypeOf(v))
+}
+
+func (g *schemaGen) schema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch {
+	case t == timeType:
+		return map[string]any{"type": "string", "format": "date-time"}
+	case t.Kind() == reflect.Struct:
+		return g.ref(t)
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number", "format": "double"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string", "format": "byte"}
+		}
+		return map[string]any{"type": "array", "items": g.schema(t.Elem())}
+	case reflect.Map:
+		if t.Elem().Kind() == reflect.Interface {
+			return map[string]any{"type": "object"}
+		}
+		return map[string]any{"type": "object", "additionalProperties": g.schema(t.Elem())}
+	}
+	return map[string]any{}
+}
+
+// ref registers the struct t under its type name, qualified with its
+// package when another type has the name, and returns a reference.
+func (g *schemaGen) ref(t reflect.Type) map[string]any {
+	if name, ok := g.names[t]; ok {
+		return map[string]any{"$ref": "#/components/schemas/" + name}
+	}
+	name := t.Name()
+	if _, taken := g.defs[name]; taken {
+		pkg := path.Base(t.PkgPath())
+		name = strings.ToUpper(pkg[:1]) + pkg[1:] + name
+	}
+	g.names[t] = name
+	ref := map[string]any{"$ref": "#/components/schemas/" + name}
+	// Claim the name first so recursive types terminate.
+	g.defs[name] = nil
+	props := map[string]any{}
+	var required []string
+	g.fields(t, props, &required)
+	def := map[string]any{"type": "object", "properties": props}
+	if required != nil {
+		def["required"] = required
+	}
+	g.defs[name] = def
+	return ref
+}
+
+// fields adds the JSON properties of struct t, inlining embedded structs
+// as encoding/json does. Fields without omitempty are required.
+func (g *schemaGen) fields(t reflect.Type, props map[string]any, required *[]string) {
+	for i := range t.NumField() {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || !f.IsExported() && !f.Anonymous {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" && f.Type.Kind() == reflect.Struct {
+			g.fields(f.Type, props, required)
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		props[name] = g.schema(f.Type)
+		if !strings.Contains(","+opts+",", ",omitempty,") {
+			*required = append(*required, name)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package app
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// ConfigKeys lists the dotted keys --set accepts: every leaf setting of
+// AppConfig, named by its mapstructure tags. Durations, parallelism, and
+// lists are leaves; tables keyed by name (tasks, profiles, headers,
+// runtime.priority, ...) have no fixed keys and are left out.
+func ConfigKeys() []string {
+	var keys []string
+	var walk func(prefix string, t reflect.Type)
+	walk = func(prefix string, t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("mapstructure"), ",")[0]
+			if name == "" || name == "-" {
+				continue
+			}
+			ft := field.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			switch {
+			case ft.Kind() == reflect.Map:
+			case ft.Kind() == reflect.Struct && !reflect.PointerTo(ft).Implements(textUnmarshalerType):
+				walk(prefix+name+".", ft)
+			default:
+				keys = append(keys, prefix+name)
+			}
+		}
+	}
+	walk("", reflect.TypeOf(AppConfig{}))
+	slices.Sort(keys)
+	return keys
+}
+
+// ConfigOverrides parses the --set KEY=VALUE values into config keys and
+// their values; a later value for a key wins. Unknown keys and values the
+// key's type does not accept are rejected. List values are separated by
+// commas.
+func (c *CommonFlags) ConfigOverrides() (map[string]string, error) {
+	if len(c.Set) == 0 {
+		return nil, nil
+	}
+	keys := ConfigKeys()
+	overrides := map[string]string{}
+	for _, raw := range c.Set {
+		key, value, ok := strings.Cut(raw, "=")
+		key = strings.ToLower(strings.TrimSpace(key))
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid --set %q (expected KEY=VALUE)", raw)
+		}
+		if !slices.Contains(keys, key) {
+			if hint := didYouMean(suggest(key, keys)); hint != "" {
+				return nil, fmt.Errorf("invalid --set %q: unknown config key %s%s", raw, key, hint)
+			}
+			return nil, fmt.Errorf("invalid --set %q: unknown config key %s (see `%s env` for the keys)", raw, key, appName)
+		}
+		cfg := defaultConfig()
+		if err := applyOverrides(&cfg, map[string]string{key: value}); err != nil {
+			// The decoder wraps the cause in a list naming the key.
+			if decodeErr := (*mapstructure.DecodeError)(nil); errors.As(err, &decodeErr) {
+				err = decodeErr.Unwrap()
+			}
+			return nil, fmt.Errorf("invalid --set %q: %w", raw, err)
+		}
+		overrides[key] = value
+	}
+	return overrides, nil
+}
+
+// applyOverrides decodes --set values over cfg the way the config file is
+// decoded.
+func applyOverrides(cfg *AppConfig, overrides map[string]string) error {
+	if len(overrides) == 0 {
+		return nil
+	}
+	table := map[string]any{}
+	for key, value := range overrides {
+		parts := strings.Split(key, ".")
+		node := table
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := node[part].(map[string]any)
+			if !ok {
+				child = map[string]any{}
+				node[part] = child
+			}
+			node = child
+		}
+		node[parts[len(parts)-1]] = value
+	}
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       configDecodeHook(),
+		WeaklyTypedInput: true,
+		Result:           cfg,
+	})
+	if err != nil {
+		return err
+	}
+	return decoder.Decode(table)
+}
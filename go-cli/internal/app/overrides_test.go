@@ -0,0 +1,40 @@
+package app_test
+
+import (
+	"os"
+	"testing"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app/apptest"
+)
+
+func TestConfigOverridesExitCode(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		file string
+		set  []string
+		want int
+	}{
+		{"unknown key", "", []string{"nope=1"}, app.ExitUsage},
+		{"value the type rejects", "", []string{"runtime.parallelism=abc"}, app.ExitUsage},
+		{"invalid value", "", []string{"logging.level=loud"}, app.ExitUsage},
+		{"invalid with the file", "", []string{"storage.backend=s3"}, app.ExitUsage},
+		{"valid with the file", "[storage.s3]\nbucket = \"b\"\n", []string{"storage.backend=s3"}, app.ExitOK},
+		{"invalid file", "[logging]\nlevel = \"loud\"\n", []string{"logging.format=json"}, app.ExitFailure},
+		{"invalid with the profile", "profile = \"ci\"\n[profiles.ci.storage]\nbackend = \"s3\"\ns3 = { bucket = \"b\" }\n", []string{"storage.s3.bucket="}, app.ExitUsage},
+		{"valid with a picked profile", "[profiles.ci.storage.s3]\nbucket = \"b\"\n", []string{"profile=ci", "storage.backend=s3"}, app.ExitOK},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := apptest.NewTestContext(t)
+			if tt.file != "" {
+				if err := os.WriteFile(tc.Paths.ConfigFile, []byte(tt.file), 0o600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			_, err := app.LoadOrInitConfig(tc.Paths, app.CommonFlags{Set: tt.set})
+			if got := app.ExitCode(err); got != tt.want {
+				t.Errorf("exit code = %d, want %d (err: %v)", got, tt.want, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,122 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+)
+
+// Parallelism is runtime.parallelism and --parallel: a fixed worker count,
+// a share of the CPUs such as "50%", or "auto" to start at the CPU count
+// and adapt to system load.
+type Parallelism struct {
+	Workers int
+	// Percent is the share of the CPUs, for values such as "50%".
+	Percent int
+	Auto    bool
+}
+
+// ParallelismAuto is the config value selecting adaptive parallelism.
+const ParallelismAuto = "auto"
+
+// String implements fmt.Stringer.
+func (p Parallelism) String() string {
+	switch {
+	case p.Auto:
+		return ParallelismAuto
+	case p.Percent > 0:
+		return strconv.Itoa(p.Percent) + "%"
+	}
+	return strconv.Itoa(p.Workers)
+}
+
+// Start returns the number of workers a run starts with on a machine with
+// cpus usable CPUs: the fixed count, the share of cpus rounded down but at
+// least one, or cpus for auto.
+func (p Parallelism) Start(cpus int) int {
+	switch {
+	case p.Auto:
+		return cpus
+	case p.Percent > 0:
+		return max(cpus*p.Percent/100, 1)
+	}
+	return p.Workers
+}
+
+// UnmarshalText accepts "auto", a positive integer, or a positive
+// percentage, as set through flags and environment variables.
+func (p *Parallelism) UnmarshalText(text []byte) error {
+	value := strings.TrimSpace(string(text))
+	if strings.EqualFold(value, ParallelismAuto) {
+		*p = Parallelism{Auto: true}
+		return nil
+	}
+	if percent, ok := strings.CutSuffix(value, "%"); ok {
+		n, err := strconv.Atoi(percent)
+		if err != nil || n < 1 {
+			return parallelismError(value)
+		}
+		*p = Parallelism{Percent: n}
+		return nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return parallelismError(value)
+	}
+	*p = Parallelism{Workers: n}
+	return nil
+}
+
+func parallelismError(value string) error {
+	return fmt.Errorf("invalid parallelism %q (expected a positive integer, a percentage of the CPUs such as 50%%, or %q)", value, ParallelismAuto)
+}
+
+// Set implements pflag.Value.
+func (p *Parallelism) Set(value string) error {
+	return p.UnmarshalText([]byte(value))
+}
+
+// Type implements pflag.Value.
+func (p *Parallelism) Type() string {
+	return "workers"
+}
+
+// MarshalJSON keeps fixed counts numeric in JSON output.
+func (p Parallelism) MarshalJSON() ([]byte, error) {
+	if p.Auto || p.Percent > 0 {
+		return json.Marshal(p.String())
+	}
+	return json.Marshal(p.Workers)
+}
+
+// MarshalYAML keeps fixed counts numeric in YAML output.
+func (p Parallelism) MarshalYAML() (any, error) {
+	if p.Auto || p.Percent > 0 {
+		return p.String(), nil
+	}
+	return p.Workers, nil
+}
+
+// parallelismHook decodes TOML integers into Parallelism; strings go
+// through UnmarshalText.
+func parallelismHook() mapstructure.DecodeHookFuncType {
+	target := reflect.TypeOf(Parallelism{})
+	return func(from, to reflect.Type, data any) (any, error) {
+		if to != target {
+			return data, nil
+		}
+		switch v := data.(type) {
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			n := reflect.ValueOf(v).Convert(reflect.TypeOf(int64(0))).Int()
+			if n < 1 {
+				return nil, parallelismError(strconv.FormatInt(n, 10))
+			}
+			return Parallelism{Workers: int(n)}, nil
+		}
+		return data, nil
+	}
+}
@@ -0,0 +1,259 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Parameter types.
+const (
+	ParamString = "string"
+	ParamInt    = "int"
+	ParamFloat  = "float"
+	ParamBool   = "bool"
+)
+
+// ParamSpec declares one parameter a task accepts through run --param.
+type ParamSpec struct {
+	// Type is string (the default), int, float, or bool.
+	Type        string `mapstructure:"type" json:"type,omitempty" yaml:"type,omitempty"`
+	Description string `mapstructure:"description" json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool   `mapstructure:"required" json:"required,omitempty" yaml:"required,omitempty"`
+	// Default is used when the parameter is not given; it must parse as
+	// Type. Ignored for required parameters.
+	Default any `mapstructure:"default" json:"default,omitempty" yaml:"default,omitempty"`
+	// Choices, when set, lists the accepted values.
+	Choices []string `mapstructure:"choices" json:"choices,omitempty" yaml:"choices,omitempty"`
+}
+
+// typ returns the declared type, defaulting to string.
+func (s ParamSpec) typ() string {
+	if s.Type == "" {
+		return ParamString
+	}
+	return s.Type
+}
+
+// zero is the value of an optional parameter that was not given and has
+// no default, so templates can still reference it.
+func (s ParamSpec) zero() any {
+	switch s.typ() {
+	case ParamInt:
+		return 0
+	case ParamFloat:
+		return 0.0
+	case ParamBool:
+		return false
+	}
+	return ""
+}
+
+// parse converts a raw value to the parameter's type and checks its choices.
+func (s ParamSpec) parse(raw string) (any, error) {
+	if len(s.Choices) > 0 && !slices.Contains(s.Choices, raw) {
+		return nil, fmt.Errorf("%q is not one of %s", raw, strings.Join(s.Choices, ", "))
+	}
+	switch s.typ() {
+	case ParamString:
+		return raw, nil
+	case ParamInt:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not an int", raw)
+		}
+		return n, nil
+	case ParamFloat:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a float", raw)
+		}
+		return f, nil
+	case ParamBool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%q is not a bool", raw)
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("unknown type %q", s.Type)
+}
+
+var paramName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+func validateParamSpecs(key string, specs map[string]ParamSpec) error {
+	names := make([]string, 0, len(specs))
+	for name := range specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		spec := specs[name]
+		if !paramName.MatchString(name) {
+			return fmt.Errorf("invalid %s.%s: parameter names use lowercase letters, digits, and _", key, name)
+		}
+		switch spec.typ() {
+		case ParamString, ParamInt, ParamFloat, ParamBool:
+		default:
+			return enumError(key+"."+name+".type", spec.Type, ParamString, ParamInt, ParamFloat, ParamBool)
+		}
+		for _, choice := range spec.Choices {
+			if _, err := (ParamSpec{Type: spec.Type}).parse(choice); err != nil {
+				return fmt.Errorf("invalid %s.%s.choices: %v", key, name, err)
+			}
+		}
+		if spec.Default != nil {
+			if _, err := spec.parse(fmt.Sprint(spec.Default)); err != nil {
+				return fmt.Errorf("invalid %s.%s.default: %v", key, name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Params are the validated parameters of the current run, keyed by name.
+// Values have the declared type: string, int, float64, or bool.
+type Params map[string]any
+
+// String returns the named parameter as a string, or "" when unset.
+func (p Params) String(name string) string {
+	if v, ok := p[name]; ok {
+		return fmt.Sprint(v)
+	}
+	return ""
+}
+
+// Int returns the named int parameter, or 0.
+func (p Params) Int(name string) int {
+	n, _ := p[name].(int)
+	return n
+}
+
+// Float returns the named float parameter, or 0.
+func (p Params) Float(name string) float64 {
+	f, _ := p[name].(float64)
+	return f
+}
+
+// Bool returns the named bool parameter, or false.
+func (p Params) Bool(name string) bool {
+	b, _ := p[name].(bool)
+	return b
+}
+
+// Environ returns the parameters as GO_CLI_PARAM_<NAME>=value pairs for
+// shell commands.
+func (p Params) Environ() []string {
+	names := make([]string, 0, len(p))
+	for name := range p {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	env := make([]string, 0, len(names))
+	for _, name := range names {
+		env = append(env, EnvPrefix()+"_PARAM_"+strings.ToUpper(name)+"="+p.String(name))
+	}
+	return env
+}
+
+// Expand substitutes {{.name}} references in text with parameter values.
+// Text without a template action is returned unchanged; referencing an
+// undeclared parameter is an error.
+func (p Params) Expand(text string) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	tmpl, err := template.New("cmd").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parse %q: %w", text, err)
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, map[string]any(p)); err != nil {
+		return "", fmt.Errorf("expand %q: %w", text, err)
+	}
+	return b.String(), nil
+}
+
+// ResolveParams checks raw NAME=VALUE arguments against schema and returns
+// typed values, with defaults filled in. Unknown or malformed parameters
+// and missing required ones are usage errors.
+func ResolveParams(task string, schema map[string]ParamSpec, raw []string) (Params, error) {
+	params := Params{}
+	for _, arg := range raw {
+		name, value, ok := strings.Cut(arg, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !ok || name == "" {
+			return nil, UsageError(fmt.Errorf("invalid --param %q (expected NAME=VALUE)", arg))
+		}
+		spec, declared := schema[name]
+		if !declared {
+			return nil, UsageError(fmt.Errorf("task %s has no parameter %q%s", task, name, paramList(schema)))
+		}
+		v, err := spec.parse(value)
+		if err != nil {
+			return nil, UsageError(fmt.Errorf("invalid --param %s: %v", name, err))
+		}
+		params[name] = v
+	}
+
+	var missing []string
+	for name, spec := range schema {
+		if _, set := params[name]; set {
+			continue
+		}
+		switch {
+		case spec.Required:
+			missing = append(missing, name)
+		case spec.Default != nil:
+			// Validated when the config was loaded.
+			params[name], _ = spec.parse(fmt.Sprint(spec.Default))
+		default:
+			params[name] = spec.zero()
+		}
+	}
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, UsageError(fmt.Errorf("task %s requires --param %s", task, strings.Join(missing, ", --param ")))
+	}
+	return params, nil
+}
+
+func paramList(schema map[string]ParamSpec) string {
+	if len(schema) == 0 {
+		return " (it takes none)"
+	}
+	names := make([]string, 0, len(schema))
+	for name := range schema {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return " (available: " + strings.Join(names, ", ") + ")"
+}
+
+// paramUsage renders a schema for task listings: name=<type>, with
+// optional parameters in brackets.
+func paramUsage(schema map[string]ParamSpec) []string {
+	names := make([]string, 0, len(schema))
+	for name := range schema {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	usage := make([]string, 0, len(names))
+	for _, name := range names {
+		spec := schema[name]
+		value := spec.typ()
+		if len(spec.Choices) > 0 {
+			value = strings.Join(spec.Choices, "|")
+		}
+		arg := name + "=<" + value + ">"
+		if !spec.Required {
+			arg = "[" + arg + "]"
+		}
+		usage = append(usage, arg)
+	}
+	return usage
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"runtime"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/container"
 )
 
 // AppPaths captures the resolved filesystem locations used by the CLI.
@@ -12,6 +14,9 @@ type AppPaths struct {
 	ConfigFile string
 	DataDir    string
 	StateDir   string
+	// CacheDir holds disposable data; see RuntimeContext.Cache. It is
+	// created on first use.
+	CacheDir string
 }
 
 // DiscoverPaths determines the config, data, and state directories for the application.
@@ -31,10 +36,16 @@ func DiscoverPaths(app string, override string) (AppPaths, error) {
 		return AppPaths{}, err
 	}
 
+	cacheDir, err := defaultCacheDir(app)
+	if err != nil {
+		return AppPaths{}, err
+	}
+
 	return AppPaths{
 		ConfigFile: configFile,
 		DataDir:    dataDir,
 		StateDir:   stateDir,
+		CacheDir:   cacheDir,
 	}, nil
 }
 
@@ -125,6 +136,10 @@ func defaultDataDir(app string) (string, error) {
 		return filepath.Join(dir, app), nil
 	}
 
+	if dir := containerDir(app, "data"); dir != "" {
+		return dir, nil
+	}
+
 	if runtime.GOOS == "windows" {
 		if dir := os.Getenv("LOCALAPPDATA"); dir != "" {
 			return filepath.Join(dir, app), nil
@@ -147,6 +162,10 @@ func defaultStateDir(app string) (string, error) {
 		return filepath.Join(dir, app), nil
 	}
 
+	if dir := containerDir(app, "state"); dir != "" {
+		return dir, nil
+	}
+
 	if runtime.GOOS == "windows" {
 		if dir := os.Getenv("LOCALAPPDATA"); dir != "" {
 			return filepath.Join(dir, app), nil
@@ -160,6 +179,21 @@ func defaultStateDir(app string) (string, error) {
 	return filepath.Join(home, ".local", "state", app), nil
 }
 
+// containerDir returns /var/lib/<app>/<kind> inside a container, so data and
+// state sit under one path that a single volume can hold, or "" elsewhere.
+// It needs the directory to exist (a mounted volume) or root to create it;
+// otherwise the home-based default applies.
+func containerDir(app, kind string) string {
+	if !container.Detect().Detected() {
+		return ""
+	}
+	root := filepath.Join("/var/lib", app)
+	if _, err := os.Stat(root); err != nil && os.Geteuid() != 0 {
+		return ""
+	}
+	return filepath.Join(root, kind)
+}
+
 func defaultCacheDir(app string) (string, error) {
 	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
 		return filepath.Join(dir, app), nil
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/prompt"
+)
+
+// DefaultTask is what `run` runs when no task is given and nobody can be
+// asked.
+const DefaultTask = "default"
+
+// PickTask chooses the task for `run` without a TASK argument: a fuzzy
+// picker over tasks on a terminal, DefaultTask under --yes or when stderr
+// is not a terminal, and a usage error listing the tasks in
+// non-interactive mode.
+func PickTask(ctx *RuntimeContext, tasks []TaskInfo) (string, error) {
+	p := ctx.Prompter()
+	if p.AssumeYes || !p.Interactive && !p.NonInteractive {
+		return DefaultTask, nil
+	}
+	choices := make([]prompt.Choice, 0, len(tasks))
+	names := make([]string, 0, len(tasks))
+	for _, t := range tasks {
+		choices = append(choices, prompt.Choice{Value: t.Name, Description: t.Description})
+		names = append(names, t.Name)
+	}
+	name, err := p.Fuzzy("Task to run:", choices, DefaultTask)
+	switch {
+	case errors.Is(err, prompt.ErrNonInteractive):
+		return "", UsageError(fmt.Errorf("no task given and prompting is disabled (%s); pass one of: %s, or --yes to run %q", p.Why(), strings.Join(names, ", "), DefaultTask))
+	case errors.Is(err, prompt.ErrAborted):
+		return "", errors.New("no task chosen")
+	}
+	return name, err
+}
+
+// PickProfile chooses the profile for a --profile given without a value:
+// a fuzzy picker over the defined profiles on a terminal, the active one
+// under --yes, and otherwise a usage error listing them.
+func PickProfile(ctx *RuntimeContext) (string, error) {
+	names := sortedKeys(ctx.Config.Profiles)
+	if len(names) == 0 {
+		return "", UsageError(errors.New("--profile needs a value; no profiles are defined (see `profile create`)"))
+	}
+	p := ctx.Prompter()
+	if !p.AssumeYes && (!p.Interactive || p.NonInteractive) {
+		return "", UsageError(fmt.Errorf("--profile needs a value when prompting is disabled; pass --profile NAME with one of: %s", strings.Join(names, ", ")))
+	}
+	choices := make([]prompt.Choice, 0, len(names))
+	def := ""
+	for _, name := range names {
+		var desc string
+		if sections := ctx.Config.Profiles[name]; len(sections) > 0 {
+			desc = "overrides " + strings.Join(sortedKeys(sections), ", ")
+		}
+		choices = append(choices, prompt.Choice{Value: name, Description: desc})
+		if name == ctx.Config.Profile {
+			def = name
+		}
+	}
+	name, err := p.Fuzzy("Profile:", choices, def)
+	if errors.Is(err, prompt.ErrAborted) {
+		return "", errors.New("no profile chosen")
+	}
+	return name, promptError(err, "--profile NAME")
+}
@@ -0,0 +1,132 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/runner"
+)
+
+// PlanCounts tallies planned actions by kind.
+type PlanCounts struct {
+	Create int `json:"create" yaml:"create"`
+	Update int `json:"update" yaml:"update"`
+	Delete int `json:"delete" yaml:"delete"`
+	Run    int `json:"run" yaml:"run"`
+}
+
+func countActions(steps []runner.Step) PlanCounts {
+	var counts PlanCounts
+	for _, step := range steps {
+		for _, action := range step.Actions {
+			switch action.Kind {
+			case runner.ActionCreate:
+				counts.Create++
+			case runner.ActionUpdate:
+				counts.Update++
+			case runner.ActionDelete:
+				counts.Delete++
+			default:
+				counts.Run++
+			}
+		}
+	}
+	return counts
+}
+
+// HandlePlan prints what running opts.Task would do, in execution order,
+// without executing anything. It backs `run --plan` and `run --dry-run`.
+func HandlePlan(ctx *RuntimeContext, opts RunOptions) error {
+	ctx.Config = ctx.Config.WithProfileOverride(opts.Profile)
+	profile := ctx.Config.Profile
+	ctx.Params, ctx.Input = opts.Params, opts.Input
+	defer func() { ctx.Params, ctx.Input = nil, nil }()
+
+	steps, err := runner.Plan(ctx, opts.Jobs)
+	if err != nil {
+		return err
+	}
+	counts := countActions(steps)
+
+	switch {
+	case ctx.Common.JSON || ctx.Common.YAML:
+		result := map[string]any{
+			"task":    opts.Task,
+			"profile": profile,
+			"steps":   steps,
+			"counts":  counts,
+		}
+		if ctx.Common.JSON {
+			data, err := json.MarshalIndent(result, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(ctx.Out.Writer(), string(data))
+		} else {
+			data, err := yaml.Marshal(result)
+			if err != nil {
+				return err
+			}
+			fmt.Fprint(ctx.Out.Writer(), string(data))
+		}
+	case ctx.Common.Porcelain:
+		for _, step := range steps {
+			for _, action := range step.Actions {
+				fmt.Fprintf(ctx.Out.Writer(), "%s\t%s\t%s\n", step.Job, action.Kind, action.Target)
+			}
+		}
+	default:
+		renderPlan(ctx, opts.Task, profile, steps, counts)
+	}
+	return nil
+}
+
+func renderPlan(ctx *RuntimeContext, task, profile string, steps []runner.Step, counts PlanCounts) {
+	out := ctx.Out
+	out.Printf(msgPlanHeader, out.Accent(ctx.Glyphs.Arrow), task, profile)
+	for _, step := range steps {
+		heading := "# " + step.Job
+		if len(step.Deps) > 0 {
+			heading += out.Dim(out.Sprintf(msgPlanAfter, strings.Join(step.Deps, ", ")))
+		}
+		fmt.Fprintf(out.Writer(), "\n  %s\n", out.Bold(heading))
+		for _, action := range step.Actions {
+			line := fmt.Sprintf("%s %-6s  %s", planSymbol(action.Kind), action.Kind, action.Target)
+			if action.Detail != "" {
+				line += out.Dim("  (" + action.Detail + ")")
+			}
+			fmt.Fprintf(out.Writer(), "  %s\n", paintAction(out, action.Kind, line))
+		}
+	}
+	out.Println()
+	out.Printf(msgPlanSummary, counts.Create, counts.Update, counts.Delete, counts.Run)
+}
+
+func planSymbol(kind string) string {
+	switch kind {
+	case runner.ActionCreate:
+		return "+"
+	case runner.ActionUpdate:
+		return "~"
+	case runner.ActionDelete:
+		return "-"
+	default:
+		return ">"
+	}
+}
+
+func paintAction(out Renderer, kind, line string) string {
+	switch kind {
+	case runner.ActionCreate:
+		return out.Green(line)
+	case runner.ActionUpdate:
+		return out.Yellow(line)
+	case runner.ActionDelete:
+		return out.Red(line)
+	default:
+		return line
+	}
+}
@@ -0,0 +1,212 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Plugins are executables named go-cli-<name> on PATH. `go-cli <name>`
+// runs one when no built-in command or alias has that name, in the style
+// of kubectl and git.
+
+// pluginPrefix is the executable name prefix that marks a plugin.
+func pluginPrefix() string {
+	return appName + "-"
+}
+
+// LookPlugin returns the executable for plugin name, if one is on PATH.
+func LookPlugin(name string) (string, bool) {
+	if name == "" || strings.ContainsAny(name, `/\`) {
+		return "", false
+	}
+	path, err := exec.LookPath(pluginPrefix() + name)
+	if err != nil {
+		return "", false
+	}
+	return path, true
+}
+
+// Plugin is an executable found on PATH.
+type Plugin struct {
+	Name string `json:"name" yaml:"name"`
+	Path string `json:"path" yaml:"path"`
+	// ShadowedBy is set when the plugin never runs: it names the built-in
+	// command or the earlier PATH entry that takes precedence.
+	ShadowedBy string `json:"shadowed_by,omitempty" yaml:"shadowed_by,omitempty"`
+}
+
+// DiscoverPlugins scans PATH in order for plugin executables. commands
+// holds the names of the built-in commands, which shadow plugins.
+func DiscoverPlugins(commands []string) []Plugin {
+	var plugins []Plugin
+	first := map[string]string{}
+	seenDirs := map[string]bool{}
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		if dir == "" || seenDirs[dir] {
+			continue
+		}
+		seenDirs[dir] = true
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name, ok := pluginName(entry.Name())
+			if !ok {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			if !isExecutable(path) {
+				continue
+			}
+			plugin := Plugin{Name: name, Path: path}
+			switch {
+			case slices.Contains(commands, name):
+				plugin.ShadowedBy = "built-in command " + name
+			case first[name] != "":
+				plugin.ShadowedBy = first[name]
+			default:
+				first[name] = path
+			}
+			plugins = append(plugins, plugin)
+		}
+	}
+	sort.SliceStable(plugins, func(i, j int) bool { return plugins[i].Name < plugins[j].Name })
+	return plugins
+}
+
+// pluginName returns the plugin name for an executable file name.
+func pluginName(file string) (string, bool) {
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(file))
+		if !slices.Contains(pathExts(), ext) {
+			return "", false
+		}
+		file = strings.TrimSuffix(file, filepath.Ext(file))
+	}
+	name, ok := strings.CutPrefix(file, pluginPrefix())
+	if !ok || name == "" {
+		return "", false
+	}
+	return name, true
+}
+
+func pathExts() []string {
+	exts := strings.Split(strings.ToLower(os.Getenv("PATHEXT")), ";")
+	if len(exts) == 1 && exts[0] == "" {
+		return []string{".com", ".exe", ".bat", ".cmd"}
+	}
+	return exts
+}
+
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || info.IsDir() {
+		return false
+	}
+	return runtime.GOOS == "windows" || info.Mode()&0o111 != 0
+}
+
+// pluginEnv describes the invocation to a plugin: where the config and
+// data live, which output format was requested, and a fresh run ID.
+func (rtx *RuntimeContext) pluginEnv() []string {
+	prefix := EnvPrefix() + "_"
+	output := "text"
+	switch {
+	case rtx.Common.JSON:
+		output = "json"
+	case rtx.Common.YAML:
+		output = "yaml"
+	case rtx.Common.Porcelain:
+		output = "porcelain"
+	}
+	dryRun := "0"
+	if rtx.Common.DryRun {
+		dryRun = "1"
+	}
+	return []string{
+		prefix + "CONFIG_FILE=" + rtx.Paths.ConfigFile,
+		prefix + "DATA_DIR=" + rtx.Paths.DataDir,
+		prefix + "STATE_DIR=" + rtx.Paths.StateDir,
+		prefix + "CACHE_DIR=" + rtx.Paths.CacheDir,
+		prefix + "OUTPUT=" + output,
+		prefix + "PROFILE=" + rtx.Config.Profile,
+		prefix + "DRY_RUN=" + dryRun,
+		prefix + "RUN_ID=" + NewRunID(rtx.Clock.Now()),
+	}
+}
+
+// RunPlugin runs the plugin executable at path with args, attached to the
+// terminal. The plugin inherits the full environment plus pluginEnv, and
+// its exit status becomes the CLI's.
+func RunPlugin(ctx *RuntimeContext, name, path string, args []string) error {
+	cmd := exec.CommandContext(ctx.Context, path, args...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	cmd.Env = append(os.Environ(), ctx.pluginEnv()...)
+	ctx.Logger.Debug("running plugin %s: %s", name, path)
+
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		return nil
+	case ctx.Context.Err() != nil:
+		return ctx.Context.Err()
+	case errors.As(err, &exitErr) && exitErr.ExitCode() > 0:
+		return WithExitCode(exitErr.ExitCode(), fmt.Errorf("plugin %s exited with status %d", name, exitErr.ExitCode()))
+	default:
+		return fmt.Errorf("run plugin %s: %w", name, err)
+	}
+}
+
+// HandlePluginList prints the plugins found on PATH. commands holds the
+// names of the built-in commands.
+func HandlePluginList(ctx *RuntimeContext, commands []string) error {
+	plugins := DiscoverPlugins(commands)
+
+	switch {
+	case ctx.Common.JSON:
+		data, err := json.MarshalIndent(plugins, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+	case ctx.Common.YAML:
+		data, err := yaml.Marshal(plugins)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		for _, p := range plugins {
+			if p.ShadowedBy == "" {
+				fmt.Fprintf(ctx.Out.Writer(), "%s\t%s\n", p.Name, p.Path)
+			}
+		}
+	default:
+		if len(plugins) == 0 {
+			ctx.Logger.Info("no plugins; install an executable named %s<name> on PATH", pluginPrefix())
+			return nil
+		}
+		rows := make([][]string, 0, len(plugins))
+		for _, p := range plugins {
+			path := p.Path
+			if p.ShadowedBy != "" {
+				path += " " + ctx.Out.Dim(ctx.Out.Sprintf(msgPluginShadowed, p.ShadowedBy))
+			}
+			rows = append(rows, []string{p.Name, path})
+		}
+		ctx.Out.Table("", headers(ctx.Out, msgColPlugin, msgColPath), rows)
+	}
+	return nil
+}
@@ -0,0 +1,529 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-viper/mapstructure/v2"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/viper"
+	yaml "gopkg.in/yaml.v3"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/textdiff"
+)
+
+// profileNamePattern limits profile names to TOML bare keys, so they can
+// appear unquoted in [profiles.NAME] headers.
+var profileNamePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// ValidateProfileName rejects names that cannot be a [profiles] table.
+func ValidateProfileName(name string) error {
+	if !profileNamePattern.MatchString(name) {
+		return UsageError(fmt.Errorf("invalid profile name %q: use letters, digits, - and _", name))
+	}
+	return nil
+}
+
+// validateProfiles checks that every [profiles] table applies cleanly to
+// cfg: known keys, valid values, and no nested profile selection.
+func validateProfiles(cfg AppConfig) error {
+	for _, name := range sortedKeys(cfg.Profiles) {
+		if !profileNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid profile name %q: use letters, digits, - and _", name)
+		}
+		for _, key := range []string{"profile", "profiles"} {
+			if _, ok := cfg.Profiles[name][key]; ok {
+				return fmt.Errorf("profiles.%s: %q cannot be set in a profile", name, key)
+			}
+		}
+		profiled, err := cfg.withProfile(name)
+		if err != nil {
+			return err
+		}
+		if err := profiled.Validate(); err != nil {
+			return fmt.Errorf("profiles.%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// withProfile returns cfg as loaded without any profile, then with the
+// [profiles] table of name applied. Name tables (tasks, aliases,
+// runtime.priority, ...) in the profile replace the top-level ones; other
+// tables are merged key by key.
+func (cfg AppConfig) withProfile(name string) (AppConfig, error) {
+	base := cfg
+	if cfg.unprofiled != nil {
+		base = *cfg.unprofiled
+	}
+	out := base
+	out.Profile = name
+	out.unprofiled = &base
+	overlay, ok := cfg.Profiles[name]
+	if !ok {
+		return out, nil
+	}
+
+	decoder, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook:       configDecodeHook(),
+		WeaklyTypedInput: true,
+		ZeroFields:       true,
+		ErrorUnused:      true,
+		Result:           &out,
+	})
+	if err != nil {
+		return AppConfig{}, err
+	}
+	if err := decoder.Decode(overlay); err != nil {
+		return AppConfig{}, fmt.Errorf("profiles.%s: %w", name, err)
+	}
+	if err := applyOverrides(&out, out.overrides); err != nil {
+		return AppConfig{}, err
+	}
+	if err := finishConfig(&out); err != nil {
+		return AppConfig{}, fmt.Errorf("profiles.%s: %w", name, err)
+	}
+	return out, nil
+}
+
+// LoadProfileNames reads the profile names from the config file for shell
+// completion, without creating the file or applying env overrides.
+func LoadProfileNames(configOverride string) []string {
+	paths, err := DiscoverPaths(appName, configOverride)
+	if err != nil {
+		return nil
+	}
+	v := viper.New()
+	v.SetConfigFile(paths.ConfigFile)
+	v.SetConfigType("toml")
+	if err := v.ReadInConfig(); err != nil {
+		return nil
+	}
+	names := sortedKeys(v.GetStringMap("profiles"))
+	if active := v.GetString("profile"); active != "" && !slices.Contains(names, active) {
+		names = append(names, active)
+	}
+	return names
+}
+
+// ProfileInfo describes a profile for `profile list`.
+type ProfileInfo struct {
+	Name   string `json:"name" yaml:"name"`
+	Active bool   `json:"active" yaml:"active"`
+	// Settings are the keys the profile's table overrides.
+	Settings []string `json:"settings" yaml:"settings"`
+}
+
+// HandleProfileList prints the profiles defined in [profiles] and the
+// active one, which may have no table.
+func HandleProfileList(ctx *RuntimeContext) error {
+	names := sortedKeys(ctx.Config.Profiles)
+	if !slices.Contains(names, ctx.Config.Profile) {
+		names = append(names, ctx.Config.Profile)
+		sort.Strings(names)
+	}
+	profiles := make([]ProfileInfo, 0, len(names))
+	for _, name := range names {
+		profiles = append(profiles, ProfileInfo{
+			Name:     name,
+			Active:   name == ctx.Config.Profile,
+			Settings: overlayKeys("", ctx.Config.Profiles[name]),
+		})
+	}
+
+	switch {
+	case ctx.Common.JSON:
+		data, err := json.MarshalIndent(profiles, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+	case ctx.Common.YAML:
+		data, err := yaml.Marshal(profiles)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		for _, p := range profiles {
+			fmt.Fprintf(ctx.Out.Writer(), "%s\t%t\n", p.Name, p.Active)
+		}
+	default:
+		rows := make([][]string, 0, len(profiles))
+		for _, p := range profiles {
+			marker := ""
+			if p.Active {
+				marker = ctx.Out.Accent("*")
+			}
+			settings := ctx.Out.Dim(ctx.Out.Text(msgNoOverrides))
+			if len(p.Settings) > 0 {
+				settings = strings.Join(p.Settings, ", ")
+			}
+			rows = append(rows, []string{marker, p.Name, settings})
+		}
+		ctx.Out.Table("", headers(ctx.Out, "", msgColProfile, msgColOverrides), rows)
+		if env := os.Getenv(EnvPrefix() + "_PROFILE"); env != "" {
+			ctx.Out.Println(ctx.Out.Dim(ctx.Out.Sprintf(msgProfileFromEnv, EnvPrefix(), env)))
+		}
+	}
+	return nil
+}
+
+// overlayKeys flattens a profile table into dotted keys.
+func overlayKeys(prefix string, table map[string]any) []string {
+	keys := []string{}
+	for _, name := range sortedKeys(table) {
+		if sub, ok := table[name].(map[string]any); ok && len(sub) > 0 {
+			keys = append(keys, overlayKeys(prefix+name+".", sub)...)
+			continue
+		}
+		keys = append(keys, prefix+name)
+	}
+	return keys
+}
+
+// ProfileCreateOptions configure `profile create`.
+type ProfileCreateOptions struct {
+	Name string
+	// From is an existing profile whose table is copied.
+	From string
+}
+
+// HandleProfileCreate adds a [profiles.NAME] table to the config file,
+// empty or copied from another profile.
+func HandleProfileCreate(ctx *RuntimeContext, opts ProfileCreateOptions) error {
+	if err := ValidateProfileName(opts.Name); err != nil {
+		return err
+	}
+	if _, ok := ctx.Config.Profiles[opts.Name]; ok {
+		return UsageError(fmt.Errorf("profile %s already exists", opts.Name))
+	}
+	current, err := readConfigFile(ctx.Paths.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	var section string
+	if opts.From != "" {
+		overlay, ok := ctx.Config.Profiles[opts.From]
+		if !ok {
+			return UsageError(fmt.Errorf("profile %s does not exist%s", opts.From, profileList(ctx.Config)))
+		}
+		if section, err = copyProfileSection(current, opts.From, opts.Name, overlay); err != nil {
+			return err
+		}
+	} else {
+		section = fmt.Sprintf("[profiles.%s]\n", opts.Name)
+	}
+
+	updated := strings.TrimRight(current, "\n")
+	if updated != "" {
+		updated += "\n\n"
+	}
+	updated += section
+	verb := "created profile " + opts.Name
+	if opts.From != "" {
+		verb += " from " + opts.From
+	}
+	return saveProfiles(ctx, current, updated, verb, func(profiles map[string]any) bool {
+		_, ok := profiles[opts.Name]
+		return ok
+	})
+}
+
+// copyProfileSection returns the [profiles.FROM] tables of content renamed
+// to TO, comments included. A profile defined without its own headers,
+// e.g. as an inline table, is re-encoded from overlay instead.
+func copyProfileSection(content, from, to string, overlay map[string]any) (string, error) {
+	var b strings.Builder
+	for _, block := range tomlBlocks(content) {
+		if block.path == nil || !isProfilePath(block.path, from) {
+			continue
+		}
+		lines := slices.Clone(block.lines)
+		lines[0] = renameHeader(lines[0], to)
+		b.WriteString(strings.TrimRight(strings.Join(lines, "\n"), "\n") + "\n\n")
+	}
+	if b.Len() > 0 {
+		return strings.TrimRight(b.String(), "\n") + "\n", nil
+	}
+
+	data, err := toml.Marshal(overlay)
+	if err != nil {
+		return "", fmt.Errorf("copy profile %s: %w", from, err)
+	}
+	var out strings.Builder
+	fmt.Fprintf(&out, "[profiles.%s]\n", to)
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "[["):
+			line = "[[profiles." + to + "." + strings.TrimPrefix(trimmed, "[[")
+		case strings.HasPrefix(trimmed, "["):
+			line = "[profiles." + to + "." + strings.TrimPrefix(trimmed, "[")
+		}
+		out.WriteString(line + "\n")
+	}
+	return out.String(), nil
+}
+
+// HandleProfileDelete removes the [profiles.NAME] tables from the config
+// file. The active profile cannot be deleted.
+func HandleProfileDelete(ctx *RuntimeContext, name string) error {
+	if _, ok := ctx.Config.Profiles[name]; !ok {
+		return UsageError(fmt.Errorf("profile %s does not exist%s", name, profileList(ctx.Config)))
+	}
+	if name == ctx.Config.Profile {
+		return UsageError(fmt.Errorf("profile %s is active; switch with `profile use` before deleting it", name))
+	}
+	current, err := readConfigFile(ctx.Paths.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	var kept []string
+	for _, block := range tomlBlocks(current) {
+		if block.path != nil && isProfilePath(block.path, name) {
+			continue
+		}
+		kept = append(kept, block.lines...)
+	}
+	updated := strings.Join(kept, "\n")
+	err = confirmDestructive(ctx, Destructive{
+		Action:   "profile delete",
+		Resource: name,
+		Question: fmt.Sprintf("Delete profile %s from %s?", name, ctx.Paths.ConfigFile),
+		HighRisk: true,
+	})
+	if err != nil {
+		return err
+	}
+	return saveProfiles(ctx, current, updated, "deleted profile "+name, func(profiles map[string]any) bool {
+		_, ok := profiles[name]
+		return !ok
+	})
+}
+
+// HandleProfileRename renames the [profiles.OLD] tables, and the active
+// profile when it is OLD.
+func HandleProfileRename(ctx *RuntimeContext, from, to string) error {
+	if err := ValidateProfileName(to); err != nil {
+		return err
+	}
+	if _, ok := ctx.Config.Profiles[from]; !ok {
+		return UsageError(fmt.Errorf("profile %s does not exist%s", from, profileList(ctx.Config)))
+	}
+	if _, ok := ctx.Config.Profiles[to]; ok {
+		return UsageError(fmt.Errorf("profile %s already exists", to))
+	}
+	current, err := readConfigFile(ctx.Paths.ConfigFile)
+	if err != nil {
+		return err
+	}
+
+	var lines []string
+	for _, block := range tomlBlocks(current) {
+		block.lines = slices.Clone(block.lines)
+		if block.path != nil && isProfilePath(block.path, from) {
+			block.lines[0] = renameHeader(block.lines[0], to)
+		}
+		lines = append(lines, block.lines...)
+	}
+	updated := strings.Join(lines, "\n")
+	if ctx.Config.Profile == from {
+		updated = setActiveProfile(updated, to)
+	}
+	return saveProfiles(ctx, current, updated, fmt.Sprintf("renamed profile %s to %s", from, to), func(profiles map[string]any) bool {
+		_, hasOld := profiles[from]
+		_, hasNew := profiles[to]
+		return !hasOld && hasNew
+	})
+}
+
+// HandleProfileUse makes name the active profile in the config file.
+func HandleProfileUse(ctx *RuntimeContext, name string) error {
+	if name == "" {
+		return UsageError(errors.New("profile name is required"))
+	}
+	if _, ok := ctx.Config.Profiles[name]; !ok && name != "default" {
+		ctx.Logger.Warn("profile %s has no [profiles.%s] table; it only labels runs", name, name)
+	}
+	current, err := readConfigFile(ctx.Paths.ConfigFile)
+	if err != nil {
+		return err
+	}
+	updated := setActiveProfile(current, name)
+	if err := saveProfiles(ctx, current, updated, "switched to profile "+name, nil); err != nil {
+		return err
+	}
+	if env := os.Getenv(EnvPrefix() + "_PROFILE"); env != "" && env != name {
+		ctx.Logger.Warn("%s_PROFILE=%s is set and takes precedence over the config file", EnvPrefix(), env)
+	}
+	return nil
+}
+
+// saveProfiles writes updated over the config file after checking that it
+// parses and that check accepts its [profiles] table. Under --dry-run it
+// prints the change instead.
+func saveProfiles(ctx *RuntimeContext, current, updated, done string, check func(profiles map[string]any) bool) error {
+	var parsed map[string]any
+	if err := toml.Unmarshal([]byte(updated), &parsed); err != nil {
+		return fmt.Errorf("editing %s would leave invalid TOML (%v); edit it by hand", ctx.Paths.ConfigFile, err)
+	}
+	profiles, _ := parsed["profiles"].(map[string]any)
+	if check != nil && !check(profiles) {
+		return fmt.Errorf("the [profiles] table in %s is not written as [profiles.NAME] sections; edit it by hand", ctx.Paths.ConfigFile)
+	}
+
+	if ctx.Common.DryRun {
+		ctx.Logger.Info("dry-run: would update %s", ctx.Paths.ConfigFile)
+		ctx.Out.Diff(textdiff.Unified(ctx.Paths.ConfigFile, ctx.Paths.ConfigFile, current, updated, textdiff.DefaultContext))
+		return nil
+	}
+	perm := os.FileMode(0o644)
+	if info, err := os.Stat(ctx.Paths.ConfigFile); err == nil {
+		perm = info.Mode().Perm()
+	}
+	if err := writeFileAtomic(ctx.Paths.ConfigFile, []byte(updated), perm); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	ctx.Logger.Info("%s in %s", done, ctx.Paths.ConfigFile)
+	return nil
+}
+
+func profileList(cfg AppConfig) string {
+	names := sortedKeys(cfg.Profiles)
+	if len(names) == 0 {
+		return "; none are defined"
+	}
+	return " (defined: " + strings.Join(names, ", ") + ")"
+}
+
+// tomlBlock is a table header line and the lines up to the next header.
+// The lines before the first header form a block with a nil path.
+type tomlBlock struct {
+	path  []string
+	lines []string
+}
+
+var tomlHeader = regexp.MustCompile(`^\s*\[\[?([^\[\]]+)\]\]?\s*(#.*)?$`)
+
+// tomlBlocks splits content at table headers. Joining the lines of every
+// block with "\n" gives content back.
+func tomlBlocks(content string) []tomlBlock {
+	blocks := []tomlBlock{{}}
+	inString := false
+	for _, line := range strings.Split(content, "\n") {
+		if m := tomlHeader.FindStringSubmatch(line); m != nil && !inString {
+			blocks = append(blocks, tomlBlock{path: splitKeyPath(m[1])})
+		}
+		last := &blocks[len(blocks)-1]
+		last.lines = append(last.lines, line)
+		// Multi-line strings may contain lines that look like headers.
+		if strings.Count(line, `"""`)%2 == 1 || strings.Count(line, "'''")%2 == 1 {
+			inString = !inString
+		}
+	}
+	return blocks
+}
+
+// splitKeyPath splits a dotted TOML key into its parts, unquoting them.
+func splitKeyPath(key string) []string {
+	var parts []string
+	var cur strings.Builder
+	quote := rune(0)
+	for _, r := range key {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+			cur.WriteRune(r)
+		case r == '"' || r == '\'':
+			quote = r
+			cur.WriteRune(r)
+		case r == '.':
+			parts = append(parts, unquoteKey(cur.String()))
+			cur.Reset()
+		default:
+			cur.WriteRune(r)
+		}
+	}
+	return append(parts, unquoteKey(cur.String()))
+}
+
+func unquoteKey(key string) string {
+	key = strings.TrimSpace(key)
+	if len(key) >= 2 && key[0] == '\'' && key[len(key)-1] == '\'' {
+		return key[1 : len(key)-1]
+	}
+	if unquoted, err := strconv.Unquote(key); err == nil {
+		return unquoted
+	}
+	return key
+}
+
+// isProfilePath reports whether path is [profiles.NAME] or one of its
+// subtables.
+func isProfilePath(path []string, name string) bool {
+	return len(path) >= 2 && path[0] == "profiles" && path[1] == name
+}
+
+// renameHeader rewrites a [profiles.OLD...] header line for profile to.
+func renameHeader(line, to string) string {
+	m := tomlHeader.FindStringSubmatchIndex(line)
+	path := splitKeyPath(line[m[2]:m[3]])
+	path[1] = to
+	return line[:m[2]] + strings.Join(path, ".") + line[m[3]:]
+}
+
+var activeProfileLine = regexp.MustCompile(`^(\s*profile\s*=\s*)("[^"]*"|'[^']*')(.*)$`)
+
+// setActiveProfile sets the top-level profile key in content, adding it
+// after the leading comments when it is missing.
+func setActiveProfile(content, name string) string {
+	lines := strings.Split(content, "\n")
+	value := strconv.Quote(name)
+	insert := -1
+	for i, line := range lines {
+		if tomlHeader.MatchString(line) {
+			break
+		}
+		if m := activeProfileLine.FindStringSubmatch(line); m != nil {
+			lines[i] = m[1] + value + m[3]
+			return strings.Join(lines, "\n")
+		}
+		trimmed := strings.TrimSpace(line)
+		if insert < 0 && trimmed != "" && !strings.HasPrefix(trimmed, "#") {
+			insert = i
+		}
+	}
+	if insert < 0 {
+		// Only comments and blank lines precede the first table, if any.
+		insert = 0
+		for insert < len(lines) && strings.HasPrefix(strings.TrimSpace(lines[insert]), "#") {
+			insert++
+		}
+	}
+	entry := []string{"profile = " + value}
+	if insert < len(lines) && tomlHeader.MatchString(lines[insert]) {
+		entry = append(entry, "")
+	}
+	return strings.Join(slices.Concat(lines[:insert], entry, lines[insert:]), "\n")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
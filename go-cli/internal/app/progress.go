@@ -0,0 +1,64 @@
+package app
+
+import (
+	"fmt"
+	"os"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/progress"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/runner"
+)
+
+// ProgressEnabled reports whether progress indicators should be drawn: never
+// with --no-progress or --quiet, and only when stdout is a terminal.
+func (rtx *RuntimeContext) ProgressEnabled() bool {
+	return !rtx.Common.NoProgress && !rtx.Common.Quiet && isTerminal(os.Stdout)
+}
+
+// ProgressStyle returns the progress glyphs for the active glyph set.
+func (rtx *RuntimeContext) ProgressStyle() progress.Style {
+	return progress.Style{
+		Frames:    rtx.Glyphs.Spinner,
+		BarFilled: rtx.Glyphs.Bar.Filled,
+		BarEmpty:  rtx.Glyphs.Bar.Empty,
+	}
+}
+
+// Accessible reports whether progress is reported as plain periodic lines,
+// which screen readers can follow, instead of redrawn spinners and bars:
+// with output.accessible, or when TERM is dumb or ACCESSIBLE is set, the
+// convention terminal UI libraries use for screen reader users.
+func (rtx *RuntimeContext) Accessible() bool {
+	return rtx.Config.Output.Accessible || os.Getenv("TERM") == "dumb" || os.Getenv("ACCESSIBLE") != ""
+}
+
+// NewTracker returns a multi-task progress tracker drawn on stderr.
+func (rtx *RuntimeContext) NewTracker(total int) *progress.Tracker {
+	tracker := progress.NewTracker(os.Stderr, total, rtx.ProgressStyle(), rtx.ProgressEnabled())
+	tracker.Plain(rtx.Accessible())
+	return tracker
+}
+
+// NewSpinner returns a spinner drawn on stderr.
+func (rtx *RuntimeContext) NewSpinner(message string) *progress.Spinner {
+	spinner := progress.NewSpinner(os.Stderr, message, rtx.ProgressStyle(), rtx.ProgressEnabled())
+	spinner.Plain(rtx.Accessible())
+	return spinner
+}
+
+// trackerObserver forwards runner events to a progress tracker.
+type trackerObserver struct {
+	tracker *progress.Tracker
+}
+
+func (o trackerObserver) JobStarted(name string) {
+	o.tracker.TaskStarted(name)
+}
+
+// JobRetrying shows a pending retry in the live status region.
+func (o trackerObserver) JobRetrying(name string, attempt int) {
+	o.tracker.TaskState(name, fmt.Sprintf("retrying (attempt %d)", attempt))
+}
+
+func (o trackerObserver) JobFinished(result runner.Result) {
+	o.tracker.TaskFinished(result.Name)
+}
@@ -0,0 +1,31 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/prompt"
+)
+
+// Prompter returns a prompter honoring --yes and --non-interactive, which
+// is implied in CI and when stdin is not a terminal. Questions go to stderr
+// so they never mix with machine-readable output.
+func (rtx *RuntimeContext) Prompter() *prompt.Prompter {
+	return prompt.New(rtx.Common.AssumeYes, rtx.Common.NonInteractive)
+}
+
+// confirm asks a yes/no question that defaults to no, as destructive
+// commands do. answer names the flag that answers it without a prompt.
+func confirm(ctx *RuntimeContext, question, answer string) (bool, error) {
+	ok, err := ctx.Prompter().Confirm(question, false)
+	return ok, promptError(err, answer)
+}
+
+// promptError makes a question refused in non-interactive mode a usage
+// error naming answer, the flag or argument that answers it.
+func promptError(err error, answer string) error {
+	if errors.Is(err, prompt.ErrNonInteractive) {
+		return UsageError(fmt.Errorf("%w; pass %s", err, answer))
+	}
+	return err
+}
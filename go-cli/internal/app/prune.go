@@ -0,0 +1,276 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/humanize"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/statestore"
+)
+
+// What `prune` can remove.
+const (
+	PruneLogs      = "logs"
+	PruneHistory   = "history"
+	PruneArtifacts = "artifacts"
+	PruneAll       = "all"
+)
+
+// PruneKinds lists the values of `prune --what`.
+var PruneKinds = []string{PruneLogs, PruneHistory, PruneArtifacts, PruneAll}
+
+// PruneOptions configure `prune`. Nil limits fall back to [retention].
+type PruneOptions struct {
+	OlderThan *time.Duration
+	KeepLast  *int
+	// What is one of PruneKinds; empty means all.
+	What string
+}
+
+// PrunedItem is a task log directory, history entry, or run directory
+// that `prune` removes.
+type PrunedItem struct {
+	Kind    string    `json:"kind" yaml:"kind"`
+	ID      string    `json:"id" yaml:"id"`
+	Started time.Time `json:"started" yaml:"started"`
+	Bytes   int64     `json:"bytes,omitempty" yaml:"bytes,omitempty"`
+	path    string
+}
+
+// HandlePrune removes task logs, history entries, and run artifacts past
+// the retention limits: an item goes once it is older than the age limit
+// and not among the newest KeepLast of its kind. With only KeepLast set,
+// everything but the newest KeepLast goes. Under --dry-run the items are
+// listed instead.
+func HandlePrune(ctx *RuntimeContext, opts PruneOptions) error {
+	what := opts.What
+	if what == "" {
+		what = PruneAll
+	}
+	if !slices.Contains(PruneKinds, what) {
+		return UsageError(fmt.Errorf("invalid --what %q (expected %s)", what, strings.Join(PruneKinds, ", ")))
+	}
+	maxAge := ctx.Config.Retention.MaxAge.Std()
+	if opts.OlderThan != nil {
+		maxAge = *opts.OlderThan
+	}
+	keep := ctx.Config.Retention.KeepLast
+	if opts.KeepLast != nil {
+		keep = *opts.KeepLast
+	}
+	if maxAge < 0 || keep < 0 {
+		return UsageError(errors.New("--older-than and --keep-last must not be negative"))
+	}
+	if maxAge == 0 && keep == 0 {
+		return UsageError(errors.New("no retention limit: pass --older-than or --keep-last, or set retention.max_age or retention.keep_last"))
+	}
+
+	var cutoff time.Time
+	if maxAge > 0 {
+		cutoff = ctx.Clock.Now().Add(-maxAge)
+	}
+	var items []PrunedItem
+	for _, kind := range PruneKinds[:3] {
+		if what != PruneAll && what != kind {
+			continue
+		}
+		candidates, err := pruneCandidates(ctx, kind)
+		if err != nil {
+			return err
+		}
+		items = append(items, expired(candidates, cutoff, keep)...)
+	}
+
+	if !ctx.Common.DryRun && len(items) > 0 {
+		resource := humanize.Plural(len(items), "item", "items")
+		err := confirmDestructive(ctx, Destructive{
+			Action:   "prune",
+			Resource: resource,
+			Question: fmt.Sprintf("Remove %s (%s)? Run with --dry-run to list them.", resource, pruneLimits(maxAge, keep)),
+		})
+		if err != nil {
+			return err
+		}
+		if err := removePruned(ctx, items); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case ctx.Common.JSON:
+		data, err := json.MarshalIndent(items, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+	case ctx.Common.YAML:
+		data, err := yaml.Marshal(items)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		for _, item := range items {
+			fmt.Fprintf(ctx.Out.Writer(), "%s\t%s\n", item.Kind, item.ID)
+		}
+	default:
+		limits := pruneLimits(maxAge, keep)
+		if len(items) == 0 {
+			ctx.Logger.Info("nothing to prune (%s)", limits)
+			return nil
+		}
+		if ctx.Common.DryRun {
+			now := ctx.Clock.Now()
+			rows := make([][]string, 0, len(items))
+			for _, item := range items {
+				size := ""
+				if item.Kind != PruneHistory {
+					size = humanize.Bytes(item.Bytes)
+				}
+				rows = append(rows, []string{item.Kind, item.ID, humanize.RelTime(item.Started, now), size})
+			}
+			ctx.Out.Table("", headers(ctx.Out, msgColKind, msgColID, msgColStarted, msgColSize), rows)
+		}
+		counts := map[string]int{}
+		var freed int64
+		for _, item := range items {
+			counts[item.Kind]++
+			freed += item.Bytes
+		}
+		verb := "removed"
+		if ctx.Common.DryRun {
+			verb = "dry-run: would remove"
+		}
+		ctx.Logger.Info("%s task logs of %s, %s, and %s (%s; %s)", verb,
+			humanize.Plural(counts[PruneLogs], "run", "runs"),
+			humanize.Plural(counts[PruneHistory], "history entry", "history entries"),
+			humanize.Plural(counts[PruneArtifacts], "run directory", "run directories"),
+			humanize.Bytes(freed), limits)
+	}
+	return nil
+}
+
+// pruneLimits describes the limits in force, for messages.
+func pruneLimits(maxAge time.Duration, keep int) string {
+	var parts []string
+	if maxAge > 0 {
+		parts = append(parts, "older than "+humanize.Duration(maxAge))
+	}
+	if keep > 0 {
+		parts = append(parts, fmt.Sprintf("keeping the newest %d", keep))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// expired picks the items past the limits from candidates, which are
+// sorted newest first. A zero cutoff disables the age limit.
+func expired(candidates []PrunedItem, cutoff time.Time, keep int) []PrunedItem {
+	var out []PrunedItem
+	for i, item := range candidates {
+		if i < keep {
+			continue
+		}
+		if !cutoff.IsZero() && !item.Started.Before(cutoff) {
+			continue
+		}
+		out = append(out, item)
+	}
+	return out
+}
+
+// pruneCandidates lists everything of kind, newest first.
+func pruneCandidates(ctx *RuntimeContext, kind string) ([]PrunedItem, error) {
+	var items []PrunedItem
+	switch kind {
+	case PruneLogs:
+		dir := taskLogsDir(ctx.Paths.StateDir)
+		entries, err := os.ReadDir(dir)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("read task logs: %w", err)
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			path := filepath.Join(dir, entry.Name())
+			started, ok := runIDTime(entry.Name())
+			if !ok {
+				info, err := entry.Info()
+				if err != nil {
+					continue
+				}
+				started = info.ModTime()
+			}
+			usage, _ := cacheUsage(path)
+			items = append(items, PrunedItem{Kind: kind, ID: entry.Name(), Started: started, Bytes: usage.Bytes, path: path})
+		}
+	case PruneHistory:
+		entries, err := loadHistory(ctx, statestore.Query{})
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			items = append(items, PrunedItem{Kind: kind, ID: e.ID, Started: e.Started})
+		}
+	case PruneArtifacts:
+		runs, err := loadRuns(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range runs {
+			path := filepath.Join(runsDir(ctx.Paths.DataDir), r.RunID)
+			usage, _ := cacheUsage(path)
+			items = append(items, PrunedItem{Kind: kind, ID: r.RunID, Started: r.Started, Bytes: usage.Bytes, path: path})
+		}
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].Started.After(items[j].Started) })
+	return items, nil
+}
+
+// removePruned deletes items: directories for logs and artifacts, and the
+// history entries in one transaction.
+func removePruned(ctx *RuntimeContext, items []PrunedItem) error {
+	history := map[string]bool{}
+	for _, item := range items {
+		if item.Kind == PruneHistory {
+			history[item.ID] = true
+			continue
+		}
+		if err := os.RemoveAll(item.path); err != nil {
+			return fmt.Errorf("remove %s %s: %w", item.Kind, item.ID, err)
+		}
+	}
+	if len(history) == 0 {
+		return nil
+	}
+
+	store, err := ctx.stateStore()
+	if err != nil {
+		return err
+	}
+	ids := make([]string, 0, len(history))
+	for id := range history {
+		ids = append(ids, id)
+	}
+	if err := store.DeleteRuns(ctx, ids); err != nil {
+		return fmt.Errorf("remove history entries: %w", err)
+	}
+	return nil
+}
+
+// runIDTime reads the start time encoded in a run ID.
+func runIDTime(id string) (time.Time, bool) {
+	stamp, _, _ := strings.Cut(id, "-")
+	t, err := time.Parse("20060102T150405Z", stamp)
+	return t, err == nil
+}
@@ -0,0 +1,128 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/remote"
+)
+
+// RemoteConfig declares a host `run --on` runs command tasks on over SSH.
+type RemoteConfig struct {
+	Host string `mapstructure:"host" json:"host" yaml:"host"`
+	// User defaults to the local user name.
+	User string `mapstructure:"user" json:"user,omitempty" yaml:"user,omitempty"`
+	// Port defaults to 22.
+	Port int `mapstructure:"port" json:"port,omitempty" yaml:"port,omitempty"`
+	// IdentityFile is a private key tried after the SSH agent; empty tries
+	// the default keys in ~/.ssh.
+	IdentityFile string `mapstructure:"identity_file" json:"identity_file,omitempty" yaml:"identity_file,omitempty"`
+	// KnownHosts is checked for the host key; empty means
+	// ~/.ssh/known_hosts.
+	KnownHosts string `mapstructure:"known_hosts" json:"known_hosts,omitempty" yaml:"known_hosts,omitempty"`
+	// Dir is the directory tasks run in, and relative task dirs are
+	// resolved against; empty means the login directory.
+	Dir string `mapstructure:"dir" json:"dir,omitempty" yaml:"dir,omitempty"`
+	// Tags group remotes so `run --on TAG` selects all of them.
+	Tags []string `mapstructure:"tags" json:"tags,omitempty" yaml:"tags,omitempty"`
+}
+
+// Remote is a configured remote selected by run --on.
+type Remote struct {
+	Name string
+	RemoteConfig
+}
+
+// SSH returns the connection settings for the remote.
+func (r Remote) SSH() remote.Config {
+	return remote.Config{
+		Host:         r.Host,
+		Port:         r.Port,
+		User:         r.User,
+		IdentityFile: r.IdentityFile,
+		KnownHosts:   r.KnownHosts,
+	}
+}
+
+func validateRemotes(remotes map[string]RemoteConfig) error {
+	names := make([]string, 0, len(remotes))
+	for name := range remotes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		r := remotes[name]
+		if strings.ContainsAny(name, ", \t") {
+			return fmt.Errorf("invalid remote name %q (must not contain commas or spaces)", name)
+		}
+		if r.Host == "" {
+			return fmt.Errorf("invalid remotes.%s.host (required)", name)
+		}
+		if r.Port < 0 || r.Port > 65535 {
+			return fmt.Errorf("invalid remotes.%s.port %d (expected 1-65535)", name, r.Port)
+		}
+		for _, tag := range r.Tags {
+			if tag == "" || strings.ContainsAny(tag, ", \t") {
+				return fmt.Errorf("invalid remotes.%s.tags entry %q (expected a word without commas)", name, tag)
+			}
+		}
+	}
+	return nil
+}
+
+// SelectRemotes resolves run --on values, each a remote name or a tag, to
+// the remotes they name, sorted by name and without duplicates.
+func (cfg AppConfig) SelectRemotes(selectors []string) ([]Remote, error) {
+	selected := map[string]bool{}
+	for _, sel := range selectors {
+		sel = strings.ToLower(sel)
+		if _, ok := cfg.Remotes[sel]; ok {
+			selected[sel] = true
+			continue
+		}
+		found := false
+		for name, r := range cfg.Remotes {
+			if slices.ContainsFunc(r.Tags, func(tag string) bool { return strings.EqualFold(tag, sel) }) {
+				selected[name], found = true, true
+			}
+		}
+		if !found {
+			return nil, UsageError(fmt.Errorf("unknown remote or tag %q (see [remotes] in the config)", sel))
+		}
+	}
+	remotes := make([]Remote, 0, len(selected))
+	for name := range selected {
+		remotes = append(remotes, Remote{Name: name, RemoteConfig: cfg.Remotes[name]})
+	}
+	sort.Slice(remotes, func(i, j int) bool { return remotes[i].Name < remotes[j].Name })
+	return remotes, nil
+}
+
+// LoadRemoteSelectors reads the remote names and tags from the config file
+// alone, for shell completion of run --on.
+func LoadRemoteSelectors(configOverride string) []string {
+	paths, err := DiscoverPaths(appName, configOverride)
+	if err != nil {
+		return nil
+	}
+	v := viper.New()
+	v.SetConfigFile(paths.ConfigFile)
+	v.SetConfigType("toml")
+	if err := v.ReadInConfig(); err != nil {
+		return nil
+	}
+	remotes := v.GetStringMap("remotes")
+	selectors := sortedKeys(remotes)
+	for _, name := range sortedKeys(remotes) {
+		for _, tag := range v.GetStringSlice("remotes." + name + ".tags") {
+			if !slices.Contains(selectors, tag) {
+				selectors = append(selectors, tag)
+			}
+		}
+	}
+	return selectors
+}
@@ -0,0 +1,193 @@
+package app
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Formats accepted by run --report.
+const ReportJUnit = "junit"
+
+// reportLogTail bounds how much of a failed task's log a report embeds.
+const reportLogTail = 64 << 10
+
+// ReportSpec is one run --report FORMAT=PATH.
+type ReportSpec struct {
+	Format string
+	Path   string
+}
+
+// ParseReports parses run --report values.
+func ParseReports(values []string) ([]ReportSpec, error) {
+	specs := make([]ReportSpec, 0, len(values))
+	for _, v := range values {
+		format, path, ok := strings.Cut(v, "=")
+		if !ok || path == "" {
+			return nil, fmt.Errorf("invalid --report %q (expected FORMAT=PATH, e.g. junit=report.xml)", v)
+		}
+		if format != ReportJUnit {
+			return nil, fmt.Errorf("invalid --report format %q (expected %s)", format, ReportJUnit)
+		}
+		specs = append(specs, ReportSpec{Format: format, Path: path})
+	}
+	return specs, nil
+}
+
+// runReport is what a report describes: one run and its task results.
+type runReport struct {
+	RunID    string
+	Task     string
+	Profile  string
+	Started  time.Time
+	Duration time.Duration
+	Results  []TaskResult
+	logs     *taskLogs
+}
+
+// writeReports writes every requested report, continuing past failures.
+func writeReports(specs []ReportSpec, report runReport) error {
+	var errs []error
+	for _, spec := range specs {
+		data, err := junitReport(report)
+		if err == nil {
+			if err = os.MkdirAll(filepath.Dir(spec.Path), 0o755); err == nil {
+				err = writeFileAtomic(spec.Path, data, 0o644)
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("write %s report %s: %w", spec.Format, spec.Path, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// JUnit XML as read by GitLab, Jenkins, GitHub Actions reporters, and
+// Azure Pipelines: the run is one test suite and every task a test case.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name       string          `xml:"name,attr"`
+	Tests      int             `xml:"tests,attr"`
+	Failures   int             `xml:"failures,attr"`
+	Errors     int             `xml:"errors,attr"`
+	Skipped    int             `xml:"skipped,attr"`
+	Time       string          `xml:"time,attr"`
+	Timestamp  string          `xml:"timestamp,attr"`
+	Hostname   string          `xml:"hostname,attr,omitempty"`
+	Properties []junitProperty `xml:"properties>property"`
+	Cases      []junitTestCase `xml:"testcase"`
+}
+
+type junitProperty struct {
+	Name  string `xml:"name,attr"`
+	Value string `xml:"value,attr"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitMessage `xml:"failure,omitempty"`
+	Skipped   *junitMessage `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitMessage struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Text    string `xml:",chardata"`
+}
+
+func junitReport(r runReport) ([]byte, error) {
+	host, _ := os.Hostname()
+	suite := junitTestSuite{
+		Name:      r.Task,
+		Time:      junitSeconds(r.Duration),
+		Timestamp: r.Started.UTC().Format("2006-01-02T15:04:05"),
+		Hostname:  host,
+		Properties: []junitProperty{
+			{Name: "run_id", Value: r.RunID},
+			{Name: "profile", Value: r.Profile},
+		},
+	}
+	for _, res := range r.Results {
+		tc := junitTestCase{
+			Name:      res.Name,
+			Classname: appName + "." + r.Task,
+			Time:      junitSeconds(res.Duration),
+		}
+		switch res.Status {
+		case TaskFailed:
+			suite.Failures++
+			msg := "failed"
+			if res.Err != nil {
+				msg = res.Err.Error()
+			}
+			first, _, _ := strings.Cut(msg, "\n")
+			tc.Failure = &junitMessage{Message: first, Type: "error", Text: fmt.Sprintf("%s\nattempts: %d", msg, res.Attempts)}
+			if path := r.logs.existing(res.Name); path != "" {
+				tc.SystemOut = logTail(path)
+			}
+		case TaskSkipped:
+			suite.Skipped++
+			tc.Skipped = &junitMessage{Message: "not run: a dependency failed, the run stopped early, or an earlier run completed it"}
+		case TaskUpToDate:
+			suite.Skipped++
+			tc.Skipped = &junitMessage{Message: "up to date: inputs unchanged since the last successful run"}
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, tc)
+	}
+	suites := junitTestSuites{
+		Name:     appName,
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}
+	data, err := xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), append(data, '\n')...), nil
+}
+
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// logTail returns the end of the log at path, at most reportLogTail bytes.
+func logTail(path string) string {
+	f, err := os.Open(path)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	if info, err := f.Stat(); err == nil && info.Size() > reportLogTail {
+		_, _ = f.Seek(-reportLogTail, io.SeekEnd)
+	}
+	data, _ := io.ReadAll(f)
+	if len(data) == reportLogTail {
+		// Drop the partial first line.
+		if i := bytes.IndexByte(data, '\n'); i >= 0 {
+			data = data[i+1:]
+		}
+	}
+	return string(data)
+}
@@ -0,0 +1,486 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	yaml "gopkg.in/yaml.v3"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/humanize"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/runner"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/stdinutil"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/tracing"
+)
+
+// RunOptions configure the run command behaviour.
+type RunOptions struct {
+	Task    string
+	Profile string
+	// Jobs is the work executed for Task, normally built from the task
+	// registry by the command layer.
+	Jobs []runner.Job
+	// Resume skips tasks recorded as completed by an interrupted run.
+	Resume bool
+	// FromScratch discards any checkpoint before running.
+	FromScratch bool
+	// Plan prints the actions the run would take instead of executing it.
+	// --dry-run implies it.
+	Plan bool
+	// Flags are the command-line flags the user set, recorded in history.
+	Flags []string
+	// Params are the validated --param values, available to tasks as
+	// RuntimeContext.Params while the run lasts.
+	Params Params
+	// Priority overrides the queue priority of the named tasks, on top of
+	// runtime.priority (run --priority NAME=N).
+	Priority map[string]int
+	// Force runs tasks even when their inputs are unchanged.
+	Force bool
+	// Chaos is the percentage of task attempts to fail or delay on purpose
+	// (hidden run --chaos, GO_CLI_CHAOS), for exercising retries, fail_fast,
+	// and failure reporting.
+	Chaos int
+	// Stats prints per-task timings and worker utilization after the run.
+	Stats bool
+	// Stream, when set, replaces Jobs: job specs are read from it (run
+	// --stdin) and fed to the workers as they arrive. Checkpoints do not
+	// apply to streamed runs.
+	Stream io.Reader
+	// StreamJob builds the job for each spec read from Stream.
+	StreamJob StreamJobFunc
+	// RunID names the run; empty generates one from the start time. serve
+	// sets it so clients can poll the run before it finishes.
+	RunID string
+	// Reports are written after the run, whether it succeeded or not
+	// (run --report FORMAT=PATH).
+	Reports []ReportSpec
+	// KeepGoing runs every job even when runtime.fail_fast is set, for
+	// independent jobs such as one per host with run --on.
+	KeepGoing bool
+	// Input is the payload piped to the run (see ReadInput), available to
+	// tasks as RuntimeContext.Input while the run lasts.
+	Input *stdinutil.Payload
+	// Notify overrides notifications.desktop for this run (run --notify).
+	Notify *bool
+	// Observer, when set, is also notified as jobs start and finish, e.g.
+	// to draw progress in the tui.
+	Observer runner.Observer
+}
+
+// adaptiveSampleInterval spaces system load samples under
+// runtime.parallelism = "auto"; the load average moves slowly anyway.
+const adaptiveSampleInterval = 5 * time.Second
+
+// TaskInfo describes a registered task for listings.
+type TaskInfo struct {
+	Name         string               `json:"name" yaml:"name"`
+	Description  string               `json:"description" yaml:"description"`
+	Dependencies []string             `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+	Params       map[string]ParamSpec `json:"params,omitempty" yaml:"params,omitempty"`
+	// Input is the format of the payload the task reads from stdin, if any.
+	Input string `json:"input,omitempty" yaml:"input,omitempty"`
+}
+
+// HandleRun executes the run command, or prints its plan under --plan and
+// --dry-run.
+func HandleRun(ctx *RuntimeContext, opts RunOptions) error {
+	if opts.Plan || ctx.Common.DryRun {
+		return HandlePlan(ctx, opts)
+	}
+
+	started := ctx.Clock.Now()
+	runID := opts.RunID
+	if runID == "" {
+		runID = NewRunID(started)
+	}
+	ctx.Params, ctx.Input, ctx.Git = opts.Params, opts.Input, detectGit(ctx)
+	defer func() { ctx.Params, ctx.Input, ctx.Git = nil, nil, nil }()
+	env := hookEnv{RunID: runID, Task: opts.Task, Profile: ctx.Config.WithProfileOverride(opts.Profile).Profile}
+
+	checkDataDir(ctx)
+	// Hooks run inside the artifacts scope so they can add to or ship the
+	// run's outputs.
+	artifacts, err := openArtifacts(ctx, RunManifest{RunID: runID, Task: opts.Task, Profile: env.Profile, Started: started.UTC(), Git: ctx.Git})
+	if err != nil {
+		ctx.Logger.Warn("no artifacts directory for this run: %v", err)
+	}
+	ctx.artifacts = artifacts
+	defer func() { ctx.artifacts = nil }()
+
+	var metrics *RunMetrics
+	err = runPreHooks(ctx, env)
+	if err == nil {
+		metrics, err = runTask(ctx, opts, runID)
+	}
+
+	code := ExitCode(err)
+	env.Status, env.ExitCode = runStatus(ctx, err), &code
+	if hookErr := runPostHooks(ctx, env); hookErr != nil && err == nil {
+		err = hookErr
+	}
+
+	if artifacts != nil {
+		if ferr := artifacts.finish(runStatus(ctx, err), ctx.Clock.Now()); ferr != nil {
+			ctx.Logger.Warn("could not write run manifest: %v", ferr)
+		}
+	}
+	entry := recordHistory(ctx, opts, runID, started, metrics, err)
+	persistRun(ctx, artifacts, entry)
+	notifyRun(ctx, opts, entry)
+	ctx.CopyPrimary("run ID", runID)
+	return err
+}
+
+// runTask performs one run; HandleRun wraps it with history recording. The
+// metrics are nil when the run failed before any task was scheduled.
+func runTask(ctx *RuntimeContext, opts RunOptions, runID string) (*RunMetrics, error) {
+	// Tasks read ctx.Config, so the profile override must be visible to them.
+	ctx.Config = ctx.Config.WithProfileOverride(opts.Profile)
+	runCfg := ctx.Config.RunConfig()
+
+	// --parallel wins over runtime.parallelism; "auto" starts at the CPU
+	// count and lets the scaler shrink the pool under load.
+	parallelism := defaultParallelism()
+	var scaler runner.Scaler
+	configured := runCfg.Runtime.Parallelism
+	if ctx.Common.Parallelism != nil {
+		configured = ctx.Common.Parallelism
+	}
+	if configured != nil {
+		parallelism = configured.Start(parallelism)
+	}
+	if configured != nil && configured.Auto {
+		scaler = &runner.Adaptive{
+			Max:      parallelism,
+			CPUs:     runtime.NumCPU(),
+			Interval: adaptiveSampleInterval,
+			Logf:     func(msg string, args ...any) { ctx.Logger.Debug(msg, args...) },
+		}
+		ctx.Logger.Debug("adaptive parallelism: up to %d workers", parallelism)
+	}
+
+	ctx.Logger.Info("running task %s with profile %s (run %s)", opts.Task, runCfg.Profile, runID)
+
+	human := !ctx.Common.JSON && !ctx.Common.YAML && !ctx.Common.Porcelain
+	if human {
+		ctx.Out.Printf(msgRunningTask, ctx.Out.Accent(ctx.Glyphs.Arrow), opts.Task, runCfg.Profile, parallelism, humanize.Duration(ctx.Timeout))
+	}
+
+	var (
+		jobs       []runner.Job
+		resumed    []string
+		checkpoint *checkpointWriter
+	)
+	if opts.Stream == nil {
+		var err error
+		checkpoint, resumed, err = prepareCheckpoint(ctx, opts, runCfg.Profile, runID)
+		if err != nil {
+			return nil, err
+		}
+		jobs, _ = skipCompleted(opts.Jobs, resumed)
+	}
+
+	retry := runCfg.Runtime.Retry
+	inputs := loadInputStore(ctx, runCfg.Profile, runID)
+	logs := newTaskLogs(ctx, runID)
+	chaosMode := newChaos(opts.Chaos, ctx.Clock)
+	if chaosMode != nil {
+		ctx.Logger.Warn("chaos mode: failing or delaying %d%% of task attempts", opts.Chaos)
+	}
+	// Without the live status region, parallel runs announce each task so
+	// piped output, or a screen reader, still gets a sequence of events.
+	announce := parallelism > 1 && (!ctx.ProgressEnabled() || ctx.Accessible())
+	prepare := func(job runner.Job) runner.Job {
+		job = logJob(ctx, chaosJob(ctx, chaosMode, job), logs, announce)
+		if _, ok := retry.Tasks[job.Name]; ok && job.Retry == nil {
+			policy := retry.RetryPolicy(job.Name)
+			job.Retry = &policy
+		}
+		job.Priority = taskPriority(job, runCfg.Runtime.Priority, opts.Priority)
+		return skipUnchanged(ctx, inputs, job, opts.Force)
+	}
+	for i := range jobs {
+		jobs[i] = prepare(jobs[i])
+	}
+
+	opCtx, cancel := ctx.WithTimeout()
+	defer cancel()
+
+	tracker := ctx.NewTracker(len(jobs))
+	tracker.ShowTasks(parallelism > 1)
+	tracker.Start()
+	trackerEvents := trackerObserver{tracker: tracker}
+	logger := ctx.Logger
+	ctx.Logger = logger.withStderr(tracker.Writer(os.Stderr))
+	defer func() { ctx.Logger = logger }()
+
+	observers := multiObserver{trackerEvents}
+	if opts.Observer != nil {
+		observers = append(observers, opts.Observer)
+	}
+	if checkpoint != nil {
+		observers = append(observers, checkpoint)
+	}
+
+	runOpts := runner.Options{
+		Parallelism: parallelism,
+		FailFast:    runCfg.Runtime.FailFast && !opts.KeepGoing,
+		Retry:       retry.RetryPolicy(""),
+		OnRetry: func(job string, attempt int, err error, delay time.Duration) {
+			ctx.Logger.Warn("task %s failed on attempt %d: %v; retrying in %s", job, attempt, err, humanize.Duration(delay))
+			trackerEvents.JobRetrying(job, attempt+1)
+		},
+		Observer: observers,
+		Scaler:   scaler,
+		Limiter:  runCfg.Runtime.RateLimit.Limiter().WithClock(ctx.Clock),
+		Clock:    ctx.Clock,
+	}
+	var report runner.Report
+	var err error
+	if opts.Stream != nil {
+		stream := readJobStream(opCtx, ctx, opts.Stream, opts.StreamJob, func(job runner.Job) runner.Job {
+			tracker.AddTotal(1)
+			return prepare(job)
+		})
+		report = runner.RunStream(opCtx, stream, runOpts)
+	} else {
+		report, err = runner.Run(opCtx, jobs, runOpts)
+	}
+	tracker.Stop()
+	if err != nil {
+		return nil, err
+	}
+	failures := collectFailures(report)
+	for _, f := range failures {
+		f.Log = logs.existing(f.Task)
+	}
+	if checkpoint != nil && len(failures) == 0 && opCtx.Err() == nil {
+		checkpoint.clear()
+	}
+
+	results := taskResults(report)
+	for _, name := range resumed {
+		results = append(results, TaskResult{Name: name, Status: TaskSkipped})
+	}
+	summary := Summarize(results, report.Duration)
+	metrics := CollectMetrics(report)
+	reportErr := writeReports(opts.Reports, runReport{
+		RunID:    runID,
+		Task:     opts.Task,
+		Profile:  runCfg.Profile,
+		Started:  report.Started,
+		Duration: report.Duration,
+		Results:  results,
+		logs:     logs,
+	})
+
+	result := map[string]any{
+		"run_id":      runID,
+		"task":        opts.Task,
+		"profile":     runCfg.Profile,
+		"parallelism": parallelism,
+		"timeout":     Duration(ctx.Timeout),
+		"metrics":     metrics,
+	}
+	if len(failures) > 0 {
+		result["failures"] = failureRecords(failures)
+	}
+	if ctx.Config.Output.Summary {
+		result["summary"] = summary
+	}
+
+	switch {
+	case ctx.Common.JSON:
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return &metrics, err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+	case ctx.Common.YAML:
+		data, err := yaml.Marshal(result)
+		if err != nil {
+			return &metrics, err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		ctx.Out.Println(runID)
+	default:
+		if ctx.Config.Output.Summary {
+			renderSummary(ctx.Out, summary)
+		}
+		renderFailures(ctx.Out, failures, summary.Attempted+summary.Skipped+summary.UpToDate)
+		if opts.Stats {
+			renderStats(ctx.Out, metrics)
+		}
+	}
+
+	err = ctx.TimeoutErr(opCtx, failureError(failures, summary.Succeeded))
+	if reportErr != nil {
+		if err == nil {
+			return &metrics, reportErr
+		}
+		ctx.Logger.Error("%v", reportErr)
+	}
+	return &metrics, err
+}
+
+// prepareCheckpoint applies --resume/--from-scratch to an existing checkpoint
+// and returns the writer for this run plus the tasks already completed.
+func prepareCheckpoint(ctx *RuntimeContext, opts RunOptions, profile, runID string) (*checkpointWriter, []string, error) {
+	existing, err := loadCheckpoint(ctx, checkpointKey(opts.Task, profile))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var resumed []string
+	switch {
+	case existing == nil:
+		if opts.Resume {
+			ctx.Logger.Info("no checkpoint for task %s; running everything", opts.Task)
+		}
+	case opts.FromScratch:
+		ctx.Logger.Info("discarding checkpoint from run %s", existing.RunID)
+	case opts.Resume:
+		resumed = existing.Completed
+		ctx.Logger.Info("resuming run %s: %d task(s) already completed", existing.RunID, len(resumed))
+	default:
+		ctx.Logger.Info("checkpoint from run %s has %d completed task(s); pass --resume to skip them", existing.RunID, len(existing.Completed))
+	}
+
+	writer := newCheckpointWriter(ctx, Checkpoint{
+		RunID:     runID,
+		Task:      opts.Task,
+		Profile:   profile,
+		Completed: append([]string(nil), resumed...),
+	})
+	if existing != nil && opts.FromScratch {
+		writer.clear()
+	}
+	return writer, resumed, nil
+}
+
+// taskPriority returns the queue priority for job: the --priority value if
+// given, else runtime.priority, else the task's own. Task names are
+// case-insensitive.
+func taskPriority(job runner.Job, config, flags map[string]int) int {
+	name := strings.ToLower(job.Name)
+	for _, overrides := range []map[string]int{flags, config} {
+		for task, p := range overrides {
+			if strings.ToLower(task) == name {
+				return p
+			}
+		}
+	}
+	return job.Priority
+}
+
+// logJob wraps job with logging of its lifecycle, at info level when
+// announce is set and at debug level otherwise. While the job runs, its
+// context carries the task's log file from logs, which always records at
+// least debug level; see RuntimeContext.TaskLogger.
+func logJob(ctx *RuntimeContext, job runner.Job, logs *taskLogs, announce bool) runner.Job {
+	run := job.Run
+	job.Run = func(jobCtx context.Context) error {
+		file, closer, err := logs.open(job.Name, max(ctx.LogSettings.Level, LevelDebug))
+		if err != nil {
+			ctx.Logger.Warn("task %s: %v", job.Name, err)
+		} else {
+			defer closer.Close()
+			jobCtx = context.WithValue(jobCtx, taskLogKey{}, file)
+		}
+		// Resolve the logger per call: HandleRun swaps ctx.Logger while the
+		// progress display is up.
+		logf := func(msg string, args ...any) {
+			if announce {
+				ctx.TaskLogger(jobCtx).Info(msg, args...)
+			} else {
+				ctx.TaskLogger(jobCtx).Debug(msg, args...)
+			}
+		}
+
+		logf("task %s started", job.Name)
+		started := ctx.Clock.Now()
+		jobCtx, span := tracing.Start(jobCtx, "task "+job.Name, time.Time{}, attribute.String("gocli.task", job.Name))
+		err = run(jobCtx)
+		tracing.End(span, err)
+		if err != nil {
+			ctx.TaskLogger(jobCtx).Error("task %s failed: %v", job.Name, err)
+		} else {
+			logf("task %s finished in %s", job.Name, humanize.Duration(ctx.Clock.Since(started)))
+		}
+		return err
+	}
+	return job
+}
+
+// taskResults converts pool results into summary task results.
+func taskResults(report runner.Report) []TaskResult {
+	results := make([]TaskResult, 0, len(report.Results))
+	for _, r := range report.Results {
+		results = append(results, TaskResult{
+			Name:     r.Name,
+			Status:   taskStatus(r),
+			Duration: r.Duration,
+			Attempts: r.Attempts,
+			Err:      r.Err,
+		})
+	}
+	return results
+}
+
+// taskStatus classifies a runner result.
+func taskStatus(r runner.Result) TaskStatus {
+	switch {
+	case r.Skipped:
+		return TaskSkipped
+	case r.UpToDate:
+		return TaskUpToDate
+	case r.Err != nil:
+		return TaskFailed
+	default:
+		return TaskSucceeded
+	}
+}
+
+// HandleTaskList prints the registered tasks.
+func HandleTaskList(ctx *RuntimeContext, infos []TaskInfo) error {
+	switch {
+	case ctx.Common.JSON:
+		data, err := json.MarshalIndent(infos, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+	case ctx.Common.YAML:
+		data, err := yaml.Marshal(infos)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		for _, info := range infos {
+			ctx.Out.Println(info.Name)
+		}
+	default:
+		rows := make([]KeyValue, 0, len(infos))
+		for _, info := range infos {
+			value := info.Description
+			if len(info.Dependencies) > 0 {
+				value += ctx.Out.Dim(ctx.Out.Sprintf(msgTaskNeeds, strings.Join(info.Dependencies, ", ")))
+			}
+			if len(info.Params) > 0 {
+				value += ctx.Out.Dim(ctx.Out.Sprintf(msgTaskParams, strings.Join(paramUsage(info.Params), " ")))
+			}
+			rows = append(rows, KeyValue{Key: info.Name, Value: value})
+		}
+		ctx.Out.KeyValues("", rows)
+	}
+	return nil
+}
@@ -0,0 +1,19 @@
+package app
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"time"
+)
+
+// NewRunID returns a sortable, collision-resistant identifier for a run,
+// e.g. 20260528T183539Z-3f9a1c. The format is part of the porcelain contract.
+func NewRunID(now time.Time) string {
+	var suffix [3]byte
+	if _, err := rand.Read(suffix[:]); err != nil {
+		// crypto/rand never fails on supported platforms; keep the ID usable
+		// if it somehow does.
+		return now.UTC().Format("20060102T150405Z")
+	}
+	return now.UTC().Format("20060102T150405Z") + "-" + hex.EncodeToString(suffix[:])
+}
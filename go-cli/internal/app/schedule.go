@@ -0,0 +1,291 @@
+package app
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/clock"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/humanize"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/runner"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/schedule"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/statestore"
+)
+
+// ScheduleEntry is a task registered to run on a cron schedule.
+type ScheduleEntry struct {
+	ID      string    `json:"id" yaml:"id"`
+	Cron    string    `json:"cron" yaml:"cron"`
+	Task    string    `json:"task" yaml:"task"`
+	Profile string    `json:"profile,omitempty" yaml:"profile,omitempty"`
+	Created time.Time `json:"created" yaml:"created"`
+}
+
+// ScheduleAddOptions configure `schedule add`.
+type ScheduleAddOptions struct {
+	Cron    string
+	Task    string
+	Profile string
+}
+
+// JobsFunc builds the runner jobs for a task, bound to rtx; the command
+// layer supplies it from the task registry.
+type JobsFunc func(rtx *RuntimeContext, task string) ([]runner.Job, error)
+
+// loadSchedules returns the registered schedules in the order they were
+// added.
+func loadSchedules(ctx *RuntimeContext) ([]ScheduleEntry, error) {
+	store, err := ctx.stateStore()
+	if err != nil {
+		return nil, err
+	}
+	records, err := store.Schedules(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("read schedules: %w", err)
+	}
+	entries := make([]ScheduleEntry, 0, len(records))
+	for _, r := range records {
+		entries = append(entries, ScheduleEntry{ID: r.ID, Cron: r.Cron, Task: r.Task, Profile: r.Profile, Created: r.Created})
+	}
+	return entries, nil
+}
+
+// scheduleRecord is the statestore form of entry.
+func scheduleRecord(entry ScheduleEntry) statestore.Schedule {
+	return statestore.Schedule{ID: entry.ID, Cron: entry.Cron, Task: entry.Task, Profile: entry.Profile, Created: entry.Created}
+}
+
+func newScheduleID() string {
+	var buf [4]byte
+	_, _ = rand.Read(buf[:])
+	return hex.EncodeToString(buf[:])
+}
+
+// HandleScheduleAdd registers a new schedule.
+func HandleScheduleAdd(ctx *RuntimeContext, opts ScheduleAddOptions) error {
+	cron, err := schedule.Parse(opts.Cron)
+	if err != nil {
+		return UsageError(err)
+	}
+
+	entry := ScheduleEntry{
+		ID:      newScheduleID(),
+		Cron:    cron.String(),
+		Task:    opts.Task,
+		Profile: opts.Profile,
+		Created: ctx.Clock.Now().UTC(),
+	}
+
+	if ctx.Common.DryRun {
+		ctx.Logger.Info("dry-run: would schedule task %s at %q", entry.Task, entry.Cron)
+		return nil
+	}
+	store, err := ctx.stateStore()
+	if err != nil {
+		return err
+	}
+	if err := store.AddSchedule(ctx, scheduleRecord(entry)); err != nil {
+		return fmt.Errorf("add schedule: %w", err)
+	}
+
+	ctx.Logger.Info("scheduled task %s at %q (id %s)", entry.Task, entry.Cron, entry.ID)
+	if ctx.Common.Porcelain {
+		ctx.Out.Println(entry.ID)
+	}
+	return nil
+}
+
+// HandleScheduleRemove deletes a schedule by ID.
+func HandleScheduleRemove(ctx *RuntimeContext, id string) error {
+	entries, err := loadSchedules(ctx)
+	if err != nil {
+		return err
+	}
+	if !slices.ContainsFunc(entries, func(e ScheduleEntry) bool { return e.ID == id }) {
+		return fmt.Errorf("no schedule with id %q", id)
+	}
+
+	if ctx.Common.DryRun {
+		ctx.Logger.Info("dry-run: would remove schedule %s", id)
+		return nil
+	}
+	store, err := ctx.stateStore()
+	if err != nil {
+		return err
+	}
+	removed, err := store.RemoveSchedule(ctx, id)
+	if err != nil {
+		return fmt.Errorf("remove schedule: %w", err)
+	}
+	if !removed {
+		// Removed from another shell in the meantime.
+		return fmt.Errorf("no schedule with id %q", id)
+	}
+	ctx.Logger.Info("removed schedule %s", id)
+	return nil
+}
+
+// scheduleListing is a schedule plus its next activation, for `schedule list`.
+type scheduleListing struct {
+	ScheduleEntry `yaml:",inline"`
+	Next          *time.Time `json:"next,omitempty" yaml:"next,omitempty"`
+}
+
+// HandleScheduleList prints the registered schedules and their next run.
+func HandleScheduleList(ctx *RuntimeContext) error {
+	entries, err := loadSchedules(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := ctx.Clock.Now()
+	listings := make([]scheduleListing, 0, len(entries))
+	for _, entry := range entries {
+		listing := scheduleListing{ScheduleEntry: entry}
+		if cron, err := schedule.Parse(entry.Cron); err == nil {
+			if next := cron.Next(now); !next.IsZero() {
+				listing.Next = &next
+			}
+		}
+		listings = append(listings, listing)
+	}
+
+	switch {
+	case ctx.Common.JSON:
+		data, err := json.MarshalIndent(listings, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+	case ctx.Common.YAML:
+		data, err := yaml.Marshal(listings)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		for _, l := range listings {
+			fmt.Fprintf(ctx.Out.Writer(), "%s\t%s\t%s\t%s\n", l.ID, l.Cron, l.Task, l.Profile)
+		}
+	default:
+		if len(listings) == 0 {
+			ctx.Logger.Info("no schedules; add one with `schedule add`")
+			return nil
+		}
+		rows := make([]KeyValue, 0, len(listings))
+		for _, l := range listings {
+			value := fmt.Sprintf("%-15s %s", l.Cron, l.Task)
+			if l.Profile != "" {
+				value += " @" + l.Profile
+			}
+			if l.Next != nil {
+				value += ctx.Out.Dim(ctx.Out.Sprintf(msgScheduleNext, humanize.RelTime(*l.Next, now)))
+			}
+			rows = append(rows, KeyValue{Key: l.ID, Value: value})
+		}
+		ctx.Out.KeyValues("", rows)
+	}
+	return nil
+}
+
+// scheduleRecheck bounds how long the scheduler sleeps before re-reading the
+// schedules.
+const scheduleRecheck = time.Minute
+
+// HandleScheduleRun runs the scheduler in the foreground until the command
+// context is canceled. The schedules are re-read at least once a minute,
+// so entries added or removed from another shell take effect without a
+// restart.
+// Runs that come due together execute one after another; a run that overlaps
+// the next activation delays it rather than running concurrently.
+func HandleScheduleRun(ctx *RuntimeContext, jobsFor JobsFunc) error {
+	ctx.Logger.Info("scheduler started (Ctrl+C to stop)")
+
+	for {
+		// In the daemon, `daemon reload` may have replaced the config.
+		base := ctx.currentConfig()
+		ctx.Config = base
+		entries, err := loadSchedules(ctx)
+		if err != nil {
+			return err
+		}
+		now := ctx.Clock.Now()
+		next, due := nextDue(ctx, entries, now)
+		wake := next
+		if next.IsZero() || next.After(now.Add(scheduleRecheck)) {
+			// Nothing due soon: wake up periodically to pick up edits.
+			wake, due = now.Add(scheduleRecheck), nil
+		}
+		if next.IsZero() {
+			ctx.Logger.Debug("no runnable schedules")
+		} else {
+			ctx.Logger.Debug("next run at %s", next.Format(time.RFC3339))
+		}
+
+		if err := clock.Sleep(ctx, ctx.Clock, wake.Sub(now)); err != nil {
+			ctx.Logger.Info("scheduler stopped")
+			return nil
+		}
+
+		for _, entry := range due {
+			// HandleRun applies the profile to ctx.Config; reset it so one
+			// schedule's profile does not leak into the next.
+			ctx.Config = base
+			jobs, err := jobsFor(ctx, entry.Task)
+			if err != nil {
+				ctx.Logger.Error("schedule %s: %v", entry.ID, err)
+				continue
+			}
+			err = ctx.withRunLock(func() error {
+				return HandleRun(ctx, RunOptions{Task: entry.Task, Profile: entry.Profile, Jobs: jobs})
+			})
+			if err != nil {
+				ctx.Logger.Error("schedule %s: %v", entry.ID, err)
+			}
+			if ctx.Err() != nil {
+				ctx.Logger.Info("scheduler stopped")
+				return nil
+			}
+		}
+		ctx.Config = base
+	}
+}
+
+// nextDue returns the earliest activation after now and the entries that
+// fire at it, in the order they were added.
+func nextDue(ctx *RuntimeContext, entries []ScheduleEntry, now time.Time) (time.Time, []ScheduleEntry) {
+	type pending struct {
+		at    time.Time
+		entry ScheduleEntry
+	}
+	var all []pending
+	for _, entry := range entries {
+		cron, err := schedule.Parse(entry.Cron)
+		if err != nil {
+			ctx.Logger.Warn("schedule %s: %v", entry.ID, err)
+			continue
+		}
+		if at := cron.Next(now); !at.IsZero() {
+			all = append(all, pending{at: at, entry: entry})
+		}
+	}
+	if len(all) == 0 {
+		return time.Time{}, nil
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].at.Before(all[j].at) })
+
+	var due []ScheduleEntry
+	for _, p := range all {
+		if !p.at.Equal(all[0].at) {
+			break
+		}
+		due = append(due, p.entry)
+	}
+	return all[0].at, due
+}
@@ -0,0 +1,722 @@
+package app
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/control"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/statestore"
+)
+
+// ServeOptions configure the serve command.
+type ServeOptions struct {
+	// Addr overrides serve.addr.
+	Addr string
+	// GRPCAddr overrides serve.grpc_addr: host:port or unix:PATH.
+	GRPCAddr string
+	// Resolve turns a run request into run options, bound to the runtime
+	// context the run executes in.
+	Resolve ResolveRunFunc
+	// Version is reported as info.version in /openapi.json.
+	Version string
+	// OpenAPI prints the OpenAPI document instead of serving.
+	OpenAPI bool
+}
+
+// ResolveRunFunc resolves task and its NAME=VALUE params into run options
+// bound to rtx; the command layer supplies it from the task registry.
+type ResolveRunFunc func(rtx *RuntimeContext, task string, params []string) (RunOptions, error)
+
+const (
+	// serveShutdownTimeout bounds how long in-flight requests may take to
+	// finish after the server is asked to stop.
+	serveShutdownTimeout = 10 * time.Second
+	// serveMaxBody bounds request bodies; run requests are tiny.
+	serveMaxBody = 1 << 20
+	// serveDefaultLimit is the number of runs GET /v1/runs returns by default.
+	serveDefaultLimit = 20
+)
+
+// Statuses of runs started through the API, before they reach history.
+const (
+	ServeQueued  = "queued"
+	ServeRunning = "running"
+)
+
+// ServeRunRequest is the body of POST /v1/runs.
+type ServeRunRequest struct {
+	Task    string            `json:"task"`
+	Profile string            `json:"profile,omitempty"`
+	Params  map[string]string `json:"params,omitempty"`
+}
+
+// ServeRun is a run started through the API.
+type ServeRun struct {
+	ID      string     `json:"id"`
+	Task    string     `json:"task"`
+	Profile string     `json:"profile,omitempty"`
+	Status  string     `json:"status"`
+	Queued  time.Time  `json:"queued"`
+	Started *time.Time `json:"started,omitempty"`
+}
+
+// ServeStatus is the body of GET /v1/status.
+type ServeStatus struct {
+	Addr    string        `json:"addr"`
+	Started time.Time     `json:"started"`
+	Active  []ServeRun    `json:"active"`
+	LastRun *HistoryEntry `json:"last_run,omitempty"`
+}
+
+// ServeSettings is the body of GET /v1/config: the effective settings with
+// secrets redacted, keyed like `config show`.
+type ServeSettings struct {
+	Profile  string            `json:"profile"`
+	Settings map[string]string `json:"settings"`
+}
+
+// ServeHealth is the body of GET /healthz and GET /readyz.
+type ServeHealth struct {
+	Status string `json:"status"`
+}
+
+// ServeError is the body of every error response.
+type ServeError struct {
+	Error string `json:"error"`
+}
+
+// server holds the state of a running `serve`.
+type server struct {
+	ctx     *RuntimeContext
+	resolve ResolveRunFunc
+	token   string
+	version string
+	addr    string
+	started time.Time
+	ready   atomic.Bool
+
+	mu sync.Mutex
+	// runs holds the queued and running runs; finished ones are looked up
+	// in history.
+	runs map[string]*ServeRun
+	// queue holds the runs not started yet, oldest first. A single worker
+	// (work) takes them in turn, so runs start in the order they were
+	// queued; the instance lock alone would let a later run overtake.
+	queue []queuedRun
+	// wake tells the worker that queue grew.
+	wake chan struct{}
+	// stopped is set when the worker has exited; no run is queued after.
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+// queuedRun is a run waiting in server.queue.
+type queuedRun struct {
+	rtx  *RuntimeContext
+	opts RunOptions
+	run  *ServeRun
+}
+
+// HandleServe serves the HTTP+JSON API until the context is canceled:
+//
+//	GET  /healthz         liveness, no auth
+//	GET  /readyz          readiness, no auth; 503 while shutting down
+//	POST /v1/runs         start a run: {"task", "profile", "params"}
+//	GET  /v1/runs         recent runs from history, newest first (?limit=N)
+//	GET  /v1/runs/{id}    one run: queued or running, else its history entry
+//	GET  /v1/status       server state, active runs, and the last run
+//	GET  /v1/config       effective settings, secrets redacted
+//	GET  /openapi.json    OpenAPI 3 description of the above, no auth
+//
+// The /v1 endpoints require "Authorization: Bearer <serve.token>" when a
+// token is configured. Without one they only answer requests addressed to
+// a loopback name, so a web page cannot reach them by DNS rebinding, and
+// POST /v1/runs requires a JSON Content-Type, which a cross-site form
+// cannot send. Runs execute one at a time in the order they were queued,
+// each under the instance lock, so they also queue behind other
+// invocations as `schedule run` does.
+//
+// With a gRPC address, the Control service of api/control/v1 is served
+// there as well, sharing the run queue and the token.
+func HandleServe(ctx *RuntimeContext, opts ServeOptions) error {
+	if opts.OpenAPI {
+		data, err := json.MarshalIndent(openAPIDocument((&server{}).apiRoutes(), opts.Version), "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+		return nil
+	}
+	addr := ctx.Config.Serve.Addr
+	if opts.Addr != "" {
+		addr = opts.Addr
+	}
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return UsageError(fmt.Errorf("invalid address %q (expected host:port): %v", addr, err))
+	}
+	token := ctx.Config.Serve.Token
+	if token == "" && !loopbackHost(host) {
+		return UsageError(fmt.Errorf("serving on %s requires serve.token (or %s_SERVE__TOKEN); only loopback addresses may be left unauthenticated", addr, EnvPrefix()))
+	}
+	rawGRPC := ctx.Config.Serve.GRPCAddr
+	if opts.GRPCAddr != "" {
+		rawGRPC = opts.GRPCAddr
+	}
+	var grpcAt grpcAddr
+	if rawGRPC != "" {
+		if grpcAt, err = parseGRPCAddr(rawGRPC); err != nil {
+			return UsageError(err)
+		}
+		if token == "" && !grpcAt.local() {
+			return UsageError(fmt.Errorf("serving gRPC on %s requires serve.token (or %s_SERVE__TOKEN); only loopback addresses and Unix sockets may be left unauthenticated", grpcAt, EnvPrefix()))
+		}
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("listen on %s: %w", addr, err)
+	}
+	var grpcLn net.Listener
+	if rawGRPC != "" {
+		if grpcLn, err = grpcAt.listen(); err != nil {
+			ln.Close()
+			return fmt.Errorf("listen on %s: %w", grpcAt, err)
+		}
+		if grpcAt.network == "unix" {
+			defer os.Remove(grpcAt.address)
+		}
+	}
+
+	// Stopping over the control socket cancels the same way Ctrl+C does.
+	sctx, stop := context.WithCancel(ctx.Context)
+	defer stop()
+	ctx = ctx.fork(sctx)
+
+	s := &server{
+		ctx:     ctx,
+		resolve: opts.Resolve,
+		version: opts.Version,
+		token:   token,
+		addr:    ln.Addr().String(),
+		started: ctx.Clock.Now().UTC(),
+		runs:    map[string]*ServeRun{},
+		wake:    make(chan struct{}, 1),
+	}
+	s.wg.Add(1)
+	go s.work()
+	srv := &http.Server{
+		Handler:           s.routes(),
+		ReadHeaderTimeout: 10 * time.Second,
+		BaseContext:       func(net.Listener) context.Context { return ctx },
+	}
+
+	errCh := make(chan error, 2)
+	go func() { errCh <- srv.Serve(ln) }()
+	var grpcSrv *grpc.Server
+	if grpcLn != nil {
+		grpcSrv = s.grpcServer()
+		go func() { errCh <- grpcSrv.Serve(grpcLn) }()
+	}
+	closeControl, err := openControl(ctx, func() DaemonStatus {
+		return DaemonStatus{
+			Running: true,
+			Mode:    InstanceServe,
+			PID:     os.Getpid(),
+			Started: &s.started,
+			Addr:    s.addr,
+			Socket:  control.Address(controlSocketPath(ctx.Paths.StateDir)),
+		}
+	}, stop)
+	if err != nil {
+		ctx.Logger.Warn("%v; `daemon status|stop|reload|logs` will not reach this server", err)
+	} else {
+		defer closeControl()
+	}
+
+	s.ready.Store(true)
+	ctx.Logger.Info("serving API on http://%s (Ctrl+C to stop)", s.addr)
+	if grpcSrv != nil {
+		ctx.Logger.Info("serving gRPC control API on %s", grpcAt)
+	}
+	if token == "" {
+		ctx.Logger.Warn("serve.token is not set; the API accepts unauthenticated requests from this machine")
+	}
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("serve: %w", err)
+	case <-ctx.Done():
+	}
+
+	s.ready.Store(false)
+	ctx.Logger.Info("shutting down; waiting for in-flight requests and runs")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+	defer cancel()
+	if grpcSrv != nil {
+		stopped := make(chan struct{})
+		go func() {
+			grpcSrv.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-shutdownCtx.Done():
+			grpcSrv.Stop()
+		}
+	}
+	err = srv.Shutdown(shutdownCtx)
+	// Runs share ctx, so they are already being interrupted; the worker
+	// drains the queue and exits.
+	s.wg.Wait()
+	if err != nil {
+		return fmt.Errorf("shut down: %w", err)
+	}
+	ctx.Logger.Info("server stopped")
+	return nil
+}
+
+// loopbackHost reports whether host only accepts local connections.
+func loopbackHost(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// apiRoute is one endpoint of the HTTP API. The mux and /openapi.json are
+// both built from apiRoutes, so the document cannot drift from the
+// handlers.
+type apiRoute struct {
+	Method string
+	// Path is a ServeMux pattern; its {wildcards} are OpenAPI path
+	// parameters too.
+	Path string
+	// ID is the operationId client generators name methods after.
+	ID      string
+	Summary string
+	// Public routes skip the bearer token check.
+	Public bool
+	Params []apiParam
+	// Request is a value of the request body type; nil for none.
+	Request   any
+	Responses []apiResponse
+	Handler   http.HandlerFunc
+}
+
+// apiParam describes a path or query parameter.
+type apiParam struct {
+	In          string
+	Name        string
+	Type        string
+	Description string
+}
+
+// apiResponse describes a response; Body is a value of its type, or an
+// apiOneOf when the type depends on the resource.
+type apiResponse struct {
+	Status      int
+	Description string
+	Body        any
+}
+
+// apiOneOf lists the alternative types of a response body.
+type apiOneOf []any
+
+func (s *server) apiRoutes() []apiRoute {
+	errBody := ServeError{}
+	return []apiRoute{
+		{
+			Method: "GET", Path: "/healthz", ID: "getHealth", Public: true,
+			Summary:   "Liveness probe",
+			Responses: []apiResponse{{http.StatusOK, "The server is up.", ServeHealth{}}},
+			Handler:   s.handleHealth,
+		},
+		{
+			Method: "GET", Path: "/readyz", ID: "getReady", Public: true,
+			Summary: "Readiness probe",
+			Responses: []apiResponse{
+				{http.StatusOK, "The server accepts runs.", ServeHealth{}},
+				{http.StatusServiceUnavailable, "The server is shutting down.", ServeHealth{}},
+			},
+			Handler: s.handleReady,
+		},
+		{
+			Method: "GET", Path: "/openapi.json", ID: "getOpenAPI", Public: true,
+			Summary:   "This OpenAPI document",
+			Responses: []apiResponse{{http.StatusOK, "OpenAPI 3 document.", map[string]any{}}},
+			Handler:   s.handleOpenAPI,
+		},
+		{
+			Method: "POST", Path: "/v1/runs", ID: "createRun",
+			Summary: "Start a run; it is queued behind earlier runs",
+			Request: ServeRunRequest{},
+			Responses: []apiResponse{
+				{http.StatusAccepted, "The run is queued; poll the Location header.", ServeRun{}},
+				{http.StatusBadRequest, "Invalid body, unknown task, or invalid params.", errBody},
+				{http.StatusUnsupportedMediaType, "The body is not sent as application/json.", errBody},
+				{http.StatusServiceUnavailable, "The server is shutting down.", errBody},
+			},
+			Handler: s.handleCreateRun,
+		},
+		{
+			Method: "GET", Path: "/v1/runs", ID: "listRuns",
+			Summary: "Recent runs from history, newest first",
+			Params:  []apiParam{{"query", "limit", "integer", fmt.Sprintf("Maximum number of runs (default %d).", serveDefaultLimit)}},
+			Responses: []apiResponse{
+				{http.StatusOK, "History entries.", []HistoryEntry{}},
+				{http.StatusBadRequest, "Invalid limit.", errBody},
+			},
+			Handler: s.handleListRuns,
+		},
+		{
+			Method: "GET", Path: "/v1/runs/{id}", ID: "getRun",
+			Summary: "One run: queued or running, else its history entry",
+			Params:  []apiParam{{"path", "id", "string", "Run ID as returned by createRun."}},
+			Responses: []apiResponse{
+				{http.StatusOK, "The active run or its history entry.", apiOneOf{ServeRun{}, HistoryEntry{}}},
+				{http.StatusNotFound, "No run has the ID.", errBody},
+			},
+			Handler: s.handleGetRun,
+		},
+		{
+			Method: "GET", Path: "/v1/status", ID: "getStatus",
+			Summary:   "Server state, active runs, and the last run",
+			Responses: []apiResponse{{http.StatusOK, "Server status.", ServeStatus{}}},
+			Handler:   s.handleStatus,
+		},
+		{
+			Method: "GET", Path: "/v1/config", ID: "getConfig",
+			Summary:   "Effective settings with secrets redacted",
+			Responses: []apiResponse{{http.StatusOK, "Settings keyed like `config show`.", ServeSettings{}}},
+			Handler:   s.handleConfig,
+		},
+	}
+}
+
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	for _, route := range s.apiRoutes() {
+		var h http.Handler = route.Handler
+		if !route.Public {
+			h = s.auth(route.Handler)
+		}
+		mux.Handle(route.Method+" "+route.Path, h)
+	}
+	return s.logRequests(mux)
+}
+
+// auth rejects requests without the configured bearer token. Without a
+// token, it rejects requests whose Host is not a loopback name or address:
+// a browser sends the attacker's host name after DNS rebinding.
+func (s *server) auth(next http.HandlerFunc) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.token != "" {
+			given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(s.token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="`+appName+`"`)
+				writeError(w, http.StatusUnauthorized, errors.New("missing or invalid bearer token"))
+				return
+			}
+		} else if !loopbackHost(requestHost(r)) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("host %q is not a loopback address; set serve.token to serve other names", r.Host))
+			return
+		}
+		next(w, r)
+	})
+}
+
+// requestHost returns the host name of r's Host header, without the port
+// or the brackets of an IPv6 address.
+func requestHost(r *http.Request) string {
+	if host, _, err := net.SplitHostPort(r.Host); err == nil {
+		return host
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(r.Host, "["), "]")
+}
+
+// statusRecorder captures the response status for the request log.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (s *server) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		start := time.Now()
+		next.ServeHTTP(rec, r)
+		s.ctx.Logger.Debug("%s %s %s -> %d (%s)", r.RemoteAddr, r.Method, r.URL.Path, rec.status, time.Since(start).Round(time.Millisecond))
+	})
+}
+
+func (s *server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, ServeHealth{Status: "ok"})
+}
+
+func (s *server) handleReady(w http.ResponseWriter, _ *http.Request) {
+	if !s.ready.Load() {
+		writeJSON(w, http.StatusServiceUnavailable, ServeHealth{Status: "shutting down"})
+		return
+	}
+	writeJSON(w, http.StatusOK, ServeHealth{Status: "ready"})
+}
+
+func (s *server) handleOpenAPI(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, openAPIDocument(s.apiRoutes(), s.version))
+}
+
+func (s *server) handleCreateRun(w http.ResponseWriter, r *http.Request) {
+	// A cross-site form or simple fetch cannot send application/json
+	// without a CORS preflight, which this server never answers.
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		writeError(w, http.StatusUnsupportedMediaType, errors.New("the request body must be application/json"))
+		return
+	}
+	var req ServeRunRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, serveMaxBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %v", err))
+		return
+	}
+	run, err := s.enqueue(req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, errServerStopping):
+			status = http.StatusServiceUnavailable
+		case ExitCode(err) == ExitUsage:
+			status = http.StatusBadRequest
+		}
+		writeError(w, status, err)
+		return
+	}
+	w.Header().Set("Location", "/v1/runs/"+run.ID)
+	writeJSON(w, http.StatusAccepted, run)
+}
+
+// errServerStopping rejects new runs once shutdown has begun.
+var errServerStopping = errors.New("server is shutting down")
+
+// enqueue resolves req and queues the run, returning a snapshot of it.
+// Invalid requests fail with a usage error.
+func (s *server) enqueue(req ServeRunRequest) (ServeRun, error) {
+	if req.Task == "" {
+		return ServeRun{}, UsageError(errors.New("task is required"))
+	}
+	if !s.ready.Load() {
+		return ServeRun{}, errServerStopping
+	}
+
+	params := make([]string, 0, len(req.Params))
+	for name, value := range req.Params {
+		params = append(params, name+"="+value)
+	}
+	sort.Strings(params)
+
+	// Each run gets its own copy of the runtime context: HandleRun applies
+	// the profile to it, and tasks are bound to it when resolved.
+	rtx := s.ctx.fork(s.ctx)
+	rtx.Config = s.ctx.currentConfig()
+	rtx.Common.NoProgress = true
+	opts, err := s.resolve(rtx, req.Task, params)
+	if err != nil {
+		return ServeRun{}, err
+	}
+	opts.Profile = req.Profile
+	opts.RunID = NewRunID(rtx.Clock.Now())
+
+	run := &ServeRun{ID: opts.RunID, Task: req.Task, Profile: req.Profile, Status: ServeQueued, Queued: rtx.Clock.Now().UTC()}
+	s.mu.Lock()
+	if s.stopped {
+		s.mu.Unlock()
+		return ServeRun{}, errServerStopping
+	}
+	s.runs[run.ID] = run
+	s.queue = append(s.queue, queuedRun{rtx: rtx, opts: opts, run: run})
+	snapshot := *run
+	s.mu.Unlock()
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+
+	s.ctx.Logger.Info("queued run %s (task %s)", run.ID, run.Task)
+	return snapshot, nil
+}
+
+// work executes the queued runs one at a time, oldest first. Once the
+// server stops it still drains the queue, whose runs then end at once as
+// interrupted, and exits.
+func (s *server) work() {
+	defer s.wg.Done()
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			if s.ctx.Err() != nil {
+				s.stopped = true
+				s.mu.Unlock()
+				return
+			}
+			s.mu.Unlock()
+			select {
+			case <-s.wake:
+			case <-s.ctx.Done():
+			}
+			continue
+		}
+		next := s.queue[0]
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+		s.execute(next.rtx, next.opts, next.run)
+	}
+}
+
+// execute performs run; work calls it once the runs queued before it have
+// finished.
+func (s *server) execute(rtx *RuntimeContext, opts RunOptions, run *ServeRun) {
+	s.update(run, func(r *ServeRun) {
+		started := rtx.Clock.Now().UTC()
+		r.Status, r.Started = ServeRunning, &started
+	})
+	if err := rtx.withRunLock(func() error { return HandleRun(rtx, opts) }); err != nil {
+		s.ctx.Logger.Error("run %s: %v", run.ID, err)
+	} else {
+		s.ctx.Logger.Info("run %s succeeded", run.ID)
+	}
+	s.mu.Lock()
+	delete(s.runs, run.ID)
+	s.mu.Unlock()
+}
+
+func (s *server) update(run *ServeRun, fn func(*ServeRun)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(run)
+}
+
+func (s *server) handleListRuns(w http.ResponseWriter, r *http.Request) {
+	limit := serveDefaultLimit
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid limit %q", raw))
+			return
+		}
+		limit = n
+	}
+	entries, err := loadHistory(s.ctx, statestore.Query{Limit: limit})
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	runs := make([]HistoryEntry, 0, len(entries))
+	for i := len(entries) - 1; i >= 0; i-- {
+		runs = append(runs, entries[i])
+	}
+	writeJSON(w, http.StatusOK, runs)
+}
+
+func (s *server) handleGetRun(w http.ResponseWriter, r *http.Request) {
+	active, entry, err := s.lookup(r.PathValue("id"))
+	switch {
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, err)
+	case active != nil:
+		writeJSON(w, http.StatusOK, active)
+	case entry != nil:
+		writeJSON(w, http.StatusOK, entry)
+	default:
+		writeError(w, http.StatusNotFound, fmt.Errorf("no run with id %q", r.PathValue("id")))
+	}
+}
+
+// lookup finds a run: queued or running, else its history entry. Both are
+// nil when no run has the id.
+func (s *server) lookup(id string) (*ServeRun, *HistoryEntry, error) {
+	s.mu.Lock()
+	run, ok := s.runs[id]
+	var snapshot ServeRun
+	if ok {
+		snapshot = *run
+	}
+	s.mu.Unlock()
+	if ok {
+		return &snapshot, nil, nil
+	}
+
+	entries, err := loadHistory(s.ctx, statestore.Query{Where: []statestore.Condition{{Field: "id", Op: "=", Value: id}}})
+	if err != nil || len(entries) == 0 {
+		return nil, nil, err
+	}
+	return nil, &entries[0], nil
+}
+
+func (s *server) handleStatus(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, s.status())
+}
+
+// status snapshots the server state for GET /v1/status.
+func (s *server) status() ServeStatus {
+	status := ServeStatus{Addr: s.addr, Started: s.started, Active: []ServeRun{}}
+	s.mu.Lock()
+	for _, run := range s.runs {
+		status.Active = append(status.Active, *run)
+	}
+	s.mu.Unlock()
+	sort.Slice(status.Active, func(i, j int) bool { return status.Active[i].Queued.Before(status.Active[j].Queued) })
+
+	if entries, err := loadHistory(s.ctx, statestore.Query{Limit: 1}); err == nil && len(entries) > 0 {
+		status.LastRun = &entries[len(entries)-1]
+	}
+	return status
+}
+
+func (s *server) handleConfig(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, s.config())
+}
+
+// config snapshots the settings the next run uses, for GET /v1/config and
+// the gRPC GetConfig.
+func (s *server) config() ServeSettings {
+	cfg := s.ctx.currentConfig()
+	out := ServeSettings{Profile: cfg.Profile, Settings: map[string]string{}}
+	for _, row := range redactedConfig(cfg) {
+		out.Settings[row.Key] = row.Value
+	}
+	return out
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, ServeError{Error: err.Error()})
+}
@@ -0,0 +1,46 @@
+package app
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServeRejectsCrossSiteRequests(t *testing.T) {
+	for _, tt := range []struct {
+		name        string
+		token       string
+		host        string
+		contentType string
+		want        int
+	}{
+		{"loopback name", "", "localhost:8765", "application/json", http.StatusBadRequest},
+		{"loopback address", "", "127.0.0.1:8765", "application/json; charset=utf-8", http.StatusBadRequest},
+		{"IPv6 loopback", "", "[::1]:8765", "application/json", http.StatusBadRequest},
+		{"rebound name", "", "attacker.example:8765", "application/json", http.StatusForbidden},
+		{"form post", "", "127.0.0.1:8765", "application/x-www-form-urlencoded", http.StatusUnsupportedMediaType},
+		{"no content type", "", "127.0.0.1:8765", "", http.StatusUnsupportedMediaType},
+		{"any name with a token", "s3cret", "ci.example:8765", "application/json", http.StatusBadRequest},
+		{"form post with a token", "s3cret", "ci.example:8765", "text/plain", http.StatusUnsupportedMediaType},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &server{ctx: &RuntimeContext{}, token: tt.token}
+			req := httptest.NewRequest(http.MethodPost, "/v1/runs", strings.NewReader(`{}`))
+			req.Host = tt.host
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			s.routes().ServeHTTP(rec, req)
+			// 400 means the request got through to the handler, which
+			// rejects the empty body for lack of a task.
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d (%s)", rec.Code, tt.want, strings.TrimSpace(rec.Body.String()))
+			}
+		})
+	}
+}
@@ -0,0 +1,657 @@
+package app
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"text/template"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/control"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/sdnotify"
+)
+
+// Service managers `service` supports.
+const (
+	ServiceSystemd = "systemd"
+	ServiceLaunchd = "launchd"
+	ServiceWindows = "windows"
+)
+
+// launchdLabel names the launchd job and its plist.
+func launchdLabel() string {
+	return "de.fraunhofer." + appName
+}
+
+// serviceDescription describes the daemon in the service manager.
+func serviceDescription() string {
+	return appName + " daemon (scheduler and watcher)"
+}
+
+// ServiceTarget selects the unit the `service` subcommands act on.
+type ServiceTarget struct {
+	// Systemd, Launchd, and Windows select the service manager; without
+	// any the platform's own is used.
+	Systemd bool
+	Launchd bool
+	Windows bool
+	// System selects a system unit (a LaunchDaemon for launchd) instead of
+	// a user unit (a LaunchAgent). Windows services are always system-wide.
+	System bool
+}
+
+// ServiceInstallOptions configure `service install`.
+type ServiceInstallOptions struct {
+	ServiceTarget
+	// RunAs is the user a system unit runs as, or the account of a Windows
+	// service.
+	RunAs string
+	// Hardening is one of ServiceHardeningLevels; systemd only.
+	Hardening string
+	// Watchdog is WatchdogSec=; 0 turns the watchdog off. systemd only.
+	Watchdog time.Duration
+	// Output overrides where the unit is written; "-" prints it. Not for
+	// Windows services, which live in the service control manager.
+	Output string
+	Force  bool
+}
+
+// DefaultServiceWatchdog is the default WatchdogSec= of generated units.
+const DefaultServiceWatchdog = 30 * time.Second
+
+// ServiceHardeningLevels lists the values of --hardening.
+var ServiceHardeningLevels = []string{"none", "basic", "strict"}
+
+// ServiceResult reports the unit a `service` subcommand acted on.
+type ServiceResult struct {
+	Manager string `json:"manager" yaml:"manager"`
+	Scope   string `json:"scope" yaml:"scope"`
+	// Path is the unit file; empty for a Windows service.
+	Path string `json:"path,omitempty" yaml:"path,omitempty"`
+	// Unit is the name the service manager knows the unit by.
+	Unit string `json:"unit" yaml:"unit"`
+}
+
+// serviceManager picks the manager from the flags, or the platform's.
+func serviceManager(t ServiceTarget) (string, error) {
+	var chosen []string
+	for _, m := range []struct {
+		name string
+		set  bool
+	}{{ServiceSystemd, t.Systemd}, {ServiceLaunchd, t.Launchd}, {ServiceWindows, t.Windows}} {
+		if m.set {
+			chosen = append(chosen, m.name)
+		}
+	}
+	if len(chosen) > 1 {
+		return "", UsageError(errors.New("--systemd, --launchd, and --windows are mutually exclusive"))
+	}
+	if len(chosen) == 1 {
+		return chosen[0], nil
+	}
+	switch runtime.GOOS {
+	case "linux":
+		return ServiceSystemd, nil
+	case "darwin":
+		return ServiceLaunchd, nil
+	case "windows":
+		return ServiceWindows, nil
+	}
+	return "", UsageError(fmt.Errorf("no supported service manager on %s; choose --systemd or --launchd", runtime.GOOS))
+}
+
+func serviceResult(manager string, t ServiceTarget, path string) ServiceResult {
+	r := ServiceResult{Manager: manager, Scope: "user", Path: path, Unit: appName + ".service"}
+	if t.System || manager == ServiceWindows {
+		r.Scope = "system"
+	}
+	switch manager {
+	case ServiceLaunchd:
+		r.Unit = launchdLabel()
+	case ServiceWindows:
+		r.Unit = appName
+	}
+	return r
+}
+
+// systemdStopTimeout gives `daemon stop`'s own wait a margin before
+// systemd kills the daemon.
+const systemdStopTimeout = daemonStopTimeout + 15*time.Second
+
+var systemdUnitTemplate = template.Must(template.New("unit").Funcs(template.FuncMap{
+	"q":    systemdQuote,
+	"secs": func(d time.Duration) int { return int(d.Round(time.Second) / time.Second) },
+}).Parse(`# Generated by {{.Name}} service install --systemd.
+[Unit]
+Description={{.Description}}
+After=network-online.target
+Wants=network-online.target
+
+[Service]
+Type=notify
+NotifyAccess=main
+ExecStart={{q .Binary}} --config {{q .Config}} daemon start --foreground
+ExecReload={{q .Binary}} --config {{q .Config}} daemon reload
+WorkingDirectory={{q .WorkDir}}
+{{- range .Env}}
+Environment={{q .}}
+{{- end}}
+{{- if .RunAs}}
+User={{.RunAs}}
+{{- end}}
+Restart=on-failure
+RestartSec=5s
+TimeoutStopSec={{secs .StopTimeout}}
+{{- if .Watchdog}}
+WatchdogSec={{secs .Watchdog}}
+{{- end}}
+{{- if ne .Hardening "none"}}
+
+# Hardening ({{.Hardening}}); see systemd.exec(5).
+NoNewPrivileges=yes
+PrivateTmp=yes
+ProtectKernelTunables=yes
+ProtectKernelModules=yes
+ProtectControlGroups=yes
+RestrictSUIDSGID=yes
+RestrictRealtime=yes
+LockPersonality=yes
+{{- if eq .Hardening "strict"}}
+ProtectSystem=strict
+ProtectHome=read-only
+PrivateDevices=yes
+RestrictNamespaces=yes
+SystemCallArchitectures=native
+UMask=0027
+{{- range .Writable}}
+ReadWritePaths=-{{q .}}
+{{- end}}
+{{- else}}
+ProtectSystem=full
+{{- end}}
+{{- end}}
+
+[Install]
+WantedBy={{.WantedBy}}
+`))
+
+// launchdPlistTemplate keeps the job alive only after a failure, like
+// Restart=on-failure: `daemon stop` exits cleanly and stays stopped.
+var launchdPlistTemplate = template.Must(template.New("plist").Funcs(template.FuncMap{
+	"x": func(s string) string {
+		var b strings.Builder
+		xml.EscapeText(&b, []byte(s))
+		return b.String()
+	},
+	"secs": func(d time.Duration) int { return int(d.Round(time.Second) / time.Second) },
+}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<!-- Generated by {{.Name}} service install for launchd. -->
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>{{x .Label}}</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>{{x .Binary}}</string>
+		<string>--config</string>
+		<string>{{x .Config}}</string>
+		<string>daemon</string>
+		<string>start</string>
+		<string>--foreground</string>
+	</array>
+	<key>WorkingDirectory</key>
+	<string>{{x .WorkDir}}</string>
+	<key>EnvironmentVariables</key>
+	<dict>
+{{- range $k, $v := .EnvMap}}
+		<key>{{x $k}}</key>
+		<string>{{x $v}}</string>
+{{- end}}
+	</dict>
+{{- if .RunAs}}
+	<key>UserName</key>
+	<string>{{x .RunAs}}</string>
+{{- end}}
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+	<key>ThrottleInterval</key>
+	<integer>5</integer>
+	<key>ExitTimeOut</key>
+	<integer>{{secs .StopTimeout}}</integer>
+	<key>ProcessType</key>
+	<string>Background</string>
+	<key>StandardOutPath</key>
+	<string>{{x .LogFile}}</string>
+	<key>StandardErrorPath</key>
+	<string>{{x .LogFile}}</string>
+</dict>
+</plist>
+`))
+
+// systemdQuote quotes s for a unit file when it needs it. Specifiers (%)
+// and variables ($) are escaped either way.
+func systemdQuote(s string) string {
+	s = strings.NewReplacer("%", "%%", "$", "$$").Replace(s)
+	if s != "" && !strings.ContainsAny(s, " \t\"'\\") {
+		return s
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// serviceUnitPath returns where the manager expects the unit: the user's
+// systemd unit directory or LaunchAgents, or the system-wide counterpart.
+// Windows services have no file; the path is empty.
+func serviceUnitPath(manager string, system bool) (string, error) {
+	switch {
+	case manager == ServiceWindows:
+		return "", nil
+	case manager == ServiceSystemd && system:
+		return filepath.Join("/etc/systemd/system", appName+".service"), nil
+	case manager == ServiceLaunchd && system:
+		return filepath.Join("/Library/LaunchDaemons", launchdLabel()+".plist"), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("determine home directory: %w", err)
+	}
+	if manager == ServiceLaunchd {
+		return filepath.Join(home, "Library", "LaunchAgents", launchdLabel()+".plist"), nil
+	}
+	if dir := os.Getenv("XDG_CONFIG_HOME"); dir != "" {
+		return filepath.Join(dir, "systemd", "user", appName+".service"), nil
+	}
+	return filepath.Join(home, ".config", "systemd", "user", appName+".service"), nil
+}
+
+// serviceSpec is what a unit runs: this binary in the foreground with the
+// config file, working directory, and data and state directories it was
+// installed with.
+type serviceSpec struct {
+	Binary  string
+	Config  string
+	WorkDir string
+	// Env pins the data and state directories: the daemon must use the
+	// state directory that `daemon status` and friends look in, whatever
+	// the unit's environment says.
+	Env   map[string]string
+	RunAs string
+}
+
+func newServiceSpec(ctx *RuntimeContext, runAs string) (serviceSpec, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return serviceSpec{}, fmt.Errorf("locate executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return serviceSpec{}, err
+	}
+	config, err := filepath.Abs(ctx.Paths.ConfigFile)
+	if err != nil {
+		return serviceSpec{}, err
+	}
+	return serviceSpec{
+		Binary:  exe,
+		Config:  config,
+		WorkDir: wd,
+		Env: map[string]string{
+			EnvPrefix() + "_PATHS__DATA_DIR":  ctx.Paths.DataDir,
+			EnvPrefix() + "_PATHS__STATE_DIR": ctx.Paths.StateDir,
+		},
+		RunAs: runAs,
+	}, nil
+}
+
+// EnvList returns Env as sorted KEY=VALUE pairs.
+func (s serviceSpec) EnvList() []string {
+	list := make([]string, 0, len(s.Env))
+	for _, k := range slices.Sorted(maps.Keys(s.Env)) {
+		list = append(list, k+"="+s.Env[k])
+	}
+	return list
+}
+
+// renderServiceUnit renders the systemd unit or launchd plist for spec.
+func renderServiceUnit(ctx *RuntimeContext, manager string, spec serviceSpec, opts ServiceInstallOptions) ([]byte, error) {
+	wantedBy := "default.target"
+	if opts.System {
+		wantedBy = "multi-user.target"
+	}
+	tmpl := systemdUnitTemplate
+	if manager == ServiceLaunchd {
+		tmpl = launchdPlistTemplate
+	}
+	var buf bytes.Buffer
+	err := tmpl.Execute(&buf, map[string]any{
+		"Name":        appName,
+		"Description": serviceDescription(),
+		"Label":       launchdLabel(),
+		"Binary":      spec.Binary,
+		"Config":      spec.Config,
+		"WorkDir":     spec.WorkDir,
+		"Env":         spec.EnvList(),
+		"EnvMap":      spec.Env,
+		"RunAs":       spec.RunAs,
+		"StopTimeout": systemdStopTimeout,
+		"Watchdog":    opts.Watchdog,
+		"Hardening":   opts.Hardening,
+		"Writable":    []string{ctx.Paths.DataDir, ctx.Paths.StateDir, ctx.Paths.CacheDir, spec.WorkDir},
+		"WantedBy":    wantedBy,
+		"LogFile":     daemonLogPath(ctx.Paths.StateDir),
+	})
+	return buf.Bytes(), err
+}
+
+// Actions of serviceCommands.
+const (
+	serviceEnable  = "enable"
+	serviceStart   = "start"
+	serviceStop    = "stop"
+	serviceDisable = "disable"
+)
+
+// serviceCommands returns the systemctl or launchctl commands that perform
+// action on an installed unit. launchd has no separate enable step: a
+// loaded job starts at once.
+func serviceCommands(manager string, t ServiceTarget, path, action string) [][]string {
+	if manager == ServiceLaunchd {
+		domain := "system"
+		if !t.System {
+			domain = fmt.Sprintf("gui/%d", os.Getuid())
+		}
+		if action == serviceEnable || action == serviceStart {
+			return [][]string{{"launchctl", "bootstrap", domain, path}}
+		}
+		return [][]string{{"launchctl", "bootout", domain, path}}
+	}
+	systemctl := func(args ...string) []string {
+		if t.System {
+			return append([]string{"systemctl"}, args...)
+		}
+		return append([]string{"systemctl", "--user"}, args...)
+	}
+	unit := serviceResult(manager, t, path).Unit
+	switch action {
+	case serviceEnable:
+		return [][]string{systemctl("daemon-reload"), systemctl("enable", "--now", unit)}
+	case serviceDisable:
+		return [][]string{systemctl("disable", "--now", unit)}
+	}
+	return [][]string{systemctl(action, unit)}
+}
+
+// runServiceCommands runs commands, stopping at the first that fails.
+func runServiceCommands(ctx *RuntimeContext, commands [][]string) error {
+	for _, args := range commands {
+		out, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+		if err != nil {
+			if msg := strings.TrimSpace(string(out)); msg != "" {
+				return fmt.Errorf("%s: %s", strings.Join(args, " "), msg)
+			}
+			return fmt.Errorf("%s: %w", strings.Join(args, " "), err)
+		}
+	}
+	return nil
+}
+
+// HandleServiceInstall registers the daemon with the service manager: it
+// writes a systemd unit or launchd plist without starting it (the result
+// says how), or creates a Windows service that starts with the system.
+func HandleServiceInstall(ctx *RuntimeContext, opts ServiceInstallOptions) error {
+	manager, err := serviceManager(opts.ServiceTarget)
+	if err != nil {
+		return err
+	}
+	if !slices.Contains(ServiceHardeningLevels, opts.Hardening) {
+		return UsageError(fmt.Errorf("invalid --hardening %q (expected %s)", opts.Hardening, strings.Join(ServiceHardeningLevels, ", ")))
+	}
+	if opts.RunAs != "" && !opts.System && manager != ServiceWindows {
+		return UsageError(errors.New("--run-as applies to system units; add --system"))
+	}
+	if opts.Watchdog < 0 {
+		return UsageError(errors.New("--watchdog must not be negative"))
+	}
+	if opts.Output != "" && manager == ServiceWindows {
+		return UsageError(errors.New("--output does not apply to Windows services"))
+	}
+	if !ctx.Config.Daemon.Scheduler && ctx.Config.Daemon.WatchTask == "" {
+		ctx.Logger.Warn("the daemon has nothing to do yet: enable daemon.scheduler or set daemon.watch_task")
+	}
+	spec, err := newServiceSpec(ctx, opts.RunAs)
+	if err != nil {
+		return err
+	}
+
+	if manager == ServiceWindows {
+		if ctx.Common.DryRun {
+			ctx.Logger.Info("dry-run: would create Windows service %s", appName)
+			return nil
+		}
+		if err := installWindowsService(spec, opts.Force); err != nil {
+			return err
+		}
+		result := serviceResult(manager, opts.ServiceTarget, "")
+		if handled, err := printServiceResult(ctx, result); handled || err != nil {
+			return err
+		}
+		ctx.Out.Success(ctx.Out.Sprintf(msgServiceCreated, result.Unit))
+		ctx.Out.Println(ctx.Out.Sprintf(msgServiceStartWith, appName+" service start"))
+		return nil
+	}
+
+	unit, err := renderServiceUnit(ctx, manager, spec, opts)
+	if err != nil {
+		return err
+	}
+	if opts.Output == "-" {
+		_, err := ctx.Out.Writer().Write(unit)
+		return err
+	}
+	path := opts.Output
+	if path == "" {
+		if path, err = serviceUnitPath(manager, opts.System); err != nil {
+			return err
+		}
+	}
+	if _, err := os.Stat(path); err == nil && !opts.Force {
+		return fmt.Errorf("%s already exists; pass --force to replace it", path)
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if ctx.Common.DryRun {
+		ctx.Logger.Info("dry-run: would write %s unit %s", manager, path)
+		return nil
+	}
+	if err := writeFileAtomic(path, unit, 0o644); err != nil {
+		return fmt.Errorf("write unit: %w", err)
+	}
+
+	result := serviceResult(manager, opts.ServiceTarget, path)
+	if handled, err := printServiceResult(ctx, result); handled || err != nil {
+		return err
+	}
+	ctx.Out.Success(ctx.Out.Sprintf(msgServiceWrote, result.Scope, manager, path))
+	lines := []string{}
+	for _, args := range serviceCommands(manager, opts.ServiceTarget, path, serviceEnable) {
+		lines = append(lines, strings.Join(args, " "))
+	}
+	ctx.Out.Println(ctx.Out.Sprintf(msgServiceStartWith, strings.Join(lines, " && ")))
+	return nil
+}
+
+// installedService resolves the target to an installed unit.
+func installedService(t ServiceTarget) (string, ServiceResult, error) {
+	manager, err := serviceManager(t)
+	if err != nil {
+		return "", ServiceResult{}, err
+	}
+	path, err := serviceUnitPath(manager, t.System)
+	if err != nil {
+		return "", ServiceResult{}, err
+	}
+	result := serviceResult(manager, t, path)
+	if manager == ServiceWindows {
+		return manager, result, nil
+	}
+	if _, err := os.Stat(path); errors.Is(err, fs.ErrNotExist) {
+		return "", ServiceResult{}, fmt.Errorf("no %s %s unit installed at %s", result.Scope, manager, path)
+	}
+	return manager, result, nil
+}
+
+// HandleServiceStart starts the installed unit.
+func HandleServiceStart(ctx *RuntimeContext, t ServiceTarget) error {
+	return serviceAction(ctx, t, serviceStart)
+}
+
+// HandleServiceStop stops the installed unit and waits for the daemon to
+// exit.
+func HandleServiceStop(ctx *RuntimeContext, t ServiceTarget) error {
+	return serviceAction(ctx, t, serviceStop)
+}
+
+func serviceAction(ctx *RuntimeContext, t ServiceTarget, action string) error {
+	manager, result, err := installedService(t)
+	if err != nil {
+		return err
+	}
+	if ctx.Common.DryRun {
+		ctx.Logger.Info("dry-run: would %s %s unit %s", action, manager, result.Unit)
+		return nil
+	}
+	if manager == ServiceWindows {
+		if action == serviceStart {
+			err = startWindowsService()
+		} else {
+			err = stopWindowsService(daemonStopTimeout)
+		}
+	} else {
+		err = runServiceCommands(ctx, serviceCommands(manager, t, result.Path, action))
+	}
+	if err != nil {
+		return err
+	}
+	if handled, err := printServiceResult(ctx, result); handled || err != nil {
+		return err
+	}
+	done := map[string]string{serviceStart: msgServiceStarted, serviceStop: msgServiceStopped}[action]
+	ctx.Out.Success(ctx.Out.Sprintf(done, manager, result.Unit))
+	return nil
+}
+
+// HandleServiceUninstall stops the installed unit and removes it. For
+// systemd and launchd, stopping is best effort: a unit that is not loaded
+// is removed all the same.
+func HandleServiceUninstall(ctx *RuntimeContext, t ServiceTarget) error {
+	manager, result, err := installedService(t)
+	if err != nil {
+		return err
+	}
+	if ctx.Common.DryRun {
+		ctx.Logger.Info("dry-run: would stop and remove %s unit %s", manager, result.Unit)
+		return nil
+	}
+
+	if manager == ServiceWindows {
+		if err := uninstallWindowsService(daemonStopTimeout); err != nil {
+			return err
+		}
+	} else {
+		for _, args := range serviceCommands(manager, t, result.Path, serviceDisable) {
+			if _, err := exec.LookPath(args[0]); err != nil {
+				ctx.Logger.Debug("not stopping the unit: %v", err)
+				break
+			}
+			if err := runServiceCommands(ctx, [][]string{args}); err != nil {
+				ctx.Logger.Debug("%v", err)
+			}
+		}
+		if err := os.Remove(result.Path); err != nil {
+			return fmt.Errorf("remove unit: %w", err)
+		}
+	}
+
+	if handled, err := printServiceResult(ctx, result); handled || err != nil {
+		return err
+	}
+	ctx.Out.Success(ctx.Out.Sprintf(msgServiceRemoved, result.Scope, manager, result.Unit))
+	return nil
+}
+
+// printServiceResult prints result in the machine-readable formats and
+// reports whether one was selected.
+func printServiceResult(ctx *RuntimeContext, result ServiceResult) (bool, error) {
+	switch {
+	case ctx.Common.JSON:
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return true, err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+	case ctx.Common.YAML:
+		data, err := yaml.Marshal(result)
+		if err != nil {
+			return true, err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		fmt.Fprintln(ctx.Out.Writer(), cmp.Or(result.Path, result.Unit))
+	default:
+		return false, nil
+	}
+	return true, nil
+}
+
+// notifySystemd tells systemd about the daemon's state when it runs as a
+// Type=notify unit.
+func notifySystemd(ctx *RuntimeContext, states ...string) {
+	if _, err := sdnotify.Notify(states...); err != nil {
+		ctx.Logger.Debug("notify systemd: %v", err)
+	}
+}
+
+// watchdog pings systemd's watchdog every interval while the control
+// socket answers, so systemd restarts a daemon whose control loop hangs.
+func watchdog(ctx *RuntimeContext, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if _, err := queryInstance(ctx, ctx.Paths.StateDir, control.CmdStatus); err != nil {
+			ctx.Logger.Warn("watchdog: control socket not answering, skipping the ping: %v", err)
+			continue
+		}
+		notifySystemd(ctx, sdnotify.Watchdog)
+	}
+}
+
+// daemonSummary is the STATUS= line systemctl status shows.
+func daemonSummary(status DaemonStatus) string {
+	return "running " + strings.Join(daemonLoops(status), ", ")
+}
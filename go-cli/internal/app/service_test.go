@@ -0,0 +1,35 @@
+package app
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderServiceUnit(t *testing.T) {
+	ctx := &RuntimeContext{Paths: AppPaths{DataDir: "/data", StateDir: "/state", CacheDir: "/cache"}}
+	spec := serviceSpec{
+		Binary:  "/usr/local/bin/" + appName,
+		Config:  "/etc/" + appName + "/config.toml",
+		WorkDir: "/srv",
+		Env:     map[string]string{"A": "1 & 2"},
+	}
+	for _, tt := range []struct {
+		manager string
+		want    []string
+	}{
+		{ServiceSystemd, []string{"Description=" + serviceDescription(), "ExecStart=", "WantedBy=default.target"}},
+		{ServiceLaunchd, []string{"<string>" + launchdLabel() + "</string>", "<string>/srv</string>", "<string>1 &amp; 2</string>"}},
+	} {
+		t.Run(tt.manager, func(t *testing.T) {
+			out, err := renderServiceUnit(ctx, tt.manager, spec, ServiceInstallOptions{})
+			if err != nil {
+				t.Fatalf("renderServiceUnit: %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(string(out), want) {
+					t.Errorf("unit does not contain %q:\n%s", want, out)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,24 @@
+//go:build !windows
+
+package app
+
+import (
+	"errors"
+	"time"
+)
+
+var errWindowsOnly = errors.New("the Windows service control manager is only available on Windows")
+
+func installWindowsService(serviceSpec, bool) error { return errWindowsOnly }
+
+func startWindowsService() error { return errWindowsOnly }
+
+func stopWindowsService(time.Duration) error { return errWindowsOnly }
+
+func uninstallWindowsService(time.Duration) error { return errWindowsOnly }
+
+// runAsService runs the daemon directly; only Windows has a service
+// control manager to hand it to.
+func runAsService(ctx *RuntimeContext, run func(*RuntimeContext) error) error {
+	return run(ctx)
+}
@@ -0,0 +1,264 @@
+//go:build windows
+
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/registry"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/eventlog"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// eventID is the ID of every event the daemon logs; the source registered
+// with InstallAsEventCreate accepts 1 to 1000.
+const eventID = 1
+
+// connectServiceManager connects to the service control manager, which
+// needs an elevated prompt for anything but queries.
+func connectServiceManager() (*mgr.Mgr, error) {
+	m, err := mgr.Connect()
+	if err != nil {
+		return nil, fmt.Errorf("connect to the service control manager (run as administrator): %w", err)
+	}
+	return m, nil
+}
+
+// installWindowsService creates a service that starts with the system and
+// registers the event-log source the daemon logs to. A service that exits
+// with an error is restarted, like Restart=on-failure.
+func installWindowsService(spec serviceSpec, force bool) error {
+	m, err := connectServiceManager()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	if s, err := m.OpenService(appName); err == nil {
+		s.Close()
+		if !force {
+			return fmt.Errorf("service %s already exists; pass --force to replace it", appName)
+		}
+		if err := uninstallWindowsService(daemonStopTimeout); err != nil {
+			return err
+		}
+	}
+
+	// Services start in the system directory; --workdir takes the daemon
+	// back to where the task file is.
+	args := []string{"--config", spec.Config, "daemon", "start", "--foreground", "--workdir", spec.WorkDir}
+	s, err := m.CreateService(appName, spec.Binary, mgr.Config{
+		DisplayName:      appName,
+		Description:      serviceDescription(),
+		StartType:        mgr.StartAutomatic,
+		ServiceStartName: spec.RunAs,
+	}, args...)
+	if err != nil {
+		return fmt.Errorf("create service: %w", err)
+	}
+	defer s.Close()
+	err = errors.Join(
+		s.SetRecoveryActions([]mgr.RecoveryAction{{Type: mgr.ServiceRestart, Delay: 5 * time.Second}}, uint32((24*time.Hour).Seconds())),
+		s.SetRecoveryActionsOnNonCrashFailures(true),
+		setServiceEnv(spec.EnvList()),
+	)
+	if err == nil {
+		err = eventlog.InstallAsEventCreate(appName, eventlog.Error|eventlog.Warning|eventlog.Info)
+		if errors.Is(err, windows.ERROR_ALREADY_EXISTS) {
+			err = nil
+		}
+	}
+	if err != nil {
+		s.Delete()
+		return fmt.Errorf("configure service: %w", err)
+	}
+	return nil
+}
+
+// setServiceEnv sets the environment the service control manager starts
+// the service with.
+func setServiceEnv(env []string) error {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, `SYSTEM\CurrentControlSet\Services\`+appName, registry.SET_VALUE)
+	if err != nil {
+		return err
+	}
+	defer k.Close()
+	return k.SetStringsValue("Environment", env)
+}
+
+func openWindowsService() (*mgr.Mgr, *mgr.Service, error) {
+	m, err := connectServiceManager()
+	if err != nil {
+		return nil, nil, err
+	}
+	s, err := m.OpenService(appName)
+	if err != nil {
+		m.Disconnect()
+		return nil, nil, fmt.Errorf("service %s is not installed: %w", appName, err)
+	}
+	return m, s, nil
+}
+
+// waitForService polls until the service reaches state.
+func waitForService(s *mgr.Service, state svc.State, timeout time.Duration) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		status, err := s.Query()
+		if err != nil {
+			return err
+		}
+		if status.State == state {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("service %s did not reach state %d within %s", appName, state, timeout)
+		}
+		time.Sleep(daemonPoll)
+	}
+}
+
+// startWindowsService starts the service and waits until it runs.
+func startWindowsService() error {
+	m, s, err := openWindowsService()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	defer s.Close()
+	if err := s.Start(); err != nil {
+		return fmt.Errorf("start service: %w", err)
+	}
+	return waitForService(s, svc.Running, daemonStartupTimeout)
+}
+
+// stopWindowsService stops the service and waits until it has exited.
+func stopWindowsService(timeout time.Duration) error {
+	m, s, err := openWindowsService()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	defer s.Close()
+	return stopService(s, timeout)
+}
+
+func stopService(s *mgr.Service, timeout time.Duration) error {
+	status, err := s.Query()
+	if err != nil {
+		return err
+	}
+	if status.State == svc.Stopped {
+		return nil
+	}
+	if _, err := s.Control(svc.Stop); err != nil {
+		return fmt.Errorf("stop service: %w", err)
+	}
+	return waitForService(s, svc.Stopped, timeout)
+}
+
+// uninstallWindowsService stops and deletes the service and removes its
+// event-log source.
+func uninstallWindowsService(timeout time.Duration) error {
+	m, s, err := openWindowsService()
+	if err != nil {
+		return err
+	}
+	defer m.Disconnect()
+	defer s.Close()
+	if err := stopService(s, timeout); err != nil {
+		return err
+	}
+	if err := s.Delete(); err != nil {
+		return fmt.Errorf("delete service: %w", err)
+	}
+	if err := eventlog.Remove(appName); err != nil && !errors.Is(err, windows.ERROR_FILE_NOT_FOUND) {
+		return fmt.Errorf("remove event log source: %w", err)
+	}
+	return nil
+}
+
+// runAsService runs the daemon under the service control manager when it
+// started the process, with the log mirrored to the Windows event log, and
+// directly otherwise.
+func runAsService(ctx *RuntimeContext, run func(*RuntimeContext) error) error {
+	if isService, err := svc.IsWindowsService(); err != nil || !isService {
+		return run(ctx)
+	}
+	if elog, err := eventlog.Open(appName); err == nil {
+		defer elog.Close()
+		ctx.Logger = ctx.Logger.tee(ConfigureLogger(LogSettings{
+			Level:   LevelInfo,
+			Format:  FormatJSON,
+			Writers: []io.Writer{eventLogWriter{elog}},
+		}))
+	}
+	service := &windowsService{ctx: ctx, run: run}
+	if err := svc.Run(appName, service); err != nil {
+		return err
+	}
+	return service.err
+}
+
+// windowsService adapts the daemon to svc.Handler.
+type windowsService struct {
+	ctx *RuntimeContext
+	run func(*RuntimeContext) error
+	err error
+}
+
+// Execute implements svc.Handler. Stop and shutdown requests cancel the
+// daemon's context, as SIGTERM does elsewhere; an error exit makes the
+// service control manager apply the recovery actions.
+func (w *windowsService) Execute(_ []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+	runCtx, cancel := context.WithCancel(w.ctx.Context)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- w.run(w.ctx.fork(runCtx)) }()
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+	for {
+		select {
+		case err := <-done:
+			w.err = err
+			if err != nil {
+				return false, 1
+			}
+			return false, 0
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending, WaitHint: uint32(daemonStopTimeout.Milliseconds())}
+				cancel()
+			}
+		}
+	}
+}
+
+// eventLogWriter turns the JSON log records it is given into events of the
+// matching type.
+type eventLogWriter struct {
+	log *eventlog.Log
+}
+
+func (w eventLogWriter) Write(p []byte) (int, error) {
+	var record jsonRecord
+	if err := json.Unmarshal(p, &record); err != nil {
+		return len(p), w.log.Info(eventID, string(p))
+	}
+	switch record.Level {
+	case "error":
+		return len(p), w.log.Error(eventID, record.Msg)
+	case "warn":
+		return len(p), w.log.Warning(eventID, record.Msg)
+	}
+	return len(p), w.log.Info(eventID, record.Msg)
+}
@@ -0,0 +1,23 @@
+package app
+
+import (
+	"os"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/execx"
+)
+
+// Command returns a subprocess running line through the platform shell,
+// logging to rtx.Logger at debug level and honoring --dry-run. The child
+// inherits only the variables allowed by exec.env_passthrough, plus
+// exec.env, and during a run GO_CLI_ARTIFACTS_DIR and the GO_CLI_GIT_*
+// variables. Callers set Dir, Env, Timeout, capture, or OnLine as needed
+// and pass it to execx.Run.
+func (rtx *RuntimeContext) Command(line string) execx.Command {
+	cfg := rtx.Config.Exec
+	return execx.Command{
+		Shell:   line,
+		BaseEnv: append(append(append(execx.Scrub(os.Environ(), cfg.EnvPassthrough), cfg.Env...), rtx.artifactsEnv()...), rtx.gitEnviron()...),
+		Logger:  rtx.Logger,
+		DryRun:  rtx.Common.DryRun,
+	}
+}
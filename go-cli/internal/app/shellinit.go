@@ -0,0 +1,187 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+// ShellInitOptions configure the snippet printed by `shell-init`.
+type ShellInitOptions struct {
+	Shell string
+	// Binary is the path the snippet runs; Name is the program name cobra
+	// generates completions for.
+	Binary string
+	Name   string
+	// Cmd names the wrapper function; it defaults to Name.
+	Cmd string
+}
+
+// ShellInitShells lists the shells `shell-init` supports.
+var ShellInitShells = []string{"bash", "zsh", "fish"}
+
+var shellFuncName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// cdFileEnv names the file the shell-init wrapper reads a directory from
+// after each command; see requestCd.
+func cdFileEnv() string {
+	return EnvPrefix() + "_CD_FILE"
+}
+
+var shellInitTemplates = map[string]string{
+	"bash": `# {{.Name}} shell integration for bash. Add to ~/.bashrc:
+#   eval "$({{.Name}} shell-init bash)"
+export {{.Prefix}}_SHELL=bash
+export {{.Prefix}}_BIN={{sh .Binary}}
+
+if [ "${BASH_VERSINFO[0]:-0}" -ge 4 ]; then
+  source <(command "${{.Prefix}}_BIN" completions bash)
+fi
+
+{{.Cmd}}() {
+  local cd_file code
+  cd_file="$(mktemp "${TMPDIR:-/tmp}/{{.Name}}-cd.XXXXXX")" || { command "${{.Prefix}}_BIN" "$@"; return; }
+  {{.Prefix}}_CD_FILE="$cd_file" command "${{.Prefix}}_BIN" "$@"
+  code=$?
+  if [ -s "$cd_file" ]; then
+    builtin cd -- "$(cat "$cd_file")" || code=$?
+  fi
+  rm -f -- "$cd_file"
+  return "$code"
+}
+{{- if ne .Cmd .Name}}
+complete -o default -F __start_{{.Name}} {{.Cmd}}
+{{- end}}
+`,
+	"zsh": `# {{.Name}} shell integration for zsh. Add to ~/.zshrc, after compinit:
+#   eval "$({{.Name}} shell-init zsh)"
+export {{.Prefix}}_SHELL=zsh
+export {{.Prefix}}_BIN={{sh .Binary}}
+
+if (( $+functions[compdef] )); then
+  source <(command "${{.Prefix}}_BIN" completions zsh)
+{{- if ne .Cmd .Name}}
+  compdef _{{.Name}} {{.Cmd}}
+{{- end}}
+fi
+
+{{.Cmd}}() {
+  local cd_file code
+  cd_file="$(mktemp "${TMPDIR:-/tmp}/{{.Name}}-cd.XXXXXX")" || { command "${{.Prefix}}_BIN" "$@"; return; }
+  {{.Prefix}}_CD_FILE="$cd_file" command "${{.Prefix}}_BIN" "$@"
+  code=$?
+  if [[ -s "$cd_file" ]]; then
+    builtin cd -- "$(<"$cd_file")" || code=$?
+  fi
+  command rm -f -- "$cd_file"
+  return "$code"
+}
+`,
+	"fish": `# {{.Name}} shell integration for fish. Add to ~/.config/fish/config.fish:
+#   {{.Name}} shell-init fish | source
+set -gx {{.Prefix}}_SHELL fish
+set -gx {{.Prefix}}_BIN {{fish .Binary}}
+
+command ${{.Prefix}}_BIN completions fish | source
+
+function {{.Cmd}}{{if ne .Cmd .Name}} --wraps {{.Name}}{{end}} --description '{{.Name}} with shell integration'
+    set -l cd_file (mktemp (set -q TMPDIR; and echo $TMPDIR; or echo /tmp)/{{.Name}}-cd.XXXXXX)
+    or begin
+        command ${{.Prefix}}_BIN $argv
+        return
+    end
+    env {{.Prefix}}_CD_FILE=$cd_file ${{.Prefix}}_BIN $argv
+    set -l code $status
+    if test -s $cd_file
+        builtin cd -- (cat $cd_file); or set code $status
+    end
+    command rm -f -- $cd_file
+    return $code
+end
+`,
+}
+
+// HandleShellInit prints the integration snippet for opts.Shell: it loads
+// completions, exports <PREFIX>_SHELL and <PREFIX>_BIN, and defines a
+// wrapper function that changes directory when a command such as `cd`
+// asks it to.
+func HandleShellInit(ctx *RuntimeContext, opts ShellInitOptions) error {
+	text, ok := shellInitTemplates[opts.Shell]
+	if !ok {
+		return UsageError(fmt.Errorf("unsupported shell %q (expected %s)", opts.Shell, strings.Join(ShellInitShells, ", ")))
+	}
+	if opts.Cmd == "" {
+		opts.Cmd = opts.Name
+	}
+	if !shellFuncName.MatchString(opts.Cmd) {
+		return UsageError(fmt.Errorf("invalid --cmd %q: not a valid function name", opts.Cmd))
+	}
+
+	tmpl := template.Must(template.New(opts.Shell).Funcs(template.FuncMap{
+		"sh":   shQuote,
+		"fish": fishQuote,
+	}).Parse(text))
+	return tmpl.Execute(ctx.Out.Writer(), struct {
+		ShellInitOptions
+		Prefix string
+	}{opts, EnvPrefix()})
+}
+
+// HandleCd prints the directory name refers to: config, data (the
+// default), state, or cache. Under the shell-init wrapper the shell then
+// changes into it.
+func HandleCd(ctx *RuntimeContext, name string) error {
+	dirs := map[string]string{
+		"config": filepath.Dir(ctx.Paths.ConfigFile),
+		"data":   ctx.Paths.DataDir,
+		"state":  ctx.Paths.StateDir,
+		"cache":  ctx.Paths.CacheDir,
+	}
+	if name == "" {
+		name = "data"
+	}
+	dir, ok := dirs[name]
+	if !ok {
+		return UsageError(fmt.Errorf("unknown directory %q (expected config, data, state, or cache)", name))
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("create %s directory: %w", name, err)
+	}
+
+	requested, err := requestCd(dir)
+	if err != nil {
+		return err
+	}
+	if !requested {
+		ctx.Out.Println(dir)
+	}
+	return nil
+}
+
+// requestCd asks the shell-init wrapper to change into dir once the
+// command exits. It reports false when the command runs without the
+// wrapper.
+func requestCd(dir string) (bool, error) {
+	path := os.Getenv(cdFileEnv())
+	if path == "" {
+		return false, nil
+	}
+	if err := os.WriteFile(path, []byte(dir), 0o600); err != nil {
+		return false, fmt.Errorf("request directory change: %w", err)
+	}
+	return true, nil
+}
+
+// shQuote quotes s for POSIX shells.
+func shQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote quotes s for fish, where backslashes escape inside single
+// quotes.
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`).Replace(s) + "'"
+}
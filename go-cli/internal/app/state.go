@@ -0,0 +1,444 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/humanize"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/statestore"
+)
+
+// State entry kinds.
+const (
+	StateDatabase   = "database"
+	StateHistory    = "history"
+	StateCheckpoint = "checkpoint"
+	StateTaskLogs   = "task logs"
+	StateInputs     = "input fingerprints"
+	StateLock       = "lock"
+	StateDaemon     = "daemon"
+	StateShell      = "shell history"
+	StateDataDir    = "data location"
+	StateVersion    = "last version"
+	StateAudit      = "audit log"
+	StateOther      = "other"
+)
+
+// ShellHistoryPath is where the interactive shell keeps its history.
+func ShellHistoryPath(stateDir string) string {
+	return filepath.Join(stateDir, "shell_history")
+}
+
+// defaultPruneAge is how old state must be for state prune to remove it
+// when --older-than is not given.
+const defaultPruneAge = 30 * 24 * time.Hour
+
+// StateEntry is one item in the state directory: a file, or a directory
+// of per-run task logs. Key is its path relative to the state directory.
+type StateEntry struct {
+	Key      string    `json:"key" yaml:"key"`
+	Kind     string    `json:"kind" yaml:"kind"`
+	Path     string    `json:"path" yaml:"path"`
+	Files    int       `json:"files" yaml:"files"`
+	Bytes    int64     `json:"bytes" yaml:"bytes"`
+	Modified time.Time `json:"modified" yaml:"modified"`
+}
+
+// stateKind classifies a top-level name in the state directory.
+func stateKind(stateDir, path string) string {
+	switch {
+	case isStateDBFile(stateDir, path):
+		return StateDatabase
+	case filepath.Dir(path) == taskLogsDir(stateDir):
+		return StateTaskLogs
+	case path == inputsPath(stateDir):
+		return StateInputs
+	case path == ShellHistoryPath(stateDir):
+		return StateShell
+	case path == dataDirRecordPath(stateDir):
+		return StateDataDir
+	case path == lastVersionPath(stateDir):
+		return StateVersion
+	case path == auditLogPath(stateDir):
+		return StateAudit
+	case path == lockPath(stateDir):
+		return StateLock
+	case path == daemonPIDPath(stateDir), path == daemonLogPath(stateDir), path == controlSocketPath(stateDir):
+		return StateDaemon
+	}
+	return StateOther
+}
+
+// loadState lists the state directory. Task log runs are listed one by
+// one; other directories count as a single entry.
+func loadState(stateDir string) ([]StateEntry, error) {
+	var entries []StateEntry
+	var scan func(dir string) error
+	scan = func(dir string) error {
+		items, err := os.ReadDir(dir)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read state directory: %w", err)
+		}
+		for _, item := range items {
+			path := filepath.Join(dir, item.Name())
+			if item.IsDir() && path == taskLogsDir(stateDir) {
+				if err := scan(path); err != nil {
+					return err
+				}
+				continue
+			}
+			entry, err := statEntry(stateDir, path)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	}
+	if err := scan(stateDir); err != nil {
+		return nil, err
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries, nil
+}
+
+// statEntry describes path, summing files below it for directories.
+func statEntry(stateDir, path string) (StateEntry, error) {
+	key, err := filepath.Rel(stateDir, path)
+	if err != nil {
+		return StateEntry{}, err
+	}
+	entry := StateEntry{Key: filepath.ToSlash(key), Kind: stateKind(stateDir, path), Path: path}
+	err = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if info.ModTime().After(entry.Modified) {
+			entry.Modified = info.ModTime()
+		}
+		if !d.IsDir() {
+			entry.Files++
+			entry.Bytes += info.Size()
+		}
+		return nil
+	})
+	if err != nil {
+		return StateEntry{}, fmt.Errorf("scan %s: %w", path, err)
+	}
+	return entry, nil
+}
+
+// HandleStateList prints the contents of the state directory.
+func HandleStateList(ctx *RuntimeContext) error {
+	entries, err := loadState(ctx.Paths.StateDir)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case ctx.Common.JSON:
+		data, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+	case ctx.Common.YAML:
+		data, err := yaml.Marshal(entries)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		for _, e := range entries {
+			fmt.Fprintf(ctx.Out.Writer(), "%s\t%s\t%d\t%d\n", e.Key, e.Kind, e.Files, e.Bytes)
+		}
+	default:
+		if len(entries) == 0 {
+			ctx.Logger.Info("nothing in %s", ctx.Paths.StateDir)
+			return nil
+		}
+		now := ctx.Clock.Now()
+		rows := make([][]string, 0, len(entries))
+		var total int64
+		for _, e := range entries {
+			rows = append(rows, []string{e.Key, e.Kind, humanize.Bytes(e.Bytes), humanize.RelTime(e.Modified, now)})
+			total += e.Bytes
+		}
+		ctx.Out.Table("", headers(ctx.Out, msgColKey, msgColKind, msgColSize, msgColModified), rows)
+		ctx.Out.Println(ctx.Out.Dim(ctx.Out.Sprintf(msgStateTotal, humanize.Bytes(total), ctx.Paths.StateDir)))
+	}
+	return nil
+}
+
+// HandleStateShow prints one state entry: a file's contents, or the files
+// of a directory.
+func HandleStateShow(ctx *RuntimeContext, key string) error {
+	if !filepath.IsLocal(filepath.FromSlash(key)) {
+		return UsageError(fmt.Errorf("invalid state key %q (see state ls)", key))
+	}
+	path := filepath.Join(ctx.Paths.StateDir, filepath.FromSlash(key))
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("no state entry %q (see state ls)", key)
+	}
+	if err != nil {
+		return err
+	}
+	entry, err := statEntry(ctx.Paths.StateDir, path)
+	if err != nil {
+		return err
+	}
+
+	var content []byte
+	var files []StateEntry
+	switch {
+	case info.IsDir():
+		items, err := os.ReadDir(path)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			f, err := statEntry(ctx.Paths.StateDir, filepath.Join(path, item.Name()))
+			if err != nil {
+				return err
+			}
+			files = append(files, f)
+		}
+	case entry.Kind == StateDatabase:
+		// Binary; printed as a summary below.
+	case info.Mode().IsRegular():
+		if content, err = os.ReadFile(path); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case ctx.Common.JSON, ctx.Common.YAML:
+		result := map[string]any{"entry": entry}
+		if info.IsDir() {
+			result["files"] = files
+		} else if content != nil {
+			result["content"] = string(content)
+		}
+		var data []byte
+		if ctx.Common.JSON {
+			data, err = json.MarshalIndent(result, "", "  ")
+			data = append(data, '\n')
+		} else {
+			data, err = yaml.Marshal(result)
+		}
+		if err != nil {
+			return err
+		}
+		ctx.Out.Writer().Write(data)
+	case info.IsDir() && ctx.Common.Porcelain:
+		for _, f := range files {
+			ctx.Out.Println(f.Key)
+		}
+	case info.IsDir():
+		rows := make([][]string, 0, len(files))
+		for _, f := range files {
+			rows = append(rows, []string{f.Key, humanize.Bytes(f.Bytes), humanize.RelTime(f.Modified, ctx.Clock.Now())})
+		}
+		ctx.Out.Table("", headers(ctx.Out, msgColKey, msgColSize, msgColModified), rows)
+	case entry.Kind == StateDatabase:
+		ctx.Logger.Info("%s is a SQLite database (%s); query it with history list --where", key, humanize.Bytes(entry.Bytes))
+	default:
+		ctx.Out.Writer().Write(content)
+	}
+	return nil
+}
+
+// StatePruneOptions configure state prune.
+type StatePruneOptions struct {
+	// OlderThan is the age past which history entries, checkpoints, task
+	// logs, and input fingerprints are removed.
+	OlderThan time.Duration
+}
+
+// PruneResult counts what state prune removed, per kind.
+type PruneResult struct {
+	Kind    string `json:"kind" yaml:"kind"`
+	Removed int    `json:"removed" yaml:"removed"`
+}
+
+// HandleStatePrune removes state older than opts.OlderThan: history
+// entries, checkpoints, per-run task logs, and input fingerprints. The
+// lock, schedules, and daemon files are left alone.
+func HandleStatePrune(ctx *RuntimeContext, opts StatePruneOptions) error {
+	if opts.OlderThan <= 0 {
+		opts.OlderThan = defaultPruneAge
+	}
+	cutoff := ctx.Clock.Now().Add(-opts.OlderThan)
+	dir := ctx.Paths.StateDir
+	dry := ctx.Common.DryRun
+	err := confirmDestructive(ctx, Destructive{
+		Action:   "state prune",
+		Resource: dir,
+		Question: fmt.Sprintf("Remove history, checkpoints, task logs, and input fingerprints older than %s from %s? Run with --dry-run to count them.", humanize.Duration(opts.OlderThan), dir),
+	})
+	if err != nil {
+		return err
+	}
+
+	history, err := pruneHistory(ctx, cutoff, dry)
+	if err != nil {
+		return err
+	}
+	checkpoints, err := pruneCheckpoints(ctx, cutoff, dry)
+	if err != nil {
+		return err
+	}
+	inputs, err := pruneInputs(inputsPath(dir), cutoff, dry)
+	if err != nil {
+		return err
+	}
+	entries, err := loadState(dir)
+	if err != nil {
+		return err
+	}
+	var logs int
+	var freed int64
+	for _, e := range entries {
+		if e.Kind != StateTaskLogs || !e.Modified.Before(cutoff) {
+			continue
+		}
+		if !dry {
+			if err := os.RemoveAll(e.Path); err != nil {
+				return fmt.Errorf("remove %s: %w", e.Key, err)
+			}
+		}
+		freed += e.Bytes
+		logs++
+	}
+
+	results := []PruneResult{
+		{Kind: StateHistory, Removed: history},
+		{Kind: StateCheckpoint, Removed: checkpoints},
+		{Kind: StateTaskLogs, Removed: logs},
+		{Kind: StateInputs, Removed: inputs},
+	}
+	switch {
+	case ctx.Common.JSON:
+		data, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+	case ctx.Common.YAML:
+		data, err := yaml.Marshal(results)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		for _, r := range results {
+			fmt.Fprintf(ctx.Out.Writer(), "%s\t%d\n", r.Kind, r.Removed)
+		}
+	default:
+		verb := "removed"
+		if dry {
+			verb = "dry-run: would remove"
+		}
+		ctx.Logger.Info("%s %s, %s, %s of task logs, and %s older than %s (%s of files)", verb,
+			humanize.Plural(history, "history entry", "history entries"),
+			humanize.Plural(checkpoints, "checkpoint", "checkpoints"),
+			humanize.Plural(logs, "run", "runs"),
+			humanize.Plural(inputs, "input fingerprint", "input fingerprints"),
+			humanize.Duration(opts.OlderThan), humanize.Bytes(freed))
+	}
+	return nil
+}
+
+// pruneHistory removes the history entries of runs started before cutoff.
+func pruneHistory(ctx *RuntimeContext, cutoff time.Time, dry bool) (int, error) {
+	store, err := ctx.stateStore()
+	if err != nil {
+		return 0, err
+	}
+	old, err := store.Runs(ctx, statestore.Query{Where: []statestore.Condition{
+		{Field: "started", Op: "<", Value: cutoff.UTC().Format(time.RFC3339Nano)},
+	}})
+	if err != nil || len(old) == 0 || dry {
+		return len(old), err
+	}
+	ids := make([]string, 0, len(old))
+	for _, r := range old {
+		ids = append(ids, r.ID)
+	}
+	if err := store.DeleteRuns(ctx, ids); err != nil {
+		return 0, fmt.Errorf("prune history: %w", err)
+	}
+	return len(ids), nil
+}
+
+// pruneCheckpoints removes checkpoints last updated before cutoff.
+func pruneCheckpoints(ctx *RuntimeContext, cutoff time.Time, dry bool) (int, error) {
+	store, err := ctx.stateStore()
+	if err != nil {
+		return 0, err
+	}
+	records, err := store.Checkpoints(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("read checkpoints: %w", err)
+	}
+	removed := 0
+	for _, rec := range records {
+		if !rec.Updated.Before(cutoff) {
+			continue
+		}
+		if !dry {
+			if err := store.DeleteCheckpoint(ctx, rec.Key); err != nil {
+				return removed, fmt.Errorf("remove checkpoint %s: %w", rec.Key, err)
+			}
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// pruneInputs drops fingerprints last recorded before cutoff, so those
+// tasks run again next time.
+func pruneInputs(path string, cutoff time.Time, dry bool) (int, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("read task fingerprints: %w", err)
+	}
+	records := map[string]inputRecord{}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return 0, fmt.Errorf("decode task fingerprints %s: %w", path, err)
+	}
+	removed := 0
+	for key, r := range records {
+		if r.UpdatedAt.Before(cutoff) {
+			delete(records, key)
+			removed++
+		}
+	}
+	if removed == 0 || dry {
+		return removed, nil
+	}
+	data, err = json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	return removed, writeFileAtomic(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,174 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/statestore"
+)
+
+// sharedState opens the state database once per process; forks share it.
+type sharedState struct {
+	once  sync.Once
+	store statestore.Store
+	err   error
+}
+
+func stateDBPath(stateDir string) string {
+	return filepath.Join(stateDir, statestore.FileName)
+}
+
+// Files that held the state before the database; importLegacyState moves
+// them aside once their contents are in it.
+func legacyHistoryPath(stateDir string) string {
+	return filepath.Join(stateDir, "history.jsonl")
+}
+
+func legacyCheckpointsDir(stateDir string) string {
+	return filepath.Join(stateDir, "checkpoints")
+}
+
+func legacySchedulesPath(stateDir string) string {
+	return filepath.Join(stateDir, "schedules.json")
+}
+
+// migratedSuffix marks a legacy file that has been imported.
+const migratedSuffix = ".migrated"
+
+// stateStore returns the state database, opening it on first use.
+func (rtx *RuntimeContext) stateStore() (statestore.Store, error) {
+	if rtx.state == nil {
+		rtx.state = &sharedState{}
+	}
+	shared := rtx.state
+	shared.once.Do(func() {
+		store, err := statestore.Open(stateDBPath(rtx.Paths.StateDir))
+		if err != nil {
+			shared.err = err
+			return
+		}
+		if err := importLegacyState(rtx, store); err != nil {
+			store.Close()
+			shared.err = err
+			return
+		}
+		shared.store = store
+	})
+	return shared.store, shared.err
+}
+
+// closeState closes the state database if it was opened.
+func (rtx *RuntimeContext) closeState() error {
+	if rtx.state == nil || rtx.state.store == nil {
+		return nil
+	}
+	return rtx.state.store.Close()
+}
+
+// importLegacyState copies history, checkpoints, and schedules from the
+// files earlier versions kept into store. Each file is renamed before it is
+// read, so of several processes starting at once only one imports it.
+func importLegacyState(ctx *RuntimeContext, store statestore.Store) error {
+	dir := ctx.Paths.StateDir
+	imported := 0
+	claim := func(path string) (string, bool, error) {
+		err := os.Rename(path, path+migratedSuffix)
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", false, nil
+		}
+		if err != nil {
+			return "", false, fmt.Errorf("import %s: %w", path, err)
+		}
+		return path + migratedSuffix, true, nil
+	}
+
+	if path, ok, err := claim(legacyHistoryPath(dir)); err != nil {
+		return err
+	} else if ok {
+		entries, err := readHistoryFile(path)
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := addHistory(ctx, store, e); err != nil {
+				return fmt.Errorf("import history: %w", err)
+			}
+		}
+		imported += len(entries)
+	}
+
+	if path, ok, err := claim(legacyCheckpointsDir(dir)); err != nil {
+		return err
+	} else if ok {
+		files, err := filepath.Glob(filepath.Join(path, "*.json"))
+		if err != nil {
+			return err
+		}
+		for _, file := range files {
+			var cp Checkpoint
+			data, err := os.ReadFile(file)
+			if err == nil {
+				err = json.Unmarshal(data, &cp)
+			}
+			if err != nil {
+				ctx.Logger.Warn("skipping checkpoint %s: %v", file, err)
+				continue
+			}
+			if err := putCheckpoint(ctx, store, cp); err != nil {
+				return fmt.Errorf("import checkpoints: %w", err)
+			}
+			imported++
+		}
+	}
+
+	if path, ok, err := claim(legacySchedulesPath(dir)); err != nil {
+		return err
+	} else if ok {
+		var entries []ScheduleEntry
+		data, err := os.ReadFile(path)
+		if err == nil {
+			err = json.Unmarshal(data, &entries)
+		}
+		if err != nil {
+			return fmt.Errorf("import schedules %s: %w", path, err)
+		}
+		for _, e := range entries {
+			if err := store.AddSchedule(context.Background(), scheduleRecord(e)); err != nil {
+				return fmt.Errorf("import schedules: %w", err)
+			}
+		}
+		imported += len(entries)
+	}
+
+	if imported > 0 {
+		ctx.Logger.Info("moved %d state record(s) into %s", imported, stateDBPath(dir))
+	}
+	return nil
+}
+
+// isStateDBFile reports whether path belongs to the state database,
+// including SQLite's write-ahead log and shared-memory files.
+func isStateDBFile(stateDir, path string) bool {
+	db := stateDBPath(stateDir)
+	return path == db || path == db+"-wal" || path == db+"-shm"
+}
+
+// parseConditions parses --where expressions for the state database.
+func parseConditions(exprs []string) ([]statestore.Condition, error) {
+	conds := make([]statestore.Condition, 0, len(exprs))
+	for _, expr := range exprs {
+		c, err := statestore.ParseCondition(strings.TrimSpace(expr))
+		if err != nil {
+			return nil, UsageError(err)
+		}
+		conds = append(conds, c)
+	}
+	return conds, nil
+}
@@ -0,0 +1,241 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/statestore"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/storage"
+)
+
+// Keys of a stored run: its files under runs/<id>/, as in the data
+// directory, and its history entry as history/<id>.json.
+func runKey(id, name string) string { return "runs/" + id + "/" + name }
+func historyKey(id string) string   { return "history/" + id + ".json" }
+
+func validateStorage(cfg StorageConfig) error {
+	switch cfg.Backend {
+	case StorageLocal:
+		return nil
+	case StorageS3:
+		if cfg.S3.Bucket == "" {
+			return errors.New("invalid storage.s3.bucket (required by the s3 backend)")
+		}
+		if cfg.S3.Endpoint != "" {
+			if u, err := url.Parse(cfg.S3.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("invalid storage.s3.endpoint %q (expected an http:// or https:// URL)", cfg.S3.Endpoint)
+			}
+		}
+		return nil
+	}
+	return enumError("storage.backend", cfg.Backend, StorageLocal, StorageS3)
+}
+
+// runStore returns the store finished runs are copied to, or nil when
+// they stay in the data directory only.
+func (rtx *RuntimeContext) runStore() (storage.Store, error) {
+	cfg := rtx.Config.Storage
+	if cfg.Backend == StorageS3 {
+		client, err := rtx.HTTPClient()
+		if err != nil {
+			return nil, err
+		}
+		s3 := storage.S3Config{
+			Endpoint:        cfg.S3.Endpoint,
+			Region:          cfg.S3.Region,
+			Bucket:          cfg.S3.Bucket,
+			Prefix:          cfg.S3.Prefix,
+			PathStyle:       cfg.S3.PathStyle,
+			AccessKeyID:     cfg.S3.AccessKeyID,
+			SecretAccessKey: cfg.S3.SecretAccessKey,
+			SessionToken:    cfg.S3.SessionToken,
+		}
+		if s3.AccessKeyID == "" && s3.SecretAccessKey == "" {
+			s3.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+			s3.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+			s3.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+		}
+		store, err := storage.NewS3(s3, client)
+		if err != nil {
+			return nil, fmt.Errorf("storage.s3: %w", err)
+		}
+		return store, nil
+	}
+
+	dir, err := expandPath(cfg.Local.Path)
+	if err != nil || dir == "" {
+		return nil, err
+	}
+	if abs, err := filepath.Abs(dir); err == nil && abs == filepath.Clean(rtx.Paths.DataDir) {
+		return nil, nil
+	}
+	return storage.NewLocal(dir), nil
+}
+
+// persistRun copies a finished run to the configured store: its artifacts,
+// then its manifest, then its history entry, so a stored manifest only
+// lists files that are there. Like history, this is logged on failure and
+// never fails the run.
+func persistRun(ctx *RuntimeContext, artifacts *artifactStore, entry HistoryEntry) {
+	store, err := ctx.runStore()
+	if err != nil {
+		ctx.Logger.Warn("could not store run %s: %v", entry.ID, err)
+		return
+	}
+	if store == nil {
+		return
+	}
+	// An interrupted run is stored all the same.
+	if err := uploadRun(context.WithoutCancel(ctx.Context), store, artifacts, entry); err != nil {
+		ctx.Logger.Warn("could not store run %s in %s: %v", entry.ID, store, err)
+		return
+	}
+	ctx.Logger.Debug("stored run %s in %s", entry.ID, store)
+}
+
+func uploadRun(ctx context.Context, store storage.Store, artifacts *artifactStore, entry HistoryEntry) error {
+	if artifacts != nil {
+		names := make([]string, 0, len(artifacts.manifest.Artifacts)+1)
+		for _, a := range artifacts.manifest.Artifacts {
+			names = append(names, a.Name)
+		}
+		for _, name := range append(names, manifestName) {
+			if err := putFile(ctx, store, runKey(entry.ID, name), filepath.Join(artifacts.dir, filepath.FromSlash(name))); err != nil {
+				return err
+			}
+		}
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return store.Put(ctx, historyKey(entry.ID), bytes.NewReader(append(data, '\n')))
+}
+
+func putFile(ctx context.Context, store storage.Store, key, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if err := store.Put(ctx, key, f); err != nil {
+		return fmt.Errorf("store %s: %w", key, err)
+	}
+	return nil
+}
+
+// getJSON decodes the stored object key into v.
+func getJSON(ctx context.Context, store storage.Store, key string, v any) error {
+	r, err := store.Get(ctx, key)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	return json.NewDecoder(r).Decode(v)
+}
+
+// loadAllRuns is loadRuns plus the runs only the configured store has,
+// newest first. It fails when the store cannot be read.
+func loadAllRuns(ctx *RuntimeContext) ([]RunManifest, error) {
+	runs, err := loadRuns(ctx)
+	if err != nil {
+		return nil, err
+	}
+	store, err := ctx.runStore()
+	if err != nil || store == nil {
+		return runs, err
+	}
+	known := map[string]bool{}
+	for _, r := range runs {
+		known[r.RunID] = true
+	}
+	objects, err := store.List(ctx, "runs/")
+	if err != nil {
+		return nil, fmt.Errorf("list runs in %s: %w", store, err)
+	}
+	for _, obj := range objects {
+		id, name, ok := strings.Cut(strings.TrimPrefix(obj.Key, "runs/"), "/")
+		if !ok || name != manifestName || known[id] {
+			continue
+		}
+		var m RunManifest
+		if err := getJSON(ctx, store, obj.Key, &m); err != nil || m.RunID != id {
+			ctx.Logger.Debug("skipping stored run %s: invalid manifest", id)
+			continue
+		}
+		runs = append(runs, m)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].RunID > runs[j].RunID })
+	return runs, nil
+}
+
+// loadAllHistory is loadHistory plus the entries only the configured store
+// has, oldest first, all matching where.
+func loadAllHistory(ctx *RuntimeContext, where []statestore.Condition) ([]HistoryEntry, error) {
+	entries, err := loadHistory(ctx, statestore.Query{Where: where})
+	if err != nil {
+		return nil, err
+	}
+	store, err := ctx.runStore()
+	if err != nil || store == nil {
+		return entries, err
+	}
+	known := map[string]bool{}
+	for _, e := range entries {
+		known[e.ID] = true
+	}
+	objects, err := store.List(ctx, "history/")
+	if err != nil {
+		return nil, fmt.Errorf("list history in %s: %w", store, err)
+	}
+	for _, obj := range objects {
+		id := strings.TrimSuffix(strings.TrimPrefix(obj.Key, "history/"), ".json")
+		if known[id] {
+			continue
+		}
+		var entry HistoryEntry
+		if err := getJSON(ctx, store, obj.Key, &entry); err != nil || entry.ID != id {
+			ctx.Logger.Debug("skipping stored history entry %s: %v", id, err)
+			continue
+		}
+		if matchesAll(where, historyRun(entry)) {
+			entries = append(entries, entry)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Started.Before(entries[j].Started) })
+	return entries, nil
+}
+
+func matchesAll(where []statestore.Condition, run statestore.Run) bool {
+	for _, c := range where {
+		if !c.Matches(run) {
+			return false
+		}
+	}
+	return true
+}
+
+// deleteStoredRun removes a run and its history entry from the store. The
+// manifest goes first, so a run deleted halfway is no longer listed.
+func deleteStoredRun(ctx context.Context, store storage.Store, id string) error {
+	objects, err := store.List(ctx, "runs/"+id+"/")
+	if err != nil {
+		return err
+	}
+	manifest := runKey(id, manifestName)
+	sort.SliceStable(objects, func(i, j int) bool { return objects[i].Key == manifest })
+	for _, obj := range objects {
+		if err := store.Delete(ctx, obj.Key); err != nil {
+			return err
+		}
+	}
+	return store.Delete(ctx, historyKey(id))
+}
@@ -0,0 +1,131 @@
+package app
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/runner"
+)
+
+// StreamSpec is one job read by `run --stdin`. An NDJSON line names either a
+// registered task or the shell commands to run:
+//
+//	{"task": "lint"}
+//	{"name": "thumb-1", "cmd": "convert a.png a.jpg", "dir": "img", "env": ["Q=80"]}
+//
+// Any other non-empty line that is not a # comment is a shell command.
+type StreamSpec struct {
+	Name string   `json:"name,omitempty"`
+	Task string   `json:"task,omitempty"`
+	Cmd  string   `json:"cmd,omitempty"`
+	Cmds []string `json:"cmds,omitempty"`
+	Dir  string   `json:"dir,omitempty"`
+	Env  []string `json:"env,omitempty"`
+}
+
+// TaskSpec returns the command task declaration for a command spec.
+func (s StreamSpec) TaskSpec() TaskSpec {
+	cmds := s.Cmds
+	if s.Cmd != "" {
+		cmds = append([]string{s.Cmd}, cmds...)
+	}
+	return TaskSpec{Cmds: cmds, Dir: s.Dir, Env: s.Env}
+}
+
+// StreamJobFunc builds the runner job for a streamed spec, bound to rtx; the
+// command layer supplies it from the task registry.
+type StreamJobFunc func(rtx *RuntimeContext, spec StreamSpec) (runner.Job, error)
+
+// maxStreamLine caps a single job spec line.
+const maxStreamLine = 1024 * 1024
+
+// parseStreamSpec decodes one input line; ok is false for blank lines and
+// comments. n numbers the jobs read so far and names unnamed command jobs.
+func parseStreamSpec(line string, n int) (spec StreamSpec, ok bool, err error) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return StreamSpec{}, false, nil
+	}
+	if strings.HasPrefix(line, "{") {
+		dec := json.NewDecoder(bytes.NewReader([]byte(line)))
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&spec); err != nil {
+			return StreamSpec{}, true, fmt.Errorf("invalid job spec: %w", err)
+		}
+		hasCmds := spec.Cmd != "" || len(spec.Cmds) > 0
+		if (spec.Task == "") == !hasCmds {
+			return StreamSpec{}, true, fmt.Errorf("invalid job spec: set either task or cmd/cmds")
+		}
+		for _, kv := range spec.Env {
+			if k, _, ok := strings.Cut(kv, "="); !ok || k == "" {
+				return StreamSpec{}, true, fmt.Errorf("invalid job spec env entry %q (expected KEY=VALUE)", kv)
+			}
+		}
+	} else {
+		spec.Cmd = line
+	}
+	if spec.Name == "" {
+		spec.Name = spec.Task
+	}
+	if spec.Name == "" {
+		spec.Name = fmt.Sprintf("job-%d", n)
+	}
+	return spec, true, nil
+}
+
+// readJobStream turns the spec lines of r into jobs, passing each through
+// prepare, until r is exhausted or ctx is canceled. A line that does not
+// parse or names an unknown task becomes a job that fails with that error,
+// so it shows up in the failure report without stopping the stream.
+func readJobStream(ctx context.Context, rtx *RuntimeContext, r io.Reader, jobFor StreamJobFunc, prepare func(runner.Job) runner.Job) <-chan runner.Job {
+	out := make(chan runner.Job)
+	go func() {
+		defer close(out)
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), maxStreamLine)
+		send := func(job runner.Job) bool {
+			select {
+			case out <- prepare(job):
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		n, lineNo := 0, 0
+		for scanner.Scan() {
+			lineNo++
+			spec, ok, err := parseStreamSpec(scanner.Text(), n+1)
+			if !ok {
+				continue
+			}
+			n++
+			job := runner.Job{}
+			if err == nil {
+				job, err = jobFor(rtx, spec)
+			}
+			if err != nil {
+				err = fmt.Errorf("stdin line %d: %w", lineNo, err)
+				job = runner.Job{
+					Name: fmt.Sprintf("line-%d", lineNo),
+					Run:  func(context.Context) error { return err },
+				}
+			}
+			if !send(job) {
+				return
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			send(runner.Job{
+				Name: "stdin",
+				Run:  func(context.Context) error { return fmt.Errorf("read stdin: %w", err) },
+			})
+		}
+	}()
+	return out
+}
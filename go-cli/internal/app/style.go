@@ -0,0 +1,238 @@
+package app
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/message"
+)
+
+const (
+	styleBold   = "\033[1m"
+	styleDim    = "\033[2m"
+	styleRed    = "\033[31m"
+	styleGreen  = "\033[32m"
+	styleYellow = "\033[33m"
+	styleCyan   = "\033[36m"
+)
+
+// Renderer writes human-readable command results to stdout. It follows the
+// same color policy as the logger, but decides auto-coloring based on stdout
+// rather than stderr so `go-cli run | less` stays plain while logs keep color.
+type Renderer struct {
+	w        io.Writer
+	colorize bool
+	glyphs   Glyphs
+	printer  *message.Printer
+}
+
+// KeyValue is a single row in a key/value layout.
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+// NewRenderer builds a renderer writing to w.
+func NewRenderer(w io.Writer, colorize bool, glyphs Glyphs, printer *message.Printer) Renderer {
+	if w == nil {
+		w = io.Discard
+	}
+	return Renderer{
+		w:        w,
+		colorize: colorize,
+		glyphs:   glyphs,
+		printer:  printer,
+	}
+}
+
+// ResolveRenderer configures the stdout renderer from the color flags.
+func ResolveRenderer(flags CommonFlags, glyphs Glyphs, printer *message.Printer) Renderer {
+	return NewRenderer(os.Stdout, shouldColorize(colorPolicy(flags), os.Stdout), glyphs, printer)
+}
+
+// Writer exposes the underlying output stream.
+func (r Renderer) Writer() io.Writer {
+	return r.w
+}
+
+// Printf writes a catalog message, translated for the active locale.
+func (r Renderer) Printf(key string, args ...any) {
+	if r.printer != nil {
+		r.printer.Fprintf(r.w, key, args...)
+		return
+	}
+	fmt.Fprintf(r.w, key, args...)
+}
+
+// Sprintf formats a catalog message for the active locale, e.g. a note or
+// result to pass to Success or Println.
+func (r Renderer) Sprintf(key string, args ...any) string {
+	if r.printer != nil {
+		return r.printer.Sprintf(key, args...)
+	}
+	return fmt.Sprintf(key, args...)
+}
+
+// Text returns a catalog message without arguments, such as a label or a
+// column header, for the active locale.
+func (r Renderer) Text(key string) string {
+	if r.printer != nil {
+		return r.printer.Sprintf(key)
+	}
+	return key
+}
+
+// Println writes a plain line.
+func (r Renderer) Println(args ...any) {
+	fmt.Fprintln(r.w, args...)
+}
+
+// Heading writes a bold section heading.
+func (r Renderer) Heading(text string) {
+	fmt.Fprintln(r.w, r.Bold(text))
+}
+
+// Success writes a line prefixed with the success glyph.
+func (r Renderer) Success(text string) {
+	fmt.Fprintf(r.w, "%s %s\n", r.paint(styleGreen, r.glyphs.Success), text)
+}
+
+// Failure writes a line prefixed with the failure glyph.
+func (r Renderer) Failure(text string) {
+	fmt.Fprintf(r.w, "%s %s\n", r.paint(styleRed, r.glyphs.Failure), text)
+}
+
+// Warning writes a line prefixed with the warning glyph.
+func (r Renderer) Warning(text string) {
+	fmt.Fprintf(r.w, "%s %s\n", r.paint(styleYellow, r.glyphs.Warning), text)
+}
+
+// KeyValues writes rows with keys padded to a common width.
+func (r Renderer) KeyValues(indent string, rows []KeyValue) {
+	width := 0
+	for _, row := range rows {
+		if n := utf8.RuneCountInString(row.Key); n > width {
+			width = n
+		}
+	}
+	for _, row := range rows {
+		pad := strings.Repeat(" ", width-utf8.RuneCountInString(row.Key))
+		fmt.Fprintf(r.w, "%s%s%s  %s\n", indent, r.Dim(row.Key+":"), pad, row.Value)
+	}
+}
+
+// Table writes rows under bold headers, indented by indent, padding each column to its widest
+// cell. Cells may contain color codes; padding ignores them.
+func (r Renderer) Table(indent string, headers []string, rows [][]string) {
+	widths := make([]int, len(headers))
+	for i, h := range headers {
+		widths[i] = utf8.RuneCountInString(h)
+	}
+	for _, row := range rows {
+		for i, cell := range row {
+			if n := visibleWidth(cell); i < len(widths) && n > widths[i] {
+				widths[i] = n
+			}
+		}
+	}
+
+	line := func(cells []string, style func(string) string) {
+		var b strings.Builder
+		b.WriteString(indent)
+		for i, cell := range cells {
+			if i > 0 {
+				b.WriteString("  ")
+			}
+			b.WriteString(style(cell))
+			if i < len(cells)-1 {
+				b.WriteString(strings.Repeat(" ", widths[i]-visibleWidth(cell)))
+			}
+		}
+		fmt.Fprintln(r.w, b.String())
+	}
+	line(headers, r.Bold)
+	for _, row := range rows {
+		line(row, func(s string) string { return s })
+	}
+}
+
+// visibleWidth counts the runes of s that occupy a column, skipping ANSI
+// escape sequences.
+func visibleWidth(s string) int {
+	n, inEscape := 0, false
+	for _, c := range s {
+		switch {
+		case inEscape:
+			if c == 'm' {
+				inEscape = false
+			}
+		case c == '\033':
+			inEscape = true
+		default:
+			n++
+		}
+	}
+	return n
+}
+
+// Diff writes a unified diff, coloring removals red, additions green, and
+// hunk headers in the accent color.
+func (r Renderer) Diff(unified string) {
+	for _, line := range strings.SplitAfter(unified, "\n") {
+		if line == "" {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			fmt.Fprint(r.w, r.Bold(strings.TrimSuffix(line, "\n"))+"\n")
+		case strings.HasPrefix(line, "@@"):
+			fmt.Fprint(r.w, r.Accent(strings.TrimSuffix(line, "\n"))+"\n")
+		case strings.HasPrefix(line, "+"):
+			fmt.Fprint(r.w, r.Green(strings.TrimSuffix(line, "\n"))+"\n")
+		case strings.HasPrefix(line, "-"):
+			fmt.Fprint(r.w, r.Red(strings.TrimSuffix(line, "\n"))+"\n")
+		default:
+			fmt.Fprint(r.w, line)
+		}
+	}
+}
+
+// Bold styles s in bold when color is enabled.
+func (r Renderer) Bold(s string) string {
+	return r.paint(styleBold, s)
+}
+
+// Dim styles s in a faint color when color is enabled.
+func (r Renderer) Dim(s string) string {
+	return r.paint(styleDim, s)
+}
+
+// Accent styles s in the accent color when color is enabled.
+func (r Renderer) Accent(s string) string {
+	return r.paint(styleCyan, s)
+}
+
+// Green styles s in green when color is enabled.
+func (r Renderer) Green(s string) string {
+	return r.paint(styleGreen, s)
+}
+
+// Yellow styles s in yellow when color is enabled.
+func (r Renderer) Yellow(s string) string {
+	return r.paint(styleYellow, s)
+}
+
+// Red styles s in red when color is enabled.
+func (r Renderer) Red(s string) string {
+	return r.paint(styleRed, s)
+}
+
+func (r Renderer) paint(style, s string) string {
+	if !r.colorize || s == "" {
+		return s
+	}
+	return style + s + resetColor()
+}
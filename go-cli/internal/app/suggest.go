@@ -0,0 +1,87 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// maxSuggestDistance is how many edits a candidate may be from the input
+// and still be suggested; cobra uses the same bound for command names.
+const maxSuggestDistance = 2
+
+// suggest returns up to three candidates within maxSuggestDistance edits
+// of input, closest first.
+func suggest(input string, candidates []string) []string {
+	type match struct {
+		value    string
+		distance int
+	}
+	var matches []match
+	for _, c := range candidates {
+		if d := editDistance(strings.ToLower(input), strings.ToLower(c)); d > 0 && d <= maxSuggestDistance {
+			matches = append(matches, match{c, d})
+		}
+	}
+	slices.SortStableFunc(matches, func(a, b match) int { return a.distance - b.distance })
+	var out []string
+	for _, m := range matches[:min(len(matches), 3)] {
+		out = append(out, m.value)
+	}
+	return out
+}
+
+// didYouMean formats suggestions as a suffix for an error message, such as
+// "; did you mean logging.level?", or returns "" without any.
+func didYouMean(suggestions []string) string {
+	if len(suggestions) == 0 {
+		return ""
+	}
+	return "; did you mean " + orList(suggestions) + "?"
+}
+
+// enumError rejects value as a setting of key that only takes one of
+// allowed, suggesting the closest.
+func enumError(key, value string, allowed ...string) error {
+	var quoted []string
+	for _, s := range suggest(value, allowed) {
+		quoted = append(quoted, fmt.Sprintf("%q", s))
+	}
+	return fmt.Errorf("invalid %s %q (expected %s)%s", key, value, orList(allowed), didYouMean(quoted))
+}
+
+// orList joins items as "a", "a or b", or "a, b, or c".
+func orList(items []string) string {
+	switch n := len(items); n {
+	case 0:
+		return ""
+	case 1:
+		return items[0]
+	case 2:
+		return items[0] + " or " + items[1]
+	default:
+		return strings.Join(items[:n-1], ", ") + ", or " + items[n-1]
+	}
+}
+
+// editDistance is the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,132 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/humanize"
+)
+
+// slowestTaskCount caps how many tasks the summary lists as slowest.
+const slowestTaskCount = 3
+
+// TaskStatus is the final state of a task within a run.
+type TaskStatus string
+
+const (
+	TaskSucceeded TaskStatus = "succeeded"
+	TaskFailed    TaskStatus = "failed"
+	TaskSkipped   TaskStatus = "skipped"
+	// TaskUpToDate marks a task skipped because its inputs are unchanged.
+	TaskUpToDate TaskStatus = "up_to_date"
+)
+
+// TaskResult records the outcome of one task.
+type TaskResult struct {
+	Name     string
+	Status   TaskStatus
+	Duration time.Duration
+	Attempts int
+	Err      error
+}
+
+// RunSummary aggregates task results for the end-of-run report.
+type RunSummary struct {
+	Attempted  int          `json:"attempted" yaml:"attempted"`
+	Succeeded  int          `json:"succeeded" yaml:"succeeded"`
+	Failed     int          `json:"failed" yaml:"failed"`
+	Skipped    int          `json:"skipped" yaml:"skipped"`
+	UpToDate   int          `json:"up_to_date" yaml:"up_to_date"`
+	Retries    int          `json:"retries" yaml:"retries"`
+	DurationMS int64        `json:"duration_ms" yaml:"duration_ms"`
+	Slowest    []TaskTiming `json:"slowest" yaml:"slowest"`
+	Retried    []TaskRetry  `json:"retried,omitempty" yaml:"retried,omitempty"`
+}
+
+// TaskRetry records how many attempts a retried task needed.
+type TaskRetry struct {
+	Name     string `json:"name" yaml:"name"`
+	Attempts int    `json:"attempts" yaml:"attempts"`
+}
+
+// TaskTiming pairs a task with its wall-clock duration.
+type TaskTiming struct {
+	Name       string `json:"name" yaml:"name"`
+	DurationMS int64  `json:"duration_ms" yaml:"duration_ms"`
+}
+
+// Summarize builds a RunSummary from task results and the total run time.
+func Summarize(results []TaskResult, total time.Duration) RunSummary {
+	summary := RunSummary{DurationMS: total.Milliseconds()}
+
+	var ran []TaskResult
+	for _, r := range results {
+		switch r.Status {
+		case TaskSucceeded:
+			summary.Succeeded++
+		case TaskFailed:
+			summary.Failed++
+		case TaskSkipped:
+			summary.Skipped++
+			continue
+		case TaskUpToDate:
+			summary.UpToDate++
+			continue
+		}
+		summary.Attempted++
+		ran = append(ran, r)
+		if r.Attempts > 1 {
+			summary.Retries += r.Attempts - 1
+			summary.Retried = append(summary.Retried, TaskRetry{Name: r.Name, Attempts: r.Attempts})
+		}
+	}
+
+	sort.SliceStable(ran, func(i, j int) bool { return ran[i].Duration > ran[j].Duration })
+	for i := 0; i < len(ran) && i < slowestTaskCount; i++ {
+		summary.Slowest = append(summary.Slowest, TaskTiming{
+			Name:       ran[i].Name,
+			DurationMS: ran[i].Duration.Milliseconds(),
+		})
+	}
+	return summary
+}
+
+// renderSummary prints the human-readable summary block.
+func renderSummary(out Renderer, summary RunSummary) {
+	slowest := make([]string, 0, len(summary.Slowest))
+	for _, t := range summary.Slowest {
+		slowest = append(slowest, fmt.Sprintf("%s (%s)", t.Name, humanize.Duration(time.Duration(t.DurationMS)*time.Millisecond)))
+	}
+
+	failed := strconv.Itoa(summary.Failed)
+	if summary.Failed > 0 {
+		failed = out.Red(failed)
+	}
+
+	out.Println()
+	out.Heading(out.Text(msgSummary))
+	rows := []KeyValue{
+		{Key: out.Text(msgLabelAttempted), Value: strconv.Itoa(summary.Attempted)},
+		{Key: out.Text(msgLabelSucceeded), Value: out.Green(strconv.Itoa(summary.Succeeded))},
+		{Key: out.Text(msgLabelFailed), Value: failed},
+		{Key: out.Text(msgLabelSkipped), Value: strconv.Itoa(summary.Skipped)},
+	}
+	if summary.UpToDate > 0 {
+		rows = append(rows, KeyValue{Key: out.Text(msgLabelUpToDate), Value: strconv.Itoa(summary.UpToDate)})
+	}
+	rows = append(rows, KeyValue{Key: out.Text(msgLabelDuration), Value: humanize.Duration(time.Duration(summary.DurationMS) * time.Millisecond)})
+	if len(slowest) > 0 {
+		rows = append(rows, KeyValue{Key: out.Text(msgLabelSlowest), Value: strings.Join(slowest, ", ")})
+	}
+	if summary.Retries > 0 {
+		retried := make([]string, 0, len(summary.Retried))
+		for _, t := range summary.Retried {
+			retried = append(retried, fmt.Sprintf("%s (%s)", t.Name, plural(out, t.Attempts, msgAttempt, msgAttempts)))
+		}
+		rows = append(rows, KeyValue{Key: out.Text(msgRetries), Value: fmt.Sprintf("%d: %s", summary.Retries, strings.Join(retried, ", "))})
+	}
+	out.KeyValues("  ", rows)
+}
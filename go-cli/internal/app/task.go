@@ -0,0 +1,188 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/humanize"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/statestore"
+)
+
+// TaskDetail describes a task for `task list` and `task describe`: its
+// declaration, the timeout a run of it gets, and its most recent run.
+type TaskDetail struct {
+	TaskInfo `yaml:",inline"`
+	// Timeout bounds a run of the task; zero means no deadline.
+	Timeout Duration      `json:"timeout" yaml:"timeout"`
+	LastRun *HistoryEntry `json:"last_run,omitempty" yaml:"last_run,omitempty"`
+}
+
+// taskDetails joins infos with the effective timeout and the latest
+// history entry of each task.
+func taskDetails(ctx *RuntimeContext, infos []TaskInfo) ([]TaskDetail, error) {
+	entries, err := loadHistory(ctx, statestore.Query{})
+	if err != nil {
+		return nil, err
+	}
+	last := map[string]*HistoryEntry{}
+	for i := range entries {
+		last[entries[i].Task] = &entries[i]
+	}
+
+	details := make([]TaskDetail, 0, len(infos))
+	for _, info := range infos {
+		details = append(details, TaskDetail{
+			TaskInfo: info,
+			Timeout:  Duration(ctx.Timeout),
+			LastRun:  last[info.Name],
+		})
+	}
+	return details, nil
+}
+
+// HandleTaskDetails prints the registered tasks with their parameters,
+// dependencies, timeout, and last run.
+func HandleTaskDetails(ctx *RuntimeContext, infos []TaskInfo) error {
+	details, err := taskDetails(ctx, infos)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case ctx.Common.JSON:
+		data, err := json.MarshalIndent(details, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+	case ctx.Common.YAML:
+		data, err := yaml.Marshal(details)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		for _, d := range details {
+			fmt.Fprintf(ctx.Out.Writer(), "%s\t%s\n", d.Name, lastRunStatus(d.LastRun))
+		}
+	default:
+		if len(details) == 0 {
+			ctx.Logger.Info("no tasks registered")
+			return nil
+		}
+		now := ctx.Clock.Now()
+		rows := make([][]string, 0, len(details))
+		for _, d := range details {
+			rows = append(rows, []string{
+				d.Name,
+				d.Description,
+				strings.Join(d.Dependencies, ", "),
+				strings.Join(sortedKeys(d.Params), ", "),
+				taskTimeout(ctx.Out, d.Timeout),
+				lastRunSummary(ctx, d.LastRun, now),
+			})
+		}
+		ctx.Out.Table("", headers(ctx.Out, msgColTask, msgColDescription, msgColNeeds, msgColParams, msgColTimeout, msgColLastRun), rows)
+	}
+	return nil
+}
+
+// HandleTaskDescribe prints everything known about one task.
+func HandleTaskDescribe(ctx *RuntimeContext, info TaskInfo) error {
+	details, err := taskDetails(ctx, []TaskInfo{info})
+	if err != nil {
+		return err
+	}
+	d := details[0]
+
+	switch {
+	case ctx.Common.JSON:
+		data, err := json.MarshalIndent(d, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+	case ctx.Common.YAML:
+		data, err := yaml.Marshal(d)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		fmt.Fprintf(ctx.Out.Writer(), "%s\t%s\n", d.Name, lastRunStatus(d.LastRun))
+	default:
+		out := ctx.Out
+		rows := []KeyValue{
+			{Key: out.Text(msgTask), Value: d.Name},
+			{Key: out.Text(msgLabelDescription), Value: d.Description},
+		}
+		if len(d.Dependencies) > 0 {
+			rows = append(rows, KeyValue{Key: out.Text(msgLabelNeeds), Value: strings.Join(d.Dependencies, ", ")})
+		}
+		for _, name := range sortedKeys(d.Params) {
+			rows = append(rows, KeyValue{Key: out.Sprintf(msgLabelParam, name), Value: paramSummary(ctx, d.Params[name])})
+		}
+		if d.Input != "" {
+			rows = append(rows, KeyValue{Key: out.Text(msgLabelInput), Value: out.Sprintf(msgInputOnStdin, d.Input)})
+		}
+		rows = append(rows,
+			KeyValue{Key: out.Text(msgLabelTimeout), Value: taskTimeout(out, d.Timeout)},
+			KeyValue{Key: out.Text(msgLabelLastRun), Value: lastRunSummary(ctx, d.LastRun, ctx.Clock.Now())},
+		)
+		if r := d.LastRun; r != nil {
+			rows = append(rows,
+				KeyValue{Key: out.Text(msgLabelRunID), Value: r.ID},
+				KeyValue{Key: out.Text(msgLabelDuration), Value: humanize.Duration(time.Duration(r.DurationMS) * time.Millisecond)},
+				KeyValue{Key: out.Text(msgLabelExitCode), Value: fmt.Sprint(r.ExitCode)},
+			)
+			if r.Error != "" {
+				rows = append(rows, KeyValue{Key: out.Text(msgLabelError), Value: strings.ReplaceAll(r.Error, "\n", "; ")})
+			}
+		}
+		ctx.Out.KeyValues("", rows)
+	}
+	return nil
+}
+
+// paramSummary renders one parameter for `task describe`.
+func paramSummary(ctx *RuntimeContext, spec ParamSpec) string {
+	parts := []string{spec.typ()}
+	if spec.Required {
+		parts = append(parts, ctx.Out.Text(msgParamRequired))
+	} else if spec.Default != nil {
+		parts = append(parts, ctx.Out.Sprintf(msgParamDefault, spec.Default))
+	}
+	if len(spec.Choices) > 0 {
+		parts = append(parts, ctx.Out.Sprintf(msgParamOneOf, strings.Join(spec.Choices, "|")))
+	}
+	value := strings.Join(parts, ", ")
+	if spec.Description != "" {
+		value += ctx.Out.Dim(" – " + spec.Description)
+	}
+	return value
+}
+
+func taskTimeout(out Renderer, d Duration) string {
+	if d <= 0 {
+		return out.Text(msgNoTimeout)
+	}
+	return d.String()
+}
+
+func lastRunStatus(entry *HistoryEntry) string {
+	if entry == nil {
+		return "never"
+	}
+	return entry.Status
+}
+
+func lastRunSummary(ctx *RuntimeContext, entry *HistoryEntry, now time.Time) string {
+	if entry == nil {
+		return ctx.Out.Dim(ctx.Out.Text(msgNeverRun))
+	}
+	return historyStatus(ctx, entry.Status) + ctx.Out.Dim(" "+humanize.RelTime(entry.Started, now))
+}
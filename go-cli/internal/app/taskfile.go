@@ -0,0 +1,160 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/stdinutil"
+)
+
+// TaskSpec declares a command task in tasks.toml or under [tasks] in the
+// config file.
+type TaskSpec struct {
+	Description string   `mapstructure:"description" json:"description,omitempty" yaml:"description,omitempty"`
+	Cmds        []string `mapstructure:"cmds" json:"cmds,omitempty" yaml:"cmds,omitempty"`
+	Deps        []string `mapstructure:"deps" json:"deps,omitempty" yaml:"deps,omitempty"`
+	Dir         string   `mapstructure:"dir" json:"dir,omitempty" yaml:"dir,omitempty"`
+	// Env holds KEY=VALUE pairs added to the inherited environment. A list
+	// rather than a table because TOML keys lose their case in viper.
+	Env []string `mapstructure:"env" json:"env,omitempty" yaml:"env,omitempty"`
+	// Inputs are glob patterns, relative to Dir, naming the files the task
+	// reads. When set, `run` skips the task while neither these files nor
+	// its definition changed since it last succeeded.
+	Inputs []string `mapstructure:"inputs" json:"inputs,omitempty" yaml:"inputs,omitempty"`
+	// Priority orders the task among ready tasks; see runtime.priority.
+	Priority int `mapstructure:"priority" json:"priority,omitempty" yaml:"priority,omitempty"`
+	// Params declares the parameters accepted through run --param. Cmds
+	// reference them as {{.name}}; they are also exported as
+	// GO_CLI_PARAM_<NAME>.
+	Params map[string]ParamSpec `mapstructure:"params" json:"params,omitempty" yaml:"params,omitempty"`
+	// Input makes the task read a payload piped to run: auto, json, yaml,
+	// or text. Cmds receive it on stdin, with its format in
+	// GO_CLI_INPUT_FORMAT.
+	Input string `mapstructure:"input" json:"input,omitempty" yaml:"input,omitempty"`
+
+	// declaredDir is Dir as written in a taskfile, before it was resolved
+	// against the taskfile's directory; nil for inline tasks.
+	declaredDir *string
+}
+
+// RemoteDir returns Dir as declared, without the taskfile's local
+// directory: what a task runs in relative to a remote's dir.
+func (s TaskSpec) RemoteDir() string {
+	if s.declaredDir != nil {
+		return *s.declaredDir
+	}
+	return s.Dir
+}
+
+func validateTaskSpecs(key string, specs map[string]TaskSpec) error {
+	names := make([]string, 0, len(specs))
+	for name := range specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		spec := specs[name]
+		if len(spec.Cmds) == 0 && len(spec.Deps) == 0 {
+			return fmt.Errorf("invalid %s.%s: declare cmds, deps, or both", key, name)
+		}
+		for _, kv := range spec.Env {
+			if k, _, ok := strings.Cut(kv, "="); !ok || k == "" {
+				return fmt.Errorf("invalid %s.%s.env entry %q (expected KEY=VALUE)", key, name, kv)
+			}
+		}
+		if err := validateParamSpecs(key+"."+name+".params", spec.Params); err != nil {
+			return err
+		}
+		if spec.Input != "" {
+			if _, err := stdinutil.ParseFormat(spec.Input); err != nil {
+				return fmt.Errorf("invalid %s.%s.input: %v", key, name, err)
+			}
+		}
+		for _, pattern := range spec.Inputs {
+			if _, err := path.Match(strings.ReplaceAll(pattern, "**", "*"), ""); err != nil || !filepath.IsLocal(filepath.FromSlash(pattern)) {
+				return fmt.Errorf("invalid %s.%s.inputs pattern %q (expected a relative glob)", key, name, pattern)
+			}
+		}
+	}
+	return nil
+}
+
+// LoadTaskfile reads the [tasks] tables of a task file. A missing file
+// yields no tasks. Relative dir settings are resolved against the file's
+// directory so the file works from any working directory.
+func LoadTaskfile(path string) (map[string]TaskSpec, error) {
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	v.SetConfigType("toml")
+	if err := v.ReadInConfig(); err != nil {
+		return nil, fmt.Errorf("read taskfile: %w", err)
+	}
+	var file struct {
+		Tasks map[string]TaskSpec `mapstructure:"tasks"`
+	}
+	if err := v.UnmarshalExact(&file, viper.DecodeHook(configDecodeHook())); err != nil {
+		return nil, fmt.Errorf("decode taskfile %s: %w", path, err)
+	}
+	if err := validateTaskSpecs(path+": tasks", file.Tasks); err != nil {
+		return nil, err
+	}
+
+	base, err := filepath.Abs(filepath.Dir(path))
+	if err != nil {
+		return nil, err
+	}
+	for name, spec := range file.Tasks {
+		declared := spec.Dir
+		spec.declaredDir = &declared
+		switch {
+		case spec.Dir == "":
+			spec.Dir = base
+		case !filepath.IsAbs(spec.Dir):
+			spec.Dir = filepath.Join(base, spec.Dir)
+		}
+		file.Tasks[name] = spec
+	}
+	return file.Tasks, nil
+}
+
+// DeclaredTasks merges inline [tasks] from the config with the configured
+// task file. Declaring the same name in both places is an error.
+func DeclaredTasks(ctx *RuntimeContext) (map[string]TaskSpec, error) {
+	specs := make(map[string]TaskSpec, len(ctx.Config.Tasks))
+	for name, spec := range ctx.Config.Tasks {
+		specs[name] = spec
+	}
+	if ctx.Config.Taskfile == "" {
+		return specs, nil
+	}
+
+	path, err := expandPath(ctx.Config.Taskfile)
+	if err != nil {
+		return nil, fmt.Errorf("expand taskfile path: %w", err)
+	}
+	fromFile, err := LoadTaskfile(path)
+	if err != nil {
+		return nil, err
+	}
+	for name, spec := range fromFile {
+		if _, dup := specs[name]; dup {
+			return nil, fmt.Errorf("task %q is declared in both the config and %s", name, path)
+		}
+		specs[name] = spec
+	}
+	if len(fromFile) > 0 {
+		ctx.Logger.Debug("loaded %d task(s) from %s", len(fromFile), path)
+	}
+	return specs, nil
+}
@@ -0,0 +1,85 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// taskLogsDir holds one directory of per-task log files per run.
+func taskLogsDir(stateDir string) string {
+	return filepath.Join(stateDir, "logs")
+}
+
+// taskLogs writes each task's log stream of one run to its own file, next
+// to the interleaved main log.
+type taskLogs struct {
+	dir string
+}
+
+func newTaskLogs(ctx *RuntimeContext, runID string) *taskLogs {
+	return &taskLogs{dir: filepath.Join(taskLogsDir(ctx.Paths.StateDir), runID)}
+}
+
+// path is the log file of the named task. Characters that cannot appear
+// in file names, as in ad-hoc --stdin job names, become _.
+func (l *taskLogs) path(task string) string {
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, task)
+	if strings.Trim(name, ".") == "" {
+		name = "_" + name
+	}
+	return filepath.Join(l.dir, name+".log")
+}
+
+// open returns a logger that appends to the task's file, so retries add to
+// the same log. Records are plain timestamped text whatever the main log
+// format.
+func (l *taskLogs) open(task string, level Level) (Logger, io.Closer, error) {
+	if err := os.MkdirAll(l.dir, 0o755); err != nil {
+		return Logger{}, nil, fmt.Errorf("create task log directory: %w", err)
+	}
+	f, err := os.OpenFile(l.path(task), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return Logger{}, nil, fmt.Errorf("open task log: %w", err)
+	}
+	logger := ConfigureLogger(LogSettings{
+		Level:       level,
+		Format:      FormatText,
+		Diagnostics: true,
+		Writers:     []io.Writer{f},
+	})
+	return logger, f, nil
+}
+
+// existing returns the task's log file, or "" if it never wrote one.
+func (l *taskLogs) existing(task string) string {
+	if l == nil {
+		return ""
+	}
+	path := l.path(task)
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+type taskLogKey struct{}
+
+// TaskLogger returns the logger a task should write to while it runs under
+// ctx: the main logger, also copied to the task's own log file during a
+// run. Outside a run it is rtx.Logger.
+func (rtx *RuntimeContext) TaskLogger(ctx context.Context) Logger {
+	if file, ok := ctx.Value(taskLogKey{}).(Logger); ok {
+		return rtx.Logger.tee(file)
+	}
+	return rtx.Logger
+}
@@ -0,0 +1,110 @@
+package app
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/buildinfo"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/httpx"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/tracing"
+)
+
+// traceFlushTimeout bounds how long exiting waits for spans to be sent.
+const traceFlushTimeout = 5 * time.Second
+
+// traces is the tracing state of one invocation: the exporter, the span
+// of the command, and when the config was loaded, recorded as a child
+// span once the command span exists.
+type traces struct {
+	shutdown           func(context.Context) error
+	command            trace.Span
+	loadStart, loadEnd time.Time
+}
+
+// setupTracing starts exporting spans when [telemetry.traces] names an
+// endpoint and joins the trace in TRACEPARENT. Tracing problems are
+// logged, never fatal.
+func (rtx *RuntimeContext) setupTracing(loadStart, loadEnd time.Time) {
+	cfg := rtx.Config.Telemetry.Traces
+	// The SDK would print export failures to stderr; a collector being
+	// down is not worth interrupting the command's output for.
+	otel.SetErrorHandler(otel.ErrorHandlerFunc(func(err error) {
+		rtx.Logger.Debug("tracing: %v", err)
+	}))
+	opts := tracing.Config{
+		Endpoint:       cfg.Endpoint,
+		Protocol:       cfg.Protocol,
+		Headers:        cfg.Headers,
+		SampleRatio:    cfg.SampleRatio,
+		ServiceName:    appName,
+		ServiceVersion: buildinfo.Get().Version,
+	}
+	// Exports go through the [http] proxy and CA bundle.
+	if opts.Endpoint != "" {
+		var err error
+		if opts.Proxy, err = httpx.ProxyFunc(rtx.httpOptions()); err == nil {
+			opts.TLS, err = httpx.TLSConfig(rtx.httpOptions())
+		}
+		if err != nil {
+			rtx.Logger.Warn("tracing disabled: %v", err)
+			opts.Endpoint = ""
+		}
+	}
+	shutdown, err := tracing.Setup(rtx.Context, opts)
+	if err != nil {
+		rtx.Logger.Warn("tracing disabled: %v", err)
+		shutdown = func(context.Context) error { return nil }
+	}
+	rtx.traces = &traces{shutdown: shutdown, loadStart: loadStart, loadEnd: loadEnd}
+	rtx.Context = tracing.FromEnviron(rtx.Context)
+}
+
+// StartCommandSpan opens the span of the command at path, e.g. "go-cli
+// run", backdated to when loading the config began. Spans of the config
+// load, the tasks, and subprocesses nest under it.
+func (rtx *RuntimeContext) StartCommandSpan(path string) {
+	if rtx.traces == nil || rtx.traces.command != nil {
+		return
+	}
+	ctx, span := tracing.Start(rtx.Context, path, rtx.traces.loadStart,
+		attribute.String("gocli.profile", rtx.Config.Profile))
+	_, load := tracing.Start(ctx, "config.load", rtx.traces.loadStart,
+		attribute.String("gocli.config_file", rtx.Paths.ConfigFile))
+	load.End(trace.WithTimestamp(rtx.traces.loadEnd))
+	rtx.traces.command = span
+	rtx.Context = ctx
+}
+
+// EndCommandSpan closes the command span with the command's outcome.
+func (rtx *RuntimeContext) EndCommandSpan(err error) {
+	if rtx == nil || rtx.traces == nil || rtx.traces.command == nil {
+		return
+	}
+	tracing.End(rtx.traces.command, err)
+	rtx.traces.command = nil
+}
+
+// recordDeprecation notes on the command span that the deprecated command
+// or flag id was used, so collectors can count who still relies on it.
+func (rtx *RuntimeContext) recordDeprecation(id string) {
+	if rtx.traces == nil || rtx.traces.command == nil {
+		return
+	}
+	rtx.traces.command.AddEvent("deprecated", trace.WithAttributes(attribute.String("gocli.deprecated", id)))
+}
+
+// flushTraces sends the spans still buffered.
+func (rtx *RuntimeContext) flushTraces() {
+	if rtx.traces == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), traceFlushTimeout)
+	defer cancel()
+	if err := rtx.traces.shutdown(ctx); err != nil {
+		rtx.Logger.Debug("tracing: flush: %v", err)
+	}
+}
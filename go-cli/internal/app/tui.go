@@ -0,0 +1,499 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/humanize"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/runner"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/statestore"
+)
+
+// TUIOptions configure the tui command.
+type TUIOptions struct {
+	// Tasks are the registered tasks the dashboard offers to run.
+	Tasks []TaskInfo
+	// Resolve builds the run for a task picked in the dashboard.
+	Resolve ResolveRunFunc
+}
+
+const (
+	// tuiRefresh is how often the dashboard re-reads history and logs.
+	tuiRefresh = time.Second
+	// tuiRows bounds the task, history, and log panes.
+	tuiRows = 8
+	// tuiLogLines is how many log records the log pane keeps.
+	tuiLogLines = 200
+	// tuiHistoryRuns is how many recent runs the history pane scrolls through.
+	tuiHistoryRuns = 50
+)
+
+// Panes that take the cursor keys.
+const (
+	tuiFocusTasks = iota
+	tuiFocusHistory
+)
+
+// HandleTUI runs the interactive dashboard until the user quits. It shows
+// the effective config, recent runs, the progress of a run started from
+// the dashboard, and the log, on the same RuntimeContext the commands
+// use: runs go through HandleRun under the instance lock, and log records
+// are drawn in the log pane instead of on stderr.
+func HandleTUI(ctx *RuntimeContext, opts TUIOptions) error {
+	if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		return UsageError(errors.New("tui needs an interactive terminal"))
+	}
+
+	logs := &logBuffer{max: tuiLogLines}
+	logger := ctx.Logger
+	ctx.Logger = logger.withStderr(logs)
+	defer func() { ctx.Logger = logger }()
+
+	m := &tuiModel{ctx: ctx, opts: opts, logs: logs}
+	m.loadHistory()
+	m.program = tea.NewProgram(m, tea.WithContext(ctx))
+	_, err := m.program.Run()
+	// Quitting interrupts a run in progress; let it record its history.
+	m.stopRun()
+	m.wg.Wait()
+	if errors.Is(err, tea.ErrProgramKilled) && ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// logBuffer keeps the last max lines written to it.
+type logBuffer struct {
+	mu    sync.Mutex
+	max   int
+	lines []string
+}
+
+func (b *logBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, line := range strings.Split(strings.TrimRight(string(p), "\n"), "\n") {
+		b.lines = append(b.lines, line)
+	}
+	if len(b.lines) > b.max {
+		b.lines = append([]string(nil), b.lines[len(b.lines)-b.max:]...)
+	}
+	return len(p), nil
+}
+
+// tail returns up to n of the most recent lines.
+func (b *logBuffer) tail(n int) []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	start := max(len(b.lines)-n, 0)
+	return append([]string(nil), b.lines[start:]...)
+}
+
+// tuiRun is the progress of a run started from the dashboard.
+type tuiRun struct {
+	ID      string
+	Task    string
+	Started time.Time
+	// Order lists the tasks in job order; State maps each to its state.
+	Order []string
+	State map[string]string
+	Done  bool
+	Err   error
+}
+
+// Task states shown in the run pane.
+const (
+	tuiPending   = "pending"
+	tuiRunning   = "running"
+	tuiSucceeded = "succeeded"
+	tuiFailed    = "failed"
+	tuiSkipped   = "skipped"
+	tuiUpToDate  = "up to date"
+)
+
+type (
+	tuiTickMsg        struct{}
+	tuiJobStartedMsg  struct{ name string }
+	tuiJobFinishedMsg struct{ result runner.Result }
+	tuiRunDoneMsg     struct {
+		id  string
+		err error
+	}
+)
+
+// tuiObserver forwards runner events to the dashboard.
+type tuiObserver struct {
+	send func(tea.Msg)
+}
+
+func (o tuiObserver) JobStarted(name string) {
+	o.send(tuiJobStartedMsg{name: name})
+}
+
+func (o tuiObserver) JobFinished(result runner.Result) {
+	o.send(tuiJobFinishedMsg{result: result})
+}
+
+type tuiModel struct {
+	ctx     *RuntimeContext
+	opts    TUIOptions
+	program *tea.Program
+	logs    *logBuffer
+
+	width, height int
+	focus         int
+	taskCursor    int
+	historyCursor int
+	// history holds recent runs, newest first.
+	history []HistoryEntry
+	frame   int
+	// notice is the last error or hint, shown above the key help.
+	notice string
+
+	run       *tuiRun
+	cancelRun context.CancelFunc
+	wg        sync.WaitGroup
+
+	// logRun, when set, switches to the task logs of that run.
+	logRun string
+}
+
+func tuiTick() tea.Cmd {
+	return tea.Tick(tuiRefresh, func(time.Time) tea.Msg { return tuiTickMsg{} })
+}
+
+func (m *tuiModel) Init() tea.Cmd {
+	return tuiTick()
+}
+
+func (m *tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case tuiTickMsg:
+		m.frame++
+		m.loadHistory()
+		return m, tuiTick()
+	case tuiJobStartedMsg:
+		if m.run != nil {
+			m.run.State[msg.name] = tuiRunning
+		}
+	case tuiJobFinishedMsg:
+		if m.run != nil {
+			m.run.State[msg.result.Name] = jobState(msg.result)
+		}
+	case tuiRunDoneMsg:
+		if m.run != nil && m.run.ID == msg.id {
+			m.run.Done, m.run.Err = true, msg.err
+		}
+		m.loadHistory()
+	case tea.KeyPressMsg:
+		return m, m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m *tuiModel) handleKey(msg tea.KeyPressMsg) tea.Cmd {
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return tea.Quit
+	case "esc":
+		m.logRun = ""
+	case "tab", "shift+tab":
+		m.focus = 1 - m.focus
+	case "up", "k":
+		m.moveCursor(-1)
+	case "down", "j":
+		m.moveCursor(1)
+	case "enter", "r":
+		task := ""
+		switch {
+		case m.focus == tuiFocusTasks && len(m.opts.Tasks) > 0:
+			task = m.opts.Tasks[m.taskCursor].Name
+		case m.focus == tuiFocusHistory && len(m.history) > 0:
+			task = m.history[m.historyCursor].Task
+		}
+		if task != "" {
+			m.startRun(task)
+		}
+	case "l":
+		switch {
+		case m.focus == tuiFocusHistory && len(m.history) > 0:
+			m.logRun = m.history[m.historyCursor].ID
+		case m.run != nil:
+			m.logRun = m.run.ID
+		default:
+			m.notice = "no run selected; pick one under Recent runs"
+		}
+	case "x":
+		if m.run != nil && !m.run.Done {
+			m.stopRun()
+			m.notice = "interrupting run " + m.run.ID
+		}
+	}
+	return nil
+}
+
+func (m *tuiModel) moveCursor(delta int) {
+	clamp := func(i, n int) int { return max(0, min(i, n-1)) }
+	if m.focus == tuiFocusTasks {
+		m.taskCursor = clamp(m.taskCursor+delta, len(m.opts.Tasks))
+	} else {
+		m.historyCursor = clamp(m.historyCursor+delta, len(m.history))
+	}
+}
+
+func (m *tuiModel) loadHistory() {
+	entries, err := loadHistory(m.ctx, statestore.Query{Limit: tuiHistoryRuns})
+	if err != nil {
+		m.notice = err.Error()
+		return
+	}
+	m.history = m.history[:0]
+	for i := len(entries) - 1; i >= 0 && len(m.history) < tuiHistoryRuns; i-- {
+		m.history = append(m.history, entries[i])
+	}
+	m.historyCursor = max(0, min(m.historyCursor, len(m.history)-1))
+}
+
+// startRun runs task in the background on its own copy of the runtime
+// context, with output discarded and progress sent to the dashboard.
+func (m *tuiModel) startRun(task string) {
+	if m.run != nil && !m.run.Done {
+		m.notice = "a run is in progress; x interrupts it"
+		return
+	}
+	runCtx, cancel := context.WithCancel(m.ctx)
+	rtx := m.ctx.fork(runCtx)
+	rtx.Common.NoProgress = true
+	rtx.Out = NewRenderer(io.Discard, false, rtx.Glyphs, rtx.Printer)
+	opts, err := m.opts.Resolve(rtx, task, nil)
+	if err != nil {
+		cancel()
+		m.notice = err.Error()
+		return
+	}
+	opts.RunID = NewRunID(rtx.Clock.Now())
+	opts.Observer = tuiObserver{send: m.program.Send}
+
+	run := &tuiRun{ID: opts.RunID, Task: task, Started: rtx.Clock.Now(), State: map[string]string{}}
+	for _, job := range opts.Jobs {
+		run.Order = append(run.Order, job.Name)
+		run.State[job.Name] = tuiPending
+	}
+	m.run, m.cancelRun, m.notice = run, cancel, ""
+
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		defer cancel()
+		err := rtx.withRunLock(func() error { return HandleRun(rtx, opts) })
+		m.program.Send(tuiRunDoneMsg{id: run.ID, err: err})
+	}()
+}
+
+func (m *tuiModel) stopRun() {
+	if m.cancelRun != nil {
+		m.cancelRun()
+	}
+}
+
+func jobState(result runner.Result) string {
+	switch {
+	case result.UpToDate:
+		return tuiUpToDate
+	case result.Skipped:
+		return tuiSkipped
+	case result.Err != nil:
+		return tuiFailed
+	default:
+		return tuiSucceeded
+	}
+}
+
+func (m *tuiModel) View() tea.View {
+	var lines []string
+	if m.logRun != "" {
+		lines = m.logView()
+	} else {
+		lines = m.dashboard()
+	}
+	if m.height > 0 && len(lines) > m.height {
+		lines = lines[:m.height]
+	}
+	if m.width > 0 {
+		for i, line := range lines {
+			lines[i] = ansi.Truncate(line, m.width, "")
+		}
+	}
+	view := tea.NewView(strings.Join(lines, "\n"))
+	view.AltScreen = true
+	return view
+}
+
+func (m *tuiModel) dashboard() []string {
+	out, g := m.ctx.Out, m.ctx.Glyphs
+	cfg := m.ctx.Config
+	lines := []string{
+		out.Bold(appName+" dashboard") + "  " + out.Dim(fmt.Sprintf("profile %s %s timeout %s", cfg.Profile, g.Bullet, humanize.Duration(m.ctx.Timeout))),
+		out.Dim("config   ") + m.ctx.Paths.ConfigFile,
+		out.Dim("taskfile ") + cfg.Taskfile,
+		out.Dim("state    ") + m.ctx.Paths.StateDir,
+		"",
+		m.section("Tasks", m.focus == tuiFocusTasks),
+	}
+
+	if len(m.opts.Tasks) == 0 {
+		lines = append(lines, out.Dim("  no tasks registered"))
+	}
+	width := 0
+	for _, t := range m.opts.Tasks {
+		width = max(width, len(t.Name))
+	}
+	start, end := window(len(m.opts.Tasks), m.taskCursor, tuiRows)
+	for i := start; i < end; i++ {
+		t := m.opts.Tasks[i]
+		lines = append(lines, m.cursor(m.focus == tuiFocusTasks && i == m.taskCursor)+fmt.Sprintf("%-*s  ", width, t.Name)+out.Dim(t.Description))
+	}
+
+	lines = append(lines, "", m.section("Recent runs", m.focus == tuiFocusHistory))
+	if len(m.history) == 0 {
+		lines = append(lines, out.Dim("  no runs recorded yet"))
+	}
+	now := m.ctx.Clock.Now()
+	start, end = window(len(m.history), m.historyCursor, tuiRows)
+	for i := start; i < end; i++ {
+		e := m.history[i]
+		lines = append(lines, m.cursor(m.focus == tuiFocusHistory && i == m.historyCursor)+fmt.Sprintf("%s  %-12s %s  %s  %s",
+			e.ID, e.Task, historyStatus(m.ctx, e.Status),
+			humanize.Duration(time.Duration(e.DurationMS)*time.Millisecond),
+			out.Dim(humanize.RelTime(e.Started, now))))
+	}
+
+	if m.run != nil {
+		lines = append(lines, "")
+		lines = append(lines, m.runPane()...)
+	}
+
+	lines = append(lines, "", out.Bold("Log"))
+	logLines := m.logs.tail(tuiRows)
+	if len(logLines) == 0 {
+		lines = append(lines, out.Dim("  nothing logged yet"))
+	}
+	for _, line := range logLines {
+		lines = append(lines, "  "+line)
+	}
+
+	lines = append(lines, "")
+	if m.notice != "" {
+		lines = append(lines, out.Yellow(m.notice))
+	}
+	return append(lines, out.Dim("tab switch pane  j/k select  enter run  l task logs  x interrupt  q quit"))
+}
+
+func (m *tuiModel) runPane() []string {
+	out, g := m.ctx.Out, m.ctx.Glyphs
+	run := m.run
+	done := 0
+	for _, name := range run.Order {
+		if state := run.State[name]; state != tuiPending && state != tuiRunning {
+			done++
+		}
+	}
+
+	heading := fmt.Sprintf("Run %s  %s  %d/%d", run.ID, run.Task, done, len(run.Order))
+	switch {
+	case !run.Done:
+		heading += "  " + out.Dim(humanize.Duration(m.ctx.Clock.Since(run.Started)))
+	case run.Err == nil:
+		heading += "  " + out.Green(g.Success+" succeeded")
+	default:
+		heading += "  " + out.Red(g.Failure+" "+run.Err.Error())
+	}
+	lines := []string{out.Bold(heading)}
+	if len(run.Order) > 0 {
+		const barWidth = 30
+		filled := barWidth * done / len(run.Order)
+		lines = append(lines, "  "+out.Accent(strings.Repeat(g.Bar.Filled, filled))+out.Dim(strings.Repeat(g.Bar.Empty, barWidth-filled)))
+	}
+	for _, name := range run.Order {
+		var mark string
+		switch state := run.State[name]; state {
+		case tuiRunning:
+			mark = out.Accent(g.Spinner[m.frame%len(g.Spinner)])
+		case tuiSucceeded:
+			mark = out.Green(g.Success)
+		case tuiFailed:
+			mark = out.Red(g.Failure)
+		case tuiPending:
+			mark = out.Dim(g.Bullet)
+		default:
+			mark = out.Dim(g.Skipped)
+		}
+		lines = append(lines, fmt.Sprintf("  %s %s %s", mark, name, out.Dim(run.State[name])))
+	}
+	return lines
+}
+
+// logView shows the end of each task log of m.logRun, re-read on every
+// refresh so a running task can be followed.
+func (m *tuiModel) logView() []string {
+	out := m.ctx.Out
+	lines := []string{out.Bold("Task logs of run " + m.logRun)}
+	paths, _ := filepath.Glob(filepath.Join(taskLogsDir(m.ctx.Paths.StateDir), m.logRun, "*.log"))
+	sort.Strings(paths)
+	rows := tuiRows * 3
+	if m.height > 0 {
+		rows = max(m.height-4, 1)
+	}
+	var body []string
+	for _, path := range paths {
+		body = append(body, out.Accent("== "+strings.TrimSuffix(filepath.Base(path), ".log")+" =="))
+		if data, ok := tailFile(path, rows); ok {
+			body = append(body, strings.Split(strings.TrimRight(string(data), "\n"), "\n")...)
+		}
+	}
+	if len(body) == 0 {
+		body = []string{out.Dim("no task logs for this run")}
+	}
+	if len(body) > rows {
+		body = body[len(body)-rows:]
+	}
+	lines = append(lines, body...)
+	return append(lines, "", out.Dim("esc back  q quit"))
+}
+
+func (m *tuiModel) section(title string, focused bool) string {
+	if focused {
+		return m.ctx.Out.Accent(m.ctx.Out.Bold(title))
+	}
+	return m.ctx.Out.Bold(title)
+}
+
+func (m *tuiModel) cursor(selected bool) string {
+	if selected {
+		return m.ctx.Out.Accent(m.ctx.Glyphs.Arrow) + " "
+	}
+	return "  "
+}
+
+// window returns the range of n rows to show so that cursor stays visible
+// in at most size rows.
+func window(n, cursor, size int) (int, int) {
+	if n <= size {
+		return 0, n
+	}
+	start := max(0, min(cursor-size/2, n-size))
+	return start, start + size
+}
@@ -0,0 +1,221 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	yaml "gopkg.in/yaml.v3"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/control"
+)
+
+// UninstallOptions configure the uninstall command.
+type UninstallOptions struct {
+	// Purge also removes the config, data, state, and cache directories.
+	Purge bool
+}
+
+// UninstallTarget is a path uninstall removes.
+type UninstallTarget struct {
+	Kind string `json:"kind" yaml:"kind"`
+	Path string `json:"path" yaml:"path"`
+}
+
+// UninstallResult reports what uninstall did.
+type UninstallResult struct {
+	Removed []UninstallTarget `json:"removed" yaml:"removed"`
+	// Skipped targets were left in place because removing them looked
+	// unsafe, e.g. a data_dir pointing at the home directory.
+	Skipped []UninstallTarget `json:"skipped,omitempty" yaml:"skipped,omitempty"`
+	// Binary is the executable, which uninstall leaves to the package
+	// manager or the user.
+	Binary string `json:"binary,omitempty" yaml:"binary,omitempty"`
+}
+
+// completionPaths lists where the completion scripts from `completions`
+// are usually installed, per the examples in the docs and the shells'
+// user and system directories.
+func completionPaths() []string {
+	home, _ := os.UserHomeDir()
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" && home != "" {
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" && home != "" {
+		configHome = filepath.Join(home, ".config")
+	}
+	paths := []string{
+		filepath.Join("/etc/bash_completion.d", appName),
+		filepath.Join("/usr/local/etc/bash_completion.d", appName),
+		filepath.Join("/usr/local/share/zsh/site-functions", "_"+appName),
+	}
+	if dataHome != "" {
+		paths = append(paths, filepath.Join(dataHome, "bash-completion", "completions", appName))
+	}
+	if configHome != "" {
+		paths = append(paths, filepath.Join(configHome, "fish", "completions", appName+".fish"))
+	}
+	return paths
+}
+
+// uninstallTargets lists the existing paths to remove.
+func uninstallTargets(ctx *RuntimeContext, purge bool) []UninstallTarget {
+	var targets []UninstallTarget
+	add := func(kind, path string) {
+		if path == "" {
+			return
+		}
+		if _, err := os.Lstat(path); err == nil {
+			targets = append(targets, UninstallTarget{Kind: kind, Path: path})
+		}
+	}
+	for _, path := range completionPaths() {
+		add("completions", path)
+	}
+	for _, manager := range []string{ServiceSystemd, ServiceLaunchd} {
+		for _, system := range []bool{false, true} {
+			if path, err := serviceUnitPath(manager, system); err == nil {
+				add("service", path)
+			}
+		}
+	}
+	if !purge {
+		return targets
+	}
+
+	configDir := filepath.Dir(ctx.Paths.ConfigFile)
+	if filepath.Base(configDir) == appName {
+		add("config", configDir)
+	} else {
+		// A --config outside the app's own directory: only the file is ours.
+		add("config", ctx.Paths.ConfigFile)
+	}
+	add("data", ctx.Paths.DataDir)
+	add("state", ctx.Paths.StateDir)
+	add("cache", ctx.Paths.CacheDir)
+	return targets
+}
+
+// unsafeToRemove reports whether path is the filesystem root, the home
+// directory, or one of its ancestors, which a misconfigured data_dir or
+// state_dir could point at.
+func unsafeToRemove(path string) bool {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return true
+	}
+	if abs == filepath.Dir(abs) {
+		return true
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(abs, home)
+	return err == nil && (rel == "." || !strings.HasPrefix(rel, ".."))
+}
+
+// HandleUninstall stops the daemon and removes installed completion
+// scripts and, with Purge, every directory the CLI writes. It asks for
+// confirmation unless --yes is given.
+func HandleUninstall(ctx *RuntimeContext, opts UninstallOptions) error {
+	result := UninstallResult{}
+	if exe, err := os.Executable(); err == nil {
+		result.Binary = exe
+	}
+
+	var targets []UninstallTarget
+	for _, t := range uninstallTargets(ctx, opts.Purge) {
+		if unsafeToRemove(t.Path) {
+			ctx.Logger.Warn("not removing %s directory %s: it contains the home directory", t.Kind, t.Path)
+			result.Skipped = append(result.Skipped, t)
+			continue
+		}
+		targets = append(targets, t)
+	}
+	daemon, daemonErr := queryInstance(ctx, ctx.Paths.StateDir, control.CmdStatus)
+
+	if ctx.Common.DryRun {
+		if daemonErr == nil {
+			ctx.Logger.Info("dry-run: would stop the %s (pid %d)", daemon.Mode, daemon.PID)
+		}
+		for _, t := range targets {
+			ctx.Logger.Info("dry-run: would remove %s %s", t.Kind, t.Path)
+		}
+		if len(targets) == 0 {
+			ctx.Logger.Info("dry-run: nothing to remove")
+		}
+		return nil
+	}
+
+	if len(targets) > 0 {
+		lines := make([]string, 0, len(targets))
+		for _, t := range targets {
+			lines = append(lines, fmt.Sprintf("  %-12s %s", t.Kind, t.Path))
+		}
+		err := confirmDestructive(ctx, Destructive{
+			Action:   "uninstall",
+			Resource: appName,
+			Question: fmt.Sprintf("This removes:\n%s\nContinue?", strings.Join(lines, "\n")),
+			HighRisk: true,
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	if daemonErr == nil {
+		if err := stopDaemon(ctx, daemon.PID); err != nil {
+			return err
+		}
+		ctx.Logger.Info("%s stopped (pid %d)", daemon.Mode, daemon.PID)
+	}
+
+	var errs []error
+	for _, t := range targets {
+		if err := os.RemoveAll(t.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("remove %s %s: %w", t.Kind, t.Path, err))
+			continue
+		}
+		result.Removed = append(result.Removed, t)
+	}
+
+	switch {
+	case ctx.Common.JSON:
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(ctx.Out.Writer(), string(data))
+	case ctx.Common.YAML:
+		data, err := yaml.Marshal(result)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(ctx.Out.Writer(), string(data))
+	case ctx.Common.Porcelain:
+		for _, t := range result.Removed {
+			fmt.Fprintf(ctx.Out.Writer(), "%s\t%s\n", t.Kind, t.Path)
+		}
+	default:
+		for _, t := range result.Removed {
+			ctx.Logger.Info("removed %s %s", t.Kind, t.Path)
+		}
+		if len(result.Removed) == 0 && len(errs) == 0 {
+			ctx.Logger.Info("nothing to remove")
+		}
+		if !opts.Purge {
+			ctx.Logger.Info("config, data, state, and cache were kept; uninstall --purge removes them")
+		}
+		if result.Binary != "" {
+			ctx.Logger.Info("the binary %s was left in place; remove it with your package manager or rm", result.Binary)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -6,6 +6,8 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/container"
 )
 
 func expandPath(path string) (string, error) {
@@ -49,11 +51,14 @@ func toEnvPrefix(name string) string {
 	return b.String()
 }
 
+// defaultParallelism is the number of CPUs, capped at the cgroup's CPU
+// quota: containers usually see all of the host's CPUs but may use fewer.
 func defaultParallelism() int {
-	if n := runtime.NumCPU(); n > 0 {
-		return n
+	n := runtime.NumCPU()
+	if q := container.CPUQuota(); q > 0 && q < n {
+		n = q
 	}
-	return 1
+	return max(n, 1)
 }
 
 func isTerminal(f *os.File) bool {
@@ -66,3 +71,29 @@ func isTerminal(f *os.File) bool {
 	}
 	return info.Mode()&os.ModeCharDevice != 0
 }
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// into place, so readers never observe a partially written file.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/watch"
+)
+
+// maxListedChanges caps how many changed files the separator line names.
+const maxListedChanges = 3
+
+// HandleWatch runs the task once, then again whenever watched files change,
+// until the command context is canceled (Ctrl+C). With jobsFor, the task's
+// jobs are built anew before each run instead of taken from opts.Jobs.
+func HandleWatch(ctx *RuntimeContext, opts RunOptions, jobsFor JobsFunc) error {
+	cfg := ctx.Config.Watch
+	runOnce := func() {
+		// In the daemon, `daemon reload` may have replaced the config.
+		ctx.Config = ctx.currentConfig()
+		run := opts
+		if jobsFor != nil {
+			jobs, err := jobsFor(ctx, opts.Task)
+			if err != nil {
+				ctx.Logger.Error("run failed: %v", err)
+				return
+			}
+			run.Jobs = jobs
+		}
+		if err := ctx.withRunLock(func() error { return HandleRun(ctx, run) }); err != nil {
+			ctx.Logger.Error("run failed: %v", err)
+		}
+	}
+
+	runOnce()
+	ctx.Logger.Info("watching %s for changes (Ctrl+C to stop)", strings.Join(cfg.Paths, ", "))
+
+	return watch.Watch(ctx, watch.Options{
+		Patterns: cfg.Paths,
+		Ignore:   cfg.Ignore,
+		Debounce: cfg.Debounce.Std(),
+	}, func(changed []string) {
+		// Machine-readable modes keep stdout to one result per run.
+		if ctx.Common.JSON || ctx.Common.YAML || ctx.Common.Porcelain {
+			ctx.Logger.Info("%d file(s) changed; re-running %s", len(changed), opts.Task)
+		} else {
+			if cfg.ClearScreen && isTerminal(os.Stdout) {
+				fmt.Fprint(ctx.Out.Writer(), "\033[H\033[2J")
+			}
+			ctx.Out.Println(ctx.Out.Dim(watchSeparator(ctx.Out, ctx.Glyphs, changed)))
+		}
+		runOnce()
+	})
+}
+
+func watchSeparator(out Renderer, glyphs Glyphs, changed []string) string {
+	listed := changed
+	if len(listed) > maxListedChanges {
+		listed = listed[:maxListedChanges]
+	}
+	label := strings.Join(listed, ", ")
+	if extra := len(changed) - len(listed); extra > 0 {
+		label += out.Sprintf(msgWatchMore, extra)
+	}
+	rule := strings.Repeat(glyphs.Box.Horizontal, 2)
+	return out.Sprintf(msgWatchChanged, rule, label, rule)
+}
@@ -0,0 +1,92 @@
+// Package buildinfo reports what binary is running. Release builds stamp
+// the fields with -ldflags; other builds fall back to the module and VCS
+// metadata the Go toolchain embeds:
+//
+//	go build -ldflags "-X gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/buildinfo.Version=v1.2.3 \
+//	    -X gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/buildinfo.Commit=$(git rev-parse HEAD) \
+//	    -X gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/buildinfo.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+)
+
+// Set with -ldflags -X at build time.
+var (
+	Version = ""
+	Commit  = ""
+	Date    = ""
+)
+
+// Info describes the running binary.
+type Info struct {
+	Version   string `json:"version" yaml:"version"`
+	Commit    string `json:"commit,omitempty" yaml:"commit,omitempty"`
+	Date      string `json:"date,omitempty" yaml:"date,omitempty"`
+	Modified  bool   `json:"modified,omitempty" yaml:"modified,omitempty"`
+	GoVersion string `json:"go_version" yaml:"go_version"`
+	Platform  string `json:"platform" yaml:"platform"`
+}
+
+// Get returns the stamped build metadata, filling unset fields from
+// debug.ReadBuildInfo. Version is "dev" when neither source knows it.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+		// VCS settings describe the checkout go build ran in; an ldflags
+		// commit may come from elsewhere, so only use them together.
+		if info.Commit == "" {
+			for _, s := range bi.Settings {
+				switch s.Key {
+				case "vcs.revision":
+					info.Commit = s.Value
+				case "vcs.time":
+					if info.Date == "" {
+						info.Date = s.Value
+					}
+				case "vcs.modified":
+					info.Modified = s.Value == "true"
+				}
+			}
+		}
+	}
+	if info.Version == "" {
+		info.Version = "dev"
+	}
+	return info
+}
+
+// ShortCommit returns the first 12 characters of the commit hash.
+func (i Info) ShortCommit() string {
+	if len(i.Commit) > 12 {
+		return i.Commit[:12]
+	}
+	return i.Commit
+}
+
+// String renders the one-line form used by --version, e.g.
+// "v1.2.3 (0123456789ab, 2026-01-02T15:04:05Z, go1.25.1 linux/amd64)".
+func (i Info) String() string {
+	detail := ""
+	if c := i.ShortCommit(); c != "" {
+		detail = c
+		if i.Modified {
+			detail += "-dirty"
+		}
+		detail += ", "
+	}
+	if i.Date != "" {
+		detail += i.Date + ", "
+	}
+	return i.Version + " (" + detail + i.GoVersion + " " + i.Platform + ")"
+}
@@ -0,0 +1,87 @@
+// Package clipboard puts text on the system clipboard: pbcopy on macOS,
+// clip on Windows, and wl-copy, xclip, or xsel on Linux and the BSDs. The
+// clipboard belongs to a desktop session, so a machine without one (an SSH
+// login, a container) gets ErrUnavailable rather than a copy.
+package clipboard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// timeout bounds the copy tool; it should return at once.
+const timeout = 10 * time.Second
+
+// ErrUnavailable reports a platform or machine without a clipboard.
+var ErrUnavailable = errors.New("no clipboard available")
+
+// tool is one clipboard program and the arguments that make it read
+// standard input.
+type tool struct {
+	name string
+	args []string
+}
+
+// Write replaces the clipboard contents with text.
+//
+// The tools run through os/exec rather than execx: wl-copy, xclip, and xsel
+// leave a child behind to serve the selection, and it would hold captured
+// output pipes open. Their output goes to the null device instead.
+func Write(ctx context.Context, text string) error {
+	t, path, err := find()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, path, t.args...)
+	cmd.Stdin = strings.NewReader(text)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", t.name, err)
+	}
+	return nil
+}
+
+// find picks the first clipboard tool installed for this session.
+func find() (tool, string, error) {
+	var tools []tool
+	var install string
+	switch runtime.GOOS {
+	case "darwin":
+		tools = []tool{{name: "pbcopy"}}
+	case "windows":
+		tools = []tool{{name: "clip"}}
+	case "linux", "freebsd", "openbsd", "netbsd", "dragonfly":
+		if os.Getenv("WAYLAND_DISPLAY") != "" {
+			tools = append(tools, tool{name: "wl-copy"})
+			install = "wl-clipboard"
+		}
+		if os.Getenv("DISPLAY") != "" {
+			tools = append(tools,
+				tool{name: "xclip", args: []string{"-selection", "clipboard"}},
+				tool{name: "xsel", args: []string{"--clipboard", "--input"}},
+			)
+			install += " or xclip"
+		}
+		if len(tools) == 0 {
+			return tool{}, "", fmt.Errorf("%w: no desktop session", ErrUnavailable)
+		}
+	default:
+		return tool{}, "", fmt.Errorf("%w on %s", ErrUnavailable, runtime.GOOS)
+	}
+	for _, t := range tools {
+		if path, err := exec.LookPath(t.name); err == nil {
+			return t, path, nil
+		}
+	}
+	if install != "" {
+		return tool{}, "", fmt.Errorf("%w: no copy tool found (install %s)", ErrUnavailable, strings.TrimPrefix(install, " or "))
+	}
+	return tool{}, "", fmt.Errorf("%w: %s not found", ErrUnavailable, tools[0].name)
+}
@@ -0,0 +1,123 @@
+// Package container detects whether the process runs inside a container
+// and reads the CPU quota the container was given. Detection uses the
+// marker files and environment variables container runtimes set, and the
+// cgroup of PID 1; none of it needs privileges.
+package container
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Info is the result of detection.
+type Info struct {
+	// Runtime names the container runtime, e.g. "docker" or "kubernetes";
+	// empty outside a container.
+	Runtime string `json:"runtime,omitempty" yaml:"runtime,omitempty"`
+	// Reason is the evidence the detection rests on.
+	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+}
+
+// Detected reports whether a container was detected.
+func (i Info) Detected() bool { return i.Runtime != "" }
+
+// cgroupMarkers map path fragments in /proc/1/cgroup to runtimes.
+var cgroupMarkers = []struct{ fragment, runtime string }{
+	{"kubepods", "kubernetes"},
+	{"docker", "docker"},
+	{"libpod", "podman"},
+	{"containerd", "containerd"},
+	{"lxc", "lxc"},
+}
+
+var (
+	detectOnce sync.Once
+	detected   Info
+)
+
+// Detect returns what it finds about the current process; the result is
+// computed once.
+func Detect() Info {
+	detectOnce.Do(func() { detected = detect() })
+	return detected
+}
+
+func detect() Info {
+	if runtime.GOOS != "linux" {
+		return Info{}
+	}
+	// Kubernetes first: its pods often run under docker or containerd too.
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		return Info{Runtime: "kubernetes", Reason: "KUBERNETES_SERVICE_HOST is set"}
+	}
+	if _, err := os.Stat("/.dockerenv"); err == nil {
+		return Info{Runtime: "docker", Reason: "/.dockerenv exists"}
+	}
+	if _, err := os.Stat("/run/.containerenv"); err == nil {
+		return Info{Runtime: "podman", Reason: "/run/.containerenv exists"}
+	}
+	// Set by systemd-nspawn, LXC, and podman for PID 1; exported by some
+	// images to their processes.
+	if name := os.Getenv("container"); name != "" {
+		return Info{Runtime: name, Reason: "container=" + name + " is set"}
+	}
+	data, err := os.ReadFile("/proc/1/cgroup")
+	if err != nil {
+		return Info{}
+	}
+	for _, m := range cgroupMarkers {
+		if bytes.Contains(data, []byte(m.fragment)) {
+			return Info{Runtime: m.runtime, Reason: "/proc/1/cgroup mentions " + m.fragment}
+		}
+	}
+	return Info{}
+}
+
+// CPUQuota returns the CPUs the cgroup may use, rounded up, or 0 without a
+// quota. It reads cgroup v2's cpu.max and falls back to v1's
+// cpu.cfs_quota_us and cpu.cfs_period_us.
+func CPUQuota() int {
+	if runtime.GOOS != "linux" {
+		return 0
+	}
+	if data, err := os.ReadFile("/sys/fs/cgroup/cpu.max"); err == nil {
+		fields := strings.Fields(string(data))
+		if len(fields) == 2 && fields[0] != "max" {
+			return quota(fields[0], fields[1])
+		}
+		return 0
+	}
+	for _, dir := range []string{"/sys/fs/cgroup/cpu", "/sys/fs/cgroup/cpu,cpuacct"} {
+		q, err1 := os.ReadFile(dir + "/cpu.cfs_quota_us")
+		p, err2 := os.ReadFile(dir + "/cpu.cfs_period_us")
+		if err1 == nil && err2 == nil {
+			return quota(firstLine(q), firstLine(p))
+		}
+	}
+	return 0
+}
+
+// quota divides a quota by its period; -1 (v1's "unlimited") and invalid
+// values give 0.
+func quota(q, period string) int {
+	qn, err1 := strconv.ParseFloat(q, 64)
+	pn, err2 := strconv.ParseFloat(period, 64)
+	if err1 != nil || err2 != nil || qn <= 0 || pn <= 0 {
+		return 0
+	}
+	return int(math.Ceil(qn / pn))
+}
+
+func firstLine(data []byte) string {
+	s := bufio.NewScanner(bytes.NewReader(data))
+	if s.Scan() {
+		return strings.TrimSpace(s.Text())
+	}
+	return ""
+}
@@ -0,0 +1,152 @@
+// Package control is the local control channel of a resident instance
+// (`daemon start`, `serve`): a Unix socket, or a named pipe on Windows,
+// that carries length-prefixed JSON frames. A client sends one Request
+// and reads Replies until one has Done set, so a status query is a
+// single exchange and `logs --follow` is a stream on the same footing.
+//
+// A frame is a 4-byte big-endian payload length followed by that many
+// bytes of JSON.
+package control
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// Commands a Request may carry.
+const (
+	CmdStatus = "status"
+	CmdStop   = "stop"
+	CmdReload = "reload"
+	CmdLogs   = "logs"
+)
+
+// MaxFrame bounds the payload of one frame.
+const MaxFrame = 1 << 20
+
+// Request is what a client sends.
+type Request struct {
+	Command string `json:"command"`
+	// Lines is how many recent log lines CmdLogs replays; 0 means all
+	// that are kept.
+	Lines int `json:"lines,omitempty"`
+	// Follow keeps CmdLogs streaming new lines until the client hangs up.
+	Follow bool `json:"follow,omitempty"`
+}
+
+// Reply is one frame of the answer.
+type Reply struct {
+	// Status describes the instance; its shape is up to the application.
+	Status json.RawMessage `json:"status,omitempty"`
+	// Line is one log line, for CmdLogs.
+	Line  string `json:"line,omitempty"`
+	Error string `json:"error,omitempty"`
+	// Done marks the last reply to a request.
+	Done bool `json:"done,omitempty"`
+}
+
+// WriteFrame sends v as one frame.
+func WriteFrame(w io.Writer, v any) error {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if len(payload) > MaxFrame {
+		return fmt.Errorf("control frame of %d bytes exceeds the %d byte limit", len(payload), MaxFrame)
+	}
+	frame := make([]byte, 4+len(payload))
+	binary.BigEndian.PutUint32(frame, uint32(len(payload)))
+	copy(frame[4:], payload)
+	_, err = w.Write(frame)
+	return err
+}
+
+// ReadFrame reads one frame into v. It returns io.EOF when the peer closed
+// the connection between frames.
+func ReadFrame(r io.Reader, v any) error {
+	var header [4]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return err
+	}
+	size := binary.BigEndian.Uint32(header[:])
+	if size > MaxFrame {
+		return fmt.Errorf("control frame of %d bytes exceeds the %d byte limit", size, MaxFrame)
+	}
+	payload := make([]byte, size)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if errors.Is(err, io.EOF) {
+			err = io.ErrUnexpectedEOF
+		}
+		return err
+	}
+	return json.Unmarshal(payload, v)
+}
+
+// Call sends req to the instance listening at path and hands each reply
+// to fn until the last one. A reply carrying Error ends the call with that
+// error. timeout bounds connecting and, unless req.Follow is set, the
+// whole exchange; canceling ctx ends a follow without an error.
+func Call(ctx context.Context, path string, req Request, timeout time.Duration, fn func(Reply) error) error {
+	conn, err := Dial(path, timeout)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if !req.Follow {
+		_ = conn.SetDeadline(time.Now().Add(timeout))
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	if err := WriteFrame(conn, req); err != nil {
+		return err
+	}
+	for {
+		var reply Reply
+		if err := ReadFrame(conn, &reply); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("read control reply: %w", err)
+		}
+		if reply.Error != "" {
+			return errors.New(reply.Error)
+		}
+		if err := fn(reply); err != nil {
+			return err
+		}
+		if reply.Done {
+			return nil
+		}
+	}
+}
+
+// Serve accepts connections on ln until it is closed, reading one Request
+// from each and passing it to handle along with a function that sends a
+// reply. A handler error is sent as the final reply.
+func Serve(ln net.Listener, handle func(conn net.Conn, req Request, send func(Reply) error) error) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go func() {
+			defer conn.Close()
+			_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+			var req Request
+			if err := ReadFrame(conn, &req); err != nil {
+				return
+			}
+			_ = conn.SetReadDeadline(time.Time{})
+			send := func(reply Reply) error { return WriteFrame(conn, reply) }
+			if err := handle(conn, req, send); err != nil {
+				_ = send(Reply{Error: err.Error(), Done: true})
+			}
+		}()
+	}
+}
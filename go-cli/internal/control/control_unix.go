@@ -0,0 +1,46 @@
+//go:build !windows
+
+package control
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"time"
+)
+
+// Listen opens the control socket at path, accessible to its owner only.
+// A socket left behind by an instance that died is replaced; one that
+// still answers is an error.
+func Listen(path string) (net.Listener, error) {
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("%s is in use by another instance", path)
+	}
+	_ = os.Remove(path)
+	// The socket is created with the umask applied; clearing group and
+	// other bits first means no other user can connect before the chmod.
+	// The umask is process-wide, but only for the duration of the bind.
+	old := syscall.Umask(0o177)
+	ln, err := net.Listen("unix", path)
+	syscall.Umask(old)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		ln.Close()
+		return nil, err
+	}
+	return ln, nil
+}
+
+// Dial connects to the control socket at path.
+func Dial(path string, timeout time.Duration) (net.Conn, error) {
+	return net.DialTimeout("unix", path, timeout)
+}
+
+// Address is where a client finds the instance whose socket is path.
+func Address(path string) string {
+	return path
+}
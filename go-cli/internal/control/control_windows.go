@@ -0,0 +1,44 @@
+//go:build windows
+
+package control
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Microsoft/go-winio"
+)
+
+// ownerOnly lets only the user who created the pipe connect.
+const ownerOnly = "D:P(A;;GA;;;OW)"
+
+// pipeName maps a control socket path to a named pipe, as Windows has no
+// pipes in the file system. The hash keeps instances with different state
+// directories apart.
+func pipeName(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	sum := sha256.Sum256([]byte(strings.ToLower(abs)))
+	return `\\.\pipe\` + strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)) + "-" + hex.EncodeToString(sum[:8])
+}
+
+// Listen opens the named pipe for path, accessible to its owner only.
+func Listen(path string) (net.Listener, error) {
+	return winio.ListenPipe(pipeName(path), &winio.PipeConfig{SecurityDescriptor: ownerOnly})
+}
+
+// Dial connects to the named pipe for path.
+func Dial(path string, timeout time.Duration) (net.Conn, error) {
+	return winio.DialPipe(pipeName(path), &timeout)
+}
+
+// Address is where a client finds the instance whose socket is path.
+func Address(path string) string {
+	return pipeName(path)
+}
@@ -0,0 +1,105 @@
+// Package desktop shows native desktop notifications: notify-send on Linux
+// and the BSDs, osascript on macOS, and a toast through PowerShell on
+// Windows. The notifier is an external program, so a machine without one
+// (a server, a container) gets an error rather than a notification.
+package desktop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/execx"
+)
+
+// timeout bounds the notifier; it should return at once.
+const timeout = 10 * time.Second
+
+// Notification is one message.
+type Notification struct {
+	// App names the sender where the platform lets it be chosen.
+	App   string
+	Title string
+	Body  string
+	// Urgent asks the desktop to keep the notification until it is
+	// dismissed, where supported.
+	Urgent bool
+}
+
+// ErrUnsupported reports a platform or machine without a notifier.
+var ErrUnsupported = errors.New("no desktop notifier available")
+
+// Notify shows n.
+func Notify(ctx context.Context, n Notification) error {
+	c, err := command(n)
+	if err != nil {
+		return err
+	}
+	c.Timeout = timeout
+	c.Capture = execx.CaptureCombined
+	c.OnLine = func(string) {}
+	if result, err := execx.Run(ctx, c); err != nil {
+		if len(result.Stdout) > 0 {
+			return fmt.Errorf("%w: %s", err, result.Stdout)
+		}
+		return err
+	}
+	return nil
+}
+
+// command builds the notifier invocation. Title and body are passed as
+// arguments or environment variables, never spliced into a script.
+func command(n Notification) (execx.Command, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return execx.Command{
+			Path: "osascript",
+			Args: []string{
+				"-e", "on run argv",
+				"-e", "display notification (item 2 of argv) with title (item 1 of argv)",
+				"-e", "end run",
+				n.Title, n.Body,
+			},
+		}, nil
+	case "windows":
+		return execx.Command{
+			Path: "powershell",
+			Args: []string{"-NoProfile", "-NonInteractive", "-Command", toastScript},
+			Env:  []string{"DESKTOP_TITLE=" + n.Title, "DESKTOP_BODY=" + n.Body},
+		}, nil
+	case "linux", "freebsd", "openbsd", "netbsd", "dragonfly":
+		path, err := exec.LookPath("notify-send")
+		if err != nil {
+			return execx.Command{}, fmt.Errorf("%w: notify-send not found (install libnotify)", ErrUnsupported)
+		}
+		if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" && os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+			return execx.Command{}, fmt.Errorf("%w: no desktop session", ErrUnsupported)
+		}
+		urgency := "normal"
+		if n.Urgent {
+			urgency = "critical"
+		}
+		args := []string{"--urgency=" + urgency}
+		if n.App != "" {
+			args = append(args, "--app-name="+n.App)
+		}
+		return execx.Command{Path: path, Args: append(args, "--", n.Title, n.Body)}, nil
+	}
+	return execx.Command{}, fmt.Errorf("%w on %s", ErrUnsupported, runtime.GOOS)
+}
+
+// toastScript shows a toast through the WinRT notification API, which
+// Windows PowerShell can load without extra modules. Windows only shows
+// toasts from registered app IDs, so it borrows PowerShell's.
+const toastScript = `$ErrorActionPreference = 'Stop'
+[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] > $null
+$xml = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$text = $xml.GetElementsByTagName('text')
+$text.Item(0).AppendChild($xml.CreateTextNode($env:DESKTOP_TITLE)) > $null
+$text.Item(1).AppendChild($xml.CreateTextNode($env:DESKTOP_BODY)) > $null
+$toast = [Windows.UI.Notifications.ToastNotification]::new($xml)
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('{1AC14E77-02E7-4E5D-B744-2EB1AE5198B7}\WindowsPowerShell\v1.0\powershell.exe').Show($toast)`
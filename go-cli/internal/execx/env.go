@@ -0,0 +1,41 @@
+package execx
+
+import (
+	"runtime"
+	"strings"
+)
+
+// Scrub returns the entries of env whose names match one of the allow
+// patterns. A pattern ending in * matches by prefix (e.g. "LC_*").
+func Scrub(env []string, allow []string) []string {
+	kept := make([]string, 0, len(allow))
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		if Allowed(name, allow) {
+			kept = append(kept, kv)
+		}
+	}
+	return kept
+}
+
+// Allowed reports whether the variable name matches one of the patterns.
+// Names compare case-insensitively on Windows, where "Path" and "PATH" are
+// the same variable.
+func Allowed(name string, patterns []string) bool {
+	if runtime.GOOS == "windows" {
+		name = strings.ToUpper(name)
+	}
+	for _, p := range patterns {
+		if runtime.GOOS == "windows" {
+			p = strings.ToUpper(p)
+		}
+		if prefix, ok := strings.CutSuffix(p, "*"); ok {
+			if strings.HasPrefix(name, prefix) {
+				return true
+			}
+		} else if name == p {
+			return true
+		}
+	}
+	return false
+}
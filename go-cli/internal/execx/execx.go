@@ -0,0 +1,193 @@
+// Package execx runs subprocesses the same way everywhere: through the
+// platform shell or directly, with an optional timeout, output streamed line
+// by line and/or captured, a controlled environment, and dry-run support.
+// Tasks and hooks shell out through it instead of using os/exec directly.
+package execx
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/humanize"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/tracing"
+)
+
+// waitDelay bounds how long Run waits for output pipes to close after the
+// process exits or is killed; grandchildren holding them open must not
+// hang the caller.
+const waitDelay = 2 * time.Second
+
+// Capture selects which output Run keeps in the Result.
+type Capture int
+
+const (
+	// CaptureNone keeps no output.
+	CaptureNone Capture = iota
+	// CaptureCombined keeps stdout and stderr interleaved in Result.Stdout.
+	CaptureCombined
+	// CaptureSplit keeps stdout and stderr separately.
+	CaptureSplit
+)
+
+// Logger receives debug records about each command; app.Logger satisfies it.
+type Logger interface {
+	Debug(msg string, args ...any)
+}
+
+// Command describes a process to run.
+type Command struct {
+	// Shell is a command line run through the platform shell (sh -c, or
+	// cmd /C on Windows). When empty, Path and Args are executed directly.
+	Shell string
+	Path  string
+	Args  []string
+	Dir   string
+	// BaseEnv is the environment the child starts from; nil means the
+	// current process environment. Use Scrub to pass only selected
+	// variables.
+	BaseEnv []string
+	// Env holds KEY=VALUE pairs added on top of BaseEnv.
+	Env []string
+	// Stdin is fed to the process; nil means no input.
+	Stdin io.Reader
+	// Timeout kills the process after this long; 0 means no limit beyond
+	// the context.
+	Timeout time.Duration
+	Capture Capture
+	// OnLine receives every line of output as it arrives. When nil, lines
+	// are streamed to Logger at debug level instead.
+	OnLine func(line string)
+	Logger Logger
+	// DryRun logs the command instead of running it.
+	DryRun bool
+}
+
+// String returns the command as the user would type it.
+func (c Command) String() string {
+	if c.Shell != "" {
+		return c.Shell
+	}
+	return strings.Join(append([]string{c.Path}, c.Args...), " ")
+}
+
+// Result describes a finished command.
+type Result struct {
+	// Stdout holds captured standard output, or both streams with
+	// CaptureCombined.
+	Stdout   []byte
+	Stderr   []byte
+	ExitCode int
+	Duration time.Duration
+}
+
+// TimeoutError reports a command killed because it exceeded its Timeout.
+type TimeoutError struct {
+	Command string
+	After   time.Duration
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("command %q timed out after %s", e.Command, humanize.Duration(e.After))
+}
+
+// Shell returns a Command running line through the platform shell.
+func Shell(line string) Command {
+	return Command{Shell: line}
+}
+
+// Run executes c and waits for it. A non-zero exit is returned as an error
+// wrapping *exec.ExitError, with Result.ExitCode set; a timeout returns a
+// *TimeoutError.
+func Run(ctx context.Context, c Command) (Result, error) {
+	logger := c.Logger
+	if logger == nil {
+		logger = nopLogger{}
+	}
+	name := c.String()
+	if c.DryRun {
+		logger.Debug("dry-run: would run $ %s", name)
+		return Result{}, nil
+	}
+
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	cmd := c.build(ctx)
+	var stdout, stderr bytes.Buffer
+	onLine := c.OnLine
+	if onLine == nil {
+		onLine = func(line string) { logger.Debug("%s | %s", name, line) }
+	}
+	lines := NewLineWriter(onLine)
+	switch c.Capture {
+	case CaptureCombined:
+		combined := &lockedWriter{w: &stdout}
+		cmd.Stdout = io.MultiWriter(lines, combined)
+		cmd.Stderr = io.MultiWriter(lines, combined)
+	case CaptureSplit:
+		cmd.Stdout = io.MultiWriter(lines, &stdout)
+		cmd.Stderr = io.MultiWriter(lines, &stderr)
+	default:
+		cmd.Stdout, cmd.Stderr = lines, lines
+	}
+
+	logger.Debug("$ %s", name)
+	started := time.Now()
+	err := cmd.Run()
+	lines.Flush()
+	result := Result{
+		Stdout:   stdout.Bytes(),
+		Stderr:   stderr.Bytes(),
+		ExitCode: cmd.ProcessState.ExitCode(),
+		Duration: time.Since(started),
+	}
+	logger.Debug("exit %d after %s: %s", result.ExitCode, humanize.Duration(result.Duration), name)
+
+	if err != nil {
+		if c.Timeout > 0 && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return result, &TimeoutError{Command: name, After: c.Timeout}
+		}
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+		return result, fmt.Errorf("command %q: %w", name, err)
+	}
+	return result, nil
+}
+
+func (c Command) build(ctx context.Context) *exec.Cmd {
+	var cmd *exec.Cmd
+	switch {
+	case c.Shell != "" && runtime.GOOS == "windows":
+		cmd = exec.CommandContext(ctx, "cmd", "/C", c.Shell)
+	case c.Shell != "":
+		cmd = exec.CommandContext(ctx, "sh", "-c", c.Shell)
+	default:
+		cmd = exec.CommandContext(ctx, c.Path, c.Args...)
+	}
+	cmd.Dir = c.Dir
+	cmd.Stdin = c.Stdin
+	base := c.BaseEnv
+	if base == nil {
+		base = os.Environ()
+	}
+	// The trace context goes before c.Env so a caller can still override it.
+	cmd.Env = append(append(append([]string(nil), base...), tracing.Environ(ctx)...), c.Env...)
+	cmd.WaitDelay = waitDelay
+	return cmd
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...any) {}
@@ -0,0 +1,58 @@
+package execx
+
+import (
+	"bytes"
+	"io"
+	"sync"
+)
+
+// LineWriter passes each complete line written to it to a callback, e.g. to
+// log child process output. It is safe to share between a command's stdout
+// and stderr, which os/exec may write concurrently.
+type LineWriter struct {
+	mu  sync.Mutex
+	buf []byte
+	log func(string)
+}
+
+// NewLineWriter returns a LineWriter calling log for every line.
+func NewLineWriter(log func(line string)) *LineWriter {
+	return &LineWriter{log: log}
+}
+
+func (l *LineWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.buf = append(l.buf, p...)
+	for {
+		i := bytes.IndexByte(l.buf, '\n')
+		if i < 0 {
+			break
+		}
+		l.log(string(bytes.TrimRight(l.buf[:i], "\r")))
+		l.buf = l.buf[i+1:]
+	}
+	return len(p), nil
+}
+
+// Flush passes on a trailing line that lacks a newline.
+func (l *LineWriter) Flush() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.buf) > 0 {
+		l.log(string(l.buf))
+		l.buf = nil
+	}
+}
+
+// lockedWriter serializes writes from stdout and stderr into one buffer.
+type lockedWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (l *lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.w.Write(p)
+}
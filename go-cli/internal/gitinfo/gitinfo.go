@@ -0,0 +1,122 @@
+// Package gitinfo describes the git repository a directory belongs to:
+// its root, branch, commit, and whether tracked files have uncommitted
+// changes. Finding the repository needs no git; describing it runs the git
+// binary, so it also works for worktrees, submodules, and packed refs.
+package gitinfo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/execx"
+)
+
+// timeout bounds each git invocation; status can be slow in huge
+// repositories, which should not hold up a run for long.
+const timeout = 10 * time.Second
+
+// Info describes a repository checkout.
+type Info struct {
+	// Root is the top-level directory of the working tree.
+	Root string `json:"root" yaml:"root"`
+	// Branch is empty when HEAD is detached.
+	Branch string `json:"branch,omitempty" yaml:"branch,omitempty"`
+	// Commit is the full hash of HEAD; empty before the first commit.
+	Commit string `json:"commit,omitempty" yaml:"commit,omitempty"`
+	// Dirty reports uncommitted changes to tracked files.
+	Dirty bool `json:"dirty" yaml:"dirty"`
+}
+
+// Short returns e.g. "main@3f9a1c2 (dirty)" for display.
+func (i Info) Short() string {
+	s := i.Branch
+	if s == "" {
+		s = "detached"
+	}
+	if i.Commit != "" {
+		s += "@" + i.Commit[:min(7, len(i.Commit))]
+	}
+	if i.Dirty {
+		s += " (dirty)"
+	}
+	return s
+}
+
+// FindRoot returns the nearest directory at or above dir that holds a
+// .git entry (a directory, or a file in worktrees and submodules), or ""
+// if there is none.
+func FindRoot(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+	for {
+		if _, err := os.Lstat(filepath.Join(dir, ".git")); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// ErrNoGit reports a repository found without a git binary to describe it.
+var ErrNoGit = errors.New("git not found in PATH")
+
+// Detect describes the repository containing dir. It returns nil without
+// an error when dir is not inside one.
+func Detect(ctx context.Context, dir string) (*Info, error) {
+	root := FindRoot(dir)
+	if root == "" {
+		return nil, nil
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return nil, ErrNoGit
+	}
+
+	info := &Info{Root: root}
+	out, err := git(ctx, root, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, err
+	}
+	info.Root = filepath.FromSlash(out)
+	// Fails on a branch without commits; Branch then still resolves.
+	if out, err := git(ctx, root, "rev-parse", "--verify", "-q", "HEAD"); err == nil {
+		info.Commit = out
+	}
+	if out, err := git(ctx, root, "symbolic-ref", "-q", "--short", "HEAD"); err == nil {
+		info.Branch = out
+	}
+	out, err = git(ctx, root, "status", "--porcelain", "--untracked-files=no")
+	if err != nil {
+		return nil, err
+	}
+	info.Dirty = out != ""
+	return info, nil
+}
+
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	result, err := execx.Run(ctx, execx.Command{
+		Path:    "git",
+		Args:    args,
+		Dir:     dir,
+		Timeout: timeout,
+		Capture: execx.CaptureSplit,
+		OnLine:  func(string) {},
+	})
+	if err != nil {
+		if msg := strings.TrimSpace(string(result.Stderr)); msg != "" {
+			return "", fmt.Errorf("git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(result.Stdout)), nil
+}
@@ -0,0 +1,252 @@
+// Package httpx builds the HTTP client every outbound request of the CLI
+// goes through: remote config, self-update, telemetry, notifications,
+// and user tasks. It applies one timeout, retries 5xx and 429 responses
+// and transport errors with exponential backoff, routes through the
+// configured or environment proxy, trusts an extra CA bundle, and sends
+// a User-Agent naming the build. ProxyFunc and TLSConfig expose the proxy
+// and TLS settings for clients built elsewhere, such as trace exporters.
+package httpx
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/clock"
+)
+
+// Options configure New.
+type Options struct {
+	// Timeout bounds a whole request, retries included; 0 means none.
+	Timeout time.Duration
+	// MaxRetries is how often a failed request is repeated; 0 disables
+	// retries.
+	MaxRetries int
+	// InitialBackoff is the wait before the first retry; later waits
+	// double up to MaxBackoff. A Retry-After header takes precedence.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	// Proxy is the proxy URL for every request. Empty uses HTTPS_PROXY and
+	// HTTP_PROXY from the environment.
+	Proxy string
+	// NoProxy lists hosts reached without the proxy, in NO_PROXY syntax:
+	// host names, domain suffixes such as .corp.example, IP addresses,
+	// and CIDR ranges, each optionally with a port. They are added to
+	// NO_PROXY from the environment.
+	NoProxy []string
+	// CABundle is a PEM bundle trusted in addition to the system roots,
+	// e.g. the CA of a TLS-intercepting proxy.
+	CABundle string
+	// InsecureSkipVerify accepts any server certificate. It defeats TLS
+	// and is meant for diagnosing certificate problems only.
+	InsecureSkipVerify bool
+	// UserAgent is sent unless a request sets its own.
+	UserAgent string
+	// Clock drives the backoff; nil uses the wall clock.
+	Clock clock.Clock
+}
+
+// Default backoff when Options leave it unset.
+const (
+	DefaultInitialBackoff = 500 * time.Millisecond
+	DefaultMaxBackoff     = 10 * time.Second
+)
+
+// New returns a client configured by opts.
+func New(opts Options) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	proxy, err := ProxyFunc(opts)
+	if err != nil {
+		return nil, err
+	}
+	transport.Proxy = proxy
+	if transport.TLSClientConfig, err = TLSConfig(opts); err != nil {
+		return nil, err
+	}
+
+	if opts.InitialBackoff <= 0 {
+		opts.InitialBackoff = DefaultInitialBackoff
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = DefaultMaxBackoff
+	}
+	return &http.Client{
+		Timeout:   opts.Timeout,
+		Transport: &retryTransport{next: transport, opts: opts},
+	}, nil
+}
+
+// ProxyFunc returns the proxy selection of opts: Proxy or the environment's
+// proxy for every request, except for hosts matched by NoProxy or
+// NO_PROXY.
+func ProxyFunc(opts Options) (func(*http.Request) (*url.URL, error), error) {
+	cfg := httpproxy.FromEnvironment()
+	if opts.Proxy != "" {
+		proxy, err := url.Parse(opts.Proxy)
+		if err != nil || proxy.Scheme == "" || proxy.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", opts.Proxy)
+		}
+		cfg.HTTPProxy, cfg.HTTPSProxy = opts.Proxy, opts.Proxy
+	}
+	if len(opts.NoProxy) > 0 {
+		noProxy := opts.NoProxy
+		if cfg.NoProxy != "" {
+			noProxy = append([]string{cfg.NoProxy}, noProxy...)
+		}
+		cfg.NoProxy = strings.Join(noProxy, ",")
+	}
+	proxy := cfg.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxy(req.URL)
+	}, nil
+}
+
+// TLSConfig returns the TLS settings of opts, or nil when Go's defaults
+// apply.
+func TLSConfig(opts Options) (*tls.Config, error) {
+	if opts.CABundle == "" && !opts.InsecureSkipVerify {
+		return nil, nil
+	}
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: opts.InsecureSkipVerify}
+	if opts.CABundle != "" {
+		pool, err := certPool(opts.CABundle)
+		if err != nil {
+			return nil, err
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// certPool returns the system roots plus the certificates in path.
+func certPool(path string) (*x509.CertPool, error) {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read CA bundle: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("CA bundle %s: no PEM certificates found", path)
+	}
+	return pool, nil
+}
+
+// retryTransport sets the User-Agent and repeats requests that failed in
+// a way worth retrying.
+type retryTransport struct {
+	next http.RoundTripper
+	opts Options
+}
+
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.opts.UserAgent != "" && req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", t.opts.UserAgent)
+	}
+	for attempt := 0; ; attempt++ {
+		resp, err := t.next.RoundTrip(req)
+		if attempt >= t.opts.MaxRetries || !retryable(req, resp, err) {
+			return resp, err
+		}
+		wait := t.backoff(attempt, resp)
+		if resp != nil {
+			// Drain so the connection can be reused.
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			resp.Body.Close()
+		}
+		if err := clock.Sleep(req.Context(), t.opts.Clock, wait); err != nil {
+			return nil, err
+		}
+		if req, err = rewind(req); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// retryable reports whether a request that ended with resp or err may be
+// sent again. Idempotent requests are retried on transport errors and on
+// 429 and 5xx responses; POST and PATCH only on 429 and 503, which mean
+// the server did not act on them. A body must be replayable.
+func retryable(req *http.Request, resp *http.Response, err error) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+	if err != nil {
+		return idempotent(req.Method) && !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	switch code := resp.StatusCode; {
+	case code == http.StatusTooManyRequests, code == http.StatusServiceUnavailable:
+		return true
+	case code >= 500 && code != http.StatusNotImplemented:
+		return idempotent(req.Method)
+	}
+	return false
+}
+
+func idempotent(method string) bool {
+	switch method {
+	case "", http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// backoff is the wait before retry number attempt+1: the server's
+// Retry-After when it sent one, else a doubling delay with jitter.
+func (t *retryTransport) backoff(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if wait, ok := retryAfter(resp.Header.Get("Retry-After"), clock.Or(t.opts.Clock).Now()); ok {
+			return min(wait, t.opts.MaxBackoff)
+		}
+	}
+	delay := float64(t.opts.InitialBackoff) * math.Pow(2, float64(attempt))
+	delay = min(delay, float64(t.opts.MaxBackoff))
+	delay += delay * 0.2 * (2*rand.Float64() - 1)
+	return time.Duration(delay)
+}
+
+// retryAfter parses a Retry-After value, given in seconds or as an HTTP
+// date.
+func retryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(at.Sub(now), 0), true
+	}
+	return 0, false
+}
+
+// rewind returns req ready to be sent again, with a fresh body.
+func rewind(req *http.Request) (*http.Request, error) {
+	if req.GetBody == nil {
+		return req, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("replay request body: %w", err)
+	}
+	req = req.Clone(req.Context())
+	req.Body = body
+	return req, nil
+}
@@ -0,0 +1,118 @@
+// Package humanize formats durations, sizes, counts, and timestamps for human
+// readers. Machine-readable output should keep using raw values.
+package humanize
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Duration renders d compactly, keeping at most two units:
+// 350ms, 4.2s, 1m32s, 2h5m, 3d4h.
+func Duration(d time.Duration) string {
+	if d < 0 {
+		return "-" + Duration(-d)
+	}
+
+	switch {
+	case d < time.Millisecond:
+		return d.String()
+	case d < time.Second:
+		return strconv.FormatInt(d.Milliseconds(), 10) + "ms"
+	case d < time.Minute:
+		return trimZero(strconv.FormatFloat(d.Seconds(), 'f', 1, 64)) + "s"
+	case d < time.Hour:
+		return twoUnits(int64(d/time.Minute), "m", int64(d%time.Minute/time.Second), "s")
+	case d < 24*time.Hour:
+		return twoUnits(int64(d/time.Hour), "h", int64(d%time.Hour/time.Minute), "m")
+	default:
+		return twoUnits(int64(d/(24*time.Hour)), "d", int64(d%(24*time.Hour)/time.Hour), "h")
+	}
+}
+
+// Bytes renders n using binary (IEC) units: 512 B, 4.2 KiB, 1.0 GiB.
+func Bytes(n int64) string {
+	const unit = 1024
+	if n < 0 {
+		return "-" + Bytes(-n)
+	}
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	exp := int(math.Log(float64(n)) / math.Log(unit))
+	if exp > 6 {
+		exp = 6
+	}
+	value := float64(n) / math.Pow(unit, float64(exp))
+	return fmt.Sprintf("%.1f %ciB", value, "KMGTPE"[exp-1])
+}
+
+// Count renders n with thousands separators: 1,234,567.
+func Count(n int64) string {
+	if n < 0 {
+		return "-" + Count(-n)
+	}
+	digits := strconv.FormatInt(n, 10)
+	var b strings.Builder
+	for i, r := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// Plural renders "1 task" / "3 tasks".
+func Plural(n int, singular, plural string) string {
+	if n == 1 {
+		return "1 " + singular
+	}
+	return strconv.Itoa(n) + " " + plural
+}
+
+// RelTime describes t relative to now: "just now", "3 minutes ago",
+// "in 2 hours". Beyond a year it falls back to a calendar date.
+func RelTime(t, now time.Time) string {
+	d := now.Sub(t)
+	suffix := " ago"
+	prefix := ""
+	if d < 0 {
+		d = -d
+		suffix = ""
+		prefix = "in "
+	}
+
+	var phrase string
+	switch {
+	case d < 10*time.Second:
+		return "just now"
+	case d < time.Minute:
+		phrase = Plural(int(d/time.Second), "second", "seconds")
+	case d < time.Hour:
+		phrase = Plural(int(d/time.Minute), "minute", "minutes")
+	case d < 24*time.Hour:
+		phrase = Plural(int(d/time.Hour), "hour", "hours")
+	case d < 30*24*time.Hour:
+		phrase = Plural(int(d/(24*time.Hour)), "day", "days")
+	case d < 365*24*time.Hour:
+		phrase = Plural(int(d/(30*24*time.Hour)), "month", "months")
+	default:
+		return t.Format("2006-01-02")
+	}
+	return prefix + phrase + suffix
+}
+
+func twoUnits(major int64, majorUnit string, minor int64, minorUnit string) string {
+	if minor == 0 {
+		return strconv.FormatInt(major, 10) + majorUnit
+	}
+	return strconv.FormatInt(major, 10) + majorUnit + strconv.FormatInt(minor, 10) + minorUnit
+}
+
+func trimZero(s string) string {
+	return strings.TrimSuffix(s, ".0")
+}
@@ -0,0 +1,202 @@
+// Package kvcache is a key/value store with expiring entries in a single
+// bbolt file. Keys live in namespaces (bbolt buckets). The file is opened
+// for each operation rather than held open, because bbolt locks it: a
+// long-running daemon would otherwise block every other process.
+package kvcache
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+// FileName is the database file inside the cache directory.
+const FileName = "cache.db"
+
+// lockTimeout bounds how long an operation waits for another process that
+// has the file open for writing.
+const lockTimeout = 5 * time.Second
+
+// headerLen is the size of the timestamps stored in front of each value.
+const headerLen = 16
+
+// DB is the cache file at a path. It is safe for concurrent use, also by
+// several processes.
+type DB struct {
+	path string
+}
+
+// New returns the cache stored at path; the file is created by the first
+// Set.
+func New(path string) *DB {
+	return &DB{path: path}
+}
+
+// Entry is a stored value with its timestamps.
+type Entry struct {
+	Namespace string
+	Key       string
+	Value     []byte
+	Created   time.Time
+	// Expires is zero for entries without a TTL.
+	Expires time.Time
+}
+
+// Expired reports whether the entry has expired at now.
+func (e Entry) Expired(now time.Time) bool {
+	return !e.Expires.IsZero() && !now.Before(e.Expires)
+}
+
+func (d *DB) open(readOnly bool) (*bolt.DB, error) {
+	if !readOnly {
+		if err := os.MkdirAll(filepath.Dir(d.path), 0o755); err != nil {
+			return nil, err
+		}
+	}
+	db, err := bolt.Open(d.path, 0o644, &bolt.Options{Timeout: lockTimeout, ReadOnly: readOnly})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("open %s: locked by another process", d.path)
+	}
+	return db, err
+}
+
+// Get returns the entry for key in namespace, or nil if there is none or
+// it is corrupt. Expired entries are returned too; the caller decides.
+func (d *DB) Get(namespace, key string) (*Entry, error) {
+	db, err := d.open(true)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var entry *Entry
+	err = db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(namespace))
+		if b == nil {
+			return nil
+		}
+		if e, err := decode(namespace, key, b.Get([]byte(key))); err == nil {
+			entry = &e
+		}
+		return nil
+	})
+	return entry, err
+}
+
+// Set stores e, replacing any entry under the same key.
+func (d *DB) Set(e Entry) error {
+	db, err := d.open(false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.Update(func(tx *bolt.Tx) error {
+		b, err := tx.CreateBucketIfNotExists([]byte(e.Namespace))
+		if err != nil {
+			return err
+		}
+		return b.Put([]byte(e.Key), encode(e))
+	})
+}
+
+// Delete removes the entry for key in namespace; a missing one is not an
+// error.
+func (d *DB) Delete(namespace, key string) error {
+	if _, err := os.Stat(d.path); errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	db, err := d.open(false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.Update(func(tx *bolt.Tx) error {
+		if b := tx.Bucket([]byte(namespace)); b != nil {
+			return b.Delete([]byte(key))
+		}
+		return nil
+	})
+}
+
+// Purge removes the entries, in any namespace, for which remove returns
+// true, and returns how many it removed. Under dryRun it only counts them.
+func (d *DB) Purge(remove func(Entry) bool, dryRun bool) (int, error) {
+	if _, err := os.Stat(d.path); errors.Is(err, fs.ErrNotExist) {
+		return 0, nil
+	}
+	db, err := d.open(dryRun)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	removed := 0
+	purge := func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+			var doomed [][]byte
+			err := b.ForEach(func(k, v []byte) error {
+				e, err := decode(string(name), string(k), v)
+				if err != nil || remove(e) {
+					doomed = append(doomed, append([]byte(nil), k...))
+				}
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+			removed += len(doomed)
+			if dryRun {
+				return nil
+			}
+			for _, k := range doomed {
+				if err := b.Delete(k); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+	if dryRun {
+		err = db.View(purge)
+	} else {
+		err = db.Update(purge)
+	}
+	return removed, err
+}
+
+// encode lays out an entry as created and expires (Unix nanoseconds, big
+// endian, zero for none) followed by the value.
+func encode(e Entry) []byte {
+	buf := make([]byte, headerLen, headerLen+len(e.Value))
+	binary.BigEndian.PutUint64(buf[0:8], uint64(e.Created.UnixNano()))
+	if !e.Expires.IsZero() {
+		binary.BigEndian.PutUint64(buf[8:16], uint64(e.Expires.UnixNano()))
+	}
+	return append(buf, e.Value...)
+}
+
+func decode(namespace, key string, raw []byte) (Entry, error) {
+	if len(raw) < headerLen {
+		return Entry{}, fmt.Errorf("corrupt cache entry %s/%s", namespace, key)
+	}
+	e := Entry{
+		Namespace: namespace,
+		Key:       key,
+		Created:   time.Unix(0, int64(binary.BigEndian.Uint64(raw[0:8]))).UTC(),
+		// bbolt's memory is only valid during the transaction.
+		Value: append([]byte(nil), raw[headerLen:]...),
+	}
+	if expires := binary.BigEndian.Uint64(raw[8:16]); expires != 0 {
+		e.Expires = time.Unix(0, int64(expires)).UTC()
+	}
+	return e, nil
+}
@@ -0,0 +1,125 @@
+//go:build ignore
+
+// gen writes licenses.json: the license file of every module the binary
+// links on the release platforms, read from the module cache, plus the Go
+// standard library's.
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// platforms are the release targets; dependencies differ between them.
+var platforms = []string{"linux/amd64", "darwin/arm64", "windows/amd64"}
+
+type module struct {
+	Path    string `json:"path"`
+	Version string `json:"version"`
+	License string `json:"license"`
+	File    string `json:"file"`
+	Text    string `json:"text"`
+}
+
+var licenseFile = regexp.MustCompile(`(?i)^(licen[cs]e|copying)(\.(md|txt))?$`)
+
+// signatures identify common licenses by a distinctive phrase, checked in
+// order.
+var signatures = []struct{ id, phrase string }{
+	{"Apache-2.0", "Apache License"},
+	{"MPL-2.0", "Mozilla Public License"},
+	{"BSD-3-Clause", "Neither the name"},
+	{"BSD-2-Clause", "Redistributions in binary form"},
+	{"MIT", "Permission is hereby granted, free of charge"},
+	{"ISC", "Permission to use, copy, modify, and/or distribute"},
+}
+
+func main() {
+	found := map[string]module{}
+	for _, platform := range platforms {
+		goos, goarch, _ := strings.Cut(platform, "/")
+		cmd := exec.Command("go", "list", "-deps", "-f", "{{with .Module}}{{if not .Main}}{{.Path}}\t{{.Version}}\t{{.Dir}}{{end}}{{end}}", "../..")
+		cmd.Env = append(os.Environ(), "GOOS="+goos, "GOARCH="+goarch, "CGO_ENABLED=0")
+		cmd.Stderr = os.Stderr
+		out, err := cmd.Output()
+		if err != nil {
+			log.Fatalf("go list for %s: %v", platform, err)
+		}
+		for _, line := range strings.Split(string(out), "\n") {
+			fields := strings.Split(line, "\t")
+			if len(fields) != 3 || found[fields[0]].Path != "" {
+				continue
+			}
+			m, err := readModule(fields[0], fields[1], fields[2])
+			if err != nil {
+				log.Fatal(err)
+			}
+			found[m.Path] = m
+		}
+	}
+
+	std, err := stdlib()
+	if err != nil {
+		log.Fatal(err)
+	}
+	found[std.Path] = std
+
+	modules := make([]module, 0, len(found))
+	for _, m := range found {
+		modules = append(modules, m)
+	}
+	sort.Slice(modules, func(i, j int) bool { return modules[i].Path < modules[j].Path })
+	data, err := json.MarshalIndent(modules, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("licenses.json", append(data, '\n'), 0o644); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("wrote licenses.json (%d modules)\n", len(modules))
+}
+
+// stdlib describes the standard library of the toolchain running gen.
+func stdlib() (module, error) {
+	out, err := exec.Command("go", "env", "GOROOT", "GOVERSION").Output()
+	if err != nil {
+		return module{}, fmt.Errorf("go env: %w", err)
+	}
+	goroot, version, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return readModule("std", version, goroot)
+}
+
+func readModule(path, version, dir string) (module, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return module{}, fmt.Errorf("%s: %w (run go mod download)", path, err)
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !licenseFile.MatchString(entry.Name()) {
+			continue
+		}
+		text, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return module{}, err
+		}
+		return module{Path: path, Version: version, License: identify(text), File: entry.Name(), Text: string(text)}, nil
+	}
+	return module{}, fmt.Errorf("%s %s: no license file found in %s", path, version, dir)
+}
+
+func identify(text []byte) string {
+	for _, s := range signatures {
+		if bytes.Contains(text, []byte(s.phrase)) {
+			return s.id
+		}
+	}
+	return "unknown"
+}
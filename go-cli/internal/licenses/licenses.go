@@ -0,0 +1,36 @@
+// Package licenses embeds the license texts of the third-party modules
+// compiled into the binary, for the `licenses` command. The inventory is
+// generated from the module cache; run `go generate ./internal/licenses`
+// (or `just licenses`) after changing dependencies.
+package licenses
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+)
+
+//go:generate go run gen.go
+
+//go:embed licenses.json
+var inventory []byte
+
+// Module is one dependency and its license.
+type Module struct {
+	Path    string `json:"path" yaml:"path"`
+	Version string `json:"version" yaml:"version"`
+	// License is an SPDX identifier guessed from the text, or "unknown".
+	License string `json:"license" yaml:"license"`
+	// File is the name of the license file in the module.
+	File string `json:"file" yaml:"file"`
+	Text string `json:"text,omitempty" yaml:"text,omitempty"`
+}
+
+// All returns the embedded inventory, sorted by module path.
+func All() ([]Module, error) {
+	var modules []Module
+	if err := json.Unmarshal(inventory, &modules); err != nil {
+		return nil, fmt.Errorf("decode embedded license inventory: %w", err)
+	}
+	return modules, nil
+}
@@ -0,0 +1,118 @@
+// Package lock provides an advisory single-instance lock backed by a file.
+// The operating system releases the lock when the holding process exits, so
+// a crash never leaves a stale lock behind.
+package lock
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// pollInterval is how often Acquire retries while waiting for the lock.
+const pollInterval = 100 * time.Millisecond
+
+// errWouldBlock is returned by the platform tryLock when another process
+// holds the lock.
+var errWouldBlock = errors.New("lock is held")
+
+// HeldError reports that another process holds the lock.
+type HeldError struct {
+	Path string
+	// PID of the holder as recorded in the lock file; 0 if unknown.
+	PID int
+}
+
+func (e *HeldError) Error() string {
+	if e.PID > 0 {
+		return fmt.Sprintf("another instance (pid %d) is running (lock file %s)", e.PID, e.Path)
+	}
+	return fmt.Sprintf("another instance is running (lock file %s)", e.Path)
+}
+
+// Lock is a held lock file.
+type Lock struct {
+	file *os.File
+}
+
+// Acquire takes the lock file at path and records the current PID in it.
+// When the lock is held elsewhere it returns a *HeldError, or with wait keeps
+// retrying until the lock is free or ctx is done.
+func Acquire(ctx context.Context, path string, wait bool) (*Lock, error) {
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("open lock file: %w", err)
+	}
+
+	for {
+		err = tryLock(f)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errWouldBlock) {
+			f.Close()
+			return nil, fmt.Errorf("lock %s: %w", path, err)
+		}
+		if !wait {
+			f.Close()
+			return nil, &HeldError{Path: path, PID: readPID(path)}
+		}
+		select {
+		case <-ctx.Done():
+			f.Close()
+			return nil, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+
+	if err := writePID(f); err != nil {
+		unlock(f)
+		f.Close()
+		return nil, fmt.Errorf("write lock file: %w", err)
+	}
+	return &Lock{file: f}, nil
+}
+
+// Release clears the recorded PID and unlocks. It is safe to call on a nil
+// lock and more than once.
+func (l *Lock) Release() error {
+	if l == nil || l.file == nil {
+		return nil
+	}
+	f := l.file
+	l.file = nil
+	// Keep the file itself: removing it would let a waiter lock an inode
+	// that a newcomer can no longer see.
+	_ = f.Truncate(0)
+	err := unlock(f)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+func writePID(f *os.File) error {
+	if err := f.Truncate(0); err != nil {
+		return err
+	}
+	_, err := f.WriteAt([]byte(strconv.Itoa(os.Getpid())+"\n"), 0)
+	return err
+}
+
+// readPID reads the holder's PID. The lock is advisory, so the file stays
+// readable while locked.
+func readPID(path string) int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
@@ -0,0 +1,384 @@
+// Package progress renders spinners and multi-task progress bars on a
+// terminal. Every type degrades to a no-op when disabled, so callers never
+// need to check whether progress output is appropriate. In plain mode
+// nothing is redrawn: progress is reported as ordinary lines a screen
+// reader can follow.
+//
+// All methods are safe for concurrent use: runner workers report into a
+// Tracker while a single render goroutine redraws the display. Route other
+// terminal output through Tracker.Writer while a tracker is running.
+package progress
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// DefaultInterval is the redraw period.
+const DefaultInterval = 100 * time.Millisecond
+
+// PlainInterval is how often plain mode reports progress, if it changed.
+const PlainInterval = 5 * time.Second
+
+const barWidth = 20
+
+// Style holds the glyphs used for drawing.
+type Style struct {
+	Frames    []string
+	BarFilled string
+	BarEmpty  string
+}
+
+// maxTaskName caps task names in the live status region so each line stays
+// on one terminal row.
+const maxTaskName = 40
+
+// Tracker shows a spinner, a completion bar, and the names of in-flight tasks
+// on a single redrawn line. With ShowTasks it instead redraws a status region
+// with one line per in-flight task above the bar, similar to buildkit.
+type Tracker struct {
+	out     io.Writer
+	style   Style
+	enabled bool
+
+	mu        sync.Mutex
+	total     int
+	done      int
+	active    map[string]*taskState
+	showTasks bool
+	plain     bool
+	reported  int
+	frame     int
+	running   bool
+	stop      chan struct{}
+	stopped   chan struct{}
+	lastSize  int
+	lastLines int
+}
+
+type taskState struct {
+	started time.Time
+	state   string
+}
+
+// NewTracker creates a tracker for total tasks. When enabled is false every
+// method is a no-op.
+func NewTracker(out io.Writer, total int, style Style, enabled bool) *Tracker {
+	if len(style.Frames) == 0 {
+		style.Frames = []string{"|", "/", "-", "\\"}
+	}
+	return &Tracker{
+		out:      out,
+		style:    style,
+		enabled:  enabled && out != nil,
+		total:    total,
+		active:   map[string]*taskState{},
+		reported: -1,
+	}
+}
+
+// ShowTasks switches to the multi-line status region. Call it before Start.
+func (t *Tracker) ShowTasks(on bool) {
+	t.mu.Lock()
+	t.showTasks = on
+	t.mu.Unlock()
+}
+
+// Plain switches to plain mode: instead of redrawing a bar, the tracker
+// writes an "N of M complete" line every PlainInterval while the count
+// changes, and a last one on Stop. ShowTasks has no effect in plain mode.
+// Call it before Start.
+func (t *Tracker) Plain(on bool) {
+	t.mu.Lock()
+	t.plain = on
+	t.mu.Unlock()
+}
+
+// Start begins redrawing until Stop is called.
+func (t *Tracker) Start() {
+	if !t.enabled {
+		return
+	}
+	t.stop = make(chan struct{})
+	t.stopped = make(chan struct{})
+	t.mu.Lock()
+	t.running = true
+	interval := DefaultInterval
+	if t.plain {
+		interval = PlainInterval
+	}
+	t.mu.Unlock()
+	go t.loop(interval)
+}
+
+// Writer wraps w, typically the log output on the same terminal, so each
+// write first erases the progress display and redraws it afterwards instead
+// of tearing it. Writes should be whole lines.
+func (t *Tracker) Writer(w io.Writer) io.Writer {
+	if !t.enabled || t.plain {
+		return w
+	}
+	return trackerWriter{t: t, w: w}
+}
+
+type trackerWriter struct {
+	t *Tracker
+	w io.Writer
+}
+
+func (tw trackerWriter) Write(p []byte) (int, error) {
+	t := tw.t
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.clearLocked()
+	n, err := tw.w.Write(p)
+	if t.running {
+		t.renderLocked()
+	}
+	return n, err
+}
+
+// TaskStarted marks name as in flight.
+func (t *Tracker) TaskStarted(name string) {
+	if !t.enabled {
+		return
+	}
+	t.mu.Lock()
+	t.active[name] = &taskState{started: time.Now(), state: "running"}
+	t.mu.Unlock()
+}
+
+// TaskState updates the state shown next to an in-flight task, e.g.
+// "retrying (attempt 2)".
+func (t *Tracker) TaskState(name, state string) {
+	if !t.enabled {
+		return
+	}
+	t.mu.Lock()
+	if task, ok := t.active[name]; ok {
+		task.state = state
+	}
+	t.mu.Unlock()
+}
+
+// TaskFinished marks name as complete (successfully, failed, or skipped).
+func (t *Tracker) TaskFinished(name string) {
+	if !t.enabled {
+		return
+	}
+	t.mu.Lock()
+	delete(t.active, name)
+	t.done++
+	t.mu.Unlock()
+}
+
+// AddTotal grows the number of expected tasks, for streams of unknown length.
+func (t *Tracker) AddTotal(n int) {
+	t.mu.Lock()
+	t.total += n
+	t.mu.Unlock()
+}
+
+// Stop halts redrawing and erases the progress line; in plain mode it
+// reports the final count instead.
+func (t *Tracker) Stop() {
+	if !t.enabled || t.stop == nil {
+		return
+	}
+	close(t.stop)
+	<-t.stopped
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.running = false
+	if t.plain {
+		t.reportLocked()
+		return
+	}
+	t.clearLocked()
+}
+
+func (t *Tracker) loop(interval time.Duration) {
+	defer close(t.stopped)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		t.mu.Lock()
+		t.renderLocked()
+		t.mu.Unlock()
+		select {
+		case <-t.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *Tracker) renderLocked() {
+	if t.plain {
+		t.reportLocked()
+		return
+	}
+	frame := t.style.Frames[t.frame%len(t.style.Frames)]
+	t.frame++
+
+	names := make([]string, 0, len(t.active))
+	for name := range t.active {
+		names = append(names, name)
+	}
+
+	bar := fmt.Sprintf("%s %s %d/%d", frame, t.bar(), t.done, t.total)
+	if !t.showTasks {
+		sort.Strings(names)
+		if len(names) > 0 {
+			bar += "  " + strings.Join(names, ", ")
+		}
+		t.writeLocked(bar)
+		return
+	}
+
+	// Longest-running first, so lines stay put while newer tasks come and go.
+	sort.Slice(names, func(i, j int) bool {
+		return t.active[names[i]].started.Before(t.active[names[j]].started)
+	})
+	width := 0
+	for _, name := range names {
+		width = max(width, min(utf8.RuneCountInString(name), maxTaskName))
+	}
+	lines := make([]string, 0, len(names)+1)
+	now := time.Now()
+	for _, name := range names {
+		task := t.active[name]
+		lines = append(lines, fmt.Sprintf("%s %-*s  %5s  %s", frame, width, truncate(name, maxTaskName), elapsed(now.Sub(task.started)), task.state))
+	}
+	t.writeLinesLocked(append(lines, bar))
+}
+
+// reportLocked writes the plain-mode progress line, unless it would repeat
+// the last one.
+func (t *Tracker) reportLocked() {
+	if t.done == t.reported {
+		return
+	}
+	fmt.Fprintf(t.out, "%d of %d complete\n", t.done, t.total)
+	t.reported = t.done
+}
+
+// writeLinesLocked redraws the status region: it moves the cursor back to
+// the region's first line, clears to the end of the screen, and writes lines.
+func (t *Tracker) writeLinesLocked(lines []string) {
+	var b strings.Builder
+	if t.lastLines > 1 {
+		fmt.Fprintf(&b, "\033[%dA", t.lastLines-1)
+	}
+	b.WriteString("\r\033[J")
+	b.WriteString(strings.Join(lines, "\n"))
+	fmt.Fprint(t.out, b.String())
+	t.lastLines = len(lines)
+}
+
+func elapsed(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+	return fmt.Sprintf("%dm%02ds", int(d.Minutes()), int(d.Seconds())%60)
+}
+
+func truncate(s string, n int) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:n-1]) + "~"
+}
+
+func (t *Tracker) bar() string {
+	filled := 0
+	if t.total > 0 {
+		filled = barWidth * t.done / t.total
+	}
+	return "[" + strings.Repeat(t.style.BarFilled, filled) + strings.Repeat(t.style.BarEmpty, barWidth-filled) + "]"
+}
+
+func (t *Tracker) writeLocked(line string) {
+	pad := ""
+	n := utf8.RuneCountInString(line)
+	if n < t.lastSize {
+		pad = strings.Repeat(" ", t.lastSize-n)
+	}
+	fmt.Fprintf(t.out, "\r%s%s", line, pad)
+	t.lastSize = n
+}
+
+func (t *Tracker) clearLocked() {
+	if t.lastLines > 0 {
+		if t.lastLines > 1 {
+			fmt.Fprintf(t.out, "\033[%dA", t.lastLines-1)
+		}
+		fmt.Fprint(t.out, "\r\033[J")
+		t.lastLines = 0
+	}
+	if t.lastSize == 0 {
+		return
+	}
+	fmt.Fprintf(t.out, "\r%s\r", strings.Repeat(" ", t.lastSize))
+	t.lastSize = 0
+}
+
+// Spinner animates a single message until stopped.
+type Spinner struct {
+	tracker *Tracker
+	message string
+}
+
+// NewSpinner creates a spinner showing message. When enabled is false every
+// method is a no-op.
+func NewSpinner(out io.Writer, message string, style Style, enabled bool) *Spinner {
+	return &Spinner{tracker: NewTracker(out, 0, style, enabled), message: message}
+}
+
+// Plain switches to plain mode, where the message is written once as a
+// line instead of animated. Call it before Start.
+func (s *Spinner) Plain(on bool) {
+	s.tracker.Plain(on)
+}
+
+// Start begins animating.
+func (s *Spinner) Start() {
+	t := s.tracker
+	if !t.enabled {
+		return
+	}
+	if t.plain {
+		fmt.Fprintln(t.out, s.message)
+		return
+	}
+	t.stop = make(chan struct{})
+	t.stopped = make(chan struct{})
+	go func() {
+		defer close(t.stopped)
+		ticker := time.NewTicker(DefaultInterval)
+		defer ticker.Stop()
+		for {
+			t.mu.Lock()
+			frame := t.style.Frames[t.frame%len(t.style.Frames)]
+			t.frame++
+			t.writeLocked(frame + " " + s.message)
+			t.mu.Unlock()
+			select {
+			case <-t.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop halts the animation and erases the line.
+func (s *Spinner) Stop() {
+	s.tracker.Stop()
+}
@@ -0,0 +1,201 @@
+package prompt
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	tea "charm.land/bubbletea/v2"
+	"github.com/charmbracelet/x/ansi"
+)
+
+// fuzzyRows bounds how many matches the picker lists at once.
+const fuzzyRows = 10
+
+// Choice is an entry of Fuzzy. The description is shown and searched
+// alongside the value.
+type Choice struct {
+	Value       string
+	Description string
+}
+
+// Fuzzy lets the user narrow choices by typing and pick one with Enter;
+// Esc or Ctrl+C aborts with ErrAborted. The cursor starts on def. Without
+// a terminal it answers like Select: def under --yes or without a
+// terminal, ErrNonInteractive in non-interactive mode.
+func (p *Prompter) Fuzzy(question string, choices []Choice, def string) (string, error) {
+	if len(choices) == 0 {
+		return "", fmt.Errorf("%q has no options", question)
+	}
+	if def == "" && (p.AssumeYes || !p.NonInteractive && !p.Interactive) {
+		return "", fmt.Errorf("%s: no default to choose without a terminal", question)
+	}
+	if ok, err := p.auto(question, "", def); !ok {
+		return def, err
+	}
+
+	m := &fuzzyModel{question: question, choices: choices}
+	m.filter()
+	for i, idx := range m.matches {
+		if choices[idx].Value == def {
+			m.cursor = i
+		}
+	}
+	if _, err := tea.NewProgram(m, tea.WithInput(p.In), tea.WithOutput(p.Out)).Run(); err != nil {
+		return "", err
+	}
+	if !m.done {
+		return "", ErrAborted
+	}
+	fmt.Fprintf(p.Out, "%s %s\n", question, m.chosen)
+	return m.chosen, nil
+}
+
+type fuzzyModel struct {
+	question string
+	choices  []Choice
+	query    string
+	// matches are indices into choices, best match first.
+	matches []int
+	cursor  int
+	width   int
+	done    bool
+	quit    bool
+	chosen  string
+}
+
+func (m *fuzzyModel) Init() tea.Cmd { return nil }
+
+func (m *fuzzyModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width = msg.Width
+	case tea.KeyPressMsg:
+		switch msg.String() {
+		case "ctrl+c", "esc":
+			m.quit = true
+			return m, tea.Quit
+		case "enter":
+			if len(m.matches) == 0 {
+				return m, nil
+			}
+			m.chosen, m.done = m.choices[m.matches[m.cursor]].Value, true
+			return m, tea.Quit
+		case "up", "ctrl+p", "shift+tab":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case "down", "ctrl+n", "tab":
+			if m.cursor < len(m.matches)-1 {
+				m.cursor++
+			}
+		case "backspace":
+			if q := []rune(m.query); len(q) > 0 {
+				m.query = string(q[:len(q)-1])
+				m.filter()
+			}
+		case "ctrl+u":
+			m.query = ""
+			m.filter()
+		default:
+			if msg.Text != "" {
+				m.query += msg.Text
+				m.filter()
+			}
+		}
+	}
+	return m, nil
+}
+
+func (m *fuzzyModel) View() tea.View {
+	if m.done || m.quit {
+		return tea.NewView("")
+	}
+	lines := []string{m.question, "Filter: " + m.query}
+	start, end := 0, len(m.matches)
+	if end > fuzzyRows {
+		start = max(0, min(m.cursor-fuzzyRows/2, end-fuzzyRows))
+		end = start + fuzzyRows
+	}
+	for i := start; i < end; i++ {
+		c := m.choices[m.matches[i]]
+		line := "  " + c.Value
+		if i == m.cursor {
+			line = "> " + c.Value
+		}
+		if c.Description != "" {
+			line += "  " + ansi.Style{}.Faint().Styled(c.Description)
+		}
+		if m.width > 0 {
+			line = ansi.Truncate(line, m.width, "…")
+		}
+		lines = append(lines, line)
+	}
+	lines = append(lines, fmt.Sprintf("  %d/%d  up/down move, enter select, esc cancel", len(m.matches), len(m.choices)))
+	view := tea.NewView(strings.Join(lines, "\n"))
+	// Keep the cursor on the query line, after the typed text.
+	view.Cursor = tea.NewCursor(len("Filter: ")+ansi.StringWidth(m.query), 1)
+	return view
+}
+
+// filter recomputes the matches for the query, keeping the cursor in
+// range.
+func (m *fuzzyModel) filter() {
+	type scored struct{ idx, score int }
+	var found []scored
+	for i, c := range m.choices {
+		score, ok := fuzzyScore(c.Value, m.query)
+		if !ok {
+			if score, ok = fuzzyScore(c.Description, m.query); !ok {
+				continue
+			}
+			// Prefer matches on the value.
+			score -= 100
+		}
+		found = append(found, scored{i, score})
+	}
+	sort.SliceStable(found, func(i, j int) bool { return found[i].score > found[j].score })
+	m.matches = m.matches[:0]
+	for _, f := range found {
+		m.matches = append(m.matches, f.idx)
+	}
+	m.cursor = max(0, min(m.cursor, len(m.matches)-1))
+	if m.query != "" {
+		m.cursor = 0
+	}
+}
+
+// fuzzyScore reports whether the runes of query occur in text in order,
+// ignoring case, and scores the match: runs of consecutive runes and runes
+// at the start of words score higher, skipped runes lower.
+func fuzzyScore(text, query string) (int, bool) {
+	if query == "" {
+		return 0, true
+	}
+	t, q := []rune(strings.ToLower(text)), []rune(strings.ToLower(query))
+	score, qi, last := 0, 0, -1
+	for ti := 0; ti < len(t) && qi < len(q); ti++ {
+		if t[ti] != q[qi] {
+			continue
+		}
+		switch {
+		case ti == 0 || !unicode.IsLetter(t[ti-1]) && !unicode.IsDigit(t[ti-1]):
+			score += 8
+		case last == ti-1:
+			score += 5
+		default:
+			score++
+		}
+		if last >= 0 {
+			score -= min(ti-last-1, 3)
+		}
+		last = ti
+		qi++
+	}
+	if qi < len(q) {
+		return 0, false
+	}
+	// Among equal matches, shorter texts are closer.
+	return score*4 - len(t)/4, true
+}
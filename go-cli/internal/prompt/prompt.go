@@ -0,0 +1,333 @@
+// Package prompt asks questions on the terminal: yes/no confirmations,
+// single and multiple choice, and validated text input. Questions answer
+// themselves with their defaults under --yes or without a terminal to ask
+// on, and fail with ErrNonInteractive in non-interactive mode, so commands
+// that prompt never block scripts and CI.
+package prompt
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// ErrNonInteractive is returned for a question asked in non-interactive
+// mode. Callers add the flag or argument that answers it.
+var ErrNonInteractive = errors.New("input required but prompting is disabled")
+
+// ErrAborted is returned when input ends (Ctrl+D) before an answer.
+var ErrAborted = errors.New("prompt aborted")
+
+// maxAttempts bounds how often an invalid answer is asked again.
+const maxAttempts = 5
+
+// Prompter asks questions on In and Out.
+type Prompter struct {
+	In  io.Reader
+	Out io.Writer
+	// AssumeYes answers every question with its default and every
+	// confirmation with yes (--yes).
+	AssumeYes bool
+	// NonInteractive fails questions instead of asking or defaulting
+	// (--non-interactive). AssumeYes takes precedence.
+	NonInteractive bool
+	// Interactive reports whether In and Out are terminals; without them,
+	// questions resolve to their defaults.
+	Interactive bool
+
+	// reason says why New turned NonInteractive on; see Why.
+	reason string
+	reader *bufio.Reader
+}
+
+// New returns a prompter on stdin and stderr. Non-interactive mode is on
+// under nonInteractive (--non-interactive), in CI, and when stdin is not a
+// terminal, so nothing waits for an answer nobody can give.
+func New(assumeYes, nonInteractive bool) *Prompter {
+	p := &Prompter{
+		In:          os.Stdin,
+		Out:         os.Stderr,
+		AssumeYes:   assumeYes,
+		Interactive: isTerminal(os.Stdin) && isTerminal(os.Stderr),
+	}
+	switch {
+	case nonInteractive:
+		p.NonInteractive = true
+	case inCI():
+		p.NonInteractive, p.reason = true, "CI is set"
+	case !isTerminal(os.Stdin):
+		p.NonInteractive, p.reason = true, "stdin is not a terminal"
+	}
+	return p
+}
+
+// inCI reports whether CI is set to anything but false or 0, as CI
+// systems set it.
+func inCI() bool {
+	switch strings.ToLower(os.Getenv("CI")) {
+	case "", "false", "0":
+		return false
+	}
+	return true
+}
+
+// Why says why prompting is off, such as "CI is set", for errors.
+func (p *Prompter) Why() string {
+	if p.reason == "" {
+		return "--non-interactive"
+	}
+	return p.reason
+}
+
+// Confirm asks a yes/no question. Under --yes the answer is yes; without
+// a terminal it is def.
+func (p *Prompter) Confirm(question string, def bool) (bool, error) {
+	hint := "[y/N]"
+	if def {
+		hint = "[Y/n]"
+	}
+	if p.AssumeYes {
+		return true, nil
+	}
+	if ok, err := p.auto(question, hint, yesNo(def)); !ok {
+		return def, err
+	}
+	for range maxAttempts {
+		answer, err := p.ask(question + " " + hint + " ")
+		if err != nil {
+			if errors.Is(err, ErrAborted) {
+				return false, nil
+			}
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+		fmt.Fprintln(p.Out, "Please answer y or n.")
+	}
+	return false, fmt.Errorf("no valid answer to %q", question)
+}
+
+// ConfirmTyped asks for expected to be typed out, for actions too risky to
+// confirm with a y; any other answer declines. Under --yes the answer is
+// yes; without a terminal it is no.
+func (p *Prompter) ConfirmTyped(question, expected string) (bool, error) {
+	if p.AssumeYes {
+		return true, nil
+	}
+	if ok, err := p.auto(question, "", "no"); !ok {
+		return false, err
+	}
+	answer, err := p.ask(fmt.Sprintf("%s Type %s to confirm: ", question, expected))
+	if errors.Is(err, ErrAborted) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return answer == expected, nil
+}
+
+// Select asks for one of options and returns it; def must be one of them
+// or empty for no default. An answer is the option's number or its text.
+func (p *Prompter) Select(question string, options []string, def string) (string, error) {
+	if len(options) == 0 {
+		return "", fmt.Errorf("%q has no options", question)
+	}
+	if def == "" && (p.AssumeYes || !p.NonInteractive && !p.Interactive) {
+		return "", fmt.Errorf("%s: no default to choose without a terminal", question)
+	}
+	if ok, err := p.auto(question, "", def); !ok {
+		return def, err
+	}
+	p.menu(question, options, []string{def})
+	for range maxAttempts {
+		prompt := "Choose 1-" + strconv.Itoa(len(options))
+		if def != "" {
+			prompt += " [" + def + "]"
+		}
+		answer, err := p.ask(prompt + ": ")
+		if err != nil {
+			return "", err
+		}
+		if answer == "" && def != "" {
+			return def, nil
+		}
+		if choice, ok := pick(options, answer); ok {
+			return choice, nil
+		}
+		fmt.Fprintf(p.Out, "%q is not one of the options.\n", answer)
+	}
+	return "", fmt.Errorf("no valid answer to %q", question)
+}
+
+// MultiSelect asks for any number of options, answered as comma-separated
+// numbers or texts, "all", or "none"; an empty answer keeps defs.
+func (p *Prompter) MultiSelect(question string, options []string, defs []string) ([]string, error) {
+	shown := strings.Join(defs, ", ")
+	if shown == "" {
+		shown = "none"
+	}
+	if ok, err := p.auto(question, "", shown); !ok {
+		return defs, err
+	}
+	p.menu(question, options, defs)
+	for range maxAttempts {
+		answer, err := p.ask("Choose numbers separated by commas, all, or none [" + shown + "]: ")
+		if err != nil {
+			return nil, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return defs, nil
+		case "all":
+			return slices.Clone(options), nil
+		case "none":
+			return []string{}, nil
+		}
+		var chosen []string
+		valid := true
+		for part := range strings.SplitSeq(answer, ",") {
+			choice, ok := pick(options, strings.TrimSpace(part))
+			if !ok {
+				fmt.Fprintf(p.Out, "%q is not one of the options.\n", strings.TrimSpace(part))
+				valid = false
+				break
+			}
+			if !slices.Contains(chosen, choice) {
+				chosen = append(chosen, choice)
+			}
+		}
+		if valid {
+			return chosen, nil
+		}
+	}
+	return nil, fmt.Errorf("no valid answer to %q", question)
+}
+
+// Input asks for a line of text. An empty answer takes def; validate, if
+// set, rejects answers with a reason and the question is asked again. The
+// default is validated too when it is taken automatically.
+func (p *Prompter) Input(question, def string, validate func(string) error) (string, error) {
+	if validate == nil {
+		validate = func(string) error { return nil }
+	}
+	hint := ""
+	if def != "" {
+		hint = "[" + def + "]"
+	}
+	if ok, err := p.auto(question, hint, def); !ok {
+		if err != nil {
+			return "", err
+		}
+		if err := validate(def); err != nil {
+			return "", fmt.Errorf("%s: default %q: %w", question, def, err)
+		}
+		return def, nil
+	}
+	for range maxAttempts {
+		q := question
+		if hint != "" {
+			q += " " + hint
+		}
+		answer, err := p.ask(q + ": ")
+		if err != nil {
+			return "", err
+		}
+		if answer == "" {
+			answer = def
+		}
+		if err := validate(answer); err != nil {
+			fmt.Fprintf(p.Out, "Invalid: %v\n", err)
+			continue
+		}
+		return answer, nil
+	}
+	return "", fmt.Errorf("no valid answer to %q", question)
+}
+
+// auto answers a question without asking when prompting is off. It
+// reports false when the caller should use answer, along with an error in
+// --non-interactive mode.
+func (p *Prompter) auto(question, hint, answer string) (bool, error) {
+	switch {
+	case p.AssumeYes:
+		return false, nil
+	case p.NonInteractive:
+		return false, fmt.Errorf("%s %w (%s)", question, ErrNonInteractive, p.Why())
+	case !p.Interactive:
+		if hint != "" {
+			question += " " + hint
+		}
+		fmt.Fprintf(p.Out, "%s %s (default; no terminal)\n", question, answer)
+		return false, nil
+	}
+	return true, nil
+}
+
+// ask prints prompt and reads one trimmed line.
+func (p *Prompter) ask(prompt string) (string, error) {
+	if p.reader == nil {
+		p.reader = bufio.NewReader(p.In)
+	}
+	fmt.Fprint(p.Out, prompt)
+	line, err := p.reader.ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Fprintln(p.Out)
+		if errors.Is(err, io.EOF) {
+			return "", ErrAborted
+		}
+		return "", err
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// menu prints question and the numbered options, marking the defaults.
+func (p *Prompter) menu(question string, options, defs []string) {
+	fmt.Fprintln(p.Out, question)
+	for i, option := range options {
+		mark := " "
+		if slices.Contains(defs, option) {
+			mark = "*"
+		}
+		fmt.Fprintf(p.Out, " %s %d) %s\n", mark, i+1, option)
+	}
+}
+
+// pick resolves an answer given as an option's number or its text.
+func pick(options []string, answer string) (string, bool) {
+	if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(options) {
+		return options[n-1], true
+	}
+	for _, option := range options {
+		if strings.EqualFold(option, answer) {
+			return option, true
+		}
+	}
+	return "", false
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// isTerminal reports whether f is a terminal; /dev/null is a character
+// device but not one.
+func isTerminal(f *os.File) bool {
+	return term.IsTerminal(int(f.Fd()))
+}
@@ -0,0 +1,161 @@
+// Package releasenotes parses the project's CHANGELOG.md, which package
+// main embeds at build time, into per-release sections for the
+// `changelog` command and the notice shown after an upgrade.
+//
+// Sections are the level-two headings of the changelog: "## Unreleased"
+// and one "## vX.Y.Z" (or "## [X.Y.Z] - 2026-01-02") per release, newest
+// first.
+package releasenotes
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Markdown is the embedded CHANGELOG.md. Package main sets it before the
+// CLI runs; it is empty in builds that do not embed one.
+var Markdown string
+
+// Release is one section of the changelog.
+type Release struct {
+	// Version is the canonical "vX.Y.Z" form, or empty for the
+	// Unreleased section.
+	Version string `json:"version,omitempty" yaml:"version,omitempty"`
+	// Title is the heading text without the leading "## ".
+	Title string `json:"title" yaml:"title"`
+	// Notes is the Markdown below the heading.
+	Notes string `json:"notes" yaml:"notes"`
+}
+
+// Unreleased reports whether r is the section for changes not yet
+// released.
+func (r Release) Unreleased() bool {
+	return r.Version == ""
+}
+
+// Parse splits markdown into its releases, in file order. Level-two
+// headings that name neither a version nor "Unreleased" are skipped along
+// with their text.
+func Parse(markdown string) []Release {
+	var releases []Release
+	var current *Release
+	var notes []string
+	flush := func() {
+		if current != nil {
+			current.Notes = strings.Trim(strings.Join(notes, "\n"), "\n")
+			releases = append(releases, *current)
+		}
+		current, notes = nil, nil
+	}
+	for _, line := range strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n") {
+		if title, ok := strings.CutPrefix(line, "## "); ok {
+			flush()
+			title = strings.TrimSpace(title)
+			if version, ok := headingVersion(title); ok {
+				current = &Release{Version: version, Title: title}
+			}
+			continue
+		}
+		if current != nil {
+			notes = append(notes, line)
+		}
+	}
+	flush()
+	return releases
+}
+
+// headingVersion reads the version a heading names. It returns "" and true
+// for the Unreleased section.
+func headingVersion(title string) (string, bool) {
+	word, _, _ := strings.Cut(title, " ")
+	word = strings.Trim(word, "[]")
+	if strings.EqualFold(word, "unreleased") {
+		return "", true
+	}
+	version := Canonical(word)
+	return version, version != ""
+}
+
+// Canonical returns v as "vMAJOR.MINOR.PATCH[-PRERELEASE]", filling in a
+// missing minor or patch, or "" when v is not a version. Build metadata
+// after "+" is dropped.
+func Canonical(v string) string {
+	v = strings.TrimPrefix(v, "v")
+	v, _, _ = strings.Cut(v, "+")
+	core, pre, hasPre := strings.Cut(v, "-")
+	parts := strings.Split(core, ".")
+	if len(parts) > 3 || (hasPre && pre == "") {
+		return ""
+	}
+	for _, p := range parts {
+		if _, err := strconv.ParseUint(p, 10, 64); err != nil {
+			return ""
+		}
+	}
+	for len(parts) < 3 {
+		parts = append(parts, "0")
+	}
+	out := "v" + strings.Join(parts, ".")
+	if hasPre {
+		out += "-" + pre
+	}
+	return out
+}
+
+// Compare orders two versions like semantic versioning: -1 if a < b, 0 if
+// equal, +1 if a > b. A pre-release sorts before its release, and
+// pre-releases compare as strings. Versions that are not valid (see
+// Canonical) compare equal to everything.
+func Compare(a, b string) int {
+	a, b = Canonical(a), Canonical(b)
+	if a == "" || b == "" {
+		return 0
+	}
+	coreA, preA, _ := strings.Cut(a[1:], "-")
+	coreB, preB, _ := strings.Cut(b[1:], "-")
+	partsA, partsB := strings.Split(coreA, "."), strings.Split(coreB, ".")
+	for i := range partsA {
+		x, _ := strconv.ParseUint(partsA[i], 10, 64)
+		y, _ := strconv.ParseUint(partsB[i], 10, 64)
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case preA == preB:
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	case preA < preB:
+		return -1
+	}
+	return 1
+}
+
+// Between returns the releases newer than from and no newer than to. An
+// empty from means from the beginning. A to that is not a version (such
+// as "dev") means the running build is unreleased, so the Unreleased
+// section is included as well.
+func Between(releases []Release, from, to string) []Release {
+	released := Canonical(to) != ""
+	var out []Release
+	for _, r := range releases {
+		switch {
+		case r.Unreleased():
+			if released {
+				continue
+			}
+		case from != "" && Compare(r.Version, from) <= 0:
+			continue
+		case released && Compare(r.Version, to) > 0:
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
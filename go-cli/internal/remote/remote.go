@@ -0,0 +1,271 @@
+// Package remote runs shell commands on other hosts over SSH. It
+// authenticates with the SSH agent ($SSH_AUTH_SOCK) and private keys and
+// verifies host keys against a known_hosts file; unknown hosts are
+// rejected rather than trusted on first use.
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/execx"
+)
+
+// DefaultPort is the SSH port used when Config.Port is 0.
+const DefaultPort = 22
+
+// connectTimeout bounds the TCP connect and SSH handshake.
+const connectTimeout = 15 * time.Second
+
+// defaultKeys are the private keys tried, in order, without an
+// IdentityFile. Encrypted keys are skipped; load them into the agent.
+var defaultKeys = []string{"id_ed25519", "id_ecdsa", "id_rsa"}
+
+// Config describes one host.
+type Config struct {
+	Host string
+	// Port defaults to DefaultPort.
+	Port int
+	// User defaults to the current user.
+	User string
+	// IdentityFile is a private key tried after the agent; empty tries
+	// ~/.ssh/id_ed25519, id_ecdsa, and id_rsa.
+	IdentityFile string
+	// KnownHosts is the known_hosts file host keys are checked against;
+	// empty means ~/.ssh/known_hosts.
+	KnownHosts string
+}
+
+// Target returns the host as user@host, with :port when not the default.
+func (c Config) Target() string {
+	target := c.Host
+	if c.User != "" {
+		target = c.User + "@" + target
+	}
+	if c.Port != 0 && c.Port != DefaultPort {
+		target += ":" + strconv.Itoa(c.Port)
+	}
+	return target
+}
+
+// ExitError reports a remote command that exited non-zero or was killed by
+// a signal.
+type ExitError struct {
+	Host string
+	// Status is the exit status, or -1 when the remote side sent none.
+	Status int
+	Signal string
+}
+
+func (e *ExitError) Error() string {
+	if e.Signal != "" {
+		return fmt.Sprintf("killed by SIG%s on %s", e.Signal, e.Host)
+	}
+	return fmt.Sprintf("exit status %d on %s", e.Status, e.Host)
+}
+
+// Client is a connection to one host.
+type Client struct {
+	host  string
+	conn  *ssh.Client
+	agent net.Conn
+}
+
+// Dial connects and authenticates to the host described by cfg.
+func Dial(ctx context.Context, cfg Config) (*Client, error) {
+	if cfg.User == "" {
+		u, err := user.Current()
+		if err != nil {
+			return nil, fmt.Errorf("remote user for %s: %w", cfg.Host, err)
+		}
+		cfg.User = u.Username
+	}
+	port := cfg.Port
+	if port == 0 {
+		port = DefaultPort
+	}
+	home, _ := os.UserHomeDir()
+	knownHosts := cfg.KnownHosts
+	if knownHosts == "" {
+		knownHosts = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	hostKeys, err := knownhosts.New(expandHome(knownHosts, home))
+	if err != nil {
+		return nil, fmt.Errorf("load known hosts: %w", err)
+	}
+
+	c := &Client{host: cfg.Target()}
+	var auth []ssh.AuthMethod
+	if sock := os.Getenv("SSH_AUTH_SOCK"); sock != "" {
+		if conn, err := net.Dial("unix", sock); err == nil {
+			c.agent = conn
+			auth = append(auth, ssh.PublicKeysCallback(agent.NewClient(conn).Signers))
+		}
+	}
+	signers, err := keySigners(cfg.IdentityFile, home)
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	if len(signers) > 0 {
+		auth = append(auth, ssh.PublicKeys(signers...))
+	}
+	if len(auth) == 0 {
+		c.Close()
+		return nil, fmt.Errorf("no SSH credentials for %s: start an SSH agent or set identity_file", c.host)
+	}
+
+	config := &ssh.ClientConfig{
+		User:            cfg.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeys,
+		Timeout:         connectTimeout,
+	}
+	addr := net.JoinHostPort(cfg.Host, strconv.Itoa(port))
+	dialer := net.Dialer{Timeout: connectTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		c.Close()
+		return nil, fmt.Errorf("connect to %s: %w", c.host, err)
+	}
+	// The handshake has no context of its own; a deadline bounds it.
+	_ = conn.SetDeadline(time.Now().Add(connectTimeout))
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, addr, config)
+	if err != nil {
+		conn.Close()
+		c.Close()
+		var keyErr *knownhosts.KeyError
+		if errors.As(err, &keyErr) && len(keyErr.Want) == 0 {
+			return nil, fmt.Errorf("connect to %s: host key not in %s; add it with ssh-keyscan or by connecting once with ssh", c.host, knownHosts)
+		}
+		return nil, fmt.Errorf("connect to %s: %w", c.host, err)
+	}
+	_ = conn.SetDeadline(time.Time{})
+	c.conn = ssh.NewClient(sshConn, chans, reqs)
+	return c, nil
+}
+
+// keySigners loads the identity file, or the default keys that exist and
+// are not passphrase-protected.
+func keySigners(identityFile, home string) ([]ssh.Signer, error) {
+	if identityFile != "" {
+		path := expandHome(identityFile, home)
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("read identity file: %w", err)
+		}
+		signer, err := ssh.ParsePrivateKey(data)
+		if err != nil {
+			return nil, fmt.Errorf("parse identity file %s: %w", path, err)
+		}
+		return []ssh.Signer{signer}, nil
+	}
+	var signers []ssh.Signer
+	for _, name := range defaultKeys {
+		data, err := os.ReadFile(filepath.Join(home, ".ssh", name))
+		if err != nil {
+			continue
+		}
+		if signer, err := ssh.ParsePrivateKey(data); err == nil {
+			signers = append(signers, signer)
+		}
+	}
+	return signers, nil
+}
+
+func expandHome(path, home string) string {
+	if rest, ok := strings.CutPrefix(path, "~/"); ok {
+		return filepath.Join(home, rest)
+	}
+	return path
+}
+
+// Close closes the connection.
+func (c *Client) Close() error {
+	var err error
+	if c.conn != nil {
+		err = c.conn.Close()
+	}
+	if c.agent != nil {
+		c.agent.Close()
+	}
+	return err
+}
+
+// Run runs command through the remote user's shell, feeding it stdin when
+// not nil and passing every line of stdout and stderr to onLine. Canceling
+// ctx sends SIGTERM and closes the session. A non-zero exit returns an
+// *ExitError.
+func (c *Client) Run(ctx context.Context, command string, stdin io.Reader, onLine func(string)) error {
+	session, err := c.conn.NewSession()
+	if err != nil {
+		return fmt.Errorf("open session on %s: %w", c.host, err)
+	}
+	defer session.Close()
+	lines := execx.NewLineWriter(onLine)
+	session.Stdout, session.Stderr = lines, lines
+	session.Stdin = stdin
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = session.Signal(ssh.SIGTERM)
+			session.Close()
+		case <-done:
+		}
+	}()
+
+	err = session.Run(command)
+	lines.Flush()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	var exitErr *ssh.ExitError
+	var missing *ssh.ExitMissingError
+	switch {
+	case errors.As(err, &exitErr):
+		return &ExitError{Host: c.host, Status: exitErr.ExitStatus(), Signal: exitErr.Signal()}
+	case errors.As(err, &missing):
+		return &ExitError{Host: c.host, Status: -1}
+	case err != nil:
+		return fmt.Errorf("run on %s: %w", c.host, err)
+	}
+	return nil
+}
+
+// ShellCommand builds the command line that runs line with sh in dir (the
+// login directory when empty) with env (KEY=VALUE pairs) exported.
+func ShellCommand(dir string, env []string, line string) string {
+	var b strings.Builder
+	if dir != "" {
+		b.WriteString("cd " + Quote(dir) + " && ")
+	}
+	if len(env) > 0 {
+		b.WriteString("env ")
+	}
+	for _, kv := range env {
+		b.WriteString(Quote(kv) + " ")
+	}
+	b.WriteString("sh -c " + Quote(line))
+	return b.String()
+}
+
+// Quote quotes s for a POSIX shell.
+func Quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,109 @@
+package runner
+
+import (
+	"fmt"
+	"time"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/sysload"
+)
+
+// Scaler caps how many of the pool's workers may run jobs at once. The pool
+// asks before dispatching work and at least once per ScaleRecheck while work
+// is waiting. Limit is only called from the pool's coordinating goroutine.
+type Scaler interface {
+	Limit(now time.Time) int
+}
+
+// ScaleRecheck is how often a pool with a Scaler re-evaluates its limit
+// while jobs are queued behind it.
+const ScaleRecheck = time.Second
+
+// Adaptive thresholds. The gap between the load levels keeps the worker
+// count from oscillating around a single value.
+const (
+	// adaptiveHighLoad shrinks the pool above this load average per CPU.
+	adaptiveHighLoad = 1.5
+	// adaptiveLowLoad lets the pool grow again below this load per CPU.
+	adaptiveLowLoad = 1.0
+	// adaptiveMemLow halves the pool below this fraction of available
+	// memory; adaptiveMemOK must be reached before it grows again.
+	adaptiveMemLow = 0.10
+	adaptiveMemOK  = 0.20
+)
+
+// Adaptive is a Scaler that starts at Max workers and follows system load:
+// it drops one worker while the load average per CPU stays high, halves the
+// pool under memory pressure, and adds workers back one at a time once the
+// machine recovers.
+type Adaptive struct {
+	// Max is the worker count to start at and never exceed.
+	Max int
+	// CPUs normalizes the load average.
+	CPUs int
+	// Interval is the minimum time between samples.
+	Interval time.Duration
+	// Sample reads the system load; nil means sysload.Sample.
+	Sample func() (sysload.Load, error)
+	// Logf, when set, receives every decision and sampling failure.
+	Logf func(format string, args ...any)
+
+	current int
+	next    time.Time
+	failed  bool
+}
+
+// Limit implements Scaler.
+func (a *Adaptive) Limit(now time.Time) int {
+	if a.current == 0 {
+		a.current = max(a.Max, 1)
+	}
+	if a.failed || now.Before(a.next) {
+		return a.current
+	}
+	a.next = now.Add(a.Interval)
+
+	sample := a.Sample
+	if sample == nil {
+		sample = sysload.Sample
+	}
+	load, err := sample()
+	if err != nil {
+		// Keep the starting size rather than guessing.
+		a.failed = true
+		a.logf("cannot sample system load, keeping parallelism at %d: %v", a.current, err)
+		return a.current
+	}
+
+	to, reason := a.decide(load)
+	if to != a.current {
+		a.logf("parallelism %d -> %d: %s", a.current, to, reason)
+		a.current = to
+	}
+	return a.current
+}
+
+func (a *Adaptive) decide(load sysload.Load) (int, string) {
+	cpus := max(a.CPUs, 1)
+	perCPU := load.Load1 / float64(cpus)
+	memKnown := load.MemAvailable >= 0
+	describe := fmt.Sprintf("load average %.2f on %d CPUs", load.Load1, cpus)
+	if memKnown {
+		describe += fmt.Sprintf(", %.0f%% memory available", load.MemAvailable*100)
+	}
+
+	switch {
+	case memKnown && load.MemAvailable < adaptiveMemLow && a.current > 1:
+		return max(a.current/2, 1), "memory pressure (" + describe + ")"
+	case perCPU > adaptiveHighLoad && a.current > 1:
+		return a.current - 1, "high load (" + describe + ")"
+	case perCPU < adaptiveLowLoad && (!memKnown || load.MemAvailable >= adaptiveMemOK) && a.current < a.Max:
+		return a.current + 1, "load recovered (" + describe + ")"
+	}
+	return a.current, describe
+}
+
+func (a *Adaptive) logf(format string, args ...any) {
+	if a.Logf != nil {
+		a.Logf(format, args...)
+	}
+}
@@ -0,0 +1,91 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// graph is the validated dependency structure of a job batch, expressed as
+// job indices.
+type graph struct {
+	deps       [][]int
+	dependents [][]int
+}
+
+func newGraph(jobs []Job) (graph, error) {
+	index := make(map[string]int, len(jobs))
+	for i, job := range jobs {
+		if _, dup := index[job.Name]; dup {
+			return graph{}, fmt.Errorf("duplicate job %q", job.Name)
+		}
+		index[job.Name] = i
+	}
+
+	g := graph{
+		deps:       make([][]int, len(jobs)),
+		dependents: make([][]int, len(jobs)),
+	}
+	for i, job := range jobs {
+		for _, dep := range job.Deps {
+			j, ok := index[dep]
+			if !ok {
+				return graph{}, fmt.Errorf("job %q depends on unknown job %q", job.Name, dep)
+			}
+			g.deps[i] = append(g.deps[i], j)
+			g.dependents[j] = append(g.dependents[j], i)
+		}
+	}
+
+	if cycle := g.findCycle(); cycle != nil {
+		names := make([]string, len(cycle))
+		for k, i := range cycle {
+			names[k] = jobs[i].Name
+		}
+		return graph{}, fmt.Errorf("dependency cycle: %s", strings.Join(names, " -> "))
+	}
+	return g, nil
+}
+
+// findCycle returns the job indices forming a cycle, with the first job
+// repeated at the end, or nil when the graph is acyclic.
+func (g graph) findCycle() []int {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(g.deps))
+	var stack []int
+
+	var visit func(i int) []int
+	visit = func(i int) []int {
+		state[i] = visiting
+		stack = append(stack, i)
+		for _, d := range g.deps[i] {
+			switch state[d] {
+			case visiting:
+				for k, s := range stack {
+					if s == d {
+						return append(append([]int{}, stack[k:]...), d)
+					}
+				}
+			case unvisited:
+				if cycle := visit(d); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[i] = visited
+		return nil
+	}
+
+	for i := range g.deps {
+		if state[i] == unvisited {
+			if cycle := visit(i); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
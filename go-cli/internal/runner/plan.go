@@ -0,0 +1,72 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+)
+
+// Action kinds reported by planning jobs, rendered terraform-style.
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+	ActionRun    = "run"
+)
+
+// Action is one change a job would make if it ran.
+type Action struct {
+	Kind   string `json:"kind" yaml:"kind"`
+	Target string `json:"target" yaml:"target"`
+	Detail string `json:"detail,omitempty" yaml:"detail,omitempty"`
+}
+
+// Step is a job's entry in a plan.
+type Step struct {
+	Job     string   `json:"job" yaml:"job"`
+	Deps    []string `json:"deps,omitempty" yaml:"deps,omitempty"`
+	Actions []Action `json:"actions" yaml:"actions"`
+}
+
+// Plan returns the steps jobs would take, in an order Run could execute
+// them: every job appears after its dependencies, and otherwise in input
+// order. Jobs without a Plan func, or whose Plan reports nothing, get a
+// single "run" action. Nothing is executed.
+func Plan(ctx context.Context, jobs []Job) ([]Step, error) {
+	graph, err := newGraph(jobs)
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := make([]int, len(jobs))
+	for i := range jobs {
+		remaining[i] = len(graph.deps[i])
+	}
+	planned := make([]bool, len(jobs))
+
+	steps := make([]Step, 0, len(jobs))
+	for len(steps) < len(jobs) {
+		// Pick the first unplanned job whose dependencies are all planned;
+		// the graph is acyclic, so one always exists.
+		i := 0
+		for planned[i] || remaining[i] > 0 {
+			i++
+		}
+		planned[i] = true
+		for _, d := range graph.dependents[i] {
+			remaining[d]--
+		}
+
+		job := jobs[i]
+		var actions []Action
+		if job.Plan != nil {
+			if actions, err = job.Plan(ctx); err != nil {
+				return nil, fmt.Errorf("plan %s: %w", job.Name, err)
+			}
+		}
+		if len(actions) == 0 {
+			actions = []Action{{Kind: ActionRun, Target: job.Name}}
+		}
+		steps = append(steps, Step{Job: job.Name, Deps: job.Deps, Actions: actions})
+	}
+	return steps, nil
+}
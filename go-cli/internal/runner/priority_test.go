@@ -0,0 +1,162 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+)
+
+// recorder collects job start order across workers.
+type recorder struct {
+	mu    sync.Mutex
+	order []string
+}
+
+func (r *recorder) job(name string, priority int, deps ...string) Job {
+	return Job{
+		Name:     name,
+		Deps:     deps,
+		Priority: priority,
+		Run: func(context.Context) error {
+			r.mu.Lock()
+			r.order = append(r.order, name)
+			r.mu.Unlock()
+			return nil
+		},
+	}
+}
+
+func runSerial(t *testing.T, jobs []Job) Report {
+	t.Helper()
+	report, err := Run(context.Background(), jobs, Options{Parallelism: 1})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if err := report.Err(); err != nil {
+		t.Fatalf("jobs failed: %v", err)
+	}
+	return report
+}
+
+func TestRunStartsHigherPriorityFirst(t *testing.T) {
+	var rec recorder
+	runSerial(t, []Job{
+		rec.job("low", -1),
+		rec.job("default", 0),
+		rec.job("high", 10),
+		rec.job("medium", 5),
+	})
+
+	want := []string{"high", "medium", "default", "low"}
+	if !slices.Equal(rec.order, want) {
+		t.Fatalf("order = %v, want %v", rec.order, want)
+	}
+}
+
+func TestRunBreaksPriorityTiesFIFO(t *testing.T) {
+	var rec recorder
+	// c and d become ready after a finishes; they queue behind b, which
+	// was ready from the start, and keep their input order.
+	runSerial(t, []Job{
+		rec.job("a", 1),
+		rec.job("b", 0),
+		rec.job("c", 0, "a"),
+		rec.job("d", 0, "a"),
+	})
+
+	want := []string{"a", "b", "c", "d"}
+	if !slices.Equal(rec.order, want) {
+		t.Fatalf("order = %v, want %v", rec.order, want)
+	}
+}
+
+func TestRunDependencyInheritsDependentPriority(t *testing.T) {
+	var rec recorder
+	// urgent needs prep. Without inheritance prep would wait behind every
+	// filler and urgent would start last.
+	jobs := []Job{
+		rec.job("filler-1", 5),
+		rec.job("filler-2", 5),
+		rec.job("filler-3", 5),
+		rec.job("prep", 0),
+		rec.job("urgent", 10, "prep"),
+	}
+	runSerial(t, jobs)
+
+	want := []string{"prep", "urgent", "filler-1", "filler-2", "filler-3"}
+	if !slices.Equal(rec.order, want) {
+		t.Fatalf("order = %v, want %v", rec.order, want)
+	}
+}
+
+func TestRunDoesNotStarveLowPriority(t *testing.T) {
+	var rec recorder
+	// A chain of high-priority jobs keeps producing ready work while the
+	// low-priority job waits. It must still run once the chain is done.
+	jobs := []Job{rec.job("low", -100)}
+	prev := ""
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("high-%02d", i)
+		if prev == "" {
+			jobs = append(jobs, rec.job(name, 100))
+		} else {
+			jobs = append(jobs, rec.job(name, 100, prev))
+		}
+		prev = name
+	}
+	report := runSerial(t, jobs)
+
+	if len(rec.order) != len(jobs) {
+		t.Fatalf("ran %d jobs, want %d: %v", len(rec.order), len(jobs), rec.order)
+	}
+	if last := rec.order[len(rec.order)-1]; last != "low" {
+		t.Fatalf("last job = %s, want low", last)
+	}
+	if r := report.Results[0]; r.Skipped || r.Attempts != 1 {
+		t.Fatalf("low result = %+v, want one successful attempt", r)
+	}
+}
+
+func TestRunPriorityWithParallelWorkers(t *testing.T) {
+	var rec recorder
+	var others sync.WaitGroup
+	release := make(chan struct{})
+
+	// The blocker holds one of the two workers until every other job has
+	// run, so the rest share the second worker and start by priority.
+	blocker := rec.job("blocker", 100)
+	run := blocker.Run
+	blocker.Run = func(ctx context.Context) error {
+		<-release
+		return run(ctx)
+	}
+	jobs := []Job{blocker}
+	for _, j := range []Job{rec.job("a", 1), rec.job("b", 5), rec.job("c", 3)} {
+		others.Add(1)
+		run := j.Run
+		j.Run = func(ctx context.Context) error {
+			defer others.Done()
+			return run(ctx)
+		}
+		jobs = append(jobs, j)
+	}
+	go func() {
+		others.Wait()
+		close(release)
+	}()
+
+	report, err := Run(context.Background(), jobs, Options{Parallelism: 2})
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if err := report.Err(); err != nil {
+		t.Fatalf("jobs failed: %v", err)
+	}
+
+	want := []string{"b", "c", "a", "blocker"}
+	if !slices.Equal(rec.order, want) {
+		t.Fatalf("order = %v, want %v", rec.order, want)
+	}
+}
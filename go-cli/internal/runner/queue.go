@@ -0,0 +1,80 @@
+package runner
+
+import "container/heap"
+
+// readyQueue holds the indices of jobs whose dependencies are satisfied. It
+// pops the highest priority first and, among equal priorities, the job that
+// became ready first.
+type readyQueue struct {
+	items []queued
+	seq   int
+}
+
+type queued struct {
+	index    int
+	priority int
+	seq      int
+}
+
+func (q *readyQueue) push(index, priority int) {
+	q.seq++
+	heap.Push((*queueHeap)(q), queued{index: index, priority: priority, seq: q.seq})
+}
+
+func (q *readyQueue) pop() int {
+	return heap.Pop((*queueHeap)(q)).(queued).index
+}
+
+func (q *readyQueue) len() int {
+	return len(q.items)
+}
+
+// queueHeap implements heap.Interface for readyQueue without exporting the
+// methods on the queue itself.
+type queueHeap readyQueue
+
+func (h *queueHeap) Len() int { return len(h.items) }
+
+func (h *queueHeap) Less(i, j int) bool {
+	a, b := h.items[i], h.items[j]
+	if a.priority != b.priority {
+		return a.priority > b.priority
+	}
+	return a.seq < b.seq
+}
+
+func (h *queueHeap) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+
+func (h *queueHeap) Push(x any) { h.items = append(h.items, x.(queued)) }
+
+func (h *queueHeap) Pop() any {
+	last := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return last
+}
+
+// effectivePriorities returns each job's priority raised to the highest
+// priority among the jobs that depend on it, directly or transitively, so a
+// high-priority job is not starved behind a low-priority dependency.
+func (g graph) effectivePriorities(jobs []Job) []int {
+	eff := make([]int, len(jobs))
+	done := make([]bool, len(jobs))
+	var visit func(i int) int
+	visit = func(i int) int {
+		if done[i] {
+			return eff[i]
+		}
+		p := jobs[i].Priority
+		for _, d := range g.dependents[i] {
+			if dp := visit(d); dp > p {
+				p = dp
+			}
+		}
+		eff[i], done[i] = p, true
+		return p
+	}
+	for i := range jobs {
+		visit(i)
+	}
+	return eff
+}
@@ -0,0 +1,88 @@
+package runner
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/clock"
+)
+
+// Limiter is a token bucket: it refills at Rate tokens per second up to
+// Burst, and each job attempt takes one token. A nil *Limiter never blocks.
+type Limiter struct {
+	rate  float64
+	burst float64
+
+	clock clock.Clock
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter returns a limiter allowing rate operations per second with
+// bursts of up to burst. A rate of 0 or less disables limiting and returns
+// nil; a burst below 1 means 1.
+func NewLimiter(rate float64, burst int) *Limiter {
+	if rate <= 0 {
+		return nil
+	}
+	if burst < 1 {
+		burst = 1
+	}
+	clk := clock.Real()
+	return &Limiter{
+		rate:   rate,
+		burst:  float64(burst),
+		clock:  clk,
+		tokens: float64(burst),
+		last:   clk.Now(),
+	}
+}
+
+// WithClock makes l measure refills and waits on c, for tests. It returns l
+// and is a no-op on a nil limiter.
+func (l *Limiter) WithClock(c clock.Clock) *Limiter {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.clock = c
+	l.last = c.Now()
+	return l
+}
+
+// Wait blocks until a token is available or ctx is done. Waiters are served
+// in the order they call Wait: each one reserves its token up front, driving
+// the bucket negative, and sleeps until the refill catches up.
+func (l *Limiter) Wait(ctx context.Context) error {
+	if l == nil {
+		return ctx.Err()
+	}
+
+	l.mu.Lock()
+	clk := l.clock
+	now := clk.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens--
+	var delay time.Duration
+	if l.tokens < 0 {
+		delay = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if err := clock.Sleep(ctx, clk, delay); err != nil {
+		// Hand the reservation back so canceled waiters do not slow others.
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,115 @@
+package runner
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
+)
+
+// Error classes accepted by RetryPolicy.RetryOn.
+const (
+	// RetryOnAny retries every error.
+	RetryOnAny = "any"
+	// RetryOnTransient retries errors marked with Transient or exposing
+	// Temporary() bool.
+	RetryOnTransient = "transient"
+	// RetryOnTimeout retries context deadlines and errors exposing
+	// Timeout() bool.
+	RetryOnTimeout = "timeout"
+)
+
+// RetryPolicy controls how often and how patiently a job is retried.
+type RetryPolicy struct {
+	// MaxAttempts is the total number of attempts; values below 1 mean 1.
+	MaxAttempts int
+	// InitialDelay is the wait before the second attempt; later waits double.
+	InitialDelay time.Duration
+	// MaxDelay caps the wait between attempts (0 = uncapped).
+	MaxDelay time.Duration
+	// Jitter randomizes each wait by up to ±Jitter (0..1) of its length.
+	Jitter float64
+	// RetryOn lists the error classes worth retrying.
+	RetryOn []string
+}
+
+// Attempts returns the effective attempt budget.
+func (p RetryPolicy) Attempts() int {
+	if p.MaxAttempts < 1 {
+		return 1
+	}
+	return p.MaxAttempts
+}
+
+// Delay returns the backoff before attempt number next (2 for the first retry).
+func (p RetryPolicy) Delay(next int) time.Duration {
+	delay := float64(p.InitialDelay) * math.Pow(2, float64(next-2))
+	if p.MaxDelay > 0 && delay > float64(p.MaxDelay) {
+		delay = float64(p.MaxDelay)
+	}
+	if p.Jitter > 0 {
+		delay += delay * p.Jitter * (2*rand.Float64() - 1)
+	}
+	if delay < 0 {
+		return 0
+	}
+	return time.Duration(delay)
+}
+
+// Retryable reports whether err belongs to one of the policy's classes.
+// Cancellation by the caller is never retried.
+func (p RetryPolicy) Retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	for _, class := range p.RetryOn {
+		switch class {
+		case RetryOnAny:
+			return true
+		case RetryOnTransient:
+			if IsTransient(err) {
+				return true
+			}
+		case RetryOnTimeout:
+			if isTimeout(err) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ValidRetryClass reports whether class is a known RetryOn value.
+func ValidRetryClass(class string) bool {
+	return slices.Contains([]string{RetryOnAny, RetryOnTransient, RetryOnTimeout}, class)
+}
+
+type transientError struct{ err error }
+
+func (e transientError) Error() string   { return e.err.Error() }
+func (e transientError) Unwrap() error   { return e.err }
+func (e transientError) Temporary() bool { return true }
+
+// Transient marks err as worth retrying under the "transient" class.
+func Transient(err error) error {
+	if err == nil {
+		return nil
+	}
+	return transientError{err: err}
+}
+
+// IsTransient reports whether any error in err's chain is temporary.
+func IsTransient(err error) bool {
+	var t interface{ Temporary() bool }
+	return errors.As(err, &t) && t.Temporary()
+}
+
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var t interface{ Timeout() bool }
+	return errors.As(err, &t) && t.Timeout()
+}
@@ -0,0 +1,396 @@
+// Package runner executes jobs concurrently on a bounded worker pool.
+//
+// Invariants:
+//   - At most Options.Parallelism jobs run at any moment, and no more than
+//     Options.Scaler allows when it is set.
+//   - A job starts only after every job it depends on has succeeded; if a
+//     dependency fails or is skipped, the dependent is skipped too.
+//   - Every job gets exactly one Result, stored at the job's index, so the
+//     report order matches the input order regardless of completion order.
+//   - Among ready jobs the highest effective priority starts first, and
+//     equal priorities start in the order they became ready (input order
+//     for jobs without dependencies). A job's effective priority is the
+//     highest of its own and that of every job depending on it.
+//   - Priority only reorders a batch: every job still gets its turn, so low
+//     priority work is delayed by at most the higher-priority jobs in the
+//     same batch, never starved.
+//   - When Options.Limiter is set, every attempt takes a token first, so
+//     the attempt rate stays within the limit regardless of parallelism.
+//   - Once the context is canceled (by the caller or by fail-fast), jobs that
+//     have not started are reported as skipped and never invoked.
+//   - Only the coordinating goroutine in Run mutates scheduling state; workers
+//     communicate exclusively through the work and done channels.
+//   - A job whose Run returns ErrUpToDate is reported as UpToDate, not
+//     failed, is not retried, and releases its dependents like a success.
+//   - RunStream jobs are independent: a streamed job that declares Deps fails
+//     without running, and results are stored in arrival order.
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/clock"
+)
+
+// Job is a named unit of work.
+type Job struct {
+	Name string
+	// Deps names jobs in the same batch that must succeed before this one.
+	Deps []string
+	// Retry overrides Options.Retry for this job when set.
+	Retry *RetryPolicy
+	// Priority orders ready jobs when more are runnable than there are
+	// workers; higher runs first. The default is 0.
+	Priority int
+	Run      func(ctx context.Context) error
+	// Plan, when set, describes what Run would do without doing it.
+	Plan func(ctx context.Context) ([]Action, error)
+	// Fingerprint, when set, summarizes the job's definition and inputs.
+	// The pool does not call it; callers use it to skip jobs whose
+	// fingerprint matches the last successful run (see ErrUpToDate). An
+	// empty fingerprint means there is nothing to compare.
+	Fingerprint func(ctx context.Context) (string, error)
+}
+
+// ErrUpToDate is returned by a job's Run when it had nothing to do because
+// its inputs did not change since it last succeeded.
+var ErrUpToDate = errors.New("up to date")
+
+// Options tune pool behaviour.
+type Options struct {
+	// Parallelism caps concurrently running jobs; values below 1 mean 1.
+	Parallelism int
+	// FailFast cancels outstanding work after the first failure.
+	FailFast bool
+	// Retry is the default retry policy for every job.
+	Retry RetryPolicy
+	// OnRetry, when set, is called before each retry wait. It runs on the
+	// worker goroutine and must be safe for concurrent use.
+	OnRetry func(job string, attempt int, err error, delay time.Duration)
+	// Observer, when set, is notified as jobs start and finish.
+	Observer Observer
+	// Limiter, when set, throttles job attempts (including retries) across
+	// all workers.
+	Limiter *Limiter
+	// Scaler, when set, lowers the number of jobs running at once below
+	// Parallelism, which then only sets the number of workers.
+	Scaler Scaler
+	// Clock times jobs and retry backoff; nil means the wall clock. Tests
+	// pass a clock.Fake to run retries without sleeping.
+	Clock clock.Clock
+}
+
+// Observer receives job lifecycle events, e.g. to drive progress output.
+// JobStarted runs on worker goroutines, so implementations must be safe for
+// concurrent use. JobFinished is called once per job, including skipped ones.
+type Observer interface {
+	JobStarted(name string)
+	JobFinished(result Result)
+}
+
+// Result records the outcome of a single job.
+type Result struct {
+	Name    string
+	Err     error
+	Skipped bool
+	// UpToDate reports that the job returned ErrUpToDate; Err is nil.
+	UpToDate bool
+	Attempts int
+	Started  time.Time
+	Duration time.Duration
+}
+
+// Report holds the results of a pool run in input order.
+type Report struct {
+	Results []Result
+	// Started is when Run began scheduling; Result.Started minus Started is
+	// how long a job waited in the queue.
+	Started  time.Time
+	Duration time.Duration
+	// Workers is the number of worker goroutines the run used.
+	Workers int
+}
+
+// Err joins the errors of every failed job, or returns nil.
+func (r Report) Err() error {
+	var errs []error
+	for _, res := range r.Results {
+		if res.Err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", res.Name, res.Err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Run executes jobs in dependency order and blocks until all of them finished
+// or were skipped. It returns an error without running anything when the
+// dependency graph is invalid.
+func Run(ctx context.Context, jobs []Job, opts Options) (Report, error) {
+	graph, err := newGraph(jobs)
+	if err != nil {
+		return Report{}, err
+	}
+
+	workers := opts.Parallelism
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > len(jobs) {
+		workers = len(jobs)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	clk := clock.Or(opts.Clock)
+	started := clk.Now()
+	results := make([]Result, len(jobs))
+	work := make(chan int)
+	done := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				results[i] = runJob(ctx, jobs[i], opts)
+				done <- i
+			}
+		}()
+	}
+
+	priority := graph.effectivePriorities(jobs)
+	remaining := make([]int, len(jobs))
+	blocked := make([]bool, len(jobs))
+	var ready readyQueue
+	for i := range jobs {
+		remaining[i] = len(graph.deps[i])
+		if remaining[i] == 0 {
+			ready.push(i, priority[i])
+		}
+	}
+
+	release := func(i int) {
+		failed := results[i].Err != nil || results[i].Skipped
+		for _, d := range graph.dependents[i] {
+			if failed {
+				blocked[d] = true
+			}
+			remaining[d]--
+			if remaining[d] == 0 {
+				ready.push(d, priority[d])
+			}
+		}
+	}
+
+	running, finished := 0, 0
+	for finished < len(jobs) {
+		limit := scaledLimit(opts, clk, workers)
+		for ready.len() > 0 && running < limit {
+			i := ready.pop()
+			if blocked[i] || ctx.Err() != nil {
+				results[i] = Result{Name: jobs[i].Name, Skipped: true}
+				finished++
+				notifyFinished(opts, results[i])
+				release(i)
+				continue
+			}
+			running++
+			work <- i
+		}
+		if running == 0 {
+			continue
+		}
+
+		// Jobs held back by the scaler need a periodic recheck even while
+		// nothing finishes.
+		var recheck clock.Timer
+		var recheckC <-chan time.Time
+		if opts.Scaler != nil && ready.len() > 0 {
+			recheck = clk.NewTimer(ScaleRecheck)
+			recheckC = recheck.C()
+		}
+		select {
+		case i := <-done:
+			running--
+			finished++
+			notifyFinished(opts, results[i])
+			if results[i].Err != nil && opts.FailFast {
+				cancel()
+			}
+			release(i)
+		case <-recheckC:
+		}
+		if recheck != nil {
+			recheck.Stop()
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	return Report{Results: results, Started: started, Duration: clk.Since(started), Workers: workers}, nil
+}
+
+// RunStream executes jobs as they arrive on jobs, up to Options.Parallelism
+// at a time, and blocks until the channel is closed and every received job
+// finished. Once the context is canceled it stops receiving; the producer
+// must watch the same context to stop sending.
+func RunStream(ctx context.Context, jobs <-chan Job, opts Options) Report {
+	workers := opts.Parallelism
+	if workers < 1 {
+		workers = 1
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	clk := clock.Or(opts.Clock)
+	started := clk.Now()
+	type finished struct {
+		index  int
+		result Result
+	}
+	type assignment struct {
+		index int
+		job   Job
+	}
+	work := make(chan assignment)
+	done := make(chan finished)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for a := range work {
+				done <- finished{a.index, runJob(ctx, a.job, opts)}
+			}
+		}()
+	}
+
+	var results []Result
+	running := 0
+	in := jobs
+	for {
+		if ctx.Err() != nil {
+			in = nil
+		}
+		if in == nil && running == 0 {
+			break
+		}
+		// Only accept a new job while a worker is free, so a fast producer
+		// is held back instead of buffering without bound.
+		accept := in
+		var recheck clock.Timer
+		var recheckC <-chan time.Time
+		if running >= scaledLimit(opts, clk, workers) {
+			accept = nil
+			if opts.Scaler != nil && running < workers {
+				recheck = clk.NewTimer(ScaleRecheck)
+				recheckC = recheck.C()
+			}
+		}
+		var canceled <-chan struct{}
+		if accept != nil {
+			canceled = ctx.Done()
+		}
+		select {
+		case job, ok := <-accept:
+			if !ok {
+				in = nil
+				continue
+			}
+			index := len(results)
+			results = append(results, Result{Name: job.Name})
+			if len(job.Deps) > 0 {
+				results[index].Err = fmt.Errorf("depends on %s, but streamed jobs cannot have dependencies", strings.Join(job.Deps, ", "))
+				notifyFinished(opts, results[index])
+				continue
+			}
+			running++
+			work <- assignment{index, job}
+		case f := <-done:
+			running--
+			results[f.index] = f.result
+			notifyFinished(opts, f.result)
+			if f.result.Err != nil && opts.FailFast {
+				cancel()
+			}
+		case <-recheckC:
+		case <-canceled:
+		}
+		if recheck != nil {
+			recheck.Stop()
+		}
+	}
+	close(work)
+	wg.Wait()
+
+	return Report{Results: results, Started: started, Duration: clk.Since(started), Workers: workers}
+}
+
+// scaledLimit returns how many jobs may run now: the scaler's limit clamped
+// to [1, workers], or workers without a scaler.
+func scaledLimit(opts Options, clk clock.Clock, workers int) int {
+	if opts.Scaler == nil {
+		return workers
+	}
+	return min(max(opts.Scaler.Limit(clk.Now()), 1), workers)
+}
+
+func runJob(ctx context.Context, job Job, opts Options) Result {
+	if ctx.Err() != nil {
+		return Result{Name: job.Name, Skipped: true}
+	}
+
+	policy := opts.Retry
+	if job.Retry != nil {
+		policy = *job.Retry
+	}
+	if opts.Observer != nil {
+		opts.Observer.JobStarted(job.Name)
+	}
+
+	clk := clock.Or(opts.Clock)
+	start := clk.Now()
+	var err error
+	attempt := 1
+	for ; ; attempt++ {
+		if err = opts.Limiter.Wait(ctx); err != nil {
+			break
+		}
+		err = job.Run(ctx)
+		if errors.Is(err, ErrUpToDate) {
+			return Result{Name: job.Name, UpToDate: true, Attempts: attempt, Started: start, Duration: clk.Since(start)}
+		}
+		if err == nil || attempt >= policy.Attempts() || !policy.Retryable(err) {
+			break
+		}
+		delay := policy.Delay(attempt + 1)
+		if opts.OnRetry != nil {
+			opts.OnRetry(job.Name, attempt, err, delay)
+		}
+		if clock.Sleep(ctx, clk, delay) != nil {
+			break
+		}
+	}
+	return Result{
+		Name:     job.Name,
+		Err:      err,
+		Attempts: attempt,
+		Started:  start,
+		Duration: clk.Since(start),
+	}
+}
+
+func notifyFinished(opts Options, result Result) {
+	if opts.Observer != nil {
+		opts.Observer.JobFinished(result)
+	}
+}
@@ -0,0 +1,215 @@
+// Package scaffold renders the files `generate command` adds to a project
+// built from this template: a cobra command, its handler, a handler test,
+// and the registration file that wires every scaffolded command into the
+// root command. The templates are embedded from templates/.
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/*.tmpl
+var files embed.FS
+
+var templates = template.Must(template.ParseFS(files, "templates/*.tmpl"))
+
+// RegistrationFile is the generated file, relative to the project root,
+// that lists the scaffolded commands.
+const RegistrationFile = "cmd/commands_generated.go"
+
+// Marker is the directive that marks a command constructor for
+// registration.
+const Marker = "//gocli:command"
+
+var (
+	namePattern   = regexp.MustCompile(`^[a-z][a-z0-9]*(-[a-z0-9]+)*$`)
+	modulePattern = regexp.MustCompile(`(?m)^module\s+(\S+)`)
+	markedFunc    = regexp.MustCompile(`(?m)^` + Marker + `\s*\nfunc (new[A-Za-z0-9]*Command)\(\)`)
+)
+
+// File is one rendered file. Path is relative to the project root.
+type File struct {
+	Path    string
+	Content []byte
+}
+
+// Command describes the command to scaffold.
+type Command struct {
+	// Name is the kebab-case command name, e.g. "sync-users".
+	Name string
+	// App is the program name used in comments.
+	App string
+	// Module is the Go module path of the project.
+	Module string
+}
+
+// Camel returns Name in CamelCase, e.g. "SyncUsers".
+func (c Command) Camel() string {
+	var b strings.Builder
+	for _, part := range strings.Split(c.Name, "-") {
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// base is the file name stem shared by the command and handler files.
+func (c Command) base() string {
+	return strings.ReplaceAll(c.Name, "-", "")
+}
+
+// ValidateName rejects names that are not lower-case kebab-case.
+func ValidateName(name string) error {
+	if !namePattern.MatchString(name) {
+		return fmt.Errorf("invalid command name %q (use lower-case letters, digits, and single hyphens, starting with a letter)", name)
+	}
+	return nil
+}
+
+// FindRoot returns the nearest directory at or above dir that holds a
+// go.mod and the cmd and internal/app packages, and its module path.
+func FindRoot(dir string) (root, module string, err error) {
+	dir, err = filepath.Abs(dir)
+	if err != nil {
+		return "", "", err
+	}
+	for {
+		data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			m := modulePattern.FindSubmatch(data)
+			if m == nil {
+				return "", "", fmt.Errorf("%s: no module directive", filepath.Join(dir, "go.mod"))
+			}
+			for _, pkg := range []string{"cmd", filepath.Join("internal", "app")} {
+				if info, err := os.Stat(filepath.Join(dir, pkg)); err != nil || !info.IsDir() {
+					return "", "", fmt.Errorf("%s has no %s package; run generate in a project built from this template", dir, filepath.ToSlash(pkg))
+				}
+			}
+			return dir, string(m[1]), nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", errors.New("no go.mod found in this directory or its parents")
+		}
+		dir = parent
+	}
+}
+
+// Render returns the command, handler, and test files for c.
+func Render(c Command) ([]File, error) {
+	outputs := []struct{ tmpl, path string }{
+		{"command.go.tmpl", filepath.Join("cmd", c.base()+".go")},
+		{"handler.go.tmpl", filepath.Join("internal", "app", c.base()+".go")},
+		{"handler_test.go.tmpl", filepath.Join("internal", "app", c.base()+"_test.go")},
+	}
+	out := make([]File, 0, len(outputs))
+	for _, o := range outputs {
+		content, err := render(o.tmpl, c)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, File{Path: o.path, Content: content})
+	}
+	return out, nil
+}
+
+// Registration renders the registration file for the marked constructors
+// found in root's cmd package, plus any extra constructors about to be
+// written.
+func Registration(root, app string, extra ...string) (File, error) {
+	constructors, err := MarkedConstructors(root)
+	if err != nil {
+		return File{}, err
+	}
+	for _, name := range extra {
+		if !slices.Contains(constructors, name) {
+			constructors = append(constructors, name)
+		}
+	}
+	sort.Strings(constructors)
+	content, err := render("registration.go.tmpl", struct {
+		App          string
+		Constructors []string
+	}{app, constructors})
+	if err != nil {
+		return File{}, err
+	}
+	return File{Path: filepath.FromSlash(RegistrationFile), Content: content}, nil
+}
+
+// MarkedConstructors lists the constructors in root's cmd package that
+// carry Marker.
+func MarkedConstructors(root string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(root, "cmd", "*.go"))
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range markedFunc.FindAllSubmatch(data, -1) {
+			names = append(names, string(m[1]))
+		}
+	}
+	return names, nil
+}
+
+// Constructor is the name of the function that builds c.
+func (c Command) Constructor() string {
+	return "new" + c.Camel() + "Command"
+}
+
+func render(name string, data any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, name, data); err != nil {
+		return nil, fmt.Errorf("render %s: %w", name, err)
+	}
+	formatted, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format %s: %w", name, err)
+	}
+	return formatted, nil
+}
+
+// Defined lists the files in root that already define c's constructor or
+// handler.
+func Defined(root string, c Command) ([]string, error) {
+	checks := []struct{ dir, decl string }{
+		{"cmd", "func " + c.Constructor() + "("},
+		{filepath.Join("internal", "app"), "func Handle" + c.Camel() + "("},
+	}
+	var found []string
+	for _, check := range checks {
+		paths, err := filepath.Glob(filepath.Join(root, check.dir, "*.go"))
+		if err != nil {
+			return nil, err
+		}
+		for _, path := range paths {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return nil, err
+			}
+			if bytes.Contains(data, []byte(check.decl)) {
+				rel, _ := filepath.Rel(root, path)
+				found = append(found, rel)
+			}
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,273 @@
+package statestore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// schema creates the tables; user_version records how far it has been
+// applied, so later versions can append migrations.
+var schema = []string{
+	`CREATE TABLE runs (
+		id          TEXT PRIMARY KEY,
+		task        TEXT NOT NULL,
+		profile     TEXT NOT NULL,
+		status      TEXT NOT NULL,
+		started     INTEGER NOT NULL,
+		duration_ms INTEGER NOT NULL,
+		exit_code   INTEGER NOT NULL,
+		data        BLOB NOT NULL
+	);
+	CREATE INDEX runs_started ON runs (started);
+	CREATE TABLE checkpoints (
+		key     TEXT PRIMARY KEY,
+		data    BLOB NOT NULL,
+		updated INTEGER NOT NULL
+	);
+	CREATE TABLE schedules (
+		seq     INTEGER PRIMARY KEY AUTOINCREMENT,
+		id      TEXT NOT NULL UNIQUE,
+		cron    TEXT NOT NULL,
+		task    TEXT NOT NULL,
+		profile TEXT NOT NULL,
+		created INTEGER NOT NULL
+	);`,
+}
+
+// busyTimeout is how long a statement waits for another process's write
+// transaction before failing.
+const busyTimeout = 10 * time.Second
+
+// SQLite is the Store backed by a database file.
+type SQLite struct {
+	db *sql.DB
+}
+
+// Open opens the database at path, creating it and bringing its schema up
+// to date as needed.
+func Open(path string) (*SQLite, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	// Built as a URL so ?, #, and % in the path are escaped rather than
+	// read as the start of the parameters. A drive letter needs a leading
+	// slash (file:///C:/...) so it is not taken for the host.
+	slashed := filepath.ToSlash(path)
+	if filepath.VolumeName(path) != "" {
+		slashed = "/" + slashed
+	}
+	dsn := url.URL{Scheme: "file", Path: slashed, RawQuery: url.Values{
+		"_pragma": {fmt.Sprintf("busy_timeout(%d)", busyTimeout.Milliseconds()), "journal_mode(wal)", "foreign_keys(1)"},
+	}.Encode()}
+	db, err := sql.Open("sqlite", dsn.String())
+	if err != nil {
+		return nil, err
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("open state database %s: %w", path, err)
+	}
+	return &SQLite{db: db}, nil
+}
+
+// migrate applies the missing schema steps. BEGIN IMMEDIATE takes the write
+// lock up front, so two processes opening a new database do not both try.
+func migrate(db *sql.DB) (err error) {
+	ctx := context.Background()
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			conn.ExecContext(ctx, "ROLLBACK")
+		}
+	}()
+	var version int
+	if err := conn.QueryRowContext(ctx, "PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > len(schema) {
+		return fmt.Errorf("schema version %d is newer than this build supports (%d)", version, len(schema))
+	}
+	for _, step := range schema[version:] {
+		if _, err := conn.ExecContext(ctx, step); err != nil {
+			return err
+		}
+	}
+	if _, err := conn.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", len(schema))); err != nil {
+		return err
+	}
+	_, err = conn.ExecContext(ctx, "COMMIT")
+	return err
+}
+
+// Close implements Store.
+func (s *SQLite) Close() error { return s.db.Close() }
+
+// AddRun implements Store.
+func (s *SQLite) AddRun(ctx context.Context, run Run) error {
+	_, err := s.db.ExecContext(ctx,
+		`INSERT OR REPLACE INTO runs (id, task, profile, status, started, duration_ms, exit_code, data) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		run.ID, run.Task, run.Profile, run.Status, run.Started.UnixMilli(), run.DurationMS, run.ExitCode, run.Data)
+	return err
+}
+
+// Runs implements Store.
+func (s *SQLite) Runs(ctx context.Context, q Query) ([]Run, error) {
+	var terms []string
+	var args []any
+	for _, c := range q.Where {
+		cl, err := c.sql()
+		if err != nil {
+			return nil, err
+		}
+		terms = append(terms, cl.text)
+		args = append(args, cl.arg)
+	}
+	query := `SELECT id, task, profile, status, started, duration_ms, exit_code, data FROM runs`
+	if len(terms) > 0 {
+		query += " WHERE " + strings.Join(terms, " AND ")
+	}
+	query += " ORDER BY started DESC, id DESC"
+	if q.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", q.Limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []Run
+	for rows.Next() {
+		var r Run
+		var started int64
+		if err := rows.Scan(&r.ID, &r.Task, &r.Profile, &r.Status, &started, &r.DurationMS, &r.ExitCode, &r.Data); err != nil {
+			return nil, err
+		}
+		r.Started = time.UnixMilli(started).UTC()
+		runs = append(runs, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// Oldest first, like the history file this replaces.
+	for i, j := 0, len(runs)-1; i < j; i, j = i+1, j-1 {
+		runs[i], runs[j] = runs[j], runs[i]
+	}
+	return runs, nil
+}
+
+// DeleteRuns implements Store.
+func (s *SQLite) DeleteRuns(ctx context.Context, ids []string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, id := range ids {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM runs WHERE id = ?`, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Checkpoint implements Store.
+func (s *SQLite) Checkpoint(ctx context.Context, key string) (*Record, error) {
+	rec := Record{Key: key}
+	var updated int64
+	err := s.db.QueryRowContext(ctx, `SELECT data, updated FROM checkpoints WHERE key = ?`, key).Scan(&rec.Data, &updated)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	rec.Updated = time.UnixMilli(updated).UTC()
+	return &rec, nil
+}
+
+// Checkpoints implements Store.
+func (s *SQLite) Checkpoints(ctx context.Context) ([]Record, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT key, data, updated FROM checkpoints ORDER BY key`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var records []Record
+	for rows.Next() {
+		var rec Record
+		var updated int64
+		if err := rows.Scan(&rec.Key, &rec.Data, &updated); err != nil {
+			return nil, err
+		}
+		rec.Updated = time.UnixMilli(updated).UTC()
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
+// PutCheckpoint implements Store.
+func (s *SQLite) PutCheckpoint(ctx context.Context, rec Record) error {
+	_, err := s.db.ExecContext(ctx, `INSERT OR REPLACE INTO checkpoints (key, data, updated) VALUES (?, ?, ?)`,
+		rec.Key, rec.Data, rec.Updated.UnixMilli())
+	return err
+}
+
+// DeleteCheckpoint implements Store.
+func (s *SQLite) DeleteCheckpoint(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, `DELETE FROM checkpoints WHERE key = ?`, key)
+	return err
+}
+
+// Schedules implements Store.
+func (s *SQLite) Schedules(ctx context.Context) ([]Schedule, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, cron, task, profile, created FROM schedules ORDER BY seq`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var schedules []Schedule
+	for rows.Next() {
+		var sch Schedule
+		var created int64
+		if err := rows.Scan(&sch.ID, &sch.Cron, &sch.Task, &sch.Profile, &created); err != nil {
+			return nil, err
+		}
+		sch.Created = time.UnixMilli(created).UTC()
+		schedules = append(schedules, sch)
+	}
+	return schedules, rows.Err()
+}
+
+// AddSchedule implements Store.
+func (s *SQLite) AddSchedule(ctx context.Context, sch Schedule) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO schedules (id, cron, task, profile, created) VALUES (?, ?, ?, ?, ?)`,
+		sch.ID, sch.Cron, sch.Task, sch.Profile, sch.Created.UnixMilli())
+	return err
+}
+
+// RemoveSchedule implements Store.
+func (s *SQLite) RemoveSchedule(ctx context.Context, id string) (bool, error) {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM schedules WHERE id = ?`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
@@ -0,0 +1,41 @@
+package statestore
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOpenEscapesPath(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "state?mode=ro#50%")
+	path := filepath.Join(dir, FileName)
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	if err := store.AddRun(ctx, Run{ID: "r1", Task: "ci", Started: time.Now(), Data: []byte("{}")}); err != nil {
+		t.Fatalf("AddRun: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("database not at %s: %v", path, err)
+	}
+	var mode string
+	if err := store.db.QueryRowContext(ctx, "PRAGMA journal_mode").Scan(&mode); err != nil || mode != "wal" {
+		t.Errorf("journal_mode = %q, %v; want wal", mode, err)
+	}
+}
+
+func TestConditionRejectsUnknownOperator(t *testing.T) {
+	c := Condition{Field: "task", Op: "= 'x' OR 1 =", Value: "ci"}
+	if _, err := c.sql(); err == nil {
+		t.Error("sql() accepted an operator outside ops")
+	}
+	if c.Matches(Run{Task: "ci"}) {
+		t.Error("Matches accepted an operator outside ops")
+	}
+}
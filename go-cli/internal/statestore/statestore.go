@@ -0,0 +1,212 @@
+// Package statestore keeps the CLI's bookkeeping (run history, resume
+// checkpoints, and schedules) in an embedded SQLite database, so updates
+// are transactional and the history can be queried. The driver is
+// modernc.org/sqlite, which needs no cgo.
+package statestore
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FileName is the database file inside the state directory.
+const FileName = "state.db"
+
+// Store holds the state. Implementations are safe for concurrent use,
+// also by several processes.
+type Store interface {
+	// AddRun records a finished run; a run already recorded is replaced.
+	AddRun(ctx context.Context, run Run) error
+	// Runs returns the recorded runs matching q, oldest first.
+	Runs(ctx context.Context, q Query) ([]Run, error)
+	// DeleteRuns removes the runs with the given IDs in one transaction.
+	DeleteRuns(ctx context.Context, ids []string) error
+
+	// Checkpoint returns the checkpoint stored under key, or nil.
+	Checkpoint(ctx context.Context, key string) (*Record, error)
+	// Checkpoints returns all checkpoints, by key.
+	Checkpoints(ctx context.Context) ([]Record, error)
+	PutCheckpoint(ctx context.Context, rec Record) error
+	// DeleteCheckpoint removes a checkpoint; a missing one is not an error.
+	DeleteCheckpoint(ctx context.Context, key string) error
+
+	// Schedules returns the schedules in the order they were added.
+	Schedules(ctx context.Context) ([]Schedule, error)
+	AddSchedule(ctx context.Context, s Schedule) error
+	// RemoveSchedule deletes a schedule and reports whether it existed.
+	RemoveSchedule(ctx context.Context, id string) (bool, error)
+
+	Close() error
+}
+
+// Run is one entry of the run history. The columns are copied out of Data,
+// the complete entry as JSON, so runs can be filtered without decoding it.
+type Run struct {
+	ID         string
+	Task       string
+	Profile    string
+	Status     string
+	Started    time.Time
+	DurationMS int64
+	ExitCode   int
+	Data       []byte
+}
+
+// Record is a JSON document kept under a key, such as a checkpoint.
+type Record struct {
+	Key     string
+	Data    []byte
+	Updated time.Time
+}
+
+// Schedule is a task registered to run on a cron schedule.
+type Schedule struct {
+	ID      string
+	Cron    string
+	Task    string
+	Profile string
+	Created time.Time
+}
+
+// Query selects runs. The zero Query selects all of them.
+type Query struct {
+	// Where holds conditions that must all hold.
+	Where []Condition
+	// Limit keeps only the newest Limit matches; 0 keeps all.
+	Limit int
+}
+
+// Condition compares a run column with a value, e.g. status=failed.
+type Condition struct {
+	Field string
+	Op    string
+	Value string
+}
+
+// Fields that conditions may test, with the column each maps to.
+var fields = map[string]string{
+	"id":          "id",
+	"task":        "task",
+	"profile":     "profile",
+	"status":      "status",
+	"exit_code":   "exit_code",
+	"duration_ms": "duration_ms",
+	"started":     "started",
+}
+
+// ops lists the comparison operators, longest first so ParseCondition
+// finds ">=" before ">".
+var ops = []string{"!=", ">=", "<=", "=", ">", "<", "~"}
+
+// ParseCondition parses FIELD OP VALUE, where OP is =, !=, <, <=, >, >=, or
+// ~ (contains). Fields are id, task, profile, status, exit_code,
+// duration_ms, and started; started takes an RFC 3339 time or a date.
+func ParseCondition(expr string) (Condition, error) {
+	for i := 0; i < len(expr); i++ {
+		for _, op := range ops {
+			if !strings.HasPrefix(expr[i:], op) {
+				continue
+			}
+			c := Condition{Field: strings.TrimSpace(expr[:i]), Op: op, Value: strings.TrimSpace(expr[i+len(op):])}
+			if _, err := c.sql(); err != nil {
+				return Condition{}, err
+			}
+			return c, nil
+		}
+	}
+	return Condition{}, fmt.Errorf("invalid condition %q (expected FIELD=VALUE, or another operator: != < <= > >= ~)", expr)
+}
+
+// sql renders the condition as a WHERE clause term and its argument.
+func (c Condition) sql() (clause, error) {
+	column, ok := fields[c.Field]
+	if !ok {
+		return clause{}, fmt.Errorf("unknown field %q in condition (expected id, task, profile, status, exit_code, duration_ms, or started)", c.Field)
+	}
+	// The operator goes into the SQL text, so only the known ones pass.
+	if !slices.Contains(ops, c.Op) {
+		return clause{}, fmt.Errorf("unknown operator %q in condition (expected =, !=, <, <=, >, >=, or ~)", c.Op)
+	}
+	var arg any = c.Value
+	switch column {
+	case "exit_code", "duration_ms":
+		n, err := strconv.ParseInt(c.Value, 10, 64)
+		if err != nil {
+			return clause{}, fmt.Errorf("invalid %s %q (expected a number)", c.Field, c.Value)
+		}
+		arg = n
+	case "started":
+		t, err := parseTime(c.Value)
+		if err != nil {
+			return clause{}, err
+		}
+		arg = t.UnixMilli()
+	}
+	if c.Op == "~" {
+		if _, ok := arg.(string); !ok {
+			return clause{}, fmt.Errorf("~ only applies to text fields, not %s", c.Field)
+		}
+		return clause{text: "instr(" + column + ", ?) > 0", arg: arg}, nil
+	}
+	return clause{text: column + " " + c.Op + " ?", arg: arg}, nil
+}
+
+// Matches reports whether run satisfies the condition, with the same
+// semantics as the database; for runs kept elsewhere.
+func (c Condition) Matches(run Run) bool {
+	cl, err := c.sql()
+	if err != nil {
+		return false
+	}
+	switch want := cl.arg.(type) {
+	case string:
+		have := map[string]string{"id": run.ID, "task": run.Task, "profile": run.Profile, "status": run.Status}[c.Field]
+		if c.Op == "~" {
+			return strings.Contains(have, want)
+		}
+		return compare(strings.Compare(have, want), c.Op)
+	case int64:
+		have := map[string]int64{"exit_code": int64(run.ExitCode), "duration_ms": run.DurationMS, "started": run.Started.UnixMilli()}[c.Field]
+		return compare(cmp.Compare(have, want), c.Op)
+	}
+	return false
+}
+
+func compare(order int, op string) bool {
+	switch op {
+	case "=":
+		return order == 0
+	case "!=":
+		return order != 0
+	case "<":
+		return order < 0
+	case "<=":
+		return order <= 0
+	case ">":
+		return order > 0
+	case ">=":
+		return order >= 0
+	}
+	return false
+}
+
+type clause struct {
+	text string
+	arg  any
+}
+
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, errors.New("invalid started " + strconv.Quote(value) + " (expected an RFC 3339 time or YYYY-MM-DD)")
+}
@@ -0,0 +1,150 @@
+// Package stdinutil reads payloads piped to the CLI, as in
+// `cat payload.json | go-cli run import`: it tells a pipe or redirected
+// file from an interactive terminal, reads at most a given number of
+// bytes, and recognizes JSON and YAML documents so handlers can decode
+// them without being told the format.
+package stdinutil
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format is the kind of document a payload holds.
+type Format string
+
+// Formats recognized by Detect. FormatAuto is not a payload format; it
+// asks Read to accept whatever arrives.
+const (
+	FormatAuto Format = "auto"
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+	FormatText Format = "text"
+)
+
+// ParseFormat checks a format name as written in configuration.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(name); f {
+	case FormatAuto, FormatJSON, FormatYAML, FormatText:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown input format %q (expected auto, json, yaml, or text)", name)
+}
+
+// ErrTooLarge reports a payload longer than the limit given to Read.
+var ErrTooLarge = errors.New("input too large")
+
+// Piped reports whether f is a pipe or a redirected file rather than a
+// terminal or a device such as /dev/null, i.e. whether reading it yields
+// data someone meant to hand over.
+func Piped(f *os.File) bool {
+	if f == nil {
+		return false
+	}
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeNamedPipe != 0 || info.Mode().IsRegular()
+}
+
+// Payload is a document read from standard input.
+type Payload struct {
+	Data   []byte
+	Format Format
+}
+
+// Read reads all of r, up to limit bytes, and detects its format. When
+// want is a specific format the payload must have it: YAML accepts JSON,
+// which is a subset of it, and text accepts anything that is valid UTF-8.
+func Read(r io.Reader, limit int64, want Format) (*Payload, error) {
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w (limit %d bytes)", ErrTooLarge, limit)
+	}
+	// A byte order mark would trip the JSON decoder.
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+	p := &Payload{Data: data, Format: Detect(data)}
+	switch want {
+	case "", FormatAuto:
+	case FormatText:
+		if !utf8.Valid(data) {
+			return nil, errors.New("input is not text (invalid UTF-8)")
+		}
+		p.Format = FormatText
+	case FormatYAML:
+		if p.Format == FormatText {
+			return nil, errors.New("input is not a YAML document")
+		}
+	case FormatJSON:
+		if p.Format != FormatJSON {
+			return nil, errors.New("input is not a JSON document")
+		}
+	default:
+		return nil, fmt.Errorf("unknown input format %q", want)
+	}
+	return p, nil
+}
+
+// Detect names the format of data: JSON for a valid JSON object or array,
+// YAML for a mapping or sequence, else text. Scalars count as text, so a
+// plain line is never mistaken for a YAML string.
+func Detect(data []byte) Format {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) == 0 {
+		return FormatText
+	}
+	if (trimmed[0] == '{' || trimmed[0] == '[') && json.Valid(trimmed) {
+		return FormatJSON
+	}
+	var v any
+	if err := yaml.Unmarshal(trimmed, &v); err == nil {
+		switch v.(type) {
+		case map[string]any, []any:
+			return FormatYAML
+		}
+	}
+	return FormatText
+}
+
+// Decode returns the payload as a value: maps, slices, and scalars for
+// JSON and YAML, the string itself for text.
+func (p *Payload) Decode() (any, error) {
+	var v any
+	err := p.Unmarshal(&v)
+	if errors.Is(err, errText) {
+		return string(p.Data), nil
+	}
+	return v, err
+}
+
+var errText = errors.New("a text payload cannot be unmarshaled")
+
+// Unmarshal decodes a JSON or YAML payload into v.
+func (p *Payload) Unmarshal(v any) error {
+	switch p.Format {
+	case FormatJSON:
+		return json.Unmarshal(p.Data, v)
+	case FormatYAML:
+		return yaml.Unmarshal(p.Data, v)
+	}
+	return errText
+}
+
+// Digest is a hash of the payload, for telling payloads apart.
+func (p *Payload) Digest() string {
+	sum := sha256.Sum256(p.Data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,45 @@
+// Package storage persists run data (artifacts, manifests, and history
+// entries) beyond the machine that produced it. A Store holds objects
+// under slash-separated keys such as runs/<id>/manifest.json; Local keeps
+// them in a directory, S3 in a bucket of any S3-compatible service.
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// Store is a flat namespace of objects.
+type Store interface {
+	// Put creates or replaces the object key with the content of r.
+	Put(ctx context.Context, key string, r io.ReadSeeker) error
+	// Get opens the object key; a missing object yields an error
+	// matching fs.ErrNotExist.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// List returns the objects whose keys start with prefix, sorted by
+	// key.
+	List(ctx context.Context, prefix string) ([]Object, error)
+	// Delete removes the object key; a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+	// String describes where the objects live, for messages.
+	String() string
+}
+
+// Object describes a stored object.
+type Object struct {
+	Key      string
+	Size     int64
+	Modified time.Time
+}
+
+// checkKey rejects keys that could escape the store's root.
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || key == ".." || strings.HasPrefix(key, "../") {
+		return fmt.Errorf("invalid storage key %q", key)
+	}
+	return nil
+}
@@ -0,0 +1,22 @@
+// Package sysload samples system load average and memory availability so
+// the worker pool can back off when the machine is overloaded.
+package sysload
+
+import "errors"
+
+// ErrUnsupported is returned by Sample on platforms without a load average.
+var ErrUnsupported = errors.New("system load sampling is not supported on this platform")
+
+// Load is a snapshot of system pressure.
+type Load struct {
+	// Load1 is the one-minute load average.
+	Load1 float64
+	// MemAvailable is the fraction of physical memory available to new
+	// work, between 0 and 1, or -1 when unknown.
+	MemAvailable float64
+}
+
+// Sample reads the current system load.
+func Sample() (Load, error) {
+	return sample()
+}
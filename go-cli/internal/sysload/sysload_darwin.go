@@ -0,0 +1,32 @@
+package sysload
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"golang.org/x/sys/unix"
+)
+
+func sample() (Load, error) {
+	// struct loadavg { fixpt_t ldavg[3]; long fscale; }, padded to 8 bytes.
+	raw, err := unix.SysctlRaw("vm.loadavg")
+	if err != nil {
+		return Load{}, err
+	}
+	if len(raw) < 24 {
+		return Load{}, fmt.Errorf("unexpected vm.loadavg size %d", len(raw))
+	}
+	fscale := binary.LittleEndian.Uint64(raw[16:24])
+	if fscale == 0 {
+		return Load{}, fmt.Errorf("vm.loadavg reports zero scale")
+	}
+	load := Load{
+		Load1:        float64(binary.LittleEndian.Uint32(raw[0:4])) / float64(fscale),
+		MemAvailable: -1,
+	}
+	// Percentage of memory the kernel considers available.
+	if level, err := unix.SysctlUint32("kern.memorystatus_level"); err == nil && level <= 100 {
+		load.MemAvailable = float64(level) / 100
+	}
+	return load, nil
+}
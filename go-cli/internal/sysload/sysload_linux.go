@@ -0,0 +1,57 @@
+package sysload
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+func sample() (Load, error) {
+	data, err := os.ReadFile("/proc/loadavg")
+	if err != nil {
+		return Load{}, err
+	}
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return Load{}, fmt.Errorf("unexpected /proc/loadavg content %q", data)
+	}
+	load1, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return Load{}, fmt.Errorf("parse /proc/loadavg: %w", err)
+	}
+	return Load{Load1: load1, MemAvailable: memAvailable()}, nil
+}
+
+// memAvailable reads MemAvailable/MemTotal from /proc/meminfo, or -1.
+func memAvailable() float64 {
+	f, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return -1
+	}
+	defer f.Close()
+
+	var total, avail float64
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "MemTotal:":
+			total = value
+		case "MemAvailable:":
+			avail = value
+		}
+	}
+	if total <= 0 || avail <= 0 {
+		return -1
+	}
+	return avail / total
+}
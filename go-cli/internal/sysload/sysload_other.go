@@ -0,0 +1,7 @@
+//go:build !linux && !darwin
+
+package sysload
+
+func sample() (Load, error) {
+	return Load{}, ErrUnsupported
+}
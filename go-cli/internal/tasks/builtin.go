@@ -0,0 +1,80 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/runner"
+)
+
+func init() {
+	Register(Func{
+		TaskName: "default",
+		Summary:  "Demo task that simulates a short unit of work.",
+		Fn: func(ctx context.Context, rtx *app.RuntimeContext) error {
+			rtx.TaskLogger(ctx).Info("default task running under profile %s", rtx.Config.Profile)
+			return sleep(ctx, 100*time.Millisecond)
+		},
+	})
+
+	// A small dependency graph showing parallel branches: fetch runs first,
+	// lint and test run concurrently, and ci waits for both.
+	// Each declares the actions `run --plan` shows for it.
+	Register(simulated("fetch", "Demo: fetch inputs.", 150*time.Millisecond, nil,
+		runner.Action{Kind: runner.ActionCreate, Target: "inputs/", Detail: "download sources"}))
+	Register(simulated("lint", "Demo: lint the fetched inputs.", 200*time.Millisecond, []string{"fetch"},
+		runner.Action{Kind: runner.ActionRun, Target: "linter", Detail: "read-only"}))
+	Register(simulated("test", "Demo: test the fetched inputs.", 300*time.Millisecond, []string{"fetch"},
+		runner.Action{Kind: runner.ActionRun, Target: "test suite"},
+		runner.Action{Kind: runner.ActionUpdate, Target: "reports/junit.xml"}))
+	// ci also shows how tasks publish outputs to the run's artifacts.
+	ci := simulated("ci", "Demo: aggregate lint and test.", 50*time.Millisecond, []string{"lint", "test"},
+		runner.Action{Kind: runner.ActionCreate, Target: "reports/summary.json"},
+		runner.Action{Kind: runner.ActionDelete, Target: "inputs/", Detail: "clean up"})
+	simulate := ci.Fn
+	ci.Fn = func(ctx context.Context, rtx *app.RuntimeContext) error {
+		if err := simulate(ctx, rtx); err != nil {
+			return err
+		}
+		w, err := rtx.ArtifactWriter("reports/summary.json")
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "{\"profile\": %q, \"passed\": [\"lint\", \"test\"]}\n", rtx.Config.Profile); err != nil {
+			w.Close()
+			return err
+		}
+		return w.Close()
+	}
+	Register(ci)
+}
+
+// simulated returns a demo task that waits for d and plans actions.
+func simulated(name, summary string, d time.Duration, deps []string, actions ...runner.Action) Func {
+	return Func{
+		TaskName: name,
+		Summary:  summary,
+		Deps:     deps,
+		Fn: func(ctx context.Context, rtx *app.RuntimeContext) error {
+			rtx.TaskLogger(ctx).Debug("%s: simulating %s of work", name, d)
+			return sleep(ctx, d)
+		},
+		PlanFn: func(context.Context, *app.RuntimeContext) ([]runner.Action, error) {
+			return actions, nil
+		},
+	}
+}
+
+// sleep waits for d or until ctx is canceled.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
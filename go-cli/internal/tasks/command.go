@@ -0,0 +1,101 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"sort"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/execx"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/runner"
+)
+
+// Command is a task declared in tasks.toml or the config's [tasks] table. It
+// runs its shell commands in order and fails on the first non-zero exit.
+type Command struct {
+	TaskName string
+	Spec     app.TaskSpec
+}
+
+// Name implements Task.
+func (c Command) Name() string { return c.TaskName }
+
+// Description implements Describer.
+func (c Command) Description() string { return c.Spec.Description }
+
+// Dependencies implements Depender.
+func (c Command) Dependencies() []string { return c.Spec.Deps }
+
+// Params implements Parameterized.
+func (c Command) Params() map[string]app.ParamSpec { return c.Spec.Params }
+
+// Input implements InputTaker.
+func (c Command) Input() string { return c.Spec.Input }
+
+// Priority implements Prioritizer.
+func (c Command) Priority() int { return c.Spec.Priority }
+
+// Plan implements Planner.
+func (c Command) Plan(_ context.Context, rtx *app.RuntimeContext) ([]runner.Action, error) {
+	actions := make([]runner.Action, 0, len(c.Spec.Cmds))
+	for _, line := range c.Spec.Cmds {
+		line, err := rtx.Params.Expand(line)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, runner.Action{Kind: runner.ActionRun, Target: line, Detail: c.Spec.Dir})
+	}
+	return actions, nil
+}
+
+// Fingerprint implements Fingerprinter. Tasks without declared inputs
+// always run; a piped payload counts as one of the inputs.
+func (c Command) Fingerprint(_ context.Context, rtx *app.RuntimeContext) (string, error) {
+	if len(c.Spec.Inputs) == 0 {
+		return "", nil
+	}
+	fp, err := c.Spec.Fingerprint(rtx.Params)
+	if err != nil || rtx.Input == nil {
+		return fp, err
+	}
+	return fp + "+" + rtx.Input.Digest(), nil
+}
+
+// Run implements Task. {{.name}} references in the commands are replaced
+// with run parameters. Command output is logged line by line, prefixed with
+// the task name, so parallel tasks stay readable, and also goes to the
+// task's own log file.
+func (c Command) Run(ctx context.Context, rtx *app.RuntimeContext) error {
+	log := rtx.TaskLogger(ctx)
+	for _, line := range c.Spec.Cmds {
+		line, err := rtx.Params.Expand(line)
+		if err != nil {
+			return err
+		}
+		cmd := rtx.Command(line)
+		cmd.Dir = c.Spec.Dir
+		cmd.Env = append(append(append([]string(nil), c.Spec.Env...), rtx.Params.Environ()...), rtx.InputEnviron()...)
+		if rtx.Input != nil {
+			cmd.Stdin = bytes.NewReader(rtx.Input.Data)
+		}
+		cmd.Logger = log
+		cmd.OnLine = func(text string) { log.Info("%s | %s", c.TaskName, text) }
+		if _, err := execx.Run(ctx, cmd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RegisterDeclared adds command tasks to r. A declared task replaces a
+// built-in of the same name, so projects can redefine "default".
+func RegisterDeclared(r *Registry, specs map[string]app.TaskSpec) {
+	names := make([]string, 0, len(specs))
+	for name := range specs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		r.Replace(Command{TaskName: name, Spec: specs[name]})
+	}
+}
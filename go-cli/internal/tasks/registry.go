@@ -0,0 +1,338 @@
+// Package tasks holds the named units of work that `run [TASK]` dispatches.
+// Add behaviour by registering a Task instead of editing app.HandleRun.
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/runner"
+)
+
+// Task is a named unit of work executed by the runner.
+type Task interface {
+	Name() string
+	Run(ctx context.Context, rtx *app.RuntimeContext) error
+}
+
+// Describer is implemented by tasks that provide a one-line description.
+type Describer interface {
+	Description() string
+}
+
+// Depender is implemented by tasks that must run after other tasks.
+type Depender interface {
+	Dependencies() []string
+}
+
+// Planner is implemented by tasks that can describe the changes they would
+// make. `run --plan` and `--dry-run` call Plan instead of Run.
+type Planner interface {
+	Plan(ctx context.Context, rtx *app.RuntimeContext) ([]runner.Action, error)
+}
+
+// Fingerprinter is implemented by tasks that can summarize their inputs.
+// `run` skips such a task while its fingerprint matches the one recorded
+// when it last succeeded, unless --force is given. An empty fingerprint
+// means the task has nothing to compare and always runs.
+type Fingerprinter interface {
+	Fingerprint(ctx context.Context, rtx *app.RuntimeContext) (string, error)
+}
+
+// Parameterized is implemented by tasks that accept run --param values.
+// Read the validated values from RuntimeContext.Params.
+type Parameterized interface {
+	Params() map[string]app.ParamSpec
+}
+
+// InputTaker is implemented by tasks that read a payload piped to run.
+// Input names the format they expect (auto, json, yaml, or text); read the
+// payload from RuntimeContext.Input, which is nil when stdin is a terminal.
+type InputTaker interface {
+	Input() string
+}
+
+// Prioritizer is implemented by tasks with a default queue priority. The
+// runtime.priority config and run --priority override it.
+type Prioritizer interface {
+	Priority() int
+}
+
+// Registry maps task names to implementations. It is safe for concurrent use.
+type Registry struct {
+	mu    sync.RWMutex
+	tasks map[string]Task
+}
+
+// NewRegistry returns an empty registry.
+func NewRegistry() *Registry {
+	return &Registry{tasks: map[string]Task{}}
+}
+
+// Default is the registry used by the CLI; built-in tasks register here.
+var Default = NewRegistry()
+
+// Register adds t to the default registry and panics on duplicates, which
+// are programming errors. Call it from init functions.
+func Register(t Task) {
+	if err := Default.Register(t); err != nil {
+		panic(err)
+	}
+}
+
+// Register adds t to the registry.
+func (r *Registry) Register(t Task) error {
+	name := t.Name()
+	if name == "" {
+		return fmt.Errorf("task name must not be empty")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, exists := r.tasks[name]; exists {
+		return fmt.Errorf("task %q is already registered", name)
+	}
+	r.tasks[name] = t
+	return nil
+}
+
+// Clone returns a registry holding the same tasks as r, to which tasks can
+// be added without changing r.
+func (r *Registry) Clone() *Registry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	clone := NewRegistry()
+	for name, t := range r.tasks {
+		clone.tasks[name] = t
+	}
+	return clone
+}
+
+// Replace registers t, replacing any task with the same name.
+func (r *Registry) Replace(t Task) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tasks[t.Name()] = t
+}
+
+// Lookup returns the task registered under name.
+func (r *Registry) Lookup(name string) (Task, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if t, ok := r.tasks[name]; ok {
+		return t, nil
+	}
+	return nil, fmt.Errorf("unknown task %q (available: %s)", name, strings.Join(r.namesLocked(), ", "))
+}
+
+// List returns all registered tasks sorted by name.
+func (r *Registry) List() []Task {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	list := make([]Task, 0, len(r.tasks))
+	for _, name := range r.namesLocked() {
+		list = append(list, r.tasks[name])
+	}
+	return list
+}
+
+// Resolve returns the named task together with everything it transitively
+// depends on, dependencies first. Cycles are reported by the runner.
+func (r *Registry) Resolve(name string) ([]Task, error) {
+	var ordered []Task
+	seen := map[string]bool{}
+
+	var visit func(name, requiredBy string) error
+	visit = func(name, requiredBy string) error {
+		if seen[name] {
+			return nil
+		}
+		seen[name] = true
+
+		t, err := r.Lookup(name)
+		if err != nil {
+			if requiredBy != "" {
+				return fmt.Errorf("task %q depends on unknown task %q", requiredBy, name)
+			}
+			return err
+		}
+		for _, dep := range Dependencies(t) {
+			if err := visit(dep, name); err != nil {
+				return err
+			}
+		}
+		ordered = append(ordered, t)
+		return nil
+	}
+
+	if err := visit(name, ""); err != nil {
+		return nil, err
+	}
+	return ordered, nil
+}
+
+// Infos describes every registered task for listing.
+func (r *Registry) Infos() []app.TaskInfo {
+	tasks := r.List()
+	infos := make([]app.TaskInfo, 0, len(tasks))
+	for _, t := range tasks {
+		infos = append(infos, Info(t))
+	}
+	return infos
+}
+
+func (r *Registry) namesLocked() []string {
+	names := make([]string, 0, len(r.tasks))
+	for name := range r.tasks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Info describes a single task.
+func Info(t Task) app.TaskInfo {
+	info := app.TaskInfo{
+		Name:         t.Name(),
+		Dependencies: Dependencies(t),
+	}
+	if d, ok := t.(Describer); ok {
+		info.Description = d.Description()
+	}
+	if p, ok := t.(Parameterized); ok {
+		info.Params = p.Params()
+	}
+	if in, ok := t.(InputTaker); ok {
+		info.Input = in.Input()
+	}
+	return info
+}
+
+// Dependencies returns the names t depends on, if it declares any.
+func Dependencies(t Task) []string {
+	if d, ok := t.(Depender); ok {
+		return d.Dependencies()
+	}
+	return nil
+}
+
+// ParamSchema merges the parameters declared by list, so a run accepts the
+// parameters of its task and every dependency. Two tasks declaring the same
+// parameter with different types conflict.
+func ParamSchema(list []Task) (map[string]app.ParamSpec, error) {
+	schema := map[string]app.ParamSpec{}
+	owner := map[string]string{}
+	for _, t := range list {
+		p, ok := t.(Parameterized)
+		if !ok {
+			continue
+		}
+		for name, spec := range p.Params() {
+			if prev, seen := schema[name]; seen {
+				if paramType(prev) != paramType(spec) {
+					return nil, fmt.Errorf("tasks %s and %s declare parameter %q with different types", owner[name], t.Name(), name)
+				}
+				spec.Required = spec.Required || prev.Required
+			}
+			schema[name], owner[name] = spec, t.Name()
+		}
+	}
+	return schema, nil
+}
+
+// InputFormat returns the input format the tasks in list expect, or ""
+// when none reads input. "auto" gives way to a specific format; two
+// different specific formats are an error.
+func InputFormat(list []Task) (string, error) {
+	format, owner := "", ""
+	for _, t := range list {
+		in, ok := t.(InputTaker)
+		if !ok || in.Input() == "" {
+			continue
+		}
+		switch want := in.Input(); {
+		case format == "" || format == "auto":
+			format, owner = want, t.Name()
+		case want != "auto" && want != format:
+			return "", fmt.Errorf("tasks %s and %s expect different input formats (%s and %s)", owner, t.Name(), format, want)
+		}
+	}
+	return format, nil
+}
+
+func paramType(spec app.ParamSpec) string {
+	if spec.Type == "" {
+		return app.ParamString
+	}
+	return spec.Type
+}
+
+// Jobs adapts tasks into runner jobs bound to rtx, preserving dependencies.
+func Jobs(rtx *app.RuntimeContext, list []Task) []runner.Job {
+	jobs := make([]runner.Job, 0, len(list))
+	for _, t := range list {
+		jobs = append(jobs, Job(rtx, t))
+	}
+	return jobs
+}
+
+// Job adapts a task into a runner job bound to rtx.
+func Job(rtx *app.RuntimeContext, t Task) runner.Job {
+	job := runner.Job{
+		Name: t.Name(),
+		Deps: Dependencies(t),
+		Run: func(ctx context.Context) error {
+			return t.Run(ctx, rtx)
+		},
+	}
+	if p, ok := t.(Planner); ok {
+		job.Plan = func(ctx context.Context) ([]runner.Action, error) {
+			return p.Plan(ctx, rtx)
+		}
+	}
+	if p, ok := t.(Prioritizer); ok {
+		job.Priority = p.Priority()
+	}
+	if f, ok := t.(Fingerprinter); ok {
+		job.Fingerprint = func(ctx context.Context) (string, error) {
+			return f.Fingerprint(ctx, rtx)
+		}
+	}
+	return job
+}
+
+// Func adapts a plain function into a Task.
+type Func struct {
+	TaskName string
+	Summary  string
+	Deps     []string
+	Fn       func(ctx context.Context, rtx *app.RuntimeContext) error
+	// PlanFn optionally describes what Fn would do; see Planner.
+	PlanFn func(ctx context.Context, rtx *app.RuntimeContext) ([]runner.Action, error)
+}
+
+// Name implements Task.
+func (f Func) Name() string { return f.TaskName }
+
+// Description implements Describer.
+func (f Func) Description() string { return f.Summary }
+
+// Dependencies implements Depender.
+func (f Func) Dependencies() []string { return f.Deps }
+
+// Run implements Task.
+func (f Func) Run(ctx context.Context, rtx *app.RuntimeContext) error {
+	return f.Fn(ctx, rtx)
+}
+
+// Plan implements Planner. Without a PlanFn the task plans a plain run.
+func (f Func) Plan(ctx context.Context, rtx *app.RuntimeContext) ([]runner.Action, error) {
+	if f.PlanFn == nil {
+		return nil, nil
+	}
+	return f.PlanFn(ctx, rtx)
+}
@@ -0,0 +1,104 @@
+package tasks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/remote"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/runner"
+)
+
+// RemoteJobs builds one job per remote that runs the resolved tasks of
+// task, in order, on that host over SSH (run --on). Only command tasks can
+// run remotely. The jobs are independent, so the runner's summary and exit
+// code aggregate the hosts: 3 when some failed and others succeeded.
+func RemoteJobs(rtx *app.RuntimeContext, task string, list []Task, remotes []app.Remote) ([]runner.Job, error) {
+	cmds := make([]Command, 0, len(list))
+	for _, t := range list {
+		c, ok := t.(Command)
+		if !ok {
+			return nil, app.UsageError(fmt.Errorf("task %q is built in and cannot run on a remote; only tasks declared with cmds can", t.Name()))
+		}
+		cmds = append(cmds, c)
+	}
+	jobs := make([]runner.Job, 0, len(remotes))
+	for _, r := range remotes {
+		jobs = append(jobs, runner.Job{
+			Name: task + "@" + r.Name,
+			Run: func(ctx context.Context) error {
+				return runRemote(ctx, rtx, r, cmds)
+			},
+			Plan: func(context.Context) ([]runner.Action, error) {
+				return planRemote(rtx, r, cmds)
+			},
+		})
+	}
+	return jobs, nil
+}
+
+// runRemote runs the commands of cmds on r over one connection, logging
+// output prefixed with the remote and task names.
+func runRemote(ctx context.Context, rtx *app.RuntimeContext, r app.Remote, cmds []Command) error {
+	log := rtx.TaskLogger(ctx)
+	client, err := remote.Dial(ctx, r.SSH())
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	log.Debug("connected to %s (%s)", r.Name, r.SSH().Target())
+	for _, c := range cmds {
+		dir := remoteDir(r, c)
+		env := append(append(append([]string(nil), c.Spec.Env...), rtx.Params.Environ()...), rtx.InputEnviron()...)
+		for _, line := range c.Spec.Cmds {
+			line, err := rtx.Params.Expand(line)
+			if err != nil {
+				return err
+			}
+			var stdin io.Reader
+			if rtx.Input != nil {
+				stdin = bytes.NewReader(rtx.Input.Data)
+			}
+			log.Debug("%s $ %s", r.Name, line)
+			onLine := func(text string) { log.Info("%s | %s | %s", r.Name, c.TaskName, text) }
+			if err := client.Run(ctx, remote.ShellCommand(dir, env, line), stdin, onLine); err != nil {
+				return fmt.Errorf("%s: command %q: %w", c.TaskName, line, err)
+			}
+		}
+	}
+	return nil
+}
+
+func planRemote(rtx *app.RuntimeContext, r app.Remote, cmds []Command) ([]runner.Action, error) {
+	var actions []runner.Action
+	for _, c := range cmds {
+		detail := "ssh " + r.SSH().Target()
+		if dir := remoteDir(r, c); dir != "" {
+			detail += " in " + dir
+		}
+		for _, line := range c.Spec.Cmds {
+			line, err := rtx.Params.Expand(line)
+			if err != nil {
+				return nil, err
+			}
+			actions = append(actions, runner.Action{Kind: runner.ActionRun, Target: line, Detail: detail})
+		}
+	}
+	return actions, nil
+}
+
+// remoteDir resolves the task's dir against the remote's dir; remote paths
+// are always slash-separated.
+func remoteDir(r app.Remote, c Command) string {
+	dir := c.Spec.RemoteDir()
+	switch {
+	case dir == "":
+		return r.Dir
+	case path.IsAbs(dir) || r.Dir == "":
+		return dir
+	}
+	return path.Join(r.Dir, dir)
+}
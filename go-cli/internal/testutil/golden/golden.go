@@ -0,0 +1,115 @@
+// Package golden compares command output with golden files under
+// testdata/. Output is normalized first, so the files hold what a reviewer
+// cares about: ANSI colors are stripped, and timestamps and run IDs become
+// placeholders. Paths that differ per machine are replaced through Path.
+//
+// Run the tests with -update to rewrite the golden files from the actual
+// output, then review the diff:
+//
+//	go test ./cmd ./internal/app -update
+package golden
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/textdiff"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files with the actual output")
+
+// Placeholders written in place of varying values.
+const (
+	Time  = "<TIME>"
+	RunID = "<RUN-ID>"
+)
+
+var (
+	ansi          = regexp.MustCompile("\x1b\\[[0-9;?]*[A-Za-z]")
+	timestamps    = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:?\d{2})?`)
+	runIDs        = regexp.MustCompile(`\b\d{8}T\d{6}Z-[0-9a-f]{6}\b`)
+	trailingSpace = regexp.MustCompile(`[ \t]+\n`)
+)
+
+// Option adjusts normalization.
+type Option func(*normalizer)
+
+type normalizer struct {
+	paths []pathReplacement
+}
+
+type pathReplacement struct {
+	path, name string
+}
+
+// Path replaces every occurrence of path, with either slash direction,
+// by name, e.g. Path(tc.Root, "$ROOT"), and turns backslashes in the rest
+// of such a path into slashes. Longer paths are replaced first, so nested
+// directories can have names of their own.
+func Path(path, name string) Option {
+	return func(n *normalizer) {
+		n.paths = append(n.paths, pathReplacement{path, name})
+	}
+}
+
+// Normalize strips colors and replaces timestamps, run IDs, and what opts
+// select with placeholders. Line endings become \n and
+// trailing blanks are dropped.
+func Normalize(s string, opts ...Option) string {
+	var n normalizer
+	for _, opt := range opts {
+		opt(&n)
+	}
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = ansi.ReplaceAllString(s, "")
+	// Longest first, so a path is not half-replaced by its parent's name.
+	slices.SortStableFunc(n.paths, func(a, b pathReplacement) int {
+		return len(b.path) - len(a.path)
+	})
+	for _, p := range n.paths {
+		if p.path == "" {
+			continue
+		}
+		s = strings.ReplaceAll(s, p.path, p.name)
+		s = strings.ReplaceAll(s, filepath.ToSlash(p.path), p.name)
+		// Escaped backslashes, as in JSON on Windows.
+		s = strings.ReplaceAll(s, strings.ReplaceAll(p.path, `\`, `\\`), p.name)
+		// The rest of a replaced path uses slashes on every platform.
+		rest := regexp.MustCompile(regexp.QuoteMeta(p.name) + `[^\s"',)]*`)
+		s = rest.ReplaceAllStringFunc(s, func(path string) string {
+			return strings.ReplaceAll(strings.ReplaceAll(path, `\\`, "/"), `\`, "/")
+		})
+	}
+	s = runIDs.ReplaceAllString(s, RunID)
+	s = timestamps.ReplaceAllString(s, Time)
+	return trailingSpace.ReplaceAllString(s, "\n")
+}
+
+// Assert compares the normalized got with testdata/NAME.golden and fails t
+// with a diff when they differ. With -update it writes the file instead.
+func Assert(t testing.TB, name, got string, opts ...Option) {
+	t.Helper()
+	got = Normalize(got, opts...)
+	path := filepath.Join("testdata", filepath.FromSlash(name)+".golden")
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("golden: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatalf("golden: %v", err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("golden: %v (run the test with -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("output differs from %s (run the test with -update to accept it):\n%s", path, textdiff.Unified(path, "actual", string(want), got, 3))
+	}
+}
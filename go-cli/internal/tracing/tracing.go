@@ -0,0 +1,182 @@
+// Package tracing sets up OpenTelemetry traces for the CLI: an OTLP
+// exporter when an endpoint is configured, and W3C trace context carried
+// through the TRACEPARENT and TRACESTATE environment variables, so a run
+// started by a traced parent, and the subprocesses it spawns, join the
+// same trace.
+//
+// Without an endpoint the global no-op tracer stays in place; spans cost
+// next to nothing but still carry an inherited trace context to children.
+package tracing
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/credentials"
+)
+
+// Export protocols accepted by Config.Protocol.
+const (
+	ProtocolHTTP = "http/protobuf"
+	ProtocolGRPC = "grpc"
+)
+
+// instrumentation names the tracer the application's spans come from.
+const instrumentation = "gitlab.cc-asp.fraunhofer.de/templates/go-cli"
+
+// Config selects where spans go.
+type Config struct {
+	// Endpoint is the OTLP collector URL, e.g. http://localhost:4318 for
+	// HTTP or http://localhost:4317 for gRPC. Empty disables exporting.
+	// An http:// URL sends without TLS. Over HTTP, spans go to /v1/traces
+	// unless the URL has a path of its own.
+	Endpoint string
+	// Protocol is ProtocolHTTP (the default) or ProtocolGRPC.
+	Protocol string
+	// Headers are sent with every export, e.g. for authentication.
+	Headers map[string]string
+	// SampleRatio is the share of new traces recorded, 0 to 1. Spans whose
+	// parent was sampled are always recorded.
+	SampleRatio float64
+	// Proxy and TLS, when set, replace the exporter's proxy selection and
+	// TLS settings, so exports follow the [http] config. gRPC exports use
+	// TLS only; they take their proxy from the environment.
+	Proxy func(*http.Request) (*url.URL, error)
+	TLS   *tls.Config
+	// ServiceName and ServiceVersion describe the process in the trace.
+	ServiceName    string
+	ServiceVersion string
+}
+
+// Setup installs the W3C propagator and, when cfg has an endpoint, an
+// exporting tracer provider. The returned function flushes and stops
+// the provider.
+func Setup(ctx context.Context, cfg Config) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	if cfg.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", cfg.ServiceName),
+			attribute.String("service.version", cfg.ServiceVersion),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+func newExporter(ctx context.Context, cfg Config) (*otlptrace.Exporter, error) {
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid traces endpoint %q (expected an http:// or https:// URL)", cfg.Endpoint)
+	}
+	switch cfg.Protocol {
+	case "", ProtocolHTTP:
+		if u.Path == "" || u.Path == "/" {
+			u.Path = "/v1/traces"
+		}
+		opts := []otlptracehttp.Option{otlptracehttp.WithEndpointURL(u.String()), otlptracehttp.WithHeaders(cfg.Headers)}
+		if cfg.Proxy != nil {
+			opts = append(opts, otlptracehttp.WithProxy(cfg.Proxy))
+		}
+		if cfg.TLS != nil && u.Scheme == "https" {
+			opts = append(opts, otlptracehttp.WithTLSClientConfig(cfg.TLS))
+		}
+		return otlptracehttp.New(ctx, opts...)
+	case ProtocolGRPC:
+		opts := []otlptracegrpc.Option{otlptracegrpc.WithEndpointURL(cfg.Endpoint), otlptracegrpc.WithHeaders(cfg.Headers)}
+		if cfg.TLS != nil && u.Scheme == "https" {
+			opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(cfg.TLS)))
+		}
+		return otlptracegrpc.New(ctx, opts...)
+	}
+	return nil, fmt.Errorf("invalid traces protocol %q (expected %s or %s)", cfg.Protocol, ProtocolHTTP, ProtocolGRPC)
+}
+
+// Tracer returns the tracer for the application's spans.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentation)
+}
+
+// Start begins a span named name under ctx's span. A zero start time means
+// now.
+func Start(ctx context.Context, name string, start time.Time, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	opts := []trace.SpanStartOption{trace.WithAttributes(attrs...)}
+	if !start.IsZero() {
+		opts = append(opts, trace.WithTimestamp(start))
+	}
+	return Tracer().Start(ctx, name, opts...)
+}
+
+// End records err on span, if any, and ends it.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// envCarrier maps the propagation fields to environment variables:
+// traceparent to TRACEPARENT, and so on.
+type envCarrier map[string]string
+
+func (c envCarrier) Get(key string) string { return c[strings.ToUpper(key)] }
+func (c envCarrier) Set(key, value string) { c[strings.ToUpper(key)] = value }
+func (c envCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, strings.ToLower(k))
+	}
+	return keys
+}
+
+// FromEnviron returns ctx joined to the trace context this process was
+// started with, if TRACEPARENT is set.
+func FromEnviron(ctx context.Context) context.Context {
+	carrier := envCarrier{}
+	for _, name := range otel.GetTextMapPropagator().Fields() {
+		if value, ok := os.LookupEnv(strings.ToUpper(name)); ok {
+			carrier[strings.ToUpper(name)] = value
+		}
+	}
+	return otel.GetTextMapPropagator().Extract(ctx, carrier)
+}
+
+// Environ returns the TRACEPARENT (and TRACESTATE) entries that carry
+// ctx's trace context to a subprocess; it is empty outside a trace.
+func Environ(ctx context.Context) []string {
+	carrier := envCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	env := make([]string, 0, len(carrier))
+	for name, value := range carrier {
+		env = append(env, name+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
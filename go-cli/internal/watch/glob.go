@@ -0,0 +1,53 @@
+package watch
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Match reports whether name matches pattern. Patterns use forward slashes
+// and path.Match syntax per segment, plus "**" for any number of segments.
+func Match(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(filepath.ToSlash(name), "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(rest, name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], name[0]); err != nil || !ok {
+			return false
+		}
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+	return len(name) == 0
+}
+
+// baseDir returns the longest leading directory of pattern without glob
+// metacharacters, i.e. the directory that must be watched recursively.
+func baseDir(pattern string) string {
+	segments := strings.Split(pattern, "/")
+	var base []string
+	for _, seg := range segments[:len(segments)-1] {
+		if strings.ContainsAny(seg, "*?[") {
+			break
+		}
+		base = append(base, seg)
+	}
+	if len(base) == 0 {
+		return "."
+	}
+	return filepath.FromSlash(strings.Join(base, "/"))
+}
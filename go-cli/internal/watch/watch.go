@@ -0,0 +1,126 @@
+// Package watch monitors files matching glob patterns and reports debounced
+// batches of changes.
+package watch
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Options configure a watch loop.
+type Options struct {
+	// Patterns select files to watch, relative to the working directory.
+	Patterns []string
+	// Ignore excludes matching files and directories.
+	Ignore []string
+	// Debounce is the quiet period that must pass before a batch is reported.
+	Debounce time.Duration
+}
+
+// Watch calls onChange with each debounced batch of changed paths until ctx
+// is canceled. onChange runs on the calling goroutine; changes arriving while
+// it executes are collected into the next batch.
+func Watch(ctx context.Context, opts Options, onChange func(changed []string)) error {
+	if len(opts.Patterns) == 0 {
+		return errors.New("no watch patterns configured")
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close()
+
+	for _, pattern := range opts.Patterns {
+		if err := addTree(watcher, baseDir(pattern), opts.Ignore); err != nil {
+			return err
+		}
+	}
+
+	pending := map[string]bool{}
+	timer := time.NewTimer(opts.Debounce)
+	if !timer.Stop() {
+		<-timer.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			name := filepath.Clean(event.Name)
+			if event.Has(fsnotify.Create) {
+				if info, err := os.Stat(name); err == nil && info.IsDir() {
+					// New directories need their own watch; errors here only
+					// mean we miss changes inside a directory that vanished.
+					_ = addTree(watcher, name, opts.Ignore)
+				}
+			}
+			if event.Op == fsnotify.Chmod || ignored(name, opts.Ignore) || !matchesAny(name, opts.Patterns) {
+				continue
+			}
+			pending[name] = true
+			timer.Reset(opts.Debounce)
+		case <-timer.C:
+			changed := make([]string, 0, len(pending))
+			for name := range pending {
+				changed = append(changed, name)
+			}
+			sort.Strings(changed)
+			clear(pending)
+			onChange(changed)
+		}
+	}
+}
+
+func addTree(watcher *fsnotify.Watcher, root string, ignore []string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && (ignored(path, ignore) || strings.HasPrefix(d.Name(), ".")) {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+func matchesAny(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if Match(pattern, name) {
+			return true
+		}
+	}
+	return false
+}
+
+func ignored(name string, ignore []string) bool {
+	for _, pattern := range ignore {
+		if Match(pattern, name) || Match(pattern+"/**", name) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,13 +1,22 @@
 package main
 
 import (
+	_ "embed"
+	"fmt"
 	"os"
 
 	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/cmd"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/releasenotes"
 )
 
+//go:embed CHANGELOG.md
+var changelog string
+
 func main() {
+	releasenotes.Markdown = changelog
 	if err := cmd.Execute(); err != nil {
-		os.Exit(1)
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(app.ExitCode(err))
 	}
 }
@@ -0,0 +1,144 @@
+// Package clifw exposes the template's application framework to other
+// modules: XDG path discovery, the layered TOML config with environment and
+// --set overrides, logging, and the runtime context handed to command
+// handlers. A program builds one App and derives a RuntimeContext from it
+// per invocation:
+//
+//	cli := clifw.New("myapp", clifw.WithFlags(flags))
+//	ctx, err := cli.Context(context.Background())
+//	if err != nil {
+//		os.Exit(clifw.ExitCode(err))
+//	}
+//	ctx.Logger.Info("config loaded from %s", ctx.Paths.ConfigFile)
+//
+// The types are aliases of the ones in internal/app, so values move freely
+// between this package and the template's own commands.
+package clifw
+
+import (
+	"context"
+
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/app"
+	"gitlab.cc-asp.fraunhofer.de/templates/go-cli/internal/clock"
+)
+
+// RuntimeContext holds the flags, paths, config, logger, and renderer of
+// one invocation.
+type RuntimeContext = app.RuntimeContext
+
+// CommonFlags are the global flags that shape a runtime context.
+type CommonFlags = app.CommonFlags
+
+// Config is the decoded config file, with environment and --set overrides
+// applied.
+type Config = app.AppConfig
+
+// Paths are the resolved config file and data, state, and cache
+// directories.
+type Paths = app.AppPaths
+
+// Logger writes leveled log lines to stderr and the optional log file.
+type Logger = app.Logger
+
+// Clock tells the time; tests pass a fake one through WithClock.
+type Clock = clock.Clock
+
+// App is a command-line program built on the framework.
+type App struct {
+	name       string
+	flags      CommonFlags
+	configFile string
+	clock      Clock
+}
+
+// Option configures an App.
+type Option func(*App)
+
+// WithFlags sets the global flags every runtime context starts from, as
+// parsed by the program's own flag handling.
+func WithFlags(flags CommonFlags) Option {
+	return func(a *App) {
+		a.flags = flags
+	}
+}
+
+// WithConfigFile loads the config from path instead of the XDG location,
+// over the ConfigPath of WithFlags.
+func WithConfigFile(path string) Option {
+	return func(a *App) {
+		a.configFile = path
+	}
+}
+
+// WithClock replaces the wall clock of runtime contexts, for tests.
+func WithClock(c Clock) Option {
+	return func(a *App) {
+		a.clock = c
+	}
+}
+
+// New returns the App called name, which sets the directory names under
+// the XDG base directories and the environment prefix (NAME_*). The name is
+// process-wide, so a program builds one App. New panics on an empty name.
+func New(name string, opts ...Option) *App {
+	if name == "" {
+		panic("clifw: empty application name")
+	}
+	a := &App{name: name}
+	for _, opt := range opts {
+		opt(a)
+	}
+	if a.configFile != "" {
+		a.flags.ConfigPath = a.configFile
+	}
+	app.SetAppName(name)
+	return a
+}
+
+// Name returns the application name.
+func (a *App) Name() string {
+	return a.name
+}
+
+// EnvPrefix returns the prefix of the environment variables that override
+// config keys, e.g. MYAPP for MYAPP_LOGGING__LEVEL.
+func (a *App) EnvPrefix() string {
+	return app.EnvPrefix()
+}
+
+// Paths resolves the config file and data, state, and cache directories
+// without loading the config, so path overrides in it are not applied.
+func (a *App) Paths() (Paths, error) {
+	return app.DiscoverPaths(a.name, a.flags.ConfigPath)
+}
+
+// Context loads the config, creating a default one on first run, sets up
+// logging, and returns the runtime context of one invocation bound to
+// parent. Close its Logger when done.
+func (a *App) Context(parent context.Context) (*RuntimeContext, error) {
+	rtx, err := app.NewRuntimeContext(parent, a.flags)
+	if err != nil {
+		return nil, err
+	}
+	if a.clock != nil {
+		rtx.Clock = a.clock
+	}
+	return rtx, nil
+}
+
+// FromContext returns the runtime context stored in ctx, as handlers
+// receive it through a context.Context.
+func FromContext(ctx context.Context) (*RuntimeContext, bool) {
+	return app.FromContext(ctx)
+}
+
+// UsageError marks err as caused by invalid flags or arguments, so
+// ExitCode maps it to the usage exit status.
+func UsageError(err error) error {
+	return app.UsageError(err)
+}
+
+// ExitCode maps err to the process exit status.
+func ExitCode(err error) int {
+	return app.ExitCode(err)
+}
@@ -59,7 +59,9 @@ $replacements = @{
     'gitlab.cc-asp.fraunhofer.de/templates/go-cli' = $Module
 }
 
-Get-ChildItem -Path $Destination -Recurse -File | ForEach-Object {
+# Generated protobuf code embeds a length-prefixed descriptor; rewriting
+# strings inside it corrupts it. It is regenerated below instead.
+Get-ChildItem -Path $Destination -Recurse -File | Where-Object { $_.Name -notlike '*.pb.go' } | ForEach-Object {
     try {
         $content = Get-Content $_.FullName -Raw -ErrorAction Stop
     } catch {
@@ -74,6 +76,17 @@ Get-ChildItem -Path $Destination -Recurse -File | ForEach-Object {
     }
 }
 
+if ((Get-Command protoc -ErrorAction SilentlyContinue) -and (Get-Command protoc-gen-go -ErrorAction SilentlyContinue) -and (Get-Command protoc-gen-go-grpc -ErrorAction SilentlyContinue)) {
+    Push-Location $Destination
+    try {
+        go generate ./api/...
+    } finally {
+        Pop-Location
+    }
+} else {
+    Write-Warning "protoc, protoc-gen-go, or protoc-gen-go-grpc not found; the stubs in api/ still name the template's module until you run go generate ./api/..."
+}
+
 Write-Output "Created CLI project at $Destination"
 Write-Output "Next steps:"
 Write-Output "  1. Set-Location $Destination"
@@ -119,6 +119,10 @@ replacements = {
 for path in dest.rglob('*'):
     if not path.is_file():
         continue
+    # Generated protobuf code embeds a length-prefixed descriptor; rewriting
+    # strings inside it corrupts it. It is regenerated below instead.
+    if path.name.endswith('.pb.go'):
+        continue
     try:
         text = path.read_text()
     except UnicodeDecodeError:
@@ -131,6 +135,12 @@ for path in dest.rglob('*'):
         path.write_text(text)
 PY
 
+if command -v protoc >/dev/null && command -v protoc-gen-go >/dev/null && command -v protoc-gen-go-grpc >/dev/null; then
+  (cd "$DEST" && go generate ./api/...)
+else
+  echo "note: protoc, protoc-gen-go, or protoc-gen-go-grpc not found; the stubs in api/ still name the template's module until you run go generate ./api/..." >&2
+fi
+
 echo "Created CLI project at $DEST"
 echo "Next steps:"
 echo "  1. cd $DEST"